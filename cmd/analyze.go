@@ -1,13 +1,23 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"instrumentation-score/internal/collectors"
+	"instrumentation-score/internal/kube"
+	"instrumentation-score/internal/loaders"
+	"instrumentation-score/internal/runconfig"
 	"instrumentation-score/internal/storage"
+	"instrumentation-score/internal/usage"
 
 	"github.com/spf13/cobra"
 )
@@ -15,27 +25,76 @@ import (
 var (
 	analyzeOutputDir                   string
 	analyzeQueryFilters                string
+	analyzeSelector                    string
 	analyzeRetryCount                  int
 	analyzeS3Upload                    bool
 	analyzeS3Bucket                    string
 	analyzeS3Prefix                    string
 	analyzeS3Region                    string
+	analyzeAzureUpload                 bool
+	analyzeAzureAccount                string
+	analyzeAzureContainer              string
+	analyzeAzurePrefix                 string
 	analyzeCollectLabelCardinality     bool
 	analyzeLabelCardinalityConcurrency int
+	analyzeLabelValueSamples           int
 	analyzeMetricsConcurrency          int
 	analyzeJobsConcurrency             int
+	analyzeMaxOpenFiles                int
+	analyzeSlowMetricsTop              int
+	analyzeMaxCardinality              int64
+	analyzeAutoTune                    bool
+	analyzeAutoTuneMax                 int
+	analyzeTargetsFile                 string
+	analyzeKubeDiscovery               bool
+	analyzeKubeNamespaces              []string
+	analyzeKubeSources                 []string
+	analyzeKubeJobLabel                string
+	analyzeScrapeHealth                bool
+	analyzeScrapeHealthWindow          string
+	analyzeSeriesChurn                 bool
+	analyzeSeriesChurnWindow           time.Duration
+	analyzeMetricUsage                 bool
+	analyzeGrafanaURL                  string
+	analyzeGrafanaToken                string
+	analyzeUsageFiles                  []string
+	analyzeQueryLogs                   []string
+	analyzePreviousRun                 string
+	analyzeTSDBSnapshot                bool
+	analyzeTSDBSmallSeries             int64
+	analyzeSchedule                    *collectors.Schedule // From --spread and --blackout, nil without them
+	analyzeSpread                      time.Duration
+	analyzeBlackouts                   []string
+	analyzeSettings                    *runconfig.Snapshot // Flags and environment, captured when the command runs
+	analyzeTimeout                     time.Duration
+	analyzeOTLPFiles                   []string
+	analyzeOTLPListen                  string
+	analyzeOTLPDuration                time.Duration
+	analyzeOTLPJobAttributes           []string
 )
 
+// analyzeEnv are the environment variables analyze reads
+var analyzeEnv = []string{
+	"url", "login", "CONCURRENT_METRICS", "CONCURRENT_JOBS", "CONCURRENT_LABEL_CARDINALITY", "CONCURRENT_SCRAPES",
+	"GRAFANA_TOKEN", "KUBE_API_SERVER", "KUBE_TOKEN", "KUBE_CA_FILE", "KUBE_NAMESPACE",
+	"S3_BUCKET", "S3_PREFIX", "AWS_REGION",
+	storage.AzureAccountEnv, storage.AzureContainerEnv, azurePrefixEnv,
+}
+
 var analyzeCmd = &cobra.Command{
 	Use:   "analyze",
 	Short: "Analyze Prometheus metrics and generate per-job reports",
 	Long: `Analyze Prometheus metrics and generate comprehensive per-job reports.
 
 This command fetches metrics from Prometheus, analyzes them by job, and generates:
-- Per-job metric files with format: JOB|METRIC_NAME|LABELS|CARDINALITY
+- Per-job metric files with format: JOB|METRIC_NAME|LABELS|CARDINALITY|LABEL_CARDINALITY|TYPE|LABEL_VALUES
+  preceded by a "#format=v2" version header ('|', ',' and ':' inside values
+  are escaped with a backslash; files without the header are read as v1)
 - Error report for any failures during analysis
 
 The reports are written to a timestamped directory in the output folder.
+With --selector only the matching series are analyzed, and the selector is
+added to the directory name, e.g. job_metrics_20251102_160000_namespace-payments.
 
 Examples:
   # For authenticated Prometheus (e.g., Grafana Cloud)
@@ -59,8 +118,14 @@ Examples:
   # Multiple filters
   instrumentation-score analyze \
     --output-dir ./reports \
-    --additional-query-filters 'cluster=~"prod-1-27-a1|prod-1-27-a1-eu-central-1",region="us-east-1"'`,
+    --additional-query-filters 'cluster=~"prod-1-27-a1|prod-1-27-a1-eu-central-1",region="us-east-1"'
+
+  # Audit a single Helm release during its rollout
+  instrumentation-score analyze \
+    --output-dir ./reports \
+    --selector 'namespace="payments",release="checkout"'`,
 	Run: func(cmd *cobra.Command, args []string) {
+		analyzeSettings = runconfig.Capture("analyze", cmd.Flags(), analyzeEnv)
 		runAnalyze()
 	},
 }
@@ -68,77 +133,211 @@ Examples:
 func init() {
 	analyzeCmd.Flags().StringVarP(&analyzeOutputDir, "output-dir", "o", ".", "Output directory for report files")
 	analyzeCmd.Flags().StringVar(&analyzeQueryFilters, "additional-query-filters", "", "PromQL label filters (e.g., 'cluster=~\"prod.*\",environment=\"production\"')")
+	analyzeCmd.Flags().StringVar(&analyzeSelector, "selector", "", "Only analyze series matching these PromQL label matchers (e.g., 'namespace=\"payments\"'), also in direct scrape mode; names the output and is shown in reports")
 	analyzeCmd.Flags().IntVar(&analyzeRetryCount, "retry-failures-count", 2, "Number of retry attempts for failed requests due to transient network issues (e.g., connection refused, timeouts)")
 	analyzeCmd.Flags().BoolVar(&analyzeS3Upload, "s3-upload", false, "Upload generated reports to S3")
 	analyzeCmd.Flags().StringVar(&analyzeS3Bucket, "s3-bucket", "", "S3 bucket name (or use S3_BUCKET env var)")
 	analyzeCmd.Flags().StringVar(&analyzeS3Prefix, "s3-prefix", "", "S3 key prefix (or use S3_PREFIX env var)")
 	analyzeCmd.Flags().StringVar(&analyzeS3Region, "s3-region", "eu-west-1", "AWS region (or use AWS_REGION env var)")
+	analyzeCmd.Flags().BoolVar(&analyzeAzureUpload, "azure-upload", false, "Upload generated reports to Azure Blob Storage (credentials: "+storage.AzureKeyEnv+" or "+storage.AzureSASTokenEnv+")")
+	analyzeCmd.Flags().StringVar(&analyzeAzureAccount, "azure-account", "", "Azure storage account (or use "+storage.AzureAccountEnv+" env var)")
+	analyzeCmd.Flags().StringVar(&analyzeAzureContainer, "azure-container", "", "Azure Blob container (or use "+storage.AzureContainerEnv+" env var)")
+	analyzeCmd.Flags().StringVar(&analyzeAzurePrefix, "azure-prefix", "", "Blob name prefix (or use "+azurePrefixEnv+" env var)")
 	analyzeCmd.Flags().BoolVar(&analyzeCollectLabelCardinality, "collect-label-cardinality", false, "Collect per-label cardinality data using Mimir cardinality API (more accurate but slower)")
+	analyzeCmd.Flags().IntVar(&analyzeLabelValueSamples, "label-value-samples", 0, "Record up to this many values per label (the most common first, via the cardinality API) for label_values validators (0 disables)")
 	analyzeCmd.Flags().IntVar(&analyzeLabelCardinalityConcurrency, "label-cardinality-concurrency", 0, "Number of concurrent label cardinality API requests (default: 50, or CONCURRENT_LABEL_CARDINALITY env var)")
 	analyzeCmd.Flags().IntVar(&analyzeMetricsConcurrency, "metrics-concurrency", 0, "Number of concurrent metrics to process (default: 5, or CONCURRENT_METRICS env var)")
 	analyzeCmd.Flags().IntVar(&analyzeJobsConcurrency, "jobs-concurrency", 0, "Number of concurrent job queries per metric (default: 3, or CONCURRENT_JOBS env var)")
+	analyzeCmd.Flags().BoolVar(&analyzeAutoTune, "auto-tune-concurrency", false, "Adjust concurrency during the run from observed latency and 429 responses (AIMD) instead of static limits")
+	analyzeCmd.Flags().IntVar(&analyzeAutoTuneMax, "auto-tune-max-concurrency", collectors.DefaultAutoTuneConfig().Max, "Upper bound for in-flight requests when --auto-tune-concurrency is set")
+	analyzeCmd.Flags().StringVar(&analyzeTargetsFile, "targets", "", "Scrape the /metrics endpoints listed in this YAML file directly instead of querying Prometheus")
+	analyzeCmd.Flags().BoolVar(&analyzeKubeDiscovery, "kube-discovery", false, "Discover scrape targets from Kubernetes pod annotations and ServiceMonitors and scrape them directly")
+	analyzeCmd.Flags().StringSliceVar(&analyzeKubeNamespaces, "kube-namespaces", nil, "Namespaces to discover targets in (default: all)")
+	analyzeCmd.Flags().StringSliceVar(&analyzeKubeSources, "kube-sources", []string{kube.SourcePods, kube.SourceServiceMonitors}, "Discovery sources: pods, servicemonitors")
+	analyzeCmd.Flags().StringVar(&analyzeKubeJobLabel, "kube-job-label", "", "Pod label used as the job name for annotated pods (default: app.kubernetes.io/name, then app)")
+	analyzeCmd.Flags().BoolVar(&analyzeScrapeHealth, "scrape-health", true, "Collect per-target up/scrape_* health into "+loaders.ScrapeHealthFileName+" for the scrape_health data source")
+	analyzeCmd.Flags().StringVar(&analyzeScrapeHealthWindow, "scrape-health-window", collectors.DefaultScrapeHealthWindow, "Range scrape health is aggregated over (PromQL duration)")
+	analyzeCmd.Flags().BoolVar(&analyzeSeriesChurn, "series-churn", false, "Count new series per job and metric into "+loaders.SeriesChurnFileName+" for the series_churn data source (queries every series over the window)")
+	analyzeCmd.Flags().DurationVar(&analyzeSeriesChurnWindow, "series-churn-window", collectors.DefaultSeriesChurnWindow, "Window series churn is measured over")
+	analyzeCmd.Flags().BoolVar(&analyzeMetricUsage, "metric-usage", false, "Record which metrics Prometheus rules and dashboards use into "+loaders.MetricUsageFileName+" (implied by --grafana-url, --usage-files and --query-log)")
+	analyzeCmd.Flags().StringVar(&analyzeGrafanaURL, "grafana-url", "", "Grafana URL to read dashboards from for --metric-usage")
+	analyzeCmd.Flags().StringVar(&analyzeGrafanaToken, "grafana-token", "", "Grafana service account token (or use GRAFANA_TOKEN env var)")
+	analyzeCmd.Flags().StringSliceVar(&analyzeUsageFiles, "usage-files", nil, "Glob patterns of dashboard JSON and Prometheus rule YAML files to scan for --metric-usage")
+	analyzeCmd.Flags().StringSliceVar(&analyzeQueryLogs, "query-log", nil, "Glob patterns of Prometheus/Mimir query logs or metric,count usage exports to count metric queries from (implies --metric-usage)")
+	analyzeCmd.Flags().Int64Var(&analyzeMaxCardinality, "max-cardinality-per-metric", 0, "Only count the series of a metric of a job above this many series, skipping its label and label cardinality queries (0 disables)")
+	analyzeCmd.Flags().IntVar(&analyzeSlowMetricsTop, "slow-metrics-top", 50, "Number of slowest metrics to list in the slow metrics report (0 lists all)")
+	analyzeCmd.Flags().StringVar(&analyzePreviousRun, "previous-run", "", "Job metrics directory of a previous run: metrics of jobs whose series count is unchanged are copied from it instead of re-queried")
+	analyzeCmd.Flags().DurationVar(&analyzeSpread, "spread", 0, "Spread the start of metric collections evenly over this window (e.g. 2h) to keep the query load on a shared Prometheus low")
+	analyzeCmd.Flags().StringSliceVar(&analyzeBlackouts, "blackout", nil, "Local-time windows in which no metric collection starts, e.g. 'Mon-Fri 08:00-18:00' (repeatable)")
+	analyzeCmd.Flags().BoolVar(&analyzeTSDBSnapshot, "tsdb-snapshot", false, "Rank metrics by series count from the TSDB status API (/api/v1/status/tsdb) and collect the largest first")
+	analyzeCmd.Flags().Int64Var(&analyzeTSDBSmallSeries, "tsdb-small-series", 0, "Collect metrics with at most this many series in the TSDB snapshot with one series query instead of per-job queries (implies --tsdb-snapshot, 0 disables)")
+	analyzeCmd.Flags().StringSliceVar(&analyzeOTLPFiles, "otlp-files", nil, "Glob patterns of OTLP JSON files, e.g. written by the OpenTelemetry Collector file exporter, to analyze instead of querying Prometheus")
+	analyzeCmd.Flags().StringVar(&analyzeOTLPListen, "otlp-listen", "", "Receive OTLP/HTTP metric exports (JSON encoding) on this address, e.g. :4318, and analyze them instead of querying Prometheus")
+	analyzeCmd.Flags().DurationVar(&analyzeOTLPDuration, "otlp-duration", 2*time.Minute, "How long --otlp-listen receives exports; cover at least one export interval of every sender")
+	analyzeCmd.Flags().StringSliceVar(&analyzeOTLPJobAttributes, "otlp-job-attributes", collectors.DefaultOTLPJobAttributes, "Resource attributes whose values, joined with '/', name the job of OTLP metrics")
+	analyzeCmd.Flags().DurationVar(&analyzeTimeout, "timeout", 0, "Stop the analysis with an error when it runs longer than this (e.g. 30m, 0 disables); Ctrl-C also stops it")
+	analyzeCmd.Flags().IntVar(&analyzeMaxOpenFiles, "max-open-files", collectors.DefaultMaxOpenJobFiles, "Maximum number of per-job files kept open while streaming results to disk")
 }
 
 func runAnalyze() {
-	client, err := collectors.NewPrometheusClientFromEnv()
+	selector, err := collectors.ParseSelector(analyzeSelector)
 	if err != nil {
 		fmt.Printf("ERROR: %v\n", err)
 		os.Exit(1)
 	}
 
+	// OTLP sources, like scrape targets, replace Prometheus as the source of series
+	otlp := len(analyzeOTLPFiles) > 0 || analyzeOTLPListen != ""
+	if otlp {
+		if analyzeTargetsFile != "" || analyzeKubeDiscovery {
+			fmt.Println("ERROR: --otlp-files and --otlp-listen cannot be used with --targets or --kube-discovery")
+			os.Exit(1)
+		}
+		if len(selector) > 0 {
+			fmt.Println("ERROR: --selector cannot be used with --otlp-files or --otlp-listen")
+			os.Exit(1)
+		}
+		if analyzeOTLPListen != "" && analyzeOTLPDuration <= 0 {
+			fmt.Println("ERROR: --otlp-duration must be positive")
+			os.Exit(1)
+		}
+	}
+
+	if analyzeSpread != 0 || len(analyzeBlackouts) > 0 {
+		if analyzeTargetsFile != "" || analyzeKubeDiscovery || otlp {
+			fmt.Println("ERROR: --spread and --blackout pace Prometheus queries and cannot be used with --targets, --kube-discovery or OTLP sources")
+			os.Exit(1)
+		}
+		blackouts, err := collectors.ParseWindows(analyzeBlackouts)
+		if err == nil {
+			analyzeSchedule = &collectors.Schedule{Spread: analyzeSpread, Blackouts: blackouts}
+			err = analyzeSchedule.Validate()
+		}
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if analyzeTSDBSnapshot || analyzeTSDBSmallSeries != 0 {
+		if analyzeTargetsFile != "" || analyzeKubeDiscovery || otlp {
+			fmt.Println("ERROR: --tsdb-snapshot and --tsdb-small-series rank Prometheus collections and cannot be used with --targets, --kube-discovery or OTLP sources")
+			os.Exit(1)
+		}
+		if analyzeTSDBSmallSeries < 0 {
+			fmt.Println("ERROR: --tsdb-small-series must not be negative")
+			os.Exit(1)
+		}
+	}
+
+	if analyzeTimeout < 0 {
+		fmt.Println("ERROR: --timeout must not be negative")
+		os.Exit(1)
+	}
+
+	var baseline *collectors.Baseline
+	if analyzePreviousRun != "" {
+		if analyzeTargetsFile != "" || analyzeKubeDiscovery || otlp {
+			fmt.Println("ERROR: --previous-run reuses Prometheus collections and cannot be used with --targets, --kube-discovery or OTLP sources")
+			os.Exit(1)
+		}
+		if baseline, err = collectors.LoadBaseline(analyzePreviousRun); err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Collecting differentially against %d metric-job records of %s\n", baseline.Records(), analyzePreviousRun)
+	}
+
+	// Ctrl-C and --timeout cancel the queries in flight instead of waiting for every one to finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if analyzeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, analyzeTimeout)
+		defer cancel()
+	}
+
+	// Direct-scrape and OTLP modes need no Prometheus connection
+	var client *collectors.PrometheusClient
+	var targets *collectors.TargetsConfig
+	switch {
+	case otlp:
+	case analyzeKubeDiscovery:
+		targets, err = discoverKubeTargets()
+	case analyzeTargetsFile != "":
+		targets, err = collectors.LoadTargetsConfig(analyzeTargetsFile)
+	default:
+		client, err = collectors.NewPrometheusClientFromEnv()
+	}
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	if targets != nil && len(selector) > 0 {
+		targets.Targets = selector.SelectTargets(targets.Targets)
+		if len(targets.Targets) == 0 {
+			fmt.Printf("ERROR: no scrape targets match selector %s\n", selector)
+			os.Exit(1)
+		}
+	}
+
 	if err := os.MkdirAll(analyzeOutputDir, 0700); err != nil {
 		fmt.Printf("ERROR: Failed to create output directory: %v\n", err)
 		os.Exit(1)
 	}
 
+	// The run ID names everything the run writes, so runs over different selectors don't mix
 	timestamp := time.Now().Format("20060102_150405")
+	if slug := selector.Slug(); slug != "" {
+		timestamp += "_" + slug
+	}
 	jobMetricsDir := filepath.Join(analyzeOutputDir, fmt.Sprintf("job_metrics_%s", timestamp))
 	if err := os.MkdirAll(jobMetricsDir, 0700); err != nil {
 		fmt.Printf("ERROR: Failed to create job metrics directory: %v\n", err)
 		os.Exit(1)
 	}
 
-	errorFile := filepath.Join(analyzeOutputDir, fmt.Sprintf("metrics_errors_%s.txt", timestamp))
-
-	fmt.Printf("Starting Prometheus metrics analysis...\n")
-	fmt.Printf("Prometheus URL: %s\n", client.BaseURL)
-	if analyzeQueryFilters != "" {
-		fmt.Printf("Query filters: %s\n", analyzeQueryFilters)
+	// Record the configuration next to the job files, so evaluate reports include it
+	analyzeSettings.AddFile(analyzeTargetsFile)
+	if err := analyzeSettings.Write(filepath.Join(jobMetricsDir, runconfig.FileName)); err != nil {
+		fmt.Printf("WARNING: %v\n", err)
 	}
-	fmt.Printf("Retry count: %d\n", analyzeRetryCount)
-	fmt.Printf("Collect label cardinality: %v\n", analyzeCollectLabelCardinality)
-	fmt.Printf("Output directory: %s\n", jobMetricsDir)
-	fmt.Println()
 
-	collector := collectors.NewCollectorWithClient(client, analyzeQueryFilters)
-	collector.SetRetryCount(analyzeRetryCount)
-	collector.SetCollectLabelCardinality(analyzeCollectLabelCardinality)
+	errorFile := filepath.Join(analyzeOutputDir, fmt.Sprintf("metrics_errors_%s.txt", timestamp))
+	slowMetricsFile := filepath.Join(analyzeOutputDir, fmt.Sprintf("slow_metrics_%s.txt", timestamp))
+	queriesFile := filepath.Join(analyzeOutputDir, fmt.Sprintf("queries_%s.txt", timestamp))
 
-	// Override concurrency settings if flags are provided (flags take precedence over env vars)
-	if analyzeLabelCardinalityConcurrency > 0 {
-		collector.SetLabelCardinalityConcurrency(analyzeLabelCardinalityConcurrency)
-	}
-	if analyzeMetricsConcurrency > 0 {
-		collector.SetMetricsConcurrency(analyzeMetricsConcurrency)
-	}
-	if analyzeJobsConcurrency > 0 {
-		collector.SetJobsConcurrency(analyzeJobsConcurrency)
+	var errors []collectors.ErrorRecord
+	if targets != nil {
+		errors = scrapeTargets(ctx, targets, jobMetricsDir)
+	} else if otlp {
+		errors = collectFromOTLP(ctx, jobMetricsDir)
+	} else {
+		errors, err = collectFromPrometheus(ctx, client, selector, baseline, jobMetricsDir, slowMetricsFile)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
 	}
-	allData, errors, err := collector.CollectMetrics()
-	if err != nil {
-		fmt.Printf("ERROR: %v\n", err)
-		os.Exit(1)
+
+	if analyzeMetricUsage || analyzeGrafanaURL != "" || len(analyzeUsageFiles) > 0 || len(analyzeQueryLogs) > 0 {
+		errors = append(errors, collectMetricUsage(ctx, client, jobMetricsDir)...)
 	}
 
-	fmt.Println("Writing per-job reports...")
-	if err := collectors.WritePerJobFiles(jobMetricsDir, allData); err != nil {
-		fmt.Printf("ERROR: Failed to write job files: %v\n", err)
-		os.Exit(1)
+	// Every query sent to Prometheus, so administrators can review the workload
+	if client != nil {
+		if err := collectors.WriteQuerySnapshot(queriesFile, client.Queries()); err != nil {
+			fmt.Printf("WARNING: Failed to write query snapshot: %v\n", err)
+		} else {
+			fmt.Printf("Query snapshot saved to %s\n", queriesFile)
+		}
 	}
-	fmt.Printf("Generated per-job files in %s/\n\n", jobMetricsDir)
 
 	if len(errors) > 0 {
 		fmt.Printf("WARNING: Encountered %d errors during processing\n", len(errors))
+		for _, total := range collectors.CountByCategory(errors) {
+			fmt.Printf("  %-13s %d\n", total.Category+":", total.Count)
+		}
 		if err := collectors.WriteErrorsToFile(errorFile, errors); err != nil {
 			fmt.Printf("WARNING: Failed to write error file: %v\n", err)
 		} else {
@@ -148,6 +347,31 @@ func runAnalyze() {
 		fmt.Println("No errors encountered!")
 	}
 
+	if analyzeS3Upload && analyzeAzureUpload {
+		fmt.Println("ERROR: --s3-upload and --azure-upload cannot be used together")
+		os.Exit(1)
+	}
+	if analyzeAzureUpload {
+		fmt.Println("\nUploading reports to Azure Blob Storage...")
+		store, err := azureStore(analyzeAzureAccount, analyzeAzureContainer, analyzeAzurePrefix)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		config := storage.AnalysisUploadConfig{
+			JobMetricsDir:   jobMetricsDir,
+			ErrorFile:       errorFile,
+			SlowMetricsFile: slowMetricsFile,
+			QueriesFile:     queriesFile,
+			Timestamp:       timestamp,
+			Store:           store,
+		}
+		if err := storage.UploadAnalysisResults(ctx, config); err != nil {
+			fmt.Printf("ERROR: Failed to upload to Azure Blob Storage: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if analyzeS3Upload {
 		fmt.Println("\nUploading reports to S3...")
 
@@ -170,15 +394,17 @@ func runAnalyze() {
 		}
 
 		config := storage.AnalysisUploadConfig{
-			Bucket:        bucket,
-			Prefix:        prefix,
-			Region:        region,
-			JobMetricsDir: jobMetricsDir,
-			ErrorFile:     errorFile,
-			Timestamp:     timestamp,
+			Bucket:          bucket,
+			Prefix:          prefix,
+			Region:          region,
+			JobMetricsDir:   jobMetricsDir,
+			ErrorFile:       errorFile,
+			SlowMetricsFile: slowMetricsFile,
+			QueriesFile:     queriesFile,
+			Timestamp:       timestamp,
 		}
 
-		if err := storage.UploadAnalysisResults(config); err != nil {
+		if err := storage.UploadAnalysisResults(ctx, config); err != nil {
 			fmt.Printf("ERROR: Failed to upload to S3: %v\n", err)
 			os.Exit(1)
 		}
@@ -186,3 +412,405 @@ func runAnalyze() {
 
 	fmt.Println("\nAnalysis complete!")
 }
+
+// collectFromPrometheus queries Prometheus for every metric matching selector and streams
+// per-job files to jobMetricsDir
+// Failed queries are returned as error records; the error is set when collection could not complete.
+// With a baseline, unchanged metrics of jobs are copied from the previous run.
+func collectFromPrometheus(ctx context.Context, client *collectors.PrometheusClient, selector collectors.Selector, baseline *collectors.Baseline, jobMetricsDir, slowMetricsFile string) ([]collectors.ErrorRecord, error) {
+	fmt.Printf("Starting Prometheus metrics analysis...\n")
+	fmt.Printf("Prometheus URL: %s\n", client.BaseURL)
+	if len(selector) > 0 {
+		fmt.Printf("Selector: %s\n", selector)
+	}
+	if analyzeQueryFilters != "" {
+		fmt.Printf("Query filters: %s\n", analyzeQueryFilters)
+	}
+	fmt.Printf("Retry count: %d\n", analyzeRetryCount)
+	fmt.Printf("Collect label cardinality: %v\n", analyzeCollectLabelCardinality)
+	if analyzeLabelValueSamples > 0 {
+		fmt.Printf("Label value samples: %d\n", analyzeLabelValueSamples)
+	}
+	if analyzeMaxCardinality > 0 {
+		fmt.Printf("Max cardinality per metric: %d\n", analyzeMaxCardinality)
+	}
+	fmt.Printf("Output directory: %s\n", jobMetricsDir)
+	fmt.Println()
+
+	collector := collectors.NewCollectorWithClient(client, collectors.CombineFilters(analyzeQueryFilters, selector.String()))
+	collector.SetRetryCount(analyzeRetryCount)
+	collector.SetCollectLabelCardinality(analyzeCollectLabelCardinality)
+	collector.SetLabelValueSamples(analyzeLabelValueSamples)
+	collector.SetMaxCardinalityPerMetric(analyzeMaxCardinality)
+
+	// Override concurrency settings if flags are provided (flags take precedence over env vars)
+	if analyzeLabelCardinalityConcurrency > 0 {
+		collector.SetLabelCardinalityConcurrency(analyzeLabelCardinalityConcurrency)
+	}
+	if analyzeMetricsConcurrency > 0 {
+		collector.SetMetricsConcurrency(analyzeMetricsConcurrency)
+	}
+	if analyzeJobsConcurrency > 0 {
+		collector.SetJobsConcurrency(analyzeJobsConcurrency)
+	}
+	if analyzeSchedule != nil {
+		collector.SetSchedule(*analyzeSchedule)
+	}
+	if baseline != nil {
+		collector.SetBaseline(baseline)
+	}
+	if analyzeTSDBSnapshot || analyzeTSDBSmallSeries > 0 {
+		collector.SetTSDBSnapshot(analyzeTSDBSmallSeries)
+	}
+	if analyzeAutoTune {
+		autoTune := collectors.DefaultAutoTuneConfig()
+		autoTune.Max = analyzeAutoTuneMax
+		collector.EnableAutoTune(autoTune)
+		fmt.Printf("Auto-tuning concurrency: start %d, max %d in-flight requests\n\n", autoTune.Initial, autoTune.Max)
+	}
+
+	// Records are streamed to per-job files as each metric is collected
+	jobWriter := collectors.NewJobFileWriter(jobMetricsDir, analyzeMaxOpenFiles)
+	_, errors, err := collector.CollectMetricsToWriter(ctx, jobWriter)
+	closeErr := jobWriter.Close()
+	if err != nil {
+		return errors, err
+	}
+	if closeErr != nil {
+		return errors, fmt.Errorf("failed to write job files: %w", closeErr)
+	}
+	fmt.Printf("Generated per-job files in %s/\n\n", jobMetricsDir)
+
+	gaps := collector.CollectionGaps()
+	if err := collectors.WriteCollectionGapsFile(filepath.Join(jobMetricsDir, loaders.CollectionGapsFileName), gaps); err != nil {
+		fmt.Printf("WARNING: Failed to write collection gap report: %v\n\n", err)
+	} else if len(gaps.Gaps) > 0 {
+		fmt.Printf("⚠️  %d metric(s) could not be collected; evaluate lowers the score confidence of the affected jobs\n\n", len(gaps.Gaps))
+	}
+
+	if baseline != nil {
+		reused, collected := baseline.Stats()
+		fmt.Printf("Reused %d unchanged metric-job record(s) from the previous run, collected %d\n\n", reused, collected)
+	}
+
+	if stats, ok := collector.AutoTuneStats(); ok {
+		fmt.Printf("Auto-tuned concurrency: final %d, lowest %d, %d backoff(s)\n\n", stats.Final, stats.Lowest, stats.Decreases)
+	}
+
+	if capped := collector.CappedMetrics(); len(capped) > 0 {
+		fmt.Printf("ℹ️  Collected only the series count of %d metric(s) above --max-cardinality-per-metric %d:\n", len(capped), analyzeMaxCardinality)
+		for i, metric := range capped {
+			if i == 10 {
+				fmt.Printf("  ... and %d more\n", len(capped)-i)
+				break
+			}
+			fmt.Printf("  %-60s %12d series\n", metric.MetricName+" ("+metric.Job+")", metric.Cardinality)
+		}
+		fmt.Println()
+	}
+
+	if analyzeScrapeHealth {
+		errors = append(errors, collectScrapeHealth(ctx, collector, jobMetricsDir)...)
+	}
+	if analyzeSeriesChurn {
+		errors = append(errors, collectSeriesChurn(ctx, collector, jobMetricsDir)...)
+	}
+
+	buildInfo, err := collector.CollectBuildInfo(ctx)
+	if err != nil {
+		fmt.Printf("WARNING: Failed to collect build info: %v\n\n", err)
+	} else {
+		writeBuildInfo(buildInfo, jobMetricsDir)
+	}
+
+	if err := collectors.WriteSlowMetricsReport(slowMetricsFile, collector.MetricTimings(), analyzeSlowMetricsTop); err != nil {
+		fmt.Printf("WARNING: Failed to write slow metrics report: %v\n", err)
+	} else {
+		fmt.Printf("Slow metrics report saved to %s\n", slowMetricsFile)
+		for _, timing := range collectors.SlowestMetrics(collector.MetricTimings(), 5) {
+			fmt.Printf("  %-60s %8.2fs\n", timing.MetricName, timing.Duration.Seconds())
+		}
+		fmt.Println()
+	}
+
+	return errors, nil
+}
+
+// collectScrapeHealth writes the scrape health report into jobMetricsDir
+// Failures only produce a warning: the report is an optional rule input.
+func collectScrapeHealth(ctx context.Context, collector *collectors.Collector, jobMetricsDir string) []collectors.ErrorRecord {
+	fmt.Printf("Collecting scrape health over %s...\n", analyzeScrapeHealthWindow)
+	health, errors, err := collector.CollectScrapeHealth(ctx, analyzeScrapeHealthWindow)
+	if err != nil {
+		fmt.Printf("WARNING: %v\n\n", err)
+		return nil
+	}
+
+	healthFile := filepath.Join(jobMetricsDir, loaders.ScrapeHealthFileName)
+	if err := collectors.WriteScrapeHealthFile(healthFile, health); err != nil {
+		fmt.Printf("WARNING: Failed to write scrape health report: %v\n\n", err)
+		return errors
+	}
+	fmt.Printf("Scrape health for %d targets saved to %s\n\n", len(health), healthFile)
+	return errors
+}
+
+// collectSeriesChurn writes the series churn report into jobMetricsDir
+// Failures only produce a warning: the report is an optional rule input.
+func collectSeriesChurn(ctx context.Context, collector *collectors.Collector, jobMetricsDir string) []collectors.ErrorRecord {
+	fmt.Printf("Collecting series churn over %s...\n", analyzeSeriesChurnWindow)
+	churn, errors, err := collector.CollectSeriesChurn(ctx, analyzeSeriesChurnWindow)
+	if err != nil {
+		fmt.Printf("WARNING: %v\n\n", err)
+		return nil
+	}
+
+	churnFile := filepath.Join(jobMetricsDir, loaders.SeriesChurnFileName)
+	if err := collectors.WriteSeriesChurnFile(churnFile, churn); err != nil {
+		fmt.Printf("WARNING: Failed to write series churn report: %v\n\n", err)
+		return errors
+	}
+	fmt.Printf("Series churn for %d metrics saved to %s\n\n", len(churn), churnFile)
+	return errors
+}
+
+// collectMetricUsage writes the metric usage report into jobMetricsDir
+// References come from Prometheus rules (Prometheus mode), Grafana dashboards and local files,
+// query counts from query logs.
+// A failing source only produces a warning and no report: a partial report would flag
+// metrics as unused that the missing source uses.
+func collectMetricUsage(ctx context.Context, client *collectors.PrometheusClient, jobMetricsDir string) []collectors.ErrorRecord {
+	if client == nil && analyzeGrafanaURL == "" && len(analyzeUsageFiles) == 0 && len(analyzeQueryLogs) == 0 {
+		fmt.Printf("WARNING: --metric-usage needs --grafana-url, --usage-files or --query-log in direct scrape mode\n\n")
+		return nil
+	}
+	fmt.Printf("Collecting metric usage from rules and dashboards...\n")
+	var expressions []usage.Expression
+	var errors []collectors.ErrorRecord
+
+	if client != nil {
+		rules, err := client.GetRuleExpressions(ctx)
+		if err != nil {
+			fmt.Printf("WARNING: Failed to read Prometheus rules, metric usage not recorded: %v\n\n", err)
+			return nil
+		}
+		fmt.Printf("  Prometheus rules: %d queries\n", len(rules))
+		expressions = append(expressions, rules...)
+	}
+
+	if analyzeGrafanaURL != "" {
+		token := analyzeGrafanaToken
+		if token == "" {
+			token = os.Getenv("GRAFANA_TOKEN")
+		}
+		dashboards, dashboardErrors, err := collectors.NewGrafanaClient(analyzeGrafanaURL, token).GetDashboardExpressions()
+		if err != nil {
+			fmt.Printf("WARNING: Failed to read Grafana dashboards, metric usage not recorded: %v\n\n", err)
+			return nil
+		}
+		fmt.Printf("  Grafana dashboards: %d queries\n", len(dashboards))
+		expressions = append(expressions, dashboards...)
+		errors = append(errors, dashboardErrors...)
+	}
+
+	if len(analyzeUsageFiles) > 0 {
+		files, err := usage.LoadFiles(analyzeUsageFiles)
+		if err != nil {
+			fmt.Printf("WARNING: Failed to read usage files, metric usage not recorded: %v\n\n", err)
+			return nil
+		}
+		fmt.Printf("  Usage files: %d queries\n", len(files))
+		expressions = append(expressions, files...)
+	}
+
+	var queryCounts map[string]int64
+	if len(analyzeQueryLogs) > 0 {
+		counts, err := usage.LoadQueryLogs(analyzeQueryLogs)
+		if err != nil {
+			fmt.Printf("WARNING: Failed to read query logs, metric usage not recorded: %v\n\n", err)
+			return nil
+		}
+		fmt.Printf("  Query logs: %d queried metrics\n", len(counts))
+		queryCounts = counts
+	}
+
+	report := usage.Report(expressions, queryCounts)
+	usageFile := filepath.Join(jobMetricsDir, loaders.MetricUsageFileName)
+	if err := collectors.WriteMetricUsageFile(usageFile, report); err != nil {
+		fmt.Printf("WARNING: Failed to write metric usage report: %v\n\n", err)
+		return errors
+	}
+	fmt.Printf("Usage of %d referenced or queried metrics saved to %s\n\n", len(report), usageFile)
+	return errors
+}
+
+// scrapeTargets scrapes the configured /metrics endpoints and writes per-job files to jobMetricsDir
+func scrapeTargets(ctx context.Context, targets *collectors.TargetsConfig, jobMetricsDir string) []collectors.ErrorRecord {
+	fmt.Printf("Starting direct scrape analysis...\n")
+	if analyzeTargetsFile != "" {
+		fmt.Printf("Targets file: %s\n", analyzeTargetsFile)
+	}
+	fmt.Printf("Targets: %d\n", len(targets.Targets))
+	if analyzeSelector != "" {
+		fmt.Printf("Selector: %s\n", analyzeSelector)
+	}
+	if analyzeQueryFilters != "" {
+		fmt.Printf("WARNING: --additional-query-filters is ignored in direct scrape mode\n")
+	}
+	fmt.Printf("Output directory: %s\n", jobMetricsDir)
+	fmt.Println()
+
+	scraper := collectors.NewScraper(targets.Targets)
+	scraper.SetLabelValueSamples(analyzeLabelValueSamples)
+	jobWriter := collectors.NewJobFileWriter(jobMetricsDir, analyzeMaxOpenFiles)
+	written, errors, err := scraper.ScrapeToWriter(ctx, jobWriter)
+	closeErr := jobWriter.Close()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	if closeErr != nil {
+		fmt.Printf("ERROR: Failed to write job files: %v\n", closeErr)
+		os.Exit(1)
+	}
+	fmt.Printf("Scrape complete! Wrote %d metric-job combinations\n", written)
+	fmt.Printf("Generated per-job files in %s/\n\n", jobMetricsDir)
+
+	writeBuildInfo(scraper.BuildInfo(), jobMetricsDir)
+
+	return errors
+}
+
+// collectFromOTLP reads OTLP files and receives OTLP/HTTP exports, and writes per-job files
+// to jobMetricsDir
+func collectFromOTLP(ctx context.Context, jobMetricsDir string) []collectors.ErrorRecord {
+	fmt.Printf("Starting OTLP metrics analysis...\n")
+	fmt.Printf("Job attributes: %s\n", strings.Join(analyzeOTLPJobAttributes, ", "))
+	if analyzeQueryFilters != "" {
+		fmt.Printf("WARNING: --additional-query-filters is ignored for OTLP sources\n")
+	}
+	fmt.Printf("Output directory: %s\n", jobMetricsDir)
+	fmt.Println()
+
+	source := collectors.NewOTLPSource(analyzeOTLPJobAttributes)
+	for _, pattern := range analyzeOTLPFiles {
+		files, err := filepath.Glob(pattern)
+		if err == nil && len(files) == 0 {
+			err = fmt.Errorf("no files match")
+		}
+		if err != nil {
+			fmt.Printf("ERROR: --otlp-files %s: %v\n", pattern, err)
+			os.Exit(1)
+		}
+		for _, file := range files {
+			if err := readOTLPFile(source, file); err != nil {
+				fmt.Printf("ERROR: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Read %s\n", file)
+		}
+	}
+
+	if analyzeOTLPListen != "" {
+		listener, err := net.Listen("tcp", analyzeOTLPListen)
+		if err != nil {
+			fmt.Printf("ERROR: Failed to listen for OTLP exports: %v\n", err)
+			os.Exit(1)
+		}
+		httpServer := &http.Server{Handler: source.Handler(), ReadHeaderTimeout: 10 * time.Second}
+		go httpServer.Serve(listener)
+		fmt.Printf("Receiving OTLP/HTTP exports on %s%s for %s...\n", listener.Addr(), collectors.OTLPMetricsPath, analyzeOTLPDuration)
+
+		select {
+		case <-time.After(analyzeOTLPDuration):
+		case <-ctx.Done():
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		httpServer.Shutdown(shutdownCtx)
+		cancel()
+		if ctx.Err() != nil {
+			fmt.Printf("ERROR: OTLP receive stopped: %v\n", ctx.Err())
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("Received %d export request(s) from %d job(s)\n", source.Requests(), source.Jobs())
+
+	jobWriter := collectors.NewJobFileWriter(jobMetricsDir, analyzeMaxOpenFiles)
+	written, errors, err := source.WriteTo(jobWriter, analyzeLabelValueSamples)
+	closeErr := jobWriter.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Printf("ERROR: Failed to write job files: %v\n", err)
+		os.Exit(1)
+	}
+	if written == 0 {
+		fmt.Println("ERROR: no OTLP metrics were read or received")
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d metric-job combinations\n", written)
+	fmt.Printf("Generated per-job files in %s/\n\n", jobMetricsDir)
+
+	writeBuildInfo(source.BuildInfo(), jobMetricsDir)
+
+	return errors
+}
+
+// readOTLPFile adds the OTLP JSON exports of a file to source
+func readOTLPFile(source *collectors.OTLPSource, filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := source.Read(file); err != nil {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	return nil
+}
+
+// writeBuildInfo writes the build info report into jobMetricsDir
+func writeBuildInfo(buildInfo []loaders.BuildInfoData, jobMetricsDir string) {
+	buildInfoFile := filepath.Join(jobMetricsDir, loaders.BuildInfoFileName)
+	if err := collectors.WriteBuildInfoFile(buildInfoFile, buildInfo); err != nil {
+		fmt.Printf("WARNING: Failed to write build info report: %v\n\n", err)
+		return
+	}
+	fmt.Printf("Build info for %d jobs saved to %s\n\n", len(loaders.ServiceVersions(buildInfo)), buildInfoFile)
+}
+
+// discoverKubeTargets discovers scrape targets in Kubernetes, adding any targets from --targets
+func discoverKubeTargets() (*collectors.TargetsConfig, error) {
+	client, err := kube.NewClientFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Discovering scrape targets in Kubernetes...")
+	discovered, warnings, err := kube.DiscoverTargets(client, kube.DiscoveryOptions{
+		Namespaces: analyzeKubeNamespaces,
+		Sources:    analyzeKubeSources,
+		JobLabel:   analyzeKubeJobLabel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		fmt.Printf("WARNING: %s\n", warning)
+	}
+	fmt.Printf("Discovered %d targets\n\n", len(discovered))
+
+	config := &collectors.TargetsConfig{Targets: discovered}
+	if analyzeTargetsFile != "" {
+		static, err := collectors.LoadTargetsConfig(analyzeTargetsFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Targets = append(config.Targets, static.Targets...)
+	}
+	if len(config.Targets) == 0 {
+		return nil, fmt.Errorf("no scrape targets discovered")
+	}
+	return config, nil
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/loaders"
+	"instrumentation-score/internal/storage"
+	"instrumentation-score/pkg/score"
+
+	"github.com/spf13/cobra"
+)
+
+// ciEnvPrefix starts the environment variables ci reads; GitHub Actions inputs (INPUT_<NAME>) work too
+const ciEnvPrefix = "INSTRUMENTATION_SCORE_"
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Score metrics in a CI pipeline, configured from the environment",
+	Long: `Evaluate metrics in a CI pipeline. Everything is read from the environment, so the
+same invocation works in any CI system and as a GitHub Action (whose inputs arrive as
+INPUT_<NAME>):
+
+  INSTRUMENTATION_SCORE_METRICS    Exposition file or /metrics URL to score as one job
+  INSTRUMENTATION_SCORE_JOB        Job name for METRICS (default: ci)
+  INSTRUMENTATION_SCORE_JOB_DIR    Directory of job files written by analyze
+  INSTRUMENTATION_SCORE_S3_SOURCE  "true" to score the job files of the analysis in
+                                   S3_BUCKET/S3_PREFIX (region: AWS_REGION)
+  INSTRUMENTATION_SCORE_RULES      Rules file (default: rules_config.yaml or built-in)
+  INSTRUMENTATION_SCORE_MIN_SCORE  Fail when the score is lower (default: 0, never)
+
+Failing rules are reported as annotations: GitHub Actions workflow commands when
+GITHUB_ACTIONS is "true", "file:severity: message" lines otherwise. The score,
+category and whether the minimum was met are written to GITHUB_OUTPUT for later
+steps, and a summary to GITHUB_STEP_SUMMARY, when those are set.
+
+Examples:
+  # Score the metrics an integration test saved
+  INSTRUMENTATION_SCORE_METRICS=testdata/metrics.prom \
+  INSTRUMENTATION_SCORE_MIN_SCORE=80 \
+    instrumentation-score ci`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runCI(cmd)
+	},
+}
+
+// ciJob is the score of one job evaluated by ci
+type ciJob struct {
+	name    string
+	file    string // File the metrics came from, for annotations; empty for S3
+	score   float64
+	results []engine.RuleResult
+}
+
+// ciSetting returns INSTRUMENTATION_SCORE_<name>, or the GitHub Actions input INPUT_<name>
+func ciSetting(name string) string {
+	if value := os.Getenv(ciEnvPrefix + name); value != "" {
+		return value
+	}
+	return os.Getenv("INPUT_" + name)
+}
+
+func runCI(cmd *cobra.Command) {
+	minScore := 0.0
+	if value := ciSetting("MIN_SCORE"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fmt.Printf("ERROR: invalid %sMIN_SCORE %q: %v\n", ciEnvPrefix, value, err)
+			os.Exit(1)
+		}
+		minScore = parsed
+	}
+
+	rulesFile := ciSetting("RULES")
+	if rulesFile == "" && !useBuiltinRules(cmd.Flags(), defaultRulesFile) {
+		rulesFile = defaultRulesFile
+	}
+	ruleEngine, err := newRuleEngine(rulesFile)
+	if err != nil {
+		fmt.Printf("ERROR: failed to load rules: %v\n", err)
+		os.Exit(1)
+	}
+
+	jobs, err := ciEvaluate(ruleEngine)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	var total float64
+	for _, job := range jobs {
+		total += job.score
+		for _, result := range job.results {
+			ciAnnotate(job, result)
+		}
+	}
+	average := total / float64(len(jobs))
+	category := score.Category(average)
+	passed := average >= minScore
+
+	fmt.Printf("Score: %.1f/100 (%s) over %d job(s)\n", average, category, len(jobs))
+	if err := ciWriteOutputs(average, category, passed); err != nil {
+		fmt.Printf("WARNING: failed to write step outputs: %v\n", err)
+	}
+	if err := ciWriteSummary(jobs, average, category); err != nil {
+		fmt.Printf("WARNING: failed to write step summary: %v\n", err)
+	}
+
+	if !passed {
+		fmt.Printf("ERROR: score %.1f is below %sMIN_SCORE %.1f\n", average, ciEnvPrefix, minScore)
+		os.Exit(1)
+	}
+}
+
+// ciEvaluate scores the metrics the environment points to
+func ciEvaluate(ruleEngine *engine.RuleEngine) ([]ciJob, error) {
+	if source := ciSetting("METRICS"); source != "" {
+		job := ciSetting("JOB")
+		if job == "" {
+			job = "ci"
+		}
+		jobData, err := readLocalMetrics(source, job)
+		if err != nil {
+			return nil, err
+		}
+		result, err := score.EvaluateJob(context.Background(), ruleEngine, job, jobData, score.Options{})
+		if err != nil {
+			return nil, err
+		}
+		logScoreWarnings(result)
+		file := source
+		if source == "-" || strings.Contains(source, "://") {
+			file = ""
+		}
+		return []ciJob{{name: job, file: file, score: result.Score, results: result.RuleResults}}, nil
+	}
+
+	dir := ciSetting("JOB_DIR")
+	if strings.EqualFold(ciSetting("S3_SOURCE"), "true") {
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = "eu-west-1"
+		}
+		downloaded, err := storage.DownloadEvaluationSource(context.Background(), storage.EvaluationDownloadConfig{
+			Bucket: os.Getenv("S3_BUCKET"),
+			Prefix: os.Getenv("S3_PREFIX"),
+			Region: region,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to download from S3: %w", err)
+		}
+		defer removeDownload(downloaded)
+		dir = downloaded
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("nothing to score: set %sMETRICS, %sJOB_DIR or %sS3_SOURCE", ciEnvPrefix, ciEnvPrefix, ciEnvPrefix)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	var jobs []ciJob
+	for _, file := range files {
+		jobData, err := loaders.LoadJobMetricReport(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", file, err)
+		}
+		if len(jobData) == 0 || ruleEngine.IsJobExcluded(jobData[0].Job) {
+			continue
+		}
+		result, err := score.EvaluateJob(context.Background(), ruleEngine, jobData[0].Job, jobData, score.Options{})
+		if err != nil {
+			fmt.Printf("WARNING: %s: %v\n", filepath.Base(file), err)
+			continue
+		}
+		logScoreWarnings(result)
+		jobs = append(jobs, ciJob{name: jobData[0].Job, score: result.Score, results: result.RuleResults})
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no jobs to score in %s", dir)
+	}
+	return jobs, nil
+}
+
+// ciAnnotate reports a failing rule of job as an annotation
+// Critical rules are errors, Important rules warnings and the rest notices.
+func ciAnnotate(job ciJob, result engine.RuleResult) {
+	names := sortedFailedMetrics(result)
+	if len(names) == 0 {
+		return
+	}
+	severity := "notice"
+	switch result.Impact {
+	case "Critical":
+		severity = "error"
+	case "Important":
+		severity = "warning"
+	}
+
+	shown := names
+	if len(shown) > 5 {
+		shown = shown[:5]
+	}
+	message := fmt.Sprintf("%s: %d of %d metrics failed: %s", job.name, len(names), result.TotalMetrics, strings.Join(shown, ", "))
+	if len(names) > len(shown) {
+		message += fmt.Sprintf(" and %d more", len(names)-len(shown))
+	}
+	title := fmt.Sprintf("%s (%s)", result.RuleID, result.Impact)
+
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		file := job.file
+		if file == "" {
+			file = job.name
+		}
+		fmt.Printf("%s:%s: %s %s\n", file, severity, title, message)
+		return
+	}
+	properties := "title=" + escapeWorkflowProperty(title)
+	if job.file != "" {
+		properties = "file=" + escapeWorkflowProperty(job.file) + "," + properties
+	}
+	fmt.Printf("::%s %s::%s\n", severity, properties, escapeWorkflowData(message))
+}
+
+// escapeWorkflowData escapes the message of a GitHub Actions workflow command
+func escapeWorkflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeWorkflowProperty escapes a property value of a GitHub Actions workflow command
+func escapeWorkflowProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// ciWriteOutputs sets the score, category and passed step outputs in GITHUB_OUTPUT, if set
+func ciWriteOutputs(score float64, category string, passed bool) error {
+	return appendEnvFile("GITHUB_OUTPUT", fmt.Sprintf("score=%.2f\ncategory=%s\npassed=%t\n", score, category, passed))
+}
+
+// ciWriteSummary writes a Markdown table of the job scores to GITHUB_STEP_SUMMARY, if set
+func ciWriteSummary(jobs []ciJob, score float64, category string) error {
+	sorted := append([]ciJob(nil), jobs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].score < sorted[j].score })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Instrumentation Score: %.1f (%s)\n\n| Job | Score | Failing rules |\n|---|---:|---|\n", score, category)
+	for _, job := range sorted {
+		var failing []string
+		for _, result := range job.results {
+			if len(result.FailedMetrics) > 0 {
+				failing = append(failing, result.RuleID)
+			}
+		}
+		fmt.Fprintf(&b, "| %s | %.1f | %s |\n", job.name, job.score, strings.Join(failing, ", "))
+	}
+	return appendEnvFile("GITHUB_STEP_SUMMARY", b.String())
+}
+
+// appendEnvFile appends content to the file named by the environment variable name, if set
+func appendEnvFile(name, content string) error {
+	path := os.Getenv(name)
+	if path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/health"
+	"instrumentation-score/internal/kube"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	controllerRules      string
+	controllerInterval   time.Duration
+	controllerNamespaces []string
+	controllerAnnotation string
+	controllerMinScore   float64
+	controllerOnce       bool
+	controllerHealth     string
+	controllerElect      bool
+	controllerLeaseNS    string
+	controllerLease      string
+	controllerReload     time.Duration
+)
+
+// leaderLeaseDuration is how long a leader lease of the controller or serve stays valid
+// without renewal
+const leaderLeaseDuration = 15 * time.Second
+
+var controllerCmd = &cobra.Command{
+	Use:   "controller",
+	Short: "Continuously score opted-in Kubernetes Deployments",
+	Long: `Run in-cluster as a controller that scores the metrics of opted-in Deployments.
+
+Every --interval the controller lists Deployments annotated
+instrumentation-score/enabled: "true", scrapes the running pods they select and
+evaluates the metrics against the rules. Results are published as:
+
+  InstrumentationScore resources (apply deploy/crd.yaml), one per Deployment,
+  with the score, pass/fail and per-rule summary in .status
+
+  Events on the Deployment: InstrumentationScoreEvaluated (Normal),
+  InstrumentationScoreBelowThreshold or InstrumentationScoreFailed (Warning)
+
+The rules file is re-read every --rules-reload-interval; valid changes apply
+from the next scored Deployment, invalid ones are logged and ignored. The
+status of each InstrumentationScore records the rules version it was scored with.
+
+With --leader-elect, replicas share a coordination.k8s.io Lease and only the
+holder scores; the others stand by and serve probes until it stops renewing.
+
+Probes on --health-addr (default :8081):
+  /healthz  Fails when no scoring pass completed for three intervals
+  /readyz   Fails when the Kubernetes API is unreachable or the rules file is invalid
+
+Deployment annotations:
+  instrumentation-score/enabled    "true" to opt in (name set by --annotation)
+  instrumentation-score/job        Job name (default: Deployment name)
+  instrumentation-score/min-score  Per-Deployment threshold (default: --min-score)
+  instrumentation-score/port       Metrics port (default: pod prometheus.io/port or a "metrics" container port)
+  instrumentation-score/path       Metrics path (default: pod prometheus.io/path or /metrics)
+
+Examples:
+  # Score every 15 minutes in all namespaces
+  instrumentation-score controller --rules rules_config.yaml --min-score 70
+
+  # Single pass against a local cluster through kubectl proxy
+  KUBE_API_SERVER=http://127.0.0.1:8001 instrumentation-score controller --once --kube-namespaces prod`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runController()
+	},
+}
+
+func init() {
+	controllerCmd.Flags().StringVarP(&controllerRules, "rules", "r", "rules_config.yaml", "Rules configuration file")
+	controllerCmd.Flags().DurationVar(&controllerInterval, "interval", 15*time.Minute, "Time between scoring passes")
+	controllerCmd.Flags().StringSliceVar(&controllerNamespaces, "kube-namespaces", nil, "Namespaces to watch (default: all)")
+	controllerCmd.Flags().StringVar(&controllerAnnotation, "annotation", kube.AnnotationEnabled, "Deployment annotation that opts in to scoring")
+	controllerCmd.Flags().Float64Var(&controllerMinScore, "min-score", 0.0, "Scores below this are reported as failing")
+	controllerCmd.Flags().BoolVar(&controllerOnce, "once", false, "Run a single scoring pass and exit")
+	controllerCmd.Flags().DurationVar(&controllerReload, "rules-reload-interval", 30*time.Second, "How often to check the rules file for changes and reload it (0 disables)")
+	controllerCmd.Flags().BoolVar(&controllerElect, "leader-elect", false, "Elect a leader through a Lease so only one of several replicas scores")
+	controllerCmd.Flags().StringVar(&controllerLeaseNS, "leader-election-namespace", "", "Namespace of the leader election Lease (default: the controller's namespace)")
+	controllerCmd.Flags().StringVar(&controllerLease, "leader-election-name", "instrumentation-score-controller", "Name of the leader election Lease")
+	controllerCmd.Flags().StringVar(&controllerHealth, "health-addr", ":8081", "Address serving /healthz and /readyz probes (empty disables)")
+}
+
+func runController() {
+	client, err := kube.NewClientFromEnv()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	rules, err := engine.NewReloadingEngine(controllerRules)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to load rules: %v\n", err)
+		os.Exit(1)
+	}
+	if controllerReload > 0 && !controllerOnce {
+		go rules.Watch(controllerReload, nil, func(version string, err error) {
+			if err != nil {
+				fmt.Printf("WARNING: rules file %s rejected, keeping rules version %s: %v\n", controllerRules, version, err)
+				return
+			}
+			fmt.Printf("Reloaded rules from %s (version %s)\n", controllerRules, version)
+		})
+	}
+
+	controller := kube.NewController(client, kube.ScrapeScorer(rules), kube.ControllerOptions{
+		Namespaces: controllerNamespaces,
+		Annotation: controllerAnnotation,
+		MinScore:   controllerMinScore,
+	})
+
+	var probes *health.Server
+	if controllerHealth != "" && !controllerOnce {
+		probes = startHealthServer(client)
+	}
+
+	var elector *kube.LeaderElector
+	electionStop, electionDone := make(chan struct{}), make(chan struct{})
+	if controllerElect && !controllerOnce {
+		elector = startLeaderElection(client, controllerLeaseNS, controllerLease, "scoring deployments", electionStop, electionDone)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	fmt.Printf("Starting controller (interval %s, annotation %s, min score %.1f)\n", controllerInterval, controllerAnnotation, controllerMinScore)
+	for {
+		// Standby replicas re-check the lease often so they take over soon after the leader stops
+		wait := controllerInterval
+		if elector == nil || elector.IsLeader() {
+			runControllerPass(controller)
+		} else {
+			wait = leaderLeaseDuration / 3
+		}
+		if probes != nil {
+			probes.PassCompleted()
+		}
+		if controllerOnce {
+			return
+		}
+
+		select {
+		case <-stop:
+			fmt.Println("Shutting down controller")
+			if elector != nil {
+				close(electionStop)
+				<-electionDone
+			}
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// startLeaderElection acquires the leader Lease name if it is free and keeps renewing it in
+// the background, releasing it when electionStop is closed; work describes what the leader does
+func startLeaderElection(client *kube.Client, namespace, name, work string, electionStop, electionDone chan struct{}) *kube.LeaderElector {
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+	if namespace == "" {
+		fmt.Println("ERROR: --leader-election-namespace is required outside a cluster")
+		os.Exit(1)
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	elector := kube.NewLeaderElector(client, namespace, name, identity, leaderLeaseDuration)
+	if _, err := elector.TryAcquireOrRenew(); err != nil {
+		fmt.Printf("WARNING: leader election: %v\n", err)
+	}
+	fmt.Printf("Leader election as %s on lease %s/%s: leader=%v\n", identity, namespace, name, elector.IsLeader())
+	go func() {
+		elector.Run(electionStop, func(leader bool) {
+			if leader {
+				fmt.Printf("Became leader, %s\n", work)
+			} else {
+				fmt.Println("Lost leadership, standing by")
+			}
+		})
+		close(electionDone)
+	}()
+	return elector
+}
+
+// startHealthServer serves the liveness and readiness probes on --health-addr
+// Liveness fails after three intervals without a completed pass; readiness checks that the
+// Kubernetes API is reachable and the rules file still loads.
+func startHealthServer(client *kube.Client) *health.Server {
+	probes := health.NewServer(3*controllerInterval,
+		health.Check{Name: "kubernetes", Run: func() error { return client.Get("/version", nil) }},
+		health.Check{Name: "rules", Run: func() error {
+			_, err := engine.NewRuleEngine(controllerRules)
+			return err
+		}},
+	)
+	server := &http.Server{Addr: controllerHealth, Handler: probes.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("ERROR: health server: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+	fmt.Printf("Serving /healthz and /readyz on %s\n", controllerHealth)
+	return probes
+}
+
+// runControllerPass reconciles once and prints a line per Deployment
+func runControllerPass(controller *kube.Controller) {
+	start := time.Now()
+	results, warnings, err := controller.Reconcile()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		return
+	}
+	for _, warning := range warnings {
+		fmt.Printf("WARNING: %s\n", warning)
+	}
+
+	failing := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failing++
+			fmt.Printf("  ✗ %s/%s: %v\n", result.Namespace, result.Name, result.Err)
+		case !result.Passed:
+			failing++
+			fmt.Printf("  ✗ %s/%s: %.1f (below %.1f)\n", result.Namespace, result.Name, result.Score, result.MinScore)
+		default:
+			fmt.Printf("  ✓ %s/%s: %.1f\n", result.Namespace, result.Name, result.Score)
+		}
+	}
+	fmt.Printf("Scored %d deployments (%d failing) in %s\n", len(results), failing, time.Since(start).Round(time.Millisecond))
+}
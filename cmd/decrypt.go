@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"instrumentation-score/internal/encryption"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	decryptOutput string
+	decryptRegion string
+)
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt <file.enc>",
+	Short: "Decrypt a report encrypted by evaluate --encrypt",
+	Long: `Decrypt a JSON or HTML report written by evaluate --encrypt.
+
+Reports encrypted with a key from ` + encryption.KeyEnv + ` need the same key set.
+Reports encrypted with a KMS key need AWS credentials allowed to decrypt with it;
+the key itself is found from the report.
+
+Examples:
+  # Decrypt into report.json
+  instrumentation-score decrypt report.json.enc
+
+  # Decrypt to stdout
+  instrumentation-score decrypt dashboard.html.enc --output -`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runDecrypt(args[0])
+	},
+}
+
+func init() {
+	decryptCmd.Flags().StringVarP(&decryptOutput, "output", "o", "", "Output file, - for stdout (default: the input file without "+encryption.Extension+")")
+	decryptCmd.Flags().StringVar(&decryptRegion, "region", "eu-west-1", "AWS region of the KMS key (or use AWS_REGION env var)")
+}
+
+func runDecrypt(file string) {
+	output := decryptOutput
+	if output == "" {
+		if !strings.HasSuffix(file, encryption.Extension) {
+			fmt.Printf("ERROR: %s does not end in %s, set --output\n", file, encryption.Extension)
+			os.Exit(1)
+		}
+		output = strings.TrimSuffix(file, encryption.Extension)
+	}
+
+	region := decryptRegion
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	encrypter, err := encryption.New("", region)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	plaintext, err := encrypter.Decrypt(data)
+	if err != nil {
+		fmt.Printf("ERROR: %s: %v\n", file, err)
+		os.Exit(1)
+	}
+
+	if output == "-" {
+		os.Stdout.Write(plaintext)
+		return
+	}
+	if err := os.WriteFile(output, plaintext, 0600); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Decrypted %s to %s\n", file, output)
+}
@@ -1,21 +1,41 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"instrumentation-score/internal/collectors"
+	"instrumentation-score/internal/encryption"
 	"instrumentation-score/internal/engine"
 	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/gate"
+	"instrumentation-score/internal/history"
 	"instrumentation-score/internal/loaders"
+	"instrumentation-score/internal/locale"
+	"instrumentation-score/internal/notify"
+	"instrumentation-score/internal/orgscore"
+	"instrumentation-score/internal/ownership"
+	"instrumentation-score/internal/progress"
+	"instrumentation-score/internal/remotewrite"
+	"instrumentation-score/internal/runconfig"
+	"instrumentation-score/internal/spec"
 	"instrumentation-score/internal/storage"
+	"instrumentation-score/internal/waivers"
+	"instrumentation-score/pkg/score"
 
 	"github.com/spf13/cobra"
 )
@@ -23,13 +43,68 @@ import (
 var (
 	// Common flags
 	rulesConfig    string
-	outputFormats  string // Comma-separated: text,json,html,prometheus
+	outputFormats  string // Comma-separated: text,json,html,prometheus,crd,openslo,pyrra,sloth,template,badge or a plugin format
 	jsonFile       string
 	htmlFile       string
 	prometheusFile string
+	crdFile        string
+	crdNamespace   string
+	opensloFile    string
+	sloTarget      float64
+	sloWindow      string
+	sloObjective   float64
+	pyrraFile      string
+	slothFile      string
+	pluginFiles    map[string]string // Output file per plugin format, from --plugin-file
+	templateFile   string            // Custom template rendered by the template output
+	templateOutput string
+	htmlTemplate   string // Replaces the built-in HTML report template
+	ownershipFile  string
+	owners         *ownership.Mapping    // Loaded from --ownership
+	phases         = progress.NewTimer() // Time spent loading, evaluating, formatting and uploading
+	healthFile     string
+	usageFile      string
+	churnFile      string
+	orgWeighting   string // How job scores combine into the organization score
+	badgeFile      string
+	junitFile      string
+	sarifFile      string
+	decayRuns      int
+	decayWeight    int
+	recordRuns     bool
+	historyDB      string
+	historyStore   *history.Store // Opened from --history-db when --decay-runs is set
+	waiverFile     string
+	waived         *waivers.File       // Loaded from --waivers
+	runSettings    *runconfig.Snapshot // Flags and environment, captured when the command runs
+	encryptOutput  bool
+	encryptKMSKey  string
+	encrypter      *encryption.Encrypter // Created when --encrypt is set
+	localeTag      string
+	localeCatalog  string
+	outputLocale   *locale.Locale // Loaded from --locale and --locale-catalog
+	previousFile   string
+	previousRun    *history.PreviousRun // Loaded from --previous-report
+	callbackURLs   []string
+	metricPrefix   string
+	metricLabels   map[string]string
+	callbacks      *notify.Webhooks // Created when --callback-url is set
+	otlpLogs       bool
+	otlpLogsURL    string
+	runLogs        *notify.OTLPLogs // Created when --otlp-logs is set
+	runStarted     time.Time
+	conformance    bool
+	conformanceDir string
+	conformanceRef string
+	specRuleset    []spec.Rule // Loaded when --spec-conformance is set
+	specOrigin     string
+	remoteWrite    remotewrite.Client // URL and credentials from the --remote-write-* flags
 
 	// Single job flags
-	jobFile string
+	jobFile         string
+	cardinalityFile string // Legacy report pair evaluated as one job, instead of --job-file
+	labelsFile      string
+	legacyJob       string // Job name of the legacy report pair
 
 	// All jobs flags
 	jobDir       string
@@ -37,36 +112,121 @@ var (
 	showFailures bool
 	showCosts    bool
 	costPrice    float64
+	jobTimeout   time.Duration
+	maxJobLines  int
+	maxLineBytes int
+	strictParse  bool
+	strictRules  bool
+	namingPack   string
+	reportURL    string
+	legacyPairs  bool // --job-dir holds <job>_cardinality.txt and <job>_labels.txt pairs
+
+	// Regression alert flags
+	alertScoreDrop      float64
+	alertPassRateDrop   float64
+	pagerDutyRoutingKey string
+	opsgenieAPIKey      string
+	opsgenieURL         string
+	alerters            []notify.Alerter // Created when an alert threshold is set
+
+	// CI gate flags
+	failBelow        float64
+	failBelowJob     float64
+	failBelowJobs    map[string]string // Job name -> minimum score, from --fail-below-job-for
+	thresholdsFile   string
+	failOnRegression bool
+	regressionMargin float64
+	gateThresholds   gate.Thresholds
 
 	// S3 flags
-	evaluateS3Source bool
-	evaluateS3Upload bool
-	evaluateS3Bucket string
-	evaluateS3Prefix string
-	evaluateS3Region string
-	evaluateS3RunID  string
+	evaluateS3Source  bool
+	evaluateS3Stream  bool
+	evaluateS3Workers int
+	evaluateS3Keep    bool
+	evaluateS3Upload  bool
+	evaluateS3Bucket  string
+	evaluateS3Prefix  string
+	evaluateS3Region  string
+	evaluateS3RunID   string
+
+	// Azure Blob Storage flags
+	azureSource    bool
+	azureUpload    bool
+	azureAccount   string
+	azureContainer string
+	azurePrefix    string
 )
 
 // JobScoreResult represents the score result for a single job
 type JobScoreResult struct {
-	JobName          string              `json:"job_name"`
-	TotalMetrics     int                 `json:"total_metrics"`
-	TotalCardinality int64               `json:"total_cardinality"`
-	EstimatedCost    float64             `json:"estimated_cost,omitempty"`
-	Score            float64             `json:"instrumentation_score"`
-	RuleResults      []engine.RuleResult `json:"rules"`
-	FailedMetrics    []string            `json:"failed_metrics,omitempty"`
-	MetricsBreakdown map[string]int      `json:"metrics_breakdown"`
+	JobName          string                 `json:"job_name"`
+	RenamedFrom      string                 `json:"renamed_from,omitempty"` // Name in the previous run, see linkRenamedJobs
+	ServiceVersion   string                 `json:"service_version,omitempty"`
+	TotalMetrics     int                    `json:"total_metrics"`
+	TotalCardinality int64                  `json:"total_cardinality"`
+	EstimatedCost    float64                `json:"estimated_cost,omitempty"`
+	Score            float64                `json:"instrumentation_score"`
+	ScoreBreakdown   *engine.ScoreBreakdown `json:"score_breakdown,omitempty"`
+	Confidence       *ScoreConfidence       `json:"confidence,omitempty"`
+	RuleResults      []engine.RuleResult    `json:"rules"`
+	FailedMetrics    []string               `json:"failed_metrics,omitempty"`
+	MetricsBreakdown map[string]int         `json:"metrics_breakdown"`
+	Warnings         []string               `json:"warnings,omitempty"` // Validators that failed to evaluate, counted as failed
+	ParseWarnings    []string               `json:"parse_warnings,omitempty"`
+	UnusedMetrics    []UnusedMetric         `json:"unused_metrics,omitempty"`
+	Remediation      []RemediationItem      `json:"remediation,omitempty"`
+	Config           *runconfig.Snapshot    `json:"config,omitempty"` // Single-job JSON only; see AllJobsReport.Config
+	Fingerprint      history.Fingerprint    `json:"metric_fingerprint,omitempty"`
+
+	sourceFile   string                  // Name of the job file in its jobSource, for the HTML report
+	source       string                  // Identifies the evaluated data in --history-db, see jobRunSource
+	decayMetrics []loaders.JobMetricData // Failing metrics, until score decay is applied
+	excluded     int                     // Metrics the exclusion list removed before scoring
+	malformed    int                     // Records skipped as malformed while loading the job file
+}
+
+// ScoreConfidence is how far a score can be trusted, lower when metrics of the job were not
+// collected, excluded or unreadable and the score rests on partial data
+type ScoreConfidence struct {
+	Level           string  `json:"level"` // score.ConfidenceHigh, ConfidenceMedium or ConfidenceLow
+	MissingFraction float64 `json:"missing_fraction"`
+	Uncollected     int     `json:"uncollected_metrics,omitempty"`
+	Excluded        int     `json:"excluded_metrics,omitempty"`
+	Malformed       int     `json:"malformed_records,omitempty"`
+}
+
+// RemediationItem is a failing metric ranked by how often it is queried
+type RemediationItem struct {
+	MetricName  string   `json:"metric_name"`
+	FailedRules []string `json:"failed_rules"`
+	QueryCount  int64    `json:"query_count"`
+	Cardinality int64    `json:"cardinality"`
+	Action      string   `json:"action"` // "fix", or "drop" when nothing uses the metric
+}
+
+// UnusedMetric is a metric no scanned dashboard, rule or logged query uses, a dead-weight candidate
+type UnusedMetric struct {
+	MetricName    string  `json:"metric_name"`
+	Cardinality   int64   `json:"cardinality"`
+	EstimatedCost float64 `json:"estimated_cost,omitempty"`
 }
 
 // AllJobsReport represents the complete report for all jobs
 type AllJobsReport struct {
-	Timestamp        string           `json:"timestamp"`
-	TotalJobs        int              `json:"total_jobs"`
-	AverageScore     float64          `json:"average_score"`
-	TotalCost        float64          `json:"total_cost,omitempty"`
-	TotalCardinality int64            `json:"total_cardinality"`
-	Jobs             []JobScoreResult `json:"jobs"`
+	Timestamp        string                  `json:"timestamp"`
+	TotalJobs        int                     `json:"total_jobs"`
+	AverageScore     float64                 `json:"average_score"`
+	OrgScore         *orgscore.Score         `json:"organization_score,omitempty"` // Job scores weighted by --org-score-weighting
+	TotalCost        float64                 `json:"total_cost,omitempty"`
+	TotalCardinality int64                   `json:"total_cardinality"`
+	Selector         string                  `json:"selector,omitempty"` // analyze --selector the jobs were collected with
+	Jobs             []JobScoreResult        `json:"jobs"`
+	Warnings         []string                `json:"warnings,omitempty"`
+	SkippedJobs      []formatters.SkippedJob `json:"skipped_jobs,omitempty"` // Job files that failed, so TotalJobs is not silently short
+	Config           *runconfig.Snapshot     `json:"config,omitempty"`
+	SpecConformance  *spec.Conformance       `json:"spec_conformance,omitempty"` // With --spec-conformance
+
+	owners *ownership.Mapping // Teams of the jobs, for ReportJobs
 }
 
 var evaluateCmd = &cobra.Command{
@@ -96,38 +256,173 @@ Examples:
   instrumentation-score evaluate \
     --job-file reports/job_metrics_*/api-service.txt`,
 	Run: func(cmd *cobra.Command, args []string) {
-		runEvaluate()
+		runSettings = runconfig.Capture("evaluate", cmd.Flags(), evaluateEnv)
+		if useBuiltinRules(cmd.Flags(), rulesConfig) {
+			rulesConfig = ""
+		}
+		code, err := runEvaluate()
+		if err != nil {
+			log.Print(err)
+		}
+		if code != 0 {
+			os.Exit(code)
+		}
 	},
 }
 
 func init() {
 	// Common flags
-	evaluateCmd.Flags().StringVarP(&rulesConfig, "rules", "r", "rules_config.yaml", "Rules configuration file")
-	evaluateCmd.Flags().StringVarP(&outputFormats, "output", "o", "text", "Output formats (comma-separated): text,json,html,prometheus")
+	evaluateCmd.Flags().StringVarP(&rulesConfig, "rules", "r", defaultRulesFile, "Rules configuration file; the built-in rules are used when left at the default and the file does not exist (see rules export-defaults)")
+	evaluateCmd.Flags().StringVarP(&outputFormats, "output", "o", "text", "Output formats (comma-separated): text,json,html,prometheus,crd,openslo,pyrra,sloth,template,badge,junit,sarif, or a plugin format")
 	evaluateCmd.Flags().StringVar(&jsonFile, "json-file", "", "JSON output file path")
 	evaluateCmd.Flags().StringVar(&htmlFile, "html-file", "", "HTML output file path")
 	evaluateCmd.Flags().StringVar(&prometheusFile, "prometheus-file", "", "Prometheus metrics output file path")
+	evaluateCmd.Flags().StringVar(&remoteWrite.URL, "remote-write-url", "", "Prometheus remote_write URL to push the score and per-rule metrics of --output prometheus to, timestamped with the run")
+	evaluateCmd.Flags().StringVar(&remoteWrite.Username, "remote-write-username", "", "Basic auth user for --remote-write-url, with the password in --remote-write-password or "+remotewrite.PasswordEnv)
+	evaluateCmd.Flags().StringVar(&remoteWrite.Password, "remote-write-password", "", "Basic auth password for --remote-write-url (or use "+remotewrite.PasswordEnv+" env var)")
+	evaluateCmd.Flags().StringVar(&remoteWrite.BearerToken, "remote-write-bearer-token", "", "Bearer token for --remote-write-url, instead of basic auth (or use "+remotewrite.BearerTokenEnv+" env var)")
+	evaluateCmd.Flags().StringToStringVar(&remoteWrite.Headers, "remote-write-header", nil, "Extra headers for --remote-write-url, e.g. X-Scope-OrgID=platform")
+	evaluateCmd.Flags().StringVar(&metricPrefix, "metric-prefix", formatters.DefaultMetricPrefix, "Prefix of exported metric names, also used in generated SLO queries")
+	evaluateCmd.Flags().StringToStringVar(&metricLabels, "metric-labels", nil, "Static labels added to every exported series and SLO query, e.g. env=prod,cluster=eu-1")
+	evaluateCmd.Flags().StringVar(&crdFile, "crd-file", "", "InstrumentationScore manifests output file path")
+	evaluateCmd.Flags().StringVar(&crdNamespace, "crd-namespace", "", "Namespace set on generated Kubernetes manifests: crd, pyrra (default: none)")
+	evaluateCmd.Flags().StringVar(&opensloFile, "openslo-file", "", "OpenSLO documents output file path")
+	evaluateCmd.Flags().Float64Var(&sloTarget, "slo-target", 75.0, "Score objective (0-100) for generated SLOs")
+	evaluateCmd.Flags().StringVar(&sloWindow, "slo-window", "28d", "Rolling window for generated SLOs")
+	evaluateCmd.Flags().Float64Var(&sloObjective, "slo-objective", 99.0, "Percent of the window the score must meet --slo-target (pyrra, sloth)")
+	evaluateCmd.Flags().StringVar(&pyrraFile, "pyrra-file", "", "Pyrra ServiceLevelObjectives output file path")
+	evaluateCmd.Flags().StringVar(&slothFile, "sloth-file", "", "Sloth SLO specs output file path")
+	evaluateCmd.Flags().StringVar(&badgeFile, "badge-file", "", "SVG score badge output file path")
+	evaluateCmd.Flags().StringVar(&junitFile, "junit-file", "", "JUnit XML output file path, for CI test report viewers")
+	evaluateCmd.Flags().StringVar(&sarifFile, "sarif-file", "", "SARIF output file path, for GitHub Code Scanning")
+	evaluateCmd.Flags().StringVar(&orgWeighting, "org-score-weighting", orgscore.DefaultWeighting, "How job scores combine into the organization score: "+strings.Join(orgscore.Weightings(), ", ")+" (team_size needs --ownership)")
+	evaluateCmd.Flags().StringVar(&templateFile, "template-file", "", "Custom template rendered by --output template (.html files are HTML-escaped)")
+	evaluateCmd.Flags().StringVar(&templateOutput, "template-output", "", "Output file of --output template (default: stdout)")
+	evaluateCmd.Flags().StringVar(&htmlTemplate, "html-template", "", "Template replacing the built-in HTML report template")
+	evaluateCmd.Flags().StringToStringVar(&pluginFiles, "plugin-file", nil, "Output file of a plugin format, e.g. confluence=report.xml (repeatable; default: stdout)")
+	evaluateCmd.Flags().StringVar(&ownershipFile, "ownership", "", "Job ownership mapping file; adds team routing labels to generated SLOs and per-team digests to callbacks")
+	evaluateCmd.Flags().StringVar(&usageFile, "metric-usage-file", "", "Metric usage report for the metric_usage data source and dead-weight candidates (default: "+loaders.MetricUsageFileName+" next to the job files)")
+	evaluateCmd.Flags().StringVar(&waiverFile, "waivers", "", "Waivers file acknowledging failing metrics until an expiry date")
+	evaluateCmd.Flags().IntVar(&decayRuns, "decay-runs", 0, "Weigh metrics failing the same rule for this many consecutive runs recorded in --history-db more heavily; records the run (0 disables)")
+	evaluateCmd.Flags().IntVar(&decayWeight, "decay-weight", 2, "How many failures a chronically failing metric counts as (with --decay-runs)")
+	evaluateCmd.Flags().BoolVar(&recordRuns, "record-history", false, "Record every job's score, cardinality and rule results in --history-db, for the history command")
+	evaluateCmd.Flags().StringVar(&historyDB, "history-db", defaultHistoryDB, "SQLite database runs are recorded in (with --record-history or --decay-runs)")
+	evaluateCmd.Flags().StringVar(&localeTag, "locale", "en", "Locale of the text and HTML reports: number and date formats and translated categories (built in: en, de, fr, es)")
+	evaluateCmd.Flags().StringVar(&localeCatalog, "locale-catalog", "", "YAML message catalog adding or overriding translations and formats for --locale")
+	evaluateCmd.Flags().StringSliceVar(&callbackURLs, "callback-url", nil, "URL to POST a JSON run summary to when the run finishes or fails (repeatable); signed with the secret in "+notify.SecretEnv+" when set")
+	evaluateCmd.Flags().BoolVar(&otlpLogs, "otlp-logs", false, "Export an OTLP log record summarizing the run (scores, regressions, errors) to the OTLP/HTTP logs endpoint, with the headers in "+notify.OTLPHeadersEnv)
+	evaluateCmd.Flags().StringVar(&otlpLogsURL, "otlp-logs-endpoint", "", "OTLP/HTTP logs URL for --otlp-logs (default: "+notify.OTLPLogsEndpointEnv+", or "+notify.OTLPEndpointEnv+" with /v1/logs)")
+	evaluateCmd.Flags().StringVar(&churnFile, "series-churn-file", "", "Series churn report for the series_churn data source (default: "+loaders.SeriesChurnFileName+" next to the job files)")
+	evaluateCmd.Flags().StringVar(&healthFile, "scrape-health-file", "", "Scrape health report for the scrape_health data source (default: "+loaders.ScrapeHealthFileName+" next to the job files)")
 
 	// Single job mode
 	evaluateCmd.Flags().StringVarP(&jobFile, "job-file", "j", "", "Evaluate single job file")
+	evaluateCmd.Flags().StringVar(&cardinalityFile, "cardinality-file", "", "Legacy cardinality report (METRIC|COUNT lines) to evaluate as one job, with --labels-file")
+	evaluateCmd.Flags().StringVar(&labelsFile, "labels-file", "", "Legacy labels report (METRIC|\"label1,label2\" lines) to evaluate as one job, with --cardinality-file")
+	evaluateCmd.Flags().StringVar(&legacyJob, "job-name", "", "Job name of the --cardinality-file and --labels-file pair (default: the cardinality file name without "+loaders.LegacyCardinalitySuffix+")")
 
 	// All jobs mode
 	evaluateCmd.Flags().StringVarP(&jobDir, "job-dir", "d", "", "Evaluate all jobs in directory")
+	evaluateCmd.Flags().BoolVar(&legacyPairs, "legacy-pairs", false, "Evaluate the <job>"+loaders.LegacyCardinalitySuffix+" and <job>"+loaders.LegacyLabelsSuffix+" report pairs in --job-dir instead of job files")
 	evaluateCmd.Flags().Float64Var(&minScore, "min-score", 0.0, "Minimum score threshold (highlight jobs below this)")
 	evaluateCmd.Flags().BoolVar(&showFailures, "show-failures", false, "Show detailed failure information")
 	evaluateCmd.Flags().BoolVar(&showCosts, "show-costs", false, "Display estimated monthly costs")
 	evaluateCmd.Flags().Float64Var(&costPrice, "cost-unit-price", 0.0, "Cost per active series per month (required with --show-costs)")
+	evaluateCmd.Flags().DurationVar(&jobTimeout, "job-timeout", 5*time.Minute, "Maximum time to evaluate a single job file before skipping it (0 disables)")
+	evaluateCmd.Flags().IntVar(&maxJobLines, "max-job-lines", 1000000, "Skip job files with more lines than this (0 disables)")
+	evaluateCmd.Flags().IntVar(&maxLineBytes, "max-line-bytes", loaders.DefaultMaxLineBytes, "Longest line read from job files and reports; a job file with a longer line fails to evaluate")
+	evaluateCmd.Flags().BoolVar(&strictParse, "strict-parse", false, "Reject job files containing malformed lines, reporting each line number and reason")
+	evaluateCmd.Flags().BoolVar(&strictRules, "strict-rules", false, "Reject rules files and included packs containing unknown fields, suggesting the field probably meant")
+	evaluateCmd.Flags().StringVar(&previousFile, "previous-report", "", "JSON report of an earlier --job-dir run; the HTML report shows score changes and newly failed metrics since then")
+	evaluateCmd.Flags().StringVar(&reportURL, "report-url", "", "URL where the HTML report is published; the summary then links each listed job to its section")
+	evaluateCmd.Flags().Float64Var(&alertScoreDrop, "alert-score-drop", 0, "Raise a PagerDuty/Opsgenie incident when the average score dropped more than this many points since --previous-report (0 disables)")
+	evaluateCmd.Flags().Float64Var(&alertPassRateDrop, "alert-pass-rate-drop", 0, "Raise a PagerDuty/Opsgenie incident when the pass rate of a Critical rule over all jobs dropped more than this many percentage points since --previous-report (0 disables)")
+	evaluateCmd.Flags().Float64Var(&failBelow, "fail-below", 0, "Exit with code 2 when the organization score (the job's score with --job-file) is below this (0 disables)")
+	evaluateCmd.Flags().Float64Var(&failBelowJob, "fail-below-job", 0, "Exit with code 2 when any job's score is below this (0 disables)")
+	evaluateCmd.Flags().StringToStringVar(&failBelowJobs, "fail-below-job-for", nil, "Minimum score of specific jobs, overriding --fail-below-job, e.g. checkout=80,search=60")
+	evaluateCmd.Flags().StringVar(&thresholdsFile, "thresholds-file", "", "YAML file of minimum job scores by job name or pattern, overriding --fail-below-job; exits with code 2 when a job is below its minimum")
+	evaluateCmd.Flags().BoolVar(&failOnRegression, "fail-on-regression", false, "Exit with code 3 when the average score or a job's score dropped since the baseline --previous-report")
+	evaluateCmd.Flags().Float64Var(&regressionMargin, "regression-tolerance", 0, "Points a score may drop before --fail-on-regression fails the run")
+	evaluateCmd.Flags().StringVar(&pagerDutyRoutingKey, "pagerduty-routing-key", "", "PagerDuty Events API v2 integration key for regression incidents (or use "+notify.PagerDutyRoutingKeyEnv+" env var)")
+	evaluateCmd.Flags().StringVar(&opsgenieAPIKey, "opsgenie-api-key", "", "Opsgenie API integration key for regression alerts (or use "+notify.OpsgenieAPIKeyEnv+" env var)")
+	evaluateCmd.Flags().StringVar(&opsgenieURL, "opsgenie-url", notify.OpsgenieAlertsURL, "Opsgenie Alert API URL, e.g. https://api.eu.opsgenie.com/v2/alerts for EU accounts")
+	evaluateCmd.Flags().BoolVar(&conformance, "spec-conformance", false, "Report which Instrumentation Score specification rules the rules implement (by spec_rule_ids) and which are not covered, in the text, JSON and HTML reports")
+	evaluateCmd.Flags().StringVar(&conformanceDir, "spec-from", "", "Rules directory of a local checkout of the specification for --spec-conformance (default: download from "+spec.DefaultRepo+")")
+	evaluateCmd.Flags().StringVar(&conformanceRef, "spec-ref", spec.DefaultRef, "Branch, tag or commit of the specification for --spec-conformance")
+	evaluateCmd.Flags().StringVar(&namingPack, "convention-pack", "", "Naming convention pack for jobs without a per-job override: "+strings.Join(engine.ConventionPackNames(), ", ")+" (default: rules file conventions.pack)")
 
 	// S3 mode
+	evaluateCmd.Flags().BoolVar(&encryptOutput, "encrypt", false, "Encrypt the JSON and HTML report files (and their S3 uploads) with AES-256-GCM, using the key in "+encryption.KeyEnv+" or --encrypt-kms-key")
+	evaluateCmd.Flags().StringVar(&encryptKMSKey, "encrypt-kms-key", "", "KMS key ID, ARN or alias generating a data key per report for --encrypt")
 	evaluateCmd.Flags().BoolVar(&evaluateS3Source, "s3-source", false, "Download job metrics from S3")
+	evaluateCmd.Flags().BoolVar(&evaluateS3Stream, "s3-stream", false, "With --s3-source, read job files from S3 as they are evaluated instead of downloading them all first")
+	evaluateCmd.Flags().IntVar(&evaluateS3Workers, "s3-concurrency", 8, "Job files read ahead concurrently with --s3-stream")
+	evaluateCmd.Flags().BoolVar(&evaluateS3Keep, "keep-downloads", false, "Keep the temporary directory --s3-source downloads job files into instead of removing it after evaluation")
 	evaluateCmd.Flags().BoolVar(&evaluateS3Upload, "s3-upload", false, "Upload evaluation results to S3")
 	evaluateCmd.Flags().StringVar(&evaluateS3Bucket, "s3-bucket", "", "S3 bucket name (or use S3_BUCKET env var)")
 	evaluateCmd.Flags().StringVar(&evaluateS3Prefix, "s3-prefix", "", "S3 key prefix/path (or use S3_PREFIX env var)")
 	evaluateCmd.Flags().StringVar(&evaluateS3Region, "s3-region", "eu-west-1", "AWS region (or use AWS_REGION env var)")
 	evaluateCmd.Flags().StringVar(&evaluateS3RunID, "s3-run-id", "", "Run ID for S3 organization (default: auto-generated timestamp)")
+	evaluateCmd.Flags().BoolVar(&azureSource, "azure-source", false, "Download job metrics from Azure Blob Storage, from the blobs under --azure-prefix")
+	evaluateCmd.Flags().BoolVar(&azureUpload, "azure-upload", false, "Upload evaluation results to Azure Blob Storage (credentials: "+storage.AzureKeyEnv+" or "+storage.AzureSASTokenEnv+"; run ID: --s3-run-id)")
+	evaluateCmd.Flags().StringVar(&azureAccount, "azure-account", "", "Azure storage account (or use "+storage.AzureAccountEnv+" env var)")
+	evaluateCmd.Flags().StringVar(&azureContainer, "azure-container", "", "Azure Blob container (or use "+storage.AzureContainerEnv+" env var)")
+	evaluateCmd.Flags().StringVar(&azurePrefix, "azure-prefix", "", "Blob name prefix (or use "+azurePrefixEnv+" env var)")
+}
+
+// runEvaluate runs an evaluation, returning the exit code and the error that failed the run
+// A failed run is reported to the --callback-url webhooks and --otlp-logs.
+func runEvaluate() (int, error) {
+	code, err := evaluate()
+	if err != nil {
+		notifyRunFailed(err)
+		return 1, err
+	}
+	return code, nil
 }
 
-func runEvaluate() {
+// evaluate validates the flags and evaluates the job file or job directory they name
+// It returns instead of exiting, so the downloads it removes when done are removed on failure too.
+func evaluate() (int, error) {
+	var streamed fs.FS // The S3 source with --s3-stream
+	phases.Start("load")
+	loaders.SetMaxLineBytes(maxLineBytes)
+
+	if evaluateS3Source && azureSource {
+		return 0, fmt.Errorf("Error: Cannot specify both --s3-source and --azure-source. Choose one source.")
+	}
+	if evaluateS3Upload && azureUpload {
+		return 0, fmt.Errorf("Error: Cannot specify both --s3-upload and --azure-upload. Choose one destination.")
+	}
+	if azureSource {
+		if evaluateS3Stream {
+			return 0, fmt.Errorf("Error: --s3-stream reads from S3 and cannot be used with --azure-source")
+		}
+		prefix := azurePrefix
+		if prefix == "" {
+			prefix = os.Getenv(azurePrefixEnv)
+		}
+		store, err := azureStore(azureAccount, azureContainer, "")
+		if err != nil {
+			return 0, fmt.Errorf("Error: %w", err)
+		}
+		cleanupStaleDownloads()
+		downloadedDir, err := downloadEvaluationSource(context.Background(), storage.EvaluationDownloadConfig{Prefix: prefix, Store: store})
+		if err != nil {
+			return 0, fmt.Errorf("Error: Failed to download from Azure Blob Storage: %w", err)
+		}
+		jobDir = downloadedDir
+		if evaluateS3Keep {
+			if err := storage.KeepDownload(jobDir); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			fmt.Printf("Downloaded job metrics from Azure Blob Storage to: %s (kept, --keep-downloads)\n\n", jobDir)
+		} else {
+			defer removeDownload(jobDir)
+			fmt.Printf("Downloaded job metrics from Azure Blob Storage to: %s\n\n", jobDir)
+		}
+	}
+
 	// Handle S3 source if specified
 	if evaluateS3Source {
 		bucket := evaluateS3Bucket
@@ -154,27 +449,61 @@ func runEvaluate() {
 			Region: region,
 		}
 
-		downloadedDir, err := storage.DownloadEvaluationSource(config)
-		if err != nil {
-			log.Fatalf("Error: Failed to download from S3: %v", err)
+		if evaluateS3Stream {
+			s3FS, err := storage.NewS3FS(config)
+			if err != nil {
+				return 0, fmt.Errorf("Error: Failed to read from S3: %w", err)
+			}
+			streamed = s3FS
+			fmt.Printf("Streaming job metrics from S3: s3://%s/%s\n\n", bucket, prefix)
+		} else {
+			cleanupStaleDownloads()
+			downloadedDir, err := downloadEvaluationSource(context.Background(), config)
+			if err != nil {
+				return 0, fmt.Errorf("Error: Failed to download from S3: %w", err)
+			}
+			jobDir = downloadedDir
+			if evaluateS3Keep {
+				if err := storage.KeepDownload(jobDir); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+				fmt.Printf("Downloaded job metrics from S3 to: %s (kept, --keep-downloads)\n\n", jobDir)
+			} else {
+				defer removeDownload(jobDir)
+				fmt.Printf("Downloaded job metrics from S3 to: %s\n\n", jobDir)
+			}
 		}
-		jobDir = downloadedDir
-		fmt.Printf("Downloaded job metrics from S3 to: %s\n\n", jobDir)
+	}
+
+	// A legacy report pair is evaluated like a single job file
+	if cardinalityFile != "" || labelsFile != "" {
+		if cardinalityFile == "" || labelsFile == "" {
+			return 0, fmt.Errorf("Error: --cardinality-file and --labels-file must be used together")
+		}
+		if jobFile != "" {
+			return 0, fmt.Errorf("Error: Cannot specify both --job-file and --cardinality-file. Choose one mode.")
+		}
+		jobFile = cardinalityFile
+	} else if legacyJob != "" {
+		return 0, fmt.Errorf("Error: --job-name names the --cardinality-file and --labels-file pair, which is not set")
+	}
+	if legacyPairs && jobDir == "" && streamed == nil {
+		return 0, fmt.Errorf("Error: --legacy-pairs needs --job-dir or --s3-source")
 	}
 
 	// Determine mode
-	if jobFile != "" && jobDir != "" {
-		log.Fatal("Error: Cannot specify both --job-file and --job-dir. Choose one mode.")
+	if jobFile != "" && (jobDir != "" || streamed != nil) {
+		return 0, fmt.Errorf("Error: Cannot specify both --job-file and --job-dir. Choose one mode.")
 	}
 
-	if jobFile == "" && jobDir == "" {
-		log.Fatal("Error: Must specify either --job-file (single job), --job-dir (all jobs), or --s3-source")
+	if jobFile == "" && jobDir == "" && streamed == nil {
+		return 0, fmt.Errorf("Error: Must specify either --job-file (single job), --cardinality-file and --labels-file (single job), --job-dir (all jobs), or --s3-source")
 	}
 
 	// Parse and validate output formats
 	formats := parseOutputFormats(outputFormats)
 	if len(formats) == 0 {
-		log.Fatal("Error: At least one output format must be specified")
+		return 0, fmt.Errorf("Error: At least one output format must be specified")
 	}
 
 	// Validate output file requirements
@@ -182,34 +511,241 @@ func runEvaluate() {
 		switch format {
 		case "json":
 			if jsonFile == "" && !contains(formats, "text") {
-				log.Fatal("Error: --json-file is required when using --output json (or include 'text' for console output)")
+				return 0, fmt.Errorf("Error: --json-file is required when using --output json (or include 'text' for console output)")
 			}
 		case "html":
 			if htmlFile == "" {
-				log.Fatal("Error: --html-file is required when using --output html")
+				return 0, fmt.Errorf("Error: --html-file is required when using --output html")
 			}
 		case "prometheus":
 			if prometheusFile == "" && !contains(formats, "text") {
-				log.Fatal("Error: --prometheus-file is required when using --output prometheus (or include 'text' for console output)")
+				return 0, fmt.Errorf("Error: --prometheus-file is required when using --output prometheus (or include 'text' for console output)")
+			}
+		case "crd":
+			if crdFile == "" && !contains(formats, "text") {
+				return 0, fmt.Errorf("Error: --crd-file is required when using --output crd (or include 'text' for console output)")
+			}
+		case "openslo":
+			if opensloFile == "" && !contains(formats, "text") {
+				return 0, fmt.Errorf("Error: --openslo-file is required when using --output openslo (or include 'text' for console output)")
+			}
+		case "pyrra":
+			if pyrraFile == "" && !contains(formats, "text") {
+				return 0, fmt.Errorf("Error: --pyrra-file is required when using --output pyrra (or include 'text' for console output)")
+			}
+		case "sloth":
+			if slothFile == "" && !contains(formats, "text") {
+				return 0, fmt.Errorf("Error: --sloth-file is required when using --output sloth (or include 'text' for console output)")
+			}
+		case "template":
+			if templateFile == "" {
+				return 0, fmt.Errorf("Error: --template-file is required when using --output template")
+			}
+		case "badge":
+			if badgeFile == "" && !contains(formats, "text") {
+				return 0, fmt.Errorf("Error: --badge-file is required when using --output badge (or include 'text' for console output)")
+			}
+		case "junit":
+			if junitFile == "" && !contains(formats, "text") {
+				return 0, fmt.Errorf("Error: --junit-file is required when using --output junit (or include 'text' for console output)")
+			}
+		case "sarif":
+			if sarifFile == "" && !contains(formats, "text") {
+				return 0, fmt.Errorf("Error: --sarif-file is required when using --output sarif (or include 'text' for console output)")
 			}
 		case "text":
 			// Text can always go to stdout
 		default:
-			log.Fatalf("Error: Unknown output format: %s. Valid formats: text, json, html, prometheus", format)
+			if _, ok := formatters.Lookup(format); !ok {
+				valid := append([]string{"text", "json", "html", "prometheus", "crd", "openslo", "pyrra", "sloth", "template", "badge", "junit", "sarif"}, formatters.Registered()...)
+				return 0, fmt.Errorf("Error: Unknown output format: %s. Valid formats: %s, or an %s<format> plugin on PATH",
+					format, strings.Join(valid, ", "), formatters.PluginPrefix)
+			}
+		}
+	}
+	for format := range pluginFiles {
+		if !contains(formats, format) {
+			return 0, fmt.Errorf("Error: --plugin-file %s is set but %s is not in --output", format, format)
+		}
+	}
+	if _, err := orgscore.Compute(nil, orgWeighting); err != nil {
+		return 0, fmt.Errorf("Error: --org-score-weighting: %w", err)
+	}
+	if orgWeighting == orgscore.WeightTeamSize && ownershipFile == "" {
+		return 0, fmt.Errorf("Error: --org-score-weighting team_size needs --ownership")
+	}
+
+	if conformance && jobFile != "" {
+		return 0, fmt.Errorf("Error: --spec-conformance reports on all jobs and needs --job-dir, --s3-source or --azure-source")
+	}
+
+	runStarted = time.Now()
+	if len(callbackURLs) > 0 {
+		callbacks = notify.NewWebhooks(callbackURLs, os.Getenv(notify.SecretEnv))
+	}
+	if otlpLogs {
+		if otlpLogsURL == "" {
+			otlpLogsURL = notify.OTLPLogsURL(os.Getenv)
 		}
+		if otlpLogsURL == "" {
+			return 0, fmt.Errorf("Error: --otlp-logs requires --otlp-logs-endpoint, %s or %s", notify.OTLPLogsEndpointEnv, notify.OTLPEndpointEnv)
+		}
+		headers, err := notify.ParseOTLPHeaders(os.Getenv(notify.OTLPHeadersEnv))
+		if err != nil {
+			return 0, fmt.Errorf("Error: %w", err)
+		}
+		runLogs = notify.NewOTLPLogs(otlpLogsURL, headers)
+	}
+	if conformance {
+		rules, origin, err := loadSpecRules(conformanceDir, spec.DefaultRepo, conformanceRef)
+		if err != nil {
+			return 0, fmt.Errorf("Error: --spec-conformance: %w", err)
+		}
+		specRuleset, specOrigin = rules, origin
+	}
+
+	l, err := locale.Load(localeTag, localeCatalog)
+	if err != nil {
+		return 0, fmt.Errorf("Error: %w", err)
+	}
+	outputLocale = l
+	formatters.SetLocale(l)
+	if err := formatters.SetMetricNaming(metricPrefix, metricLabels); err != nil {
+		return 0, fmt.Errorf("Error: %w", err)
+	}
+
+	if ownershipFile != "" {
+		mapping, err := ownership.Load(ownershipFile)
+		if err != nil {
+			return 0, fmt.Errorf("Error: %w", err)
+		}
+		if mapping.Directory != nil {
+			for _, err := range mapping.ResolveContacts(os.Getenv(mapping.Directory.SecretEnv())) {
+				log.Printf("Warning: ownership directory: %v", err)
+			}
+		}
+		owners = mapping
+		formatters.SetOwnership(mapping)
+	}
+	formatters.SetHTMLTemplate(htmlTemplate)
+
+	if waiverFile != "" {
+		file, err := waivers.Load(waiverFile)
+		if err != nil {
+			return 0, fmt.Errorf("Error: %w", err)
+		}
+		waived = file
+	}
+
+	if previousFile != "" {
+		run, err := history.LoadPreviousRun(previousFile)
+		if err != nil {
+			return 0, fmt.Errorf("Error: %w", err)
+		}
+		previousRun = run
+	}
+
+	gateThresholds = gate.Thresholds{MinScore: failBelow, MinJobScore: failBelowJob, Regression: failOnRegression, Tolerance: regressionMargin}
+	if len(failBelowJobs) > 0 {
+		gateThresholds.JobMinScores = make(map[string]float64, len(failBelowJobs))
+		for job, value := range failBelowJobs {
+			limit, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return 0, fmt.Errorf("Error: --fail-below-job-for %s: invalid score %q", job, value)
+			}
+			gateThresholds.JobMinScores[job] = limit
+		}
+	}
+	if thresholdsFile != "" {
+		file, err := gate.LoadThresholdsFile(thresholdsFile)
+		if err != nil {
+			return 0, fmt.Errorf("Error: %w", err)
+		}
+		gateThresholds.File = file
+	}
+	if failOnRegression && previousFile == "" {
+		return 0, fmt.Errorf("Error: --fail-on-regression compares with the baseline --previous-report, which is not set")
+	}
+
+	if alertScoreDrop > 0 || alertPassRateDrop > 0 {
+		if previousFile == "" {
+			return 0, fmt.Errorf("Error: --alert-score-drop and --alert-pass-rate-drop compare with --previous-report, which is not set")
+		}
+		alerters = regressionAlerters()
+		if len(alerters) == 0 {
+			return 0, fmt.Errorf("Error: regression alerts need --pagerduty-routing-key or --opsgenie-api-key (or %s / %s)", notify.PagerDutyRoutingKeyEnv, notify.OpsgenieAPIKeyEnv)
+		}
+	}
+
+	if encryptOutput {
+		if encryptKMSKey == "" && os.Getenv(encryption.KeyEnv) == "" {
+			return 0, fmt.Errorf("Error: --encrypt needs --encrypt-kms-key or a base64 256-bit key in %s", encryption.KeyEnv)
+		}
+		e, err := encryption.New(encryptKMSKey, evaluateS3Region)
+		if err != nil {
+			return 0, fmt.Errorf("Error: %w", err)
+		}
+		encrypter = e
+	}
+
+	if decayRuns > 0 {
+		if decayWeight < 1 {
+			return 0, fmt.Errorf("Error: --decay-weight must be at least 1")
+		}
+		store, err := history.OpenStore(historyDB)
+		if err != nil {
+			return 0, fmt.Errorf("Error: %w", err)
+		}
+		historyStore = store
 	}
 
 	// Validate cost flags
 	if showCosts && costPrice <= 0 {
-		log.Fatal("Error: --cost-unit-price must be specified and greater than 0 when --show-costs is enabled")
+		return 0, fmt.Errorf("Error: --cost-unit-price must be specified and greater than 0 when --show-costs is enabled")
 	}
 
 	// Route to appropriate handler
+	var report AllJobsReport
 	if jobFile != "" {
-		runSingleJobEvaluation(formats)
+		report, err = runSingleJobEvaluation(formats)
 	} else {
-		runAllJobsEvaluation(formats)
+		src := jobSource{fsys: streamed, dir: jobDir, legacyPairs: legacyPairs, strictParse: strictParse, maxLines: maxJobLines, timeout: jobTimeout,
+			opts: scoreOptions(), owners: owners, weighting: orgWeighting}
+		if src.fsys == nil {
+			src.fsys = os.DirFS(jobDir)
+		}
+		report, err = runAllJobsEvaluation(src, formats)
 	}
+
+	if historyStore != nil {
+		historyStore.Close()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return enforceGate(report), nil
+}
+
+// enforceGate returns the gate exit code when the run does not meet the --fail-below
+// thresholds, or regressed since --previous-report with --fail-on-regression, and 0 otherwise
+func enforceGate(report AllJobsReport) int {
+	run := gate.Run{Score: report.AverageScore, AverageScore: report.AverageScore}
+	if report.OrgScore != nil {
+		run.Score = report.OrgScore.Score
+	}
+	for _, job := range report.Jobs {
+		run.Jobs = append(run.Jobs, gate.JobScore{Name: job.JobName, Score: job.Score})
+	}
+
+	violations := gate.Check(run, gateThresholds, previousRun)
+	if len(violations) == 0 {
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "\n❌ Quality gate failed:\n")
+	for _, violation := range violations {
+		fmt.Fprintf(os.Stderr, "  - %s\n", violation)
+	}
+	return gate.ExitCode(violations)
 }
 
 // parseOutputFormats parses comma-separated output formats
@@ -239,77 +775,112 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-// runSingleJobEvaluation evaluates a single job
-func runSingleJobEvaluation(formats []string) {
+// runSingleJobEvaluation evaluates a single job and returns its report as a one-job run
+func runSingleJobEvaluation(formats []string) (AllJobsReport, error) {
 	// Load job metrics
-	jobData, err := loaders.LoadJobMetricReport(jobFile)
+	jobData, parseWarnings, err := loadSingleJob()
 	if err != nil {
-		log.Fatalf("Error loading job metrics from %s: %v", jobFile, err)
+		return AllJobsReport{}, fmt.Errorf("Error loading job metrics from %s: %w", jobFile, err)
+	}
+	if err := checkParseWarnings(jobFile, parseWarnings, strictParse); err != nil {
+		return AllJobsReport{}, fmt.Errorf("Error: %w", err)
+	}
+	for _, warning := range parseWarnings {
+		log.Printf("Warning: skipped malformed record at %s", warning)
 	}
 
 	if len(jobData) == 0 {
-		log.Fatalf("No metrics found in %s", jobFile)
+		return AllJobsReport{}, fmt.Errorf("No metrics found in %s", jobFile)
 	}
 
 	// Get job name from first entry
 	jobName := jobData[0].Job
 
 	// Initialize rule engine
-	ruleEngine, err := engine.NewRuleEngine(rulesConfig)
+	ruleEngine, err := loadRuleEngine()
 	if err != nil {
-		log.Fatalf("Error initializing rule engine: %v\n\nPlease ensure rules_config.yaml exists", err)
+		return AllJobsReport{}, fmt.Errorf("Error initializing rule engine: %v\n\nPlease ensure rules_config.yaml exists", err)
+	}
+	dirFS := os.DirFS(filepath.Dir(jobFile))
+	if err := loadScrapeHealth(ruleEngine, dirFS); err != nil {
+		return AllJobsReport{}, err
+	}
+	if err := loadSeriesChurn(ruleEngine, dirFS); err != nil {
+		return AllJobsReport{}, err
+	}
+	if err := loadMetricUsage(ruleEngine, dirFS); err != nil {
+		return AllJobsReport{}, err
+	}
+	serviceVersion := loadServiceVersions(dirFS)[jobName]
+	for _, warning := range expiredWaiverWarnings() {
+		log.Printf("Warning: %s", warning)
 	}
-
-	// Convert to evaluation format
-	cardinalityData := loaders.ConvertJobMetricToCardinality(jobData)
-	labelsData := loaders.ConvertJobMetricToLabels(jobData)
 
 	// Evaluate
-	results, err := ruleEngine.EvaluateWithData(cardinalityData, labelsData)
+	phases.Start("evaluate")
+	scored, err := score.EvaluateJob(context.Background(), ruleEngine, jobName, jobData, score.Options{Adjust: applyWaivers})
 	if err != nil {
-		log.Fatalf("Error evaluating rules: %v", err)
+		return AllJobsReport{}, fmt.Errorf("Error evaluating rules: %w", err)
 	}
+	logScoreWarnings(scored)
+	results := scored.RuleResults
+	source := jobRunSource(dirFS, filepath.Base(jobFile))
+	applyScoreDecay(jobName, source, results, scored.Evaluated)
 
 	// Calculate score
-	score := engine.CalculateInstrumentationScore(results)
+	scoreBreakdown := engine.ExplainScore(results)
+	jobScore := scoreBreakdown.Score
 
 	// Calculate cost if requested
 	var totalCardinality int64
 	var estimatedCost float64
 	if showCosts && costPrice > 0 {
-		for _, metric := range cardinalityData {
-			totalCardinality += metric.Count
-		}
+		totalCardinality = scored.TotalCardinality
 		estimatedCost = float64(totalCardinality) * costPrice
 	}
 
+	result := jobScoreResult(scored)
+	result.ServiceVersion = serviceVersion
+	result.TotalCardinality = totalCardinality
+	result.EstimatedCost = estimatedCost
+	result.Score = jobScore
+	result.ScoreBreakdown = &scoreBreakdown
+	result.UnusedMetrics = unusedMetrics(ruleEngine, scored.Evaluated)
+	result.Remediation = remediationPriorities(ruleEngine, results, scored.Evaluated)
+	result.Config = evaluationConfig(ruleEngine, dirFS)
+	result.Fingerprint = jobFingerprint(jobData)
+	result.source = source
+	result.malformed = loaders.SkippedRecords(parseWarnings)
+	result.Confidence = scoreConfidence(result, loadCollectionGaps(dirFS))
+	confidence := result.Confidence.Level
+
 	// Generate outputs for each requested format
+	phases.Start("format")
 	for _, format := range formats {
+		var err error
 		switch format {
 		case "text":
 			fmt.Printf("\n=== Instrumentation Score Report for Job: %s ===\n\n", jobName)
-			fmt.Printf("Total Metrics: %d\n", len(jobData))
+			if serviceVersion != "" {
+				fmt.Printf("Service Version: %s\n", serviceVersion)
+			}
+			fmt.Printf("Total Metrics: %s\n", outputLocale.Int(int64(result.TotalMetrics)))
 			if showCosts {
 				fmt.Printf("Total Cardinality: %d series\n", totalCardinality)
 				fmt.Printf("Estimated Cost: $%.2f/month\n", estimatedCost)
 			}
-			fmt.Printf("Instrumentation Score: %.2f%%\n\n", score)
-			formatters.Text(jobName, score, results)
+			fmt.Printf("Instrumentation Score: %s%%\n", outputLocale.Float(jobScore, 2))
+			fmt.Printf("Score Confidence: %s\n\n", confidenceSummary(result.Confidence))
+			formatters.Text(jobName, jobScore, results)
+			printUnusedMetrics(result.UnusedMetrics, len(result.UnusedMetrics))
+			printRemediation(result.Remediation, 10)
 
 		case "json":
-			result := JobScoreResult{
-				JobName:          jobName,
-				TotalMetrics:     len(jobData),
-				TotalCardinality: totalCardinality,
-				EstimatedCost:    estimatedCost,
-				Score:            score,
-				RuleResults:      results,
-			}
 			data, _ := json.MarshalIndent(result, "", "  ")
 
 			if jsonFile != "" {
 				if err := os.WriteFile(jsonFile, data, 0600); err != nil {
-					log.Fatalf("Error writing JSON file: %v", err)
+					return AllJobsReport{}, fmt.Errorf("Error writing JSON file: %w", err)
 				}
 				fmt.Printf("JSON report saved to %s\n", jsonFile)
 			} else {
@@ -317,7 +888,9 @@ func runSingleJobEvaluation(formats []string) {
 			}
 
 		case "html":
-			formatters.HTML(jobName, score, results, htmlFile)
+			if err := formatters.HTMLWithConfidence(jobName, serviceVersion, confidence, jobScore, results, htmlFile); err != nil {
+				return AllJobsReport{}, err
+			}
 			fmt.Printf("HTML report saved to %s\n", htmlFile)
 
 		case "prometheus":
@@ -325,43 +898,143 @@ func runSingleJobEvaluation(formats []string) {
 				// Write to file
 				file, err := os.OpenFile(prometheusFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 				if err != nil {
-					log.Fatalf("Error creating prometheus file: %v", err)
+					return AllJobsReport{}, fmt.Errorf("Error creating prometheus file: %w", err)
 				}
 				defer file.Close()
 
 				// Redirect stdout temporarily
 				oldStdout := os.Stdout
 				os.Stdout = file
-				formatters.PrometheusMetrics(jobName, score, results)
+				formatters.PrometheusMetricsWithConfidence(jobName, confidence, jobScore, results)
 				os.Stdout = oldStdout
 
 				fmt.Printf("Prometheus metrics saved to %s\n", prometheusFile)
 			} else {
-				formatters.PrometheusMetrics(jobName, score, results)
+				formatters.PrometheusMetricsWithConfidence(jobName, confidence, jobScore, results)
+			}
+
+		case "crd":
+			err = writeCRDManifests([]formatters.JobScoreData{{
+				JobName:          jobName,
+				TotalMetrics:     result.TotalMetrics,
+				TotalCardinality: totalCardinality,
+				Score:            jobScore,
+				RuleResults:      results,
+				Confidence:       confidence,
+			}}, time.Now().Format(time.RFC3339))
+
+		case "openslo", "pyrra", "sloth":
+			err = writeSLODocuments(format, []formatters.JobScoreData{{JobName: jobName, Score: jobScore}})
+
+		case "template":
+			err = writeTemplateOutput(result)
+
+		case "badge":
+			err = writeBadge("instrumentation score", jobScore)
+
+		case "junit":
+			err = writeJUnit([]formatters.JobScoreData{{JobName: jobName, Score: jobScore, RuleResults: results, Confidence: confidence}}, nil, time.Now().Format(time.RFC3339))
+
+		case "sarif":
+			source := jobFile
+			if source == "" {
+				source = cardinalityFile
 			}
+			err = writeSARIF([]formatters.JobScoreData{{JobName: jobName, Score: jobScore, RuleResults: results, SourceFile: filepath.ToSlash(source)}})
+
+		default:
+			err = writePluginOutput(format, result)
+		}
+		if err != nil {
+			return AllJobsReport{}, err
+		}
+	}
+	if err := encryptReports(formats); err != nil {
+		return AllJobsReport{}, err
+	}
+	if remoteWrite.URL != "" {
+		err := pushRemoteWrite([]formatters.JobScoreData{{JobName: jobName, TotalMetrics: result.TotalMetrics, TotalCardinality: result.TotalCardinality,
+			EstimatedCost: result.EstimatedCost, Score: jobScore, RuleResults: results}}, nil, time.Now())
+		if err != nil {
+			return AllJobsReport{}, err
+		}
+	}
+
+	if recordRuns || historyStore != nil {
+		// Recorded with the cardinality --show-costs leaves out of the report
+		recorded := result
+		recorded.TotalCardinality = scored.TotalCardinality
+		recordHistory(ruleEngine, AllJobsReport{Timestamp: time.Now().Format(time.RFC3339), AverageScore: jobScore, Jobs: []JobScoreResult{recorded}})
+	}
+	phases.Stop()
+	fmt.Printf("\n⏱  Timing: %s\n", phases.Summary())
+
+	run := AllJobsReport{
+		Timestamp:        time.Now().Format(time.RFC3339),
+		TotalJobs:        1,
+		AverageScore:     jobScore,
+		TotalCardinality: totalCardinality,
+		Jobs:             []JobScoreResult{{JobName: jobName, Score: jobScore}},
+	}
+	notifyRunCompleted(run)
+	return run, nil
+}
+
+// writeCRDManifests writes InstrumentationScore manifests to --crd-file, or stdout
+func writeCRDManifests(jobs []formatters.JobScoreData, timestamp string) error {
+	manifests, err := formatters.CRDManifests(jobs, crdNamespace, minScore, timestamp)
+	if err != nil {
+		return fmt.Errorf("Error generating CRD manifests: %w", err)
+	}
+
+	if crdFile != "" {
+		if err := os.WriteFile(crdFile, []byte(manifests), 0600); err != nil {
+			return fmt.Errorf("Error writing CRD file: %w", err)
 		}
+		fmt.Printf("InstrumentationScore manifests saved to %s\n", crdFile)
+	} else {
+		fmt.Print(manifests)
 	}
+	return nil
 }
 
-// runAllJobsEvaluation evaluates all jobs in a directory
-func runAllJobsEvaluation(formats []string) {
-	// Find all job files
-	files, err := filepath.Glob(filepath.Join(jobDir, "*.txt"))
+// runAllJobsEvaluation evaluates all jobs in a directory and returns the run's report
+func runAllJobsEvaluation(src jobSource, formats []string) (AllJobsReport, error) {
+	// Find all job files, or the cardinality reports of legacy report pairs
+	pattern := "*.txt"
+	if legacyPairs {
+		pattern = "*" + loaders.LegacyCardinalitySuffix
+	}
+	files, err := fs.Glob(src.fsys, pattern)
 	if err != nil {
-		log.Fatalf("Error reading directory %s: %v", jobDir, err)
+		return AllJobsReport{}, fmt.Errorf("Error reading directory %s: %w", jobSourceName(), err)
 	}
 
 	if len(files) == 0 {
-		log.Fatalf("No job metric files found in %s", jobDir)
+		return AllJobsReport{}, fmt.Errorf("No job metric files found in %s", jobSourceName())
+	}
+	if s3FS, ok := src.fsys.(*storage.S3FS); ok {
+		s3FS.Prefetch(files, evaluateS3Workers)
 	}
 
 	fmt.Printf("Found %d job files to evaluate...\n", len(files))
 
 	// Initialize rule engine
-	ruleEngine, err := engine.NewRuleEngine(rulesConfig)
+	ruleEngine, err := loadRuleEngine()
 	if err != nil {
-		log.Fatalf("Error initializing rule engine: %v\n\nPlease ensure rules_config.yaml exists", err)
+		return AllJobsReport{}, fmt.Errorf("Error initializing rule engine: %v\n\nPlease ensure rules_config.yaml exists", err)
+	}
+	if err := loadScrapeHealth(ruleEngine, src.fsys); err != nil {
+		return AllJobsReport{}, err
+	}
+	if err := loadSeriesChurn(ruleEngine, src.fsys); err != nil {
+		return AllJobsReport{}, err
+	}
+	if err := loadMetricUsage(ruleEngine, src.fsys); err != nil {
+		return AllJobsReport{}, err
 	}
+	serviceVersions := loadServiceVersions(src.fsys)
+	gaps := loadCollectionGaps(src.fsys)
 
 	// Evaluate each job
 	var allResults []JobScoreResult
@@ -369,35 +1042,79 @@ func runAllJobsEvaluation(formats []string) {
 	var totalCost float64
 	var totalCardinality int64
 	var excludedCount int
+	var skipped []formatters.SkippedJob
+	warnings := expiredWaiverWarnings()
 
+	phases.Start("evaluate")
+	bar := progress.NewBar("Evaluating jobs", len(files))
 	for i, file := range files {
-		fmt.Printf("\rEvaluating jobs: %d/%d", i+1, len(files))
+		if i > 0 {
+			bar.Done()
+			src.releaseJobFile(files[i-1])
+		}
+		bar.Start(strings.TrimSuffix(path.Base(file), ".txt"))
 
-		result, err := evaluateSingleJobFile(file, ruleEngine)
+		result, err := scoreJobFile(src, file, ruleEngine)
+		var skip *skippedJobError
+		if errors.As(err, &skip) {
+			log.Printf("\nWarning: skipped %s: %s", filepath.Base(file), skip.reason)
+			skipped = append(skipped, formatters.SkippedJob{File: filepath.Base(file), Reason: skip.reason})
+			continue
+		}
 		if err != nil {
 			// Check if it's an exclusion error
-			if strings.Contains(err.Error(), "is excluded from evaluation") || strings.Contains(err.Error(), "no metrics remaining after exclusion filtering") {
+			if errors.Is(err, score.ErrExcluded) {
 				excludedCount++
 			} else {
 				log.Printf("\nWarning: Failed to evaluate %s: %v", filepath.Base(file), err)
+				skipped = append(skipped, formatters.SkippedJob{File: filepath.Base(file), Reason: err.Error()})
 			}
 			continue
 		}
 
+		result.ServiceVersion = serviceVersions[result.JobName]
+		result.Confidence = scoreConfidence(result, gaps)
 		allResults = append(allResults, result)
-		totalScore += result.Score
-		totalCost += result.EstimatedCost
-		totalCardinality += result.TotalCardinality
 	}
+	bar.Done()
+	src.releaseJobFile(files[len(files)-1])
+	bar.Finish()
+	phases.Start("format")
 
-	fmt.Printf("\n\n")
+	fmt.Println()
 
 	if excludedCount > 0 {
-		fmt.Printf("ℹ️  Excluded %d job(s) based on exclusion_list in rules_config.yaml\n\n", excludedCount)
+		if len(ruleEngine.Inclusions()) > 0 {
+			fmt.Printf("ℹ️  Excluded %d job(s) based on inclusion_list and exclusion_list in rules_config.yaml\n\n", excludedCount)
+		} else {
+			fmt.Printf("ℹ️  Excluded %d job(s) based on exclusion_list in rules_config.yaml\n\n", excludedCount)
+		}
 	}
 
 	if len(allResults) == 0 {
-		log.Fatal("No jobs were successfully evaluated")
+		return AllJobsReport{}, fmt.Errorf("No jobs were successfully evaluated")
+	}
+	// Decay once renames are linked, so a renamed job keeps the streaks of its earlier name
+	linkRenamedJobs(allResults)
+	for i := range allResults {
+		result := &allResults[i]
+		if result.decayMetrics != nil {
+			historyName := result.JobName
+			if result.RenamedFrom != "" {
+				historyName = result.RenamedFrom
+			}
+			applyScoreDecay(historyName, result.source, result.RuleResults, result.decayMetrics)
+			breakdown := engine.ExplainScore(result.RuleResults)
+			result.ScoreBreakdown, result.Score = &breakdown, breakdown.Score
+			result.decayMetrics = nil
+		}
+		totalScore += result.Score
+		totalCost += result.EstimatedCost
+		totalCardinality += result.TotalCardinality
+	}
+	if len(skipped) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d of %d job file(s) could not be evaluated and are missing from the totals (see skipped_jobs)",
+			len(skipped), len(skipped)+len(allResults)))
 	}
 
 	// Calculate average score
@@ -411,10 +1128,25 @@ func runAllJobsEvaluation(formats []string) {
 		TotalCost:        totalCost,
 		TotalCardinality: totalCardinality,
 		Jobs:             allResults,
+		Warnings:         warnings,
+		SkippedJobs:      skipped,
+		Config:           evaluationConfig(ruleEngine, src.fsys),
+		owners:           src.owners,
+	}
+	report.OrgScore, err = organizationScore(allResults, src.owners, src.weighting)
+	if err != nil {
+		return AllJobsReport{}, fmt.Errorf("Error: %w", err)
+	}
+	report.Selector = analysisSelector(report.Config)
+	if conformance {
+		report.SpecConformance = spec.CheckConformance(specOrigin, specRuleset, ruleEngine.Rules())
+		formatters.SetConformance(report.SpecConformance)
 	}
+	applySelectorLabels(report.Selector)
 
 	// Generate outputs for each requested format
 	for _, format := range formats {
+		var err error
 		switch format {
 		case "text":
 			printSummary(report)
@@ -422,12 +1154,12 @@ func runAllJobsEvaluation(formats []string) {
 		case "json":
 			data, err := json.MarshalIndent(report, "", "  ")
 			if err != nil {
-				log.Fatalf("Error marshaling JSON: %v", err)
+				return AllJobsReport{}, fmt.Errorf("Error marshaling JSON: %w", err)
 			}
 
 			if jsonFile != "" {
 				if err := os.WriteFile(jsonFile, data, 0600); err != nil {
-					log.Fatalf("Error writing JSON file: %v", err)
+					return AllJobsReport{}, fmt.Errorf("Error writing JSON file: %w", err)
 				}
 				fmt.Printf("JSON report saved to %s\n", jsonFile)
 			} else {
@@ -435,39 +1167,72 @@ func runAllJobsEvaluation(formats []string) {
 			}
 
 		case "html":
-			generateHTMLReport(report, files)
+			err = generateHTMLReport(src, report)
 
 		case "prometheus":
-			// Convert JobScoreResult to formatters.JobScoreData
-			var jobsData []formatters.JobScoreData
-			for _, job := range allResults {
-				jobsData = append(jobsData, formatters.JobScoreData{
-					JobName:          job.JobName,
-					TotalMetrics:     job.TotalMetrics,
-					TotalCardinality: job.TotalCardinality,
-					EstimatedCost:    job.EstimatedCost,
-					Score:            job.Score,
-					RuleResults:      job.RuleResults,
-				})
-			}
-
-			// Generate SLI metrics for Cortex.io SLO tracking
-			promMetrics := formatters.PrometheusMetricsWithSLO(jobsData)
+			// Generate SLI metrics for Cortex.io SLO tracking, the pass/fail gauge Pyrra and Sloth SLOs count,
+			// and per-rule and per-category breakdowns
+			promMetrics := prometheusMetrics(jobScoreData(allResults), report.OrgScore)
 
 			if prometheusFile != "" {
 				if err := os.WriteFile(prometheusFile, []byte(promMetrics), 0600); err != nil {
-					log.Fatalf("Error writing Prometheus file: %v", err)
+					return AllJobsReport{}, fmt.Errorf("Error writing Prometheus file: %w", err)
 				}
 				fmt.Printf("Prometheus metrics saved to %s\n", prometheusFile)
 			} else {
 				fmt.Print(promMetrics)
 			}
+
+		case "crd":
+			err = writeCRDManifests(jobScoreData(allResults), report.Timestamp)
+
+		case "openslo", "pyrra", "sloth":
+			err = writeSLODocuments(format, jobScoreData(allResults))
+
+		case "template":
+			err = writeTemplateOutput(report)
+
+		case "badge":
+			err = writeBadge("org instrumentation score", report.OrgScore.Score)
+
+		case "junit":
+			err = writeJUnit(jobScoreData(allResults), report.SkippedJobs, report.Timestamp)
+
+		case "sarif":
+			err = writeSARIF(jobScoreData(allResults))
+
+		default:
+			err = writePluginOutput(format, report)
+		}
+		if err != nil {
+			return AllJobsReport{}, err
+		}
+	}
+	if err := encryptReports(formats); err != nil {
+		return AllJobsReport{}, err
+	}
+	if remoteWrite.URL != "" {
+		timestamp, err := time.Parse(time.RFC3339, report.Timestamp)
+		if err != nil {
+			timestamp = time.Now()
 		}
+		if err := pushRemoteWrite(jobScoreData(allResults), report.OrgScore, timestamp); err != nil {
+			return AllJobsReport{}, err
+		}
+	}
+	if recordRuns || historyStore != nil {
+		recordHistory(ruleEngine, report)
 	}
 
-	// Upload to S3 if requested
-	if evaluateS3Upload {
-		fmt.Println("\nUploading evaluation results to S3...")
+	// Upload to S3, or Azure Blob Storage, if requested
+	if evaluateS3Upload || azureUpload {
+		if azureUpload {
+			fmt.Println("\nUploading evaluation results to Azure Blob Storage...")
+		} else {
+			fmt.Println("\nUploading evaluation results to S3...")
+		}
+		formatTimings := phases.Seconds()
+		phases.Start("upload")
 
 		bucket := evaluateS3Bucket
 		if bucket == "" {
@@ -489,19 +1254,34 @@ func runAllJobsEvaluation(formats []string) {
 
 		// Create manifest
 		manifest := &storage.EvaluationManifest{
-			Timestamp:        report.Timestamp,
-			TotalJobs:        report.TotalJobs,
-			AverageScore:     report.AverageScore,
-			TotalCardinality: report.TotalCardinality,
-			TotalCost:        report.TotalCost,
-			RulesConfig:      rulesConfig,
-			OutputFormats:    strings.Join(formats, ","),
+			Timestamp:         report.Timestamp,
+			TotalJobs:         report.TotalJobs,
+			AverageScore:      report.AverageScore,
+			OrgScore:          report.OrgScore.Score,
+			OrgScoreWeighting: report.OrgScore.Weighting,
+			TotalCardinality:  report.TotalCardinality,
+			TotalCost:         report.TotalCost,
+			RulesConfig:       rulesDisplayName(rulesConfig),
+			OutputFormats:     strings.Join(formats, ","),
+			Config:            report.Config,
+			Timings:           formatTimings,
+		}
+		for _, job := range report.Jobs {
+			manifest.JobScores = append(manifest.JobScores, job.Score)
+		}
+		distribution := orgscore.Distribute(manifest.JobScores)
+		manifest.ScoreDistribution = &distribution
+		if encrypter != nil {
+			manifest.Encryption = encrypter.Description()
 		}
 
 		// Determine source type
 		if evaluateS3Source {
 			manifest.SourceType = "s3"
 			manifest.SourcePath = fmt.Sprintf("s3://%s/%s", bucket, evaluateS3Prefix)
+		} else if azureSource {
+			manifest.SourceType = "azure"
+			manifest.SourcePath = azureSourcePath()
 		} else if jobDir != "" {
 			manifest.SourceType = "local_directory"
 			manifest.SourcePath = jobDir
@@ -518,121 +1298,1257 @@ func runAllJobsEvaluation(formats []string) {
 			JSONFile:       jsonFile,
 			HTMLFile:       htmlFile,
 			PrometheusFile: prometheusFile,
+			CRDFile:        crdFile,
+			OpenSLOFile:    opensloFile,
+			PyrraFile:      pyrraFile,
+			SlothFile:      slothFile,
+			BadgeFile:      badgeFile,
 			OutputFormats:  formats,
 			Manifest:       manifest,
 		}
+		if azureUpload {
+			prefix := azurePrefix
+			if prefix == "" {
+				prefix = os.Getenv(azurePrefixEnv)
+			}
+			store, err := azureStore(azureAccount, azureContainer, prefix)
+			if err != nil {
+				return AllJobsReport{}, fmt.Errorf("Error: %w", err)
+			}
+			config.Store = store
+		}
 
-		if err := storage.UploadEvaluationResults(config); err != nil {
-			log.Fatalf("Error: Failed to upload to S3: %v", err)
+		if err := storage.UploadEvaluationResults(context.Background(), config); err != nil {
+			return AllJobsReport{}, fmt.Errorf("Error: Failed to upload evaluation results: %w", err)
 		}
 	}
+	phases.Stop()
+	fmt.Printf("\n⏱  Timing: %s\n", phases.Summary())
+
+	notifyRunCompleted(report)
+	alertRegressions(report)
+	return report, nil
 }
 
-func evaluateSingleJobFile(filePath string, ruleEngine *engine.RuleEngine) (JobScoreResult, error) {
-	// Load job metrics
-	jobData, err := loaders.LoadJobMetricReport(filePath)
+// organizationScore weighs the job scores into the organization score as weighting says, see
+// --org-score-weighting. Team sizes come from the directory groups of the mapping.
+func organizationScore(results []JobScoreResult, mapping *ownership.Mapping, weighting string) (*orgscore.Score, error) {
+	jobs := make([]orgscore.Job, 0, len(results))
+	for _, result := range results {
+		job := orgscore.Job{Score: result.Score, Cardinality: result.TotalCardinality}
+		if owner, owned := mapping.OwnerOf(result.JobName); owned {
+			job.Team = owner.Team
+			job.TeamSize = len(owner.Contacts)
+		}
+		jobs = append(jobs, job)
+	}
+	score, err := orgscore.Compute(jobs, weighting)
 	if err != nil {
-		return JobScoreResult{}, err
+		return nil, err
 	}
+	return &score, nil
+}
 
-	if len(jobData) == 0 {
-		return JobScoreResult{}, fmt.Errorf("no metrics found")
+// writeJUnit writes jobs as a JUnit XML report to --junit-file, or stdout
+func writeJUnit(jobs []formatters.JobScoreData, skipped []formatters.SkippedJob, timestamp string) error {
+	report, err := formatters.JUnit(jobs, skipped, timestamp)
+	if err != nil {
+		return fmt.Errorf("Error generating JUnit report: %w", err)
 	}
-
-	jobName := jobData[0].Job
-
-	// Check if job is completely excluded
-	if ruleEngine.IsJobExcluded(jobName) {
-		return JobScoreResult{}, fmt.Errorf("job %s is excluded from evaluation", jobName)
+	if junitFile == "" {
+		fmt.Print(report)
+		return nil
 	}
-
-	// Convert formats
-	cardinalityData := loaders.ConvertJobMetricToCardinality(jobData)
-	labelsData := loaders.ConvertJobMetricToLabels(jobData)
-
-	// Filter out excluded metrics
-	cardinalityData, labelsData = ruleEngine.FilterExcludedMetrics(jobName, cardinalityData, labelsData)
-
-	// Check if any metrics remain after filtering
-	if len(cardinalityData) == 0 && len(labelsData) == 0 {
-		return JobScoreResult{}, fmt.Errorf("no metrics remaining after exclusion filtering for job %s", jobName)
+	if err := os.WriteFile(junitFile, []byte(report), 0600); err != nil {
+		return fmt.Errorf("Error writing JUnit file: %w", err)
 	}
+	fmt.Printf("JUnit report saved to %s\n", junitFile)
+	return nil
+}
 
-	// Calculate total cardinality
-	var totalCardinality int64
-	for _, metric := range cardinalityData {
-		totalCardinality += metric.Count
+// prometheusMetrics renders the score, pass/fail and per-rule metrics of jobs, and the
+// organization score when org is set, in the Prometheus text format
+func prometheusMetrics(jobs []formatters.JobScoreData, org *orgscore.Score) string {
+	metrics := formatters.PrometheusMetricsWithSLO(jobs) + formatters.PrometheusPassingMetrics(jobs, sloTarget) +
+		formatters.PrometheusRuleMetrics(jobs)
+	if org != nil {
+		metrics += formatters.PrometheusOrganizationScore(org.Score, org.Weighting)
 	}
+	return metrics
+}
 
-	// Calculate cost if enabled
-	var estimatedCost float64
-	if showCosts && costPrice > 0 {
-		estimatedCost = float64(totalCardinality) * costPrice
+// pushRemoteWrite pushes the metrics of jobs to --remote-write-url, all samples timestamped at
+func pushRemoteWrite(jobs []formatters.JobScoreData, org *orgscore.Score, at time.Time) error {
+	series, err := remotewrite.ParseText(prometheusMetrics(jobs, org))
+	if err != nil {
+		return fmt.Errorf("Error preparing remote write: %w", err)
+	}
+	client := remotewrite.NewClient(remoteWrite.URL)
+	client.Username, client.Password, client.BearerToken = remoteWrite.Username, remoteWrite.Password, remoteWrite.BearerToken
+	client.Headers = remoteWrite.Headers
+	if client.Password == "" {
+		client.Password = os.Getenv(remotewrite.PasswordEnv)
+	}
+	if client.BearerToken == "" {
+		client.BearerToken = os.Getenv(remotewrite.BearerTokenEnv)
 	}
+	if err := client.Push(series, at); err != nil {
+		return fmt.Errorf("Error: %w", err)
+	}
+	fmt.Printf("Pushed %d series to %s\n", len(series), remoteWrite.URL)
+	return nil
+}
 
-	// Evaluate
-	results, err := ruleEngine.EvaluateWithData(cardinalityData, labelsData)
+// writeSARIF writes the failing validators of jobs as SARIF to --sarif-file, or stdout
+func writeSARIF(jobs []formatters.JobScoreData) error {
+	report, err := formatters.SARIF(jobs, Version)
 	if err != nil {
-		return JobScoreResult{}, err
+		return fmt.Errorf("Error generating SARIF report: %w", err)
 	}
-
-	// Calculate score
-	score := engine.CalculateInstrumentationScore(results)
-
-	// Collect failed metrics
-	var failedMetrics []string
-	failedMetricsMap := make(map[string]bool)
-	for _, result := range results {
-		for metricName := range result.FailedMetrics {
-			if !failedMetricsMap[metricName] {
-				failedMetrics = append(failedMetrics, metricName)
-				failedMetricsMap[metricName] = true
-			}
-		}
+	if sarifFile == "" {
+		fmt.Print(report)
+		return nil
 	}
-
-	// Create breakdown
-	breakdown := make(map[string]int)
-	for _, result := range results {
-		breakdown[result.RuleID] = result.PassedChecks
+	if err := os.WriteFile(sarifFile, []byte(report), 0600); err != nil {
+		return fmt.Errorf("Error writing SARIF file: %w", err)
 	}
+	fmt.Printf("SARIF report saved to %s\n", sarifFile)
+	return nil
+}
+
+// writeBadge writes an SVG badge showing score to --badge-file, or stdout
+func writeBadge(label string, score float64) error {
+	badge := formatters.Badge(label, score)
+	if badgeFile == "" {
+		fmt.Print(badge)
+		return nil
+	}
+	if err := os.WriteFile(badgeFile, []byte(badge), 0600); err != nil {
+		return fmt.Errorf("Error writing badge file: %w", err)
+	}
+	fmt.Printf("Score badge saved to %s\n", badgeFile)
+	return nil
+}
+
+// recordHistory adds the run to --history-db; failing to record it does not fail the run
+func recordHistory(ruleEngine *engine.RuleEngine, report AllJobsReport) {
+	timestamp, err := time.Parse(time.RFC3339, report.Timestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+	run := history.Run{Timestamp: timestamp, RulesHash: ruleEngine.RulesHash(), AverageScore: report.AverageScore}
+	for _, job := range report.Jobs {
+		run.Jobs = append(run.Jobs, history.JobRun{
+			JobName:          job.JobName,
+			Score:            job.Score,
+			TotalMetrics:     job.TotalMetrics,
+			TotalCardinality: job.TotalCardinality,
+			Rules:            job.RuleResults,
+			Fingerprint:      job.Fingerprint,
+			Source:           job.source,
+			RenamedFrom:      job.RenamedFrom,
+		})
+	}
+
+	store := historyStore
+	if store == nil {
+		opened, err := history.OpenStore(historyDB)
+		if err != nil {
+			log.Printf("Warning: run not recorded: %v", err)
+			return
+		}
+		defer opened.Close()
+		store = opened
+	}
+	runID, err := store.Record(run)
+	if err != nil {
+		log.Printf("Warning: run not recorded in %s: %v", historyDB, err)
+		return
+	}
+	fmt.Printf("Run %d recorded in %s\n", runID, historyDB)
+}
+
+// regressionAlerters returns the on-call systems regression incidents are raised in
+func regressionAlerters() []notify.Alerter {
+	var configured []notify.Alerter
+	routingKey := pagerDutyRoutingKey
+	if routingKey == "" {
+		routingKey = os.Getenv(notify.PagerDutyRoutingKeyEnv)
+	}
+	if routingKey != "" {
+		configured = append(configured, notify.NewPagerDuty(routingKey))
+	}
+	apiKey := opsgenieAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv(notify.OpsgenieAPIKeyEnv)
+	}
+	if apiKey != "" {
+		opsgenie := notify.NewOpsgenie(apiKey)
+		opsgenie.URL = opsgenieURL
+		configured = append(configured, opsgenie)
+	}
+	return configured
+}
+
+// alertRegressions raises an incident when the run regressed more than --alert-score-drop or
+// --alert-pass-rate-drop allow since --previous-report
+// Per-job thresholds are for the reports; incidents are for drops across the organization.
+func alertRegressions(report AllJobsReport) {
+	if len(alerters) == 0 || previousRun == nil {
+		return
+	}
+	jobResults := make([][]engine.RuleResult, len(report.Jobs))
+	for i, job := range report.Jobs {
+		jobResults[i] = job.RuleResults
+	}
+	regressions := previousRun.Regressions(report.AverageScore, history.RulePassRates(jobResults), history.RegressionThresholds{
+		ScoreDrop:    alertScoreDrop,
+		PassRateDrop: alertPassRateDrop,
+	})
+	if len(regressions) == 0 {
+		return
+	}
+
+	descriptions := make([]string, len(regressions))
+	for i, regression := range regressions {
+		descriptions[i] = regression.String()
+	}
+	summary := "Instrumentation score regressed: " + descriptions[0]
+	if len(regressions) > 1 {
+		summary += fmt.Sprintf(" (and %d more)", len(regressions)-1)
+	}
+	dedupKey := "instrumentation-score-regression"
+	if report.Selector != "" {
+		dedupKey += " " + report.Selector
+	}
+	source := jobDir
+	if source == "" || evaluateS3Source || azureSource {
+		source = "instrumentation-score"
+	}
+	incident := notify.Incident{
+		Summary: summary,
+		Details: map[string]string{
+			"regressions":    strings.Join(descriptions, "; "),
+			"average_score":  fmt.Sprintf("%.1f", report.AverageScore),
+			"total_jobs":     strconv.Itoa(report.TotalJobs),
+			"previous_run":   previousRun.Timestamp,
+			"evaluation_run": report.Timestamp,
+		},
+		DedupKey:  dedupKey,
+		Source:    source,
+		ReportURL: reportURL,
+	}
+
+	fmt.Printf("\n⚠️  %s\n", summary)
+	for _, alerter := range alerters {
+		if err := alerter.Trigger(incident); err != nil {
+			log.Printf("Warning: failed to raise %s incident: %v", alerter.Name(), err)
+			continue
+		}
+		fmt.Printf("✓ Raised %s incident\n", alerter.Name())
+	}
+}
+
+// notifyRunCompleted posts the summary of a finished run to the --callback-url webhooks
+// and exports it as an OTLP log record with --otlp-logs
+func notifyRunCompleted(report AllJobsReport) {
+	if callbacks == nil && runLogs == nil {
+		return
+	}
+	summary := notify.RunSummary{
+		Status:           notify.StatusSuccess,
+		Timestamp:        report.Timestamp,
+		Duration:         time.Since(runStarted).Seconds(),
+		TotalJobs:        report.TotalJobs,
+		AverageScore:     report.AverageScore,
+		TotalCardinality: report.TotalCardinality,
+		MinScore:         minScore,
+		ReportURL:        reportURL,
+		Warnings:         report.Warnings,
+	}
+	if report.OrgScore != nil {
+		summary.OrgScore = report.OrgScore.Score
+		summary.OrgScoreWeighting = report.OrgScore.Weighting
+	}
+	if previousRun != nil {
+		run := gate.Run{AverageScore: report.AverageScore}
+		for _, job := range report.Jobs {
+			run.Jobs = append(run.Jobs, gate.JobScore{Name: job.JobName, Score: job.Score})
+		}
+		for _, regression := range gate.Check(run, gate.Thresholds{Regression: true, Tolerance: regressionMargin}, previousRun) {
+			summary.Regressions = append(summary.Regressions, regression.String())
+		}
+	}
+	teams := make(map[string]*notify.TeamDigest)
+	var order []string
+	for _, job := range report.Jobs {
+		owner, owned := owners.OwnerOf(job.JobName)
+		jobScore := notify.JobScore{JobName: job.JobName, Score: job.Score, Team: owner.Team, Contacts: owner.Contacts}
+		if job.Score < minScore {
+			summary.JobsBelowMin = append(summary.JobsBelowMin, jobScore)
+		}
+		if !owned {
+			continue
+		}
+		digest, ok := teams[owner.Team]
+		if !ok {
+			digest = &notify.TeamDigest{Team: owner.Team, Contacts: owner.Contacts, Labels: owners.RoutingLabels(job.JobName)}
+			teams[owner.Team] = digest
+			order = append(order, owner.Team)
+		}
+		digest.TotalJobs++
+		digest.AverageScore += job.Score
+		if job.Score < minScore {
+			digest.JobsBelowMin = append(digest.JobsBelowMin, jobScore)
+		}
+	}
+	sort.Strings(order)
+	for _, team := range order {
+		digest := teams[team]
+		digest.AverageScore /= float64(digest.TotalJobs)
+		summary.Teams = append(summary.Teams, *digest)
+	}
+	sendCallbacks(summary)
+}
+
+// notifyRunFailed reports a run that failed with err to the --callback-url webhooks and --otlp-logs
+func notifyRunFailed(err error) {
+	if callbacks == nil && runLogs == nil {
+		return
+	}
+	sendCallbacks(notify.RunSummary{
+		Status:    notify.StatusFailure,
+		Error:     err.Error(),
+		Timestamp: time.Now().Format(time.RFC3339),
+		Duration:  time.Since(runStarted).Seconds(),
+		MinScore:  minScore,
+		ReportURL: reportURL,
+	})
+}
+
+// sendCallbacks posts summary to every callback URL and the OTLP logs endpoint, warning
+// about the ones that failed
+func sendCallbacks(summary notify.RunSummary) {
+	if callbacks != nil {
+		for _, err := range callbacks.Send(summary) {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if runLogs != nil {
+		if err := runLogs.Export(summary); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// encryptReports replaces the JSON and HTML report files with encrypted ones, when --encrypt is set
+// The file flags are pointed at the encrypted files, so those are what gets uploaded.
+func encryptReports(formats []string) error {
+	if encrypter == nil {
+		return nil
+	}
+	for _, report := range []struct {
+		format string
+		file   *string
+	}{{"json", &jsonFile}, {"html", &htmlFile}} {
+		if *report.file == "" || !contains(formats, report.format) {
+			continue
+		}
+		encryptedFile, err := encrypter.EncryptFile(*report.file)
+		if err != nil {
+			return fmt.Errorf("Error encrypting %s report: %w", strings.ToUpper(report.format), err)
+		}
+		*report.file = encryptedFile
+		fmt.Printf("Encrypted %s report to %s\n", strings.ToUpper(report.format), encryptedFile)
+	}
+	return nil
+}
+
+// writeTemplateOutput renders report with --template-file, to --template-output or stdout
+func writeTemplateOutput(report interface{}) error {
+	var out bytes.Buffer
+	if err := formatters.RenderTemplate(templateFile, report, &out); err != nil {
+		return fmt.Errorf("Error rendering %s: %w", templateFile, err)
+	}
+	if templateOutput == "" {
+		fmt.Print(out.String())
+		return nil
+	}
+	if err := os.WriteFile(templateOutput, out.Bytes(), 0600); err != nil {
+		return fmt.Errorf("Error writing template output: %w", err)
+	}
+	fmt.Printf("Template output saved to %s\n", templateOutput)
+	return nil
+}
+
+// writePluginOutput renders report with the formatter of a plugin format, to its
+// --plugin-file or stdout
+func writePluginOutput(format string, report interface{}) error {
+	formatter, _ := formatters.Lookup(format)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error marshaling JSON: %w", err)
+	}
+
+	outputFile := pluginFiles[format]
+	if outputFile == "" {
+		if err := formatter.Format(data, os.Stdout); err != nil {
+			return fmt.Errorf("Error formatting %s output: %w", format, err)
+		}
+		return nil
+	}
+	var out bytes.Buffer
+	if err := formatter.Format(data, &out); err != nil {
+		return fmt.Errorf("Error formatting %s output: %w", format, err)
+	}
+	if err := os.WriteFile(outputFile, out.Bytes(), 0600); err != nil {
+		return fmt.Errorf("Error writing %s file: %w", format, err)
+	}
+	fmt.Printf("%s output saved to %s\n", format, outputFile)
+	return nil
+}
+
+// writeSLODocuments generates SLO definitions in format (openslo, pyrra or sloth)
+// and writes them to the format's file flag, or stdout
+func writeSLODocuments(format string, jobs []formatters.JobScoreData) error {
+	opts := formatters.SLOOptions{
+		Target:    sloTarget,
+		Window:    sloWindow,
+		Objective: sloObjective,
+		Namespace: crdNamespace,
+		Owners:    owners,
+	}
+
+	var documents, outputFile, name string
+	var err error
+	switch format {
+	case "openslo":
+		documents, err = formatters.OpenSLO(jobs, opts)
+		outputFile, name = opensloFile, "OpenSLO documents"
+	case "pyrra":
+		documents, err = formatters.Pyrra(jobs, opts)
+		outputFile, name = pyrraFile, "Pyrra SLOs"
+	case "sloth":
+		documents, err = formatters.Sloth(jobs, opts)
+		outputFile, name = slothFile, "Sloth SLOs"
+	}
+	if err != nil {
+		return fmt.Errorf("Error generating %s: %w", name, err)
+	}
+
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, []byte(documents), 0600); err != nil {
+			return fmt.Errorf("Error writing %s: %w", name, err)
+		}
+		fmt.Printf("%s saved to %s\n", name, outputFile)
+	} else {
+		fmt.Print(documents)
+	}
+	return nil
+}
+
+// jobScoreData converts job results to the formatters representation
+func jobScoreData(results []JobScoreResult) []formatters.JobScoreData {
+	var jobsData []formatters.JobScoreData
+	for _, job := range results {
+		jobsData = append(jobsData, formatters.JobScoreData{
+			JobName:          job.JobName,
+			TotalMetrics:     job.TotalMetrics,
+			TotalCardinality: job.TotalCardinality,
+			EstimatedCost:    job.EstimatedCost,
+			Score:            job.Score,
+			RuleResults:      job.RuleResults,
+			SourceFile:       filepath.ToSlash(filepath.Join(jobDir, job.sourceFile)),
+			Confidence:       confidenceLevel(job.Confidence),
+		})
+	}
+	return jobsData
+}
+
+// loadRuleEngine loads --rules, or the built-in rules, and applies --convention-pack
+// With --strict-rules unknown fields in the rules file are errors; the built-in rules have none.
+func loadRuleEngine() (*engine.RuleEngine, error) {
+	var ruleEngine *engine.RuleEngine
+	var err error
+	if strictRules && rulesConfig != "" {
+		ruleEngine, err = engine.NewRuleEngineStrict(rulesConfig)
+	} else {
+		ruleEngine, err = newRuleEngine(rulesConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if namingPack != "" {
+		if err := ruleEngine.SetConventionPack(namingPack); err != nil {
+			return nil, err
+		}
+	}
+	return ruleEngine, nil
+}
+
+// evaluateEnv are the environment variables evaluate reads
+var evaluateEnv = []string{"S3_BUCKET", "S3_PREFIX", "AWS_REGION", "TIMESTAMP", encryption.KeyEnv,
+	storage.AzureAccountEnv, storage.AzureContainerEnv, azurePrefixEnv, notify.OTLPEndpointEnv, notify.OTLPLogsEndpointEnv}
+
+// azurePrefixEnv is the blob name prefix used when --azure-prefix is not set
+const azurePrefixEnv = "AZURE_STORAGE_PREFIX"
+
+// azureStore creates an Azure Blob Storage client, falling back to the environment for
+// the account and container
+func azureStore(account, container, prefix string) (storage.ResultStore, error) {
+	if account == "" {
+		account = os.Getenv(storage.AzureAccountEnv)
+	}
+	if container == "" {
+		container = os.Getenv(storage.AzureContainerEnv)
+	}
+	client, err := storage.NewAzureBlobClient(account, container, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Blob Storage client: %w", err)
+	}
+	return client, nil
+}
+
+// azureSourcePath names the blobs --azure-source downloaded, for the manifest
+func azureSourcePath() string {
+	account, container, prefix := azureAccount, azureContainer, azurePrefix
+	if account == "" {
+		account = os.Getenv(storage.AzureAccountEnv)
+	}
+	if container == "" {
+		container = os.Getenv(storage.AzureContainerEnv)
+	}
+	if prefix == "" {
+		prefix = os.Getenv(azurePrefixEnv)
+	}
+	return fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", account, container, strings.Trim(prefix, "/"))
+}
+
+// cleanupStaleDownloads removes S3 download directories left behind by earlier runs that crashed
+// A run that exits on a fatal error skips its own cleanup, so the next S3 run catches it.
+func cleanupStaleDownloads() {
+	removed, err := storage.CleanupStaleDownloads(os.TempDir(), storage.StaleDownloadAge, time.Now())
+	if err != nil {
+		log.Printf("Warning: failed to remove stale S3 downloads: %v", err)
+	}
+	if len(removed) > 0 {
+		fmt.Printf("Removed %d stale S3 download directories from earlier runs\n", len(removed))
+	}
+}
+
+// downloadEvaluationSource downloads the job files of --s3-source and --azure-source, replaced in tests
+var downloadEvaluationSource = storage.DownloadEvaluationSource
+
+// removeDownload removes the directory --s3-source downloaded job files into
+func removeDownload(dir string) {
+	if err := storage.RemoveDownload(dir); err != nil {
+		log.Printf("Warning: failed to remove downloaded job metrics: %v", err)
+	}
+}
+
+// evaluationConfig snapshots the configuration of this evaluation for the JSON report and S3 manifest
+// The snapshot analyze wrote next to the job files in fsys is included, so collection
+// settings are part of the record too.
+func evaluationConfig(ruleEngine *engine.RuleEngine, fsys fs.FS) *runconfig.Snapshot {
+	snapshot := runSettings
+	for _, file := range []string{rulesConfig, waiverFile, ownershipFile, usageFile, healthFile, churnFile, localeCatalog, previousFile, thresholdsFile} {
+		snapshot.AddFile(file)
+	}
+	snapshot.RulesHash = ruleEngine.RulesHash()
+	snapshot.Exclusions = ruleEngine.Exclusions()
+	snapshot.Inclusions = ruleEngine.Inclusions()
+	if showCosts {
+		snapshot.CostModel = &runconfig.CostModel{UnitPrice: costPrice, Unit: "active series per month"}
+	}
+
+	file, err := fsys.Open(runconfig.FileName)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Warning: %v", err)
+		}
+		return snapshot
+	}
+	defer file.Close()
+	analysis, err := runconfig.Read(file)
+	if err != nil {
+		log.Printf("Warning: %s: %v", runconfig.FileName, err)
+		return snapshot
+	}
+	snapshot.Analysis = analysis
+	return snapshot
+}
+
+// analysisSelector returns the --selector the analysis in config was scoped to, empty
+// when it covered the whole fleet or recorded no configuration
+func analysisSelector(config *runconfig.Snapshot) string {
+	if config == nil || config.Analysis == nil {
+		return ""
+	}
+	return config.Analysis.Flags["selector"]
+}
+
+// applySelectorLabels adds the labels selector fixes, such as namespace, to every exported
+// series, so the scores of runs over different namespaces or releases stay apart
+// --metric-labels take precedence.
+func applySelectorLabels(selector string) {
+	parsed, err := collectors.ParseSelector(selector)
+	if err != nil || len(parsed) == 0 {
+		return
+	}
+	labels := parsed.Labels()
+	for name, value := range metricLabels {
+		labels[name] = value
+	}
+	if err := formatters.SetMetricNaming(metricPrefix, labels); err != nil {
+		log.Printf("Warning: selector labels are not added to exported metrics: %v", err)
+	}
+}
+
+// loadServiceVersions returns the version of each job from the build info report analyze wrote into fsys
+// A missing or unreadable report only means versions are not shown.
+func loadServiceVersions(fsys fs.FS) map[string]string {
+	file, err := fsys.Open(loaders.BuildInfoFileName)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Warning: failed to load build info: %v", err)
+		}
+		return nil
+	}
+	defer file.Close()
+	buildInfo, err := loaders.ReadBuildInfoReport(file)
+	if err != nil {
+		log.Printf("Warning: failed to load build info: %v", err)
+		return nil
+	}
+	return loaders.ServiceVersions(buildInfo)
+}
+
+// loadCollectionGaps returns the metrics analyze failed to collect, from the collection gap
+// report it wrote into fsys; without a report every job is taken as completely collected.
+func loadCollectionGaps(fsys fs.FS) loaders.CollectionGaps {
+	file, err := fsys.Open(loaders.CollectionGapsFileName)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Warning: failed to load collection gaps: %v", err)
+		}
+		return loaders.CollectionGaps{}
+	}
+	defer file.Close()
+	gaps, err := loaders.ReadCollectionGapsReport(file)
+	if err != nil {
+		log.Printf("Warning: failed to load collection gaps: %v", err)
+		return loaders.CollectionGaps{}
+	}
+	return gaps
+}
+
+// scoreConfidence rates the score of result by the fraction of the job's metrics it is missing:
+// metrics that failed to collect for the job, excluded metrics and malformed records, combined
+// with the fraction of the run's metrics that failed for every job
+func scoreConfidence(result JobScoreResult, gaps loaders.CollectionGaps) *ScoreConfidence {
+	confidence := &ScoreConfidence{
+		Uncollected: gaps.Missing(result.JobName),
+		Excluded:    result.excluded,
+		Malformed:   result.malformed,
+	}
+	var jobFraction float64
+	if total := result.TotalMetrics + confidence.Uncollected + confidence.Malformed; total > 0 {
+		jobFraction = float64(confidence.Uncollected+confidence.Excluded+confidence.Malformed) / float64(total)
+	}
+	// Rounded, so a fraction on a threshold is not rated by floating point error
+	missing := 1 - (1-jobFraction)*(1-gaps.UnknownJobsFraction())
+	confidence.MissingFraction = math.Round(missing*10000) / 10000
+	confidence.Level = score.Confidence(confidence.MissingFraction)
+	return confidence
+}
+
+// confidenceLevel returns the level of confidence, empty when the score was not rated
+func confidenceLevel(confidence *ScoreConfidence) string {
+	if confidence == nil {
+		return ""
+	}
+	return confidence.Level
+}
+
+// confidenceSummary describes a confidence for the text report: its level and, when metrics
+// are missing, how many and why
+func confidenceSummary(confidence *ScoreConfidence) string {
+	var reasons []string
+	if confidence.Uncollected > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d not collected", confidence.Uncollected))
+	}
+	if confidence.Excluded > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d excluded", confidence.Excluded))
+	}
+	if confidence.Malformed > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d malformed", confidence.Malformed))
+	}
+	if confidence.MissingFraction == 0 {
+		return confidence.Level
+	}
+	summary := fmt.Sprintf("%s (%.0f%% of metrics missing", confidence.Level, confidence.MissingFraction*100)
+	if len(reasons) > 0 {
+		summary += ": " + strings.Join(reasons, ", ")
+	}
+	return summary + ")"
+}
+
+// loadMetricUsage feeds --metric-usage-file, or the report analyze wrote into fsys, to the rule engine
+func loadMetricUsage(ruleEngine *engine.RuleEngine, fsys fs.FS) error {
+	file, source, err := openReport(fsys, usageFile, loaders.MetricUsageFileName)
+	if err != nil {
+		return fmt.Errorf("Error loading metric usage from %s: %w", source, err)
+	}
+	if file == nil {
+		return nil
+	}
+	defer file.Close()
+	data, err := loaders.ReadMetricUsageReport(file)
+	if err != nil {
+		return fmt.Errorf("Error loading metric usage from %s: %w", source, err)
+	}
+	ruleEngine.SetMetricUsage(data)
+	return nil
+}
+
+// loadScrapeHealth feeds --scrape-health-file, or the report analyze wrote into fsys, to the rule engine
+func loadScrapeHealth(ruleEngine *engine.RuleEngine, fsys fs.FS) error {
+	file, source, err := openReport(fsys, healthFile, loaders.ScrapeHealthFileName)
+	if err != nil {
+		return fmt.Errorf("Error loading scrape health from %s: %w", source, err)
+	}
+	if file == nil {
+		return nil
+	}
+	defer file.Close()
+	health, err := loaders.ReadScrapeHealthReport(file)
+	if err != nil {
+		return fmt.Errorf("Error loading scrape health from %s: %w", source, err)
+	}
+	ruleEngine.SetScrapeHealth(health)
+	return nil
+}
+
+// loadSeriesChurn feeds --series-churn-file, or the report analyze wrote into fsys, to the rule engine
+func loadSeriesChurn(ruleEngine *engine.RuleEngine, fsys fs.FS) error {
+	file, source, err := openReport(fsys, churnFile, loaders.SeriesChurnFileName)
+	if err != nil {
+		return fmt.Errorf("Error loading series churn from %s: %w", source, err)
+	}
+	if file == nil {
+		return nil
+	}
+	defer file.Close()
+	churn, err := loaders.ReadSeriesChurnReport(file)
+	if err != nil {
+		return fmt.Errorf("Error loading series churn from %s: %w", source, err)
+	}
+	ruleEngine.SetSeriesChurn(churn)
+	return nil
+}
+
+// openReport opens the report file given by a flag, or else the report analyze wrote into fsys
+// The file is nil, without an error, when no flag is set and analyze wrote no such report.
+func openReport(fsys fs.FS, flagPath, defaultName string) (io.ReadCloser, string, error) {
+	if flagPath != "" {
+		file, err := os.Open(flagPath)
+		if err != nil {
+			return nil, flagPath, err
+		}
+		return file, flagPath, nil
+	}
+	file, err := fsys.Open(defaultName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, defaultName, nil
+	}
+	if err != nil {
+		return nil, defaultName, err
+	}
+	return file, defaultName, nil
+}
+
+// applyWaivers marks the job's failures acknowledged by --waivers
+func applyWaivers(jobName string, results []engine.RuleResult, jobData []loaders.JobMetricData) {
+	if waived == nil {
+		return
+	}
+	now := time.Now()
+	engine.ApplyWaivers(results, jobData, func(ruleID, metricName string) (engine.Acknowledgement, bool) {
+		waiver, ok := waived.Lookup(jobName, ruleID, metricName, now)
+		return engine.Acknowledgement{Reason: waiver.Reason, Expires: waiver.Expires, Exempt: waiver.Exempt}, ok
+	})
+}
+
+// expiredWaiverWarnings describes the waivers in --waivers that have expired
+func expiredWaiverWarnings() []string {
+	var warnings []string
+	for _, waiver := range waived.Expired(time.Now()) {
+		job := waiver.Job
+		if job == "" {
+			job = waiver.JobPattern
+		}
+		warnings = append(warnings, fmt.Sprintf("waiver for %s/%s expired on %s, its failures count again", job, waiver.Metric, waiver.Expires))
+	}
+	return warnings
+}
+
+// jobFingerprint returns the fingerprint recognizing a job by its metrics across runs
+// All metrics count, excluded ones too, so changing the exclusion list does not look like a rename.
+func jobFingerprint(jobData []loaders.JobMetricData) history.Fingerprint {
+	names := make([]string, len(jobData))
+	for i, metric := range jobData {
+		names[i] = metric.MetricName
+	}
+	return history.MetricFingerprint(names)
+}
+
+// linkRenamedJobs recognizes jobs renamed since the previous run by their metrics and
+// links them to their --previous-report results and --history-db streaks, so a rename
+// does not look like one job disappearing and a new one appearing
+func linkRenamedJobs(results []JobScoreResult) {
+	if previousRun == nil && historyStore == nil {
+		return
+	}
+	current := make(map[string]history.Fingerprint, len(results))
+	for _, result := range results {
+		current[result.JobName] = result.Fingerprint
+	}
+
+	renames := make(map[string]string)
+	if historyStore != nil {
+		recorded, err := historyStore.LatestFingerprints()
+		if err != nil {
+			log.Printf("Warning: renamed jobs not linked to their history: %v", err)
+		}
+		for job, old := range history.DetectRenames(recorded, current) {
+			renames[job] = old
+		}
+	}
+	if previousRun != nil {
+		for job, old := range previousRun.DetectRenames(current) {
+			renames[job] = old
+		}
+	}
+	for i := range results {
+		if old, ok := renames[results[i].JobName]; ok {
+			results[i].RenamedFrom = old
+			fmt.Printf("ℹ️  Job %s was renamed from %s since the previous run, its history carries over\n", results[i].JobName, old)
+		}
+	}
+}
+
+// jobRunSource identifies the evaluated job file by its name and modification time, so
+// evaluating the same analysis output twice does not lengthen the failure streaks
+func jobRunSource(fsys fs.FS, name string) string {
+	source := path.Base(name)
+	if info, err := fs.Stat(fsys, name); err == nil {
+		source += "@" + info.ModTime().UTC().Format(time.RFC3339Nano)
+	}
+	return source
+}
+
+// applyScoreDecay weighs the job's chronic failures when --decay-runs is set, continuing the
+// failure streaks recorded in --history-db under historyName, the job's name in earlier runs
+func applyScoreDecay(historyName, source string, results []engine.RuleResult, jobData []loaders.JobMetricData) {
+	if historyStore == nil {
+		return
+	}
+	recorded, err := historyStore.FailureStreaks(historyName, source, decayRuns)
+	if err != nil {
+		log.Printf("Warning: score decay not applied to %s: %v", historyName, err)
+		return
+	}
+	engine.ApplyScoreDecay(results, jobData, func(ruleID, metricName string) int {
+		// The run being evaluated continues the recorded streak
+		return 1 + recorded[ruleID][metricName]
+	}, decayRuns, decayWeight)
+}
+
+// skippedJobError skips a job file over --max-job-lines or --job-timeout, for reason
+type skippedJobError struct {
+	reason string
+}
 
+func (e *skippedJobError) Error() string {
+	return e.reason
+}
+
+// scoreJobFile scores a job file of src within its limits: a file over src.maxLines lines is
+// skipped before it is loaded, and reading and scoring it give up after src.timeout. The
+// abandoned evaluation is cancelled and stops at its next read or rule, so one pathological
+// file cannot stall the whole batch or race the evaluation of the next one.
+func scoreJobFile(src jobSource, name string, ruleEngine *engine.RuleEngine) (JobScoreResult, error) {
+	ctx, cancel := src.context()
+	defer cancel()
+	timedOut := &skippedJobError{fmt.Sprintf("evaluation exceeded %s (--job-timeout)", src.timeout)}
+
+	// Circuit breaker: skip pathological files before loading them into memory
+	if tooLarge, err := src.exceedsLineLimit(ctx, name); err == nil && tooLarge {
+		return JobScoreResult{}, &skippedJobError{fmt.Sprintf("more than %d lines (--max-job-lines)", src.maxLines)}
+	}
+
+	type outcome struct {
+		result JobScoreResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := evaluateSingleJobFile(ctx, src, name, ruleEngine)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		if errors.Is(o.err, context.DeadlineExceeded) {
+			return JobScoreResult{}, timedOut
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		return JobScoreResult{}, timedOut
+	}
+}
+
+// evaluateSingleJobFile scores a job file of src, stopping with ctx's error once ctx is done
+func evaluateSingleJobFile(ctx context.Context, src jobSource, name string, ruleEngine *engine.RuleEngine) (JobScoreResult, error) {
+	// Load job metrics
+	jobData, parseWarnings, err := src.readJobFile(ctx, name)
+	if err != nil {
+		return JobScoreResult{}, err
+	}
+	if err := checkParseWarnings(src.path(name), parseWarnings, src.strictParse); err != nil {
+		return JobScoreResult{}, err
+	}
+
+	if len(jobData) == 0 {
+		return JobScoreResult{}, fmt.Errorf("no metrics found")
+	}
+
+	jobName := jobData[0].Job
+	result, err := score.EvaluateJob(ctx, ruleEngine, jobName, jobData, src.opts)
+	if err != nil {
+		return JobScoreResult{}, err
+	}
+	logScoreWarnings(result)
+
+	var decayMetrics []loaders.JobMetricData
+	if historyStore != nil {
+		decayMetrics = failingMetrics(result)
+	}
+	jobResult := jobScoreResult(result)
+	jobResult.ParseWarnings = formatParseWarnings(parseWarnings)
+	jobResult.UnusedMetrics = unusedMetrics(ruleEngine, result.Evaluated)
+	jobResult.Remediation = remediationPriorities(ruleEngine, result.RuleResults, result.Evaluated)
+	jobResult.Fingerprint = jobFingerprint(jobData)
+	jobResult.sourceFile = name
+	jobResult.source = jobRunSource(src.fsys, name)
+	jobResult.decayMetrics = decayMetrics
+	jobResult.malformed = loaders.SkippedRecords(parseWarnings)
+	return jobResult, nil
+}
+
+// jobScoreResult reports a job scored by the library as evaluate reports a job
+func jobScoreResult(result score.JobScore) JobScoreResult {
 	return JobScoreResult{
-		JobName:          jobName,
-		TotalMetrics:     len(jobData),
-		TotalCardinality: totalCardinality,
-		EstimatedCost:    estimatedCost,
-		Score:            score,
-		RuleResults:      results,
-		FailedMetrics:    failedMetrics,
-		MetricsBreakdown: breakdown,
-	}, nil
+		JobName:          result.Job,
+		TotalMetrics:     result.Metrics,
+		TotalCardinality: result.TotalCardinality,
+		EstimatedCost:    result.EstimatedCost,
+		Score:            result.Score,
+		ScoreBreakdown:   &result.Breakdown,
+		RuleResults:      result.RuleResults,
+		FailedMetrics:    result.FailedMetrics,
+		MetricsBreakdown: result.PassedChecks,
+		Warnings:         result.Warnings,
+		excluded:         result.Metrics - len(result.Evaluated),
+	}
 }
 
-func generateHTMLReport(report AllJobsReport, files []string) {
-	// Prepare HTML data
-	var jobsHTMLData []formatters.JobHTMLData
+// scoreOptions are the library options of the CLI: the --cost-price when costs are shown, and
+// waivers applied to the rule results. Score decay is applied once renames are linked.
+func scoreOptions() score.Options {
+	opts := score.Options{Adjust: applyWaivers}
+	if showCosts && costPrice > 0 {
+		opts.CostPerSeries = costPrice
+	}
+	return opts
+}
+
+// failingMetrics returns the evaluated metrics failing a rule of the job, the cardinality
+// score decay weighs them by
+func failingMetrics(result score.JobScore) []loaders.JobMetricData {
+	failing := make(map[string]bool)
+	for _, rule := range result.RuleResults {
+		for metricName := range rule.FailedMetrics {
+			failing[metricName] = true
+		}
+	}
+	metrics := []loaders.JobMetricData{}
+	for _, metric := range result.Evaluated {
+		if failing[metric.MetricName] {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
+
+// logScoreWarnings logs the validators that failed while scoring a job
+func logScoreWarnings(result score.JobScore) {
+	for _, warning := range result.Warnings {
+		log.Printf("Warning: job %s: %s (counted as failed)", result.Job, warning)
+	}
+}
+
+// jobSource is a directory of job files to score and how to read and score them: evaluate's
+// --job-dir or S3 source, serve's --job-dir, or the job files of one of serve's runs
+// Each run scores its own, so runs of serve score concurrently.
+type jobSource struct {
+	fsys        fs.FS
+	dir         string // Directory of fsys on disk, naming job files in messages; "" for S3
+	legacyPairs bool   // fsys holds legacy report pairs rather than job files, see --legacy-pairs
+	strictParse bool   // Fail job files with malformed lines, see --strict-parse
+	maxLines    int           // Skip job files with more lines, see --max-job-lines; 0 disables
+	timeout     time.Duration // Give up reading and scoring a job file after, see --job-timeout; 0 disables
+	opts        score.Options
+
+	owners    *ownership.Mapping // Teams of the jobs, nil when unknown
+	weighting string             // How job scores combine into the organization score
+}
+
+// context returns the context reading and scoring one job file of the source, done after its timeout
+func (src jobSource) context() (context.Context, context.CancelFunc) {
+	if src.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), src.timeout)
+}
+
+// readJobFile loads a job file of the source, failing with ctx's error once ctx is done
+func (src jobSource) readJobFile(ctx context.Context, name string) ([]loaders.JobMetricData, []loaders.ParseWarning, error) {
+	if src.legacyPairs {
+		data, err := src.readLegacyPair(ctx, name)
+		return data, nil, err
+	}
+	file, err := src.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	return loaders.ReadJobMetricReport(loaders.ContextReader(ctx, file), src.path(name))
+}
+
+// readLegacyPair reads the legacy cardinality report name of the source with its labels report
+func (src jobSource) readLegacyPair(ctx context.Context, name string) ([]loaders.JobMetricData, error) {
+	file, err := src.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	cardinality, err := loaders.ReadCardinalityReport(loaders.ContextReader(ctx, file))
+	if err != nil {
+		return nil, err
+	}
+
+	labelsName := loaders.LegacyLabelsFile(name)
+	labelsFile, err := src.fsys.Open(labelsName)
+	if err != nil {
+		return nil, fmt.Errorf("labels report of the pair: %w", err)
+	}
+	defer labelsFile.Close()
+	labels, err := loaders.ReadLabelsReport(loaders.ContextReader(ctx, labelsFile))
+	if err != nil {
+		return nil, err
+	}
+	return loaders.MergeLegacyReports(loaders.LegacyJobName(name), cardinality, labels), nil
+}
+
+// loadSingleJob loads --job-file, or the --cardinality-file and --labels-file pair
+func loadSingleJob() ([]loaders.JobMetricData, []loaders.ParseWarning, error) {
+	if labelsFile == "" {
+		return loaders.LoadJobMetricReportWithWarnings(jobFile)
+	}
+	job := legacyJob
+	if job == "" {
+		job = loaders.LegacyJobName(cardinalityFile)
+	}
+	data, err := loaders.LoadLegacyReports(job, cardinalityFile, labelsFile)
+	return data, nil, err
+}
 
-	// Create a map for quick lookup using actual job names from file content
-	jobFileMap := make(map[string]string)
-	for _, file := range files {
-		jobData, err := loaders.LoadJobMetricReport(file)
-		if err != nil || len(jobData) == 0 {
+// exceedsLineLimit reports whether a job file of the source has more than maxLines lines
+func (src jobSource) exceedsLineLimit(ctx context.Context, name string) (bool, error) {
+	if src.maxLines <= 0 {
+		return false, nil
+	}
+	file, err := src.fsys.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	return loaders.ReaderExceedsLineLimit(loaders.ContextReader(ctx, file), src.maxLines)
+}
+
+// releaseJobFile lets a streamed S3 source drop a job file it no longer needs from memory
+func (src jobSource) releaseJobFile(name string) {
+	if s3FS, ok := src.fsys.(*storage.S3FS); ok {
+		s3FS.Release(name)
+	}
+}
+
+// path names a job file of the source for messages
+func (src jobSource) path(name string) string {
+	if src.dir == "" {
+		return name
+	}
+	return filepath.Join(src.dir, name)
+}
+
+// jobSourceName names where all-jobs mode reads job files from, for messages
+func jobSourceName() string {
+	if jobDir == "" {
+		return fmt.Sprintf("s3://%s/%s", evaluateS3Bucket, evaluateS3Prefix)
+	}
+	return jobDir
+}
+
+// unusedMetrics lists the metrics of a job no scanned dashboard or rule references, highest cardinality first
+// It returns nil when no metric usage report was loaded.
+func unusedMetrics(ruleEngine *engine.RuleEngine, jobData []loaders.JobMetricData) []UnusedMetric {
+	index := ruleEngine.MetricUsage()
+	if index == nil {
+		return nil
+	}
+
+	var unused []UnusedMetric
+	for _, metric := range jobData {
+		if index.Used(metric.MetricName) {
 			continue
 		}
-		actualJobName := jobData[0].Job
-		jobFileMap[actualJobName] = file
+		entry := UnusedMetric{MetricName: metric.MetricName, Cardinality: metric.Cardinality}
+		if showCosts && costPrice > 0 {
+			entry.EstimatedCost = float64(metric.Cardinality) * costPrice
+		}
+		unused = append(unused, entry)
 	}
+	sort.SliceStable(unused, func(i, j int) bool { return unused[i].Cardinality > unused[j].Cardinality })
+	return unused
+}
 
-	for _, jobResult := range report.Jobs {
-		// Find the corresponding file
-		jobFilePath := jobFileMap[jobResult.JobName]
-		if jobFilePath == "" {
+// remediationPriorities ranks a job's failing metrics by query volume, then cardinality
+// Metrics nothing uses are listed last with action "drop": removing them beats fixing them.
+// It returns nil unless the metric usage report includes query log counts.
+func remediationPriorities(ruleEngine *engine.RuleEngine, results []engine.RuleResult, jobData []loaders.JobMetricData) []RemediationItem {
+	index := ruleEngine.MetricUsage()
+	if index == nil || !index.HasQueryLog() {
+		return nil
+	}
+
+	var items []RemediationItem
+	for _, metric := range jobData {
+		var failedRules []string
+		for _, result := range results {
+			if _, failed := result.FailedMetrics[metric.MetricName]; failed {
+				failedRules = append(failedRules, result.RuleID)
+			}
+		}
+		if len(failedRules) == 0 {
 			continue
 		}
+		action := "fix"
+		if !index.Used(metric.MetricName) {
+			action = "drop"
+		}
+		items = append(items, RemediationItem{
+			MetricName:  metric.MetricName,
+			FailedRules: failedRules,
+			QueryCount:  index.QueryCount(metric.MetricName),
+			Cardinality: metric.Cardinality,
+			Action:      action,
+		})
+	}
+	sortRemediation(items)
+	return items
+}
+
+// sortRemediation orders metrics to fix before metrics to drop, each by query count then cardinality
+func sortRemediation(items []RemediationItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Action != items[j].Action {
+			return items[i].Action == "fix"
+		}
+		if items[i].QueryCount != items[j].QueryCount {
+			return items[i].QueryCount > items[j].QueryCount
+		}
+		return items[i].Cardinality > items[j].Cardinality
+	})
+}
+
+// printRemediation prints up to limit failing metrics in remediation order
+func printRemediation(items []RemediationItem, limit int) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Printf("\nRemediation Priorities (most queried first):\n")
+	for i, item := range items {
+		if i == limit {
+			fmt.Printf("  ... and %d more (see remediation in JSON)\n", len(items)-limit)
+			break
+		}
+		fmt.Printf("  %-4s %-60s %8d queries  %s\n", item.Action, item.MetricName, item.QueryCount, strings.Join(item.FailedRules, ", "))
+	}
+}
+
+// printUnusedMetrics prints up to limit dead-weight candidates with their series count and cost
+func printUnusedMetrics(unused []UnusedMetric, limit int) {
+	if len(unused) == 0 {
+		return
+	}
+	var series int64
+	var cost float64
+	for _, metric := range unused {
+		series += metric.Cardinality
+		cost += metric.EstimatedCost
+	}
+
+	fmt.Printf("\nDead-weight Candidates: %d metric(s) not used by any dashboard, rule or logged query, %d series", len(unused), series)
+	if showCosts {
+		fmt.Printf(", $%.2f/month", cost)
+	}
+	fmt.Println()
+	for i, metric := range unused {
+		if i == limit {
+			fmt.Printf("  ... and %d more (see unused_metrics in JSON)\n", len(unused)-limit)
+			break
+		}
+		fmt.Printf("  %-60s %10d series\n", metric.MetricName, metric.Cardinality)
+	}
+}
+
+// checkParseWarnings fails a job file with malformed lines when strict, see --strict-parse
+func checkParseWarnings(filePath string, warnings []loaders.ParseWarning, strict bool) error {
+	if !strict || len(warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d malformed line(s) in %s (--strict-parse):\n  %s",
+		len(warnings), filePath, strings.Join(formatParseWarnings(warnings), "\n  "))
+}
+
+// formatParseWarnings renders parse warnings as "line N: reason" strings
+func formatParseWarnings(warnings []loaders.ParseWarning) []string {
+	var formatted []string
+	for _, warning := range warnings {
+		formatted = append(formatted, fmt.Sprintf("line %d: %s", warning.Line, warning.Reason))
+	}
+	return formatted
+}
+
+// buildJobsHTMLData prepares the jobs of report for the HTML report, worst score first,
+// reading each job's metrics again for the metric details
+func buildJobsHTMLData(src jobSource, report AllJobsReport) []formatters.JobHTMLData {
+	var jobsHTMLData []formatters.JobHTMLData
 
+	for _, jobResult := range report.Jobs {
 		// Load job data for detailed metrics
-		jobData, err := loaders.LoadJobMetricReport(jobFilePath)
+		jobData, _, err := src.readJobFile(context.Background(), jobResult.sourceFile)
 		if err != nil {
 			continue
 		}
@@ -641,6 +2557,14 @@ func generateHTMLReport(report AllJobsReport, files []string) {
 		cardinalityData := loaders.ConvertJobMetricToCardinality(jobData)
 		labelsDataList := loaders.ConvertJobMetricToLabels(jobData)
 
+		// Compare with the previous run, if any
+		var previousJob history.PreviousJob
+		hasPrevious := false
+		if previousRun != nil {
+			previousJob, hasPrevious = previousRun.Job(jobResult.JobName)
+		}
+		newlyFailed := 0
+
 		// Create metric details
 		var metrics []formatters.JobMetricDetail
 		for _, metric := range jobData {
@@ -670,14 +2594,38 @@ func generateHTMLReport(report AllJobsReport, files []string) {
 				}
 			}
 
-			// Check if metric failed
+			// Check if metric failed, and whether every failure is acknowledged
 			failedValidators := jobResult.RuleResults
-			var failures []string
+			var failures, waiverNotes []string
 			status := "pass"
+			unacknowledged := false
 			for _, result := range failedValidators {
-				if validators, exists := result.FailedMetrics[metric.MetricName]; exists {
-					failures = append(failures, validators...)
-					status = "fail"
+				validators, exists := result.FailedMetrics[metric.MetricName]
+				if !exists {
+					continue
+				}
+				failures = append(failures, validators...)
+				acknowledged := false
+				for _, ack := range result.Acknowledged {
+					if ack.MetricName == metric.MetricName {
+						waiverNotes = append(waiverNotes, fmt.Sprintf("%s: %s (until %s)", result.RuleID, ack.Reason, ack.Expires))
+						acknowledged = true
+					}
+				}
+				unacknowledged = unacknowledged || !acknowledged
+			}
+			if len(failures) > 0 {
+				status = "fail"
+				if !unacknowledged {
+					status = "acknowledged"
+				}
+			}
+
+			var change string
+			if hasPrevious {
+				change = previousJob.MetricChange(metric.MetricName, len(failures) > 0)
+				if change == history.MetricNewlyFailed {
+					newlyFailed++
 				}
 			}
 
@@ -688,6 +2636,8 @@ func generateHTMLReport(report AllJobsReport, files []string) {
 				Status:           status,
 				FailedRules:      failures,
 				LabelCardinality: labelCardinalityJSON,
+				Waiver:           strings.Join(waiverNotes, "; "),
+				Change:           change,
 			})
 		}
 
@@ -710,6 +2660,7 @@ func generateHTMLReport(report AllJobsReport, files []string) {
 
 		jobsHTMLData = append(jobsHTMLData, formatters.JobHTMLData{
 			JobName:          jobResult.JobName,
+			ServiceVersion:   jobResult.ServiceVersion,
 			Score:            jobResult.Score,
 			ScoreInt:         scoreInt,
 			Category:         category,
@@ -720,6 +2671,11 @@ func generateHTMLReport(report AllJobsReport, files []string) {
 			TotalCardinality: jobResult.TotalCardinality,
 			EstimatedCost:    jobResult.EstimatedCost,
 			ShowCost:         showCosts,
+			HasPrevious:      hasPrevious,
+			ScoreDelta:       jobResult.Score - previousJob.Score,
+			NewlyFailed:      newlyFailed,
+			RenamedFrom:      jobResult.RenamedFrom,
+			Confidence:       confidenceLevel(jobResult.Confidence),
 		})
 	}
 
@@ -727,19 +2683,49 @@ func generateHTMLReport(report AllJobsReport, files []string) {
 	sort.Slice(jobsHTMLData, func(i, j int) bool {
 		return jobsHTMLData[i].Score < jobsHTMLData[j].Score
 	})
+	return jobsHTMLData
+}
+
+func generateHTMLReport(src jobSource, report AllJobsReport) error {
+	jobsHTMLData := buildJobsHTMLData(src, report)
 
 	// Generate HTML
-	formatters.HTMLMultiJobWithCost(jobsHTMLData, report.AverageScore, report.TotalCost, report.TotalCardinality, showCosts, htmlFile, rulesConfig)
+	var previousTimestamp string
+	if previousRun != nil {
+		previousTimestamp = previousRun.Timestamp
+		if previousTimestamp == "" {
+			previousTimestamp = previousFile
+		}
+	}
+	rulesData, err := readRulesFile(rulesConfig)
+	if err != nil {
+		fmt.Printf("WARNING: rule descriptions are missing from the HTML report: %v\n", err)
+	}
+	err = formatters.HTMLMultiJobWithWarnings(jobsHTMLData, report.AverageScore, report.TotalCost, report.TotalCardinality, showCosts, htmlFile, rulesData, previousTimestamp, report.Selector,
+		report.Warnings, report.SkippedJobs, report)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("✅ HTML report saved to %s\n", htmlFile)
+	return nil
 }
 
 func printSummary(report AllJobsReport) {
 	fmt.Printf("\n=== Summary ===\n")
-	fmt.Printf("Total Jobs: %d\n", report.TotalJobs)
-	fmt.Printf("Average Score: %.2f%%\n", report.AverageScore)
-	fmt.Printf("Total Active Series: %d\n", report.TotalCardinality)
+	if report.Selector != "" {
+		fmt.Printf("Selector: %s\n", report.Selector)
+	}
+	fmt.Printf("Total Jobs: %s\n", outputLocale.Int(int64(report.TotalJobs)))
+	fmt.Printf("Average Score: %s%%\n", outputLocale.Float(report.AverageScore, 2))
+	if report.OrgScore != nil && report.OrgScore.Weighting != orgscore.WeightJobs {
+		fmt.Printf("Organization Score: %s%% (weighted by %s)\n", outputLocale.Float(report.OrgScore.Score, 2), report.OrgScore.Weighting)
+	}
+	fmt.Printf("Total Active Series: %s\n", outputLocale.Int(report.TotalCardinality))
 	if showCosts {
-		fmt.Printf("Total Cost: $%.2f/month\n", report.TotalCost)
+		fmt.Printf("Total Cost: $%s/month\n", outputLocale.Float(report.TotalCost, 2))
+	}
+	if reportURL != "" {
+		fmt.Printf("Report: %s\n", reportURL)
 	}
 
 	// Count by category
@@ -758,10 +2744,112 @@ func printSummary(report AllJobsReport) {
 	}
 
 	fmt.Printf("\nScore Distribution:\n")
-	fmt.Printf("  Excellent (90-100): %d jobs\n", excellent)
-	fmt.Printf("  Good (75-89): %d jobs\n", good)
-	fmt.Printf("  Needs Improvement (50-74): %d jobs\n", needsImprovement)
-	fmt.Printf("  Poor (0-49): %d jobs\n", poor)
+	fmt.Printf("  %s (90-100): %d jobs\n", outputLocale.T("Excellent"), excellent)
+	fmt.Printf("  %s (75-89): %d jobs\n", outputLocale.T("Good"), good)
+	fmt.Printf("  %s (50-74): %d jobs\n", outputLocale.T("Needs Improvement"), needsImprovement)
+	fmt.Printf("  %s (0-49): %d jobs\n", outputLocale.T("Poor"), poor)
+
+	if c := report.SpecConformance; c != nil {
+		fmt.Printf("\nSpec Conformance: %d of %d specification rules implemented (%s%%, %s)\n",
+			c.Implemented, c.Total, outputLocale.Float(c.Percentage, 1), c.Origin)
+		for _, rule := range c.Uncovered() {
+			status := "not covered"
+			if rule.Status == spec.StatusPlaceholder {
+				status = "placeholder " + strings.Join(rule.Placeholders, ", ") + " has no validators"
+			}
+			fmt.Printf("  ○ %-10s %-9s %s (%s)\n", rule.SpecRuleID, rule.Impact, rule.Title, status)
+		}
+		for _, ref := range c.Unknown {
+			fmt.Printf("  ⚠ %s declares %s, which is not in the specification\n", ref.RuleID, ref.SpecRuleID)
+		}
+	}
+
+	parseWarnings, filesWithWarnings := 0, 0
+	for _, job := range report.Jobs {
+		if len(job.ParseWarnings) > 0 {
+			parseWarnings += len(job.ParseWarnings)
+			filesWithWarnings++
+		}
+	}
+	if parseWarnings > 0 {
+		fmt.Printf("\nParse Warnings: skipped %d malformed line(s) in %d job file(s) (see parse_warnings in JSON, or use --strict-parse)\n",
+			parseWarnings, filesWithWarnings)
+	}
+
+	var partial []JobScoreResult
+	for _, job := range report.Jobs {
+		if partialDataNote(job) != "" {
+			partial = append(partial, job)
+		}
+	}
+	if len(partial) > 0 {
+		sort.SliceStable(partial, func(i, j int) bool {
+			return partial[i].Confidence.MissingFraction > partial[j].Confidence.MissingFraction
+		})
+		fmt.Printf("\nPartial Data: %d job(s) scored on incomplete metrics, their scores are less certain:\n", len(partial))
+		for i, job := range partial {
+			if i == 10 {
+				fmt.Printf("  ... and %d more (see confidence in JSON)\n", len(partial)-i)
+				break
+			}
+			fmt.Printf("  - %s: %s\n", job.JobName, confidenceSummary(job.Confidence))
+		}
+	}
+
+	acknowledged, exempt, jobsWithWaivers := 0, 0, 0
+	for _, job := range report.Jobs {
+		jobAcknowledged := 0
+		for _, result := range job.RuleResults {
+			jobAcknowledged += len(result.Acknowledged)
+			for _, ack := range result.Acknowledged {
+				if ack.Exempt {
+					exempt++
+				}
+			}
+		}
+		if jobAcknowledged > 0 {
+			acknowledged += jobAcknowledged
+			jobsWithWaivers++
+		}
+	}
+	if acknowledged > 0 {
+		fmt.Printf("\nAcknowledged Failures: %d in %d job(s), %d exempt from the score until their waiver expires\n",
+			acknowledged, jobsWithWaivers, exempt)
+	}
+
+	var unused []UnusedMetric
+	for _, job := range report.Jobs {
+		for _, metric := range job.UnusedMetrics {
+			metric.MetricName = job.JobName + "/" + metric.MetricName
+			unused = append(unused, metric)
+		}
+	}
+	sort.SliceStable(unused, func(i, j int) bool { return unused[i].Cardinality > unused[j].Cardinality })
+	printUnusedMetrics(unused, 10)
+
+	var remediation []RemediationItem
+	for _, job := range report.Jobs {
+		for _, item := range job.Remediation {
+			item.MetricName = job.JobName + "/" + item.MetricName
+			remediation = append(remediation, item)
+		}
+	}
+	sortRemediation(remediation)
+	printRemediation(remediation, 10)
+
+	if len(report.SkippedJobs) > 0 {
+		fmt.Printf("\nSkipped Jobs (%d):\n", len(report.SkippedJobs))
+		for _, job := range report.SkippedJobs {
+			fmt.Printf("  - %s: %s\n", job.File, job.Reason)
+		}
+	}
+
+	if len(report.Warnings) > 0 {
+		fmt.Printf("\nWarnings (%d):\n", len(report.Warnings))
+		for _, warning := range report.Warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+	}
 
 	if minScore > 0 {
 		fmt.Printf("\nJobs Below Threshold (%.2f%%):\n", minScore)
@@ -769,11 +2857,39 @@ func printSummary(report AllJobsReport) {
 		for _, job := range report.Jobs {
 			if job.Score < minScore {
 				count++
-				fmt.Printf("  - %s: %.2f%%\n", job.JobName, job.Score)
+				fmt.Printf("  - %s: %.2f%%%s%s\n", job.JobName, job.Score, partialDataNote(job), jobLink(job.JobName))
 			}
 		}
 		if count == 0 {
 			fmt.Printf("  (none)\n")
 		}
+	} else if reportURL != "" && len(report.Jobs) > 0 {
+		lowest := make([]JobScoreResult, len(report.Jobs))
+		copy(lowest, report.Jobs)
+		sort.SliceStable(lowest, func(i, j int) bool { return lowest[i].Score < lowest[j].Score })
+		if len(lowest) > 5 {
+			lowest = lowest[:5]
+		}
+		fmt.Printf("\nLowest Scoring Jobs:\n")
+		for _, job := range lowest {
+			fmt.Printf("  - %s: %.2f%%%s%s\n", job.JobName, job.Score, partialDataNote(job), jobLink(job.JobName))
+		}
+	}
+}
+
+// partialDataNote returns " [<level> confidence]" for a job scored with less than high confidence
+func partialDataNote(job JobScoreResult) string {
+	level := confidenceLevel(job.Confidence)
+	if level == "" || level == score.ConfidenceHigh {
+		return ""
+	}
+	return " [" + level + " confidence]"
+}
+
+// jobLink returns " (link)" to the job's section of the report at --report-url, if set
+func jobLink(jobName string) string {
+	if reportURL == "" {
+		return ""
 	}
+	return " (" + formatters.JobURL(reportURL, jobName) + ")"
 }
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/health"
+	"instrumentation-score/internal/kube"
+	"instrumentation-score/internal/orgscore"
+	"instrumentation-score/internal/runconfig"
+	"instrumentation-score/internal/server"
+	"instrumentation-score/internal/storage"
+)
+
+// fakeS3Source makes --s3-source download the job files into a new download directory in dir
+func fakeS3Source(t *testing.T, dir string, jobFiles map[string]string) *string {
+	t.Helper()
+	var downloaded string
+	restore := downloadEvaluationSource
+	downloadEvaluationSource = func(ctx context.Context, config storage.EvaluationDownloadConfig) (string, error) {
+		target, err := os.MkdirTemp(dir, storage.DownloadDirPattern)
+		if err != nil {
+			return "", err
+		}
+		for name, content := range jobFiles {
+			if err := os.WriteFile(filepath.Join(target, name), []byte(content), 0600); err != nil {
+				return "", err
+			}
+		}
+		downloaded = target
+		return target, nil
+	}
+
+	savedSource, savedRules, savedOutput, savedFailBelow, savedJobDir := evaluateS3Source, rulesConfig, outputFormats, failBelow, jobDir
+	t.Cleanup(func() {
+		downloadEvaluationSource = restore
+		evaluateS3Source, rulesConfig, outputFormats, failBelow, jobDir = savedSource, savedRules, savedOutput, savedFailBelow, savedJobDir
+	})
+	runSettings = runconfig.Capture("evaluate", evaluateCmd.Flags(), evaluateEnv)
+	evaluateS3Source, rulesConfig, outputFormats, jobDir = true, "../rules_config.yaml", "text", ""
+	return &downloaded
+}
+
+func TestRunEvaluate_RemovesDownloadOnFailure(t *testing.T) {
+	const jobFile = "api|http_requests_total|method,status|1500\napi|up|instance|1\n"
+
+	t.Run("quality gate", func(t *testing.T) {
+		downloaded := fakeS3Source(t, t.TempDir(), map[string]string{"api.txt": jobFile})
+		failBelow = 101
+
+		code, err := runEvaluate()
+		if err != nil || code == 0 {
+			t.Fatalf("runEvaluate() = %d, %v, want the gate exit code", code, err)
+		}
+		if _, err := os.Stat(*downloaded); !os.IsNotExist(err) {
+			t.Errorf("download %s left behind after the gate failed: %v", *downloaded, err)
+		}
+	})
+
+	t.Run("fatal error", func(t *testing.T) {
+		downloaded := fakeS3Source(t, t.TempDir(), nil)
+
+		code, err := runEvaluate()
+		if err == nil || code != 1 {
+			t.Fatalf("runEvaluate() without job files = %d, %v, want exit code 1 and an error", code, err)
+		}
+		if _, err := os.Stat(*downloaded); !os.IsNotExist(err) {
+			t.Errorf("download %s left behind after the run failed: %v", *downloaded, err)
+		}
+	})
+}
+
+func TestScoreJobDir_Concurrent(t *testing.T) {
+	ruleEngine, err := engine.NewRuleEngine("../rules_config.yaml")
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	jobs := []string{"api", "web", "worker", "batch"}
+	dirs := make([]string, len(jobs))
+	for i, job := range jobs {
+		dirs[i] = t.TempDir()
+		content := job + "|http_requests_total|method,status|1500\n" + job + "|up|instance|1\n"
+		if err := os.WriteFile(filepath.Join(dirs[i], job+".txt"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each run scores its own directory, whatever the others score meanwhile
+	reports := make([]AllJobsReport, len(jobs))
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reports[i], errs[i] = scoreJobDir(ruleEngine, jobSource{weighting: orgscore.DefaultWeighting}, dirs[i])
+		}(i)
+	}
+	wg.Wait()
+	for i, job := range jobs {
+		if errs[i] != nil {
+			t.Fatalf("scoreJobDir(%s) error = %v", job, errs[i])
+		}
+		if len(reports[i].Jobs) != 1 || reports[i].Jobs[0].JobName != job {
+			t.Errorf("scoreJobDir(%s) scored %+v, want only job %s", job, reports[i].Jobs, job)
+		}
+	}
+}
+
+func TestScoreJobFiles_Limits(t *testing.T) {
+	ruleEngine, err := engine.NewRuleEngine("../rules_config.yaml")
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"api.txt":   "api|http_requests_total|method,status|1500\napi|up|instance|1\n",
+		"large.txt": "large|a||1\nlarge|b||1\nlarge|c||1\nlarge|d||1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		src        jobSource
+		wantJobs   int
+		wantReason string
+	}{
+		{name: "line limit", src: jobSource{maxLines: 3}, wantJobs: 1, wantReason: "more than 3 lines (--max-job-lines)"},
+		{name: "timeout", src: jobSource{timeout: time.Nanosecond}, wantReason: "evaluation exceeded 1ns (--job-timeout)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.src.weighting = orgscore.DefaultWeighting
+			report, _ := scoreJobDir(ruleEngine, tt.src, dir)
+			if len(report.Jobs) != tt.wantJobs {
+				t.Errorf("scored %d jobs, want %d", len(report.Jobs), tt.wantJobs)
+			}
+			if len(report.SkippedJobs) == 0 || report.SkippedJobs[len(report.SkippedJobs)-1].Reason != tt.wantReason {
+				t.Errorf("skipped %+v, want a job skipped for %q", report.SkippedJobs, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestReadinessChecks(t *testing.T) {
+	prometheusUp := true
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !prometheusUp {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[0,"1"]}]}}`)
+	}))
+	defer prometheus.Close()
+
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	rules, err := os.ReadFile("../rules_config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rulesFile, rules, 0600); err != nil {
+		t.Fatal(err)
+	}
+	schedules := []scheduledCollection{{Name: "nightly", URL: prometheus.URL}}
+	probes := health.NewServer(0, readinessChecks(rulesFile, false, schedules, nil)...)
+
+	readyz := func() (int, health.Status) {
+		recorder := httptest.NewRecorder()
+		probes.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/readyz", nil))
+		var status health.Status
+		if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+			t.Fatalf("invalid /readyz body %q: %v", recorder.Body.String(), err)
+		}
+		return recorder.Code, status
+	}
+
+	if code, status := readyz(); code != http.StatusOK {
+		t.Fatalf("/readyz = %d %v, want 200", code, status.Checks)
+	}
+
+	prometheusUp = false
+	code, status := readyz()
+	if code != http.StatusServiceUnavailable || !strings.HasPrefix(status.Checks["prometheus"], "schedule nightly:") || status.Checks["rules"] != "ok" {
+		t.Errorf("/readyz with Prometheus down = %d %v, want 503 naming the schedule", code, status.Checks)
+	}
+
+	prometheusUp = true
+	if err := os.WriteFile(rulesFile, []byte("rules: ["), 0600); err != nil {
+		t.Fatal(err)
+	}
+	code, status = readyz()
+	if code != http.StatusServiceUnavailable || status.Checks["rules"] == "ok" || status.Checks["prometheus"] != "ok" {
+		t.Errorf("/readyz with invalid rules = %d %v, want 503 naming the rules", code, status.Checks)
+	}
+}
+
+func TestScheduler_OnlyLeaderRuns(t *testing.T) {
+	holder := "other-replica"
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && holder == "":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "GET":
+			json.NewEncoder(w).Encode(kube.Lease{Spec: kube.LeaseSpec{
+				HolderIdentity:       holder,
+				LeaseDurationSeconds: 15,
+				RenewTime:            time.Now().UTC().Format(time.RFC3339Nano),
+			}})
+		case r.Method == "POST":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, "{}")
+		default:
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+	defer api.Close()
+	t.Setenv("KUBE_API_SERVER", api.URL)
+	t.Setenv("url", "")
+	client, err := kube.NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	elector := kube.NewLeaderElector(client, "ops", "serve", "this-replica", leaderLeaseDuration)
+	runs := server.NewRuns(ctx, 0, 1, 0)
+	s := &scheduler{runs: runs, dir: t.TempDir(), leader: elector}
+	nightly := scheduledCollection{Name: "nightly"}
+
+	if leader, err := elector.TryAcquireOrRenew(); leader || err != nil {
+		t.Fatalf("TryAcquireOrRenew() = %v, %v, want the lease held by another replica", leader, err)
+	}
+	s.run(ctx, nightly)
+	if listed := runs.List(); len(listed) != 0 {
+		t.Fatalf("a replica that is not the leader ran %d schedule(s)", len(listed))
+	}
+
+	holder = ""
+	if leader, err := elector.TryAcquireOrRenew(); !leader || err != nil {
+		t.Fatalf("TryAcquireOrRenew() = %v, %v, want the free lease acquired", leader, err)
+	}
+	s.run(ctx, nightly)
+	if listed := runs.List(); len(listed) != 1 || listed[0].Schedule != "nightly" {
+		t.Errorf("the leader's runs = %+v, want the nightly schedule", listed)
+	}
+}
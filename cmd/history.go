@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/history"
+	"instrumentation-score/internal/locale"
+	"instrumentation-score/internal/orgscore"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultHistoryDB is where evaluate --record-history records runs and history reads them
+const defaultHistoryDB = "score_history.db"
+
+var (
+	historyPath   string
+	historyJob    string
+	historySince  string
+	historyLast   int
+	historyOutput string
+	historyLocale string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show how job scores changed over the runs recorded by evaluate",
+	Long: `Query the runs evaluate --record-history recorded, to see whether instrumentation
+quality is improving.
+
+With --job, every recorded run of the job is listed with its score, the change since
+the run before, its metrics and series, and the rules it failed. Runs scored by
+different rules than the run before are marked, as their score change may come from
+the rules. Without --job, every job is listed with its first and latest score in the
+period, largest drop first.
+
+Examples:
+  # Record each run
+  instrumentation-score evaluate --job-dir ./reports/job_metrics_*/ --record-history
+
+  # Score progression of a job over the last 30 days
+  instrumentation-score history --job api-service --since 30d
+
+  # Every job's change since the start of the quarter, as JSON
+  instrumentation-score history --since 2025-10-01 --output json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runHistory()
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyPath, "history-db", defaultHistoryDB, "SQLite database evaluate --record-history records runs in")
+	historyCmd.Flags().StringVarP(&historyJob, "job", "j", "", "Job to show the runs of (default: summarize every job)")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only runs in this period, e.g. 30d, 12h or 2025-11-01 (default: all)")
+	historyCmd.Flags().IntVar(&historyLast, "last", 0, "Only the job's latest runs, e.g. 10 (with --job; 0 for all)")
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", "text", "Output format: text or json")
+	historyCmd.Flags().StringVar(&historyLocale, "locale", "en", "Locale of the text output (built in: en, de, fr, es)")
+}
+
+func runHistory() {
+	if historyOutput != "text" && historyOutput != "json" {
+		fmt.Printf("ERROR: unsupported output format %q for history\n", historyOutput)
+		os.Exit(1)
+	}
+	since, err := parseSince(historySince, time.Now())
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	l, err := locale.Get(historyLocale)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	formatters.SetLocale(l)
+
+	if _, err := os.Stat(historyPath); err != nil {
+		fmt.Printf("ERROR: no history database at %s; record runs with evaluate --record-history\n", historyPath)
+		os.Exit(1)
+	}
+	store, err := history.OpenStore(historyPath)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	var result interface{}
+	var text string
+	if historyJob != "" {
+		points, err := store.Trend(historyJob, since, historyLast)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		result = struct {
+			JobName string               `json:"job_name"`
+			Runs    []history.TrendPoint `json:"runs"`
+		}{historyJob, points}
+		text = formatters.HistoryText(historyJob, points)
+	} else {
+		jobs, err := store.Jobs(since)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		first, latest := history.ScoreDistributions(jobs)
+		result = struct {
+			Jobs   []history.JobSummary  `json:"jobs"`
+			First  orgscore.Distribution `json:"first_distribution"`
+			Latest orgscore.Distribution `json:"latest_distribution"`
+		}{jobs, first, latest}
+		text = formatters.HistoryJobsText(jobs)
+	}
+
+	if historyOutput == "text" {
+		fmt.Print(text)
+		return
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("ERROR: Failed to marshal JSON: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+// parseSince turns --since into the start of the period: a duration before now, with d for
+// days (30d), or a date (2025-11-01). An empty value is the zero time, for all runs.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid --since %q: use a number of days such as 30d", value)
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since %q: use a period such as 30d or 12h, or a date such as 2025-11-01", value)
+	}
+	return now.Add(-d), nil
+}
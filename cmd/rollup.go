@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/locale"
+	"instrumentation-score/internal/rollup"
+	"instrumentation-score/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollupUnits    string
+	rollupOutput   string
+	rollupJSONFile string
+	rollupHTMLFile string
+	rollupLocale   string
+	rollupCatalog  string
+)
+
+var rollupCmd = &cobra.Command{
+	Use:   "rollup",
+	Short: "Roll up the latest evaluation of every business unit into one report",
+	Long: `Aggregate the latest evaluation each business unit uploaded with evaluate --s3-upload
+into a single organization report with per-unit scores and organization totals.
+
+Units are listed in a YAML file, each with the bucket and prefix it uploads to, and
+optionally the region and an IAM role to assume for buckets in other accounts. The
+newest <prefix>/evaluations/<run-id>/manifest.json of each unit is read. Units that
+cannot be read are reported and left out of the totals.
+
+The organization score is the average over all jobs, so larger units weigh more.
+
+Examples:
+  # Executive summary on the console
+  instrumentation-score rollup --units units.yaml
+
+  # HTML and JSON reports
+  instrumentation-score rollup --units units.yaml \
+    --output html,json --html-file org.html --json-file org.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runRollup()
+	},
+}
+
+func init() {
+	rollupCmd.Flags().StringVar(&rollupUnits, "units", "", "YAML file listing the business units and where their evaluations are uploaded (required)")
+	rollupCmd.Flags().StringVarP(&rollupOutput, "output", "o", "text", "Output formats (comma-separated): text,json,html")
+	rollupCmd.Flags().StringVar(&rollupJSONFile, "json-file", "", "JSON output file path (default: stdout)")
+	rollupCmd.Flags().StringVar(&rollupHTMLFile, "html-file", "", "HTML output file path")
+	rollupCmd.Flags().StringVar(&rollupLocale, "locale", "en", "Locale of the text and HTML reports (built in: en, de, fr, es)")
+	rollupCmd.Flags().StringVar(&rollupCatalog, "locale-catalog", "", "YAML message catalog adding or overriding translations and formats for --locale")
+	rollupCmd.MarkFlagRequired("units")
+}
+
+func runRollup() {
+	formats := parseOutputFormats(rollupOutput)
+	for _, format := range formats {
+		switch format {
+		case "text", "json":
+		case "html":
+			if rollupHTMLFile == "" {
+				fmt.Println("ERROR: --html-file is required when using --output html")
+				os.Exit(1)
+			}
+		default:
+			fmt.Printf("ERROR: unsupported output format %q for rollup\n", format)
+			os.Exit(1)
+		}
+	}
+
+	l, err := locale.Load(rollupLocale, rollupCatalog)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	formatters.SetLocale(l)
+
+	config, err := rollup.Load(rollupUnits)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	var units []rollup.UnitSummary
+	for _, unit := range config.Units {
+		manifest, err := storage.LatestEvaluation(unit.Bucket, unit.Prefix, unit.Region, unit.RoleARN)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: %v\n", unit.Name, err)
+			units = append(units, rollup.Failed(unit, err))
+			continue
+		}
+		units = append(units, rollup.Summarize(unit, manifest))
+	}
+
+	report := rollup.NewReport(units, time.Now().UTC().Format(time.RFC3339))
+	if report.MissingUnits == len(report.Units) {
+		fmt.Println("ERROR: no unit's latest evaluation could be read")
+		os.Exit(1)
+	}
+
+	for _, format := range formats {
+		switch format {
+		case "text":
+			fmt.Print(formatters.RollupText(report))
+
+		case "json":
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Printf("ERROR: Failed to marshal JSON: %v\n", err)
+				os.Exit(1)
+			}
+			if rollupJSONFile == "" {
+				fmt.Println(string(data))
+				continue
+			}
+			if err := os.WriteFile(rollupJSONFile, data, 0600); err != nil {
+				fmt.Printf("ERROR: Failed to write JSON file: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("JSON report saved to %s\n", rollupJSONFile)
+
+		case "html":
+			page, err := formatters.RollupHTML(report)
+			if err != nil {
+				fmt.Printf("ERROR: %v\n", err)
+				os.Exit(1)
+			}
+			if err := os.WriteFile(rollupHTMLFile, []byte(page), 0600); err != nil {
+				fmt.Printf("ERROR: Failed to write HTML file: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("HTML report saved to %s\n", rollupHTMLFile)
+		}
+	}
+}
@@ -7,6 +7,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Version is the release this binary was built from, set at build time with
+// -ldflags "-X instrumentation-score/cmd.Version=v1.2.3"
+var Version = "dev"
+
 var rootCmd = &cobra.Command{
 	Use:   "instrumentation-score",
 	Short: "Evaluate Prometheus metrics quality with automated scoring",
@@ -18,6 +22,15 @@ adapted for Prometheus metrics.
 Commands:
   analyze     - Collect metrics from Prometheus grouped by job
   evaluate    - Evaluate job metrics with scoring and cost analysis
+  score-local - Score one application's /metrics endpoint, e.g. in a pre-commit hook
+  ci          - Score metrics in a CI pipeline, configured from the environment
+  controller  - Continuously score opted-in Kubernetes Deployments
+  serve       - Serve scores and the dashboard over an HTTP API
+  rollup      - Roll up the latest evaluation of every business unit
+  history     - Show how job scores changed over recorded runs
+  decrypt     - Decrypt a report encrypted by evaluate --encrypt
+  rules       - Export the built-in rules for customization
+  self-update - Replace this binary with the latest release
   completion  - Generate shell completion scripts
 
 Workflow:
@@ -91,7 +104,17 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.Version = Version
 	rootCmd.AddCommand(analyzeCmd)
 	rootCmd.AddCommand(evaluateCmd)
+	rootCmd.AddCommand(scoreLocalCmd)
+	rootCmd.AddCommand(ciCmd)
+	rootCmd.AddCommand(controllerCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(rollupCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(decryptCmd)
+	rootCmd.AddCommand(rulesCmd)
+	rootCmd.AddCommand(selfUpdateCmd)
 	rootCmd.AddCommand(completionCmd)
 }
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/spec"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultRulesFile is the --rules default and the name of the rules file among the built-in rules
+const defaultRulesFile = "rules_config.yaml"
+
+// builtinRulesName names the built-in rules in reports and messages
+const builtinRulesName = "(built-in)"
+
+// builtinRules holds the rules shipped with the binary, see SetDefaultRules
+var builtinRules fs.FS
+
+// SetDefaultRules sets the rules used when no rules file is given or found
+// fsys holds rules_config.yaml and the rule packs it includes.
+func SetDefaultRules(fsys fs.FS) {
+	builtinRules = fsys
+}
+
+var (
+	exportRulesDir   string
+	exportRulesForce bool
+
+	specRepo   string
+	specRef    string
+	specDir    string
+	specRules  string
+	specOutput string
+	specForce  bool
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage the rules configuration",
+}
+
+var exportDefaultsCmd = &cobra.Command{
+	Use:   "export-defaults",
+	Short: "Write the built-in rules to files for customization",
+	Long: `Write the rules built into the binary, rules_config.yaml and the rule packs it
+includes, to a directory. Edit the files and pass them to evaluate with --rules.
+
+evaluate uses the built-in rules when --rules is not set and there is no
+rules_config.yaml in the working directory.
+
+Examples:
+  # Write rules_config.yaml and rules/packs/ to the current directory
+  instrumentation-score rules export-defaults
+
+  # Write them elsewhere, replacing earlier exports
+  instrumentation-score rules export-defaults --output-dir config/ --force`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runExportDefaults()
+	},
+}
+
+var importRulesCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import rules from other sources into this tool's rules schema",
+}
+
+var importSpecCmd = &cobra.Command{
+	Use:   "spec",
+	Short: "Import the rules of the Instrumentation Score specification",
+	Long: `Fetch the rules of the Instrumentation Score specification
+(https://github.com/instrumentation-score/spec) and convert them to a rule pack.
+
+Rules declare the specification rules they implement in spec_rule_ids. Specification
+rules that no rule of --rules implements are written to the pack as placeholders: rules
+with the specification's ID, description and impact, commented with its target and
+criteria, but no validators. Placeholders do not affect scores until validators are
+added. The summary lists which specification rules are implemented and by which rules,
+and evaluate reports spec_rule_ids with every rule result.
+
+GITHUB_TOKEN, when set, is sent with every request to avoid API rate limits.
+
+Examples:
+  # Import the specification's main branch next to the rules file
+  instrumentation-score rules import spec --rules rules_config.yaml --output rules/packs/spec.yaml
+
+  # Import a release, or a local checkout of the specification
+  instrumentation-score rules import spec --ref v0.1.0
+  instrumentation-score rules import spec --from ../spec/rules`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if useBuiltinRules(cmd.Flags(), specRules) {
+			specRules = ""
+		}
+		runImportSpec()
+	},
+}
+
+func init() {
+	exportDefaultsCmd.Flags().StringVar(&exportRulesDir, "output-dir", ".", "Directory to write the rules to")
+	exportDefaultsCmd.Flags().BoolVar(&exportRulesForce, "force", false, "Overwrite existing files")
+	rulesCmd.AddCommand(exportDefaultsCmd)
+
+	importSpecCmd.Flags().StringVar(&specRepo, "repo", spec.DefaultRepo, "GitHub repository of the specification")
+	importSpecCmd.Flags().StringVar(&specRef, "ref", spec.DefaultRef, "Branch, tag or commit of the specification to import")
+	importSpecCmd.Flags().StringVar(&specDir, "from", "", "Read the rules from this local directory instead of GitHub")
+	importSpecCmd.Flags().StringVarP(&specRules, "rules", "r", defaultRulesFile, "Rules configuration whose spec_rule_ids are matched; the built-in rules are used when left at the default and the file does not exist")
+	importSpecCmd.Flags().StringVarP(&specOutput, "output", "o", "rules/packs/spec.yaml", "Rule pack to write the placeholders to")
+	importSpecCmd.Flags().BoolVar(&specForce, "force", false, "Overwrite an existing output file")
+	importRulesCmd.AddCommand(importSpecCmd)
+	rulesCmd.AddCommand(importRulesCmd)
+}
+
+func runImportSpec() {
+	rules, origin, err := loadSpecRules(specDir, specRepo, specRef)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	ruleEngine, err := newRuleEngine(specRules)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	result := spec.Convert(rules, ruleEngine.Rules())
+
+	fmt.Printf("Specification rules from %s: %d\n", origin, len(rules))
+	for _, rule := range rules {
+		if implementers, ok := result.Implemented[rule.ID]; ok {
+			fmt.Printf("  ✓ %-10s %-9s implemented by %s\n", rule.ID, rule.Impact, strings.Join(implementers, ", "))
+		} else {
+			fmt.Printf("  ○ %-10s %-9s %s (placeholder)\n", rule.ID, rule.Impact, rule.Title)
+		}
+	}
+	fmt.Printf("Implemented: %d of %d\n", len(rules)-len(result.Imported), len(rules))
+	if len(result.Imported) == 0 {
+		return
+	}
+
+	if _, err := os.Stat(specOutput); err == nil && !specForce {
+		fmt.Printf("ERROR: %s already exists, use --force to overwrite it\n", specOutput)
+		os.Exit(1)
+	}
+	pack, err := result.Pack(origin)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(specOutput), 0755); err == nil {
+			err = os.WriteFile(specOutput, pack, 0644)
+		}
+	}
+	if err != nil {
+		fmt.Printf("ERROR: failed to write %s: %v\n", specOutput, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Wrote %d placeholder rules to %s; add it to the include list of the rules file\n", len(result.Imported), specOutput)
+}
+
+// loadSpecRules reads the specification's rules from the rules directory of a local checkout,
+// or from repo at ref on GitHub when dir is empty, and returns them with where they came from
+func loadSpecRules(dir, repo, ref string) ([]spec.Rule, string, error) {
+	if dir != "" {
+		rules, err := spec.LoadDir(os.DirFS(dir), ".")
+		return rules, dir, err
+	}
+	client := &spec.Client{
+		HTTP:   &http.Client{Timeout: time.Minute},
+		APIURL: spec.DefaultAPIURL,
+		Token:  os.Getenv("GITHUB_TOKEN"),
+	}
+	rules, err := client.Fetch(repo, ref)
+	return rules, repo + "@" + ref, err
+}
+
+func runExportDefaults() {
+	if builtinRules == nil {
+		fmt.Println("ERROR: this binary was built without built-in rules")
+		os.Exit(1)
+	}
+
+	var files []string
+	err := fs.WalkDir(builtinRules, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		target := filepath.Join(exportRulesDir, filepath.FromSlash(name))
+		if _, err := os.Stat(target); err == nil && !exportRulesForce {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", target)
+		}
+		files = append(files, name)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, name := range files {
+		data, err := fs.ReadFile(builtinRules, name)
+		if err == nil {
+			target := filepath.Join(exportRulesDir, filepath.FromSlash(name))
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+				err = os.WriteFile(target, data, 0644)
+			}
+		}
+		if err != nil {
+			fmt.Printf("ERROR: failed to write %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Wrote %s\n", filepath.Join(exportRulesDir, filepath.FromSlash(name)))
+	}
+}
+
+// useBuiltinRules reports whether a command should use the built-in rules: its --rules
+// flag was left at the default and there is no rules_config.yaml in the working directory
+func useBuiltinRules(flags *pflag.FlagSet, rulesFile string) bool {
+	if builtinRules == nil || flags.Changed("rules") || rulesFile != defaultRulesFile {
+		return false
+	}
+	_, err := os.Stat(rulesFile)
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// newRuleEngine loads rulesFile, or the built-in rules when rulesFile is empty
+func newRuleEngine(rulesFile string) (*engine.RuleEngine, error) {
+	if rulesFile == "" {
+		return engine.NewRuleEngineFS(builtinRules, defaultRulesFile)
+	}
+	return engine.NewRuleEngine(rulesFile)
+}
+
+// readRulesFile returns the content of rulesFile, or of the built-in rules when rulesFile is empty
+func readRulesFile(rulesFile string) ([]byte, error) {
+	if rulesFile == "" {
+		return fs.ReadFile(builtinRules, defaultRulesFile)
+	}
+	return os.ReadFile(rulesFile)
+}
+
+// rulesDisplayName names rulesFile in reports, "(built-in)" when it is empty
+func rulesDisplayName(rulesFile string) string {
+	if rulesFile == "" {
+		return builtinRulesName
+	}
+	return rulesFile
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"instrumentation-score/internal/collectors"
+	"instrumentation-score/internal/cron"
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/kube"
+	"instrumentation-score/internal/server"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scheduleConfig is the --schedules file of serve
+//
+// Example schedules.yaml:
+//
+//	schedules:
+//	  - name: prod-nightly
+//	    cron: "0 2 * * *"
+//	    selector: 'cluster="prod"'
+//	  - name: team-a
+//	    cron: "0 */6 * * *"
+//	    url: https://mimir.example.com/prometheus
+//	    tenant: team-a
+//	    login_env: TEAM_A_LOGIN
+type scheduleConfig struct {
+	Schedules []scheduledCollection `yaml:"schedules"`
+}
+
+// scheduledCollection is an analyze and evaluate cycle serve runs on a cron schedule
+type scheduledCollection struct {
+	Name     string `yaml:"name"`
+	Cron     string `yaml:"cron"`      // Five-field cron expression in local time, or @daily etc.
+	Selector string `yaml:"selector"`  // Only collect series matching these label matchers, like analyze --selector
+	URL      string `yaml:"url"`       // Prometheus URL (default: the url environment variable)
+	LoginEnv string `yaml:"login_env"` // Environment variable holding user:password (default: login)
+	Tenant   string `yaml:"tenant"`    // Sent as X-Scope-OrgID to multi-tenant Mimir or Cortex
+
+	schedule cron.Schedule
+	selector collectors.Selector
+}
+
+// loadSchedules reads and validates a --schedules file
+func loadSchedules(filename string) ([]scheduledCollection, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedules: %w", err)
+	}
+	var config scheduleConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse schedules: %w", err)
+	}
+	if len(config.Schedules) == 0 {
+		return nil, fmt.Errorf("%s: no schedules defined", filename)
+	}
+
+	names := make(map[string]bool)
+	for i := range config.Schedules {
+		s := &config.Schedules[i]
+		if s.Name == "" {
+			return nil, fmt.Errorf("%s: schedules[%d]: name is required", filename, i)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("%s: duplicate schedule %s", filename, s.Name)
+		}
+		names[s.Name] = true
+		if s.schedule, err = cron.Parse(s.Cron); err != nil {
+			return nil, fmt.Errorf("%s: schedule %s: %w", filename, s.Name, err)
+		}
+		if s.selector, err = collectors.ParseSelector(s.Selector); err != nil {
+			return nil, fmt.Errorf("%s: schedule %s: %w", filename, s.Name, err)
+		}
+	}
+	return config.Schedules, nil
+}
+
+// prometheusClient creates the client the schedule collects with
+func (s scheduledCollection) prometheusClient() (*collectors.PrometheusClient, error) {
+	url := s.URL
+	if url == "" {
+		url = os.Getenv("url")
+	}
+	if url == "" {
+		return nil, fmt.Errorf("schedule %s has no url, and the url environment variable is not set", s.Name)
+	}
+	loginEnv := s.LoginEnv
+	if loginEnv == "" {
+		loginEnv = "login"
+	}
+	client := collectors.NewPrometheusClient(url, os.Getenv(loginEnv))
+	if s.Tenant != "" {
+		client.Headers = map[string]string{"X-Scope-OrgID": s.Tenant}
+	}
+	return client, nil
+}
+
+// scheduler runs the scheduled collections of serve, recording each in the run registry
+// and, with --history-db, in the history store
+type scheduler struct {
+	rules  *engine.ReloadingEngine
+	source jobSource // How the collected job files are scored
+	runs   *server.Runs
+	dir    string              // Each run collects into a directory here, removed once scored
+	leader *kube.LeaderElector // With --leader-elect, only runs the schedules while it holds the lease
+
+	record    bool       // Record the runs in --history-db
+	historyMu sync.Mutex // Serializes recording the runs of different schedules
+}
+
+// start submits every schedule at each activation until ctx is done; an activation missed
+// while the schedule's previous run was still queued or going is skipped
+func (s *scheduler) start(ctx context.Context, schedules []scheduledCollection) {
+	for _, schedule := range schedules {
+		go func(schedule scheduledCollection) {
+			for {
+				next := schedule.schedule.Next(time.Now())
+				if next.IsZero() {
+					fmt.Printf("WARNING: schedule %s never runs: %s\n", schedule.Name, schedule.Cron)
+					return
+				}
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				s.run(ctx, schedule)
+			}
+		}(schedule)
+	}
+}
+
+// run queues a run collecting and scoring the jobs of a schedule and waits until it finished
+// A replica that is not the leader skips the activation, which the leader runs.
+func (s *scheduler) run(ctx context.Context, schedule scheduledCollection) {
+	if s.leader != nil && !s.leader.IsLeader() {
+		return
+	}
+	var report AllJobsReport
+	id, err := s.runs.Submit(server.Run{Kind: server.RunSchedule, Schedule: schedule.Name}, func(ctx context.Context) (server.RunReport, error) {
+		var err error
+		if report, err = s.collectAndScore(ctx, schedule); err != nil {
+			return nil, err
+		}
+		return func(visible func(job string) bool) interface{} {
+			return visibleReport(report, visible)
+		}, nil
+	})
+	if err != nil {
+		fmt.Printf("WARNING: skipping schedule %s: %v\n", schedule.Name, err)
+		return
+	}
+	fmt.Printf("Run %s of schedule %s queued\n", id, schedule.Name)
+	run, err := s.runs.Wait(ctx, id)
+	switch {
+	case err != nil:
+		return
+	case run.Status == server.RunFailed:
+		fmt.Printf("WARNING: run %s of schedule %s failed: %s\n", id, schedule.Name, run.Error)
+	default:
+		fmt.Printf("Run %s of schedule %s scored %d job(s), average %.2f\n", id, schedule.Name, report.TotalJobs, report.AverageScore)
+	}
+}
+
+// collectAndScore collects the schedule's jobs into a run directory and scores them
+func (s *scheduler) collectAndScore(ctx context.Context, schedule scheduledCollection) (AllJobsReport, error) {
+	client, err := schedule.prometheusClient()
+	if err != nil {
+		return AllJobsReport{}, err
+	}
+	runDir, err := os.MkdirTemp(s.dir, "run-")
+	if err != nil {
+		return AllJobsReport{}, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	defer os.RemoveAll(runDir)
+
+	jobMetricsDir := filepath.Join(runDir, "job_metrics")
+	if err := os.MkdirAll(jobMetricsDir, 0700); err != nil {
+		return AllJobsReport{}, fmt.Errorf("failed to create job metrics directory: %w", err)
+	}
+	records, err := collectFromPrometheus(ctx, client, schedule.selector, nil, jobMetricsDir, filepath.Join(runDir, "slow_metrics.txt"))
+	if err != nil {
+		return AllJobsReport{}, err
+	}
+	if len(records) > 0 {
+		fmt.Printf("WARNING: Encountered %d errors during collection\n", len(records))
+	}
+	if err := ctx.Err(); err != nil {
+		return AllJobsReport{}, errors.New("stopped by shutdown")
+	}
+
+	ruleEngine, _ := s.rules.Current()
+	report, err := scoreJobDir(ruleEngine, s.source, jobMetricsDir)
+	if err != nil {
+		return AllJobsReport{}, err
+	}
+	report.Selector = schedule.Selector
+	if s.record {
+		s.historyMu.Lock()
+		recordHistory(ruleEngine, report)
+		s.historyMu.Unlock()
+	}
+	return report, nil
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"instrumentation-score/internal/collectors"
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/loaders"
+	"instrumentation-score/pkg/score"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	localRulesFile  string
+	localJob        string
+	localMinScore   float64
+	localOutput     string
+	localMaxMetrics int
+)
+
+var scoreLocalCmd = &cobra.Command{
+	Use:   "score-local <metrics-url|file|->",
+	Short: "Score one application's /metrics endpoint or exposition file",
+	Long: `Score a single application from its /metrics endpoint, a saved exposition file or
+standard input ("-"), and print the score and the failing metrics in seconds.
+
+Nothing is written to disk and no Prometheus is needed, so it fits pre-commit hooks
+and local development loops: start the application, point score-local at it and fix
+the failures before they reach a cluster. With --min-score the command exits with
+status 1 when the score is lower, failing the hook.
+
+Examples:
+  # Score a running application
+  instrumentation-score score-local http://localhost:8080/metrics
+
+  # Score a saved exposition with the organization rules, failing below 80
+  instrumentation-score score-local metrics.prom --rules org-rules.yaml --min-score 80
+
+  # Score the output of a test binary
+  ./bin/app --dump-metrics | instrumentation-score score-local - --job checkout`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if useBuiltinRules(cmd.Flags(), localRulesFile) {
+			localRulesFile = ""
+		}
+		runScoreLocal(args[0])
+	},
+}
+
+func init() {
+	scoreLocalCmd.Flags().StringVarP(&localRulesFile, "rules", "r", defaultRulesFile, "Path to rules configuration file (built-in rules when not set and missing)")
+	scoreLocalCmd.Flags().StringVar(&localJob, "job", "local", "Job name the metrics are scored as, for job exclusions and reports")
+	scoreLocalCmd.Flags().Float64Var(&localMinScore, "min-score", 0, "Exit with status 1 when the score is below this value (0 disables)")
+	scoreLocalCmd.Flags().StringVarP(&localOutput, "output", "o", "text", "Output format: text or json")
+	scoreLocalCmd.Flags().IntVar(&localMaxMetrics, "max-metrics", 10, "Failing metrics listed per rule in text output (0 lists all)")
+}
+
+func runScoreLocal(source string) {
+	started := time.Now()
+	if localOutput != "text" && localOutput != "json" {
+		fmt.Printf("ERROR: unknown output format %s, use text or json\n", localOutput)
+		os.Exit(1)
+	}
+
+	ruleEngine, err := newRuleEngine(localRulesFile)
+	if err != nil {
+		fmt.Printf("ERROR: failed to load rules: %v\n", err)
+		os.Exit(1)
+	}
+
+	jobData, err := readLocalMetrics(source, localJob)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	result, err := score.EvaluateJob(context.Background(), ruleEngine, localJob, jobData, score.Options{})
+	if err != nil {
+		fmt.Printf("ERROR: %s: %v\n", source, err)
+		os.Exit(1)
+	}
+	logScoreWarnings(result)
+
+	if localOutput == "json" {
+		printLocalJSON(result)
+	} else {
+		printLocalText(len(result.Evaluated), result.RuleResults, result.Score, time.Since(started))
+	}
+
+	if localMinScore > 0 && result.Score < localMinScore {
+		fmt.Fprintf(os.Stderr, "ERROR: score %.1f is below --min-score %.1f\n", result.Score, localMinScore)
+		os.Exit(1)
+	}
+}
+
+// readLocalMetrics returns the metrics of job: source is scraped when it is a URL, and
+// otherwise read as an exposition file, or from standard input when it is "-"
+func readLocalMetrics(source, job string) ([]loaders.JobMetricData, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return collectors.ScrapeJob(context.Background(), collectors.ScrapeTarget{Job: job, URL: source})
+	}
+
+	var r io.Reader = os.Stdin
+	instance := "stdin"
+	if source != "-" {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+		instance = source
+	}
+	jobData, err := collectors.ReadExposition(r, job, instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	return jobData, nil
+}
+
+// sortedFailedMetrics returns the names of the metrics that failed result, sorted
+func sortedFailedMetrics(result engine.RuleResult) []string {
+	names := make([]string, 0, len(result.FailedMetrics))
+	for name := range result.FailedMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printLocalText prints the score and, per failing rule, the metrics that failed and why
+func printLocalText(metrics int, results []engine.RuleResult, localScore float64, elapsed time.Duration) {
+	fmt.Printf("Score: %.1f/100 (%s) - %s, %d metrics, %.2fs\n\n", localScore, score.Category(localScore), localJob, metrics, elapsed.Seconds())
+
+	passed := 0
+	for _, result := range results {
+		if len(result.FailedMetrics) == 0 && len(result.Errors) == 0 {
+			passed++
+			continue
+		}
+		fmt.Printf("✗ %s (%s): %d/%d metrics passed\n", result.RuleID, result.Impact, result.PassedMetrics, result.TotalMetrics)
+
+		names := sortedFailedMetrics(result)
+		for i, name := range names {
+			if localMaxMetrics > 0 && i == localMaxMetrics {
+				fmt.Printf("    ...and %d more\n", len(names)-i)
+				break
+			}
+			var problems []string
+			for _, validator := range result.FailedMetrics[name] {
+				problems = append(problems, result.ValidatorUI(validator).Title)
+			}
+			fmt.Printf("    %s: %s\n", name, strings.Join(problems, ", "))
+		}
+		for _, evalErr := range result.Errors {
+			fmt.Printf("    evaluation error: %s\n", evalErr)
+		}
+	}
+	fmt.Printf("\n✓ %d of %d rules passed\n", passed, len(results))
+}
+
+// printLocalJSON prints the result in the shape of a job in evaluate's JSON report
+func printLocalJSON(result score.JobScore) {
+	data, err := json.MarshalIndent(jobScoreResult(result), "", "  ")
+	if err != nil {
+		fmt.Printf("ERROR: failed to marshal JSON: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"instrumentation-score/internal/selfupdate"
+
+	"github.com/spf13/cobra"
+)
+
+// UpdatePublicKey is the base64 encoded Ed25519 key release checksums are signed with,
+// set at build time with -ldflags "-X instrumentation-score/cmd.UpdatePublicKey=..."
+var UpdatePublicKey = ""
+
+var (
+	updateCheck      bool
+	updateForce      bool
+	updateReleaseURL string
+	updatePublicKey  string
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace this binary with the latest release",
+	Long: `Download the latest release for this platform, verify it and replace the running binary,
+so CI agents stay current without rebuilding their images.
+
+The archive is checked against checksums.txt of the release. When a public key is
+built in or given with --public-key, checksums.txt must carry a valid Ed25519
+signature (checksums.txt.sig) and the update is refused otherwise.
+
+--release-url points at a mirror serving the GitHub releases API format
+({"tag_name": ..., "assets": [{"name": ..., "browser_download_url": ...}]}).
+GITHUB_TOKEN, when set, is sent to api.github.com and github.com, never to mirrors
+or asset hosts, to avoid API rate limits.
+
+Examples:
+  # Report whether an update is available, exiting with status 1 if so
+  instrumentation-score self-update --check
+
+  # Update from an internal mirror
+  instrumentation-score self-update --release-url https://mirror.example.com/instrumentation-score/latest.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runSelfUpdate()
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().BoolVar(&updateCheck, "check", false, "Only report whether a newer release exists (exit status 1 if so)")
+	selfUpdateCmd.Flags().BoolVar(&updateForce, "force", false, "Install the latest release even if it is not newer, e.g. over a dev build")
+	selfUpdateCmd.Flags().StringVar(&updateReleaseURL, "release-url", selfupdate.DefaultReleaseURL, "Endpoint describing the latest release")
+	selfUpdateCmd.Flags().StringVar(&updatePublicKey, "public-key", "", "Base64 Ed25519 public key to verify checksums.txt.sig with (default: the built-in key)")
+}
+
+func runSelfUpdate() {
+	client := &selfupdate.Client{
+		HTTP:  &http.Client{Timeout: 5 * time.Minute},
+		Token: os.Getenv("GITHUB_TOKEN"),
+	}
+	release, err := client.Latest(updateReleaseURL)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	newer := selfupdate.Newer(release.Tag, Version)
+	if updateCheck {
+		if newer {
+			fmt.Printf("ℹ️  %s is available (current: %s)\n", release.Tag, Version)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ %s is up to date (latest: %s)\n", Version, release.Tag)
+		return
+	}
+	if !newer && !updateForce {
+		fmt.Printf("✓ %s is up to date (latest: %s)\n", Version, release.Tag)
+		return
+	}
+
+	name := selfupdate.ArchiveName(runtime.GOOS, runtime.GOARCH)
+	archiveAsset, ok := release.Asset(name)
+	if !ok {
+		fmt.Printf("ERROR: release %s has no %s\n", release.Tag, name)
+		os.Exit(1)
+	}
+	checksumsAsset, ok := release.Asset(selfupdate.ChecksumsAsset)
+	if !ok {
+		fmt.Printf("ERROR: release %s has no %s, refusing to install an unverified binary\n", release.Tag, selfupdate.ChecksumsAsset)
+		os.Exit(1)
+	}
+	checksums, err := client.Download(checksumsAsset.URL)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	publicKey := updatePublicKey
+	if publicKey == "" {
+		publicKey = UpdatePublicKey
+	}
+	if publicKey != "" {
+		signatureAsset, ok := release.Asset(selfupdate.SignatureAsset)
+		if !ok {
+			fmt.Printf("ERROR: release %s has no %s\n", release.Tag, selfupdate.SignatureAsset)
+			os.Exit(1)
+		}
+		signature, err := client.Download(signatureAsset.URL)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		if err := selfupdate.VerifySignature(checksums, signature, publicKey); err != nil {
+			fmt.Printf("ERROR: %s: %v\n", selfupdate.ChecksumsAsset, err)
+			os.Exit(1)
+		}
+	} else {
+		fmt.Println("WARNING: no public key; verifying the checksum only")
+	}
+
+	archive, err := client.Download(archiveAsset.URL)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	if err := selfupdate.VerifyChecksum(archive, checksums, name); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	binary, err := selfupdate.ExtractBinary(archive, name)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		fmt.Printf("ERROR: cannot locate the running binary: %v\n", err)
+		os.Exit(1)
+	}
+	if err := selfupdate.Replace(executable, binary); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Updated %s from %s to %s\n", executable, Version, release.Tag)
+}
@@ -0,0 +1,749 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"instrumentation-score/internal/collectors"
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/health"
+	"instrumentation-score/internal/kube"
+	"instrumentation-score/internal/loaders"
+	"instrumentation-score/internal/orgscore"
+	"instrumentation-score/internal/ownership"
+	"instrumentation-score/internal/server"
+	"instrumentation-score/internal/storage"
+	"instrumentation-score/pkg/score"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr    string
+	serveRules   string
+	serveJobDir  string
+	serveReload  time.Duration
+	serveMaxBody int64
+	serveExport  bool
+	serveEvery   time.Duration
+	serveExpDir  string
+	serveAuth    string
+	serveOwners  string
+	serveSched   string
+	serveHistory string
+	serveMaxRuns int
+	serveWorkers int
+	serveQueued  int
+	serveProfile string
+	serveRate    float64
+	serveBurst   int
+	serveBulk    int64
+	serveBuckets []string
+	serveTimeout time.Duration
+	serveLines   int
+	serveElect   bool
+	serveLeaseNS string
+	serveLease   string
+)
+
+// probeTimeout bounds each request a readiness check sends to Prometheus or S3
+const probeTimeout = 5 * time.Second
+
+// profileName is the name of a --rules-profiles profile, the file name of its rules without .yaml
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve scores over an HTTP API",
+	Long: `Run a long-lived HTTP server scoring metrics with the rule engine, so other
+services can request scores without running the CLI.
+
+Endpoints:
+  POST /evaluate?job=NAME         Score the Prometheus exposition in the body as job NAME
+  POST /evaluate?input=job-file   Score a per-job file written by analyze
+  POST /evaluate?profile=NAME     Score against the rules profile NAME.yaml of --rules-profiles
+  GET  /jobs/{job}/score          Score the job's file in --job-dir
+  POST /runs                      Score a gzip tarball of job files, or {"s3_uri": ...}, as a run
+  GET  /runs                      Scheduled runs and evaluation requests, newest first
+  GET  /runs/{id}[/report]        A run's status, and the evaluate JSON report once done
+  GET  /api/v1/report[?run=ID]    A page of the jobs in --job-dir, or of a run, sorted and filtered
+  GET  /healthz                   Liveness; fails after three --interval without an exporter run
+  GET  /readyz                    Readiness; fails when the rules file, Prometheus or S3 is unusable
+  GET  /                          HTML dashboard of every job in --job-dir
+
+Scores are returned in the shape of a job in evaluate's JSON report, and every
+response carries the rules version in X-Rules-Version.
+
+A team can try stricter rules against its own service without changing the
+server's: /evaluate scores against a named profile with profile=NAME, or against
+a rules document posted as multipart/form-data with the metrics in a "metrics"
+part and the rules in a "rules" part. A posted document can only include the
+rule packs built into the binary.
+
+The evaluation endpoints are shared, so one client cannot monopolize them: with
+--rate-limit each client, the authenticated user or else the remote address, may
+send that many /evaluate, /jobs/{job}/score and POST /runs requests a minute and is answered
+429 with Retry-After beyond it, and bodies over --max-body-bytes are answered 413,
+before they are read when the request declares its length.
+
+With --schedules the server replaces a cron wrapper around analyze and evaluate:
+each schedule in the file collects its jobs from Prometheus at the times of its
+cron expression (local time), with its own selector, URL and tenant, scores them
+and keeps the report for /runs. With --history-db every run is also recorded
+for the history command.
+
+POST /runs scores many jobs at once without waiting: the body is a gzip tarball
+of job files, as CI packages the output of analyze, or a JSON {"s3_uri":
+"s3://bucket/prefix"} of a bucket in --bulk-s3-buckets. It answers 202 with the
+queued run, whose status and report are then polled on /runs/{id}.
+
+/api/v1/report pages through the jobs of --job-dir, or of the report of run ID,
+instead of returning them all at once: offset and limit (default 100, at most
+1000) select the page, sort orders it by score, cost or name (- first for
+descending), and team, category, min_score and max_score filter the jobs. The
+response carries the total of matching jobs and the query of the next page.
+
+Scheduled runs and evaluation requests share a queue: at most
+--max-concurrent-runs execute at a time, the others wait in order as queued
+runs, and once --max-queued-runs are waiting new requests are answered 503
+with Retry-After and scheduled activations are skipped. /runs lists each run as
+queued, running, done or failed; evaluation responses carry their run id in
+X-Run-ID.
+
+With --auth-config every endpoint but /healthz and /readyz requires HTTP basic
+auth, a bearer token or an OIDC token, and users only see the jobs their teams
+own in the --ownership mapping. The rules file is re-read
+every --rules-reload-interval; valid changes apply to the next request, invalid
+ones are logged and ignored.
+
+With --exporter the server is a Prometheus exporter: every --interval it collects
+the metrics of every job from Prometheus, as analyze does (url and login
+environment variables), scores them and serves the latest scores on /metrics,
+in the format of evaluate --output prometheus. The run's freshness is exported
+too: instrumentation_score_last_run_timestamp is when the served scores were
+collected, and a failed run keeps the previous scores with
+instrumentation_score_last_run_success 0. /metrics answers 503 until the first
+run completes.
+
+With --leader-elect, replicas share a coordination.k8s.io Lease and only the
+holder runs the exporter and the schedules; every replica serves the API, and
+the others answer /metrics with 503 until they take over.
+
+/readyz checks that the rules file still loads, that the Prometheus of --exporter
+and of every schedule answers a query, and that every --bulk-s3-buckets bucket is
+accessible, answering 503 with the failing checks otherwise.
+
+Examples:
+  # Score posted metrics, and the jobs collected by analyze
+  instrumentation-score serve --rules rules_config.yaml --job-dir ./reports/job_metrics_20251102_160000
+
+  # Score a running application
+  curl -s localhost:8080/metrics | curl -s --data-binary @- 'localhost:9090/evaluate?job=checkout'
+
+  # Export the scores of every job, refreshed every 6 hours
+  export url="https://your-prometheus-instance.com/api/prom"
+  instrumentation-score serve --exporter --interval 6h`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runServe()
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":9090", "Address to listen on")
+	serveCmd.Flags().StringVarP(&serveRules, "rules", "r", "rules_config.yaml", "Rules configuration file")
+	serveCmd.Flags().StringVar(&serveJobDir, "job-dir", "", "Directory of per-job files scored by /jobs/{job}/score and the dashboard")
+	serveCmd.Flags().DurationVar(&serveReload, "rules-reload-interval", 30*time.Second, "How often to check the rules file for changes and reload it (0 disables)")
+	serveCmd.Flags().Int64Var(&serveMaxBody, "max-body-bytes", server.DefaultMaxBodyBytes, "Largest body accepted by /evaluate")
+	serveCmd.Flags().BoolVar(&serveExport, "exporter", false, "Periodically collect and score every job from Prometheus and serve the scores on /metrics")
+	serveCmd.Flags().DurationVar(&serveEvery, "interval", 6*time.Hour, "How often --exporter collects and scores the jobs")
+	serveCmd.Flags().StringVar(&serveExpDir, "exporter-dir", "", "Directory --exporter collects job files into; only the latest run is kept (default: a temporary directory)")
+	serveCmd.Flags().StringVar(&serveSched, "schedules", "", "YAML file of cron schedules collecting and scoring jobs from Prometheus, listed on /runs")
+	serveCmd.Flags().StringVar(&serveHistory, "history-db", "", "SQLite database scheduled runs are recorded in, for the history command (default: not recorded)")
+	serveCmd.Flags().IntVar(&serveMaxRuns, "max-runs", server.DefaultMaxRuns, "Finished runs kept with their reports for /runs")
+	serveCmd.Flags().IntVar(&serveWorkers, "max-concurrent-runs", server.DefaultRunWorkers, "Scheduled runs and evaluation requests executed at the same time")
+	serveCmd.Flags().IntVar(&serveQueued, "max-queued-runs", server.DefaultRunQueue, "Runs waiting for a free slot before new ones are refused")
+	serveCmd.Flags().Float64Var(&serveRate, "rate-limit", 0, "Evaluation requests a minute allowed per client, the authenticated user or else the remote address (0 disables)")
+	serveCmd.Flags().IntVar(&serveBurst, "rate-burst", 0, "Evaluation requests a client may send at once before --rate-limit applies (default: a minute's worth)")
+	serveCmd.Flags().Int64Var(&serveBulk, "max-bulk-bytes", server.DefaultMaxBulkBytes, "Largest tarball of job files accepted by POST /runs, and total size of its files")
+	serveCmd.Flags().StringSliceVar(&serveBuckets, "bulk-s3-buckets", nil, "S3 buckets POST /runs may score job files from by s3_uri (default: none)")
+	serveCmd.Flags().StringVar(&serveProfile, "rules-profiles", "", "Directory of rules files /evaluate?profile=NAME scores against, NAME.yaml each")
+	serveCmd.Flags().StringVar(&serveAuth, "auth-config", "", "YAML file of the users, tokens and OIDC issuer allowed to use the API (default: no authentication)")
+	serveCmd.Flags().StringVar(&serveOwners, "ownership", "", "Job ownership mapping YAML; with --auth-config users only see the jobs of their teams")
+	serveCmd.Flags().DurationVar(&serveTimeout, "job-timeout", 5*time.Minute, "Maximum time to read and score a single job, for a request or within a run (0 disables)")
+	serveCmd.Flags().IntVar(&serveLines, "max-job-lines", 1000000, "Skip job files with more lines than this (0 disables)")
+	serveCmd.Flags().BoolVar(&serveElect, "leader-elect", false, "Elect a leader through a Lease so only one of several replicas runs the exporter and schedules")
+	serveCmd.Flags().StringVar(&serveLeaseNS, "leader-election-namespace", "", "Namespace of the leader election Lease (default: the server's namespace)")
+	serveCmd.Flags().StringVar(&serveLease, "leader-election-name", "instrumentation-score-serve", "Name of the leader election Lease")
+}
+
+func runServe() {
+	rules, err := engine.NewReloadingEngine(serveRules)
+	if err != nil {
+		fmt.Printf("ERROR: Failed to load rules: %v\n", err)
+		os.Exit(1)
+	}
+	stopWatch := make(chan struct{})
+	exportCtx, stopExport := context.WithCancel(context.Background())
+	if serveReload > 0 {
+		go rules.Watch(serveReload, stopWatch, func(version string, err error) {
+			if err != nil {
+				fmt.Printf("WARNING: rules file %s rejected, keeping rules version %s: %v\n", serveRules, version, err)
+				return
+			}
+			fmt.Printf("Reloaded rules from %s (version %s)\n", serveRules, version)
+		})
+	}
+
+	// Every request and run scores job files as source says, in a directory of its own
+	source := jobSource{maxLines: serveLines, timeout: serveTimeout, weighting: orgscore.DefaultWeighting}
+	opts := server.Options{
+		Evaluate: func(job string, metrics []loaders.JobMetricData, override interface{}) (interface{}, error) {
+			ruleEngine, _ := rules.Current()
+			if override != nil {
+				ruleEngine = override.(*engine.RuleEngine)
+			}
+			ctx, cancel := source.context()
+			defer cancel()
+			result, err := score.EvaluateJob(ctx, ruleEngine, job, metrics, score.Options{})
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, fmt.Errorf("evaluation exceeded %s (--job-timeout)", source.timeout)
+			}
+			if err != nil {
+				return nil, err
+			}
+			logScoreWarnings(result)
+			return jobScoreResult(result), nil
+		},
+		RulesVersion: func() string {
+			_, version := rules.Current()
+			return version
+		},
+		LoadRules: func(override server.RulesOverride) (interface{}, string, error) {
+			ruleEngine, err := loadRulesOverride(override)
+			if err != nil {
+				return nil, "", err
+			}
+			return ruleEngine, ruleEngine.RulesHash()[:12], nil
+		},
+		MaxBodyBytes: serveMaxBody,
+		RateLimit:    serveRate,
+		RateBurst:    serveBurst,
+		EvaluateBulk: func(ctx context.Context, request server.BulkRequest) (server.RunReport, error) {
+			ruleEngine, _ := rules.Current()
+			report, err := scoreBulk(ruleEngine, source, request)
+			if err != nil {
+				return nil, err
+			}
+			return func(visible func(job string) bool) interface{} {
+				return visibleReport(report, visible)
+			}, nil
+		},
+		MaxBulkBytes:  serveBulk,
+		BulkS3Buckets: serveBuckets,
+		Runs:          server.NewRuns(exportCtx, serveMaxRuns, serveWorkers, serveQueued),
+	}
+	if serveOwners != "" {
+		mapping, err := ownership.Load(serveOwners)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		source.owners = mapping
+		opts.TeamOf = func(job string) string {
+			owner, _ := mapping.OwnerOf(job)
+			return owner.Team
+		}
+	}
+	if serveAuth != "" {
+		auth, err := server.LoadAuth(serveAuth)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Auth = auth
+		if serveOwners == "" {
+			fmt.Println("WARNING: no --ownership mapping, so only users with teams [\"*\"] see any job")
+		}
+	}
+	if serveProfile != "" {
+		if info, err := os.Stat(serveProfile); err != nil || !info.IsDir() {
+			fmt.Printf("ERROR: --rules-profiles %s is not a directory\n", serveProfile)
+			os.Exit(1)
+		}
+	}
+	if serveJobDir != "" {
+		if info, err := os.Stat(serveJobDir); err != nil || !info.IsDir() {
+			fmt.Printf("ERROR: --job-dir %s is not a directory\n", serveJobDir)
+			os.Exit(1)
+		}
+		dirSource := source
+		dirSource.fsys, dirSource.dir = os.DirFS(serveJobDir), serveJobDir
+		opts.JobScore = func(job string) (interface{}, error) {
+			ruleEngine, _ := rules.Current()
+			return serveJobScore(ruleEngine, dirSource, job)
+		}
+		opts.Dashboard = func(w io.Writer, visible func(job string) bool) error {
+			ruleEngine, _ := rules.Current()
+			return serveDashboard(ruleEngine, dirSource, w, visible)
+		}
+		opts.Report = func() (server.JobReport, error) {
+			ruleEngine, _ := rules.Current()
+			return scoreJobFiles(ruleEngine, dirSource)
+		}
+	}
+
+	var schedules []scheduledCollection
+	if serveSched != "" {
+		if schedules, err = loadSchedules(serveSched); err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// Liveness fails after three exporter intervals without a completed run
+	var maxPassAge time.Duration
+	if serveExport {
+		maxPassAge = 3 * serveEvery
+	}
+	probes := health.NewServer(maxPassAge, readinessChecks(serveRules, serveExport, schedules, serveBuckets)...)
+	opts.Probes = probes
+
+	var elector *kube.LeaderElector
+	electionStop, electionDone := make(chan struct{}), make(chan struct{})
+	if serveElect {
+		client, err := kube.NewClientFromEnv()
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		elector = startLeaderElection(client, serveLeaseNS, serveLease, "running the exporter and schedules", electionStop, electionDone)
+	}
+
+	if serveExport {
+		if serveJobDir != "" {
+			fmt.Println("ERROR: --job-dir cannot be used with --exporter, which collects its own job files")
+			os.Exit(1)
+		}
+		if serveEvery <= 0 {
+			fmt.Println("ERROR: --interval must be positive")
+			os.Exit(1)
+		}
+		exporter, err := newExporter(rules, source, serveExpDir)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		if serveExpDir == "" {
+			defer os.RemoveAll(exporter.dir)
+		}
+		exporter.probes, exporter.leader = probes, elector
+		opts.Metrics = exporter.writeMetrics
+		go exporter.loop(exportCtx, serveEvery)
+	}
+
+	if serveSched != "" {
+		dir, err := os.MkdirTemp("", "instrumentation-score-runs-")
+		if err != nil {
+			fmt.Printf("ERROR: failed to create runs directory: %v\n", err)
+			os.Exit(1)
+		}
+		defer os.RemoveAll(dir)
+		if serveHistory != "" {
+			historyDB = serveHistory
+		}
+		runScheduler := &scheduler{rules: rules, source: source, runs: opts.Runs, dir: dir, leader: elector, record: serveHistory != ""}
+		runScheduler.start(exportCtx, schedules)
+	}
+
+	httpServer := &http.Server{Addr: serveAddr, Handler: server.Handler(opts), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+		fmt.Println("Shutting down server")
+		close(stopWatch)
+		stopExport()
+		if elector != nil {
+			close(electionStop)
+			<-electionDone
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+	}()
+
+	_, version := rules.Current()
+	fmt.Printf("Serving scores on %s (rules %s, version %s)\n", serveAddr, serveRules, version)
+	if serveJobDir != "" {
+		fmt.Printf("Serving jobs and dashboard from %s\n", serveJobDir)
+	}
+	if serveExport {
+		fmt.Printf("Exporting scores on %s/metrics, collected every %s\n", serveAddr, serveEvery)
+	}
+	if serveSched != "" {
+		fmt.Printf("Running the schedules of %s, listed on %s/runs\n", serveSched, serveAddr)
+	}
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadRulesOverride loads the rules an /evaluate request asks for: a --rules-profiles profile,
+// or a posted document, which may only include the built-in rule packs
+func loadRulesOverride(override server.RulesOverride) (*engine.RuleEngine, error) {
+	if override.Profile == "" {
+		return engine.NewRuleEngineBytes(override.Document, builtinRules)
+	}
+	if serveProfile == "" {
+		return nil, errors.New("this server has no rules profiles")
+	}
+	if !profileName.MatchString(override.Profile) {
+		return nil, fmt.Errorf("invalid profile name %q", override.Profile)
+	}
+	path := filepath.Join(serveProfile, override.Profile+".yaml")
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("unknown profile %s", override.Profile)
+	}
+	return engine.NewRuleEngine(path)
+}
+
+// serveJobScore scores the per-job file of job in src, serve's --job-dir
+func serveJobScore(ruleEngine *engine.RuleEngine, src jobSource, job string) (JobScoreResult, error) {
+	name := collectors.JobFileName(job)
+	if _, err := fs.Stat(src.fsys, name); err != nil {
+		return JobScoreResult{}, server.ErrNotFound
+	}
+	result, err := scoreJobFile(src, name, ruleEngine)
+	if err != nil {
+		return JobScoreResult{}, err
+	}
+	result.ServiceVersion = loadServiceVersions(src.fsys)[result.JobName]
+	result.Confidence = scoreConfidence(result, loadCollectionGaps(src.fsys))
+	return result, nil
+}
+
+// serveDashboard scores every job in src, serve's --job-dir, and writes the HTML report
+// evaluate would of the jobs visible reports true for
+func serveDashboard(ruleEngine *engine.RuleEngine, src jobSource, w io.Writer, visible func(job string) bool) error {
+	report, err := scoreJobFiles(ruleEngine, src)
+	if err != nil {
+		return err
+	}
+	report = visibleReport(report, visible)
+	rulesData, err := readRulesFile(serveRules)
+	if err != nil {
+		fmt.Printf("WARNING: rule descriptions are missing from the dashboard: %v\n", err)
+	}
+	return formatters.WriteHTMLMultiJob(w, buildJobsHTMLData(src, report), report.AverageScore, report.TotalCost, report.TotalCardinality, showCosts,
+		rulesData, "", "", nil, report.SkippedJobs, report)
+}
+
+// scoreJobDir scores every job file in dir into a report, as src scores job files
+func scoreJobDir(ruleEngine *engine.RuleEngine, src jobSource, dir string) (AllJobsReport, error) {
+	src.fsys, src.dir = os.DirFS(dir), dir
+	return scoreJobFiles(ruleEngine, src)
+}
+
+// scoreBulk scores the job files of a POST /runs: extracted from a tarball, or under an S3
+// prefix read in place, as evaluate --s3-stream does
+func scoreBulk(ruleEngine *engine.RuleEngine, src jobSource, request server.BulkRequest) (AllJobsReport, error) {
+	if request.S3URI == "" {
+		return scoreJobDir(ruleEngine, src, request.Dir)
+	}
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(request.S3URI, "s3://"), "/")
+	fsys, err := storage.NewS3FS(storage.EvaluationDownloadConfig{Bucket: bucket, Prefix: prefix, Region: bulkS3Region()})
+	if err != nil {
+		return AllJobsReport{}, err
+	}
+	src.fsys, src.dir = fsys, ""
+	return scoreJobFiles(ruleEngine, src)
+}
+
+// bulkS3Region is the region of the --bulk-s3-buckets, AWS_REGION or else eu-west-1
+func bulkS3Region() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return "eu-west-1"
+}
+
+// readinessChecks returns the checks of serve's /readyz: the rules file still loads, the
+// Prometheus the exporter and every schedule collect from answers a query, and every bulk
+// bucket is accessible
+func readinessChecks(rulesFile string, exporting bool, schedules []scheduledCollection, buckets []string) []health.Check {
+	checks := []health.Check{{Name: "rules", Run: func() error {
+		_, err := engine.NewRuleEngine(rulesFile)
+		return err
+	}}}
+	if exporting || len(schedules) > 0 {
+		checks = append(checks, health.Check{Name: "prometheus", Run: func() error {
+			if exporting {
+				client, err := collectors.NewPrometheusClientFromEnv()
+				if err == nil {
+					err = pingPrometheus(client)
+				}
+				if err != nil {
+					return fmt.Errorf("exporter: %w", err)
+				}
+			}
+			for _, s := range schedules {
+				client, err := s.prometheusClient()
+				if err == nil {
+					err = pingPrometheus(client)
+				}
+				if err != nil {
+					return fmt.Errorf("schedule %s: %w", s.Name, err)
+				}
+			}
+			return nil
+		}})
+	}
+	if len(buckets) > 0 {
+		checks = append(checks, health.Check{Name: "s3", Run: func() error {
+			for _, bucket := range buckets {
+				client, err := storage.NewS3Client(bucket, "", bulkS3Region())
+				if err != nil {
+					return err
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+				err = client.CheckAccess(ctx)
+				cancel()
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}})
+	}
+	return checks
+}
+
+// pingPrometheus runs a trivial query, once, to check that Prometheus answers
+func pingPrometheus(client *collectors.PrometheusClient) error {
+	client.SetRetryCount(0)
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	_, err := client.QueryVector(ctx, "vector(1)", time.Now().Unix())
+	return err
+}
+
+// scoreJobFiles scores every job file of src into a report
+func scoreJobFiles(ruleEngine *engine.RuleEngine, src jobSource) (AllJobsReport, error) {
+	files, err := fs.Glob(src.fsys, "*.txt")
+	if err != nil {
+		return AllJobsReport{}, err
+	}
+	serviceVersions := loadServiceVersions(src.fsys)
+	gaps := loadCollectionGaps(src.fsys)
+
+	report := AllJobsReport{Timestamp: time.Now().Format(time.RFC3339), owners: src.owners}
+	var jobs []JobScoreResult
+	for _, file := range files {
+		result, err := scoreJobFile(src, file, ruleEngine)
+		if err != nil {
+			if !errors.Is(err, score.ErrExcluded) {
+				report.SkippedJobs = append(report.SkippedJobs, formatters.SkippedJob{File: file, Reason: err.Error()})
+			}
+			continue
+		}
+		result.ServiceVersion = serviceVersions[result.JobName]
+		result.Confidence = scoreConfidence(result, gaps)
+		jobs = append(jobs, result)
+	}
+	if len(jobs) == 0 {
+		return report, errors.New("no jobs were successfully evaluated")
+	}
+	return withJobs(report, jobs), nil
+}
+
+// withJobs sets the jobs of report and the totals over them
+func withJobs(report AllJobsReport, jobs []JobScoreResult) AllJobsReport {
+	report.Jobs = jobs
+	report.TotalJobs = len(jobs)
+	report.AverageScore, report.TotalCost, report.TotalCardinality = 0, 0, 0
+	for _, job := range jobs {
+		report.AverageScore += job.Score
+		report.TotalCost += job.EstimatedCost
+		report.TotalCardinality += job.TotalCardinality
+	}
+	if len(jobs) > 0 {
+		report.AverageScore /= float64(len(jobs))
+	}
+	return report
+}
+
+// visibleReport keeps the jobs of report, and its skipped job files, visible reports true for
+func visibleReport(report AllJobsReport, visible func(job string) bool) AllJobsReport {
+	var jobs []JobScoreResult
+	for _, job := range report.Jobs {
+		if visible(job.JobName) {
+			jobs = append(jobs, job)
+		}
+	}
+	var skipped []formatters.SkippedJob
+	for _, job := range report.SkippedJobs {
+		if visible(strings.TrimSuffix(job.File, ".txt")) {
+			skipped = append(skipped, job)
+		}
+	}
+	report.SkippedJobs = skipped
+	return withJobs(report, jobs)
+}
+
+// ReportJobs returns the jobs of report as /api/v1/report filters and sorts them
+func (r AllJobsReport) ReportJobs() []server.ReportJob {
+	jobs := make([]server.ReportJob, len(r.Jobs))
+	for i, job := range r.Jobs {
+		owner, _ := r.owners.OwnerOf(job.JobName)
+		jobs[i] = server.ReportJob{
+			Name:     job.JobName,
+			Team:     owner.Team,
+			Category: score.Category(job.Score),
+			Score:    job.Score,
+			Cost:     job.EstimatedCost,
+			Entry:    job,
+		}
+	}
+	return jobs
+}
+
+// exporter collects and scores every job each interval for serve --exporter, keeping the
+// Prometheus metrics of the latest successful run for /metrics
+type exporter struct {
+	rules    *engine.ReloadingEngine
+	source   jobSource           // How the collected job files are scored
+	dir      string              // Each run collects into a job_metrics directory here
+	previous string              // Job directory of the exported scores, removed once a newer run succeeds
+	probes   *health.Server      // Told of every completed run, for liveness; nil without probes
+	leader   *kube.LeaderElector // With --leader-elect, only runs while it holds the lease
+
+	mu      sync.Mutex
+	metrics string // "" until a run succeeded
+	run     formatters.ExporterRun
+}
+
+// newExporter creates an exporter collecting into dir, or a temporary directory when dir is empty
+func newExporter(rules *engine.ReloadingEngine, source jobSource, dir string) (*exporter, error) {
+	var err error
+	if dir == "" {
+		dir, err = os.MkdirTemp("", "instrumentation-score-exporter-")
+	} else {
+		err = os.MkdirAll(dir, 0700)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exporter directory: %w", err)
+	}
+	return &exporter{rules: rules, source: source, dir: dir}, nil
+}
+
+// loop runs immediately and then every interval until ctx is done, which also stops a run in progress
+// A replica that is not the leader re-checks the lease often instead, so it runs soon after
+// taking over.
+func (e *exporter) loop(ctx context.Context, interval time.Duration) {
+	for {
+		start := time.Now()
+		wait := leaderLeaseDuration / 3
+		if e.leader == nil || e.leader.IsLeader() {
+			e.runOnce(ctx)
+			wait = time.Until(start.Add(interval))
+		} else if e.probes != nil {
+			e.probes.PassCompleted()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// runOnce collects and scores every job; a failed run keeps exporting the previous scores
+func (e *exporter) runOnce(ctx context.Context) {
+	start := time.Now()
+	metrics, jobs, err := e.collectAndScore(ctx, start)
+	if ctx.Err() != nil {
+		return
+	}
+	if e.probes != nil {
+		e.probes.PassCompleted()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.run.Duration = time.Since(start)
+	e.run.Failed = err != nil
+	if err != nil {
+		fmt.Printf("WARNING: exporter run failed, still exporting the scores collected at %s: %v\n", e.run.LastSuccess.Format(time.RFC3339), err)
+		return
+	}
+	e.metrics = metrics
+	e.run.LastSuccess = start
+	fmt.Printf("Exporter scored %d job(s) in %s\n", jobs, e.run.Duration.Round(time.Second))
+}
+
+// collectAndScore collects every job from Prometheus into a new job directory and renders their scores
+func (e *exporter) collectAndScore(ctx context.Context, start time.Time) (string, int, error) {
+	client, err := collectors.NewPrometheusClientFromEnv()
+	if err != nil {
+		return "", 0, err
+	}
+	jobMetricsDir := filepath.Join(e.dir, "job_metrics_"+start.Format("20060102_150405"))
+	if err := os.MkdirAll(jobMetricsDir, 0700); err != nil {
+		return "", 0, fmt.Errorf("failed to create job metrics directory: %w", err)
+	}
+	// Only metrics whose series counts changed since the exported run are queried again
+	var baseline *collectors.Baseline
+	if e.previous != "" {
+		if baseline, err = collectors.LoadBaseline(e.previous); err != nil {
+			fmt.Printf("WARNING: collecting every metric again: %v\n", err)
+		}
+	}
+	records, err := collectFromPrometheus(ctx, client, nil, baseline, jobMetricsDir, filepath.Join(e.dir, "slow_metrics.txt"))
+	if err == nil && len(records) > 0 {
+		fmt.Printf("WARNING: Encountered %d errors during collection\n", len(records))
+	}
+
+	var report AllJobsReport
+	if err == nil {
+		ruleEngine, _ := e.rules.Current()
+		report, err = scoreJobDir(ruleEngine, e.source, jobMetricsDir)
+	}
+	if err != nil {
+		os.RemoveAll(jobMetricsDir)
+		return "", 0, err
+	}
+
+	if e.previous != "" && e.previous != jobMetricsDir {
+		os.RemoveAll(e.previous)
+	}
+	e.previous = jobMetricsDir
+	org, err := organizationScore(report.Jobs, e.source.owners, e.source.weighting)
+	if err != nil {
+		return "", 0, err
+	}
+	return prometheusMetrics(jobScoreData(report.Jobs), org), len(report.Jobs), nil
+}
+
+// writeMetrics writes the scores of the latest successful run with its freshness metadata
+func (e *exporter) writeMetrics(w io.Writer) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.metrics == "" {
+		return errors.New("no scoring run completed yet")
+	}
+	_, err := io.WriteString(w, e.metrics+formatters.PrometheusExporterMetrics(e.run))
+	return err
+}
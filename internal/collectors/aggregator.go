@@ -0,0 +1,143 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorCategory groups collection errors by their likely cause
+type ErrorCategory string
+
+const (
+	CategoryAuth        ErrorCategory = "auth"
+	CategoryRateLimit   ErrorCategory = "rate_limit"
+	CategoryTimeout     ErrorCategory = "timeout"
+	CategoryNotFound    ErrorCategory = "not_found"
+	CategorySeriesLimit ErrorCategory = "series_limit"
+	CategoryOther       ErrorCategory = "other"
+)
+
+// CategorizeError classifies a collection error from its type or the HTTP status in its message
+func CategorizeError(err error) ErrorCategory {
+	if err == nil {
+		return CategoryOther
+	}
+
+	if IsSeriesLimitError(err) {
+		return CategorySeriesLimit
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CategoryTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return CategoryTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "http 401"), strings.Contains(msg, "http 403"):
+		return CategoryAuth
+	case strings.Contains(msg, "http 429"), strings.Contains(msg, "too many requests"):
+		return CategoryRateLimit
+	case strings.Contains(msg, "http 404"):
+		return CategoryNotFound
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "deadline exceeded"), strings.Contains(msg, "http 504"):
+		return CategoryTimeout
+	default:
+		return CategoryOther
+	}
+}
+
+// ErrorAggregator collects ErrorRecords from concurrent workers
+type ErrorAggregator struct {
+	mu      sync.Mutex
+	records []ErrorRecord
+}
+
+// NewErrorAggregator creates an empty error aggregator
+func NewErrorAggregator() *ErrorAggregator {
+	return &ErrorAggregator{}
+}
+
+// Add records a failed operation for a metric
+func (a *ErrorAggregator) Add(metricName, operation string, err error) {
+	record := ErrorRecord{
+		MetricName: metricName,
+		Operation:  operation,
+		Category:   CategorizeError(err),
+		Error:      err.Error(),
+		Timestamp:  time.Now(),
+	}
+
+	a.mu.Lock()
+	a.records = append(a.records, record)
+	a.mu.Unlock()
+}
+
+// Records returns a copy of the recorded errors
+func (a *ErrorAggregator) Records() []ErrorRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	records := make([]ErrorRecord, len(a.records))
+	copy(records, a.records)
+	return records
+}
+
+// CategoryCount is the number of errors in one category
+type CategoryCount struct {
+	Category ErrorCategory
+	Count    int
+}
+
+// CountByCategory totals errors per category, largest first
+func CountByCategory(records []ErrorRecord) []CategoryCount {
+	counts := make(map[ErrorCategory]int)
+	for _, record := range records {
+		category := record.Category
+		if category == "" {
+			category = CategoryOther
+		}
+		counts[category]++
+	}
+
+	totals := make([]CategoryCount, 0, len(counts))
+	for category, count := range counts {
+		totals = append(totals, CategoryCount{Category: category, Count: count})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Count != totals[j].Count {
+			return totals[i].Count > totals[j].Count
+		}
+		return totals[i].Category < totals[j].Category
+	})
+	return totals
+}
+
+// progressTracker reports completion of a fixed number of work items from concurrent workers
+type progressTracker struct {
+	label    string
+	total    int32
+	done     int32
+	interval int32
+}
+
+// newProgressTracker prints "label: done/total (pct%)" every interval items and on completion
+func newProgressTracker(label string, total, interval int) *progressTracker {
+	return &progressTracker{label: label, total: int32(total), interval: int32(interval)}
+}
+
+// Increment marks one item done and prints progress when due
+func (p *progressTracker) Increment() {
+	current := atomic.AddInt32(&p.done, 1)
+	if current%p.interval == 0 || current == p.total {
+		fmt.Printf("\r%s: %d/%d (%.1f%%)", p.label, current, p.total, float64(current)/float64(p.total)*100)
+	}
+}
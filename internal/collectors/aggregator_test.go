@@ -0,0 +1,68 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCategorizeError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCategory
+	}{
+		{"unauthorized", errors.New("HTTP 401 - labels API - job: api - error: unauthorized"), CategoryAuth},
+		{"forbidden", errors.New("HTTP 403 (403 Forbidden) - query: count by (job) - error: denied"), CategoryAuth},
+		{"rate limited", errors.New("HTTP 429 - cardinality query - job: api - error: slow down"), CategoryRateLimit},
+		{"not found", errors.New("HTTP 404 - metadata API - error: not found"), CategoryNotFound},
+		{"client timeout", errors.New("Get \"http://x\": net/http: request canceled (Client.Timeout exceeded while awaiting headers)"), CategoryTimeout},
+		{"deadline", fmt.Errorf("query failed: %w", context.DeadlineExceeded), CategoryTimeout},
+		{"series limit", fmt.Errorf("HTTP 422: %w", &SeriesLimitError{Query: "q", Message: "too many series"}), CategorySeriesLimit},
+		{"other", errors.New("failed to parse response"), CategoryOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CategorizeError(tt.err); got != tt.want {
+				t.Errorf("CategorizeError() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorAggregator(t *testing.T) {
+	agg := NewErrorAggregator()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%4 == 0 {
+				agg.Add("m", "fetch_job_data", errors.New("HTTP 429 - too many requests"))
+			} else {
+				agg.Add("m", "fetch_job_data", errors.New("HTTP 401 - unauthorized"))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	records := agg.Records()
+	if len(records) != 20 {
+		t.Fatalf("expected 20 records, got %d", len(records))
+	}
+
+	totals := CountByCategory(records)
+	want := []CategoryCount{{CategoryAuth, 15}, {CategoryRateLimit, 5}}
+	if len(totals) != len(want) {
+		t.Fatalf("expected %d categories, got %v", len(want), totals)
+	}
+	for i := range want {
+		if totals[i] != want[i] {
+			t.Errorf("totals[%d] = %v, want %v", i, totals[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package collectors
+
+import (
+	"errors"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// AutoTuneConfig controls AIMD concurrency auto-tuning of Prometheus requests
+type AutoTuneConfig struct {
+	Initial       int           // Starting number of in-flight requests
+	Min           int           // Lower bound for the limit
+	Max           int           // Upper bound for the limit
+	TargetLatency time.Duration // Responses slower than this count as congestion
+}
+
+// DefaultAutoTuneConfig returns settings that start conservatively and can grow for local Prometheus
+func DefaultAutoTuneConfig() AutoTuneConfig {
+	return AutoTuneConfig{
+		Initial:       10,
+		Min:           1,
+		Max:           100,
+		TargetLatency: 2 * time.Second,
+	}
+}
+
+// aimdLimiter bounds in-flight requests with an additive-increase/multiplicative-decrease limit
+// Every fast, successful response grows the limit by 1/limit (about +1 per round of requests);
+// a 429, timeout or slow response halves it, at most once per TargetLatency so a burst of
+// failures from the same round only counts once.
+type aimdLimiter struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	config       AutoTuneConfig
+	limit        float64
+	inFlight     int
+	lastDecrease time.Time
+	lowest       int
+	decreases    int
+}
+
+func newAIMDLimiter(config AutoTuneConfig) *aimdLimiter {
+	if config.Min < 1 {
+		config.Min = 1
+	}
+	if config.Max < config.Min {
+		config.Max = config.Min
+	}
+	if config.Initial < config.Min || config.Initial > config.Max {
+		config.Initial = config.Min
+	}
+	l := &aimdLimiter{config: config, limit: float64(config.Initial), lowest: config.Initial}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Acquire blocks until a request slot is available under the current limit
+func (l *aimdLimiter) Acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= int(l.limit) {
+		l.cond.Wait()
+	}
+	l.inFlight++
+}
+
+// Release frees a slot and adjusts the limit from the request outcome
+func (l *aimdLimiter) Release(latency time.Duration, statusCode int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+
+	if isCongestionSignal(latency, statusCode, err, l.config.TargetLatency) {
+		now := time.Now()
+		if now.Sub(l.lastDecrease) >= l.config.TargetLatency {
+			l.limit = math.Max(float64(l.config.Min), math.Floor(l.limit/2))
+			l.lastDecrease = now
+			l.decreases++
+			if int(l.limit) < l.lowest {
+				l.lowest = int(l.limit)
+			}
+		}
+	} else if err == nil {
+		l.limit = math.Min(float64(l.config.Max), l.limit+1/l.limit)
+	}
+
+	l.cond.Broadcast()
+}
+
+// Limit returns the current number of allowed in-flight requests
+func (l *aimdLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// isCongestionSignal reports whether a request outcome indicates the server is overloaded
+func isCongestionSignal(latency time.Duration, statusCode int, err error, target time.Duration) bool {
+	if statusCode == 429 || statusCode == 503 || statusCode == 504 {
+		return true
+	}
+	var netErr net.Error
+	if err != nil && errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return target > 0 && latency > target
+}
+
+// AutoTuneStats summarizes how the limit moved during a run
+type AutoTuneStats struct {
+	Final     int
+	Lowest    int
+	Decreases int
+}
+
+func (l *aimdLimiter) stats() AutoTuneStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return AutoTuneStats{Final: int(l.limit), Lowest: l.lowest, Decreases: l.decreases}
+}
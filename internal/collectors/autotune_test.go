@@ -0,0 +1,98 @@
+package collectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAIMDLimiter_IncreaseAndDecrease(t *testing.T) {
+	limiter := newAIMDLimiter(AutoTuneConfig{Initial: 4, Min: 1, Max: 6, TargetLatency: time.Hour})
+
+	// Additive increase: roughly +1 per limit-many fast successes
+	for i := 0; i < 4; i++ {
+		limiter.Acquire()
+		limiter.Release(time.Millisecond, 200, nil)
+	}
+	if got := limiter.Limit(); got != 4 && got != 5 {
+		t.Fatalf("expected limit to grow by about one, got %d", got)
+	}
+	for i := 0; i < 100; i++ {
+		limiter.Acquire()
+		limiter.Release(time.Millisecond, 200, nil)
+	}
+	if got := limiter.Limit(); got != 6 {
+		t.Errorf("expected limit capped at max 6, got %d", got)
+	}
+
+	// Multiplicative decrease on 429, once per cooldown window
+	limiter.Acquire()
+	limiter.Release(time.Millisecond, 429, nil)
+	if got := limiter.Limit(); got != 3 {
+		t.Errorf("expected limit halved to 3, got %d", got)
+	}
+	limiter.Acquire()
+	limiter.Release(time.Millisecond, 429, nil)
+	if got := limiter.Limit(); got != 3 {
+		t.Errorf("expected second 429 within cooldown to be ignored, got %d", got)
+	}
+
+	stats := limiter.stats()
+	if stats.Decreases != 1 || stats.Lowest != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestAIMDLimiter_SlowResponsesBackOffToMin(t *testing.T) {
+	limiter := newAIMDLimiter(AutoTuneConfig{Initial: 8, Min: 2, Max: 8, TargetLatency: time.Nanosecond})
+
+	for i := 0; i < 10; i++ {
+		limiter.Acquire()
+		limiter.Release(time.Second, 200, nil)
+		time.Sleep(time.Millisecond) // let the cooldown pass
+	}
+	if got := limiter.Limit(); got != 2 {
+		t.Errorf("expected limit to settle at min 2, got %d", got)
+	}
+}
+
+func TestPrometheusClient_AutoTuneBoundsInFlight(t *testing.T) {
+	var inFlight, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.EnableAutoTune(AutoTuneConfig{Initial: 2, Min: 1, Max: 2, TargetLatency: time.Minute})
+
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		go func() {
+			client.GetAllMetricNames(context.Background(), "")
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 in-flight requests, saw %d", peak)
+	}
+	if _, ok := client.AutoTuneStats(); !ok {
+		t.Error("expected auto-tune stats to be available")
+	}
+}
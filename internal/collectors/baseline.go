@@ -0,0 +1,83 @@
+package collectors
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// Baseline holds the job files of a previous run for differential collection: a metric of a
+// job whose series count is unchanged keeps its previous record instead of being re-queried
+// The series count alone decides, so a label set that changed at the same count is only
+// picked up by a full collection.
+type Baseline struct {
+	records   map[string]map[string]JobMetricData // Metric -> job -> record
+	reused    atomic.Int64
+	collected atomic.Int64
+}
+
+// LoadBaseline reads the per-job files analyze wrote to jobMetricsDir
+func LoadBaseline(jobMetricsDir string) (*Baseline, error) {
+	files, err := filepath.Glob(filepath.Join(jobMetricsDir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no job files in previous run %s", jobMetricsDir)
+	}
+
+	baseline := &Baseline{records: make(map[string]map[string]JobMetricData)}
+	for _, file := range files {
+		jobData, err := loaders.LoadJobMetricReport(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load previous run: %w", err)
+		}
+		for _, data := range jobData {
+			baseline.add(JobMetricData{
+				Job:              data.Job,
+				MetricName:       data.MetricName,
+				Labels:           data.Labels,
+				Cardinality:      strconv.FormatInt(data.Cardinality, 10),
+				LabelCardinality: data.LabelCardinality,
+				Type:             data.Type,
+				LabelValues:      data.LabelValues,
+			})
+		}
+	}
+	return baseline, nil
+}
+
+func (b *Baseline) add(data JobMetricData) {
+	jobs := b.records[data.MetricName]
+	if jobs == nil {
+		jobs = make(map[string]JobMetricData)
+		b.records[data.MetricName] = jobs
+	}
+	jobs[data.Job] = data
+}
+
+// Records returns the number of metric-job records of the previous run
+func (b *Baseline) Records() int {
+	total := 0
+	for _, jobs := range b.records {
+		total += len(jobs)
+	}
+	return total
+}
+
+// unchanged returns the previous record of a metric of a job when it had count series
+func (b *Baseline) unchanged(metricName, job string, count int64) (JobMetricData, bool) {
+	previous, ok := b.records[metricName][job]
+	if !ok || previous.Cardinality != strconv.FormatInt(count, 10) {
+		return JobMetricData{}, false
+	}
+	return previous, true
+}
+
+// Stats returns how many metric-job records were reused from the previous run and how many collected
+func (b *Baseline) Stats() (reused, collected int64) {
+	return b.reused.Load(), b.collected.Load()
+}
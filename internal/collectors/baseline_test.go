@@ -0,0 +1,94 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGetJobMetricDataForMetric_Baseline(t *testing.T) {
+	previousDir := t.TempDir()
+	writer := NewJobFileWriter(previousDir, 4)
+	for _, data := range []JobMetricData{
+		{Job: "api", MetricName: "requests_total", Labels: []string{"job", "method"}, Cardinality: "5"},
+		{Job: "web", MetricName: "requests_total", Labels: []string{"job"}, Cardinality: "3"},
+	} {
+		if err := writer.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := LoadBaseline(previousDir)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	if baseline.Records() != 2 {
+		t.Errorf("Records() = %d, want 2", baseline.Records())
+	}
+
+	counts := map[string]string{"api": "5", "web": "7", "new": "1"}
+	var mu sync.Mutex
+	queriedJobs := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query") + r.URL.Query().Get("match[]")
+		var result []map[string]interface{}
+		if strings.HasPrefix(query, "count by (job)") {
+			for job, count := range counts {
+				result = append(result, map[string]interface{}{"metric": map[string]string{"job": job}, "value": []interface{}{0, count}})
+			}
+		} else {
+			for job, count := range counts {
+				if strings.Contains(query, `job="`+job+`"`) {
+					mu.Lock()
+					queriedJobs[job]++
+					mu.Unlock()
+					result = append(result, map[string]interface{}{"metric": map[string]string{"__name__": "requests_total", "job": job}, "value": []interface{}{0, count}})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": map[string]interface{}{"resultType": "vector", "result": result}})
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.SetRetryCount(0)
+	collector := NewCollectorWithClient(client, "")
+	collector.SetBaseline(baseline)
+
+	data, err := collector.getJobMetricDataForMetric(context.Background(), "requests_total", 1700000000)
+	if err != nil {
+		t.Fatalf("getJobMetricDataForMetric() error = %v", err)
+	}
+	byJob := map[string]JobMetricData{}
+	for _, d := range data {
+		byJob[d.Job] = d
+	}
+	if got := byJob["api"]; got.Cardinality != "5" || len(got.Labels) != 2 || queriedJobs["api"] != 0 {
+		t.Errorf("unchanged job: got %+v after %d queries, want the previous record without queries", got, queriedJobs["api"])
+	}
+	if got := byJob["web"]; got.Cardinality != "7" || queriedJobs["web"] == 0 {
+		t.Errorf("changed job: got %+v, want it collected again", got)
+	}
+	if _, ok := byJob["new"]; !ok || queriedJobs["new"] == 0 {
+		t.Errorf("new job was not collected: %+v", byJob)
+	}
+	if reused, collected := baseline.Stats(); reused != 1 || collected != 2 {
+		t.Errorf("Stats() = %d reused, %d collected, want 1 and 2", reused, collected)
+	}
+
+	// Per-label data the previous run lacks is collected
+	collector.SetCollectLabelCardinality(true)
+	if collector.reusable(baseline.records["requests_total"]["api"]) {
+		t.Error("a record without label cardinality should not be reused when it is collected")
+	}
+
+	if _, err := LoadBaseline(t.TempDir()); err == nil {
+		t.Error("LoadBaseline() of a directory without job files should fail")
+	}
+}
@@ -0,0 +1,84 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// buildInfoQuery returns one series per job, build info metric and version label combination
+const buildInfoQuery = `group by (job, __name__, version, service_version, app_version, revision, branch) ({__name__=~".+_build_info|build_info|target_info"%s})`
+
+// CollectBuildInfo queries the version labels of every job's build info metrics
+func (c *Collector) CollectBuildInfo(ctx context.Context) ([]loaders.BuildInfoData, error) {
+	filters := ""
+	if c.queryFilters != "" {
+		filters = "," + c.queryFilters
+	}
+	samples, err := c.client.QueryVector(ctx, fmt.Sprintf(buildInfoQuery, filters), time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query build info: %w", err)
+	}
+
+	var data []loaders.BuildInfoData
+	for _, sample := range samples {
+		job := sample.Labels["job"]
+		if job == "" {
+			continue
+		}
+		if info, ok := loaders.BuildInfoFromLabels(job, sample.Labels["__name__"], sample.Labels); ok {
+			data = append(data, info)
+		}
+	}
+	return sortBuildInfo(data), nil
+}
+
+// sortBuildInfo orders build info by job, metric and version, dropping duplicates
+func sortBuildInfo(data []loaders.BuildInfoData) []loaders.BuildInfoData {
+	sort.Slice(data, func(i, j int) bool {
+		a, b := data[i], data[j]
+		if a.Job != b.Job {
+			return a.Job < b.Job
+		}
+		if a.MetricName != b.MetricName {
+			return a.MetricName < b.MetricName
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Revision < b.Revision
+	})
+
+	unique := data[:0]
+	for i, info := range data {
+		if i == 0 || info != data[i-1] {
+			unique = append(unique, info)
+		}
+	}
+	return unique
+}
+
+// WriteBuildInfoFile writes a build info report
+func WriteBuildInfoFile(filename string, data []loaders.BuildInfoData) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create build info file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.WriteString(loaders.BuildInfoColumnHeader + "\n"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, info := range data {
+		if _, err := writer.WriteString(loaders.FormatBuildInfoLine(info)); err != nil {
+			return fmt.Errorf("failed to write build info line: %w", err)
+		}
+	}
+	return writer.Flush()
+}
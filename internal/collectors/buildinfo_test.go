@@ -0,0 +1,75 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestCollector_CollectBuildInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		if !strings.Contains(query, `_build_info`) || !strings.Contains(query, `,cluster="prod"`) {
+			t.Errorf("unexpected query %s", query)
+		}
+		result := []map[string]interface{}{
+			{"metric": map[string]string{"__name__": "api_build_info", "job": "api", "version": "1.2.0", "revision": "abc"}, "value": []interface{}{1, "1"}},
+			{"metric": map[string]string{"__name__": "api_build_info", "job": "api", "version": "1.3.0", "revision": "def"}, "value": []interface{}{1, "1"}},
+			{"metric": map[string]string{"__name__": "target_info", "job": "checkout", "service_version": "2.0.0"}, "value": []interface{}{1, "1"}},
+			{"metric": map[string]string{"__name__": "target_info", "job": "unversioned"}, "value": []interface{}{1, "1"}},
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data":   map[string]interface{}{"resultType": "vector", "result": result},
+		})
+	}))
+	defer server.Close()
+
+	got, err := NewCollector(server.URL, "", `cluster="prod"`).CollectBuildInfo(context.Background())
+	if err != nil {
+		t.Fatalf("CollectBuildInfo() error = %v", err)
+	}
+	want := []loaders.BuildInfoData{
+		{Job: "api", MetricName: "api_build_info", Version: "1.2.0", Revision: "abc"},
+		{Job: "api", MetricName: "api_build_info", Version: "1.3.0", Revision: "def"},
+		{Job: "checkout", MetricName: "target_info", Version: "2.0.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectBuildInfo() = %+v, want %+v", got, want)
+	}
+}
+
+func TestScraper_BuildInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# TYPE app_build_info gauge\napp_build_info{version=\"0.9.1\",branch=\"main\"} 1\nrequests_total 3\n"))
+	}))
+	defer server.Close()
+
+	targets := []ScrapeTarget{
+		{Job: "app", URL: server.URL + "/metrics"},
+		{Job: "app", URL: server.URL + "/metrics"},
+	}
+	for i := range targets {
+		if err := targets[i].Validate(); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+	}
+
+	writer := NewJobFileWriter(t.TempDir(), 0)
+	defer writer.Close()
+	scraper := NewScraper(targets)
+	if _, _, err := scraper.ScrapeToWriter(context.Background(), writer); err != nil {
+		t.Fatalf("ScrapeToWriter() error = %v", err)
+	}
+
+	want := []loaders.BuildInfoData{{Job: "app", MetricName: "app_build_info", Version: "0.9.1", Branch: "main"}}
+	if got := scraper.BuildInfo(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildInfo() = %+v, want %+v", got, want)
+	}
+}
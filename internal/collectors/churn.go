@@ -0,0 +1,121 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// DefaultSeriesChurnWindow is the window series churn is measured over
+const DefaultSeriesChurnWindow = time.Hour
+
+// seriesChurnQuery is one per-metric count feeding a SeriesChurnData field
+type seriesChurnQuery struct {
+	name     string
+	expr     string // %[1]s is the series selector, %[2]s the window
+	required bool
+	apply    func(c *loaders.SeriesChurnData, value int64)
+}
+
+var seriesChurnQueries = []seriesChurnQuery{
+	{
+		name:     "active_series",
+		expr:     `count by (job, __name__) (%[1]s)`,
+		required: true,
+		apply:    func(c *loaders.SeriesChurnData, v int64) { c.ActiveSeries = v },
+	},
+	{
+		name:     "window_series",
+		expr:     `count by (job, __name__) (last_over_time(%[1]s[%[2]s]))`,
+		required: true,
+		apply:    func(c *loaders.SeriesChurnData, v int64) { c.WindowSeries = v },
+	},
+	{
+		name:  "new_series",
+		expr:  `count by (job, __name__) (%[1]s unless %[1]s offset %[2]s)`,
+		apply: func(c *loaders.SeriesChurnData, v int64) { c.NewSeries = v },
+	},
+}
+
+// CollectSeriesChurn counts, per job and metric, the series active now, the series seen
+// over window and the active series that did not exist window ago
+// A failing new_series query is recorded as an error and leaves the field at zero;
+// failing to count active or window series aborts collection.
+func (c *Collector) CollectSeriesChurn(ctx context.Context, window time.Duration) ([]loaders.SeriesChurnData, []ErrorRecord, error) {
+	if window <= 0 {
+		window = DefaultSeriesChurnWindow
+	}
+	now := time.Now().Unix()
+	errors := NewErrorAggregator()
+
+	matchers := []string{`__name__=~".+"`}
+	if c.queryFilters != "" {
+		matchers = append(matchers, c.queryFilters)
+	}
+	selector := "{" + strings.Join(matchers, ",") + "}"
+	promWindow := fmt.Sprintf("%ds", int64(window.Seconds()))
+
+	type key struct{ job, metric string }
+	byMetric := make(map[key]*loaders.SeriesChurnData)
+
+	for _, q := range seriesChurnQueries {
+		samples, err := c.client.QueryVector(ctx, fmt.Sprintf(q.expr, selector, promWindow), now)
+		if err != nil {
+			if q.required {
+				return nil, nil, fmt.Errorf("failed to query series churn (%s): %w", q.name, err)
+			}
+			errors.Add(q.name, "series_churn", err)
+			continue
+		}
+		for _, sample := range samples {
+			k := key{sample.Labels["job"], sample.Labels["__name__"]}
+			if k.job == "" || k.metric == "" {
+				continue
+			}
+			churn, ok := byMetric[k]
+			if !ok {
+				churn = &loaders.SeriesChurnData{Job: k.job, MetricName: k.metric, WindowSeconds: int64(window.Seconds())}
+				byMetric[k] = churn
+			}
+			q.apply(churn, int64(sample.Value))
+		}
+	}
+
+	results := make([]loaders.SeriesChurnData, 0, len(byMetric))
+	for _, churn := range byMetric {
+		results = append(results, *churn)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Job != results[j].Job {
+			return results[i].Job < results[j].Job
+		}
+		return results[i].MetricName < results[j].MetricName
+	})
+	return results, errors.Records(), nil
+}
+
+// WriteSeriesChurnFile writes a series churn report
+func WriteSeriesChurnFile(filename string, churn []loaders.SeriesChurnData) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create series churn file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.WriteString(loaders.SeriesChurnColumnHeader + "\n"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, c := range churn {
+		if _, err := writer.WriteString(loaders.FormatSeriesChurnLine(c)); err != nil {
+			return fmt.Errorf("failed to write series churn line: %w", err)
+		}
+	}
+	return writer.Flush()
+}
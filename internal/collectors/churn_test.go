@@ -0,0 +1,77 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestCollector_CollectSeriesChurn(t *testing.T) {
+	requests := map[string]string{"job": "api", "__name__": "http_requests_total"}
+	up := map[string]string{"job": "api", "__name__": "up"}
+	var queries []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+
+		var samples []map[string]interface{}
+		switch {
+		case strings.Contains(query, "unless"):
+			samples = []map[string]interface{}{{"metric": requests, "value": []interface{}{0, "900"}}}
+		case strings.Contains(query, "last_over_time"):
+			samples = []map[string]interface{}{
+				{"metric": requests, "value": []interface{}{0, "4000"}},
+				{"metric": up, "value": []interface{}{0, "2"}},
+			}
+		default:
+			samples = []map[string]interface{}{
+				{"metric": requests, "value": []interface{}{0, "1000"}},
+				{"metric": up, "value": []interface{}{0, "2"}},
+				{"metric": map[string]string{"__name__": "pushed"}, "value": []interface{}{0, "1"}},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data":   map[string]interface{}{"resultType": "vector", "result": samples},
+		})
+	}))
+	defer server.Close()
+
+	collector := NewCollector(server.URL, "", `cluster="prod"`)
+	churn, errors, err := collector.CollectSeriesChurn(context.Background(), 30*time.Minute)
+	if err != nil {
+		t.Fatalf("CollectSeriesChurn() error = %v", err)
+	}
+	if len(errors) != 0 {
+		t.Errorf("unexpected errors: %+v", errors)
+	}
+
+	want := []loaders.SeriesChurnData{
+		{Job: "api", MetricName: "http_requests_total", ActiveSeries: 1000, WindowSeries: 4000, NewSeries: 900, WindowSeconds: 1800},
+		{Job: "api", MetricName: "up", ActiveSeries: 2, WindowSeries: 2, WindowSeconds: 1800},
+	}
+	if !reflect.DeepEqual(churn, want) {
+		t.Errorf("CollectSeriesChurn() = %+v, want %+v", churn, want)
+	}
+	if len(queries) != 3 || !strings.Contains(queries[1], `{__name__=~".+",cluster="prod"}[1800s]`) {
+		t.Errorf("unexpected queries: %q", queries)
+	}
+
+	path := filepath.Join(t.TempDir(), loaders.SeriesChurnFileName)
+	if err := WriteSeriesChurnFile(path, churn); err != nil {
+		t.Fatalf("WriteSeriesChurnFile() error = %v", err)
+	}
+	loaded, err := loaders.LoadSeriesChurnReport(path)
+	if err != nil || !reflect.DeepEqual(loaded, want) {
+		t.Errorf("round trip = %+v (err %v), want %+v", loaded, err, want)
+	}
+}
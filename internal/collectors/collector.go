@@ -2,14 +2,16 @@ package collectors
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
+
+	"instrumentation-score/internal/loaders"
 )
 
 // JobMetricData represents metric data for a specific job
@@ -18,17 +20,28 @@ type JobMetricData struct {
 	MetricName       string
 	Labels           []string
 	Cardinality      string
-	LabelCardinality map[string]int64 // Per-label cardinality (label_name -> cardinality)
+	LabelCardinality map[string]int64    // Per-label cardinality (label_name -> cardinality)
+	Type             string              // Metric TYPE from metadata (counter, gauge, histogram, summary) or "" if unknown
+	LabelValues      map[string][]string // Sampled values per label (label_name -> values), nil when not sampled
 }
 
 // ErrorRecord represents an error that occurred during collection
 type ErrorRecord struct {
 	MetricName string
 	Operation  string
+	Category   ErrorCategory
 	Error      string
 	Timestamp  time.Time
 }
 
+// CappedMetric is a metric of a job whose series count exceeded the cardinality cap,
+// so only the count was collected
+type CappedMetric struct {
+	MetricName  string
+	Job         string
+	Cardinality int64
+}
+
 // Collector orchestrates the collection of metrics from Prometheus
 type Collector struct {
 	client                        *PrometheusClient
@@ -37,6 +50,20 @@ type Collector struct {
 	maxConcurrentJobs             int // Concurrent job queries per metric
 	maxConcurrentLabelCardinality int // Concurrent label cardinality API calls
 	collectLabelCardinality       bool
+	labelValueSamples             int               // Values sampled per label, 0 for none
+	maxCardinalityPerMetric       int64             // Series count above which labels are not collected, 0 for no cap
+	metricTypes                   map[string]string // Metric family name -> TYPE from metadata API
+	timings                       timingRecorder    // Per-metric collection durations
+	cappedMu                      sync.Mutex
+	capped                        []CappedMetric
+	schedule                      *Schedule // Paces metric collection, nil starts metrics as concurrency allows
+	baseline                      *Baseline // Previous run for differential collection, nil collects everything
+	tsdbSnapshot                  bool      // Rank metrics by the TSDB status API before collecting
+	smallSeries                   int64     // Metrics in small have at most this many head series and are collected with one series query
+	small                         map[string]bool
+	gapsMu                        sync.Mutex
+	gaps                          []loaders.CollectionGap
+	metricCount                   int
 }
 
 // NewCollector creates a new metrics collector
@@ -81,6 +108,50 @@ func (c *Collector) SetCollectLabelCardinality(enabled bool) {
 	c.collectLabelCardinality = enabled
 }
 
+// SetLabelValueSamples samples up to n values of every label, the ones with the most series
+// first, so label_values validators can check value conventions; 0 disables sampling.
+// Values come from the cardinality API, which is then queried even without label cardinality.
+func (c *Collector) SetLabelValueSamples(n int) {
+	c.labelValueSamples = n
+}
+
+// SetMaxCardinalityPerMetric skips label and label cardinality collection for a metric of a
+// job with more series than max, recording just its count; 0 removes the cap
+func (c *Collector) SetMaxCardinalityPerMetric(max int64) {
+	c.maxCardinalityPerMetric = max
+}
+
+// CappedMetrics returns the metrics whose labels were not collected
+// because of the cardinality cap, largest first
+func (c *Collector) CappedMetrics() []CappedMetric {
+	c.cappedMu.Lock()
+	defer c.cappedMu.Unlock()
+	capped := make([]CappedMetric, len(c.capped))
+	copy(capped, c.capped)
+	sort.Slice(capped, func(i, j int) bool {
+		if capped[i].Cardinality != capped[j].Cardinality {
+			return capped[i].Cardinality > capped[j].Cardinality
+		}
+		return capped[i].MetricName+"/"+capped[i].Job < capped[j].MetricName+"/"+capped[j].Job
+	})
+	return capped
+}
+
+// exceedsCardinalityCap reports whether a metric of a job is above the cap, and records it if so
+func (c *Collector) exceedsCardinalityCap(metricName, job, cardinality string) bool {
+	if c.maxCardinalityPerMetric <= 0 {
+		return false
+	}
+	count := parseCardinality(cardinality)
+	if count <= c.maxCardinalityPerMetric {
+		return false
+	}
+	c.cappedMu.Lock()
+	c.capped = append(c.capped, CappedMetric{MetricName: metricName, Job: job, Cardinality: count})
+	c.cappedMu.Unlock()
+	return true
+}
+
 // SetLabelCardinalityConcurrency sets the number of concurrent label cardinality API requests
 func (c *Collector) SetLabelCardinalityConcurrency(concurrency int) {
 	if concurrency > 0 {
@@ -103,82 +174,201 @@ func (c *Collector) SetJobsConcurrency(concurrency int) {
 }
 
 // CollectMetrics collects all metrics from Prometheus and returns job-specific data
-func (c *Collector) CollectMetrics() ([]JobMetricData, []ErrorRecord, error) {
+func (c *Collector) CollectMetrics(ctx context.Context) ([]JobMetricData, []ErrorRecord, error) {
 	now := time.Now().Unix()
-	var errors []ErrorRecord
-	var errorsMu sync.Mutex
+	errors := NewErrorAggregator()
+
+	metricNames, err := c.prepareCollection(ctx, errors)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.schedule != nil {
+		fmt.Printf("Collection schedule: %s\n", c.schedule.Describe(len(metricNames), time.Now()))
+	}
+	fmt.Println("Analyzing metrics by job (this may take a while)...")
+	var allData []JobMetricData
+	var dataMu sync.Mutex
+	err = c.fetchJobMetricData(ctx, metricNames, now, errors, func(jobData []JobMetricData) error {
+		dataMu.Lock()
+		allData = append(allData, jobData...)
+		dataMu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return allData, errors.Records(), fmt.Errorf("collection stopped after %d metric-job combinations: %w", len(allData), err)
+	}
+	fmt.Printf("\nAnalysis complete! Processed %d metric-job combinations\n\n", len(allData))
+
+	return allData, errors.Records(), nil
+}
+
+// SetBaseline collects differentially: metrics of jobs whose series count did not change since
+// the baseline run keep their previous records
+func (c *Collector) SetBaseline(baseline *Baseline) {
+	c.baseline = baseline
+}
+
+// SetSchedule spreads metric collection over a time window and pauses it in blackout windows
+func (c *Collector) SetSchedule(schedule Schedule) {
+	c.schedule = &schedule
+}
+
+// EnableAutoTune replaces static concurrency with an AIMD limit on in-flight requests
+// The per-stage semaphores are raised to config.Max so only the adaptive limit applies.
+func (c *Collector) EnableAutoTune(config AutoTuneConfig) {
+	c.client.EnableAutoTune(config)
+	if config.Max > c.maxConcurrentMetrics {
+		c.maxConcurrentMetrics = config.Max
+	}
+	if config.Max > c.maxConcurrentLabelCardinality {
+		c.maxConcurrentLabelCardinality = config.Max
+	}
+}
+
+// AutoTuneStats reports how the adaptive limit moved, or false when auto-tuning is disabled
+func (c *Collector) AutoTuneStats() (AutoTuneStats, bool) {
+	return c.client.AutoTuneStats()
+}
+
+// MetricTimings returns how long each metric took to collect in the last run
+func (c *Collector) MetricTimings() []MetricTiming {
+	return c.timings.snapshot()
+}
 
+// prepareCollection fetches the metric names and metadata shared by every collection mode
+func (c *Collector) prepareCollection(ctx context.Context, errors *ErrorAggregator) ([]string, error) {
 	fmt.Println("Fetching metric names...")
-	metricNames, err := c.client.GetAllMetricNames(c.queryFilters)
+	metricNames, err := c.client.GetAllMetricNames(ctx, c.queryFilters)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch metric names: %w", err)
+		return nil, fmt.Errorf("failed to fetch metric names: %w", err)
 	}
 	fmt.Printf("Found %d metrics\n\n", len(metricNames))
+	c.metricCount = len(metricNames)
+
+	fmt.Println("Fetching metric metadata...")
+	c.metricTypes, err = c.client.GetMetricMetadata(ctx)
+	if err != nil {
+		// Metadata is optional - rules targeting metric types will simply skip untyped metrics
+		fmt.Printf("WARNING: Failed to fetch metric metadata, metric types will be unknown: %v\n", err)
+		errors.Add("*", "fetch_metadata", err)
+	}
 
 	if c.queryFilters != "" {
 		fmt.Printf("Using query filters: %s\n", c.queryFilters)
 	}
+	if c.tsdbSnapshot {
+		metricNames = c.applyTSDBSnapshot(ctx, metricNames, errors)
+	}
+	return metricNames, nil
+}
+
+// CollectMetricsToWriter collects all metrics and streams each metric's job data to
+// writer as soon as it is fetched, so memory does not grow with the number of series
+// It returns the number of metric-job combinations written. The caller closes writer.
+func (c *Collector) CollectMetricsToWriter(ctx context.Context, writer *JobFileWriter) (int, []ErrorRecord, error) {
+	now := time.Now().Unix()
+	errors := NewErrorAggregator()
+
+	metricNames, err := c.prepareCollection(ctx, errors)
+	if err != nil {
+		return 0, nil, err
+	}
 
+	if c.schedule != nil {
+		fmt.Printf("Collection schedule: %s\n", c.schedule.Describe(len(metricNames), time.Now()))
+	}
 	fmt.Println("Analyzing metrics by job (this may take a while)...")
-	allData := c.fetchJobMetricData(metricNames, now, &errors, &errorsMu)
-	fmt.Printf("\nAnalysis complete! Processed %d metric-job combinations\n\n", len(allData))
+	err = c.fetchJobMetricData(ctx, metricNames, now, errors, func(jobData []JobMetricData) error {
+		for _, data := range jobData {
+			if err := writer.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	written := writer.Records()
+	if err != nil {
+		return written, errors.Records(), fmt.Errorf("collection stopped after %d metric-job combinations: %w", written, err)
+	}
+	fmt.Printf("\nAnalysis complete! Processed %d metric-job combinations\n\n", written)
 
-	return allData, errors, nil
+	return written, errors.Records(), nil
 }
 
-func (c *Collector) fetchJobMetricData(metricNames []string, now int64, errors *[]ErrorRecord, errorsMu *sync.Mutex) []JobMetricData {
-	var allData []JobMetricData
-	var dataMu sync.Mutex
+// fetchJobMetricData fetches job data for every metric and hands each metric's results to emit
+// Fetch and emit failures are recorded as errors for the metric. When ctx is done no further
+// metric starts, and the context's error is returned once the metrics in flight have stopped.
+func (c *Collector) fetchJobMetricData(ctx context.Context, metricNames []string, now int64, errors *ErrorAggregator, emit func([]JobMetricData) error) error {
 	var wg sync.WaitGroup
-	var processed int32
 
 	sem := make(chan struct{}, c.maxConcurrentMetrics)
-	total := len(metricNames)
+	progress := newProgressTracker("Processing metrics", len(metricNames), 50)
+	var pace *pacer
+	if c.schedule != nil {
+		pace = c.schedule.pacer(len(metricNames))
+	}
 
-	for _, metricName := range metricNames {
+	for i, metricName := range metricNames {
+		if pace != nil && pace.wait(ctx, i) != nil {
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
 		wg.Add(1)
-		sem <- struct{}{}
 
 		go func(metric string) {
 			defer wg.Done()
 			defer func() { <-sem }()
-
-			jobData, err := c.getJobMetricDataForMetric(metric, now)
+			defer progress.Increment()
+
+			start := time.Now()
+			jobData, err := c.getJobMetricDataForMetric(ctx, metric, now)
+			c.timings.record(MetricTiming{
+				MetricName: metric,
+				Duration:   time.Since(start),
+				Jobs:       len(jobData),
+				Failed:     err != nil,
+			})
 			if err != nil {
-				errorsMu.Lock()
-				*errors = append(*errors, ErrorRecord{
-					MetricName: metric,
-					Operation:  "fetch_job_data",
-					Error:      err.Error(),
-					Timestamp:  time.Now(),
-				})
-				errorsMu.Unlock()
+				if ctx.Err() == nil {
+					errors.Add(metric, "fetch_job_data", err)
+					c.recordGap(ctx, metric, loaders.AllJobs, "fetch_job_data", err)
+				}
 			} else if len(jobData) > 0 {
-				dataMu.Lock()
-				allData = append(allData, jobData...)
-				dataMu.Unlock()
-			}
-
-			current := atomic.AddInt32(&processed, 1)
-			if current%50 == 0 || current == int32(total) {
-				fmt.Printf("\rProcessing metrics: %d/%d (%.1f%%)", current, total, float64(current)/float64(total)*100)
+				if err := emit(jobData); err != nil {
+					errors.Add(metric, "write_job_data", err)
+				}
 			}
 		}(metricName)
 	}
 
 	wg.Wait()
 	fmt.Println()
-	return allData
+	return ctx.Err()
 }
 
-func (c *Collector) getJobMetricDataForMetric(metricName string, now int64) ([]JobMetricData, error) {
-	jobNames, err := c.client.GetJobsForMetric(metricName, c.queryFilters, now)
+func (c *Collector) getJobMetricDataForMetric(ctx context.Context, metricName string, now int64) ([]JobMetricData, error) {
+	if c.small[metricName] {
+		// A single series query returns everything the per-job queries would
+		return c.getJobMetricDataFromSeries(ctx, metricName, now)
+	}
+	jobNames, reused, err := c.jobsToCollect(ctx, metricName, now)
+	if IsSeriesLimitError(err) {
+		// The metric is too large for an instant query; derive everything from its series
+		return c.getJobMetricDataFromSeries(ctx, metricName, now)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if len(jobNames) == 0 {
-		return nil, nil
+		return reused, nil
 	}
 
 	// Phase 1: Collect basic metric data (cardinality + labels) with limited concurrency
@@ -200,13 +390,38 @@ func (c *Collector) getJobMetricDataForMetric(metricName string, now int64) ([]J
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			cardinality, err := c.client.GetCardinality(metricName, job, c.queryFilters, now)
+			cardinality, err := c.client.GetCardinality(ctx, metricName, job, c.queryFilters, now)
+			if IsSeriesLimitError(err) {
+				summary, seriesErr := c.jobSeriesSummary(ctx, metricName, job, now)
+				if seriesErr != nil {
+					c.recordGap(ctx, metricName, job, "series", seriesErr)
+					return
+				}
+				mu.Lock()
+				basicData = append(basicData, basicMetricData{
+					job:         job,
+					cardinality: strconv.FormatInt(summary.count, 10),
+					labels:      summary.labels,
+				})
+				mu.Unlock()
+				return
+			}
 			if err != nil {
+				c.recordGap(ctx, metricName, job, "cardinality", err)
+				return
+			}
+
+			// Labels of a pathological metric are not worth the queries; the count is enough to score it
+			if c.exceedsCardinalityCap(metricName, job, cardinality) {
+				mu.Lock()
+				basicData = append(basicData, basicMetricData{job: job, cardinality: cardinality})
+				mu.Unlock()
 				return
 			}
 
-			labels, err := c.client.GetLabels(metricName, job, c.queryFilters)
+			labels, err := c.client.GetLabels(ctx, metricName, job, c.queryFilters)
 			if err != nil {
+				c.recordGap(ctx, metricName, job, "labels", err)
 				return
 			}
 
@@ -221,9 +436,11 @@ func (c *Collector) getJobMetricDataForMetric(metricName string, now int64) ([]J
 	}
 	wg.Wait()
 
-	// Phase 2: Collect label cardinality with higher concurrency (if enabled)
+	metricType := resolveMetricType(c.metricTypes, metricName)
+
+	// Phase 2: Collect label cardinality and sampled values with higher concurrency (if enabled)
 	var results []JobMetricData
-	if c.collectLabelCardinality {
+	if c.collectLabelCardinality || c.labelValueSamples > 0 {
 		var wg2 sync.WaitGroup
 		var mu2 sync.Mutex
 		// Use separate semaphore with higher concurrency for label cardinality API
@@ -237,15 +454,19 @@ func (c *Collector) getJobMetricDataForMetric(metricName string, now int64) ([]J
 				defer func() { <-labelCardSem }()
 
 				var labelCardinality map[string]int64
+				var labelValues map[string][]string
 				if len(d.labels) > 0 {
 					var err error
-					labelCardinality, err = c.client.GetLabelCardinality(metricName, d.job, d.labels, c.queryFilters)
+					labelCardinality, labelValues, err = c.client.GetLabelCardinalityWithValues(ctx, metricName, d.job, d.labels, c.queryFilters, c.labelValueSamples)
 					if err != nil {
 						// Log error but don't fail - fall back to no per-label data
 						fmt.Printf("WARNING: Failed to get label cardinality for %s/%s: %v\n", metricName, d.job, err)
-						labelCardinality = nil
+						labelCardinality, labelValues = nil, nil
 					}
 				}
+				if !c.collectLabelCardinality {
+					labelCardinality = nil
+				}
 
 				mu2.Lock()
 				results = append(results, JobMetricData{
@@ -254,6 +475,8 @@ func (c *Collector) getJobMetricDataForMetric(metricName string, now int64) ([]J
 					Labels:           d.labels,
 					Cardinality:      d.cardinality,
 					LabelCardinality: labelCardinality,
+					Type:             metricType,
+					LabelValues:      labelValues,
 				})
 				mu2.Unlock()
 			}(data)
@@ -268,13 +491,109 @@ func (c *Collector) getJobMetricDataForMetric(metricName string, now int64) ([]J
 				Labels:           data.labels,
 				Cardinality:      data.cardinality,
 				LabelCardinality: nil,
+				Type:             metricType,
 			})
 		}
 	}
 
+	return append(results, reused...), nil
+}
+
+// jobsToCollect returns the jobs a metric is collected from; with a baseline, jobs whose series
+// count is unchanged are returned as their previous records instead
+func (c *Collector) jobsToCollect(ctx context.Context, metricName string, now int64) ([]string, []JobMetricData, error) {
+	if c.baseline == nil {
+		jobNames, err := c.client.GetJobsForMetric(ctx, metricName, c.queryFilters, now)
+		return jobNames, nil, err
+	}
+	counts, err := c.client.GetJobSeriesCounts(ctx, metricName, c.queryFilters, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var jobNames []string
+	var reused []JobMetricData
+	metricType := resolveMetricType(c.metricTypes, metricName)
+	for _, count := range counts {
+		previous, ok := c.baseline.unchanged(metricName, count.Job, count.Count)
+		if ok && c.reusable(previous) {
+			if metricType != "" {
+				previous.Type = metricType
+			}
+			reused = append(reused, previous)
+		} else {
+			jobNames = append(jobNames, count.Job)
+		}
+	}
+	c.baseline.reused.Add(int64(len(reused)))
+	c.baseline.collected.Add(int64(len(jobNames)))
+	return jobNames, reused, nil
+}
+
+// reusable reports whether a previous record has everything this collection would collect:
+// a record capped without labels only while the cap still applies, and per-label data when enabled
+func (c *Collector) reusable(previous JobMetricData) bool {
+	if len(previous.Labels) == 0 {
+		return c.exceedsCardinalityCap(previous.MetricName, previous.Job, previous.Cardinality)
+	}
+	if c.collectLabelCardinality && previous.LabelCardinality == nil {
+		return false
+	}
+	return c.labelValueSamples == 0 || previous.LabelValues != nil
+}
+
+// getJobMetricDataFromSeries builds job data for a metric from /api/v1/series when
+// instant queries exceed the server's series limit. Per-label cardinality, when enabled,
+// is counted from the same series instead of calling the cardinality API.
+func (c *Collector) getJobMetricDataFromSeries(ctx context.Context, metricName string, now int64) ([]JobMetricData, error) {
+	series, err := c.client.GetSeriesSliced(ctx, metricSelector(metricName, "", c.queryFilters), now)
+	if err != nil {
+		return nil, fmt.Errorf("series fallback failed: %w", err)
+	}
+
+	metricType := resolveMetricType(c.metricTypes, metricName)
+	summaries := summarizeSeriesByJob(series)
+
+	jobs := make([]string, 0, len(summaries))
+	for job := range summaries {
+		jobs = append(jobs, job)
+	}
+	sort.Strings(jobs)
+
+	results := make([]JobMetricData, 0, len(jobs))
+	for _, job := range jobs {
+		summary := summaries[job]
+		data := JobMetricData{
+			Job:         job,
+			MetricName:  metricName,
+			Labels:      summary.labels,
+			Cardinality: strconv.FormatInt(summary.count, 10),
+			Type:        metricType,
+		}
+		if c.collectLabelCardinality {
+			data.LabelCardinality = summary.labelCardinality()
+		}
+		if c.labelValueSamples > 0 {
+			data.LabelValues = summary.sampleLabelValues(c.labelValueSamples)
+		}
+		results = append(results, data)
+	}
 	return results, nil
 }
 
+// jobSeriesSummary summarizes a single job's series for a metric via /api/v1/series
+func (c *Collector) jobSeriesSummary(ctx context.Context, metricName, job string, now int64) (*seriesSummary, error) {
+	series, err := c.client.GetSeriesSliced(ctx, metricSelector(metricName, job, c.queryFilters), now)
+	if err != nil {
+		return nil, err
+	}
+	summary, ok := summarizeSeriesByJob(series)[job]
+	if !ok {
+		return &seriesSummary{}, nil
+	}
+	return summary, nil
+}
+
 // sanitizeJobName replaces filesystem-unsafe characters in job names
 func sanitizeJobName(jobName string) string {
 	replacer := strings.NewReplacer(
@@ -292,71 +611,57 @@ func sanitizeJobName(jobName string) string {
 }
 
 // WritePerJobFiles writes collected data to per-job files
+// Duplicate job/metric records are merged first so they are not double counted.
 func WritePerJobFiles(outputDir string, allData []JobMetricData) error {
-	jobFiles := make(map[string]*os.File)
-	jobWriters := make(map[string]*bufio.Writer)
-	skippedJobs := make(map[string]bool)
-	var writeErrors []string
-
-	defer func() {
-		for _, writer := range jobWriters {
-			writer.Flush()
-		}
-		for _, file := range jobFiles {
-			file.Close()
-		}
-	}()
-
-	for _, data := range allData {
-		if skippedJobs[data.Job] {
-			continue
-		}
-
-		if _, exists := jobFiles[data.Job]; !exists {
-			safeJobName := sanitizeJobName(data.Job)
-			filePath := filepath.Join(outputDir, fmt.Sprintf("%s.txt", safeJobName))
-			file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
-			if err != nil {
-				errMsg := fmt.Sprintf("failed to create file for job %s (sanitized: %s): %v", data.Job, safeJobName, err)
-				writeErrors = append(writeErrors, errMsg)
-				skippedJobs[data.Job] = true
-				fmt.Printf("WARNING: %s\n", errMsg)
-				continue
-			}
-		jobFiles[data.Job] = file
-		writer := bufio.NewWriter(file)
-		jobWriters[data.Job] = writer
-		if _, err := writer.WriteString("JOB|METRIC_NAME|LABELS|CARDINALITY|LABEL_CARDINALITY\n"); err != nil {
-			return fmt.Errorf("failed to write header: %w", err)
+	writer := NewJobFileWriter(outputDir, DefaultMaxOpenJobFiles)
+	for _, data := range mergeJobMetricData(allData) {
+		if err := writer.Write(data); err != nil {
+			writer.Close()
+			return err
 		}
 	}
+	return writer.Close()
+}
 
-	writer := jobWriters[data.Job]
-	labelsStr := strings.Join(data.Labels, ",")
-
-	// Format per-label cardinality as label1:count1,label2:count2,...
-	var labelCardinalityStr string
-	if len(data.LabelCardinality) > 0 {
-		var parts []string
-		for _, label := range data.Labels {
-			if count, ok := data.LabelCardinality[label]; ok {
-				parts = append(parts, fmt.Sprintf("%s:%d", label, count))
-			}
+// mergeJobMetricData collapses records for the same job and metric with
+// loaders.MergeJobMetricData, which merges them the same way when job files are loaded
+func mergeJobMetricData(allData []JobMetricData) []JobMetricData {
+	loaded := make([]loaders.JobMetricData, len(allData))
+	for i, data := range allData {
+		loaded[i] = loaders.JobMetricData{
+			Job:              data.Job,
+			MetricName:       data.MetricName,
+			Labels:           data.Labels,
+			Cardinality:      parseCardinality(data.Cardinality),
+			LabelCardinality: data.LabelCardinality,
+			Type:             data.Type,
+			LabelValues:      data.LabelValues,
 		}
-		labelCardinalityStr = strings.Join(parts, ",")
 	}
 
-	line := fmt.Sprintf("%s|%s|%s|%s|%s\n", data.Job, data.MetricName, labelsStr, data.Cardinality, labelCardinalityStr)
-	if _, err := writer.WriteString(line); err != nil {
-		return fmt.Errorf("failed to write metric data: %w", err)
-	}
+	merged := loaders.MergeJobMetricData(loaded)
+	result := make([]JobMetricData, len(merged))
+	for i, data := range merged {
+		result[i] = JobMetricData{
+			Job:              data.Job,
+			MetricName:       data.MetricName,
+			Labels:           data.Labels,
+			Cardinality:      strconv.FormatInt(data.Cardinality, 10),
+			LabelCardinality: data.LabelCardinality,
+			Type:             data.Type,
+			LabelValues:      data.LabelValues,
+		}
 	}
+	return result
+}
 
-	if len(writeErrors) > 0 {
-		fmt.Printf("\nWARNING: Skipped %d job(s) due to file creation errors\n", len(skippedJobs))
+// parseCardinality parses a collected cardinality, treating unparseable values as zero
+func parseCardinality(cardinality string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(cardinality), 10, 64)
+	if err != nil {
+		return 0
 	}
-
-	return nil
+	return n
 }
 
 // WriteErrorsToFile writes error records to a file
@@ -370,14 +675,19 @@ func WriteErrorsToFile(filename string, errors []ErrorRecord) error {
 	writer := bufio.NewWriter(file)
 	defer writer.Flush()
 
-	if _, err := writer.WriteString("TIMESTAMP|METRIC_NAME|OPERATION|ERROR\n"); err != nil {
+	if _, err := writer.WriteString("TIMESTAMP|METRIC_NAME|OPERATION|CATEGORY|ERROR\n"); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 	for _, e := range errors {
-		line := fmt.Sprintf("%s|%s|%s|%s\n",
+		category := e.Category
+		if category == "" {
+			category = CategoryOther
+		}
+		line := fmt.Sprintf("%s|%s|%s|%s|%s\n",
 			e.Timestamp.Format("2006-01-02 15:04:05"),
 			e.MetricName,
 			e.Operation,
+			category,
 			e.Error)
 		if _, err := writer.WriteString(line); err != nil {
 			return fmt.Errorf("failed to write error line: %w", err)
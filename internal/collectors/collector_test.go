@@ -1,10 +1,19 @@
 package collectors
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"instrumentation-score/internal/loaders"
 )
 
 func TestWritePerJobFiles(t *testing.T) {
@@ -96,9 +105,8 @@ func TestWritePerJobFiles(t *testing.T) {
 					t.Errorf("file %s is empty", jobFile)
 				}
 
-				// Check for header
-				contentStr := string(content)
-				if len(contentStr) > 0 && contentStr[:3] != "JOB" {
+				// Check for format and column headers
+				if !strings.HasPrefix(string(content), loaders.FileHeader()) {
 					t.Errorf("file %s missing header", jobFile)
 				}
 			}
@@ -299,3 +307,187 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestWritePerJobFiles_EscapesDelimiters(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	data := []JobMetricData{
+		{
+			Job:              "batch|nightly",
+			MetricName:       "http_requests_total",
+			Labels:           []string{"path,full", "status"},
+			Cardinality:      "42",
+			LabelCardinality: map[string]int64{"path,full": 7, "status": 3},
+			Type:             "counter",
+			LabelValues:      map[string][]string{"status": {"ok", "a:b"}},
+		},
+	}
+
+	if err := WritePerJobFiles(tmpDir, data); err != nil {
+		t.Fatalf("WritePerJobFiles() error = %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(tmpDir, "*.txt"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one job file, got %v (err %v)", files, err)
+	}
+
+	loaded, warnings, err := loaders.LoadJobMetricReportWithWarnings(files[0])
+	if err != nil {
+		t.Fatalf("failed to load written file: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no parse warnings, got %v", warnings)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(loaded))
+	}
+	if loaded[0].Job != "batch|nightly" {
+		t.Errorf("expected job 'batch|nightly', got %q", loaded[0].Job)
+	}
+	if len(loaded[0].Labels) != 2 || loaded[0].Labels[0] != "path,full" {
+		t.Errorf("expected labels [path,full status], got %q", loaded[0].Labels)
+	}
+	if loaded[0].LabelCardinality["path,full"] != 7 {
+		t.Errorf("expected path,full cardinality 7, got %v", loaded[0].LabelCardinality)
+	}
+	if values := loaded[0].LabelValues["status"]; len(values) != 2 || values[1] != "a:b" {
+		t.Errorf("expected status values [ok a:b], got %q", loaded[0].LabelValues)
+	}
+}
+
+func TestWritePerJobFiles_MergesDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []JobMetricData{
+		{Job: "api", MetricName: "up", Labels: []string{"instance"}, Cardinality: "10", LabelCardinality: map[string]int64{"instance": 10}},
+		{Job: "api", MetricName: "requests_total", Labels: []string{"method"}, Cardinality: "4"},
+		{Job: "api", MetricName: "up", Labels: []string{"instance", "zone"}, Cardinality: "25", LabelCardinality: map[string]int64{"instance": 8, "zone": 3}, Type: "gauge"},
+	}
+
+	if err := WritePerJobFiles(tmpDir, data); err != nil {
+		t.Fatalf("WritePerJobFiles() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, "api.txt"))
+	if err != nil {
+		t.Fatalf("failed to read job file: %v", err)
+	}
+
+	var records []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "api|") {
+			records = append(records, line)
+		}
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected the duplicate up records written as one, got %q", records)
+	}
+	if !strings.HasPrefix(records[0], "api|up|instance,zone|25|") || !strings.Contains(records[0], "gauge") {
+		t.Errorf("expected up merged with the highest cardinality, label union and type, got %q", records[0])
+	}
+}
+
+func TestGetJobMetricDataForMetric_CardinalityCap(t *testing.T) {
+	counts := map[string]string{"api": "2000000", "web": "3"}
+	var mu sync.Mutex
+	labelQueries := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		var result []map[string]interface{}
+		switch {
+		case strings.HasPrefix(query, "count by (job)"):
+			for job := range counts {
+				result = append(result, map[string]interface{}{"metric": map[string]string{"job": job}, "value": []interface{}{0, "1"}})
+			}
+		case strings.HasPrefix(query, "count("):
+			for job, count := range counts {
+				if strings.Contains(query, `job="`+job+`"`) {
+					result = append(result, map[string]interface{}{"metric": map[string]string{}, "value": []interface{}{0, count}})
+				}
+			}
+		default:
+			for job := range counts {
+				if strings.Contains(query, `job="`+job+`"`) {
+					mu.Lock()
+					labelQueries[job]++
+					mu.Unlock()
+					result = append(result, map[string]interface{}{"metric": map[string]string{"__name__": "requests_total", "job": job, "pod": "a"}})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": map[string]interface{}{"resultType": "vector", "result": result}})
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.SetRetryCount(0)
+	collector := NewCollectorWithClient(client, "")
+	collector.SetCollectLabelCardinality(true)
+	collector.SetMaxCardinalityPerMetric(1000000)
+
+	data, err := collector.getJobMetricDataForMetric(context.Background(), "requests_total", 1700000000)
+	if err != nil {
+		t.Fatalf("getJobMetricDataForMetric() error = %v", err)
+	}
+	byJob := map[string]JobMetricData{}
+	for _, d := range data {
+		byJob[d.Job] = d
+	}
+	if got := byJob["api"]; got.Cardinality != "2000000" || got.Labels != nil || got.LabelCardinality != nil {
+		t.Errorf("expected only the count of the capped job, got %+v", got)
+	}
+	if labelQueries["api"] != 0 {
+		t.Errorf("expected no label queries for the capped job, got %d", labelQueries["api"])
+	}
+	if got := byJob["web"]; got.Cardinality != "3" || len(got.Labels) == 0 {
+		t.Errorf("expected labels of the job under the cap, got %+v", got)
+	}
+
+	capped := collector.CappedMetrics()
+	if len(capped) != 1 || capped[0] != (CappedMetric{MetricName: "requests_total", Job: "api", Cardinality: 2000000}) {
+		t.Errorf("CappedMetrics() = %+v", capped)
+	}
+}
+
+func TestCollectMetrics_Cancelled(t *testing.T) {
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/label/__name__/values":
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": []string{"a_total", "b_total", "c_total", "d_total"}})
+		case "/api/v1/query":
+			// Hold every query until the client gives up on it
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-r.Context().Done()
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.SetRetryCount(3)
+	collector := NewCollectorWithClient(client, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := collector.CollectMetrics(ctx)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("CollectMetrics() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CollectMetrics() kept running after its context was cancelled")
+	}
+}
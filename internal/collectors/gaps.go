@@ -0,0 +1,58 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// recordGap records a metric of job that could not be collected, job loaders.AllJobs when the
+// metric failed before its jobs were known; failures of a cancelled collection are not gaps
+func (c *Collector) recordGap(ctx context.Context, metricName, job, operation string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	c.gapsMu.Lock()
+	c.gaps = append(c.gaps, loaders.CollectionGap{Job: job, MetricName: metricName, Operation: operation, Error: err.Error()})
+	c.gapsMu.Unlock()
+}
+
+// CollectionGaps returns the metrics of jobs the last collection failed to collect, sorted by
+// job and metric, with the number of metrics it tried to collect
+func (c *Collector) CollectionGaps() loaders.CollectionGaps {
+	c.gapsMu.Lock()
+	defer c.gapsMu.Unlock()
+	gaps := append([]loaders.CollectionGap(nil), c.gaps...)
+	sort.SliceStable(gaps, func(i, j int) bool {
+		if gaps[i].Job != gaps[j].Job {
+			return gaps[i].Job < gaps[j].Job
+		}
+		return gaps[i].MetricName < gaps[j].MetricName
+	})
+	return loaders.CollectionGaps{Metrics: c.metricCount, Gaps: gaps}
+}
+
+// WriteCollectionGapsFile writes a collection gap report
+// The report is written when nothing failed too, so evaluate knows the run was complete.
+func WriteCollectionGapsFile(filename string, gaps loaders.CollectionGaps) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create collection gap file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.WriteString(loaders.FormatCollectionGapsHeader(gaps.Metrics)); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, gap := range gaps.Gaps {
+		if _, err := writer.WriteString(loaders.FormatCollectionGapLine(gap)); err != nil {
+			return fmt.Errorf("failed to write collection gap line: %w", err)
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,74 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestCollectionGaps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		var result []map[string]interface{}
+		switch {
+		case strings.HasPrefix(query, "count by (job)"):
+			for _, job := range []string{"api", "web"} {
+				result = append(result, map[string]interface{}{"metric": map[string]string{"job": job}, "value": []interface{}{0, "1"}})
+			}
+		case strings.HasPrefix(query, "count("):
+			result = append(result, map[string]interface{}{"metric": map[string]string{}, "value": []interface{}{0, "2"}})
+		case strings.Contains(query, `job="web"`):
+			http.Error(w, "query timed out", http.StatusServiceUnavailable)
+			return
+		default:
+			result = append(result, map[string]interface{}{"metric": map[string]string{"__name__": "requests_total", "job": "api"}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": map[string]interface{}{"resultType": "vector", "result": result}})
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.SetRetryCount(0)
+	collector := NewCollectorWithClient(client, "")
+	collector.metricCount = 1
+
+	data, err := collector.getJobMetricDataForMetric(context.Background(), "requests_total", 1700000000)
+	if err != nil {
+		t.Fatalf("getJobMetricDataForMetric() error = %v", err)
+	}
+	if len(data) != 1 || data[0].Job != "api" {
+		t.Errorf("data = %+v, want only api", data)
+	}
+
+	gaps := collector.CollectionGaps()
+	if len(gaps.Gaps) != 1 || gaps.Gaps[0].Job != "web" || gaps.Gaps[0].Operation != "labels" {
+		t.Fatalf("CollectionGaps() = %+v, want the labels of web", gaps)
+	}
+
+	filename := filepath.Join(t.TempDir(), loaders.CollectionGapsFileName)
+	if err := WriteCollectionGapsFile(filename, gaps); err != nil {
+		t.Fatalf("WriteCollectionGapsFile() error = %v", err)
+	}
+	loaded, err := loaders.LoadCollectionGapsReport(filename)
+	if err != nil {
+		t.Fatalf("LoadCollectionGapsReport() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, gaps) {
+		t.Errorf("loaded %+v, want %+v", loaded, gaps)
+	}
+
+	// A cancelled collection leaves no gaps behind
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	collector.recordGap(ctx, "requests_total", "api", "labels", context.Canceled)
+	if got := collector.CollectionGaps(); len(got.Gaps) != 1 {
+		t.Errorf("CollectionGaps() after cancellation = %+v", got)
+	}
+}
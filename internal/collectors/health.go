@@ -0,0 +1,204 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// DefaultScrapeHealthWindow is the range scrape health is aggregated over
+const DefaultScrapeHealthWindow = "1h"
+
+// VectorSample is one series of an instant query result
+type VectorSample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// QueryVector runs an instant PromQL query and returns its vector result
+func (c *PrometheusClient) QueryVector(ctx context.Context, query string, now int64) ([]VectorSample, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("time", strconv.FormatInt(now, 10))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/query?%s", c.BaseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	c.addAuthIfNeeded(req)
+
+	resp, err := c.doRequestWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		var errorResp struct {
+			Error string `json:"error"`
+		}
+		errorMsg := string(body)
+		if json.Unmarshal(body, &errorResp) == nil && errorResp.Error != "" {
+			errorMsg = errorResp.Error
+		}
+		return nil, fmt.Errorf("HTTP %d - query: %s - error: %s", resp.StatusCode, query, errorMsg)
+	}
+
+	var result struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  []interface{}     `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	samples := make([]VectorSample, 0, len(result.Data.Result))
+	for _, series := range result.Data.Result {
+		if len(series.Value) < 2 {
+			continue
+		}
+		valueStr, ok := series.Value[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, VectorSample{Labels: series.Metric, Value: value})
+	}
+	return samples, nil
+}
+
+// scrapeHealthQuery is one per-target aggregation feeding a ScrapeHealthData field
+type scrapeHealthQuery struct {
+	name     string
+	expr     string // %[1]s is the series selector filter, %[2]s the window
+	required bool   // Optional queries rely on --enable-feature=extra-scrape-metrics
+	apply    func(h *loaders.ScrapeHealthData, value float64)
+}
+
+var scrapeHealthQueries = []scrapeHealthQuery{
+	{
+		name:     "up",
+		expr:     `avg by (job, instance) (avg_over_time(up{%[1]s}[%[2]s]))`,
+		required: true,
+		apply:    func(h *loaders.ScrapeHealthData, v float64) { h.UpRatio = v },
+	},
+	{
+		name:     "up_changes",
+		expr:     `max by (job, instance) (changes(up{%[1]s}[%[2]s]))`,
+		required: true,
+		apply:    func(h *loaders.ScrapeHealthData, v float64) { h.UpChanges = int(v) },
+	},
+	{
+		name:  "scrape_duration_seconds",
+		expr:  `max by (job, instance) (max_over_time(scrape_duration_seconds{%[1]s}[%[2]s]))`,
+		apply: func(h *loaders.ScrapeHealthData, v float64) { h.ScrapeDurationSeconds = v },
+	},
+	{
+		name:  "scrape_timeout_seconds",
+		expr:  `max by (job, instance) (last_over_time(scrape_timeout_seconds{%[1]s}[%[2]s]))`,
+		apply: func(h *loaders.ScrapeHealthData, v float64) { h.ScrapeTimeoutSeconds = v },
+	},
+	{
+		name:  "scrape_samples_post_metric_relabeling",
+		expr:  `max by (job, instance) (max_over_time(scrape_samples_post_metric_relabeling{%[1]s}[%[2]s]))`,
+		apply: func(h *loaders.ScrapeHealthData, v float64) { h.SamplesPostRelabeling = int64(v) },
+	},
+	{
+		name:  "scrape_sample_limit",
+		expr:  `max by (job, instance) (last_over_time(scrape_sample_limit{%[1]s}[%[2]s]))`,
+		apply: func(h *loaders.ScrapeHealthData, v float64) { h.SampleLimit = int64(v) },
+	},
+}
+
+// CollectScrapeHealth aggregates up and the scrape_* series of every target over window
+// Failing optional queries are recorded as errors and leave their fields at zero;
+// only failing to query up aborts collection.
+func (c *Collector) CollectScrapeHealth(ctx context.Context, window string) ([]loaders.ScrapeHealthData, []ErrorRecord, error) {
+	if window == "" {
+		window = DefaultScrapeHealthWindow
+	}
+	now := time.Now().Unix()
+	errors := NewErrorAggregator()
+
+	type key struct{ job, instance string }
+	byTarget := make(map[key]*loaders.ScrapeHealthData)
+
+	for _, q := range scrapeHealthQueries {
+		samples, err := c.client.QueryVector(ctx, fmt.Sprintf(q.expr, c.queryFilters, window), now)
+		if err != nil {
+			if q.required {
+				return nil, nil, fmt.Errorf("failed to query scrape health (%s): %w", q.name, err)
+			}
+			errors.Add(q.name, "scrape_health", err)
+			continue
+		}
+		for _, sample := range samples {
+			k := key{sample.Labels["job"], sample.Labels["instance"]}
+			if k.job == "" {
+				continue
+			}
+			health, ok := byTarget[k]
+			if !ok {
+				if q.name != "up" {
+					continue // Only targets Prometheus scrapes have health
+				}
+				health = &loaders.ScrapeHealthData{Job: k.job, Instance: k.instance}
+				byTarget[k] = health
+			}
+			q.apply(health, sample.Value)
+		}
+	}
+
+	results := make([]loaders.ScrapeHealthData, 0, len(byTarget))
+	for _, health := range byTarget {
+		results = append(results, *health)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Job != results[j].Job {
+			return results[i].Job < results[j].Job
+		}
+		return results[i].Instance < results[j].Instance
+	})
+	return results, errors.Records(), nil
+}
+
+// WriteScrapeHealthFile writes a scrape health report
+func WriteScrapeHealthFile(filename string, health []loaders.ScrapeHealthData) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create scrape health file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.WriteString(loaders.ScrapeHealthColumnHeader + "\n"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, h := range health {
+		if _, err := writer.WriteString(loaders.FormatScrapeHealthLine(h)); err != nil {
+			return fmt.Errorf("failed to write scrape health line: %w", err)
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,116 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// scrapeHealthServer answers each scrape health query with the samples of the metric it aggregates
+// Metrics without samples return an error, like servers without extra-scrape-metrics.
+func scrapeHealthServer(t *testing.T, samples map[string][]VectorSample) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			return
+		}
+		query := r.URL.Query().Get("query")
+
+		var matched []VectorSample
+		found := false
+		for metric, metricSamples := range samples {
+			if strings.Contains(query, "("+metric+"{") {
+				matched, found = metricSamples, true
+			}
+		}
+		if !found {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "unknown metric"})
+			return
+		}
+
+		var result []map[string]interface{}
+		for _, s := range matched {
+			result = append(result, map[string]interface{}{
+				"metric": s.Labels,
+				"value":  []interface{}{1700000000, strconv.FormatFloat(s.Value, 'f', -1, 64)},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data":   map[string]interface{}{"resultType": "vector", "result": result},
+		})
+	}))
+}
+
+func TestCollector_CollectScrapeHealth(t *testing.T) {
+	api := map[string]string{"job": "api", "instance": "10.0.0.1:8080"}
+	worker := map[string]string{"job": "worker", "instance": "10.0.0.2:9090"}
+	unscraped := map[string]string{"job": "pushed", "instance": "gw"}
+
+	server := scrapeHealthServer(t, map[string][]VectorSample{
+		// avg_over_time and changes both aggregate up, so both queries see the same values
+		"up": {{Labels: api, Value: 1}, {Labels: worker, Value: 0.5}},
+		"scrape_duration_seconds": {
+			{Labels: api, Value: 0.25}, {Labels: worker, Value: 9.5}, {Labels: unscraped, Value: 1},
+		},
+		"scrape_samples_post_metric_relabeling": {{Labels: api, Value: 1200}, {Labels: worker, Value: 48000}},
+	})
+	defer server.Close()
+
+	collector := NewCollector(server.URL, "", "")
+	health, errors, err := collector.CollectScrapeHealth(context.Background(), "30m")
+	if err != nil {
+		t.Fatalf("CollectScrapeHealth() error = %v", err)
+	}
+
+	want := []loaders.ScrapeHealthData{
+		{Job: "api", Instance: "10.0.0.1:8080", UpRatio: 1, UpChanges: 1, ScrapeDurationSeconds: 0.25, SamplesPostRelabeling: 1200},
+		{Job: "worker", Instance: "10.0.0.2:9090", UpRatio: 0.5, UpChanges: 0, ScrapeDurationSeconds: 9.5, SamplesPostRelabeling: 48000},
+	}
+	if !reflect.DeepEqual(health, want) {
+		t.Errorf("CollectScrapeHealth() = %+v, want %+v", health, want)
+	}
+
+	// scrape_timeout_seconds and scrape_sample_limit are unavailable
+	if len(errors) != 2 {
+		t.Errorf("got %d errors, want 2: %+v", len(errors), errors)
+	}
+}
+
+func TestCollector_CollectScrapeHealth_UpFails(t *testing.T) {
+	server := scrapeHealthServer(t, map[string][]VectorSample{})
+	defer server.Close()
+
+	collector := NewCollector(server.URL, "", "")
+	collector.SetRetryCount(0)
+	if _, _, err := collector.CollectScrapeHealth(context.Background(), ""); err == nil {
+		t.Fatal("CollectScrapeHealth() expected error when up cannot be queried")
+	}
+}
+
+func TestWriteScrapeHealthFile(t *testing.T) {
+	health := []loaders.ScrapeHealthData{
+		{Job: "batch|nightly", Instance: "host:9100", UpRatio: 0.75, UpChanges: 3, ScrapeDurationSeconds: 1.5, ScrapeTimeoutSeconds: 10, SamplesPostRelabeling: 500, SampleLimit: 1000},
+	}
+	path := filepath.Join(t.TempDir(), loaders.ScrapeHealthFileName)
+	if err := WriteScrapeHealthFile(path, health); err != nil {
+		t.Fatalf("WriteScrapeHealthFile() error = %v", err)
+	}
+
+	got, err := loaders.LoadScrapeHealthReport(path)
+	if err != nil {
+		t.Fatalf("LoadScrapeHealthReport() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, health) {
+		t.Errorf("round trip = %+v, want %+v", got, health)
+	}
+}
@@ -0,0 +1,445 @@
+package collectors
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// OTLPMetricsPath is where OTLP/HTTP exporters send metrics
+const OTLPMetricsPath = "/v1/metrics"
+
+// DefaultOTLPJobAttributes name a job the way Prometheus does: service.namespace/service.name
+var DefaultOTLPJobAttributes = []string{"service.namespace", "service.name"}
+
+// unknownService is the job of resources without any job attribute, as OpenTelemetry SDKs name them
+const unknownService = "unknown_service"
+
+// maxOTLPRequestBytes bounds a single OTLP/HTTP request body
+const maxOTLPRequestBytes = 64 << 20
+
+// OTLPSource collects per-job metric data from OTLP metrics, without Prometheus
+// Metrics and their data points are translated to the series Prometheus would store for them,
+// and resources are mapped to jobs by their attributes. Exports repeat series every interval,
+// so every series is counted once however often it is received.
+type OTLPSource struct {
+	jobAttributes []string
+	errors        *ErrorAggregator
+
+	mu        sync.Mutex
+	summaries map[string]map[string]*seriesSummary // job -> metric -> summary
+	seen      map[string]bool                      // Series already counted
+	types     map[string]string
+	buildInfo []loaders.BuildInfoData
+	requests  int
+}
+
+// NewOTLPSource creates a source joining the values of jobAttributes with '/' into job names
+func NewOTLPSource(jobAttributes []string) *OTLPSource {
+	if len(jobAttributes) == 0 {
+		jobAttributes = DefaultOTLPJobAttributes
+	}
+	return &OTLPSource{
+		jobAttributes: jobAttributes,
+		errors:        NewErrorAggregator(),
+		summaries:     make(map[string]map[string]*seriesSummary),
+		seen:          make(map[string]bool),
+		types:         make(map[string]string),
+	}
+}
+
+// otlpRequest is the JSON encoding of an OTLP ExportMetricsServiceRequest
+type otlpRequest struct {
+	ResourceMetrics []struct {
+		Resource struct {
+			Attributes []otlpAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeMetrics []struct {
+			Metrics []otlpMetric `json:"metrics"`
+		} `json:"scopeMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+type otlpMetric struct {
+	Name                 string             `json:"name"`
+	Unit                 string             `json:"unit"`
+	Gauge                *otlpDataPoints    `json:"gauge"`
+	Sum                  *otlpSumDataPoints `json:"sum"`
+	Histogram            *otlpDataPoints    `json:"histogram"`
+	ExponentialHistogram *otlpDataPoints    `json:"exponentialHistogram"`
+	Summary              *otlpDataPoints    `json:"summary"`
+}
+
+type otlpDataPoints struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSumDataPoints struct {
+	DataPoints  []otlpDataPoint `json:"dataPoints"`
+	IsMonotonic bool            `json:"isMonotonic"`
+}
+
+// otlpDataPoint holds the fields of every data point kind that decide its series
+type otlpDataPoint struct {
+	Attributes     []otlpAttribute `json:"attributes"`
+	ExplicitBounds []float64       `json:"explicitBounds"`
+	QuantileValues []struct {
+		Quantile float64 `json:"quantile"`
+	} `json:"quantileValues"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string         `json:"stringValue"`
+		BoolValue   *bool           `json:"boolValue"`
+		IntValue    json.RawMessage `json:"intValue"` // A string in OTLP JSON, a number from lenient encoders
+		DoubleValue *float64        `json:"doubleValue"`
+		ArrayValue  json.RawMessage `json:"arrayValue"`
+		KvlistValue json.RawMessage `json:"kvlistValue"`
+		BytesValue  *string         `json:"bytesValue"`
+	} `json:"value"`
+}
+
+// String returns the attribute value as the Prometheus exporter renders it into a label
+func (a otlpAttribute) String() string {
+	v := a.Value
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strings.Trim(string(v.IntValue), `"`)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+	case v.ArrayValue != nil:
+		return string(v.ArrayValue)
+	case v.KvlistValue != nil:
+		return string(v.KvlistValue)
+	case v.BytesValue != nil:
+		return *v.BytesValue
+	}
+	return ""
+}
+
+// Read adds the metrics of OTLP JSON read from r: a single export request, or one per line
+// as the OpenTelemetry Collector file exporter writes them
+func (s *OTLPSource) Read(r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var request otlpRequest
+		if err := decoder.Decode(&request); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("export request %d: %w", i, err)
+		}
+		s.add(&request)
+	}
+}
+
+// Handler receives OTLP/HTTP metric exports with the JSON encoding on OTLPMetricsPath
+// Rejected requests are recorded as errors, so a misconfigured exporter shows in the report.
+func (s *OTLPSource) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(OTLPMetricsPath, func(w http.ResponseWriter, r *http.Request) {
+		status, err := s.receive(w, r)
+		if err != nil {
+			s.errors.Add("*", "receive_otlp", err)
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	})
+	return mux
+}
+
+// receive adds the metrics of one OTLP/HTTP request, returning the status to reject it with
+func (s *OTLPSource) receive(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("%s %s: only POST is supported", r.Method, r.URL.Path)
+	}
+	if contentType := r.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("content type %q is not supported, set encoding: json on the otlphttp exporter", contentType)
+	}
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxOTLPRequestBytes)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return http.StatusBadRequest, fmt.Errorf("failed to decompress request: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	var request otlpRequest
+	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to parse export request: %w", err)
+	}
+	s.add(&request)
+	return http.StatusOK, nil
+}
+
+// add counts the series of an export request that have not been seen before
+func (s *OTLPSource) add(request *otlpRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	for _, resourceMetrics := range request.ResourceMetrics {
+		resource := make(map[string]string, len(resourceMetrics.Resource.Attributes))
+		for _, attribute := range resourceMetrics.Resource.Attributes {
+			resource[attribute.Key] = attribute.String()
+		}
+		targetLabels := map[string]string{"job": s.jobName(resource)}
+		if instance := resource["service.instance.id"]; instance != "" {
+			targetLabels["instance"] = instance
+		}
+
+		// Resource attributes become labels of target_info, as the Prometheus exporter writes them
+		info := map[string]string{"__name__": loaders.TargetInfoMetric}
+		for key, value := range resource {
+			info[PrometheusLabelName(key)] = value
+		}
+		attachTargetLabels(info, targetLabels)
+		if s.addSeries(info) {
+			s.types[loaders.TargetInfoMetric] = "gauge"
+			if data, ok := loaders.BuildInfoFromLabels(info["job"], loaders.TargetInfoMetric, info); ok {
+				s.buildInfo = append(s.buildInfo, data)
+			}
+		}
+
+		for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+			for _, metric := range scopeMetrics.Metrics {
+				s.addMetric(metric, targetLabels)
+			}
+		}
+	}
+}
+
+// addMetric counts the series of each data point of metric
+func (s *OTLPSource) addMetric(metric otlpMetric, targetLabels map[string]string) {
+	var metricType string
+	var points []otlpDataPoint
+	switch {
+	case metric.Gauge != nil:
+		metricType, points = "gauge", metric.Gauge.DataPoints
+	case metric.Sum != nil && metric.Sum.IsMonotonic:
+		metricType, points = "counter", metric.Sum.DataPoints
+	case metric.Sum != nil:
+		metricType, points = "gauge", metric.Sum.DataPoints
+	case metric.Histogram != nil:
+		metricType, points = "histogram", metric.Histogram.DataPoints
+	case metric.ExponentialHistogram != nil:
+		// Stored as a native histogram: one series per data point
+		metricType, points = "histogram", metric.ExponentialHistogram.DataPoints
+	case metric.Summary != nil:
+		metricType, points = "summary", metric.Summary.DataPoints
+	default:
+		return
+	}
+	name := PrometheusMetricName(metric.Name, metric.Unit, metricType)
+	s.types[name] = metricType
+
+	for _, point := range points {
+		labels := make(map[string]string, len(point.Attributes)+3)
+		for _, attribute := range point.Attributes {
+			labels[PrometheusLabelName(attribute.Key)] = attribute.String()
+		}
+		attachTargetLabels(labels, targetLabels)
+
+		series := func(suffix string, extra ...string) {
+			copied := make(map[string]string, len(labels)+2)
+			for label, value := range labels {
+				copied[label] = value
+			}
+			copied["__name__"] = name + suffix
+			for i := 0; i+1 < len(extra); i += 2 {
+				copied[extra[i]] = extra[i+1]
+			}
+			s.addSeries(copied)
+		}
+		switch {
+		case metric.Histogram != nil:
+			for _, bound := range point.ExplicitBounds {
+				series("_bucket", "le", strconv.FormatFloat(bound, 'g', -1, 64))
+			}
+			series("_bucket", "le", "+Inf")
+			series("_sum")
+			series("_count")
+		case metric.Summary != nil:
+			for _, quantile := range point.QuantileValues {
+				series("", "quantile", strconv.FormatFloat(quantile.Quantile, 'g', -1, 64))
+			}
+			series("_sum")
+			series("_count")
+		default:
+			series("")
+		}
+	}
+}
+
+// addSeries counts a series unless it was seen before, reporting whether it was new
+func (s *OTLPSource) addSeries(labels map[string]string) bool {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var key strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&key, "%s=%q,", name, labels[name])
+	}
+	if s.seen[key.String()] {
+		return false
+	}
+	s.seen[key.String()] = true
+	addSeries(s.summaries, labels["job"], labels)
+	return true
+}
+
+// jobName joins the values of the job attributes a resource has
+func (s *OTLPSource) jobName(resource map[string]string) string {
+	var parts []string
+	for _, attribute := range s.jobAttributes {
+		if value := resource[attribute]; value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if len(parts) == 0 {
+		return unknownService
+	}
+	return strings.Join(parts, "/")
+}
+
+// Requests returns the number of export requests read or received
+func (s *OTLPSource) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Jobs returns the number of jobs seen so far
+func (s *OTLPSource) Jobs() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.summaries)
+}
+
+// WriteTo writes one record per job and metric to writer, recording up to valueSample values
+// per label, and returns the number of records written along with the rejected requests
+func (s *OTLPSource) WriteTo(writer *JobFileWriter, valueSample int) (int, []ErrorRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := writeSummaries(writer, s.summaries, s.types, valueSample); err != nil {
+		return writer.Records(), s.errors.Records(), err
+	}
+	return writer.Records(), s.errors.Records(), nil
+}
+
+// BuildInfo returns the service versions of the resources seen
+func (s *OTLPSource) BuildInfo() []loaders.BuildInfoData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortBuildInfo(append([]loaders.BuildInfoData(nil), s.buildInfo...))
+}
+
+// otlpUnits are the Prometheus suffixes of OTLP units, as the Prometheus exporter adds them
+var otlpUnits = map[string]string{
+	"d": "days", "h": "hours", "min": "minutes", "s": "seconds", "ms": "milliseconds", "us": "microseconds", "ns": "nanoseconds",
+	"By": "bytes", "KiBy": "kibibytes", "MiBy": "mebibytes", "GiBy": "gibibytes", "KBy": "kilobytes", "MBy": "megabytes", "GBy": "gigabytes",
+	"bit": "bits", "m": "meters", "V": "volts", "A": "amperes", "J": "joules", "W": "watts", "g": "grams", "Cel": "celsius", "Hz": "hertz",
+	"%": "percent",
+}
+
+// otlpPerUnits are the Prometheus suffixes of the denominators of OTLP rate units
+var otlpPerUnits = map[string]string{"s": "second", "m": "minute", "h": "hour", "d": "day", "w": "week", "mo": "month", "y": "year"}
+
+// PrometheusMetricName translates an OTLP metric name to the name the Prometheus exporter
+// gives it: invalid characters become '_', the unit is added as a suffix, and counters end
+// in _total. Histogram and summary series suffixes are not included.
+func PrometheusMetricName(name, unit, metricType string) string {
+	name = sanitizeName(name, false)
+
+	var suffixes []string
+	if main, per, _ := strings.Cut(unitWithoutAnnotations(unit), "/"); main != "" || per != "" {
+		if suffix := otlpUnits[main]; suffix != "" {
+			suffixes = append(suffixes, suffix)
+		} else if main == "1" && metricType == "gauge" {
+			suffixes = append(suffixes, "ratio")
+		} else if main != "" && main != "1" {
+			suffixes = append(suffixes, sanitizeName(main, false))
+		}
+		if per != "" {
+			if suffix := otlpPerUnits[per]; suffix != "" {
+				suffixes = append(suffixes, "per_"+suffix)
+			} else {
+				suffixes = append(suffixes, "per_"+sanitizeName(per, false))
+			}
+		}
+	}
+	for _, suffix := range suffixes {
+		if !strings.HasSuffix(name, "_"+suffix) {
+			name += "_" + suffix
+		}
+	}
+	if metricType == "counter" && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return name
+}
+
+// PrometheusLabelName translates an OTLP attribute key to a label name: invalid characters
+// become '_' and a leading digit is prefixed with key_
+func PrometheusLabelName(key string) string {
+	return sanitizeName(key, true)
+}
+
+// unitWithoutAnnotations drops {annotations} from a unit, e.g. {request}/s becomes /s
+func unitWithoutAnnotations(unit string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range unit {
+		switch {
+		case r == '{':
+			depth++
+		case r == '}' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// sanitizeName replaces characters invalid in metric names (':' is valid) or label names with '_'
+func sanitizeName(name string, label bool) string {
+	var b strings.Builder
+	for i, r := range name {
+		valid := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') || (!label && r == ':')
+		if i == 0 && r >= '0' && r <= '9' {
+			if label {
+				b.WriteString("key_")
+			} else {
+				b.WriteByte('_')
+			}
+			valid = true
+		}
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package collectors
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// testOTLPExport is an OTLP JSON export of two services, the second without a namespace
+const testOTLPExport = `{"resourceMetrics":[
+{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"cart"}},{"key":"service.namespace","value":{"stringValue":"shop"}},
+	{"key":"service.instance.id","value":{"stringValue":"cart-1"}},{"key":"service.version","value":{"stringValue":"1.4.0"}}]},
+ "scopeMetrics":[{"scope":{"name":"io.opentelemetry.http"},"metrics":[
+	{"name":"http.server.request.duration","unit":"s","histogram":{"aggregationTemporality":2,"dataPoints":[
+		{"attributes":[{"key":"http.route","value":{"stringValue":"/cart"}},{"key":"http.response.status_code","value":{"intValue":"200"}}],"explicitBounds":[0.1,1],"bucketCounts":["1","2","0"]}]}},
+	{"name":"http.server.active_requests","unit":"{request}","sum":{"isMonotonic":false,"dataPoints":[{"asInt":"2"}]}},
+	{"name":"cart.items.added","unit":"{item}","sum":{"isMonotonic":true,"dataPoints":[
+		{"attributes":[{"key":"premium","value":{"boolValue":true}}]},{"attributes":[{"key":"premium","value":{"boolValue":false}}]}]}}]}]},
+{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"billing"}}]},
+ "scopeMetrics":[{"metrics":[{"name":"process.memory.usage","unit":"By","gauge":{"dataPoints":[{"asInt":"1024"}]}}]}]}]}`
+
+func TestOTLPSource_Read(t *testing.T) {
+	source := NewOTLPSource(nil)
+	// The file exporter writes an export per line; repeated exports must not add series
+	if err := source.Read(strings.NewReader(testOTLPExport + "\n" + testOTLPExport + "\n")); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if source.Requests() != 2 || source.Jobs() != 2 {
+		t.Errorf("Requests() = %d, Jobs() = %d, want 2 and 2", source.Requests(), source.Jobs())
+	}
+
+	tmpDir := t.TempDir()
+	writer := NewJobFileWriter(tmpDir, 0)
+	written, errs, err := source.WriteTo(writer, 0)
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("WriteTo() errors = %v, %v", errs, err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if written != 8 {
+		t.Errorf("written = %d, want 6 metrics of shop/cart and 2 of billing", written)
+	}
+
+	data, err := loaders.LoadJobMetricReport(filepath.Join(tmpDir, JobFileName("shop/cart")))
+	if err != nil {
+		t.Fatalf("failed to load shop/cart job file: %v", err)
+	}
+	byName := make(map[string]loaders.JobMetricData)
+	for _, jm := range data {
+		byName[jm.MetricName] = jm
+	}
+	want := map[string]struct {
+		series     int64
+		metricType string
+	}{
+		"http_server_request_duration_seconds_bucket": {3, "histogram"},
+		"http_server_request_duration_seconds_count":  {1, "histogram"},
+		"http_server_request_duration_seconds_sum":    {1, "histogram"},
+		"http_server_active_requests":                 {1, "gauge"},
+		"cart_items_added_total":                      {2, "counter"},
+		"target_info":                                 {1, "gauge"},
+	}
+	for name, w := range want {
+		got, ok := byName[name]
+		if !ok || got.Cardinality != w.series || got.Type != w.metricType {
+			t.Errorf("%s = %+v, want %d series of type %s", name, got, w.series, w.metricType)
+		}
+	}
+	buckets := byName["http_server_request_duration_seconds_bucket"]
+	if got := strings.Join(buckets.Labels, ","); got != "http_response_status_code,http_route,instance,job,le" {
+		t.Errorf("bucket labels = %s", got)
+	}
+
+	if info := source.BuildInfo(); len(info) != 1 || info[0].Job != "shop/cart" || info[0].Version != "1.4.0" {
+		t.Errorf("BuildInfo() = %+v", info)
+	}
+
+	if err := source.Read(strings.NewReader(`{"resourceMetrics":`)); err == nil {
+		t.Error("expected error for truncated OTLP JSON")
+	}
+}
+
+func TestOTLPSource_Handler(t *testing.T) {
+	source := NewOTLPSource([]string{"service.name"})
+	server := httptest.NewServer(source.Handler())
+	defer server.Close()
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(testOTLPExport))
+	gz.Close()
+	req, _ := http.NewRequest("POST", server.URL+OTLPMetricsPath, &compressed)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	resp, err = http.Post(server.URL+OTLPMetricsPath, "application/x-protobuf", strings.NewReader("\x0a\x00"))
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("protobuf status = %d, want 415", resp.StatusCode)
+	}
+
+	writer := NewJobFileWriter(t.TempDir(), 0)
+	defer writer.Close()
+	if _, errs, _ := source.WriteTo(writer, 0); len(errs) != 1 || errs[0].Operation != "receive_otlp" {
+		t.Errorf("errors = %+v, want the rejected protobuf request", errs)
+	}
+	// Without service.namespace among the job attributes, cart is its own job
+	if source.Jobs() != 2 || source.jobName(map[string]string{"service.name": "cart", "service.namespace": "shop"}) != "cart" {
+		t.Errorf("Jobs() = %d", source.Jobs())
+	}
+}
+
+func TestPrometheusMetricName(t *testing.T) {
+	tests := []struct {
+		name, unit, metricType, want string
+	}{
+		{"http.server.request.duration", "s", "histogram", "http_server_request_duration_seconds"},
+		{"http.server.active_requests", "{request}", "gauge", "http_server_active_requests"},
+		{"process.cpu.utilization", "1", "gauge", "process_cpu_utilization_ratio"},
+		{"system.network.io", "By", "counter", "system_network_io_bytes_total"},
+		{"queue.throughput", "{message}/s", "gauge", "queue_throughput_per_second"},
+		{"requests_total", "", "counter", "requests_total"},
+		{"jvm.memory.used_bytes", "By", "gauge", "jvm_memory_used_bytes"},
+		{"2xx.responses", "", "counter", "_2xx_responses_total"},
+	}
+	for _, tt := range tests {
+		if got := PrometheusMetricName(tt.name, tt.unit, tt.metricType); got != tt.want {
+			t.Errorf("PrometheusMetricName(%q, %q, %q) = %q, want %q", tt.name, tt.unit, tt.metricType, got, tt.want)
+		}
+	}
+	if got := PrometheusLabelName("k8s.pod.name"); got != "k8s_pod_name" {
+		t.Errorf("PrometheusLabelName() = %q", got)
+	}
+}
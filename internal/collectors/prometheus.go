@@ -1,12 +1,14 @@
 package collectors
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,6 +19,9 @@ type PrometheusClient struct {
 	Login      string
 	Client     *http.Client
 	RetryCount int
+	Headers    map[string]string // Sent with every request, e.g. X-Scope-OrgID for a Mimir tenant
+	limiter    *aimdLimiter      // Optional adaptive in-flight request limit
+	queries    queryRecorder
 }
 
 // NewPrometheusClient creates a new Prometheus API client
@@ -34,20 +39,30 @@ func (c *PrometheusClient) SetRetryCount(count int) {
 	c.RetryCount = count
 }
 
+// Queries returns the query templates of the requests sent so far
+func (c *PrometheusClient) Queries() []QueryTemplate {
+	return c.queries.snapshot()
+}
+
 // doRequestWithRetry executes an HTTP request with retry logic
 func (c *PrometheusClient) doRequestWithRetry(req *http.Request) (*http.Response, error) {
+	c.queries.record(req)
+
 	var lastErr error
 	var resp *http.Response
 
 	for attempt := 0; attempt <= c.RetryCount; attempt++ {
 		if attempt > 0 {
 			waitTime := time.Duration(attempt) * time.Second
-			time.Sleep(waitTime)
+			if err := sleepContext(req.Context(), waitTime); err != nil {
+				return nil, err
+			}
 		}
 
-		resp, lastErr = c.Client.Do(req)
+		resp, lastErr = c.do(req)
 		if lastErr != nil {
-			if attempt < c.RetryCount {
+			// A canceled request fails again at once; retrying only delays the caller
+			if attempt < c.RetryCount && req.Context().Err() == nil {
 				continue
 			}
 			return nil, lastErr
@@ -69,6 +84,36 @@ func (c *PrometheusClient) doRequestWithRetry(req *http.Request) (*http.Response
 	return resp, lastErr
 }
 
+// do sends a single request, holding an auto-tuned request slot when enabled
+func (c *PrometheusClient) do(req *http.Request) (*http.Response, error) {
+	if c.limiter == nil {
+		return c.Client.Do(req)
+	}
+
+	c.limiter.Acquire()
+	start := time.Now()
+	resp, err := c.Client.Do(req)
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	c.limiter.Release(time.Since(start), statusCode, err)
+	return resp, err
+}
+
+// EnableAutoTune limits in-flight requests with an AIMD limit driven by latency and 429s
+func (c *PrometheusClient) EnableAutoTune(config AutoTuneConfig) {
+	c.limiter = newAIMDLimiter(config)
+}
+
+// AutoTuneStats reports the auto-tuned limit, or false when auto-tuning is disabled
+func (c *PrometheusClient) AutoTuneStats() (AutoTuneStats, bool) {
+	if c.limiter == nil {
+		return AutoTuneStats{}, false
+	}
+	return c.limiter.stats(), true
+}
+
 // NewPrometheusClientFromEnv creates a Prometheus client from environment variables
 // Returns error if required environment variables are not set
 // Note: 'login' is optional (for local/unauthenticated Prometheus instances)
@@ -98,8 +143,12 @@ type PrometheusResponse struct {
 	} `json:"data"`
 }
 
-// addAuthIfNeeded adds Basic Auth to the request if login credentials are provided
+// addAuthIfNeeded adds Basic Auth to the request if login credentials are provided, and the
+// client's Headers
 func (c *PrometheusClient) addAuthIfNeeded(req *http.Request) {
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
 	if c.Login != "" {
 		parts := strings.Split(c.Login, ":")
 		if len(parts) == 2 {
@@ -109,7 +158,7 @@ func (c *PrometheusClient) addAuthIfNeeded(req *http.Request) {
 }
 
 // GetAllMetricNames fetches all metric names from Prometheus with optional filtering
-func (c *PrometheusClient) GetAllMetricNames(queryFilters string) ([]string, error) {
+func (c *PrometheusClient) GetAllMetricNames(ctx context.Context, queryFilters string) ([]string, error) {
 	endpoint := fmt.Sprintf("%s/api/v1/label/__name__/values", c.BaseURL)
 
 	if queryFilters != "" {
@@ -119,7 +168,7 @@ func (c *PrometheusClient) GetAllMetricNames(queryFilters string) ([]string, err
 		endpoint = fmt.Sprintf("%s?%s", endpoint, params.Encode())
 	}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +191,26 @@ func (c *PrometheusClient) GetAllMetricNames(queryFilters string) ([]string, err
 }
 
 // GetJobsForMetric fetches all job names for a specific metric
-func (c *PrometheusClient) GetJobsForMetric(metricName, queryFilters string, now int64) ([]string, error) {
+func (c *PrometheusClient) GetJobsForMetric(ctx context.Context, metricName, queryFilters string, now int64) ([]string, error) {
+	counts, err := c.GetJobSeriesCounts(ctx, metricName, queryFilters, now)
+	if err != nil {
+		return nil, err
+	}
+	jobNames := make([]string, 0, len(counts))
+	for _, count := range counts {
+		jobNames = append(jobNames, count.Job)
+	}
+	return jobNames, nil
+}
+
+// JobSeriesCount is the number of series of a metric in a job
+type JobSeriesCount struct {
+	Job   string
+	Count int64
+}
+
+// GetJobSeriesCounts fetches the series count of a metric in every job with one count by (job) query
+func (c *PrometheusClient) GetJobSeriesCounts(ctx context.Context, metricName, queryFilters string, now int64) ([]JobSeriesCount, error) {
 	var query string
 	if queryFilters != "" {
 		query = fmt.Sprintf(`count by (job) ({__name__="%s",%s})`, metricName, queryFilters)
@@ -155,7 +223,7 @@ func (c *PrometheusClient) GetJobsForMetric(metricName, queryFilters string, now
 	params.Set("time", fmt.Sprintf("%d", now))
 
 	endpoint := fmt.Sprintf("%s/api/v1/query?%s", c.BaseURL, params.Encode())
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("request creation failed: %w", err)
 	}
@@ -182,8 +250,12 @@ func (c *PrometheusClient) GetJobsForMetric(metricName, queryFilters string, now
 		if json.Unmarshal(body, &errorResp) == nil && errorResp.Error != "" {
 			errorMsg = errorResp.Error
 		}
+		if isSeriesLimitMessage(errorMsg) {
+			return nil, fmt.Errorf("HTTP %d - query: count by (job): %w",
+				resp.StatusCode, &SeriesLimitError{Query: query, Message: errorMsg})
+		}
 		if resp.StatusCode == 429 {
-			time.Sleep(2 * time.Second)
+			sleepContext(ctx, 2*time.Second)
 		}
 		return nil, fmt.Errorf("HTTP %d (%s) - query: count by (job) - error: %s",
 			resp.StatusCode, resp.Status, errorMsg)
@@ -193,6 +265,7 @@ func (c *PrometheusClient) GetJobsForMetric(metricName, queryFilters string, now
 		Data struct {
 			Result []struct {
 				Metric map[string]string `json:"metric"`
+				Value  []interface{}     `json:"value"`
 			} `json:"result"`
 		} `json:"data"`
 	}
@@ -201,18 +274,40 @@ func (c *PrometheusClient) GetJobsForMetric(metricName, queryFilters string, now
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	var jobNames []string
+	var counts []JobSeriesCount
 	for _, series := range result.Data.Result {
-		if jobName, ok := series.Metric["job"]; ok {
-			jobNames = append(jobNames, jobName)
+		jobName, ok := series.Metric["job"]
+		if !ok {
+			continue
 		}
+		count := JobSeriesCount{Job: jobName, Count: -1} // -1 when the value is unreadable, never matching a previous count
+		if len(series.Value) == 2 {
+			if value, ok := series.Value[1].(string); ok {
+				if n, err := strconv.ParseFloat(value, 64); err == nil {
+					count.Count = int64(n)
+				}
+			}
+		}
+		counts = append(counts, count)
 	}
 
-	return jobNames, nil
+	return counts, nil
+}
+
+// metricSelector builds the series selector for a metric, optionally restricted to a job
+func metricSelector(metricName, job, queryFilters string) string {
+	parts := []string{fmt.Sprintf(`__name__="%s"`, metricName)}
+	if queryFilters != "" {
+		parts = append(parts, queryFilters)
+	}
+	if job != "" {
+		parts = append(parts, fmt.Sprintf(`job="%s"`, job))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
 }
 
 // GetCardinality fetches the cardinality for a specific metric and job
-func (c *PrometheusClient) GetCardinality(metricName, job, queryFilters string, now int64) (string, error) {
+func (c *PrometheusClient) GetCardinality(ctx context.Context, metricName, job, queryFilters string, now int64) (string, error) {
 	var query string
 	if queryFilters != "" {
 		query = fmt.Sprintf(`count({__name__="%s",%s,job="%s"})`, metricName, queryFilters, job)
@@ -225,7 +320,7 @@ func (c *PrometheusClient) GetCardinality(metricName, job, queryFilters string,
 	params.Set("time", fmt.Sprintf("%d", now))
 
 	endpoint := fmt.Sprintf("%s/api/v1/query?%s", c.BaseURL, params.Encode())
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return "0", err
 	}
@@ -250,8 +345,12 @@ func (c *PrometheusClient) GetCardinality(metricName, job, queryFilters string,
 		if json.Unmarshal(body, &errorResp) == nil && errorResp.Error != "" {
 			errorMsg = errorResp.Error
 		}
+		if isSeriesLimitMessage(errorMsg) {
+			return "0", fmt.Errorf("HTTP %d - cardinality query - job: %s: %w",
+				resp.StatusCode, job, &SeriesLimitError{Query: query, Message: errorMsg})
+		}
 		if resp.StatusCode == 429 {
-			time.Sleep(2 * time.Second)
+			sleepContext(ctx, 2*time.Second)
 		}
 		return "0", fmt.Errorf("HTTP %d - cardinality query - job: %s - error: %s",
 			resp.StatusCode, job, errorMsg)
@@ -271,16 +370,16 @@ func (c *PrometheusClient) GetCardinality(metricName, job, queryFilters string,
 }
 
 // GetLabels fetches all labels for a specific metric and job
-func (c *PrometheusClient) GetLabels(metricName, job, queryFilters string) ([]string, error) {
-	labels, err := c.getLabelsViaQuery(metricName, job, queryFilters)
+func (c *PrometheusClient) GetLabels(ctx context.Context, metricName, job, queryFilters string) ([]string, error) {
+	labels, err := c.getLabelsViaQuery(ctx, metricName, job, queryFilters)
 	if err == nil && len(labels) > 0 {
 		return labels, nil
 	}
 
-	return c.getLabelsViaAPI(metricName, job, queryFilters)
+	return c.getLabelsViaAPI(ctx, metricName, job, queryFilters)
 }
 
-func (c *PrometheusClient) getLabelsViaQuery(metricName, job, queryFilters string) ([]string, error) {
+func (c *PrometheusClient) getLabelsViaQuery(ctx context.Context, metricName, job, queryFilters string) ([]string, error) {
 	var query string
 	if queryFilters != "" {
 		query = fmt.Sprintf(`{__name__="%s",%s,job="%s"}`, metricName, queryFilters, job)
@@ -292,7 +391,7 @@ func (c *PrometheusClient) getLabelsViaQuery(metricName, job, queryFilters strin
 	params.Set("query", query)
 
 	endpoint := fmt.Sprintf("%s/api/v1/query?%s", c.BaseURL, params.Encode())
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -311,7 +410,7 @@ func (c *PrometheusClient) getLabelsViaQuery(metricName, job, queryFilters strin
 
 	if resp.StatusCode != 200 {
 		if resp.StatusCode == 429 {
-			time.Sleep(2 * time.Second)
+			sleepContext(ctx, 2*time.Second)
 		}
 		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
@@ -344,7 +443,7 @@ func (c *PrometheusClient) getLabelsViaQuery(metricName, job, queryFilters strin
 	return labels, nil
 }
 
-func (c *PrometheusClient) getLabelsViaAPI(metricName, job, queryFilters string) ([]string, error) {
+func (c *PrometheusClient) getLabelsViaAPI(ctx context.Context, metricName, job, queryFilters string) ([]string, error) {
 	params := url.Values{}
 	var matchQuery string
 	if queryFilters != "" {
@@ -355,7 +454,7 @@ func (c *PrometheusClient) getLabelsViaAPI(metricName, job, queryFilters string)
 	params.Set("match[]", matchQuery)
 
 	endpoint := fmt.Sprintf("%s/api/v1/labels?%s", c.BaseURL, params.Encode())
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -381,7 +480,7 @@ func (c *PrometheusClient) getLabelsViaAPI(metricName, job, queryFilters string)
 			errorMsg = errorResp.Error
 		}
 		if resp.StatusCode == 429 {
-			time.Sleep(2 * time.Second)
+			sleepContext(ctx, 2*time.Second)
 		}
 		return nil, fmt.Errorf("HTTP %d - labels API - job: %s - error: %s",
 			resp.StatusCode, job, errorMsg)
@@ -407,7 +506,14 @@ func (c *PrometheusClient) getLabelsViaAPI(metricName, job, queryFilters string)
 // GetLabelCardinality fetches per-label cardinality using Mimir's cardinality API
 // This uses the /api/v1/cardinality/label_values endpoint which is more accurate than estimates
 // Reference: https://grafana.com/docs/mimir/latest/query/query-metric-labels/
-func (c *PrometheusClient) GetLabelCardinality(metricName, job string, labels []string, queryFilters string) (map[string]int64, error) {
+func (c *PrometheusClient) GetLabelCardinality(ctx context.Context, metricName, job string, labels []string, queryFilters string) (map[string]int64, error) {
+	cardinality, _, err := c.GetLabelCardinalityWithValues(ctx, metricName, job, labels, queryFilters, 0)
+	return cardinality, err
+}
+
+// GetLabelCardinalityWithValues is GetLabelCardinality that also returns up to samples
+// values of each label, the ones with the most series first; 0 samples returns no values
+func (c *PrometheusClient) GetLabelCardinalityWithValues(ctx context.Context, metricName, job string, labels []string, queryFilters string, samples int) (map[string]int64, map[string][]string, error) {
 	// Build the selector for this metric and job
 	var selector string
 	if queryFilters != "" {
@@ -425,23 +531,26 @@ func (c *PrometheusClient) GetLabelCardinality(metricName, job string, labels []
 		params.Add("label_names[]", label)
 	}
 	params.Set("selector", selector)
+	if samples > 0 {
+		params.Set("limit", strconv.Itoa(samples))
+	}
 	
-	req, err := http.NewRequest("POST", endpoint, strings.NewReader(params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(params.Encode()))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	c.addAuthIfNeeded(req)
 
 	resp, err := c.doRequestWithRetry(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != 200 {
@@ -453,9 +562,9 @@ func (c *PrometheusClient) GetLabelCardinality(metricName, job string, labels []
 			errorMsg = errorResp.Error
 		}
 		if resp.StatusCode == 429 {
-			time.Sleep(2 * time.Second)
+			sleepContext(ctx, 2*time.Second)
 		}
-		return nil, fmt.Errorf("HTTP %d - label cardinality API - job: %s - error: %s",
+		return nil, nil, fmt.Errorf("HTTP %d - label cardinality API - job: %s - error: %s",
 			resp.StatusCode, job, errorMsg)
 	}
 
@@ -465,18 +574,109 @@ func (c *PrometheusClient) GetLabelCardinality(metricName, job string, labels []
 			LabelName        string `json:"label_name"`
 			SeriesCount      int64  `json:"series_count"`
 			LabelValuesCount int64  `json:"label_values_count"`
+			Cardinality      []struct {
+				LabelValue string `json:"label_value"`
+			} `json:"cardinality"`
 		} `json:"labels"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Build the cardinality map using label_values_count (unique values per label)
 	cardinalityMap := make(map[string]int64)
+	var valuesMap map[string][]string
 	for _, item := range result.Labels {
 		cardinalityMap[item.LabelName] = item.LabelValuesCount
+		if samples > 0 && len(item.Cardinality) > 0 {
+			if valuesMap == nil {
+				valuesMap = make(map[string][]string)
+			}
+			for _, value := range item.Cardinality {
+				if len(valuesMap[item.LabelName]) < samples {
+					valuesMap[item.LabelName] = append(valuesMap[item.LabelName], value.LabelValue)
+				}
+			}
+		}
+	}
+
+	return cardinalityMap, valuesMap, nil
+}
+
+// GetMetricMetadata fetches metric TYPE metadata from the /api/v1/metadata endpoint
+// Returns a map of metric family name -> type (counter, gauge, histogram, summary, ...)
+func (c *PrometheusClient) GetMetricMetadata(ctx context.Context) (map[string]string, error) {
+	params := url.Values{}
+	params.Set("limit_per_metric", "1")
+
+	endpoint := fmt.Sprintf("%s/api/v1/metadata?%s", c.BaseURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	c.addAuthIfNeeded(req)
+
+	resp, err := c.doRequestWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		var errorResp struct {
+			Error string `json:"error"`
+		}
+		errorMsg := string(body)
+		if json.Unmarshal(body, &errorResp) == nil && errorResp.Error != "" {
+			errorMsg = errorResp.Error
+		}
+		return nil, fmt.Errorf("HTTP %d - metadata API - error: %s", resp.StatusCode, errorMsg)
+	}
+
+	var result struct {
+		Data map[string][]struct {
+			Type string `json:"type"`
+			Help string `json:"help"`
+			Unit string `json:"unit"`
+		} `json:"data"`
 	}
 
-	return cardinalityMap, nil
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	types := make(map[string]string, len(result.Data))
+	for name, entries := range result.Data {
+		if len(entries) > 0 && entries[0].Type != "" {
+			types[name] = entries[0].Type
+		}
+	}
+
+	return types, nil
+}
+
+// metricTypeSuffixes are series-name suffixes that Prometheus appends to a metric family name
+var metricTypeSuffixes = []string{"_bucket", "_sum", "_count", "_total", "_created", "_gsum", "_gcount"}
+
+// resolveMetricType looks up the TYPE of a series name in metadata keyed by family name
+// Histogram/summary series (_bucket, _sum, _count) and counters (_total) are mapped back
+// to their family before lookup. Returns "" when the type is unknown.
+func resolveMetricType(metadata map[string]string, metricName string) string {
+	if t, ok := metadata[metricName]; ok {
+		return t
+	}
+	for _, suffix := range metricTypeSuffixes {
+		if strings.HasSuffix(metricName, suffix) {
+			if t, ok := metadata[strings.TrimSuffix(metricName, suffix)]; ok {
+				return t
+			}
+		}
+	}
+	return ""
 }
@@ -1,6 +1,7 @@
 package collectors
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -56,7 +57,7 @@ func TestPrometheusClient_GetAllMetricNames(t *testing.T) {
 			defer server.Close()
 
 			client := NewPrometheusClient(server.URL, "user:pass")
-			metrics, err := client.GetAllMetricNames(tt.queryFilters)
+			metrics, err := client.GetAllMetricNames(context.Background(), tt.queryFilters)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetAllMetricNames() error = %v, wantErr %v", err, tt.wantErr)
@@ -118,7 +119,7 @@ func TestPrometheusClient_GetJobsForMetric(t *testing.T) {
 			defer server.Close()
 
 			client := NewPrometheusClient(server.URL, "user:pass")
-			jobs, err := client.GetJobsForMetric(tt.metricName, tt.queryFilters, 1234567890)
+			jobs, err := client.GetJobsForMetric(context.Background(), tt.metricName, tt.queryFilters, 1234567890)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetJobsForMetric() error = %v, wantErr %v", err, tt.wantErr)
@@ -182,7 +183,7 @@ func TestPrometheusClient_GetCardinality(t *testing.T) {
 			defer server.Close()
 
 			client := NewPrometheusClient(server.URL, "user:pass")
-			card, err := client.GetCardinality(tt.metricName, tt.job, tt.queryFilters, 1234567890)
+			card, err := client.GetCardinality(context.Background(), tt.metricName, tt.job, tt.queryFilters, 1234567890)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetCardinality() error = %v, wantErr %v", err, tt.wantErr)
@@ -262,7 +263,7 @@ func TestPrometheusClient_GetLabels(t *testing.T) {
 			defer server.Close()
 
 			client := NewPrometheusClient(server.URL, "user:pass")
-			labels, err := client.GetLabels(tt.metricName, tt.job, tt.queryFilters)
+			labels, err := client.GetLabels(context.Background(), tt.metricName, tt.job, tt.queryFilters)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetLabels() error = %v, wantErr %v", err, tt.wantErr)
@@ -288,7 +289,7 @@ func TestPrometheusClient_ErrorHandling(t *testing.T) {
 		defer server.Close()
 
 		client := NewPrometheusClient(server.URL, "user:pass")
-		_, err := client.GetCardinality("test_metric", "test_job", "", 1234567890)
+		_, err := client.GetCardinality(context.Background(), "test_metric", "test_job", "", 1234567890)
 
 		if err == nil {
 			t.Error("expected error for 429 response")
@@ -305,7 +306,7 @@ func TestPrometheusClient_ErrorHandling(t *testing.T) {
 		defer server.Close()
 
 		client := NewPrometheusClient(server.URL, "user:pass")
-		_, err := client.GetJobsForMetric("test_metric", "", 1234567890)
+		_, err := client.GetJobsForMetric(context.Background(), "test_metric", "", 1234567890)
 
 		if err == nil {
 			t.Error("expected error for 500 response")
@@ -334,7 +335,7 @@ func TestPrometheusClient_RetryLogic(t *testing.T) {
 		client := NewPrometheusClient(server.URL, "user:pass")
 		client.SetRetryCount(2)
 		
-		metrics, err := client.GetAllMetricNames("")
+		metrics, err := client.GetAllMetricNames(context.Background(), "")
 		
 		if err != nil {
 			t.Errorf("expected success after retries, got error: %v", err)
@@ -361,7 +362,7 @@ func TestPrometheusClient_RetryLogic(t *testing.T) {
 		client := NewPrometheusClient(server.URL, "user:pass")
 		client.SetRetryCount(2)
 		
-		_, err := client.GetAllMetricNames("")
+		_, err := client.GetAllMetricNames(context.Background(), "")
 		
 		if err == nil {
 			t.Error("expected error after max retries")
@@ -384,7 +385,7 @@ func TestPrometheusClient_RetryLogic(t *testing.T) {
 		client := NewPrometheusClient(server.URL, "user:pass")
 		client.SetRetryCount(2)
 		
-		metrics, err := client.GetAllMetricNames("")
+		metrics, err := client.GetAllMetricNames(context.Background(), "")
 		
 		if err != nil {
 			t.Errorf("expected success, got error: %v", err)
@@ -397,3 +398,80 @@ func TestPrometheusClient_RetryLogic(t *testing.T) {
 		}
 	})
 }
+
+func TestPrometheusClient_GetMetricMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/metadata" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"http_requests_total":           []map[string]string{{"type": "counter", "help": "Total requests"}},
+				"http_request_duration_seconds": []map[string]string{{"type": "histogram", "help": "Latency"}},
+				"process_open_fds":              []map[string]string{{"type": "gauge", "help": "Open fds"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	metadata, err := client.GetMetricMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetMetricMetadata() error = %v", err)
+	}
+
+	tests := []struct {
+		metricName string
+		wantType   string
+	}{
+		{"http_requests_total", "counter"},
+		{"http_request_duration_seconds_bucket", "histogram"},
+		{"http_request_duration_seconds_count", "histogram"},
+		{"process_open_fds", "gauge"},
+		{"unknown_metric", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.metricName, func(t *testing.T) {
+			if got := resolveMetricType(metadata, tt.metricName); got != tt.wantType {
+				t.Errorf("resolveMetricType(%s) = %q, want %q", tt.metricName, got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestPrometheusClient_GetLabelCardinalityWithValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if got := r.Form.Get("limit"); got != "2" {
+			t.Errorf("expected limit=2, got %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"labels": []map[string]interface{}{
+				{
+					"label_name":         "status",
+					"label_values_count": 3,
+					"cardinality": []map[string]interface{}{
+						{"label_value": "ok", "series_count": 10},
+						{"label_value": "OK ", "series_count": 4},
+						{"label_value": "Ok", "series_count": 1},
+					},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	cardinality, values, err := client.GetLabelCardinalityWithValues(context.Background(), "requests_total", "api", []string{"status"}, "", 2)
+	if err != nil {
+		t.Fatalf("GetLabelCardinalityWithValues() error = %v", err)
+	}
+	if cardinality["status"] != 3 {
+		t.Errorf("expected status cardinality 3, got %v", cardinality)
+	}
+	if got := values["status"]; len(got) != 2 || got[0] != "ok" || got[1] != "OK " {
+		t.Errorf("expected the 2 values with the most series, got %q", got)
+	}
+}
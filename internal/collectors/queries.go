@@ -0,0 +1,161 @@
+package collectors
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// QueryTemplate is a distinct query shape sent to Prometheus during a run
+type QueryTemplate struct {
+	Endpoint string // Method and API path, e.g. GET /api/v1/query
+	Template string // Parameters with label values replaced by placeholders, e.g. count({__name__="<metric>",job="<job>"})
+	Example  string // The first concrete parameters sent with this template
+	Requests int    // Requests sent with this template, retries not included
+}
+
+// queryTimeParams change on every request and carry no workload information
+var queryTimeParams = map[string]bool{"time": true, "start": true, "end": true}
+
+// labelMatcherPattern matches a PromQL label matcher with a quoted value
+var labelMatcherPattern = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*"(?:[^"\\]|\\.)*"`)
+
+// queryRecorder counts the requests of a run by query template
+type queryRecorder struct {
+	mu        sync.Mutex
+	templates map[string]*QueryTemplate
+}
+
+// record adds req to its template
+func (r *queryRecorder) record(req *http.Request) {
+	endpoint := req.Method + " " + req.URL.Path
+	example, template := describeQuery(requestParams(req))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.templates == nil {
+		r.templates = make(map[string]*QueryTemplate)
+	}
+	key := endpoint + "\x00" + template
+	entry, ok := r.templates[key]
+	if !ok {
+		entry = &QueryTemplate{Endpoint: endpoint, Template: template, Example: example}
+		r.templates[key] = entry
+	}
+	entry.Requests++
+}
+
+// snapshot returns the recorded templates ordered by endpoint and template
+func (r *queryRecorder) snapshot() []QueryTemplate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	templates := make([]QueryTemplate, 0, len(r.templates))
+	for _, entry := range r.templates {
+		templates = append(templates, *entry)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Endpoint != templates[j].Endpoint {
+			return templates[i].Endpoint < templates[j].Endpoint
+		}
+		return templates[i].Template < templates[j].Template
+	})
+	return templates
+}
+
+// requestParams returns the URL parameters of req, and its form body when it posts one
+func requestParams(req *http.Request) url.Values {
+	params := req.URL.Query()
+	if req.Method != http.MethodPost || req.GetBody == nil {
+		return params
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return params
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return params
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		return params
+	}
+	for key, values := range form {
+		params[key] = append(params[key], values...)
+	}
+	return params
+}
+
+// describeQuery renders params as "key=value" pairs, concretely and as a template
+// The metric name becomes <metric>, other label values <label name>, and the label
+// names the cardinality API is asked about <labels>.
+func describeQuery(params url.Values) (example, template string) {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if !queryTimeParams[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var examples, templates []string
+	for _, key := range keys {
+		value := strings.Join(params[key], ",")
+		examples = append(examples, key+"="+value)
+		if key == "label_names[]" {
+			templates = append(templates, key+"=<labels>")
+		} else {
+			templates = append(templates, key+"="+QueryTemplateOf(value))
+		}
+	}
+	return strings.Join(examples, " "), strings.Join(templates, " ")
+}
+
+// QueryTemplateOf replaces the label values of a PromQL query with placeholders,
+// so queries differing only in metric, job or filter values share a template
+func QueryTemplateOf(query string) string {
+	return labelMatcherPattern.ReplaceAllStringFunc(query, func(matcher string) string {
+		parts := labelMatcherPattern.FindStringSubmatch(matcher)
+		placeholder := parts[1]
+		if placeholder == "__name__" {
+			placeholder = "metric"
+		}
+		return fmt.Sprintf(`%s%s"<%s>"`, parts[1], parts[2], placeholder)
+	})
+}
+
+// WriteQuerySnapshot writes the query templates of a run to a file, for Prometheus
+// administrators to review or allow-list the workload analyze generates
+func WriteQuerySnapshot(filename string, templates []QueryTemplate) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create query snapshot file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	total := 0
+	for _, template := range templates {
+		total += template.Requests
+	}
+	if _, err := fmt.Fprintf(writer, "# %d requests in %d query templates\n", total, len(templates)); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, template := range templates {
+		block := fmt.Sprintf("\n# %s, %d requests\ntemplate: %s\nexample:  %s\n", template.Endpoint, template.Requests, template.Template, template.Example)
+		if _, err := writer.WriteString(block); err != nil {
+			return fmt.Errorf("failed to write query template: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package collectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueryTemplateOf(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`count by (job) ({__name__="http_requests_total"})`, `count by (job) ({__name__="<metric>"})`},
+		{`count({__name__="up",cluster=~"prod.*",job="api"})`, `count({__name__="<metric>",cluster=~"<cluster>",job="<job>"})`},
+		{`{__name__="a", path!="/say \"hi\""}`, `{__name__="<metric>", path!="<path>"}`},
+		{`avg_over_time(up[1h])`, `avg_over_time(up[1h])`},
+	}
+	for _, tt := range tests {
+		if got := QueryTemplateOf(tt.query); got != tt.want {
+			t.Errorf("QueryTemplateOf(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPrometheusClient_Queries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.SetRetryCount(0)
+	client.GetCardinality(context.Background(), "http_requests_total", "api", "", 1700000000)
+	client.GetCardinality(context.Background(), "http_requests_total", "web", "", 1700000000)
+	client.GetCardinality(context.Background(), "up", "api", "", 1700000000)
+	client.GetLabelCardinality(context.Background(), "up", "api", []string{"instance", "pod"}, "")
+
+	queries := client.Queries()
+	if len(queries) != 2 {
+		t.Fatalf("expected 2 templates, got %+v", queries)
+	}
+	cardinality := queries[0]
+	if cardinality.Endpoint != "GET /api/v1/query" || cardinality.Requests != 3 ||
+		cardinality.Template != `query=count({__name__="<metric>",job="<job>"})` ||
+		cardinality.Example != `query=count({__name__="http_requests_total",job="api"})` {
+		t.Errorf("unexpected query template %+v", cardinality)
+	}
+	labelValues := queries[1]
+	if labelValues.Endpoint != "POST /api/v1/cardinality/label_values" ||
+		labelValues.Template != `label_names[]=<labels> selector={__name__="<metric>",job="<job>"}` ||
+		labelValues.Example != `label_names[]=instance,pod selector={__name__="up",job="api"}` {
+		t.Errorf("unexpected form template %+v", labelValues)
+	}
+
+	filename := filepath.Join(t.TempDir(), "queries.txt")
+	if err := WriteQuerySnapshot(filename, queries); err != nil {
+		t.Fatalf("WriteQuerySnapshot() error = %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	for _, want := range []string{
+		"# 4 requests in 2 query templates\n",
+		"# GET /api/v1/query, 3 requests\ntemplate: query=count(",
+		"example:  label_names[]=instance,pod",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected snapshot to contain %q, got:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule paces metric collection, keeping its query load away from peak usage of a shared Prometheus
+type Schedule struct {
+	Spread    time.Duration // Metric collections start evenly over this window; 0 starts them as concurrency allows
+	Blackouts []Window      // No metric collection starts inside these windows
+
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+// Window is a daily time range in local time, on some days of the week
+// An End before Start crosses midnight; Days then refers to the day the window starts.
+type Window struct {
+	Days  [7]bool       // Indexed by time.Weekday
+	Start time.Duration // Since midnight
+	End   time.Duration
+	spec  string
+}
+
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// ParseWindow parses "[DAYS ]HH:MM-HH:MM", e.g. "09:00-17:00", "Mon-Fri 08:00-18:00" or
+// "Sat 22:00-02:00"; DAYS is a day or a range of days, every day when omitted
+func ParseWindow(spec string) (Window, error) {
+	window := Window{spec: spec}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 1:
+		for day := range window.Days {
+			window.Days[day] = true
+		}
+	case 2:
+		if err := window.parseDays(fields[0]); err != nil {
+			return Window{}, fmt.Errorf("invalid window %q: %w", spec, err)
+		}
+		fields = fields[1:]
+	default:
+		return Window{}, fmt.Errorf("invalid window %q: use [DAYS ]HH:MM-HH:MM", spec)
+	}
+
+	start, end, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %q: use [DAYS ]HH:MM-HH:MM", spec)
+	}
+	var err error
+	if window.Start, err = parseTimeOfDay(start); err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", spec, err)
+	}
+	if window.End, err = parseTimeOfDay(end); err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", spec, err)
+	}
+	if window.Start == window.End {
+		return Window{}, fmt.Errorf("invalid window %q: start and end are equal", spec)
+	}
+	return window, nil
+}
+
+// ParseWindows parses every spec with ParseWindow
+func ParseWindows(specs []string) ([]Window, error) {
+	var windows []Window
+	for _, spec := range specs {
+		window, err := ParseWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// parseDays parses "Mon" or "Mon-Fri"; ranges may wrap around the week, e.g. "Fri-Mon"
+func (w *Window) parseDays(spec string) error {
+	first, last, isRange := strings.Cut(strings.ToLower(spec), "-")
+	if !isRange {
+		last = first
+	}
+	from, to := dayIndex(first), dayIndex(last)
+	if from < 0 || to < 0 {
+		return fmt.Errorf("unknown day in %q (use mon, tue, wed, thu, fri, sat, sun)", spec)
+	}
+	for day := from; ; day = (day + 1) % 7 {
+		w.Days[day] = true
+		if day == to {
+			return nil
+		}
+	}
+}
+
+// dayIndex returns the time.Weekday of a day name or its abbreviation of three or more letters, or -1
+func dayIndex(name string) int {
+	for i, day := range weekdays {
+		if len(name) >= 3 && strings.HasPrefix(day, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseTimeOfDay parses HH:MM; 24:00 ends a window at midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// String returns the window as it was given
+func (w Window) String() string {
+	return w.spec
+}
+
+// activeUntil returns the end of the window when t is inside it
+func (w Window) activeUntil(t time.Time) (time.Time, bool) {
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	// A window crossing midnight may have started the day before
+	for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+		if !w.Days[day.Weekday()] {
+			continue
+		}
+		start, end := day.Add(w.Start), day.Add(w.End)
+		if w.End < w.Start {
+			end = end.Add(24 * time.Hour)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// maxBlackout bounds a blackout, so windows covering the whole week cannot pause collection forever
+const maxBlackout = 7 * 24 * time.Hour
+
+// Validate rejects blackout windows that leave no time to collect in
+func (s *Schedule) Validate() error {
+	if s.Spread < 0 {
+		return fmt.Errorf("spread must not be negative")
+	}
+	week := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local) // A Monday
+	for t := week; t.Before(week.Add(maxBlackout)); t = t.Add(time.Minute) {
+		if _, _, ok := s.blackoutUntil(t); !ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("blackout windows cover the whole week")
+}
+
+// blackoutUntil returns when the blackout windows containing t end, following windows that
+// continue where another ends
+func (s *Schedule) blackoutUntil(t time.Time) (time.Time, *Window, bool) {
+	var found *Window
+	end := t
+	for extended := true; extended && end.Sub(t) < maxBlackout; {
+		extended = false
+		for i := range s.Blackouts {
+			if until, ok := s.Blackouts[i].activeUntil(end); ok && until.After(end) {
+				end, extended = until, true
+				if found == nil {
+					found = &s.Blackouts[i]
+				}
+			}
+		}
+	}
+	return end, found, found != nil
+}
+
+// pacer applies a Schedule to one collection of total metrics
+type pacer struct {
+	schedule *Schedule
+	start    time.Time
+	total    int
+	paused   time.Duration // Spent in blackouts, which delays the remaining spread
+}
+
+func (s *Schedule) pacer(total int) *pacer {
+	if s.now == nil {
+		s.now = time.Now
+	}
+	if s.sleep == nil {
+		s.sleep = sleepContext
+	}
+	return &pacer{schedule: s, start: s.now(), total: total}
+}
+
+// wait blocks until collection of the i-th metric may start, or ctx is done
+func (p *pacer) wait(ctx context.Context, i int) error {
+	s := p.schedule
+	if s.Spread > 0 && p.total > 0 {
+		due := p.start.Add(p.paused + s.Spread*time.Duration(i)/time.Duration(p.total))
+		if delay := due.Sub(s.now()); delay > 0 {
+			if err := s.sleep(ctx, delay); err != nil {
+				return err
+			}
+		}
+	}
+	now := s.now()
+	if end, window, ok := s.blackoutUntil(now); ok {
+		fmt.Printf("\nPausing collection during blackout window %s until %s (%d of %d metrics started)\n",
+			window, end.Format("15:04"), i, p.total)
+		if err := s.sleep(ctx, end.Sub(now)); err != nil {
+			return err
+		}
+		p.paused += end.Sub(now)
+	}
+	return nil
+}
+
+// sleepContext sleeps for d, returning early with the context's error when ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Describe summarizes how collecting total metrics starting at now is paced, for the analyze log
+// With a spread the expected finish is included, time in the blackouts it overlaps added.
+func (s *Schedule) Describe(total int, now time.Time) string {
+	var parts []string
+	if s.Spread > 0 && total > 0 {
+		parts = append(parts, fmt.Sprintf("spreading %d metrics over %s (one every %s)", total, s.Spread, (s.Spread/time.Duration(total)).Round(time.Millisecond)))
+	}
+	if len(s.Blackouts) > 0 {
+		specs := make([]string, len(s.Blackouts))
+		for i, window := range s.Blackouts {
+			specs[i] = window.String()
+		}
+		parts = append(parts, "pausing during "+strings.Join(specs, ", "))
+	}
+	if end, window, ok := s.blackoutUntil(now); ok {
+		parts = append(parts, fmt.Sprintf("waiting for blackout window %s to end at %s", window, end.Format("Mon 15:04")))
+	}
+	if s.Spread > 0 {
+		parts = append(parts, "finishing around "+s.estimateFinish(now).Format("Mon 15:04"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// estimateFinish returns when a spread starting at from ends, time in blackouts added
+func (s *Schedule) estimateFinish(from time.Time) time.Time {
+	t, remaining := from, s.Spread
+	for remaining > 0 {
+		if end, _, ok := s.blackoutUntil(t); ok {
+			t = end
+			continue
+		}
+		step := min(remaining, time.Minute)
+		t, remaining = t.Add(step), remaining-step
+	}
+	return t
+}
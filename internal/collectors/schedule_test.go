@@ -0,0 +1,119 @@
+package collectors
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	window, err := ParseWindow("Fri-Mon 22:00-02:30")
+	if err != nil {
+		t.Fatalf("ParseWindow() error = %v", err)
+	}
+	wantDays := [7]bool{true, true, false, false, false, true, true} // Sun, Mon, Fri, Sat
+	if window.Days != wantDays || window.Start != 22*time.Hour || window.End != 2*time.Hour+30*time.Minute {
+		t.Errorf("ParseWindow() = %+v", window)
+	}
+	if every, err := ParseWindow("09:00-24:00"); err != nil || every.Days != [7]bool{true, true, true, true, true, true, true} || every.End != 24*time.Hour {
+		t.Errorf("ParseWindow() = %+v, %v, want every day until midnight", every, err)
+	}
+
+	for _, invalid := range []string{"09:00", "Mon-Fri", "Xyz 09:00-10:00", "Mo 09:00-10:00", "9am-5pm", "10:00-10:00", "Mon Tue 09:00-10:00"} {
+		if _, err := ParseWindow(invalid); err == nil {
+			t.Errorf("ParseWindow(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestSchedule_BlackoutUntil(t *testing.T) {
+	windows, err := ParseWindows([]string{"Fri 22:00-02:00", "02:00-03:00", "Mon-Fri 09:00-17:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule := Schedule{Blackouts: windows}
+	at := func(day, clock string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", day+" "+clock, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time // Zero when not in a blackout
+	}{
+		{"weekday peak", at("2025-11-05", "10:00"), at("2025-11-05", "17:00")},
+		{"weekday evening", at("2025-11-05", "18:00"), time.Time{}},
+		{"weekend", at("2025-11-08", "10:00"), time.Time{}},
+		{"after midnight, chained into the next window", at("2025-11-08", "01:00"), at("2025-11-08", "03:00")},
+		{"Thursday night", at("2025-11-06", "23:00"), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, _, ok := schedule.blackoutUntil(tt.t)
+			if ok != !tt.want.IsZero() || (ok && !end.Equal(tt.want)) {
+				t.Errorf("blackoutUntil(%s) = %s, %v, want %s", tt.t, end, ok, tt.want)
+			}
+		})
+	}
+
+	always := Schedule{Blackouts: []Window{mustWindow(t, "00:00-12:00"), mustWindow(t, "12:00-24:00")}}
+	if err := always.Validate(); err == nil || !strings.Contains(err.Error(), "whole week") {
+		t.Errorf("Validate() error = %v, want windows covering the whole week rejected", err)
+	}
+	if err := schedule.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestPacer(t *testing.T) {
+	clock := time.Date(2025, 11, 5, 8, 0, 0, 0, time.Local) // Wednesday
+	var slept []time.Duration
+	schedule := &Schedule{
+		Spread:    2 * time.Hour,
+		Blackouts: []Window{mustWindow(t, "Mon-Fri 09:00-10:00")},
+		now:       func() time.Time { return clock },
+		sleep: func(ctx context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			clock = clock.Add(d)
+			return nil
+		},
+	}
+	if got := schedule.Describe(4, clock); !strings.Contains(got, "one every 30m0s") || !strings.Contains(got, "finishing around Wed 11:00") {
+		t.Errorf("Describe() = %q", got)
+	}
+
+	pace := schedule.pacer(4)
+	for i := 0; i < 4; i++ {
+		if err := pace.wait(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Metrics start at 08:00 and 08:30; the one due at 09:00 waits out the blackout, delaying the rest by an hour
+	want := []time.Duration{30 * time.Minute, 30 * time.Minute, time.Hour, 30 * time.Minute}
+	if len(slept) != len(want) {
+		t.Fatalf("slept %v, want %v", slept, want)
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Errorf("slept %v, want %v", slept, want)
+			break
+		}
+	}
+	if clock.Hour() != 10 || clock.Minute() != 30 {
+		t.Errorf("last metric started at %s, want 10:30", clock.Format("15:04"))
+	}
+}
+
+func mustWindow(t *testing.T, spec string) Window {
+	t.Helper()
+	window, err := ParseWindow(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return window
+}
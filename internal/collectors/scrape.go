@@ -0,0 +1,403 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// Scraper collects per-job metric data directly from /metrics endpoints, without Prometheus
+type Scraper struct {
+	targets     []ScrapeTarget
+	concurrency int
+	valueSample int                     // Values sampled per label, 0 for none
+	buildInfo   []loaders.BuildInfoData // Version labels of build info series seen while scraping
+}
+
+// NewScraper creates a scraper for the given targets
+func NewScraper(targets []ScrapeTarget) *Scraper {
+	return &Scraper{
+		targets:     targets,
+		concurrency: getEnvInt("CONCURRENT_SCRAPES", 10),
+	}
+}
+
+// SetConcurrency sets the number of targets scraped in parallel
+func (s *Scraper) SetConcurrency(concurrency int) {
+	if concurrency > 0 {
+		s.concurrency = concurrency
+	}
+}
+
+// SetLabelValueSamples records up to n values of every label, in sorted order; 0 records none
+func (s *Scraper) SetLabelValueSamples(n int) {
+	s.valueSample = n
+}
+
+// ScrapeToWriter scrapes every target and writes one record per job and metric to writer
+// Series from all targets of a job are combined the way Prometheus would store them,
+// with job and instance target labels attached. Failed targets are returned as errors.
+// When ctx is done no further target is scraped and nothing is written.
+func (s *Scraper) ScrapeToWriter(ctx context.Context, writer *JobFileWriter) (int, []ErrorRecord, error) {
+	errors := NewErrorAggregator()
+	s.buildInfo = nil
+	summaries := make(map[string]map[string]*seriesSummary) // job -> metric -> summary
+	types := make(map[string]string)
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.concurrency)
+	progress := newProgressTracker("Scraping targets", len(s.targets), 10)
+
+	for i := range s.targets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(target *ScrapeTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer progress.Increment()
+
+			series, targetTypes, err := scrapeTarget(ctx, target)
+			if err != nil {
+				if ctx.Err() == nil {
+					errors.Add(target.URL, "scrape_target", err)
+				}
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for name, metricType := range targetTypes {
+				types[name] = metricType
+			}
+			for _, labels := range series {
+				// The job label is the target's job unless the target honors exposed labels
+				job, name := labels["job"], labels["__name__"]
+				if loaders.IsBuildInfoMetric(name) {
+					if info, ok := loaders.BuildInfoFromLabels(job, name, labels); ok {
+						s.buildInfo = append(s.buildInfo, info)
+					}
+				}
+				addSeries(summaries, job, labels)
+			}
+		}(&s.targets[i])
+	}
+	wg.Wait()
+	fmt.Println()
+	if ctx.Err() != nil {
+		return 0, errors.Records(), fmt.Errorf("scrape stopped: %w", ctx.Err())
+	}
+
+	if err := writeSummaries(writer, summaries, types, s.valueSample); err != nil {
+		return writer.Records(), errors.Records(), err
+	}
+	return writer.Records(), errors.Records(), nil
+}
+
+// BuildInfo returns the version labels of the build info series seen by the last scrape
+func (s *Scraper) BuildInfo() []loaders.BuildInfoData {
+	return sortBuildInfo(append([]loaders.BuildInfoData(nil), s.buildInfo...))
+}
+
+// ScrapeJob scrapes a single target and returns its metric records, as analyze would write
+// them for the target's job, without writing job files
+func ScrapeJob(ctx context.Context, target ScrapeTarget) ([]loaders.JobMetricData, error) {
+	if err := target.Validate(); err != nil {
+		return nil, err
+	}
+	series, types, err := scrapeTarget(ctx, &target)
+	if err != nil {
+		return nil, err
+	}
+	return jobMetrics(target.Job, series, types), nil
+}
+
+// ReadExposition reads a saved exposition, e.g. the output of curl localhost:8080/metrics,
+// and returns its metric records as if instance of job had been scraped
+func ReadExposition(r io.Reader, job, instance string) ([]loaders.JobMetricData, error) {
+	series, types, err := parseExposition(r)
+	if err != nil {
+		return nil, err
+	}
+	targetLabels := map[string]string{"job": job, "instance": instance}
+	for _, labels := range series {
+		attachTargetLabels(labels, targetLabels)
+	}
+	return jobMetrics(job, series, types), nil
+}
+
+// addSeries counts a series in the summary of its metric in job
+func addSeries(summaries map[string]map[string]*seriesSummary, job string, labels map[string]string) {
+	jobSummaries, ok := summaries[job]
+	if !ok {
+		jobSummaries = make(map[string]*seriesSummary)
+		summaries[job] = jobSummaries
+	}
+	summary, ok := jobSummaries[labels["__name__"]]
+	if !ok {
+		summary = newSeriesSummary()
+		jobSummaries[labels["__name__"]] = summary
+	}
+	summary.add(labels)
+}
+
+// writeSummaries writes one record per job and metric of summaries to writer, sorted by job
+// and metric, recording up to valueSample values per label
+func writeSummaries(writer *JobFileWriter, summaries map[string]map[string]*seriesSummary, types map[string]string, valueSample int) error {
+	jobs := make([]string, 0, len(summaries))
+	for job := range summaries {
+		jobs = append(jobs, job)
+	}
+	sort.Strings(jobs)
+
+	for _, job := range jobs {
+		metrics := make([]string, 0, len(summaries[job]))
+		for metric := range summaries[job] {
+			metrics = append(metrics, metric)
+		}
+		sort.Strings(metrics)
+
+		for _, metric := range metrics {
+			summary := summaries[job][metric]
+			sort.Strings(summary.labels)
+			data := JobMetricData{
+				Job:              job,
+				MetricName:       metric,
+				Labels:           summary.labels,
+				Cardinality:      strconv.FormatInt(summary.count, 10),
+				LabelCardinality: summary.labelCardinality(),
+				Type:             resolveMetricType(types, metric),
+			}
+			if valueSample > 0 {
+				data.LabelValues = summary.sampleLabelValues(valueSample)
+			}
+			if err := writer.Write(data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// jobMetrics combines the series of one job into a record per metric, sorted by metric name
+func jobMetrics(job string, series []map[string]string, types map[string]string) []loaders.JobMetricData {
+	summaries := make(map[string]*seriesSummary)
+	for _, labels := range series {
+		name := labels["__name__"]
+		summary, ok := summaries[name]
+		if !ok {
+			summary = newSeriesSummary()
+			summaries[name] = summary
+		}
+		summary.add(labels)
+	}
+
+	records := make([]loaders.JobMetricData, 0, len(summaries))
+	for name, summary := range summaries {
+		sort.Strings(summary.labels)
+		records = append(records, loaders.JobMetricData{
+			Job:              job,
+			MetricName:       name,
+			Labels:           summary.labels,
+			Cardinality:      summary.count,
+			LabelCardinality: summary.labelCardinality(),
+			Type:             resolveMetricType(types, name),
+		})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].MetricName < records[j].MetricName })
+	return records
+}
+
+// scrapeTarget fetches and parses a target's exposition, attaching target labels to each series
+func scrapeTarget(ctx context.Context, target *ScrapeTarget) ([]map[string]string, map[string]string, error) {
+	client, err := target.httpClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target.URL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4;q=1,*/*;q=0.1")
+	if err := target.authorize(req); err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("scrape failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, nil, fmt.Errorf("HTTP %d - scrape %s - error: %s", resp.StatusCode, target.URL, strings.TrimSpace(string(body)))
+	}
+
+	series, types, err := parseExposition(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", target.URL, err)
+	}
+
+	targetLabels := map[string]string{"job": target.Job, "instance": target.Instance}
+	for name, value := range target.Labels {
+		targetLabels[name] = value
+	}
+	for _, labels := range series {
+		if target.HonorLabels {
+			honorExposedLabels(labels, targetLabels)
+		} else {
+			attachTargetLabels(labels, targetLabels)
+		}
+	}
+	return series, types, nil
+}
+
+// attachTargetLabels adds target labels to a series, renaming clashing exposed labels to
+// exported_<name> as Prometheus does with honor_labels: false
+func attachTargetLabels(labels, targetLabels map[string]string) {
+	for name, value := range targetLabels {
+		if existing, ok := labels[name]; ok {
+			labels["exported_"+name] = existing
+		}
+		labels[name] = value
+	}
+}
+
+// honorExposedLabels adds target labels a series does not expose, keeping exposed ones as
+// Prometheus does with honor_labels: true
+func honorExposedLabels(labels, targetLabels map[string]string) {
+	for name, value := range targetLabels {
+		if labels[name] == "" {
+			labels[name] = value
+		}
+	}
+}
+
+// parseExposition parses the Prometheus text exposition format (and the OpenMetrics text
+// subset it shares) into label sets keyed by "__name__" plus a map of declared TYPEs
+func parseExposition(r io.Reader) ([]map[string]string, map[string]string, error) {
+	var series []map[string]string
+	types := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = strings.ToLower(fields[3])
+			}
+			continue
+		}
+
+		labels, err := parseSampleLine(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		series = append(series, labels)
+	}
+
+	return series, types, scanner.Err()
+}
+
+// parseSampleLine parses `name{label="value",...} value [timestamp]` into a label set
+func parseSampleLine(line string) (map[string]string, error) {
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd <= 0 {
+		return nil, fmt.Errorf("missing value for sample %q", line)
+	}
+
+	labels := map[string]string{"__name__": line[:nameEnd]}
+	rest := line[nameEnd:]
+	if strings.HasPrefix(rest, "{") {
+		end, err := parseLabelSet(rest, labels)
+		if err != nil {
+			return nil, err
+		}
+		rest = rest[end:]
+	}
+
+	if strings.TrimSpace(rest) == "" {
+		return nil, fmt.Errorf("missing value for sample %q", labels["__name__"])
+	}
+	return labels, nil
+}
+
+// parseLabelSet parses a `{...}` label block into labels and returns the index after '}'
+func parseLabelSet(s string, labels map[string]string) (int, error) {
+	i := 1 // skip '{'
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("unterminated label set")
+		}
+		if s[i] == '}' {
+			return i + 1, nil
+		}
+
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 {
+			return 0, fmt.Errorf("label without value in %q", s)
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) || s[i] != '"' {
+			return 0, fmt.Errorf("label %s value is not quoted", name)
+		}
+		i++
+
+		var value strings.Builder
+		for {
+			if i >= len(s) {
+				return 0, fmt.Errorf("unterminated value for label %s", name)
+			}
+			c := s[i]
+			if c == '"' {
+				i++
+				break
+			}
+			if c == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				i++
+				continue
+			}
+			value.WriteByte(c)
+			i++
+		}
+		labels[name] = value.String()
+	}
+}
@@ -0,0 +1,225 @@
+package collectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+const testExposition = `# HELP http_requests_total Total requests.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",path="/a"} 10
+http_requests_total{method="POST",path="/a\"quoted\""} 3 1700000000000
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 1
+request_duration_seconds_bucket{le="+Inf"} 2
+request_duration_seconds_sum 0.3
+request_duration_seconds_count 2
+up 1
+`
+
+func TestParseExposition(t *testing.T) {
+	series, types, err := parseExposition(strings.NewReader(testExposition))
+	if err != nil {
+		t.Fatalf("parseExposition() error = %v", err)
+	}
+
+	if len(series) != 7 {
+		t.Fatalf("expected 7 series, got %d", len(series))
+	}
+	if series[1]["path"] != `/a"quoted"` {
+		t.Errorf("expected escaped quote to be unescaped, got %q", series[1]["path"])
+	}
+	if series[6]["__name__"] != "up" || len(series[6]) != 1 {
+		t.Errorf("expected bare 'up' series, got %v", series[6])
+	}
+	if types["http_requests_total"] != "counter" || types["request_duration_seconds"] != "histogram" {
+		t.Errorf("unexpected types %v", types)
+	}
+}
+
+func TestParseExposition_Invalid(t *testing.T) {
+	tests := []string{
+		`metric_without_value`,
+		`metric{label="unterminated} 1`,
+		`metric{label=unquoted} 1`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, _, err := parseExposition(strings.NewReader(input)); err == nil {
+				t.Errorf("expected error for %q", input)
+			}
+		})
+	}
+}
+
+func TestScraper_ScrapeToWriter(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "scraper" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testExposition))
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	auth := &BasicAuth{Username: "scraper", Password: "secret"}
+	targets := []ScrapeTarget{
+		{Job: "api", URL: first.URL + "/metrics", BasicAuth: auth},
+		{Job: "api", URL: second.URL + "/metrics", BasicAuth: auth},
+		{Job: "web", URL: first.URL + "/metrics"}, // missing credentials
+	}
+	for i := range targets {
+		if err := targets[i].Validate(); err != nil {
+			t.Fatalf("validate() error = %v", err)
+		}
+	}
+
+	tmpDir := t.TempDir()
+	writer := NewJobFileWriter(tmpDir, 0)
+	written, errs, err := NewScraper(targets).ScrapeToWriter(context.Background(), writer)
+	if err != nil {
+		t.Fatalf("ScrapeToWriter() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(errs) != 1 || errs[0].Category != CategoryAuth {
+		t.Errorf("expected one auth error for the web target, got %+v", errs)
+	}
+	if written != 5 {
+		t.Errorf("expected 5 metric records for api, got %d", written)
+	}
+
+	data, err := loaders.LoadJobMetricReport(filepath.Join(tmpDir, "api.txt"))
+	if err != nil {
+		t.Fatalf("failed to load api job file: %v", err)
+	}
+
+	byName := make(map[string]loaders.JobMetricData)
+	for _, jm := range data {
+		byName[jm.MetricName] = jm
+	}
+
+	requests := byName["http_requests_total"]
+	if requests.Cardinality != 4 {
+		t.Errorf("expected 2 series x 2 instances = 4, got %d", requests.Cardinality)
+	}
+	if requests.Type != "counter" {
+		t.Errorf("expected counter type, got %q", requests.Type)
+	}
+	if requests.LabelCardinality["instance"] != 2 || requests.LabelCardinality["method"] != 2 {
+		t.Errorf("unexpected label cardinality %v", requests.LabelCardinality)
+	}
+	if byName["request_duration_seconds_bucket"].Type != "histogram" {
+		t.Errorf("expected histogram type for bucket series, got %q", byName["request_duration_seconds_bucket"].Type)
+	}
+}
+
+func TestReadExposition(t *testing.T) {
+	data, err := ReadExposition(strings.NewReader(testExposition), "checkout", "local")
+	if err != nil {
+		t.Fatalf("ReadExposition() error = %v", err)
+	}
+
+	if len(data) != 5 {
+		t.Fatalf("expected 5 metric records, got %d", len(data))
+	}
+	if data[0].MetricName != "http_requests_total" || data[4].MetricName != "up" {
+		t.Errorf("expected records sorted by metric name, got %s ... %s", data[0].MetricName, data[4].MetricName)
+	}
+	requests := data[0]
+	if requests.Job != "checkout" || requests.Cardinality != 2 || requests.Type != "counter" {
+		t.Errorf("unexpected http_requests_total record %+v", requests)
+	}
+	if got := strings.Join(requests.Labels, ","); got != "instance,job,method,path" {
+		t.Errorf("expected target labels attached, got labels %s", got)
+	}
+
+	if _, err := ReadExposition(strings.NewReader(`metric_without_value`), "checkout", "local"); err == nil {
+		t.Error("expected error for an invalid exposition")
+	}
+}
+
+func TestScrapeJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testExposition))
+	}))
+	defer server.Close()
+
+	data, err := ScrapeJob(context.Background(), ScrapeTarget{Job: "checkout", URL: server.URL + "/metrics"})
+	if err != nil {
+		t.Fatalf("ScrapeJob() error = %v", err)
+	}
+	if len(data) != 5 || data[0].LabelCardinality["instance"] != 1 {
+		t.Errorf("unexpected records %+v", data)
+	}
+
+	if _, err := ScrapeJob(context.Background(), ScrapeTarget{Job: "checkout", URL: server.URL + "/missing"}); err == nil {
+		t.Error("expected error for a failed scrape")
+	}
+}
+
+func TestAttachTargetLabels(t *testing.T) {
+	labels := map[string]string{"__name__": "up", "job": "exposed"}
+	attachTargetLabels(labels, map[string]string{"job": "api", "instance": "host:9090"})
+
+	if labels["job"] != "api" || labels["exported_job"] != "exposed" || labels["instance"] != "host:9090" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+}
+
+func TestScraper_HonorLabels(t *testing.T) {
+	// An OpenTelemetry Collector Prometheus exporter labels each service's series with its job
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`# TYPE http_server_requests_total counter
+http_server_requests_total{job="shop/cart",instance="cart-1",route="/"} 3
+http_server_requests_total{job="shop/cart",instance="cart-2",route="/"} 4
+http_server_requests_total{job="billing",route="/pay"} 1
+target_info{job="shop/cart",instance="cart-1",service_version="1.4.0"} 1
+otelcol_exporter_sent_metric_points_total 12
+`))
+	}))
+	defer server.Close()
+
+	targets := []ScrapeTarget{{Job: "otel-collector", URL: server.URL + "/metrics", HonorLabels: true}}
+	if err := targets[0].Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	tmpDir := t.TempDir()
+	writer := NewJobFileWriter(tmpDir, 0)
+	scraper := NewScraper(targets)
+	if _, _, err := scraper.ScrapeToWriter(context.Background(), writer); err != nil {
+		t.Fatalf("ScrapeToWriter() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for job, want := range map[string]int{"shop/cart": 2, "billing": 1, "otel-collector": 1} {
+		data, err := loaders.LoadJobMetricReport(filepath.Join(tmpDir, JobFileName(job)))
+		if err != nil {
+			t.Fatalf("failed to load %s job file: %v", job, err)
+		}
+		if len(data) != want {
+			t.Errorf("%s: got %d metrics, want %d", job, len(data), want)
+		}
+	}
+	if info := scraper.BuildInfo(); len(info) != 1 || info[0].Job != "shop/cart" || info[0].Version != "1.4.0" {
+		t.Errorf("BuildInfo() = %+v", info)
+	}
+}
@@ -0,0 +1,165 @@
+package collectors
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LabelMatcher is one PromQL label matcher, e.g. namespace="payments"
+type LabelMatcher struct {
+	Name  string
+	Op    string // =, !=, =~ or !~
+	Value string
+}
+
+// String formats the matcher as PromQL
+func (m LabelMatcher) String() string {
+	return m.Name + m.Op + strconv.Quote(m.Value)
+}
+
+// Selector scopes a run to part of the fleet, such as one namespace or Helm release
+// It is applied to every collection query, names the output of the run and is recorded
+// in the reports, so a release can be audited in isolation.
+type Selector []LabelMatcher
+
+var selectorLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ParseSelector parses comma-separated label matchers, optionally wrapped in braces,
+// e.g. `namespace="payments",release=~"checkout-.*"`
+func ParseSelector(s string) (Selector, error) {
+	rest := strings.TrimSpace(s)
+	if strings.HasPrefix(rest, "{") && strings.HasSuffix(rest, "}") {
+		rest = strings.TrimSpace(rest[1 : len(rest)-1])
+	}
+
+	var selector Selector
+	for rest != "" {
+		opStart := strings.IndexAny(rest, "=!")
+		if opStart < 0 {
+			return nil, fmt.Errorf("invalid selector %q: matcher %q has no operator", s, rest)
+		}
+		name := strings.TrimSpace(rest[:opStart])
+		if !selectorLabelName.MatchString(name) {
+			return nil, fmt.Errorf("invalid selector %q: invalid label name %q", s, name)
+		}
+		rest = rest[opStart:]
+
+		var op string
+		for _, candidate := range []string{"=~", "!~", "!=", "="} {
+			if strings.HasPrefix(rest, candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("invalid selector %q: invalid operator for label %s", s, name)
+		}
+		rest = strings.TrimSpace(rest[len(op):])
+
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil || !strings.HasPrefix(quoted, `"`) {
+			return nil, fmt.Errorf("invalid selector %q: label %s value is not a double-quoted string", s, name)
+		}
+		value, _ := strconv.Unquote(quoted)
+		if strings.HasSuffix(op, "~") {
+			if _, err := regexp.Compile(value); err != nil {
+				return nil, fmt.Errorf("invalid selector %q: label %s: %v", s, name, err)
+			}
+		}
+		selector = append(selector, LabelMatcher{Name: name, Op: op, Value: value})
+
+		rest = strings.TrimSpace(rest[len(quoted):])
+		if rest != "" {
+			if rest[0] != ',' {
+				return nil, fmt.Errorf("invalid selector %q: expected ',' before %q", s, rest)
+			}
+			rest = strings.TrimSpace(rest[1:])
+		}
+	}
+	return selector, nil
+}
+
+// String formats the selector as PromQL label filters, without braces
+func (s Selector) String() string {
+	matchers := make([]string, len(s))
+	for i, m := range s {
+		matchers[i] = m.String()
+	}
+	return strings.Join(matchers, ",")
+}
+
+// Labels returns the labels the selector fixes with = matchers, e.g. namespace="payments"
+func (s Selector) Labels() map[string]string {
+	labels := make(map[string]string)
+	for _, m := range s {
+		if m.Op == "=" {
+			labels[m.Name] = m.Value
+		}
+	}
+	return labels
+}
+
+// Matches reports whether a series or target with labels is selected, as Prometheus
+// matches series: a missing label has the empty value and regexes are fully anchored
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, m := range s {
+		value := labels[m.Name]
+		var matched bool
+		switch m.Op {
+		case "=", "!=":
+			matched = value == m.Value
+		default:
+			matched = regexp.MustCompile("^(?:" + m.Value + ")$").MatchString(value)
+		}
+		if matched != (m.Op == "=" || m.Op == "=~") {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectTargets returns the targets whose job, instance and target labels match the selector
+func (s Selector) SelectTargets(targets []ScrapeTarget) []ScrapeTarget {
+	var selected []ScrapeTarget
+	for _, target := range targets {
+		labels := map[string]string{"job": target.Job, "instance": target.Instance}
+		for name, value := range target.Labels {
+			labels[name] = value
+		}
+		if s.Matches(labels) {
+			selected = append(selected, target)
+		}
+	}
+	return selected
+}
+
+// slugUnsafe matches runs of characters not kept in Slug
+var slugUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// Slug returns a file name friendly form of the selector, e.g. namespace-payments, to
+// tell the output of runs over different parts of the fleet apart
+func (s Selector) Slug() string {
+	parts := make([]string, 0, len(s))
+	for _, m := range s {
+		op := map[string]string{"=": "-", "!=": "-not-", "=~": "-like-", "!~": "-notlike-"}[m.Op]
+		parts = append(parts, strings.Trim(slugUnsafe.ReplaceAllString(m.Name+op+m.Value, "-"), "-"))
+	}
+	slug := strings.Join(parts, "_")
+	if len(slug) > 64 {
+		slug = strings.TrimRight(slug[:64], "-_")
+	}
+	return slug
+}
+
+// CombineFilters joins PromQL label filters, skipping empty ones
+func CombineFilters(filters ...string) string {
+	var parts []string
+	for _, filter := range filters {
+		if filter = strings.TrimSpace(filter); filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	return strings.Join(parts, ",")
+}
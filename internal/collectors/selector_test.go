@@ -0,0 +1,125 @@
+package collectors
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Selector
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: nil},
+		{name: "equality", input: `namespace="payments"`, want: Selector{{Name: "namespace", Op: "=", Value: "payments"}}},
+		{
+			name:  "braces and all operators",
+			input: `{ namespace = "payments", release=~"checkout-.*",env!="dev",team!~"qa|test" }`,
+			want: Selector{
+				{Name: "namespace", Op: "=", Value: "payments"},
+				{Name: "release", Op: "=~", Value: "checkout-.*"},
+				{Name: "env", Op: "!=", Value: "dev"},
+				{Name: "team", Op: "!~", Value: "qa|test"},
+			},
+		},
+		{name: "escaped quote", input: `note="a \"b\""`, want: Selector{{Name: "note", Op: "=", Value: `a "b"`}}},
+		{name: "unquoted value", input: `namespace=payments`, wantErr: true},
+		{name: "single quotes", input: `namespace='payments'`, wantErr: true},
+		{name: "missing operator", input: `namespace`, wantErr: true},
+		{name: "invalid label name", input: `1ns="payments"`, wantErr: true},
+		{name: "invalid regex", input: `release=~"("`, wantErr: true},
+		{name: "missing comma", input: `a="1" b="2"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelector(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelector(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSelector(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelector_Formats(t *testing.T) {
+	selector, err := ParseSelector(`{namespace="payments", release=~"checkout-.*", env!="dev"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := selector.String(), `namespace="payments",release=~"checkout-.*",env!="dev"`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := selector.Slug(), "namespace-payments_release-like-checkout_env-not-dev"; got != want {
+		t.Errorf("Slug() = %s, want %s", got, want)
+	}
+	if got, want := selector.Labels(), map[string]string{"namespace": "payments"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels() = %v, want %v", got, want)
+	}
+	if got := Selector(nil).Slug(); got != "" {
+		t.Errorf("empty Slug() = %q, want empty", got)
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	selector, err := ParseSelector(`namespace="payments",release=~"checkout-.*",env!="dev"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{"all match", map[string]string{"namespace": "payments", "release": "checkout-v2"}, true},
+		{"other namespace", map[string]string{"namespace": "orders", "release": "checkout-v2"}, false},
+		{"regex is anchored", map[string]string{"namespace": "payments", "release": "old-checkout-v2"}, false},
+		{"missing regex label", map[string]string{"namespace": "payments"}, false},
+		{"excluded value", map[string]string{"namespace": "payments", "release": "checkout-v2", "env": "dev"}, false},
+	}
+	for _, tt := range tests {
+		if got := selector.Matches(tt.labels); got != tt.want {
+			t.Errorf("%s: Matches(%v) = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestSelector_SelectTargets(t *testing.T) {
+	targets := []ScrapeTarget{
+		{Job: "checkout", Labels: map[string]string{"namespace": "payments"}},
+		{Job: "orders", Labels: map[string]string{"namespace": "orders"}},
+		{Job: "ledger", Labels: map[string]string{"namespace": "payments"}},
+	}
+	selector, err := ParseSelector(`namespace="payments",job!="ledger"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := selector.SelectTargets(targets)
+	if len(got) != 1 || got[0].Job != "checkout" {
+		t.Errorf("SelectTargets() = %+v, want only checkout", got)
+	}
+}
+
+func TestCombineFilters(t *testing.T) {
+	tests := []struct {
+		filters []string
+		want    string
+	}{
+		{nil, ""},
+		{[]string{"", " "}, ""},
+		{[]string{`cluster="prod"`, ""}, `cluster="prod"`},
+		{[]string{`cluster="prod"`, `namespace="payments"`}, `cluster="prod",namespace="payments"`},
+	}
+	for _, tt := range tests {
+		if got := CombineFilters(tt.filters...); got != tt.want {
+			t.Errorf("CombineFilters(%q) = %s, want %s", tt.filters, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+)
+
+const (
+	// seriesLookback is the window queried on /api/v1/series to approximate an instant query
+	// It matches the default Prometheus staleness period.
+	seriesLookback = 5 * time.Minute
+	// minSeriesSlice is the smallest window the series fallback will split down to
+	minSeriesSlice = 10 * time.Second
+)
+
+// seriesLimitMessages are substrings of Prometheus/Mimir/Thanos errors returned when a
+// query touches more series or samples than the server allows
+var seriesLimitMessages = []string{
+	"query would load too many samples",
+	"query processing would load too many samples",
+	"too many series",
+	"maximum number of series",
+	"max number of series",
+	"exceeded the limit of",
+	"series limit",
+}
+
+// SeriesLimitError reports that the server refused a query because it would load too many
+// series or samples. The collector falls back to the /api/v1/series endpoint when it sees one.
+type SeriesLimitError struct {
+	Query   string
+	Message string
+}
+
+func (e *SeriesLimitError) Error() string {
+	return fmt.Sprintf("series limit exceeded for %s: %s", e.Query, e.Message)
+}
+
+// isSeriesLimitMessage reports whether an API error message describes a series/sample limit
+func isSeriesLimitMessage(msg string) bool {
+	lower := strings.ToLower(msg)
+	for _, pattern := range seriesLimitMessages {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSeriesLimitError reports whether err (or any error it wraps) is a SeriesLimitError
+func IsSeriesLimitError(err error) bool {
+	var limitErr *SeriesLimitError
+	return errors.As(err, &limitErr)
+}
+
+// GetSeries lists the label sets of every series matching selector between start and end
+func (c *PrometheusClient) GetSeries(ctx context.Context, selector string, start, end int64) ([]map[string]string, error) {
+	params := url.Values{}
+	params.Set("match[]", selector)
+	params.Set("start", fmt.Sprintf("%d", start))
+	params.Set("end", fmt.Sprintf("%d", end))
+
+	endpoint := fmt.Sprintf("%s/api/v1/series?%s", c.BaseURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	c.addAuthIfNeeded(req)
+
+	resp, err := c.doRequestWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		errorMsg := apiErrorMessage(body)
+		if isSeriesLimitMessage(errorMsg) {
+			return nil, &SeriesLimitError{Query: selector, Message: errorMsg}
+		}
+		if resp.StatusCode == 429 {
+			sleepContext(ctx, 2*time.Second)
+		}
+		return nil, fmt.Errorf("HTTP %d - series API - error: %s", resp.StatusCode, errorMsg)
+	}
+
+	var result struct {
+		Data []map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.Data, nil
+}
+
+// GetSeriesSliced lists the series matching selector over the lookback window ending at now
+// When the server rejects a window for exceeding its series limit, the window is split in
+// half and each half is fetched separately, down to minSeriesSlice. Series seen in more
+// than one slice are returned once. Label names and values are shared between series, as
+// metrics too large for instant queries can have millions of them.
+func (c *PrometheusClient) GetSeriesSliced(ctx context.Context, selector string, now int64) ([]map[string]string, error) {
+	end := time.Unix(now, 0)
+	seen := make(map[string]bool)
+	names := make(loaders.Interner)
+	var all []map[string]string
+
+	var fetch func(start, end time.Time) error
+	fetch = func(start, end time.Time) error {
+		series, err := c.GetSeries(ctx, selector, start.Unix(), end.Unix())
+		if err != nil {
+			if IsSeriesLimitError(err) && end.Sub(start) > minSeriesSlice {
+				mid := start.Add(end.Sub(start) / 2)
+				if err := fetch(start, mid); err != nil {
+					return err
+				}
+				return fetch(mid, end)
+			}
+			return err
+		}
+
+		for _, labels := range series {
+			key := seriesKey(labels)
+			if !seen[key] {
+				seen[key] = true
+				all = append(all, internLabels(names, labels))
+			}
+		}
+		return nil
+	}
+
+	if err := fetch(end.Add(-seriesLookback), end); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// internLabels copies a label set with its names and values shared through names
+func internLabels(names loaders.Interner, labels map[string]string) map[string]string {
+	shared := make(map[string]string, len(labels))
+	for name, value := range labels {
+		shared[names.Intern(name)] = names.Intern(value)
+	}
+	return shared
+}
+
+// seriesKey builds a stable identity for a label set
+func seriesKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(labels[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// apiErrorMessage extracts the "error" field of a Prometheus API error body, or returns the raw body
+func apiErrorMessage(body []byte) string {
+	var errorResp struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &errorResp) == nil && errorResp.Error != "" {
+		return errorResp.Error
+	}
+	return string(body)
+}
+
+// seriesSummary is the per-job view of a metric derived from its raw series
+type seriesSummary struct {
+	count       int64
+	labels      []string
+	labelValues map[string]map[string]bool
+}
+
+// summarizeSeriesByJob groups series by job and counts series, labels and label values
+func summarizeSeriesByJob(series []map[string]string) map[string]*seriesSummary {
+	summaries := make(map[string]*seriesSummary)
+	for _, labels := range series {
+		job := labels["job"]
+		if job == "" {
+			continue
+		}
+		summary, ok := summaries[job]
+		if !ok {
+			summary = newSeriesSummary()
+			summaries[job] = summary
+		}
+		summary.add(labels)
+	}
+	for _, summary := range summaries {
+		sort.Strings(summary.labels)
+	}
+	return summaries
+}
+
+func newSeriesSummary() *seriesSummary {
+	return &seriesSummary{labelValues: make(map[string]map[string]bool)}
+}
+
+// add counts one series and records its label names and values
+func (s *seriesSummary) add(labels map[string]string) {
+	s.count++
+	for name, value := range labels {
+		if name == "__name__" {
+			continue
+		}
+		values, ok := s.labelValues[name]
+		if !ok {
+			values = make(map[string]bool)
+			s.labelValues[name] = values
+			s.labels = append(s.labels, name)
+		}
+		values[value] = true
+	}
+}
+
+// labelCardinality returns the number of distinct values per label
+func (s *seriesSummary) labelCardinality() map[string]int64 {
+	cardinality := make(map[string]int64, len(s.labelValues))
+	for name, values := range s.labelValues {
+		cardinality[name] = int64(len(values))
+	}
+	return cardinality
+}
+
+// sampleLabelValues returns up to n distinct values per label, in sorted order
+func (s *seriesSummary) sampleLabelValues(n int) map[string][]string {
+	samples := make(map[string][]string, len(s.labelValues))
+	for name, values := range s.labelValues {
+		sorted := make([]string, 0, len(values))
+		for value := range values {
+			sorted = append(sorted, value)
+		}
+		sort.Strings(sorted)
+		if len(sorted) > n {
+			sorted = sorted[:n]
+		}
+		samples[name] = sorted
+	}
+	return samples
+}
@@ -0,0 +1,121 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+func TestIsSeriesLimitMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"query processing would load too many samples into memory in query execution", true},
+		{"expanding series: the query exceeded the maximum number of series (limit: 100000)", true},
+		{"bad_data: parse error", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			if got := isSeriesLimitMessage(tt.msg); got != tt.want {
+				t.Errorf("isSeriesLimitMessage(%q) = %v, want %v", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+// limitedSeriesServer serves /api/v1/series but rejects windows longer than maxWindow seconds
+func limitedSeriesServer(t *testing.T, maxWindow int64, series []map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/series":
+			start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+			end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+			if end-start > maxWindow {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "query would load too many samples"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": series})
+		case "/api/v1/query":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "query processing would load too many samples into memory"})
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestPrometheusClient_GetSeriesSliced(t *testing.T) {
+	series := []map[string]string{
+		{"__name__": "big_metric", "job": "api", "pod": "a"},
+		{"__name__": "big_metric", "job": "api", "pod": "b"},
+	}
+	server := limitedSeriesServer(t, 60, series)
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.SetRetryCount(0)
+
+	got, err := client.GetSeriesSliced(context.Background(), `{__name__="big_metric"}`, 1700000000)
+	if err != nil {
+		t.Fatalf("GetSeriesSliced() error = %v", err)
+	}
+	// Every slice returns the same two series; they must be deduplicated
+	if len(got) != 2 {
+		t.Fatalf("expected 2 unique series, got %d", len(got))
+	}
+	// Values repeated across series are shared, not one decoded copy per series
+	if unsafe.StringData(got[0]["job"]) != unsafe.StringData(got[1]["job"]) {
+		t.Error("expected series to share their job label value")
+	}
+
+	// A server that rejects even the smallest slice surfaces the limit error
+	strict := limitedSeriesServer(t, 1, series)
+	defer strict.Close()
+	client = NewPrometheusClient(strict.URL, "")
+	client.SetRetryCount(0)
+	if _, err := client.GetSeriesSliced(context.Background(), `{__name__="big_metric"}`, 1700000000); !IsSeriesLimitError(err) {
+		t.Errorf("expected SeriesLimitError, got %v", err)
+	}
+}
+
+func TestCollector_FallsBackToSeriesOnLimit(t *testing.T) {
+	series := []map[string]string{
+		{"__name__": "big_metric", "job": "api", "pod": "a", "path": "/x"},
+		{"__name__": "big_metric", "job": "api", "pod": "b", "path": "/x"},
+		{"__name__": "big_metric", "job": "web", "pod": "c"},
+	}
+	server := limitedSeriesServer(t, 120, series)
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.SetRetryCount(0)
+	collector := NewCollectorWithClient(client, "")
+	collector.SetCollectLabelCardinality(true)
+
+	data, err := collector.getJobMetricDataForMetric(context.Background(), "big_metric", 1700000000)
+	if err != nil {
+		t.Fatalf("getJobMetricDataForMetric() error = %v", err)
+	}
+	if len(data) != 2 {
+		t.Fatalf("expected data for 2 jobs, got %d", len(data))
+	}
+
+	api := data[0]
+	if api.Job != "api" || api.Cardinality != "2" {
+		t.Errorf("expected api with cardinality 2, got %s %s", api.Job, api.Cardinality)
+	}
+	if len(api.Labels) != 3 {
+		t.Errorf("expected labels [job path pod], got %v", api.Labels)
+	}
+	if api.LabelCardinality["pod"] != 2 || api.LabelCardinality["path"] != 1 {
+		t.Errorf("unexpected label cardinality %v", api.LabelCardinality)
+	}
+}
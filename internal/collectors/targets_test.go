@@ -0,0 +1,86 @@
+package collectors
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadTargetsConfig(t *testing.T) {
+	t.Setenv("TEST_SCRAPE_TOKEN", "from-env")
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "valid targets",
+			content: `targets:
+  - job: api
+    url: http://api:8080/metrics
+    bearer_token: ${TEST_SCRAPE_TOKEN}
+  - job: web
+    url: https://web:8443/metrics
+    timeout: 3s
+    instance: web-0
+`,
+		},
+		{name: "no targets", content: "targets: []\n", wantErr: true},
+		{name: "missing job", content: "targets:\n  - url: http://api/metrics\n", wantErr: true},
+		{name: "invalid url", content: "targets:\n  - job: api\n    url: not-a-url\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "targets.yaml")
+			if err := os.WriteFile(filename, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("failed to write targets file: %v", err)
+			}
+
+			config, err := LoadTargetsConfig(filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadTargetsConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			api, web := config.Targets[0], config.Targets[1]
+			if api.BearerToken != "from-env" {
+				t.Errorf("expected bearer token expanded from env, got %q", api.BearerToken)
+			}
+			if api.Instance != "api:8080" || api.Timeout != defaultScrapeTimeout {
+				t.Errorf("expected defaults for api target, got instance %q timeout %v", api.Instance, api.Timeout)
+			}
+			if web.Instance != "web-0" || web.Timeout != 3*time.Second {
+				t.Errorf("expected explicit settings for web target, got instance %q timeout %v", web.Instance, web.Timeout)
+			}
+		})
+	}
+}
+
+func TestScrapeTarget_Authorize(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+
+	target := ScrapeTarget{
+		BearerTokenFile: tokenFile,
+		Headers:         map[string]string{"X-Scope-OrgID": "tenant-1"},
+	}
+	req, _ := http.NewRequest("GET", "http://example/metrics", nil)
+	if err := target.authorize(req); err != nil {
+		t.Fatalf("authorize() error = %v", err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer file-token" {
+		t.Errorf("expected bearer token from file, got %q", got)
+	}
+	if got := req.Header.Get("X-Scope-OrgID"); got != "tenant-1" {
+		t.Errorf("expected custom header, got %q", got)
+	}
+}
@@ -0,0 +1,86 @@
+package collectors
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MetricTiming records how long collecting a single metric took
+type MetricTiming struct {
+	MetricName string
+	Duration   time.Duration
+	Jobs       int  // Jobs the metric was found in
+	Failed     bool // Collection returned an error
+}
+
+// timingRecorder collects MetricTimings from concurrent workers
+type timingRecorder struct {
+	mu      sync.Mutex
+	timings []MetricTiming
+}
+
+func (r *timingRecorder) record(timing MetricTiming) {
+	r.mu.Lock()
+	r.timings = append(r.timings, timing)
+	r.mu.Unlock()
+}
+
+// snapshot returns a copy of the recorded timings
+func (r *timingRecorder) snapshot() []MetricTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timings := make([]MetricTiming, len(r.timings))
+	copy(timings, r.timings)
+	return timings
+}
+
+// SlowestMetrics returns up to n timings ordered from slowest to fastest
+// An n of 0 or less returns every timing.
+func SlowestMetrics(timings []MetricTiming, n int) []MetricTiming {
+	sorted := make([]MetricTiming, len(timings))
+	copy(sorted, timings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration > sorted[j].Duration
+	})
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// WriteSlowMetricsReport writes the n slowest metrics to a file
+func WriteSlowMetricsReport(filename string, timings []MetricTiming, n int) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create slow metrics file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	if _, err := writer.WriteString("RANK|METRIC_NAME|DURATION_SECONDS|JOBS|STATUS\n"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for i, timing := range SlowestMetrics(timings, n) {
+		status := "ok"
+		if timing.Failed {
+			status = "failed"
+		}
+		line := fmt.Sprintf("%d|%s|%.3f|%d|%s\n",
+			i+1,
+			timing.MetricName,
+			timing.Duration.Seconds(),
+			timing.Jobs,
+			status)
+		if _, err := writer.WriteString(line); err != nil {
+			return fmt.Errorf("failed to write slow metric line: %w", err)
+		}
+	}
+
+	return nil
+}
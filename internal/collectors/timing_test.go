@@ -0,0 +1,78 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowestMetrics(t *testing.T) {
+	timings := []MetricTiming{
+		{MetricName: "fast", Duration: 10 * time.Millisecond},
+		{MetricName: "slow", Duration: 5 * time.Second, Jobs: 3},
+		{MetricName: "medium", Duration: time.Second, Failed: true},
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{"top two", 2, []string{"slow", "medium"}},
+		{"all", 0, []string{"slow", "medium", "fast"}},
+		{"more than available", 10, []string{"slow", "medium", "fast"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SlowestMetrics(timings, tt.n)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d timings, got %d", len(tt.want), len(got))
+			}
+			for i, name := range tt.want {
+				if got[i].MetricName != name {
+					t.Errorf("position %d: expected %s, got %s", i, name, got[i].MetricName)
+				}
+			}
+		})
+	}
+
+	if timings[0].MetricName != "fast" {
+		t.Error("SlowestMetrics should not reorder its input")
+	}
+}
+
+func TestWriteSlowMetricsReport(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "slow_metrics.txt")
+	timings := []MetricTiming{
+		{MetricName: "fast", Duration: 10 * time.Millisecond, Jobs: 1},
+		{MetricName: "slow", Duration: 2500 * time.Millisecond, Jobs: 4},
+		{MetricName: "broken", Duration: time.Second, Failed: true},
+	}
+
+	if err := WriteSlowMetricsReport(filename, timings, 2); err != nil {
+		t.Fatalf("WriteSlowMetricsReport() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	want := []string{
+		"RANK|METRIC_NAME|DURATION_SECONDS|JOBS|STATUS",
+		"1|slow|2.500|4|ok",
+		"2|broken|1.000|0|failed",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d: %q", len(want), len(lines), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NameCount is a metric or label name with a count from the TSDB status API
+type NameCount struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+// TSDBStatus is the head block cardinality Prometheus reports on /api/v1/status/tsdb
+// The counts cover every series of the head block, ignoring query filters and selectors.
+type TSDBStatus struct {
+	HeadSeries                 int64
+	SeriesCountByMetricName    []NameCount // Largest first
+	LabelValueCountByLabelName []NameCount // Largest first
+	limit                      int
+}
+
+// GetTSDBStatus fetches the head block cardinality of up to limit metric and label names
+// Servers before the limit parameter return their default of 10 names.
+func (c *PrometheusClient) GetTSDBStatus(ctx context.Context, limit int) (*TSDBStatus, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+
+	endpoint := fmt.Sprintf("%s/api/v1/status/tsdb?%s", c.BaseURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	c.addAuthIfNeeded(req)
+
+	resp, err := c.doRequestWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d - TSDB status API - error: %s", resp.StatusCode, apiErrorMessage(body))
+	}
+
+	var result struct {
+		Data struct {
+			HeadStats struct {
+				NumSeries int64 `json:"numSeries"`
+			} `json:"headStats"`
+			SeriesCountByMetricName    []NameCount `json:"seriesCountByMetricName"`
+			LabelValueCountByLabelName []NameCount `json:"labelValueCountByLabelName"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	status := &TSDBStatus{
+		HeadSeries:                 result.Data.HeadStats.NumSeries,
+		SeriesCountByMetricName:    result.Data.SeriesCountByMetricName,
+		LabelValueCountByLabelName: result.Data.LabelValueCountByLabelName,
+		limit:                      limit,
+	}
+	for _, names := range [][]NameCount{status.SeriesCountByMetricName, status.LabelValueCountByLabelName} {
+		sort.SliceStable(names, func(i, j int) bool { return names[i].Value > names[j].Value })
+	}
+	return status, nil
+}
+
+// defaultTSDBStatusLimit is the number of names servers ignoring the limit parameter return
+const defaultTSDBStatusLimit = 10
+
+// seriesBound returns the most series a metric can have in the head block
+// A metric left out of a list cut at the limit has no more series than the smallest listed;
+// left out of a complete list, it has none. A list of the default length may have been cut.
+func (s *TSDBStatus) seriesBound(metricName string) int64 {
+	listed := s.SeriesCountByMetricName
+	for _, metric := range listed {
+		if metric.Name == metricName {
+			return metric.Value
+		}
+	}
+	complete := len(listed) < s.limit && len(listed) != defaultTSDBStatusLimit
+	if complete || len(listed) == 0 {
+		return 0
+	}
+	return listed[len(listed)-1].Value
+}
+
+// Rank orders metricNames by their head block series, largest first, so the slowest metrics
+// start while there are others to collect beside them; metrics not listed keep their order last
+func (s *TSDBStatus) Rank(metricNames []string) []string {
+	series := make(map[string]int64, len(s.SeriesCountByMetricName))
+	for _, metric := range s.SeriesCountByMetricName {
+		series[metric.Name] = metric.Value
+	}
+	ranked := append([]string(nil), metricNames...)
+	sort.SliceStable(ranked, func(i, j int) bool { return series[ranked[i]] > series[ranked[j]] })
+	return ranked
+}
+
+// Summary describes the snapshot for the analyze log: head series and the largest metrics and labels
+func (s *TSDBStatus) Summary(top int) string {
+	format := func(names []NameCount) string {
+		var parts []string
+		for _, name := range names[:min(top, len(names))] {
+			parts = append(parts, fmt.Sprintf("%s (%d)", name.Name, name.Value))
+		}
+		return strings.Join(parts, ", ")
+	}
+	summary := fmt.Sprintf("%d head series", s.HeadSeries)
+	if len(s.SeriesCountByMetricName) > 0 {
+		summary += "; most series: " + format(s.SeriesCountByMetricName)
+	}
+	if len(s.LabelValueCountByLabelName) > 0 {
+		summary += "; most label values: " + format(s.LabelValueCountByLabelName)
+	}
+	return summary
+}
+
+// SetTSDBSnapshot ranks metrics by the TSDB status API before collecting them, largest first
+// Metrics with at most smallSeries head series are collected with one series query instead of
+// per-job queries; 0 queries every metric per job.
+func (c *Collector) SetTSDBSnapshot(smallSeries int64) {
+	c.tsdbSnapshot = true
+	c.smallSeries = smallSeries
+}
+
+// applyTSDBSnapshot fetches the TSDB status and ranks metricNames by it, marking the small
+// metrics; when the status is unavailable the metrics are collected as without a snapshot
+func (c *Collector) applyTSDBSnapshot(ctx context.Context, metricNames []string, errors *ErrorAggregator) []string {
+	fmt.Println("Fetching TSDB status...")
+	status, err := c.client.GetTSDBStatus(ctx, len(metricNames))
+	if err != nil {
+		fmt.Printf("WARNING: Failed to fetch TSDB status, metrics are collected unranked: %v\n", err)
+		errors.Add("*", "fetch_tsdb_status", err)
+		return metricNames
+	}
+	fmt.Printf("TSDB snapshot: %s\n", status.Summary(5))
+
+	if c.smallSeries > 0 {
+		c.small = make(map[string]bool)
+		for _, metricName := range metricNames {
+			if status.seriesBound(metricName) <= c.smallSeries {
+				c.small[metricName] = true
+			}
+		}
+		fmt.Printf("%d of %d metrics have at most %d series and are collected with one series query each\n",
+			len(c.small), len(metricNames), c.smallSeries)
+	}
+	fmt.Println()
+	return status.Rank(metricNames)
+}
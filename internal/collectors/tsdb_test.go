@@ -0,0 +1,102 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTSDBSnapshot(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v1/status/tsdb":
+			if r.URL.Query().Get("limit") != "4" {
+				t.Errorf("limit = %q, want one per metric", r.URL.Query().Get("limit"))
+			}
+			w.Write([]byte(`{"status":"success","data":{"headStats":{"numSeries":5230},
+				"seriesCountByMetricName":[{"name":"requests_total","value":200},{"name":"request_duration_seconds_bucket","value":5000},{"name":"build_info","value":3}],
+				"labelValueCountByLabelName":[{"name":"pod","value":120},{"name":"le","value":12}]}}`))
+		case "/api/v1/series":
+			series := []map[string]string{
+				{"__name__": "build_info", "job": "api", "version": "1.2"},
+				{"__name__": "build_info", "job": "api", "version": "1.3"},
+				{"__name__": "build_info", "job": "web", "version": "2.0"},
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": series})
+		default:
+			http.Error(w, "unexpected per-job query", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, "")
+	client.SetRetryCount(0)
+	collector := NewCollectorWithClient(client, "")
+	collector.SetTSDBSnapshot(10)
+
+	metricNames := []string{"build_info", "process_start_time_seconds", "request_duration_seconds_bucket", "requests_total"}
+	ranked := collector.applyTSDBSnapshot(context.Background(), metricNames, NewErrorAggregator())
+	wantRanked := []string{"request_duration_seconds_bucket", "requests_total", "build_info", "process_start_time_seconds"}
+	if !reflect.DeepEqual(ranked, wantRanked) {
+		t.Errorf("ranked = %v, want %v", ranked, wantRanked)
+	}
+	// The list is complete, so the unlisted metric has no head series
+	if !collector.small["build_info"] || !collector.small["process_start_time_seconds"] || collector.small["requests_total"] {
+		t.Errorf("small = %v, want build_info and process_start_time_seconds", collector.small)
+	}
+
+	data, err := collector.getJobMetricDataForMetric(context.Background(), "build_info", 1700000000)
+	if err != nil {
+		t.Fatalf("getJobMetricDataForMetric() error = %v", err)
+	}
+	if len(data) != 2 || data[0].Job != "api" || data[0].Cardinality != "2" || data[1].Cardinality != "1" {
+		t.Errorf("data = %+v, want api with 2 series and web with 1", data)
+	}
+	if requests["/api/v1/series"] != 1 || requests["/api/v1/query"] != 0 {
+		t.Errorf("requests = %v, want a single series query", requests)
+	}
+}
+
+func TestTSDBStatus_SeriesBound(t *testing.T) {
+	listed := func(n int) []NameCount {
+		var names []NameCount
+		for i := 0; i < n; i++ {
+			names = append(names, NameCount{Name: strings.Repeat("m", i+1), Value: int64(1000 - i*10)})
+		}
+		return names
+	}
+
+	tests := []struct {
+		name   string
+		status TSDBStatus
+		metric string
+		want   int64
+	}{
+		{"listed", TSDBStatus{SeriesCountByMetricName: listed(3), limit: 50}, "mm", 990},
+		{"not in a complete list", TSDBStatus{SeriesCountByMetricName: listed(3), limit: 50}, "absent", 0},
+		{"not in a list cut at the limit", TSDBStatus{SeriesCountByMetricName: listed(5), limit: 5}, "absent", 960},
+		{"limit ignored by the server", TSDBStatus{SeriesCountByMetricName: listed(10), limit: 50}, "absent", 910},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.seriesBound(tt.metric); got != tt.want {
+				t.Errorf("seriesBound(%q) = %d, want %d", tt.metric, got, tt.want)
+			}
+		})
+	}
+
+	status := TSDBStatus{HeadSeries: 42, SeriesCountByMetricName: listed(3), LabelValueCountByLabelName: []NameCount{{"pod", 7}}}
+	if got, want := status.Summary(2), "42 head series; most series: m (1000), mm (990); most label values: pod (7)"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+	"instrumentation-score/internal/usage"
+)
+
+// grafanaSearchPageSize is the number of dashboards requested per search page
+const grafanaSearchPageSize = 1000
+
+// GetRuleExpressions fetches the queries of every alerting and recording rule loaded in Prometheus
+func (c *PrometheusClient) GetRuleExpressions(ctx context.Context) ([]usage.Expression, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/rules", c.BaseURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	c.addAuthIfNeeded(req)
+
+	resp, err := c.doRequestWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d - rules API - error: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data struct {
+			Groups []struct {
+				Rules []struct {
+					Name  string `json:"name"`
+					Query string `json:"query"`
+					Type  string `json:"type"`
+				} `json:"rules"`
+			} `json:"groups"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var expressions []usage.Expression
+	for _, group := range result.Data.Groups {
+		for _, rule := range group.Rules {
+			source := usage.AlertSource + rule.Name
+			if rule.Type == "recording" {
+				source = usage.RecordSource + rule.Name
+			}
+			expressions = append(expressions, usage.Expression{Source: source, Query: rule.Query})
+		}
+	}
+	return expressions, nil
+}
+
+// GrafanaClient reads dashboards from the Grafana HTTP API
+type GrafanaClient struct {
+	BaseURL string
+	Token   string // Service account token or API key, sent as a bearer token
+	Client  *http.Client
+}
+
+// NewGrafanaClient creates a new Grafana API client
+func NewGrafanaClient(baseURL, token string) *GrafanaClient {
+	return &GrafanaClient{
+		BaseURL: baseURL,
+		Token:   token,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// get fetches a Grafana API path and returns the response body
+func (g *GrafanaClient) get(path string) ([]byte, error) {
+	req, err := http.NewRequest("GET", g.BaseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d - %s - error: %s", resp.StatusCode, path, string(body))
+	}
+	return body, nil
+}
+
+// GetDashboardExpressions fetches every dashboard and returns the PromQL queries they contain
+// Dashboards that cannot be fetched or parsed are returned as errors without aborting the scan.
+func (g *GrafanaClient) GetDashboardExpressions() ([]usage.Expression, []ErrorRecord, error) {
+	var uids []string
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("type", "dash-db")
+		params.Set("limit", strconv.Itoa(grafanaSearchPageSize))
+		params.Set("page", strconv.Itoa(page))
+		body, err := g.get("/api/search?" + params.Encode())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to search dashboards: %w", err)
+		}
+
+		var results []struct {
+			UID string `json:"uid"`
+		}
+		if err := json.Unmarshal(body, &results); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse dashboard search: %w", err)
+		}
+		for _, result := range results {
+			uids = append(uids, result.UID)
+		}
+		if len(results) < grafanaSearchPageSize {
+			break
+		}
+	}
+
+	errors := NewErrorAggregator()
+	var expressions []usage.Expression
+	for _, uid := range uids {
+		body, err := g.get("/api/dashboards/uid/" + url.PathEscape(uid))
+		if err != nil {
+			errors.Add(uid, "grafana_dashboard", err)
+			continue
+		}
+		found, err := usage.DashboardExpressions(body)
+		if err != nil {
+			errors.Add(uid, "grafana_dashboard", err)
+			continue
+		}
+		expressions = append(expressions, found...)
+	}
+	return expressions, errors.Records(), nil
+}
+
+// WriteMetricUsageFile writes a metric usage report
+func WriteMetricUsageFile(filename string, data []loaders.MetricUsageData) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create metric usage file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.WriteString(loaders.MetricUsageColumnHeader + "\n"); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, record := range data {
+		if _, err := writer.WriteString(loaders.FormatMetricUsageLine(record)); err != nil {
+			return fmt.Errorf("failed to write metric usage line: %w", err)
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,66 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"instrumentation-score/internal/usage"
+)
+
+func TestPrometheusClient_GetRuleExpressions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rules" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"groups":[{"rules":[
+			{"name":"HighErrorRate","query":"rate(http_errors_total[5m]) > 1","type":"alerting"},
+			{"name":"job:up:sum","query":"sum by (job) (up)","type":"recording"}]}]}}`)
+	}))
+	defer server.Close()
+
+	got, err := NewPrometheusClient(server.URL, "").GetRuleExpressions(context.Background())
+	if err != nil {
+		t.Fatalf("GetRuleExpressions() error = %v", err)
+	}
+	want := []usage.Expression{
+		{Source: "alert:HighErrorRate", Query: "rate(http_errors_total[5m]) > 1"},
+		{Source: "record:job:up:sum", Query: "sum by (job) (up)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetRuleExpressions() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGrafanaClient_GetDashboardExpressions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/search":
+			fmt.Fprint(w, `[{"uid":"api"},{"uid":"broken"}]`)
+		case "/api/dashboards/uid/api":
+			fmt.Fprint(w, `{"dashboard":{"title":"API","panels":[{"targets":[{"expr":"rate(http_requests_total[5m])"}]}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	got, errors, err := NewGrafanaClient(server.URL, "secret").GetDashboardExpressions()
+	if err != nil {
+		t.Fatalf("GetDashboardExpressions() error = %v", err)
+	}
+	want := []usage.Expression{{Source: "dashboard:API", Query: "rate(http_requests_total[5m])"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetDashboardExpressions() = %+v, want %+v", got, want)
+	}
+	if len(errors) != 1 || errors[0].MetricName != "broken" {
+		t.Errorf("errors = %+v, want one error for the broken dashboard", errors)
+	}
+}
@@ -0,0 +1,216 @@
+package collectors
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// DefaultMaxOpenJobFiles bounds the number of per-job files kept open while streaming
+const DefaultMaxOpenJobFiles = 256
+
+// openJobFile is a per-job file currently held open by a JobFileWriter
+type openJobFile struct {
+	file     *os.File
+	writer   *bufio.Writer
+	lastUsed uint64
+}
+
+// JobFileWriter streams JobMetricData records into per-job files as they are collected
+// At most maxOpenFiles files are open at once; the least recently used file is
+// flushed and closed when the limit is reached and reopened for append on its next record.
+// It is safe for concurrent use.
+type JobFileWriter struct {
+	outputDir    string
+	maxOpenFiles int
+
+	mu      sync.Mutex
+	open    map[string]*openJobFile
+	created map[string]bool // Jobs whose file (and header) has been written
+	skipped map[string]bool // Jobs whose file could not be created
+	clock   uint64
+	records int
+}
+
+// NewJobFileWriter creates a streaming writer for per-job files in outputDir
+// A maxOpenFiles of 0 or less uses DefaultMaxOpenJobFiles.
+func NewJobFileWriter(outputDir string, maxOpenFiles int) *JobFileWriter {
+	if maxOpenFiles <= 0 {
+		maxOpenFiles = DefaultMaxOpenJobFiles
+	}
+	return &JobFileWriter{
+		outputDir:    outputDir,
+		maxOpenFiles: maxOpenFiles,
+		open:         make(map[string]*openJobFile),
+		created:      make(map[string]bool),
+		skipped:      make(map[string]bool),
+	}
+}
+
+// Write appends a record to its job's file, creating the file with a header on first use
+// Records for jobs whose file cannot be created are dropped with a warning.
+func (w *JobFileWriter) Write(data JobMetricData) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.skipped[data.Job] {
+		return nil
+	}
+
+	jf, err := w.fileFor(data.Job)
+	if err != nil {
+		return err
+	}
+	if jf == nil {
+		return nil
+	}
+
+	if _, err := jf.writer.WriteString(formatJobLine(data)); err != nil {
+		return fmt.Errorf("failed to write metric data: %w", err)
+	}
+	w.records++
+	return nil
+}
+
+// fileFor returns the open file for a job, opening (and evicting) as needed
+// It returns nil without error when the job's file could not be created.
+func (w *JobFileWriter) fileFor(job string) (*openJobFile, error) {
+	w.clock++
+	if jf, ok := w.open[job]; ok {
+		jf.lastUsed = w.clock
+		return jf, nil
+	}
+
+	if len(w.open) >= w.maxOpenFiles {
+		if err := w.evictLeastRecentlyUsed(); err != nil {
+			return nil, err
+		}
+	}
+
+	safeJobName := sanitizeJobName(job)
+	filePath := filepath.Join(w.outputDir, safeJobName+".txt")
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if w.created[job] {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(filePath, flags, 0600)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to create file for job %s (sanitized: %s): %v", job, safeJobName, err)
+		w.skipped[job] = true
+		fmt.Printf("WARNING: %s\n", errMsg)
+		return nil, nil
+	}
+
+	jf := &openJobFile{file: file, writer: bufio.NewWriter(file), lastUsed: w.clock}
+	if !w.created[job] {
+		if _, err := jf.writer.WriteString(loaders.FileHeader()); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write header: %w", err)
+		}
+		w.created[job] = true
+	}
+	w.open[job] = jf
+	return jf, nil
+}
+
+// evictLeastRecentlyUsed flushes and closes the least recently written file
+func (w *JobFileWriter) evictLeastRecentlyUsed() error {
+	var oldestJob string
+	var oldest *openJobFile
+	for job, jf := range w.open {
+		if oldest == nil || jf.lastUsed < oldest.lastUsed {
+			oldestJob, oldest = job, jf
+		}
+	}
+	if oldest == nil {
+		return nil
+	}
+	delete(w.open, oldestJob)
+	return closeJobFile(oldest)
+}
+
+// closeJobFile flushes buffered data and closes the file
+func closeJobFile(jf *openJobFile) error {
+	flushErr := jf.writer.Flush()
+	closeErr := jf.file.Close()
+	if flushErr != nil {
+		return fmt.Errorf("failed to flush job file: %w", flushErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close job file: %w", closeErr)
+	}
+	return nil
+}
+
+// Close flushes and closes all open files and reports skipped jobs
+func (w *JobFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var firstErr error
+	for job, jf := range w.open {
+		if err := closeJobFile(jf); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(w.open, job)
+	}
+
+	if len(w.skipped) > 0 {
+		fmt.Printf("\nWARNING: Skipped %d job(s) due to file creation errors\n", len(w.skipped))
+	}
+	return firstErr
+}
+
+// Records returns the number of records written so far
+func (w *JobFileWriter) Records() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.records
+}
+
+// JobFileName returns the name of the per-job file of job
+func JobFileName(job string) string {
+	return sanitizeJobName(job) + ".txt"
+}
+
+// formatJobLine renders a record as a per-job file line
+func formatJobLine(data JobMetricData) string {
+	// Format per-label cardinality as label1:count1,label2:count2,...
+	var labelCardinalityStr string
+	if len(data.LabelCardinality) > 0 {
+		var parts []string
+		for _, label := range data.Labels {
+			if count, ok := data.LabelCardinality[label]; ok {
+				parts = append(parts, fmt.Sprintf("%s:%d", loaders.EscapeField(label), count))
+			}
+		}
+		labelCardinalityStr = strings.Join(parts, ",")
+	}
+
+	// Format sampled values as label1:value1:value2,label2:value1,...
+	var labelValuesStr string
+	if len(data.LabelValues) > 0 {
+		var parts []string
+		for _, label := range data.Labels {
+			if values, ok := data.LabelValues[label]; ok && len(values) > 0 {
+				parts = append(parts, loaders.EscapeField(label)+":"+loaders.JoinEscaped(values, ":"))
+			}
+		}
+		labelValuesStr = strings.Join(parts, ",")
+	}
+
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s\n",
+		loaders.EscapeField(data.Job),
+		loaders.EscapeField(data.MetricName),
+		loaders.JoinEscaped(data.Labels, ","),
+		data.Cardinality,
+		labelCardinalityStr,
+		loaders.EscapeField(data.Type),
+		labelValuesStr)
+}
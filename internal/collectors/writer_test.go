@@ -0,0 +1,108 @@
+package collectors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestJobFileWriter_EvictsAndReopens(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Only one file open at a time forces every job switch to evict and reopen
+	writer := NewJobFileWriter(tmpDir, 1)
+	records := []JobMetricData{
+		{Job: "api", MetricName: "m1", Labels: []string{"a"}, Cardinality: "1"},
+		{Job: "web", MetricName: "m1", Labels: []string{"a"}, Cardinality: "2"},
+		{Job: "api", MetricName: "m2", Labels: []string{"b"}, Cardinality: "3"},
+		{Job: "web", MetricName: "m2", Labels: []string{"b"}, Cardinality: "4"},
+		{Job: "api", MetricName: "m3", Labels: []string{"c"}, Cardinality: "5"},
+	}
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if writer.Records() != len(records) {
+		t.Errorf("expected %d records written, got %d", len(records), writer.Records())
+	}
+
+	tests := []struct {
+		file        string
+		wantMetrics []string
+	}{
+		{"api.txt", []string{"m1", "m2", "m3"}},
+		{"web.txt", []string{"m1", "m2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.file)
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tt.file, err)
+			}
+			if n := strings.Count(string(content), loaders.FormatHeaderPrefix); n != 1 {
+				t.Errorf("expected exactly one format header, got %d", n)
+			}
+
+			data, err := loaders.LoadJobMetricReport(path)
+			if err != nil {
+				t.Fatalf("failed to load %s: %v", tt.file, err)
+			}
+			if len(data) != len(tt.wantMetrics) {
+				t.Fatalf("expected %d records, got %d", len(tt.wantMetrics), len(data))
+			}
+			for i, metric := range tt.wantMetrics {
+				if data[i].MetricName != metric {
+					t.Errorf("record %d: expected metric %s, got %s", i, metric, data[i].MetricName)
+				}
+			}
+		})
+	}
+}
+
+func TestJobFileWriter_ConcurrentWrites(t *testing.T) {
+	tmpDir := t.TempDir()
+	writer := NewJobFileWriter(tmpDir, 2)
+
+	jobs := []string{"a", "b", "c", "d"}
+	const perJob = 50
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job string) {
+			defer wg.Done()
+			for i := 0; i < perJob; i++ {
+				if err := writer.Write(JobMetricData{Job: job, MetricName: fmt.Sprintf("m%d", i), Cardinality: "1"}); err != nil {
+					t.Errorf("Write() error = %v", err)
+					return
+				}
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, job := range jobs {
+		data, err := loaders.LoadJobMetricReport(filepath.Join(tmpDir, job+".txt"))
+		if err != nil {
+			t.Fatalf("failed to load job %s: %v", job, err)
+		}
+		if len(data) != perJob {
+			t.Errorf("job %s: expected %d records, got %d", job, perJob, len(data))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2025, 11, 5, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 11, 5, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 11, 5, 10, 30, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2025, 11, 6, 2, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 11, 5, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2025, 11, 6, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 11, 9, 0, 0, 0, 0, time.UTC)}, // 7 is Sunday
+		{"0 6 1 jan,jul *", time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"10/20 10 * * *", time.Date(2025, 11, 5, 10, 30, 0, 0, time.UTC)},
+		// Day of month or day of week: the 6th, or the next Sunday
+		{"0 0 6 * sun", time.Date(2025, 11, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}}, // Never
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@often"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) expected an error", expr)
+		}
+	}
+}
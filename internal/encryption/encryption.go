@@ -0,0 +1,213 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// KeyEnv holds a base64-encoded 256-bit key for encrypting reports without KMS
+const KeyEnv = "INSTRUMENTATION_SCORE_ENCRYPTION_KEY"
+
+// Extension is appended to the name of encrypted files
+const Extension = ".enc"
+
+// magic starts every encrypted file
+const magic = "ISENC1"
+
+// Key sources, recorded in the header of encrypted files
+const (
+	sourceEnv byte = 0 // The key in KeyEnv
+	sourceKMS byte = 1 // A KMS data key, stored wrapped in the header
+)
+
+// kmsAPI is the part of KMS an Encrypter uses
+type kmsAPI interface {
+	GenerateDataKey(*kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(*kms.DecryptInput) (*kms.DecryptOutput, error)
+}
+
+// Encrypter encrypts report artifacts with AES-256-GCM
+// The key comes from KeyEnv, or with a KMS key ID every file is encrypted with a fresh
+// KMS data key stored wrapped in its header, so only principals allowed to use the KMS
+// key can read it.
+//
+// Encrypted files are: "ISENC1", the key source byte, the big-endian uint16 length of
+// the wrapped data key and the wrapped key (empty for KeyEnv), the 12-byte nonce, and
+// the ciphertext. Everything before the nonce is authenticated.
+type Encrypter struct {
+	key      []byte // From KeyEnv, nil when unset
+	kmsKeyID string
+	region   string
+	kms      kmsAPI // Created on first use
+}
+
+// New returns an Encrypter using kmsKeyID when set, or else the key in KeyEnv
+// Decrypting only needs whichever one encrypted the file; KMS finds its key from the
+// wrapped data key.
+func New(kmsKeyID, region string) (*Encrypter, error) {
+	e := &Encrypter{kmsKeyID: kmsKeyID, region: region}
+	if encoded := strings.TrimSpace(os.Getenv(KeyEnv)); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not valid base64: %w", KeyEnv, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("%s must hold a 256-bit key, got %d bytes", KeyEnv, len(key))
+		}
+		e.key = key
+	}
+	return e, nil
+}
+
+// Description names the algorithm and key source, for manifests
+func (e *Encrypter) Description() string {
+	if e.kmsKeyID != "" {
+		return "AES-256-GCM, data key from KMS key " + e.kmsKeyID
+	}
+	return "AES-256-GCM, key from " + KeyEnv
+}
+
+// kmsClient returns the KMS client, creating it on first use
+func (e *Encrypter) kmsClient() (kmsAPI, error) {
+	if e.kms == nil {
+		sess, err := session.NewSession(&aws.Config{Region: aws.String(e.region)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS session: %w", err)
+		}
+		e.kms = kms.New(sess)
+	}
+	return e.kms, nil
+}
+
+// Encrypt encrypts data
+func (e *Encrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	source, key, wrapped := sourceEnv, e.key, []byte(nil)
+	if e.kmsKeyID != "" {
+		client, err := e.kmsClient()
+		if err != nil {
+			return nil, err
+		}
+		output, err := client.GenerateDataKey(&kms.GenerateDataKeyInput{
+			KeyId:   aws.String(e.kmsKeyID),
+			KeySpec: aws.String(kms.DataKeySpecAes256),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate KMS data key: %w", err)
+		}
+		source, key, wrapped = sourceKMS, output.Plaintext, output.CiphertextBlob
+	}
+	if key == nil {
+		return nil, fmt.Errorf("no encryption key: set %s or a KMS key", KeyEnv)
+	}
+	if len(wrapped) > 0xFFFF {
+		return nil, fmt.Errorf("wrapped data key too long: %d bytes", len(wrapped))
+	}
+
+	header := append([]byte(magic), source)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := append(append([]byte{}, header...), nonce...)
+	return gcm.Seal(out, nonce, plaintext, header), nil
+}
+
+// Decrypt decrypts data written by Encrypt
+func (e *Encrypter) Decrypt(data []byte) ([]byte, error) {
+	headerLen := len(magic) + 3
+	if len(data) < headerLen || string(data[:len(magic)]) != magic {
+		return nil, errors.New("not an encrypted report")
+	}
+	source := data[len(magic)]
+	wrappedLen := int(binary.BigEndian.Uint16(data[len(magic)+1:]))
+	if len(data) < headerLen+wrappedLen {
+		return nil, errors.New("truncated encrypted report")
+	}
+	wrapped := data[headerLen : headerLen+wrappedLen]
+	header := data[:headerLen+wrappedLen]
+
+	var key []byte
+	switch source {
+	case sourceEnv:
+		if e.key == nil {
+			return nil, fmt.Errorf("report was encrypted with a key from %s, which is not set", KeyEnv)
+		}
+		key = e.key
+	case sourceKMS:
+		client, err := e.kmsClient()
+		if err != nil {
+			return nil, err
+		}
+		output, err := client.Decrypt(&kms.DecryptInput{CiphertextBlob: wrapped})
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt KMS data key: %w", err)
+		}
+		key = output.Plaintext
+	default:
+		return nil, fmt.Errorf("unknown key source %d", source)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	rest := data[len(header):]
+	if len(rest) < gcm.NonceSize() {
+		return nil, errors.New("truncated encrypted report")
+	}
+	plaintext, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, errors.New("failed to decrypt report: wrong key or corrupted file")
+	}
+	return plaintext, nil
+}
+
+// EncryptFile replaces a file with its encrypted version, named with Extension appended
+// It returns the path of the encrypted file.
+func (e *Encrypter) EncryptFile(path string) (string, error) {
+	plaintext, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := e.Encrypt(plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	encryptedPath := path + Extension
+	if err := os.WriteFile(encryptedPath, ciphertext, 0600); err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	return encryptedPath, nil
+}
+
+// newGCM returns AES-GCM with a 256-bit key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
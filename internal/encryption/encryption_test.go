@@ -0,0 +1,158 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// fakeKMS wraps data keys by prefixing them with the key ID
+type fakeKMS struct {
+	generated int
+}
+
+func (f *fakeKMS) GenerateDataKey(input *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error) {
+	f.generated++
+	key := bytes.Repeat([]byte{byte(f.generated)}, 32)
+	return &kms.GenerateDataKeyOutput{
+		Plaintext:      key,
+		CiphertextBlob: append([]byte(aws.StringValue(input.KeyId)+":"), key...),
+	}, nil
+}
+
+func (f *fakeKMS) Decrypt(input *kms.DecryptInput) (*kms.DecryptOutput, error) {
+	blob := input.CiphertextBlob
+	return &kms.DecryptOutput{Plaintext: blob[bytes.IndexByte(blob, ':')+1:]}, nil
+}
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestEncryptDecrypt_EnvKey(t *testing.T) {
+	t.Setenv(KeyEnv, testKey(7))
+	e, err := New("", "")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	plaintext := []byte(`{"jobs":[{"job_name":"checkout"}]}`)
+	ciphertext, err := e.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if bytes.Contains(ciphertext, []byte("checkout")) {
+		t.Error("ciphertext contains the plaintext")
+	}
+	decrypted, err := e.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Decrypt() = %q, want %q", decrypted, plaintext)
+	}
+
+	// Another key, or a modified file, must not decrypt
+	t.Setenv(KeyEnv, testKey(8))
+	other, _ := New("", "")
+	if _, err := other.Decrypt(ciphertext); err == nil {
+		t.Error("Decrypt() with the wrong key should fail")
+	}
+	tampered := append([]byte{}, ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := e.Decrypt(tampered); err == nil {
+		t.Error("Decrypt() of a modified file should fail")
+	}
+}
+
+func TestEncryptDecrypt_KMS(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	fake := &fakeKMS{}
+	e, err := New("alias/reports", "eu-west-1")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	e.kms = fake
+
+	first, err := e.Encrypt([]byte("report"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if _, err := e.Encrypt([]byte("report")); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if fake.generated != 2 {
+		t.Errorf("generated %d data keys, want one per file", fake.generated)
+	}
+
+	// Decrypting needs only KMS access, not the key ID
+	d, _ := New("", "eu-west-1")
+	d.kms = fake
+	decrypted, err := d.Decrypt(first)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(decrypted) != "report" {
+		t.Errorf("Decrypt() = %q, want report", decrypted)
+	}
+}
+
+func TestNew_InvalidKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "not-base64!"},
+		{"wrong length", base64.StdEncoding.EncodeToString([]byte("short"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(KeyEnv, tt.value)
+			if _, err := New("", ""); err == nil {
+				t.Error("New() should reject the key")
+			}
+		})
+	}
+}
+
+func TestEncrypt_NoKey(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	e, err := New("", "")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := e.Encrypt([]byte("report")); err == nil || !strings.Contains(err.Error(), KeyEnv) {
+		t.Errorf("Encrypt() error = %v, want one naming %s", err, KeyEnv)
+	}
+}
+
+func TestEncryptFile(t *testing.T) {
+	t.Setenv(KeyEnv, testKey(1))
+	e, _ := New("", "")
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(`{"score":80}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	encryptedPath, err := e.EncryptFile(path)
+	if err != nil {
+		t.Fatalf("EncryptFile() error = %v", err)
+	}
+	if encryptedPath != path+Extension {
+		t.Errorf("EncryptFile() = %s, want %s", encryptedPath, path+Extension)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("plaintext file should be removed")
+	}
+	data, _ := os.ReadFile(encryptedPath)
+	decrypted, err := e.Decrypt(data)
+	if err != nil || string(decrypted) != `{"score":80}` {
+		t.Errorf("Decrypt() = %q, %v", decrypted, err)
+	}
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"instrumentation-score/internal/loaders"
+)
+
+// SeriesChurnDataSource is the data source telling how quickly each metric's series turn over
+// Its records come from SetSeriesChurn; without it the source is empty and rules using
+// it do not affect the score.
+const SeriesChurnDataSource = "series_churn"
+
+// SetSeriesChurn supplies the series churn of every job and metric, used by the series_churn data source
+func (e *RuleEngine) SetSeriesChurn(churn []loaders.SeriesChurnData) {
+	e.seriesChurn = make(map[string]map[string]loaders.SeriesChurnData)
+	for _, c := range churn {
+		if e.seriesChurn[c.Job] == nil {
+			e.seriesChurn[c.Job] = make(map[string]loaders.SeriesChurnData)
+		}
+		e.seriesChurn[c.Job][c.MetricName] = c
+	}
+}
+
+// buildSeriesChurnRecords exposes the churn of each metric of a job; metrics without churn data are left out
+func buildSeriesChurnRecords(jobData []loaders.JobMetricData, churn map[string]loaders.SeriesChurnData) []Record {
+	records := make([]Record, 0, len(jobData))
+	for _, jm := range jobData {
+		c, ok := churn[jm.MetricName]
+		if !ok {
+			continue
+		}
+		newPerHour := 0.0
+		if c.WindowSeconds > 0 {
+			newPerHour = float64(c.NewSeries) * 3600 / float64(c.WindowSeconds)
+		}
+		churnRatio := 0.0
+		if c.WindowSeries > 0 {
+			churnRatio = float64(c.NewSeries) / float64(c.WindowSeries)
+		}
+
+		records = append(records, Record{
+			MetricName: jm.MetricName,
+			Type:       jm.Type,
+			Fields: map[string]interface{}{
+				"active_series":       c.ActiveSeries,
+				"window_series":       c.WindowSeries,
+				"new_series":          c.NewSeries,
+				"new_series_per_hour": newPerHour,
+				"churn_ratio":         churnRatio,
+				"count":               jm.Cardinality,
+			},
+		})
+	}
+	return records
+}
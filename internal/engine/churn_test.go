@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+const seriesChurnRules = `
+rules:
+  - rule_id: "PROM-CHN-01"
+    impact: "Important"
+    validators:
+      - name: "series_churn_check"
+        type: "series_churn"
+        data_source: "series_churn"
+        conditions:
+          - field: "new_series_per_hour"
+            operator: "lte"
+            value: 1000
+`
+
+func TestEvaluateJob_SeriesChurn(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, seriesChurnRules))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	jobData := []loaders.JobMetricData{
+		{Job: "api", MetricName: "http_requests_total", Cardinality: 1000},
+		{Job: "api", MetricName: "sessions_active", Cardinality: 500},
+		{Job: "api", MetricName: "up", Cardinality: 2},
+	}
+
+	// Without series churn the rule has nothing to evaluate
+	results, err := ruleEngine.EvaluateJob(jobData)
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	if results[0].TotalMetrics != 0 {
+		t.Fatalf("TotalMetrics = %d without series churn, want 0", results[0].TotalMetrics)
+	}
+
+	ruleEngine.SetSeriesChurn([]loaders.SeriesChurnData{
+		{Job: "api", MetricName: "http_requests_total", ActiveSeries: 1000, WindowSeries: 1100, NewSeries: 100, WindowSeconds: 3600},
+		// 600 new series in 30 minutes is 1200 per hour
+		{Job: "api", MetricName: "sessions_active", ActiveSeries: 500, WindowSeries: 1100, NewSeries: 600, WindowSeconds: 1800},
+		{Job: "other", MetricName: "up", ActiveSeries: 1, WindowSeries: 5000, NewSeries: 5000, WindowSeconds: 3600},
+	})
+
+	results, err = ruleEngine.EvaluateJob(jobData)
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	result := results[0]
+	if result.TotalMetrics != 2 || result.PassedMetrics != 1 {
+		t.Errorf("passed %d/%d, want 1/2", result.PassedMetrics, result.TotalMetrics)
+	}
+	if _, ok := result.FailedMetrics["sessions_active"]; !ok || len(result.FailedMetrics) != 1 {
+		t.Errorf("FailedMetrics = %v, want only sessions_active", result.FailedMetrics)
+	}
+}
+
+func TestBuildSeriesChurnRecords(t *testing.T) {
+	records := buildSeriesChurnRecords(
+		[]loaders.JobMetricData{{MetricName: "requests_total", Type: "counter", Cardinality: 1000}},
+		map[string]loaders.SeriesChurnData{"requests_total": {ActiveSeries: 1000, WindowSeries: 4000, NewSeries: 3000, WindowSeconds: 7200}},
+	)
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	fields := records[0].Fields
+	if fields["new_series_per_hour"] != 1500.0 || fields["churn_ratio"] != 0.75 {
+		t.Errorf("new_series_per_hour = %v, churn_ratio = %v, want 1500 and 0.75", fields["new_series_per_hour"], fields["churn_ratio"])
+	}
+}
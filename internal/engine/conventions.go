@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// DefaultConventionPack is used for jobs no convention override matches
+const DefaultConventionPack = "prometheus"
+
+// ConventionPack adapts names from one instrumentation ecosystem before naming checks
+// Names are normalized the way that ecosystem's Prometheus exporter would translate them,
+// so format and labels validators judge the instrumentation rather than the export path.
+// Failed metrics are still reported under their original names.
+type ConventionPack struct {
+	Name        string
+	Description string
+	normalize   func(name string) string // nil keeps names as-is
+}
+
+// conventionPacks are the built-in packs, by name
+var conventionPacks = map[string]ConventionPack{
+	"prometheus": {
+		Name:        "prometheus",
+		Description: "Prometheus native names, checked as-is",
+	},
+	"otel": {
+		Name:        "otel",
+		Description: "OpenTelemetry semantic convention names (http.server.request.duration); dots and other separators become underscores",
+		normalize:   otelName,
+	},
+	"statsd": {
+		Name:        "statsd",
+		Description: "Legacy StatsD names (Api.Requests-Total); lowercased, with dots and dashes becoming underscores",
+		normalize:   statsdName,
+	},
+}
+
+var (
+	// invalidNameChars are characters the Prometheus translators replace with '_'
+	invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]+`)
+	// repeatedUnderscores are collapsed after translation
+	repeatedUnderscores = regexp.MustCompile(`__+`)
+)
+
+// otelName translates an OTel name as the collector's Prometheus exporter does
+func otelName(name string) string {
+	translated := invalidNameChars.ReplaceAllString(name, "_")
+	return strings.Trim(repeatedUnderscores.ReplaceAllString(translated, "_"), "_")
+}
+
+// statsdName translates a StatsD name as statsd_exporter's default mapping does, lowercased
+func statsdName(name string) string {
+	return otelName(strings.ToLower(name))
+}
+
+// ConventionPackNames returns the names of the built-in convention packs
+func ConventionPackNames() []string {
+	names := make([]string, 0, len(conventionPacks))
+	for name := range conventionPacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getConventionPack looks up a built-in pack
+func getConventionPack(name string) (ConventionPack, error) {
+	pack, ok := conventionPacks[name]
+	if !ok {
+		return ConventionPack{}, fmt.Errorf("unknown convention pack %q (available: %s)", name, strings.Join(ConventionPackNames(), ", "))
+	}
+	return pack, nil
+}
+
+// normalizeLabelsData returns metric with its name and label names normalized by the pack
+func (p ConventionPack) normalizeLabelsData(metric loaders.LabelsData) loaders.LabelsData {
+	if p.normalize == nil {
+		return metric
+	}
+	normalized := metric
+	normalized.MetricName = p.normalize(metric.MetricName)
+	normalized.Labels = make([]string, len(metric.Labels))
+	for i, label := range metric.Labels {
+		normalized.Labels[i] = p.normalize(label)
+	}
+	return normalized
+}
+
+// ConventionsConfig selects the convention pack per job
+type ConventionsConfig struct {
+	Pack string               `yaml:"pack,omitempty"` // Default pack (default: prometheus)
+	Jobs []ConventionOverride `yaml:"jobs,omitempty"` // Per-job packs; the first match wins
+}
+
+// ConventionOverride assigns a pack to jobs by exact name or regex pattern
+type ConventionOverride struct {
+	Job            string `yaml:"job,omitempty"`
+	JobNamePattern string `yaml:"job_name_pattern,omitempty"`
+	Pack           string `yaml:"pack"`
+}
+
+// conventionSelector resolves the pack of a job from a validated ConventionsConfig
+type conventionSelector struct {
+	defaultPack ConventionPack
+	overrides   []ConventionOverride
+	patterns    []*regexp.Regexp
+	packs       []ConventionPack
+}
+
+// newConventionSelector validates the config, resolving pack names and compiling patterns
+func newConventionSelector(config ConventionsConfig) (*conventionSelector, error) {
+	name := config.Pack
+	if name == "" {
+		name = DefaultConventionPack
+	}
+	defaultPack, err := getConventionPack(name)
+	if err != nil {
+		return nil, fmt.Errorf("conventions.pack: %w", err)
+	}
+
+	selector := &conventionSelector{defaultPack: defaultPack, overrides: config.Jobs}
+	for i, override := range config.Jobs {
+		pack, err := getConventionPack(override.Pack)
+		if err != nil {
+			return nil, fmt.Errorf("conventions.jobs[%d]: %w", i, err)
+		}
+		var pattern *regexp.Regexp
+		if override.JobNamePattern != "" {
+			pattern, err = regexp.Compile(override.JobNamePattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex pattern in conventions.jobs[%d]: %w", i, err)
+			}
+		} else if override.Job == "" {
+			return nil, fmt.Errorf("conventions.jobs[%d]: job or job_name_pattern is required", i)
+		}
+		selector.packs = append(selector.packs, pack)
+		selector.patterns = append(selector.patterns, pattern)
+	}
+	return selector, nil
+}
+
+// packFor returns the pack for a job
+func (s *conventionSelector) packFor(jobName string) ConventionPack {
+	for i, override := range s.overrides {
+		if override.Job != "" && override.Job == jobName {
+			return s.packs[i]
+		}
+		if s.patterns[i] != nil && s.patterns[i].MatchString(jobName) {
+			return s.packs[i]
+		}
+	}
+	return s.defaultPack
+}
@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestConventionPack_Normalize(t *testing.T) {
+	tests := []struct {
+		pack string
+		name string
+		want string
+	}{
+		{"prometheus", "http.server.request.duration", "http.server.request.duration"},
+		{"otel", "http.server.request.duration", "http_server_request_duration"},
+		{"otel", "http.request.method", "http_request_method"},
+		{"otel", "process.runtime..gc_count", "process_runtime_gc_count"},
+		{"otel", "httpRequests", "httpRequests"},
+		{"statsd", "Api.Requests-Total", "api_requests_total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pack+"/"+tt.name, func(t *testing.T) {
+			pack, err := getConventionPack(tt.pack)
+			if err != nil {
+				t.Fatalf("getConventionPack() error = %v", err)
+			}
+			got := pack.normalizeLabelsData(loaders.LabelsData{MetricName: tt.name, Labels: []string{tt.name}})
+			if got.MetricName != tt.want || got.Labels[0] != tt.want {
+				t.Errorf("normalize(%q) = %q, %v, want %q", tt.name, got.MetricName, got.Labels, tt.want)
+			}
+		})
+	}
+}
+
+const conventionRules = `
+conventions:
+  pack: prometheus
+  jobs:
+    - job_name_pattern: "^otel-"
+      pack: otel
+    - job: legacy-statsd
+      pack: statsd
+rules:
+- rule_id: "PROM-MET-01"
+  impact: "Important"
+  validators:
+    - name: "metric_format"
+      type: "format"
+      data_source: "labels"
+      conditions:
+        - field: "metric_name"
+          operator: "matches"
+          value: "^[a-z][a-z0-9_]*[a-z0-9]$"
+    - name: "label_format"
+      type: "labels"
+      data_source: "labels"
+      conditions:
+        - field: "labels"
+          operator: "snake_case"
+`
+
+func writeRules(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	return path
+}
+
+func TestEvaluateJob_ConventionPacks(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, conventionRules))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+
+	tests := []struct {
+		job        string
+		wantPack   string
+		wantFailed bool
+	}{
+		{"otel-checkout", "otel", false},
+		{"native-checkout", "prometheus", true},
+		{"legacy-statsd", "statsd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.job, func(t *testing.T) {
+			if pack := ruleEngine.ConventionPackFor(tt.job); pack.Name != tt.wantPack {
+				t.Errorf("ConventionPackFor(%q) = %s, want %s", tt.job, pack.Name, tt.wantPack)
+			}
+
+			jobData := []loaders.JobMetricData{{
+				Job:        tt.job,
+				MetricName: "http.server.request.duration",
+				Labels:     []string{"http.request.method", "job"},
+			}}
+			if tt.job == "legacy-statsd" {
+				jobData[0].MetricName = "Api.Requests-Total"
+			}
+
+			results, err := ruleEngine.EvaluateJob(jobData)
+			if err != nil {
+				t.Fatalf("EvaluateJob() error = %v", err)
+			}
+			failed := results[0].FailedMetrics
+			if got := len(failed) > 0; got != tt.wantFailed {
+				t.Errorf("failed metrics = %v, want failures: %v", failed, tt.wantFailed)
+			}
+			if tt.wantFailed && len(failed["http.server.request.duration"]) != 2 {
+				t.Errorf("expected failures reported under the original name, got %v", failed)
+			}
+		})
+	}
+}
+
+func TestSetConventionPack(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, conventionRules))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+
+	if err := ruleEngine.SetConventionPack("otel"); err != nil {
+		t.Fatalf("SetConventionPack() error = %v", err)
+	}
+	if pack := ruleEngine.ConventionPackFor("native-checkout"); pack.Name != "otel" {
+		t.Errorf("default pack = %s, want otel", pack.Name)
+	}
+	if pack := ruleEngine.ConventionPackFor("legacy-statsd"); pack.Name != "statsd" {
+		t.Errorf("override pack = %s, want statsd", pack.Name)
+	}
+	if err := ruleEngine.SetConventionPack("graphite"); err == nil {
+		t.Error("SetConventionPack() with unknown pack: expected error")
+	}
+}
+
+func TestNewRuleEngine_InvalidConventions(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{"unknown default pack", "conventions:\n  pack: graphite\nrules: []\n"},
+		{"unknown override pack", "conventions:\n  jobs:\n    - job: api\n      pack: graphite\nrules: []\n"},
+		{"missing job", "conventions:\n  jobs:\n    - pack: otel\nrules: []\n"},
+		{"invalid pattern", "conventions:\n  jobs:\n    - job_name_pattern: \"(\"\n      pack: otel\nrules: []\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRuleEngine(writeRules(t, tt.rules)); err == nil {
+				t.Error("NewRuleEngine() expected error")
+			}
+		})
+	}
+}
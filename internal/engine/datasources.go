@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// Record is a single metric entry of a custom data source
+// Conditions reference "metric_name" or any key in Fields
+type Record struct {
+	MetricName string
+	Type       string                 // Metric TYPE, used for applies_to filtering
+	Fields     map[string]interface{} // field name -> value (string, []string, bool, int, int64, float64)
+}
+
+// DataSourceBuilder derives a data source from a job's metrics
+// Built-in sources return []loaders.CardinalityData or []loaders.LabelsData;
+// custom sources return []Record and are evaluated generically.
+type DataSourceBuilder func(jobData []loaders.JobMetricData) (interface{}, error)
+
+// DataSourceFileLoader loads a data source from a standalone report file
+type DataSourceFileLoader func(filename string) (interface{}, error)
+
+// DataSource describes how a named data source is produced
+type DataSource struct {
+	Name     string
+	Build    DataSourceBuilder    // Required: build from per-job metric data
+	LoadFile DataSourceFileLoader // Optional: load from a standalone file (EvaluateRules)
+}
+
+// DataSourceRegistry holds the data sources validators can reference via data_source
+type DataSourceRegistry struct {
+	mu      sync.RWMutex
+	sources map[string]DataSource
+}
+
+// NewDataSourceRegistry creates a registry pre-populated with the built-in data sources
+func NewDataSourceRegistry() *DataSourceRegistry {
+	r := &DataSourceRegistry{sources: make(map[string]DataSource)}
+	r.Register(DataSource{
+		Name: "cardinality",
+		Build: func(jobData []loaders.JobMetricData) (interface{}, error) {
+			return loaders.ConvertJobMetricToCardinality(jobData), nil
+		},
+		LoadFile: func(filename string) (interface{}, error) {
+			return loaders.LoadCardinalityReport(filename)
+		},
+	})
+	r.Register(DataSource{
+		Name: "labels",
+		Build: func(jobData []loaders.JobMetricData) (interface{}, error) {
+			return loaders.ConvertJobMetricToLabels(jobData), nil
+		},
+		LoadFile: func(filename string) (interface{}, error) {
+			return loaders.LoadLabelsReport(filename)
+		},
+	})
+	r.Register(DataSource{
+		Name:  "metadata",
+		Build: buildMetadataRecords,
+	})
+	r.Register(DataSource{
+		Name:  "semconv",
+		Build: buildSemconvRecords,
+	})
+	r.Register(DataSource{
+		Name:  "otel_resource",
+		Build: buildOTelResourceRecords,
+	})
+	r.Register(DataSource{
+		Name: ScrapeHealthDataSource,
+		Build: func(jobData []loaders.JobMetricData) (interface{}, error) {
+			return []Record{}, nil // Filled from RuleEngine.SetScrapeHealth
+		},
+	})
+	r.Register(DataSource{
+		Name: MetricUsageDataSource,
+		Build: func(jobData []loaders.JobMetricData) (interface{}, error) {
+			return []Record{}, nil // Filled from RuleEngine.SetMetricUsage
+		},
+	})
+	r.Register(DataSource{
+		Name: SeriesChurnDataSource,
+		Build: func(jobData []loaders.JobMetricData) (interface{}, error) {
+			return []Record{}, nil // Filled from RuleEngine.SetSeriesChurn
+		},
+	})
+	return r
+}
+
+// Register adds or replaces a data source
+func (r *DataSourceRegistry) Register(source DataSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[source.Name] = source
+}
+
+// Get returns the data source registered under name
+func (r *DataSourceRegistry) Get(name string) (DataSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	source, ok := r.sources[name]
+	return source, ok
+}
+
+// Names returns the registered data source names in sorted order
+func (r *DataSourceRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.sources))
+	for name := range r.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildAll builds every registered data source from a job's metrics
+func (r *DataSourceRegistry) BuildAll(jobData []loaders.JobMetricData) (map[string]interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dataSources := make(map[string]interface{}, len(r.sources))
+	for name, source := range r.sources {
+		data, err := source.Build(jobData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build data source %s: %w", name, err)
+		}
+		dataSources[name] = data
+	}
+	return dataSources, nil
+}
+
+// defaultRegistry is shared by rule engines unless replaced, see NewRuleEngineWithRegistry
+var defaultRegistry = NewDataSourceRegistry()
+
+// RegisterDataSource adds a data source to the default registry
+// Call it from an init function so it is available before rules are loaded.
+func RegisterDataSource(source DataSource) {
+	defaultRegistry.Register(source)
+}
+
+// buildMetadataRecords exposes per-metric metadata (type, labels, cardinality) as generic records
+func buildMetadataRecords(jobData []loaders.JobMetricData) (interface{}, error) {
+	records := make([]Record, 0, len(jobData))
+	for _, jm := range jobData {
+		records = append(records, Record{
+			MetricName: jm.MetricName,
+			Type:       jm.Type,
+			Fields: map[string]interface{}{
+				"type":        jm.Type,
+				"labels":      jm.Labels,
+				"label_count": len(jm.Labels),
+				"count":       jm.Cardinality,
+			},
+		})
+	}
+	return records, nil
+}
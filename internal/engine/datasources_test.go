@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"os"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestDataSourceRegistry_BuiltIns(t *testing.T) {
+	registry := NewDataSourceRegistry()
+
+	for _, name := range []string{"cardinality", "labels", "metadata"} {
+		if _, ok := registry.Get(name); !ok {
+			t.Errorf("expected built-in data source %s to be registered", name)
+		}
+	}
+
+	jobData := []loaders.JobMetricData{
+		{Job: "api", MetricName: "http_requests_total", Labels: []string{"method"}, Cardinality: 10, Type: "counter"},
+	}
+
+	dataSources, err := registry.BuildAll(jobData)
+	if err != nil {
+		t.Fatalf("BuildAll() error = %v", err)
+	}
+	if _, ok := dataSources["cardinality"].([]loaders.CardinalityData); !ok {
+		t.Errorf("cardinality source has type %T", dataSources["cardinality"])
+	}
+	if _, ok := dataSources["labels"].([]loaders.LabelsData); !ok {
+		t.Errorf("labels source has type %T", dataSources["labels"])
+	}
+	records, ok := dataSources["metadata"].([]Record)
+	if !ok || len(records) != 1 || records[0].Fields["type"] != "counter" {
+		t.Errorf("unexpected metadata source: %#v", dataSources["metadata"])
+	}
+}
+
+func TestRuleEngine_CustomDataSource(t *testing.T) {
+	// A custom source that marks metrics as documented when their name is in a fixed set
+	documented := map[string]bool{"http_requests_total": true}
+	RegisterDataSource(DataSource{
+		Name: "test_docs",
+		Build: func(jobData []loaders.JobMetricData) (interface{}, error) {
+			var records []Record
+			for _, jm := range jobData {
+				records = append(records, Record{
+					MetricName: jm.MetricName,
+					Fields: map[string]interface{}{
+						"documented": documented[jm.MetricName],
+						"help_words": int64(len(jm.MetricName)),
+					},
+				})
+			}
+			return records, nil
+		},
+	})
+	defer func() {
+		defaultRegistry.mu.Lock()
+		delete(defaultRegistry.sources, "test_docs")
+		defaultRegistry.mu.Unlock()
+	}()
+
+	rulesContent := `
+rules:
+- rule_id: "TEST-DOC-01"
+  description: "Metrics must be documented"
+  impact: "Normal"
+  validators:
+    - name: "documented_check"
+      type: "documentation"
+      data_source: "test_docs"
+      conditions:
+        - field: "documented"
+          operator: "eq"
+          value: true
+`
+	tmpRulesFile, err := os.CreateTemp("", "test_rules_*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp rules file: %v", err)
+	}
+	defer os.Remove(tmpRulesFile.Name())
+	if _, err := tmpRulesFile.WriteString(rulesContent); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	tmpRulesFile.Close()
+
+	engine, err := NewRuleEngine(tmpRulesFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	results, err := engine.EvaluateJob([]loaders.JobMetricData{
+		{Job: "api", MetricName: "http_requests_total"},
+		{Job: "api", MetricName: "undocumented_metric"},
+	})
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+
+	if results[0].PassedMetrics != 1 || results[0].TotalMetrics != 2 {
+		t.Errorf("Expected 1/2 passed, got %d/%d", results[0].PassedMetrics, results[0].TotalMetrics)
+	}
+	if _, failed := results[0].FailedMetrics["undocumented_metric"]; !failed {
+		t.Errorf("Expected undocumented_metric to fail, got %v", results[0].FailedMetrics)
+	}
+}
+
+func TestNewRuleEngine_UnknownDataSource(t *testing.T) {
+	rulesContent := `
+rules:
+- rule_id: "TEST-BAD-01"
+  impact: "Normal"
+  validators:
+    - name: "bad_source"
+      type: "format"
+      data_source: "does_not_exist"
+`
+	tmpRulesFile, err := os.CreateTemp("", "test_rules_*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp rules file: %v", err)
+	}
+	defer os.Remove(tmpRulesFile.Name())
+	if _, err := tmpRulesFile.WriteString(rulesContent); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	tmpRulesFile.Close()
+
+	if _, err := NewRuleEngine(tmpRulesFile.Name()); err == nil {
+		t.Error("Expected error for unknown data source")
+	}
+}
+
+func TestNewRuleEngineWithRegistry(t *testing.T) {
+	registry := NewDataSourceRegistry()
+	registry.Register(DataSource{
+		Name: "test_owned",
+		Build: func(jobData []loaders.JobMetricData) (interface{}, error) {
+			var records []Record
+			for _, jm := range jobData {
+				records = append(records, Record{
+					MetricName: jm.MetricName,
+					Fields:     map[string]interface{}{"owned": jm.MetricName != "orphan_total"},
+				})
+			}
+			return records, nil
+		},
+	})
+	rulesFile := writeRules(t, `
+rules:
+- rule_id: "TEST-OWN-01"
+  impact: "Normal"
+  validators:
+    - name: "owned_check"
+      type: "ownership"
+      data_source: "test_owned"
+      conditions:
+        - field: "owned"
+          operator: "eq"
+          value: true
+`)
+
+	// The source is only in the custom registry, not the default one
+	if _, err := NewRuleEngine(rulesFile); err == nil {
+		t.Error("NewRuleEngine() accepted a data source missing from the default registry")
+	}
+	ruleEngine, err := NewRuleEngineWithRegistry(rulesFile, registry)
+	if err != nil {
+		t.Fatalf("NewRuleEngineWithRegistry() error = %v", err)
+	}
+	results, err := ruleEngine.EvaluateJob([]loaders.JobMetricData{
+		{Job: "api", MetricName: "http_requests_total"},
+		{Job: "api", MetricName: "orphan_total"},
+	})
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	if _, failed := results[0].FailedMetrics["orphan_total"]; !failed || results[0].PassedMetrics != 1 {
+		t.Errorf("EvaluateJob() = %+v, want orphan_total failing", results[0])
+	}
+
+	if err := ruleEngine.SetDataSourceRegistry(NewDataSourceRegistry()); err == nil {
+		t.Error("SetDataSourceRegistry() accepted a registry without test_owned")
+	}
+	if err := ruleEngine.SetDataSourceRegistry(registry); err != nil {
+		t.Errorf("SetDataSourceRegistry() error = %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"sort"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// StreakFunc returns for how many consecutive runs, including this one, a metric failed a rule
+type StreakFunc func(ruleID, metricName string) int
+
+// ApplyScoreDecay makes chronic failures weigh more heavily in the score
+// A metric that failed a rule in at least minRuns consecutive runs counts weight times
+// as a failure instead of once, so long-standing issues lower the score more than new
+// ones. Rules scored by cardinality are penalized by the metric's series count instead.
+// Failures exempted by a waiver (see ApplyWaivers) are not penalized.
+func ApplyScoreDecay(results []RuleResult, jobData []loaders.JobMetricData, streak StreakFunc, minRuns, weight int) {
+	if minRuns <= 0 || weight <= 1 {
+		return
+	}
+	cardinality := make(map[string]int64, len(jobData))
+	for _, jm := range jobData {
+		cardinality[jm.MetricName] += jm.Cardinality
+	}
+
+	for i := range results {
+		result := &results[i]
+		result.ChronicMetrics = nil
+		result.DecayPenalty = 0
+		exempt := exemptMetrics(*result)
+		for metricName := range result.FailedMetrics {
+			if exempt[metricName] || streak(result.RuleID, metricName) < minRuns {
+				continue
+			}
+			result.ChronicMetrics = append(result.ChronicMetrics, metricName)
+			if result.TotalCardinality > 0 {
+				result.DecayPenalty += int64(weight-1) * cardinality[metricName]
+			} else {
+				result.DecayPenalty += int64(weight - 1)
+			}
+		}
+		sort.Strings(result.ChronicMetrics)
+	}
+}
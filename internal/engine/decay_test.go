@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestApplyScoreDecay(t *testing.T) {
+	jobData := []loaders.JobMetricData{
+		{MetricName: "old_failure", Cardinality: 100},
+		{MetricName: "new_failure", Cardinality: 10},
+		{MetricName: "passing", Cardinality: 90},
+	}
+	streaks := map[string]int{"PROM-MET-01/old_failure": 5, "PROM-MET-02/old_failure": 3, "PROM-MET-01/new_failure": 1}
+	streak := func(ruleID, metricName string) int { return streaks[ruleID+"/"+metricName] }
+
+	newResults := func() []RuleResult {
+		return []RuleResult{
+			{
+				RuleID: "PROM-MET-01", Impact: "Important",
+				FailedMetrics: map[string][]string{"old_failure": {"v"}, "new_failure": {"v"}},
+				PassedMetrics: 1, TotalMetrics: 3,
+			},
+			{
+				RuleID: "PROM-MET-02", Impact: "Critical",
+				FailedMetrics:     map[string][]string{"old_failure": {"v"}},
+				PassedCardinality: 100, TotalCardinality: 200,
+			},
+		}
+	}
+
+	results := newResults()
+	ApplyScoreDecay(results, jobData, streak, 3, 2)
+	if !reflect.DeepEqual(results[0].ChronicMetrics, []string{"old_failure"}) || results[0].DecayPenalty != 1 {
+		t.Errorf("metric-scored rule: chronic %v penalty %d, want [old_failure] 1", results[0].ChronicMetrics, results[0].DecayPenalty)
+	}
+	if results[1].DecayPenalty != 100 {
+		t.Errorf("cardinality-scored rule: penalty %d, want 100 series", results[1].DecayPenalty)
+	}
+	if decayed, plain := CalculateInstrumentationScore(results), CalculateInstrumentationScore(newResults()); decayed >= plain {
+		t.Errorf("decayed score %.2f, want below %.2f", decayed, plain)
+	}
+
+	// Disabled decay leaves results untouched
+	results = newResults()
+	ApplyScoreDecay(results, jobData, streak, 0, 2)
+	if results[0].DecayPenalty != 0 || results[0].ChronicMetrics != nil {
+		t.Errorf("decay applied with minRuns 0: %+v", results[0])
+	}
+}
@@ -1,14 +1,21 @@
 package engine
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	"instrumentation-score/internal/loaders"
-
-	"gopkg.in/yaml.v3"
+	"instrumentation-score/internal/usage"
 )
 
 // RuleResult represents the result of evaluating a rule
@@ -24,6 +31,12 @@ type RuleResult struct {
 	PassedCardinality int64               // Total cardinality of passed metrics (for weighted scoring)
 	TotalCardinality  int64               // Total cardinality of all metrics (for weighted scoring)
 	ValidatorStats    []ValidatorStat     // Detailed stats per validator
+	Errors            []string            `json:",omitempty"` // Validators that could not be evaluated (excluded from the counts above)
+	ChronicMetrics    []string            `json:",omitempty"` // Failed metrics that also failed the previous runs, see ApplyScoreDecay
+	DecayPenalty      int64               `json:",omitempty"` // Extra failed metrics (or series) chronic failures add to the total
+	Acknowledged      []Acknowledgement   `json:",omitempty"` // Failed metrics with an active waiver, see ApplyWaivers
+	WaivedCredit      int64               `json:",omitempty"` // Failed metrics (or series) exempt waivers count as passed
+	SpecRuleIDs       []string            `json:",omitempty"` // Specification rules the rule implements, see RuleDefinition
 }
 
 // ValidatorStat tracks pass/fail statistics for a single validator
@@ -32,8 +45,13 @@ type ValidatorStat struct {
 	PassedMetrics int
 	TotalMetrics  int
 	PassRate      float64
-	UITitle       string // Display title for UI
-	UIDescription string // Description for UI
+	UI            ValidatorUI // How reports present the validator's failures
+}
+
+// jobContext is what rule evaluation knows about the job being evaluated
+type jobContext struct {
+	name string         // "" when evaluating standalone report files
+	pack ConventionPack // Naming checks see metric and label names normalized by pack
 }
 
 // RuleEngine evaluates rules based on declarative definitions
@@ -41,18 +59,74 @@ type RuleEngine struct {
 	rules             []RuleDefinition
 	exclusionList     []ExclusionEntry
 	exclusionPatterns []*regexp.Regexp
+	inclusionList     []InclusionEntry
+	inclusionPatterns []*regexp.Regexp
+	registry          *DataSourceRegistry
+	conventions       *conventionSelector
+	scrapeHealth      map[string][]loaders.ScrapeHealthData         // job -> targets, see SetScrapeHealth
+	metricUsage       *usage.Index                                  // see SetMetricUsage
+	seriesChurn       map[string]map[string]loaders.SeriesChurnData // job -> metric -> churn, see SetSeriesChurn
+	rulesHash         string                                        // see RulesHash
 }
 
 // NewRuleEngine creates a new rule engine from a YAML rules file
 func NewRuleEngine(rulesFile string) (*RuleEngine, error) {
-	data, err := os.ReadFile(rulesFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	return newRuleEngine(rulesFile, false, osReadFile, resolveRelative, defaultRegistry)
+}
+
+// NewRuleEngineWithRegistry creates a rule engine whose validators reference the data sources
+// of registry instead of the default registry
+func NewRuleEngineWithRegistry(rulesFile string, registry *DataSourceRegistry) (*RuleEngine, error) {
+	return newRuleEngine(rulesFile, false, osReadFile, resolveRelative, registry)
+}
+
+// osReadFile reads rules files from disk
+var osReadFile = os.ReadFile
+
+// resolveRelative resolves an include path relative to the directory of the including rules file
+func resolveRelative(rulesFile, include string) string {
+	if filepath.IsAbs(include) {
+		return include
 	}
+	return filepath.Join(filepath.Dir(rulesFile), include)
+}
 
-	var config RulesConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal rules: %w", err)
+// NewRuleEngineFS creates a rule engine from a rules file in fsys, such as the rules built into the binary
+// Included packs are read from fsys too, relative to the rules file.
+func NewRuleEngineFS(fsys fs.FS, rulesFile string) (*RuleEngine, error) {
+	readFile := func(name string) ([]byte, error) { return fs.ReadFile(fsys, name) }
+	return newRuleEngine(rulesFile, false, readFile, func(rulesFile, include string) string {
+		return path.Join(path.Dir(rulesFile), include)
+	}, defaultRegistry)
+}
+
+// NewRuleEngineBytes creates a rule engine from a rules document, such as one posted to serve
+// Included packs are read from packs, relative to its root; nil allows no includes, so a
+// document from an untrusted source never reads the local disk.
+func NewRuleEngineBytes(data []byte, packs fs.FS) (*RuleEngine, error) {
+	const document = "(rules document)"
+	readFile := func(name string) ([]byte, error) {
+		if name == document {
+			return data, nil
+		}
+		if packs == nil {
+			return nil, errors.New("rule packs cannot be included")
+		}
+		return fs.ReadFile(packs, name)
+	}
+	return newRuleEngine(document, false, readFile, func(rulesFile, include string) string {
+		return path.Clean(include)
+	}, defaultRegistry)
+}
+
+// newRuleEngine loads rulesFile through readFile, resolving the paths of included packs with resolve
+// In strict mode unknown fields are errors, see NewRuleEngineStrict. Validators must reference
+// data sources of registry.
+func newRuleEngine(rulesFile string, strict bool, readFile func(string) ([]byte, error), resolve func(rulesFile, include string) string,
+	registry *DataSourceRegistry) (*RuleEngine, error) {
+	config, err := loadRulesConfig(rulesFile, strict, readFile, resolve)
+	if err != nil {
+		return nil, err
 	}
 
 	// Compile regex patterns for job name matching
@@ -68,16 +142,156 @@ func NewRuleEngine(rulesFile string) (*RuleEngine, error) {
 			patterns = append(patterns, nil)
 		}
 	}
+	var inclusionPatterns []*regexp.Regexp
+	for i, inclusion := range config.InclusionList {
+		if inclusion.Job == "" && inclusion.JobNamePattern == "" {
+			return nil, fmt.Errorf("inclusion_list[%d] needs a job or job_name_pattern", i)
+		}
+		var pattern *regexp.Regexp
+		if inclusion.JobNamePattern != "" {
+			if pattern, err = regexp.Compile(inclusion.JobNamePattern); err != nil {
+				return nil, fmt.Errorf("invalid regex pattern in inclusion_list[%d]: %w", i, err)
+			}
+		}
+		inclusionPatterns = append(inclusionPatterns, pattern)
+	}
+
+	if err := checkDataSources(config.Rules, registry); err != nil {
+		return nil, err
+	}
+
+	if err := compileRequiredMetrics(config.Rules); err != nil {
+		return nil, err
+	}
+
+	conventions, err := newConventionSelector(config.Conventions)
+	if err != nil {
+		return nil, err
+	}
 
 	return &RuleEngine{
 		rules:             config.Rules,
 		exclusionList:     config.ExclusionList,
 		exclusionPatterns: patterns,
+		inclusionList:     config.InclusionList,
+		inclusionPatterns: inclusionPatterns,
+		registry:          registry,
+		conventions:       conventions,
+		rulesHash:         hashRulesConfig(config),
 	}, nil
 }
 
+// checkDataSources checks that every validator references a data source of registry
+func checkDataSources(rules []RuleDefinition, registry *DataSourceRegistry) error {
+	for _, rule := range rules {
+		for _, validator := range rule.Validators {
+			if _, ok := registry.Get(validator.DataSource); !ok {
+				return fmt.Errorf("rule %s validator %s references unknown data source %q (available: %s)",
+					rule.RuleID, validator.Name, validator.DataSource, strings.Join(registry.Names(), ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// hashRulesConfig returns the sha256 of the effective rules configuration
+// Hashing the parsed config rather than the files ignores comments and formatting.
+func hashRulesConfig(config RulesConfig) string {
+	config.Include = nil // Their rules are merged in already
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RulesHash identifies the effective rules: equal hashes mean the same rules, exclusions and conventions
+func (e *RuleEngine) RulesHash() string {
+	return e.rulesHash
+}
+
+// Rules returns the effective rules, included packs merged
+func (e *RuleEngine) Rules() []RuleDefinition {
+	return e.rules
+}
+
+// Exclusions returns the effective exclusion list, included packs merged
+func (e *RuleEngine) Exclusions() []ExclusionEntry {
+	return e.exclusionList
+}
+
+// Inclusions returns the effective inclusion list, included packs merged; empty when every job is evaluated
+func (e *RuleEngine) Inclusions() []InclusionEntry {
+	return e.inclusionList
+}
+
+// loadRulesConfig reads a rules file and merges the rule packs it includes
+// Include paths are relative to the including file; packs contribute rules and
+// inclusions and exclusions but cannot include further packs or set conventions.
+func loadRulesConfig(rulesFile string, strict bool, readFile func(string) ([]byte, error), resolve func(rulesFile, include string) string) (RulesConfig, error) {
+	var config RulesConfig
+	data, err := readFile(rulesFile)
+	if err != nil {
+		return config, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	if err := decodeRulesConfig(data, &config, strict); err != nil {
+		return config, fmt.Errorf("failed to unmarshal rules: %w", err)
+	}
+
+	for _, include := range config.Include {
+		packData, err := readFile(resolve(rulesFile, include))
+		if err != nil {
+			return config, fmt.Errorf("failed to read included rule pack %s: %w", include, err)
+		}
+		var pack RulesConfig
+		if err := decodeRulesConfig(packData, &pack, strict); err != nil {
+			return config, fmt.Errorf("failed to unmarshal included rule pack %s: %w", include, err)
+		}
+		if len(pack.Include) > 0 {
+			return config, fmt.Errorf("included rule pack %s cannot include other packs", include)
+		}
+		if pack.Conventions.Pack != "" || len(pack.Conventions.Jobs) > 0 {
+			return config, fmt.Errorf("included rule pack %s cannot set conventions", include)
+		}
+		config.InclusionList = append(config.InclusionList, pack.InclusionList...)
+		config.ExclusionList = append(config.ExclusionList, pack.ExclusionList...)
+		config.Rules = append(config.Rules, pack.Rules...)
+	}
+	return config, nil
+}
+
+// SetConventionPack replaces the default convention pack; per-job overrides still apply
+func (e *RuleEngine) SetConventionPack(name string) error {
+	pack, err := getConventionPack(name)
+	if err != nil {
+		return err
+	}
+	e.conventions.defaultPack = pack
+	return nil
+}
+
+// ConventionPackFor returns the convention pack used for a job's naming checks
+func (e *RuleEngine) ConventionPackFor(jobName string) ConventionPack {
+	return e.conventions.packFor(jobName)
+}
+
+// SetDataSourceRegistry replaces the registry used to build data sources for this engine
+// The registry is left unchanged when a validator references a data source it lacks.
+func (e *RuleEngine) SetDataSourceRegistry(registry *DataSourceRegistry) error {
+	if err := checkDataSources(e.rules, registry); err != nil {
+		return err
+	}
+	e.registry = registry
+	return nil
+}
+
 // IsJobExcluded checks if a job is completely excluded
+// With an inclusion list, jobs it does not match are excluded too.
 func (e *RuleEngine) IsJobExcluded(jobName string) bool {
+	if !e.IsJobIncluded(jobName) {
+		return true
+	}
 	for i, exclusion := range e.exclusionList {
 		// Check exact job name match
 		if exclusion.Job != "" && exclusion.Job == jobName && len(exclusion.Metrics) == 0 {
@@ -93,6 +307,22 @@ func (e *RuleEngine) IsJobExcluded(jobName string) bool {
 	return false
 }
 
+// IsJobIncluded checks if a job matches the inclusion list; every job does when it is empty
+func (e *RuleEngine) IsJobIncluded(jobName string) bool {
+	if len(e.inclusionList) == 0 {
+		return true
+	}
+	for i, inclusion := range e.inclusionList {
+		if inclusion.Job != "" && inclusion.Job == jobName {
+			return true
+		}
+		if e.inclusionPatterns[i] != nil && e.inclusionPatterns[i].MatchString(jobName) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsMetricExcluded checks if a specific metric is excluded for a job
 func (e *RuleEngine) IsMetricExcluded(jobName, metricName string) bool {
 	for i, exclusion := range e.exclusionList {
@@ -148,27 +378,62 @@ func (e *RuleEngine) FilterExcludedMetrics(jobName string, cardinalityData []loa
 	return filteredCardinality, filteredLabels
 }
 
+// FilterExcludedJobData filters out excluded metrics from a job's metric data
+func (e *RuleEngine) FilterExcludedJobData(jobName string, jobData []loaders.JobMetricData) []loaders.JobMetricData {
+	var filtered []loaders.JobMetricData
+	for _, data := range jobData {
+		if !e.IsMetricExcluded(jobName, data.MetricName) {
+			filtered = append(filtered, data)
+		}
+	}
+	return filtered
+}
+
 // EvaluateRules evaluates all rules against the provided data
+// dataFiles maps data source names to files; the source must support loading from a file.
 func (e *RuleEngine) EvaluateRules(dataFiles map[string]string) ([]RuleResult, error) {
 	dataSources := make(map[string]interface{})
 	for key, file := range dataFiles {
-		switch key {
-		case "cardinality":
-			data, err := loaders.LoadCardinalityReport(file)
-			if err != nil {
-				return nil, fmt.Errorf("failed to load cardinality data: %w", err)
-			}
-			dataSources["cardinality"] = data
-		case "labels":
-			data, err := loaders.LoadLabelsReport(file)
-			if err != nil {
-				return nil, fmt.Errorf("failed to load labels data: %w", err)
-			}
-			dataSources["labels"] = data
+		source, ok := e.registry.Get(key)
+		if !ok || source.LoadFile == nil {
+			continue
+		}
+		data, err := source.LoadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s data: %w", key, err)
 		}
+		dataSources[key] = data
+	}
+
+	return e.evaluateWithDataSources(context.Background(), dataSources, jobContext{pack: e.conventions.defaultPack})
+}
+
+// EvaluateJob builds every registered data source from a job's metrics and evaluates all rules
+func (e *RuleEngine) EvaluateJob(jobData []loaders.JobMetricData) ([]RuleResult, error) {
+	return e.EvaluateJobContext(context.Background(), jobData)
+}
+
+// EvaluateJobContext is EvaluateJob stopping between rules once ctx is done, with ctx's error
+func (e *RuleEngine) EvaluateJobContext(ctx context.Context, jobData []loaders.JobMetricData) ([]RuleResult, error) {
+	dataSources, err := e.registry.BuildAll(jobData)
+	if err != nil {
+		return nil, err
 	}
 
-	return e.evaluateWithDataSources(dataSources)
+	job := jobContext{pack: e.conventions.defaultPack}
+	if len(jobData) > 0 {
+		job = jobContext{name: jobData[0].Job, pack: e.ConventionPackFor(jobData[0].Job)}
+		if health, ok := e.scrapeHealth[job.name]; ok {
+			dataSources[ScrapeHealthDataSource] = buildScrapeHealthRecords(health)
+		}
+		if e.metricUsage != nil {
+			dataSources[MetricUsageDataSource] = buildMetricUsageRecords(jobData, e.metricUsage)
+		}
+		if churn, ok := e.seriesChurn[job.name]; ok {
+			dataSources[SeriesChurnDataSource] = buildSeriesChurnRecords(jobData, churn)
+		}
+	}
+	return e.evaluateWithDataSources(ctx, dataSources, job)
 }
 
 // EvaluateWithData evaluates rules using in-memory data instead of files
@@ -177,28 +442,43 @@ func (e *RuleEngine) EvaluateWithData(cardinalityData []loaders.CardinalityData,
 	dataSources["cardinality"] = cardinalityData
 	dataSources["labels"] = labelsData
 
-	return e.evaluateWithDataSources(dataSources)
+	return e.evaluateWithDataSources(context.Background(), dataSources, jobContext{pack: e.conventions.defaultPack})
 }
 
-func (e *RuleEngine) evaluateWithDataSources(dataSources map[string]interface{}) ([]RuleResult, error) {
+// evaluateWithDataSources evaluates every rule, continuing past validators that fail
+// Returns the (possibly partial) results and an EvaluationErrors error when any validator failed,
+// or no results and ctx's error once ctx is done.
+func (e *RuleEngine) evaluateWithDataSources(ctx context.Context, dataSources map[string]interface{}, job jobContext) ([]RuleResult, error) {
 	var results []RuleResult
+	var evalErrors EvaluationErrors
 
 	for _, rule := range e.rules {
-		result, err := e.evaluateRule(rule, dataSources)
-		if err != nil {
-			return nil, fmt.Errorf("failed to evaluate rule %s: %w", rule.RuleID, err)
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
+		result, ruleErrors := e.evaluateRule(rule, dataSources, job)
 		results = append(results, result)
+		evalErrors = append(evalErrors, ruleErrors...)
 	}
 
+	if len(evalErrors) > 0 {
+		return results, evalErrors
+	}
 	return results, nil
 }
 
 // evaluateRule evaluates a single rule
-func (e *RuleEngine) evaluateRule(rule RuleDefinition, dataSources map[string]interface{}) (RuleResult, error) {
+// Validators that fail are reported and count as failing every metric the rule applies to,
+// so a broken validator cannot raise the score.
+func (e *RuleEngine) evaluateRule(rule RuleDefinition, dataSources map[string]interface{}, job jobContext) (RuleResult, []*EvaluationError) {
+	if len(rule.AppliesTo) > 0 {
+		dataSources = filterDataSourcesByType(dataSources, rule.AppliesTo)
+	}
+
 	result := RuleResult{
 		RuleID:            rule.RuleID,
 		Impact:            rule.Impact,
+		SpecRuleIDs:       rule.SpecRuleIDs,
 		PassedChecks:      0,
 		TotalChecks:       len(rule.Validators),
 		FailedChecks:      []string{},
@@ -210,10 +490,20 @@ func (e *RuleEngine) evaluateRule(rule RuleDefinition, dataSources map[string]in
 		ValidatorStats:    []ValidatorStat{},
 	}
 
+	var evalErrors []*EvaluationError
 	for _, validator := range rule.Validators {
-		passedCount, totalCount, failedMetrics, passedCard, totalCard, err := e.evaluateValidatorWithStats(validator, dataSources)
+		passedCount, totalCount, failedMetrics, passedCard, totalCard, err := e.evaluateValidatorWithStats(validator, dataSources, job)
 		if err != nil {
-			return result, fmt.Errorf("validator %s failed: %w", validator.Name, err)
+			evalErr := &EvaluationError{
+				RuleID:     rule.RuleID,
+				Validator:  validator.Name,
+				DataSource: validator.DataSource,
+				Err:        err,
+			}
+			evalErrors = append(evalErrors, evalErr)
+			result.Errors = append(result.Errors, evalErr.Error())
+			failValidator(&result, validator, dataSources)
+			continue
 		}
 
 		passRate := 0.0
@@ -226,8 +516,7 @@ func (e *RuleEngine) evaluateRule(rule RuleDefinition, dataSources map[string]in
 			PassedMetrics: passedCount,
 			TotalMetrics:  totalCount,
 			PassRate:      passRate,
-			UITitle:       validator.UITitle,
-			UIDescription: validator.UIDescription,
+			UI:            validator.Metadata(rule),
 		})
 
 		result.PassedMetrics += passedCount
@@ -244,7 +533,66 @@ func (e *RuleEngine) evaluateRule(rule RuleDefinition, dataSources map[string]in
 		}
 	}
 
-	return result, nil
+	return result, evalErrors
+}
+
+// failValidator counts a validator that could not be evaluated as failing every metric of the
+// job, after applies_to filtering, in the cardinality units of a cardinality validator. It
+// has no ValidatorStats, which are of the validators evaluated.
+func failValidator(result *RuleResult, validator ValidatorConfig, dataSources map[string]interface{}) {
+	metrics, _ := dataSources["cardinality"].([]loaders.CardinalityData)
+	result.TotalMetrics += len(metrics)
+	if len(metrics) > 0 {
+		result.FailedChecks = append(result.FailedChecks, validator.Name)
+	}
+	for _, metric := range metrics {
+		result.FailedMetrics[metric.MetricName] = append(result.FailedMetrics[metric.MetricName], validator.Name)
+		if validator.Type == "cardinality" {
+			result.TotalCardinality += metric.Count
+		}
+	}
+}
+
+// filterDataSourcesByType keeps only metrics whose TYPE is one of metricTypes
+// Metrics with unknown type are dropped, since the rule cannot be shown to apply to them
+func filterDataSourcesByType(dataSources map[string]interface{}, metricTypes []string) map[string]interface{} {
+	allowed := make(map[string]bool, len(metricTypes))
+	for _, t := range metricTypes {
+		allowed[strings.ToLower(t)] = true
+	}
+
+	filtered := make(map[string]interface{}, len(dataSources))
+	for name, data := range dataSources {
+		switch d := data.(type) {
+		case []loaders.CardinalityData:
+			kept := []loaders.CardinalityData{}
+			for _, metric := range d {
+				if allowed[strings.ToLower(metric.Type)] {
+					kept = append(kept, metric)
+				}
+			}
+			filtered[name] = kept
+		case []loaders.LabelsData:
+			kept := []loaders.LabelsData{}
+			for _, metric := range d {
+				if allowed[strings.ToLower(metric.Type)] {
+					kept = append(kept, metric)
+				}
+			}
+			filtered[name] = kept
+		case []Record:
+			kept := []Record{}
+			for _, record := range d {
+				if allowed[strings.ToLower(record.Type)] {
+					kept = append(kept, record)
+				}
+			}
+			filtered[name] = kept
+		default:
+			filtered[name] = data
+		}
+	}
+	return filtered
 }
 
 // ValidatorResult contains the results of evaluating a validator
@@ -257,12 +605,24 @@ type ValidatorResult struct {
 }
 
 // evaluateValidatorWithStats evaluates a validator and returns pass/fail statistics
-func (e *RuleEngine) evaluateValidatorWithStats(validator ValidatorConfig, dataSources map[string]interface{}) (int, int, []string, int64, int64, error) {
+func (e *RuleEngine) evaluateValidatorWithStats(validator ValidatorConfig, dataSources map[string]interface{}, job jobContext) (int, int, []string, int64, int64, error) {
 	data := dataSources[validator.DataSource]
 	if data == nil {
 		return 0, 0, nil, 0, 0, fmt.Errorf("data source %s not found", validator.DataSource)
 	}
 
+	// Required metrics judge which metrics exist rather than the metrics themselves
+	if validator.Type == "required_metrics" {
+		passed, total, missing, err := evaluateRequiredMetrics(validator, data, job.name)
+		return passed, total, missing, 0, 0, err
+	}
+
+	// Custom data sources are evaluated generically regardless of validator type
+	if records, ok := data.([]Record); ok {
+		passed, total, failed, err := evaluateMetrics(records, validator, e.evaluateRecord)
+		return passed, total, failed, 0, 0, err
+	}
+
 	switch validator.Type {
 	case "cardinality":
 		cardinalityData, ok := data.([]loaders.CardinalityData)
@@ -276,14 +636,25 @@ func (e *RuleEngine) evaluateValidatorWithStats(validator ValidatorConfig, dataS
 		if !ok {
 			return 0, 0, nil, 0, 0, fmt.Errorf("format validator requires labels data source")
 		}
-		passed, total, failed, err := evaluateMetrics(labelsData, validator, e.evaluateLabelsMetric)
+		passed, total, failed, err := evaluateMetrics(labelsData, validator, e.conventionEvaluator(job.pack))
 		return passed, total, failed, 0, 0, err
 	case "labels", "label_count":
 		labelsData, ok := data.([]loaders.LabelsData)
 		if !ok {
 			return 0, 0, nil, 0, 0, fmt.Errorf("invalid data type for %s validator", validator.Type)
 		}
-		passed, total, failed, err := evaluateMetrics(labelsData, validator, e.evaluateLabelsMetric)
+		evaluator := e.evaluateLabelsMetric
+		if validator.Type == "labels" {
+			evaluator = e.conventionEvaluator(job.pack)
+		}
+		passed, total, failed, err := evaluateMetrics(labelsData, validator, evaluator)
+		return passed, total, failed, 0, 0, err
+	case "label_values":
+		labelsData, ok := data.([]loaders.LabelsData)
+		if !ok {
+			return 0, 0, nil, 0, 0, fmt.Errorf("label_values validator requires labels data source")
+		}
+		passed, total, failed, err := e.evaluateLabelValues(labelsData, validator)
 		return passed, total, failed, 0, 0, err
 	default:
 		return 0, 0, nil, 0, 0, fmt.Errorf("unknown validator type: %s", validator.Type)
@@ -309,6 +680,8 @@ func evaluateMetrics[T any](data []T, validator ValidatorConfig, evaluator Metri
 				metricName = m.MetricName
 			case loaders.LabelsData:
 				metricName = m.MetricName
+			case Record:
+				metricName = m.MetricName
 			}
 			failedMetrics = append(failedMetrics, metricName)
 		}
@@ -357,6 +730,13 @@ func (e *RuleEngine) evaluateCardinalityMetric(metric loaders.CardinalityData, c
 	return true
 }
 
+// conventionEvaluator evaluates labels metrics after normalizing their names with pack
+func (e *RuleEngine) conventionEvaluator(pack ConventionPack) MetricEvaluator[loaders.LabelsData] {
+	return func(metric loaders.LabelsData, conditions []ConditionConfig, validatorType string) bool {
+		return e.evaluateLabelsMetric(pack.normalizeLabelsData(metric), conditions, validatorType)
+	}
+}
+
 // evaluateLabelsMetric evaluates a labels or label_count metric
 func (e *RuleEngine) evaluateLabelsMetric(metric loaders.LabelsData, conditions []ConditionConfig, validatorType string) bool {
 	for _, condition := range conditions {
@@ -367,7 +747,7 @@ func (e *RuleEngine) evaluateLabelsMetric(metric loaders.LabelsData, conditions
 		case "labels":
 			conditionMet = e.evaluateLabelsField(metric.Labels, condition)
 		case "label_count":
-			conditionMet = e.compareLabelCount(len(metric.Labels), condition)
+			conditionMet = e.compareLabelCount(countLabels(metric.Labels, condition), condition)
 		default:
 			return false
 		}
@@ -378,8 +758,62 @@ func (e *RuleEngine) evaluateLabelsMetric(metric loaders.LabelsData, conditions
 	return true
 }
 
+// evaluateRecord evaluates a custom data source record, resolving fields by name
+func (e *RuleEngine) evaluateRecord(record Record, conditions []ConditionConfig, validatorType string) bool {
+	for _, condition := range conditions {
+		var value interface{}
+		if condition.Field == "metric_name" {
+			value = record.MetricName
+		} else {
+			fieldValue, ok := record.Fields[condition.Field]
+			if !ok {
+				return false
+			}
+			value = fieldValue
+		}
+		if !e.compareField(value, condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareField dispatches a condition to the comparison matching the field's value type
+func (e *RuleEngine) compareField(value interface{}, condition ConditionConfig) bool {
+	switch v := value.(type) {
+	case string:
+		return e.compareStrings(v, condition.Operator, condition.Value)
+	case []string:
+		return e.evaluateLabelsField(v, condition)
+	case bool:
+		expected, ok := condition.Value.(bool)
+		if !ok || condition.Operator != "eq" {
+			return false
+		}
+		return v == expected
+	case int:
+		return e.compareValues(float64(v), condition.Operator, condition.Value)
+	case int64:
+		return e.compareValues(float64(v), condition.Operator, condition.Value)
+	case float64:
+		return e.compareValues(v, condition.Operator, condition.Value)
+	default:
+		return false
+	}
+}
+
 // evaluateLabelsField evaluates label field conditions
 func (e *RuleEngine) evaluateLabelsField(labels []string, condition ConditionConfig) bool {
+	// Naming operators must hold for every label name
+	if isNamingOperator(condition.Operator) {
+		for _, label := range labels {
+			if !checkName(label, condition.Operator, true) {
+				return false
+			}
+		}
+		return true
+	}
+
 	expectedStr, ok := condition.Value.(string)
 	if !ok {
 		return false
@@ -418,6 +852,31 @@ func (e *RuleEngine) evaluateLabelsField(labels []string, condition ConditionCon
 	}
 }
 
+// countLabels counts labels, skipping the ones the condition asks to ignore
+func countLabels(labels []string, condition ConditionConfig) int {
+	if !condition.IgnoreStandardLabels && len(condition.IgnoreLabels) == 0 {
+		return len(labels)
+	}
+
+	ignored := make(map[string]bool)
+	if condition.IgnoreStandardLabels {
+		for _, label := range StandardLabels {
+			ignored[label] = true
+		}
+	}
+	for _, label := range condition.IgnoreLabels {
+		ignored[label] = true
+	}
+
+	count := 0
+	for _, label := range labels {
+		if !ignored[label] {
+			count++
+		}
+	}
+	return count
+}
+
 // compareLabelCount compares label count against a condition
 func (e *RuleEngine) compareLabelCount(labelCount int, condition ConditionConfig) bool {
 	intVal, ok := condition.Value.(int)
@@ -471,6 +930,10 @@ func (e *RuleEngine) compareValues(actual float64, operator string, expected int
 
 // compareStrings compares string values
 func (e *RuleEngine) compareStrings(actual string, operator string, expected interface{}) bool {
+	if isNamingOperator(operator) {
+		return checkName(actual, operator, false)
+	}
+
 	expectedStr, ok := expected.(string)
 	if !ok {
 		return false
@@ -489,44 +952,93 @@ func (e *RuleEngine) compareStrings(actual string, operator string, expected int
 		return !strings.Contains(strings.ToLower(actual), strings.ToLower(expectedStr))
 	case "eq":
 		return actual == expectedStr
+	case "starts_with":
+		return strings.HasPrefix(actual, expectedStr)
+	case "ends_with":
+		return strings.HasSuffix(actual, expectedStr)
 	default:
 		return false
 	}
 }
 
-// CalculateInstrumentationScore implements the formula from the spec:
-// Score = (Σ(Pi × Wi)) / (Σ(Ti × Wi)) × 100
-// Rules with cardinality data use cardinality-weighted scoring, others use metric-count scoring
-func CalculateInstrumentationScore(results []RuleResult) float64 {
-	impactWeights := map[string]float64{
-		"Critical":  40.0, // Increased from 40.0 to emphasize cardinality impact
-		"Important": 30.0, // Decreased from 30.0
-		"Normal":    20.0,
-		"Low":       10.0,
-	}
+// impactWeights are the W_i of the score formula
+var impactWeights = map[string]float64{
+	"Critical":  40.0, // Increased from 40.0 to emphasize cardinality impact
+	"Important": 30.0, // Decreased from 30.0
+	"Normal":    20.0,
+	"Low":       10.0,
+}
 
-	var numerator float64   // Σ(P_i × W_i)
-	var denominator float64 // Σ(T_i × W_i)
+// ScoreFormula is the formula CalculateInstrumentationScore implements
+const ScoreFormula = "Score = (Σ(P_i × W_i) / Σ(T_i × W_i)) × 100"
+
+// ScoreBreakdown is the score formula worked out rule by rule, so a score can be
+// recomputed by hand from the numbers in a report
+type ScoreBreakdown struct {
+	Formula     string             `json:"formula"`
+	Rules       []RuleContribution `json:"rules"`
+	Numerator   float64            `json:"numerator"`   // Σ(P_i × W_i)
+	Denominator float64            `json:"denominator"` // Σ(T_i × W_i)
+	Score       float64            `json:"score"`       // Numerator / Denominator × 100, 0 without a denominator
+}
+
+// RuleContribution is one rule's terms of the score formula
+type RuleContribution struct {
+	RuleID       string  `json:"rule_id"`
+	Impact       string  `json:"impact"`
+	Weight       float64 `json:"weight"` // W_i
+	Basis        string  `json:"basis"`  // "cardinality" (series) or "metrics"
+	Passed       int64   `json:"passed"` // P_i, including WaivedCredit
+	Total        int64   `json:"total"`  // T_i, including DecayPenalty
+	WaivedCredit int64   `json:"waived_credit,omitempty"`
+	DecayPenalty int64   `json:"decay_penalty,omitempty"`
+	Numerator    float64 `json:"numerator"`   // P_i × W_i
+	Denominator  float64 `json:"denominator"` // T_i × W_i
+}
+
+// ExplainScore returns the terms of the score formula for results
+// Rules with cardinality data are weighed by series, others by metric count.
+func ExplainScore(results []RuleResult) ScoreBreakdown {
+	breakdown := ScoreBreakdown{Formula: ScoreFormula, Rules: make([]RuleContribution, 0, len(results))}
 
 	for _, result := range results {
-		weight := impactWeights[result.Impact]
+		contribution := RuleContribution{
+			RuleID:       result.RuleID,
+			Impact:       result.Impact,
+			Weight:       impactWeights[result.Impact],
+			WaivedCredit: result.WaivedCredit,
+			DecayPenalty: result.DecayPenalty,
+		}
 
 		// Use cardinality-weighted scoring if the rule has cardinality data
 		// Rules using "cardinality" data source will have TotalCardinality > 0
 		// Rules using "labels" data source will have TotalCardinality = 0
 		if result.TotalCardinality > 0 {
-			numerator += float64(result.PassedCardinality) * weight
-			denominator += float64(result.TotalCardinality) * weight
+			contribution.Basis = "cardinality"
+			contribution.Passed = result.PassedCardinality + result.WaivedCredit
+			contribution.Total = result.TotalCardinality + result.DecayPenalty
 		} else {
-			numerator += float64(result.PassedMetrics) * weight
-			denominator += float64(result.TotalMetrics) * weight
+			contribution.Basis = "metrics"
+			contribution.Passed = int64(result.PassedMetrics) + result.WaivedCredit
+			contribution.Total = int64(result.TotalMetrics) + result.DecayPenalty
 		}
+		contribution.Numerator = float64(contribution.Passed) * contribution.Weight
+		contribution.Denominator = float64(contribution.Total) * contribution.Weight
+
+		breakdown.Numerator += contribution.Numerator
+		breakdown.Denominator += contribution.Denominator
+		breakdown.Rules = append(breakdown.Rules, contribution)
 	}
 
-	if denominator == 0 {
-		return 0.0
+	if breakdown.Denominator != 0 {
+		breakdown.Score = (breakdown.Numerator / breakdown.Denominator) * 100
 	}
+	return breakdown
+}
 
-	// Score = (Σ(P_i × W_i) / Σ(T_i × W_i)) × 100
-	return (numerator / denominator) * 100
+// CalculateInstrumentationScore implements the formula from the spec:
+// Score = (Σ(Pi × Wi)) / (Σ(Ti × Wi)) × 100
+// Rules with cardinality data use cardinality-weighted scoring, others use metric-count scoring
+func CalculateInstrumentationScore(results []RuleResult) float64 {
+	return ExplainScore(results).Score
 }
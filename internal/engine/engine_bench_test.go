@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// Benchmarks run the shipped rules against synthetic jobs shaped like real exporters:
+// a mix of well and badly named metrics, label counts and cardinalities.
+// The performance budget they are held to is documented in CONTRIBUTING.md.
+
+const benchRulesFile = "../../rules_config.yaml"
+
+// syntheticJob returns metrics metric records for job
+func syntheticJob(job string, metrics int) []loaders.JobMetricData {
+	types := []string{"counter", "gauge", "histogram", "summary", ""}
+	labels := []string{"method", "status", "endpoint", "instance", "job", "pod", "namespace", "region", "user_id", "trace_id"}
+
+	data := make([]loaders.JobMetricData, metrics)
+	for i := range data {
+		name := fmt.Sprintf("app_component%d_requests_total", i)
+		if i%7 == 0 {
+			name = fmt.Sprintf("AppComponent%dRequests", i) // Fails the naming rules
+		}
+		metricLabels := labels[:1+i%len(labels)]
+		labelCardinality := make(map[string]int64, len(metricLabels))
+		for j, label := range metricLabels {
+			labelCardinality[label] = int64(1 + (i+j)%50)
+		}
+		data[i] = loaders.JobMetricData{
+			Job:              job,
+			MetricName:       name,
+			Labels:           metricLabels,
+			Cardinality:      int64(1 + (i*37)%20000),
+			LabelCardinality: labelCardinality,
+			Type:             types[i%len(types)],
+		}
+	}
+	return data
+}
+
+func benchmarkEngine(b *testing.B) *RuleEngine {
+	ruleEngine, err := NewRuleEngine(benchRulesFile)
+	if err != nil {
+		b.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	return ruleEngine
+}
+
+func BenchmarkEvaluateJob(b *testing.B) {
+	ruleEngine := benchmarkEngine(b)
+	for _, metrics := range []int{10_000, 100_000} {
+		jobData := syntheticJob("bench", metrics)
+		b.Run(fmt.Sprintf("metrics=%d", metrics), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				results, err := ruleEngine.EvaluateJob(jobData)
+				if err != nil {
+					b.Fatal(err)
+				}
+				CalculateInstrumentationScore(results)
+			}
+		})
+	}
+}
+
+// BenchmarkEvaluateDirectory reads, evaluates and scores a directory of per-job files
+// the way evaluate --job-dir does, without the report writers
+func BenchmarkEvaluateDirectory(b *testing.B) {
+	const jobs, metricsPerJob = 5000, 40
+
+	dir := b.TempDir()
+	for i := 0; i < jobs; i++ {
+		var content strings.Builder
+		content.WriteString(loaders.FileHeader())
+		for _, metric := range syntheticJob(fmt.Sprintf("job-%d", i), metricsPerJob) {
+			fmt.Fprintf(&content, "%s|%s|%s|%d||%s\n", metric.Job, metric.MetricName, strings.Join(metric.Labels, ","), metric.Cardinality, metric.Type)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("job-%d.txt", i)), []byte(content.String()), 0600); err != nil {
+			b.Fatal(err)
+		}
+	}
+	ruleEngine := benchmarkEngine(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+		if err != nil || len(files) != jobs {
+			b.Fatalf("found %d job files: %v", len(files), err)
+		}
+		for _, file := range files {
+			jobData, err := loaders.LoadJobMetricReport(file)
+			if err != nil {
+				b.Fatal(err)
+			}
+			results, err := ruleEngine.EvaluateJob(ruleEngine.FilterExcludedJobData(jobData[0].Job, jobData))
+			if err != nil {
+				b.Fatal(err)
+			}
+			CalculateInstrumentationScore(results)
+		}
+	}
+}
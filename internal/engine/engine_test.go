@@ -1,8 +1,17 @@
 package engine
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"testing/quick"
 
 	"instrumentation-score/internal/loaders"
 )
@@ -295,6 +304,12 @@ func TestCompareStrings(t *testing.T) {
 		{"not_contains false", "user_id_label", "not_contains", "user_id", false},
 		{"eq true", "exact_match", "eq", "exact_match", true},
 		{"eq false", "not_match", "eq", "exact_match", false},
+		{"starts_with true", "acme_http_requests_total", "starts_with", "acme_", true},
+		{"starts_with false", "http_requests_total", "starts_with", "acme_", false},
+		{"ends_with true", "http_requests_total", "ends_with", "_total", true},
+		{"ends_with false", "http_requests", "ends_with", "_total", false},
+		{"snake_case ignores value", "http_requests_total", "snake_case", nil, true},
+		{"valid_prom_name colon", "job:http_requests:rate5m", "valid_prom_name", nil, true},
 	}
 
 	for _, tt := range tests {
@@ -364,3 +379,396 @@ func TestEvaluateCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateLabelsMetric_LabelCountIgnoresStandardLabels(t *testing.T) {
+	engine := &RuleEngine{}
+
+	metric := loaders.LabelsData{
+		MetricName: "http_requests_total",
+		Labels:     []string{"job", "instance", "cluster", "namespace", "method", "status", "pod"},
+	}
+
+	tests := []struct {
+		name      string
+		condition ConditionConfig
+		want      bool
+	}{
+		{
+			name:      "all labels counted",
+			condition: ConditionConfig{Field: "label_count", Operator: "lte", Value: 3},
+			want:      false,
+		},
+		{
+			name:      "standard labels ignored",
+			condition: ConditionConfig{Field: "label_count", Operator: "lte", Value: 3, IgnoreStandardLabels: true},
+			want:      true,
+		},
+		{
+			name:      "standard and custom labels ignored",
+			condition: ConditionConfig{Field: "label_count", Operator: "eq", Value: 2, IgnoreStandardLabels: true, IgnoreLabels: []string{"pod"}},
+			want:      true,
+		},
+		{
+			name:      "only custom labels ignored",
+			condition: ConditionConfig{Field: "label_count", Operator: "eq", Value: 6, IgnoreLabels: []string{"pod"}},
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.evaluateLabelsMetric(metric, []ConditionConfig{tt.condition}, "label_count")
+			if got != tt.want {
+				t.Errorf("evaluateLabelsMetric() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleEngine_AppliesTo(t *testing.T) {
+	rulesContent := `
+exclusion_list: []
+rules:
+- rule_id: "TEST-MET-04"
+  description: "Counters must end in _total"
+  impact: "Important"
+  applies_to: ["counter"]
+  validators:
+    - name: "counter_suffix_check"
+      type: "format"
+      data_source: "labels"
+      conditions:
+        - field: "metric_name"
+          operator: "ends_with"
+          value: "_total"
+`
+	tmpRulesFile, err := os.CreateTemp("", "test_rules_*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp rules file: %v", err)
+	}
+	defer os.Remove(tmpRulesFile.Name())
+
+	if _, err := tmpRulesFile.WriteString(rulesContent); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	tmpRulesFile.Close()
+
+	engine, err := NewRuleEngine(tmpRulesFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	labelsData := []loaders.LabelsData{
+		{MetricName: "http_requests_total", Type: "counter"},
+		{MetricName: "http_errors", Type: "counter"},
+		{MetricName: "memory_usage_bytes", Type: "gauge"},
+		{MetricName: "untyped_metric"},
+	}
+
+	results, err := engine.EvaluateWithData(nil, labelsData)
+	if err != nil {
+		t.Fatalf("Failed to evaluate rules: %v", err)
+	}
+
+	// Only the two counters are evaluated; the gauge and the untyped metric are skipped
+	if results[0].TotalMetrics != 2 {
+		t.Errorf("Expected 2 total metrics, got %d", results[0].TotalMetrics)
+	}
+	if results[0].PassedMetrics != 1 {
+		t.Errorf("Expected 1 passed metric, got %d", results[0].PassedMetrics)
+	}
+	if _, failed := results[0].FailedMetrics["http_errors"]; !failed {
+		t.Errorf("Expected http_errors to fail, got %v", results[0].FailedMetrics)
+	}
+}
+
+func TestRuleEngine_RulesHash(t *testing.T) {
+	const rules = `
+exclusion_list:
+  - job: "test-job"
+rules:
+  - rule_id: "R1"
+    impact: "Low"
+    validators:
+      - name: "v"
+        type: "format"
+        data_source: "labels"
+        conditions:
+          - field: "metric_name"
+            operator: "matches"
+            value: "^[a-z_]+$"
+`
+	hash := func(content string) string {
+		ruleEngine, err := NewRuleEngine(writeRules(t, content))
+		if err != nil {
+			t.Fatalf("NewRuleEngine() error = %v", err)
+		}
+		return ruleEngine.RulesHash()
+	}
+
+	base := hash(rules)
+	if len(base) != 64 {
+		t.Fatalf("RulesHash() = %q, want a sha256", base)
+	}
+	if got := hash("# A comment\n" + rules); got != base {
+		t.Error("RulesHash() changed with a comment")
+	}
+	if got := hash(strings.Replace(rules, `"Low"`, `"Critical"`, 1)); got == base {
+		t.Error("RulesHash() unchanged after changing a rule's impact")
+	}
+}
+
+// cancelAfter is a context canceled once Err has been checked checks times
+type cancelAfter struct {
+	context.Context
+	checks int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.checks == 0 {
+		return context.Canceled
+	}
+	c.checks--
+	return nil
+}
+
+func TestRuleEngine_EvaluateJobContext(t *testing.T) {
+	rule := func(id string) string {
+		return `
+  - rule_id: "` + id + `"
+    impact: "Low"
+    validators:
+      - name: "v"
+        type: "format"
+        data_source: "labels"
+        conditions:
+          - field: "metric_name"
+            operator: "matches"
+            value: "^[a-z_]+$"`
+	}
+	ruleEngine, err := NewRuleEngine(writeRules(t, "rules:"+rule("R1")+rule("R2")))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	jobData := []loaders.JobMetricData{{Job: "api", MetricName: "http_requests_total", Cardinality: 1}}
+
+	results, err := ruleEngine.EvaluateJobContext(context.Background(), jobData)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("EvaluateJobContext() = %d results, %v, want 2", len(results), err)
+	}
+	// Canceled after the first rule, the second is not evaluated
+	results, err = ruleEngine.EvaluateJobContext(&cancelAfter{Context: context.Background(), checks: 1}, jobData)
+	if !errors.Is(err, context.Canceled) || results != nil {
+		t.Errorf("EvaluateJobContext() canceled between rules = %v, %v, want no results and context.Canceled", results, err)
+	}
+}
+
+func TestRuleEngine_InclusionList(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, `
+inclusion_list:
+  - job: "checkout"
+  - job_name_pattern: "^payments-"
+exclusion_list:
+  - job: "payments-sandbox"
+  - job: "checkout"
+    metrics: ["debug_info"]
+rules: []
+`))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	for job, want := range map[string]bool{
+		"checkout":         false,
+		"payments-api":     false,
+		"payments-sandbox": true, // Included, then excluded
+		"node-exporter":    true, // Not included
+	} {
+		if got := ruleEngine.IsJobExcluded(job); got != want {
+			t.Errorf("IsJobExcluded(%q) = %v, want %v", job, got, want)
+		}
+	}
+	if !ruleEngine.IsMetricExcluded("checkout", "debug_info") || ruleEngine.IsMetricExcluded("checkout", "requests_total") {
+		t.Error("the exclusion list should still exclude metrics of included jobs")
+	}
+
+	for content, wantErr := range map[string]string{
+		"inclusion_list:\n  - job_name_pattern: \"(\"\nrules: []\n": "invalid regex pattern in inclusion_list[0]",
+		"inclusion_list:\n  - {}\nrules: []\n":                      "inclusion_list[0] needs a job or job_name_pattern",
+	} {
+		if _, err := NewRuleEngine(writeRules(t, content)); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("NewRuleEngine() error = %v, want %q", err, wantErr)
+		}
+	}
+
+	// Without an inclusion list every job is evaluated
+	everyJob, err := NewRuleEngine(writeRules(t, "rules: []\n"))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	if everyJob.IsJobExcluded("node-exporter") || len(everyJob.Inclusions()) != 0 {
+		t.Error("without an inclusion list no job should be excluded")
+	}
+}
+
+func TestNewRuleEngineFS(t *testing.T) {
+	const pack = `
+rules:
+  - rule_id: "PACK-01"
+    impact: "Low"
+    validators: []
+`
+	fsys := fstest.MapFS{
+		"config/rules.yaml":      {Data: []byte("include:\n  - packs/pack.yaml\nrules: []\n")},
+		"config/packs/pack.yaml": {Data: []byte(pack)},
+	}
+	ruleEngine, err := NewRuleEngineFS(fsys, "config/rules.yaml")
+	if err != nil {
+		t.Fatalf("NewRuleEngineFS() error = %v", err)
+	}
+	if len(ruleEngine.rules) != 1 || ruleEngine.rules[0].RuleID != "PACK-01" {
+		t.Errorf("rules = %+v, want the pack included relative to the rules file", ruleEngine.rules)
+	}
+
+	// The same rules read from disk or from a file system have the same hash
+	fromDisk, err := NewRuleEngine("../../rules_config.yaml")
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	fromFS, err := NewRuleEngineFS(os.DirFS("../.."), "rules_config.yaml")
+	if err != nil {
+		t.Fatalf("NewRuleEngineFS() error = %v", err)
+	}
+	if fromFS.RulesHash() != fromDisk.RulesHash() {
+		t.Error("RulesHash() differs between the file system and disk")
+	}
+
+	if _, err := NewRuleEngineFS(fsys, "absent.yaml"); err == nil {
+		t.Error("NewRuleEngineFS() of a missing file should fail")
+	}
+}
+
+func TestNewRuleEngineBytes(t *testing.T) {
+	packs := fstest.MapFS{
+		"packs/pack.yaml": {Data: []byte("rules:\n  - rule_id: \"PACK-01\"\n    impact: \"Low\"\n    validators: []\n")},
+	}
+	ruleEngine, err := NewRuleEngineBytes([]byte("include:\n  - packs/pack.yaml\nrules: []\n"), packs)
+	if err != nil {
+		t.Fatalf("NewRuleEngineBytes() error = %v", err)
+	}
+	if len(ruleEngine.rules) != 1 || ruleEngine.rules[0].RuleID != "PACK-01" {
+		t.Errorf("rules = %+v, want the pack included from packs", ruleEngine.rules)
+	}
+
+	for _, include := range []string{"packs/absent.yaml", "../../rules_config.yaml", "/etc/passwd"} {
+		if _, err := NewRuleEngineBytes([]byte("include:\n  - "+include+"\n"), packs); err == nil {
+			t.Errorf("NewRuleEngineBytes() including %s should fail", include)
+		}
+	}
+	if _, err := NewRuleEngineBytes([]byte("include:\n  - packs/pack.yaml\n"), nil); err == nil {
+		t.Error("NewRuleEngineBytes() without packs should refuse includes")
+	}
+	if _, err := NewRuleEngineBytes([]byte("rules: [unclosed"), nil); err == nil {
+		t.Error("NewRuleEngineBytes() of an invalid document should fail")
+	}
+}
+
+func TestExplainScore(t *testing.T) {
+	results := []RuleResult{
+		{RuleID: "CARD", Impact: "Critical", PassedCardinality: 900, TotalCardinality: 1000, PassedMetrics: 1, TotalMetrics: 2},
+		{RuleID: "NAMING", Impact: "Low", PassedMetrics: 3, TotalMetrics: 4, WaivedCredit: 1, DecayPenalty: 2},
+	}
+
+	breakdown := ExplainScore(results)
+	if len(breakdown.Rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(breakdown.Rules))
+	}
+
+	card, naming := breakdown.Rules[0], breakdown.Rules[1]
+	if card.Basis != "cardinality" || card.Weight != 40 || card.Numerator != 36000 || card.Denominator != 40000 {
+		t.Errorf("CARD = %+v", card)
+	}
+	if naming.Basis != "metrics" || naming.Passed != 4 || naming.Total != 6 || naming.Numerator != 40 || naming.Denominator != 60 {
+		t.Errorf("NAMING = %+v, want waived credit in P and decay penalty in T", naming)
+	}
+
+	// Recomputing the score from the breakdown gives the reported score
+	if breakdown.Numerator != 36040 || breakdown.Denominator != 40060 {
+		t.Errorf("sums = %v / %v", breakdown.Numerator, breakdown.Denominator)
+	}
+	if score := breakdown.Score; score < 89.9650 || score > 89.9651 || CalculateInstrumentationScore(results) != score {
+		t.Errorf("Score = %v, want 36040 / 40060 × 100", score)
+	}
+
+	if empty := ExplainScore(nil); empty.Score != 0 || empty.Rules == nil {
+		t.Errorf("ExplainScore(nil) = %+v, want score 0 and an empty rule list", empty)
+	}
+}
+
+// scoreInputs generates arbitrary rule results for property tests: each rule gets an
+// impact, a total and a passed count no larger than the total, by metrics or by series
+type scoreInputs []RuleResult
+
+func (scoreInputs) Generate(rand *rand.Rand, size int) reflect.Value {
+	impacts := []string{"Critical", "Important", "Normal", "Low"}
+	results := make(scoreInputs, rand.Intn(size+1))
+	for i := range results {
+		total := rand.Intn(1000)
+		passed := 0
+		if total > 0 {
+			passed = rand.Intn(total + 1)
+		}
+		results[i] = RuleResult{RuleID: fmt.Sprintf("R%d", i), Impact: impacts[rand.Intn(len(impacts))], PassedMetrics: passed, TotalMetrics: total}
+		if rand.Intn(2) == 0 {
+			results[i].TotalCardinality = int64(total) * 10
+			results[i].PassedCardinality = int64(passed) * 10
+		}
+	}
+	return reflect.ValueOf(results)
+}
+
+func TestCalculateInstrumentationScore_Properties(t *testing.T) {
+	t.Run("bounded", func(t *testing.T) {
+		bounded := func(results scoreInputs) bool {
+			score := CalculateInstrumentationScore(results)
+			return score >= 0 && score <= 100
+		}
+		if err := quick.Check(bounded, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// A failing metric that passes one more rule never lowers the score
+	t.Run("monotonic", func(t *testing.T) {
+		monotonic := func(results scoreInputs, pick uint) bool {
+			if len(results) == 0 {
+				return true
+			}
+			improved := append(scoreInputs{}, results...)
+			rule := &improved[pick%uint(len(improved))]
+			if rule.PassedMetrics == rule.TotalMetrics {
+				return true
+			}
+			rule.PassedMetrics++
+			if rule.TotalCardinality > 0 {
+				rule.PassedCardinality += 10
+			}
+			return CalculateInstrumentationScore(improved) >= CalculateInstrumentationScore(results)
+		}
+		if err := quick.Check(monotonic, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("all passing scores 100", func(t *testing.T) {
+		perfect := func(results scoreInputs) bool {
+			for i := range results {
+				results[i].PassedMetrics, results[i].PassedCardinality = results[i].TotalMetrics, results[i].TotalCardinality
+			}
+			score := CalculateInstrumentationScore(results)
+			return score == 0 || math.Abs(score-100) < 1e-9
+		}
+		if err := quick.Check(perfect, nil); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EvaluationError describes a validator that could not be evaluated
+type EvaluationError struct {
+	RuleID     string
+	Validator  string
+	DataSource string
+	Err        error
+}
+
+func (e *EvaluationError) Error() string {
+	return fmt.Sprintf("rule %s validator %s (data source %s): %v", e.RuleID, e.Validator, e.DataSource, e.Err)
+}
+
+func (e *EvaluationError) Unwrap() error {
+	return e.Err
+}
+
+// EvaluationErrors collects the validator failures of an evaluation
+// It is returned alongside partial results: rules and validators that
+// evaluated successfully are still present in the results.
+type EvaluationErrors []*EvaluationError
+
+func (e EvaluationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d validators failed: %s", len(e), strings.Join(messages, "; "))
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestRuleEngine_PartialResultsOnValidatorError(t *testing.T) {
+	rulesContent := `
+rules:
+- rule_id: "TEST-ERR-01"
+  impact: "Important"
+  validators:
+    - name: "broken_validator"
+      type: "no_such_type"
+      data_source: "labels"
+    - name: "format_check"
+      type: "format"
+      data_source: "labels"
+      conditions:
+        - field: "metric_name"
+          operator: "snake_case"
+- rule_id: "TEST-ERR-02"
+  impact: "Critical"
+  validators:
+    - name: "cardinality_check"
+      type: "cardinality"
+      data_source: "cardinality"
+      conditions:
+        - field: "count"
+          operator: "lt"
+          value: 100
+`
+	tmpRulesFile, err := os.CreateTemp("", "test_rules_*.yaml")
+	if err != nil {
+		t.Fatalf("Failed to create temp rules file: %v", err)
+	}
+	defer os.Remove(tmpRulesFile.Name())
+	if _, err := tmpRulesFile.WriteString(rulesContent); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	tmpRulesFile.Close()
+
+	engine, err := NewRuleEngine(tmpRulesFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	results, err := engine.EvaluateJob([]loaders.JobMetricData{
+		{Job: "api", MetricName: "http_requests_total", Cardinality: 10},
+		{Job: "api", MetricName: "BadName", Cardinality: 500},
+	})
+
+	var evalErrors EvaluationErrors
+	if !errors.As(err, &evalErrors) {
+		t.Fatalf("Expected EvaluationErrors, got %v", err)
+	}
+	if len(evalErrors) != 1 {
+		t.Fatalf("Expected 1 evaluation error, got %d", len(evalErrors))
+	}
+	evalErr := evalErrors[0]
+	if evalErr.RuleID != "TEST-ERR-01" || evalErr.Validator != "broken_validator" || evalErr.DataSource != "labels" {
+		t.Errorf("Unexpected error context: %+v", evalErr)
+	}
+	if !strings.Contains(evalErr.Error(), "unknown validator type") {
+		t.Errorf("Expected wrapped cause in message, got %q", evalErr.Error())
+	}
+
+	// Both rules still produce results
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	// The broken validator counts as failing both metrics, next to the format check's 1/2
+	if results[0].TotalMetrics != 4 || results[0].PassedMetrics != 1 {
+		t.Errorf("Expected the rule to count 1/4, got %d/%d", results[0].PassedMetrics, results[0].TotalMetrics)
+	}
+	if got := results[0].FailedMetrics["http_requests_total"]; len(got) != 1 || got[0] != "broken_validator" {
+		t.Errorf("Expected http_requests_total to fail broken_validator, got %v", got)
+	}
+	if len(results[0].Errors) != 1 {
+		t.Errorf("Expected the rule result to record 1 error, got %v", results[0].Errors)
+	}
+	if results[1].TotalMetrics != 2 || results[1].PassedMetrics != 1 {
+		t.Errorf("Expected cardinality check to count 1/2, got %d/%d", results[1].PassedMetrics, results[1].TotalMetrics)
+	}
+}
+
+func TestRuleEngine_ValidatorErrorLowersScore(t *testing.T) {
+	const rule = `
+rules:
+- rule_id: "TEST-ERR-01"
+  impact: "Important"
+  validators:
+    - name: "format_check"
+      type: "format"
+      data_source: "labels"
+      conditions:
+        - field: "metric_name"
+          operator: "snake_case"
+`
+	const broken = `
+    - name: "broken_validator"
+      type: "no_such_type"
+      data_source: "labels"
+`
+	jobData := []loaders.JobMetricData{
+		{Job: "api", MetricName: "http_requests_total", Cardinality: 10},
+		{Job: "api", MetricName: "BadName", Cardinality: 500},
+	}
+	score := func(rules string) float64 {
+		ruleEngine, err := NewRuleEngine(writeRules(t, rules))
+		if err != nil {
+			t.Fatalf("NewRuleEngine() error = %v", err)
+		}
+		results, _ := ruleEngine.EvaluateJob(jobData)
+		return ExplainScore(results).Score
+	}
+
+	healthy, withBroken := score(rule), score(rule+broken)
+	if healthy != 50 {
+		t.Fatalf("score without the broken validator = %v, want 50", healthy)
+	}
+	if withBroken != 25 {
+		t.Errorf("score with a broken validator = %v, want 25: it counts as failing both metrics", withBroken)
+	}
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// Label value checks judge the values analyze sampled with --label-value-samples
+// Only metrics with sampled values for a condition's labels are counted: a metric
+// collected without samples cannot be shown to pass or fail.
+
+// evaluateLabelValuesMetric evaluates a label_values metric
+// label_values conditions apply to the sampled values, others as in a labels validator.
+func (e *RuleEngine) evaluateLabelValuesMetric(metric loaders.LabelsData, conditions []ConditionConfig, validatorType string) bool {
+	for _, condition := range conditions {
+		if condition.Field != "label_values" {
+			if !e.evaluateLabelsMetric(metric, []ConditionConfig{condition}, validatorType) {
+				return false
+			}
+			continue
+		}
+		for _, values := range conditionLabelValues(metric, condition) {
+			if !e.checkLabelValues(values, condition) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// evaluateLabelValues evaluates a label_values validator over the metrics with sampled values
+func (e *RuleEngine) evaluateLabelValues(labelsData []loaders.LabelsData, validator ValidatorConfig) (int, int, []string, error) {
+	for _, condition := range validator.Conditions {
+		if condition.Field == "label_values" && condition.Operator == "one_of" {
+			if _, err := allowedLabelValues(condition.Value); err != nil {
+				return 0, 0, nil, err
+			}
+		}
+	}
+
+	sampled := make([]loaders.LabelsData, 0, len(labelsData))
+	for _, metric := range labelsData {
+		if hasSampledLabelValues(metric, validator.Conditions) {
+			sampled = append(sampled, metric)
+		}
+	}
+	return evaluateMetrics(sampled, validator, e.evaluateLabelValuesMetric)
+}
+
+// hasSampledLabelValues reports whether metric has sampled values for every label_values condition
+func hasSampledLabelValues(metric loaders.LabelsData, conditions []ConditionConfig) bool {
+	for _, condition := range conditions {
+		if condition.Field == "label_values" && len(conditionLabelValues(metric, condition)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionLabelValues returns the sampled values a condition checks, by label
+// A condition without labels checks every sampled label.
+func conditionLabelValues(metric loaders.LabelsData, condition ConditionConfig) map[string][]string {
+	if len(condition.Labels) == 0 {
+		return metric.LabelValues
+	}
+	selected := make(map[string][]string, len(condition.Labels))
+	for _, label := range condition.Labels {
+		if values, ok := metric.LabelValues[label]; ok && len(values) > 0 {
+			selected[label] = values
+		}
+	}
+	return selected
+}
+
+// checkLabelValues applies a condition to the sampled values of one label
+func (e *RuleEngine) checkLabelValues(values []string, condition ConditionConfig) bool {
+	switch condition.Operator {
+	case "lowercase":
+		for _, value := range values {
+			if strings.ToLower(value) != value {
+				return false
+			}
+		}
+		return true
+	case "no_spaces":
+		for _, value := range values {
+			if strings.IndexFunc(value, unicode.IsSpace) >= 0 {
+				return false
+			}
+		}
+		return true
+	case "consistent_case":
+		// "OK ", "Ok" and "ok" are one value spelled three ways
+		seen := make(map[string]string, len(values))
+		for _, value := range values {
+			folded := strings.ToLower(strings.TrimSpace(value))
+			if other, ok := seen[folded]; ok && other != value {
+				return false
+			}
+			seen[folded] = value
+		}
+		return true
+	case "one_of":
+		allowed, err := allowedLabelValues(condition.Value)
+		if err != nil {
+			return false
+		}
+		for _, value := range values {
+			if !allowed[value] {
+				return false
+			}
+		}
+		return true
+	default:
+		// String operators must hold for every value
+		for _, value := range values {
+			if !e.compareStrings(value, condition.Operator, condition.Value) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// allowedLabelValues converts a one_of condition value (a YAML list) into a set
+func allowedLabelValues(value interface{}) (map[string]bool, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("one_of requires a list of values, got %T", value)
+	}
+	allowed := make(map[string]bool, len(list))
+	for _, item := range list {
+		allowed[fmt.Sprint(item)] = true
+	}
+	return allowed, nil
+}
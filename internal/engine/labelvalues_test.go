@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+const labelValueRules = `
+rules:
+  - rule_id: "PROM-LBL-02"
+    impact: "Normal"
+    validators:
+      - name: "status_values"
+        type: "label_values"
+        data_source: "labels"
+        conditions:
+          - field: "label_values"
+            operator: "consistent_case"
+          - field: "label_values"
+            operator: "no_spaces"
+      - name: "lowercase_values"
+        type: "label_values"
+        data_source: "labels"
+        conditions:
+          - field: "label_values"
+            operator: "lowercase"
+            labels: ["status"]
+      - name: "bounded_outcome"
+        type: "label_values"
+        data_source: "labels"
+        conditions:
+          - field: "label_values"
+            operator: "one_of"
+            value: ["success", "failure"]
+            labels: ["outcome"]
+`
+
+func TestEvaluateJob_LabelValues(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, labelValueRules))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+
+	jobData := []loaders.JobMetricData{
+		{Job: "api", MetricName: "requests_total", Labels: []string{"status"}, LabelValues: map[string][]string{"status": {"ok", "error"}}},
+		{Job: "api", MetricName: "responses_total", Labels: []string{"status"}, LabelValues: map[string][]string{"status": {"OK ", "Ok", "ok"}}},
+		{Job: "api", MetricName: "jobs_total", Labels: []string{"outcome"}, LabelValues: map[string][]string{"outcome": {"success", "timeout"}}},
+		{Job: "api", MetricName: "up", Labels: []string{"instance"}},
+	}
+
+	results, err := ruleEngine.EvaluateJob(jobData)
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+
+	stats := map[string]ValidatorStat{}
+	for _, stat := range results[0].ValidatorStats {
+		stats[stat.Name] = stat
+	}
+	// Metrics without sampled values are not counted
+	if got := stats["status_values"]; got.PassedMetrics != 2 || got.TotalMetrics != 3 {
+		t.Errorf("status_values = %d/%d, want 2/3", got.PassedMetrics, got.TotalMetrics)
+	}
+	if got := stats["lowercase_values"]; got.PassedMetrics != 1 || got.TotalMetrics != 2 {
+		t.Errorf("lowercase_values = %d/%d, want 1/2", got.PassedMetrics, got.TotalMetrics)
+	}
+	if got := stats["bounded_outcome"]; got.PassedMetrics != 0 || got.TotalMetrics != 1 {
+		t.Errorf("bounded_outcome = %d/%d, want 0/1", got.PassedMetrics, got.TotalMetrics)
+	}
+
+	var failed []string
+	for metric := range results[0].FailedMetrics {
+		failed = append(failed, metric)
+	}
+	sort.Strings(failed)
+	if want := []string{"jobs_total", "responses_total"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("FailedMetrics = %v, want %v", failed, want)
+	}
+}
+
+func TestCheckLabelValues(t *testing.T) {
+	e := &RuleEngine{}
+	tests := []struct {
+		name      string
+		values    []string
+		condition ConditionConfig
+		want      bool
+	}{
+		{"lowercase", []string{"ok", "not_found"}, ConditionConfig{Operator: "lowercase"}, true},
+		{"uppercase", []string{"ok", "OK"}, ConditionConfig{Operator: "lowercase"}, false},
+		{"no spaces", []string{"GET"}, ConditionConfig{Operator: "no_spaces"}, true},
+		{"trailing space", []string{"ok "}, ConditionConfig{Operator: "no_spaces"}, false},
+		{"consistent", []string{"ok", "error"}, ConditionConfig{Operator: "consistent_case"}, true},
+		{"diverging case", []string{"OK", "ok"}, ConditionConfig{Operator: "consistent_case"}, false},
+		{"one_of", []string{"200", "404"}, ConditionConfig{Operator: "one_of", Value: []interface{}{200, 404, 500}}, true},
+		{"not one_of", []string{"201"}, ConditionConfig{Operator: "one_of", Value: []interface{}{"200"}}, false},
+		{"one_of without list", []string{"200"}, ConditionConfig{Operator: "one_of", Value: "200"}, false},
+		{"matches", []string{"2xx", "5xx"}, ConditionConfig{Operator: "matches", Value: "^[1-5]xx$"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.checkLabelValues(tt.values, tt.condition); got != tt.want {
+				t.Errorf("checkLabelValues(%q) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
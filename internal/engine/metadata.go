@@ -0,0 +1,33 @@
+package engine
+
+// Metadata returns how reports present the validator's failures in rule
+// The ui block takes precedence over the deprecated ui_title and ui_description; the
+// title defaults to the validator name and the severity text to the rule's impact.
+func (v ValidatorConfig) Metadata(rule RuleDefinition) ValidatorUI {
+	ui := v.UI
+	if ui.Title == "" {
+		ui.Title = v.UITitle
+	}
+	if ui.Description == "" {
+		ui.Description = v.UIDescription
+	}
+	if ui.Title == "" {
+		ui.Title = v.Name
+	}
+	if ui.Severity == "" {
+		ui.Severity = rule.Impact
+	}
+	return ui
+}
+
+// ValidatorUI returns the metadata of a validator evaluated for the rule, such as one named in
+// FailedChecks or FailedMetrics. Validators without stats, e.g. ones that could not be evaluated,
+// are presented by name.
+func (r RuleResult) ValidatorUI(name string) ValidatorUI {
+	for _, stat := range r.ValidatorStats {
+		if stat.Name == name {
+			return stat.UI
+		}
+	}
+	return ValidatorUI{Title: name, Severity: r.Impact}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestValidatorConfig_Metadata(t *testing.T) {
+	rule := RuleDefinition{RuleID: "PROM-MET-02", Impact: "Critical"}
+	tests := []struct {
+		name      string
+		validator ValidatorConfig
+		want      ValidatorUI
+	}{
+		{
+			name:      "defaults",
+			validator: ValidatorConfig{Name: "cardinality_check"},
+			want:      ValidatorUI{Title: "cardinality_check", Severity: "Critical"},
+		},
+		{
+			name:      "deprecated fields",
+			validator: ValidatorConfig{Name: "cardinality_check", UITitle: "High Cardinality", UIDescription: "Too many series."},
+			want:      ValidatorUI{Title: "High Cardinality", Description: "Too many series.", Severity: "Critical"},
+		},
+		{
+			name: "ui block takes precedence",
+			validator: ValidatorConfig{
+				Name:    "cardinality_check",
+				UITitle: "Old title",
+				UI: ValidatorUI{
+					Title:       "High Cardinality",
+					Remediation: "Drop unbounded labels.",
+					Severity:    "Review recommended",
+					DocURL:      "https://example.com/PROM-MET-02",
+					MessageKeys: MessageKeys{Title: "rules.cardinality.title"},
+				},
+			},
+			want: ValidatorUI{
+				Title:       "High Cardinality",
+				Remediation: "Drop unbounded labels.",
+				Severity:    "Review recommended",
+				DocURL:      "https://example.com/PROM-MET-02",
+				MessageKeys: MessageKeys{Title: "rules.cardinality.title"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.validator.Metadata(rule); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Metadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleResult_ValidatorUI(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, `
+rules:
+  - rule_id: "PROM-MET-01"
+    impact: "Important"
+    validators:
+      - name: "format_check"
+        type: "format"
+        data_source: "labels"
+        ui:
+          title: "Naming Convention"
+          remediation: "Use snake_case."
+          doc_url: "rules/PROM-MET-01.md"
+          message_keys:
+            title: "rules.naming.title"
+        conditions:
+          - field: "metric_name"
+            operator: "matches"
+            value: "^[a-z_]+$"
+`))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	results, err := ruleEngine.EvaluateJob([]loaders.JobMetricData{{Job: "api", MetricName: "BadName", Cardinality: 1}})
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+
+	want := ValidatorUI{
+		Title:       "Naming Convention",
+		Remediation: "Use snake_case.",
+		Severity:    "Important",
+		DocURL:      "rules/PROM-MET-01.md",
+		MessageKeys: MessageKeys{Title: "rules.naming.title"},
+	}
+	if got := results[0].ValidatorUI("format_check"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidatorUI() = %+v, want %+v", got, want)
+	}
+	if got := results[0].ValidatorUI("unknown_check"); got.Title != "unknown_check" || got.Severity != "Important" {
+		t.Errorf("ValidatorUI() of a validator without stats = %+v", got)
+	}
+}
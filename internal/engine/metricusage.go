@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"instrumentation-score/internal/loaders"
+	"instrumentation-score/internal/usage"
+)
+
+// MetricUsageDataSource is the data source telling which metrics dashboards and rules use
+// Its records come from SetMetricUsage; without it the source is empty and rules using
+// it do not affect the score.
+const MetricUsageDataSource = "metric_usage"
+
+// SetMetricUsage supplies the metric usage report, used by the metric_usage data source
+func (e *RuleEngine) SetMetricUsage(data []loaders.MetricUsageData) {
+	e.metricUsage = usage.NewIndex(data)
+}
+
+// MetricUsage returns the metric usage index, or nil if SetMetricUsage was not called
+func (e *RuleEngine) MetricUsage() *usage.Index {
+	return e.metricUsage
+}
+
+// buildMetricUsageRecords exposes whether each metric of a job is referenced or queried
+func buildMetricUsageRecords(jobData []loaders.JobMetricData, index *usage.Index) []Record {
+	records := make([]Record, 0, len(jobData))
+	for _, jm := range jobData {
+		references := index.References(jm.MetricName)
+		queryCount := index.QueryCount(jm.MetricName)
+		records = append(records, Record{
+			MetricName: jm.MetricName,
+			Type:       jm.Type,
+			Fields: map[string]interface{}{
+				"used":            index.Used(jm.MetricName),
+				"reference_count": len(references),
+				"referenced_by":   references,
+				"query_count":     queryCount,
+				"queried":         queryCount > 0,
+				"count":           jm.Cardinality,
+			},
+		})
+	}
+	return records
+}
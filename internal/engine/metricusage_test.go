@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+const metricUsageRules = `
+rules:
+  - rule_id: "PROM-USE-01"
+    impact: "Low"
+    validators:
+      - name: "metric_used_check"
+        type: "usage"
+        data_source: "metric_usage"
+        conditions:
+          - field: "used"
+            operator: "eq"
+            value: true
+`
+
+func TestEvaluateJob_MetricUsage(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, metricUsageRules))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	jobData := []loaders.JobMetricData{
+		{Job: "api", MetricName: "http_requests_total", Cardinality: 10},
+		{Job: "api", MetricName: "http_request_duration_seconds_count", Cardinality: 5},
+		{Job: "api", MetricName: "legacy_cache_hits_total", Cardinality: 300},
+		{Job: "api", MetricName: "adhoc_debug_total", Cardinality: 20},
+	}
+
+	// Without a usage report the rule has nothing to evaluate
+	results, err := ruleEngine.EvaluateJob(jobData)
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	if results[0].TotalMetrics != 0 {
+		t.Fatalf("TotalMetrics = %d without metric usage, want 0", results[0].TotalMetrics)
+	}
+
+	ruleEngine.SetMetricUsage([]loaders.MetricUsageData{
+		{MetricName: "http_requests_total", References: []string{"dashboard:API"}},
+		{MetricName: "http_request_duration_seconds_bucket", References: []string{"alert:SlowRequests"}},
+		{MetricName: "adhoc_debug_total", QueryCount: 5},
+	})
+	results, err = ruleEngine.EvaluateJob(jobData)
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	result := results[0]
+	if result.PassedMetrics != 3 || result.TotalMetrics != 4 {
+		t.Errorf("passed %d/%d, want 3/4", result.PassedMetrics, result.TotalMetrics)
+	}
+	if _, ok := result.FailedMetrics["legacy_cache_hits_total"]; !ok || len(result.FailedMetrics) != 1 {
+		t.Errorf("FailedMetrics = %v, want only legacy_cache_hits_total", result.FailedMetrics)
+	}
+}
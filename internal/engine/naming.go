@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// snakeCasePattern matches lowercase words separated by single underscores
+	snakeCasePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	// promMetricNamePattern is the metric name charset from the Prometheus data model
+	promMetricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	// promLabelNamePattern is the label name charset from the Prometheus data model
+	promLabelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// namingOperators are operators that validate a name on their own and ignore the condition value
+var namingOperators = map[string]bool{
+	"snake_case":      true,
+	"no_camel_case":   true,
+	"valid_prom_name": true,
+}
+
+// isNamingOperator reports whether the operator is a built-in naming check
+func isNamingOperator(operator string) bool {
+	return namingOperators[operator]
+}
+
+// isSnakeCase reports whether name is lowercase snake_case without leading,
+// trailing or repeated underscores
+func isSnakeCase(name string) bool {
+	return snakeCasePattern.MatchString(name)
+}
+
+// hasCamelCase reports whether name contains a lowercase letter or digit
+// immediately followed by an uppercase letter (e.g. "httpRequests")
+func hasCamelCase(name string) bool {
+	runes := []rune(name)
+	for i := 1; i < len(runes); i++ {
+		prev := runes[i-1]
+		if unicode.IsUpper(runes[i]) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidPromMetricName reports whether name is a legal Prometheus metric name
+func isValidPromMetricName(name string) bool {
+	return promMetricNamePattern.MatchString(name)
+}
+
+// isValidPromLabelName reports whether name is a legal, non-reserved Prometheus label name
+func isValidPromLabelName(name string) bool {
+	return promLabelNamePattern.MatchString(name) && !strings.HasPrefix(name, "__")
+}
+
+// checkName applies a naming operator to a metric or label name
+func checkName(name, operator string, isLabel bool) bool {
+	switch operator {
+	case "snake_case":
+		return isSnakeCase(name)
+	case "no_camel_case":
+		return !hasCamelCase(name)
+	case "valid_prom_name":
+		if isLabel {
+			return isValidPromLabelName(name)
+		}
+		return isValidPromMetricName(name)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+package engine
+
+import "testing"
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		operator string
+		isLabel  bool
+		want     bool
+	}{
+		{"snake_case valid", "http_requests_total", "snake_case", false, true},
+		{"snake_case double underscore", "http__requests", "snake_case", false, false},
+		{"snake_case trailing underscore", "http_requests_", "snake_case", false, false},
+		{"snake_case uppercase", "Http_requests", "snake_case", false, false},
+		{"snake_case leading digit", "1xx_responses", "snake_case", false, false},
+		{"no_camel_case valid", "http_requests_total", "no_camel_case", false, true},
+		{"no_camel_case camel", "httpRequestsTotal", "no_camel_case", false, false},
+		{"no_camel_case digit then upper", "http2Requests", "no_camel_case", false, false},
+		{"no_camel_case all caps", "HTTP_REQUESTS", "no_camel_case", false, true},
+		{"valid_prom_name metric colon", "job:requests:rate5m", "valid_prom_name", false, true},
+		{"valid_prom_name metric dot", "http.server.duration", "valid_prom_name", false, false},
+		{"valid_prom_name label colon", "le:bucket", "valid_prom_name", true, false},
+		{"valid_prom_name label reserved", "__address__", "valid_prom_name", true, false},
+		{"valid_prom_name label ok", "status_code", "valid_prom_name", true, true},
+		{"unknown operator", "anything", "camel_case", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkName(tt.value, tt.operator, tt.isLabel); got != tt.want {
+				t.Errorf("checkName(%q, %q) = %v, want %v", tt.value, tt.operator, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateLabelsField_NamingOperators(t *testing.T) {
+	engine := &RuleEngine{}
+
+	tests := []struct {
+		name      string
+		labels    []string
+		condition ConditionConfig
+		want      bool
+	}{
+		{
+			name:      "all labels snake_case",
+			labels:    []string{"method", "status_code"},
+			condition: ConditionConfig{Field: "labels", Operator: "snake_case"},
+			want:      true,
+		},
+		{
+			name:      "one camelCase label fails",
+			labels:    []string{"method", "statusCode"},
+			condition: ConditionConfig{Field: "labels", Operator: "no_camel_case"},
+			want:      false,
+		},
+		{
+			name:      "any label ends_with",
+			labels:    []string{"method", "user_id"},
+			condition: ConditionConfig{Field: "labels", Operator: "ends_with", Value: "_id"},
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := engine.evaluateLabelsField(tt.labels, tt.condition); got != tt.want {
+				t.Errorf("evaluateLabelsField() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
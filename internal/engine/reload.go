@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ReloadingEngine keeps a RuleEngine in step with its rules file for long-running commands
+// Changes are detected by content hash, which also catches the symlink swaps of mounted
+// ConfigMaps. An invalid new file is rejected and the previous rules stay active.
+type ReloadingEngine struct {
+	path string
+
+	mu       sync.RWMutex
+	engine   *RuleEngine
+	fileSum  string // sha256 of the loaded file
+	rejected string // sha256 of the last invalid file, reported once
+}
+
+// NewReloadingEngine loads rulesFile, failing if it is invalid
+func NewReloadingEngine(rulesFile string) (*ReloadingEngine, error) {
+	r := &ReloadingEngine{path: rulesFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Current returns the active engine and its rules version, the start of its RulesHash
+func (r *ReloadingEngine) Current() (*RuleEngine, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.engine, r.engine.RulesHash()[:12]
+}
+
+// Reload loads the rules file if its content changed and reports whether the effective
+// rules changed; edits to comments or formatting only are not a change.
+// A rejected file is only reported once, until its content changes again.
+func (r *ReloadingEngine) Reload() (bool, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	fileSum := hex.EncodeToString(sum[:])
+
+	r.mu.RLock()
+	unchanged := fileSum == r.fileSum || fileSum == r.rejected
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	engine, err := NewRuleEngine(r.path)
+	if err != nil {
+		r.mu.Lock()
+		r.rejected = fileSum
+		r.mu.Unlock()
+		return false, err
+	}
+	r.mu.Lock()
+	changed := r.engine == nil || r.engine.RulesHash() != engine.RulesHash()
+	r.engine, r.fileSum = engine, fileSum
+	r.mu.Unlock()
+	return changed, nil
+}
+
+// Watch checks the rules file every interval until stop is closed
+// onReload is called after every change, with the error when the new file was rejected.
+func (r *ReloadingEngine) Watch(interval time.Duration, stop <-chan struct{}, onReload func(version string, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := r.Reload()
+		if !changed && err == nil {
+			continue
+		}
+		_, version := r.Current()
+		onReload(version, err)
+	}
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReloadingEngine(t *testing.T) {
+	const rules = `
+rules:
+  - rule_id: "R1"
+    impact: "Low"
+    validators:
+      - name: "v"
+        type: "format"
+        data_source: "labels"
+        conditions:
+          - field: "metric_name"
+            operator: "matches"
+            value: "^[a-z_]+$"
+`
+	path := writeRules(t, rules)
+	reloading, err := NewReloadingEngine(path)
+	if err != nil {
+		t.Fatalf("NewReloadingEngine() error = %v", err)
+	}
+	first, version := reloading.Current()
+	if len(version) != 12 || !strings.HasPrefix(first.RulesHash(), version) {
+		t.Fatalf("version = %q, want the start of RulesHash %q", version, first.RulesHash())
+	}
+
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("# Comment only\n" + rules)
+	if changed, err := reloading.Reload(); changed || err != nil {
+		t.Errorf("Reload() after a comment = %v, %v; want no change", changed, err)
+	}
+
+	write(strings.Replace(rules, `"Low"`, `"Critical"`, 1))
+	if changed, err := reloading.Reload(); !changed || err != nil {
+		t.Fatalf("Reload() after an impact change = %v, %v; want a change", changed, err)
+	}
+	if _, newVersion := reloading.Current(); newVersion == version {
+		t.Error("version unchanged after reloading different rules")
+	}
+
+	// An invalid file keeps the current rules and is reported once
+	current, _ := reloading.Current()
+	write(strings.Replace(rules, `"labels"`, `"unknown"`, 1))
+	if _, err := reloading.Reload(); err == nil {
+		t.Error("Reload() of an invalid file should fail")
+	}
+	if _, err := reloading.Reload(); err != nil {
+		t.Errorf("Reload() of the same invalid file again = %v, want it reported once", err)
+	}
+	if active, _ := reloading.Current(); active != current {
+		t.Error("an invalid file replaced the active rules")
+	}
+}
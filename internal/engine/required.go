@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// compileRequiredMetrics validates the required_metrics sets of every validator in place
+func compileRequiredMetrics(rules []RuleDefinition) error {
+	for _, rule := range rules {
+		for _, validator := range rule.Validators {
+			if validator.Type == "required_metrics" && len(validator.RequiredMetrics) == 0 {
+				return fmt.Errorf("rule %s validator %s: required_metrics validator needs at least one required_metrics set", rule.RuleID, validator.Name)
+			}
+			for i := range validator.RequiredMetrics {
+				set := &validator.RequiredMetrics[i]
+				if set.JobNamePattern != "" {
+					pattern, err := regexp.Compile(set.JobNamePattern)
+					if err != nil {
+						return fmt.Errorf("invalid regex pattern in rule %s validator %s required_metrics[%d]: %w", rule.RuleID, validator.Name, i, err)
+					}
+					set.pattern = pattern
+				}
+				for _, metric := range set.Metrics {
+					for _, alternative := range strings.Split(metric, "|") {
+						if _, err := path.Match(alternative, ""); err != nil {
+							return fmt.Errorf("invalid metric pattern %q in rule %s validator %s required_metrics[%d]: %w", metric, rule.RuleID, validator.Name, i, err)
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// appliesTo reports whether the set applies to a job
+func (s RequiredMetricSet) appliesTo(jobName string) bool {
+	switch {
+	case s.Job != "":
+		return s.Job == jobName
+	case s.pattern != nil:
+		return s.pattern.MatchString(jobName)
+	default:
+		return true
+	}
+}
+
+// requiredMetricsFor returns the required metric names and globs for a job, without duplicates
+func requiredMetricsFor(sets []RequiredMetricSet, jobName string) []string {
+	seen := make(map[string]bool)
+	var required []string
+	for _, set := range sets {
+		if !set.appliesTo(jobName) {
+			continue
+		}
+		for _, metric := range set.Metrics {
+			if !seen[metric] {
+				seen[metric] = true
+				required = append(required, metric)
+			}
+		}
+	}
+	return required
+}
+
+// evaluateRequiredMetrics checks that every metric required of the job exists in the data source
+// Each required metric counts as one check; missing ones are reported under the required name.
+// Jobs no set applies to contribute nothing.
+func evaluateRequiredMetrics(validator ValidatorConfig, data interface{}, jobName string) (int, int, []string, error) {
+	var present []string
+	switch d := data.(type) {
+	case []loaders.LabelsData:
+		for _, metric := range d {
+			present = append(present, metric.MetricName)
+		}
+	case []loaders.CardinalityData:
+		for _, metric := range d {
+			present = append(present, metric.MetricName)
+		}
+	case []Record:
+		for _, record := range d {
+			present = append(present, record.MetricName)
+		}
+	default:
+		return 0, 0, nil, fmt.Errorf("invalid data type for %s validator", validator.Type)
+	}
+
+	required := requiredMetricsFor(validator.RequiredMetrics, jobName)
+	passed := 0
+	var missing []string
+	for _, pattern := range required {
+		if hasMatchingMetric(present, pattern) {
+			passed++
+		} else {
+			missing = append(missing, pattern)
+		}
+	}
+	return passed, len(required), missing, nil
+}
+
+// hasMatchingMetric reports whether any metric name equals or matches the glob pattern
+// Patterns may list alternatives separated by "|"; any one of them satisfies the requirement.
+func hasMatchingMetric(metricNames []string, pattern string) bool {
+	for _, alternative := range strings.Split(pattern, "|") {
+		for _, name := range metricNames {
+			if name == alternative {
+				return true
+			}
+			if matched, _ := path.Match(alternative, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
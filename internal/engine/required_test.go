@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+const requiredMetricsRules = `
+rules:
+  - rule_id: "PROM-REQ-01"
+    impact: "Important"
+    validators:
+      - name: "required_metrics_check"
+        type: "required_metrics"
+        data_source: "labels"
+        required_metrics:
+          - metrics: ["*_build_info"]
+          - job_name_pattern: "-api$"
+            metrics: ["http_server_request_duration_seconds", "*_build_info"]
+          - job: "payments-api"
+            metrics: ["payments_processed_total"]
+`
+
+func TestEvaluateJob_RequiredMetrics(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, requiredMetricsRules))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		job         string
+		metrics     []string
+		wantPassed  int
+		wantTotal   int
+		wantMissing []string
+	}{
+		{
+			name:        "worker needs build info only",
+			job:         "worker",
+			metrics:     []string{"worker_build_info", "jobs_total"},
+			wantPassed:  1,
+			wantTotal:   1,
+			wantMissing: nil,
+		},
+		{
+			name:        "api matched by pattern",
+			job:         "checkout-api",
+			metrics:     []string{"checkout_build_info"},
+			wantPassed:  1,
+			wantTotal:   2,
+			wantMissing: []string{"http_server_request_duration_seconds"},
+		},
+		{
+			name:        "exact job adds to pattern sets",
+			job:         "payments-api",
+			metrics:     []string{"http_server_request_duration_seconds"},
+			wantPassed:  1,
+			wantTotal:   3,
+			wantMissing: []string{"*_build_info", "payments_processed_total"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var jobData []loaders.JobMetricData
+			for _, metric := range tt.metrics {
+				jobData = append(jobData, loaders.JobMetricData{Job: tt.job, MetricName: metric, Cardinality: 1})
+			}
+			results, err := ruleEngine.EvaluateJob(jobData)
+			if err != nil {
+				t.Fatalf("EvaluateJob() error = %v", err)
+			}
+			result := results[0]
+			if result.PassedMetrics != tt.wantPassed || result.TotalMetrics != tt.wantTotal {
+				t.Errorf("passed %d/%d, want %d/%d", result.PassedMetrics, result.TotalMetrics, tt.wantPassed, tt.wantTotal)
+			}
+			var missing []string
+			for _, name := range tt.wantMissing {
+				if _, ok := result.FailedMetrics[name]; ok {
+					missing = append(missing, name)
+				}
+			}
+			if len(result.FailedMetrics) != len(tt.wantMissing) || !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("FailedMetrics = %v, want %v", result.FailedMetrics, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestNewRuleEngine_RequiredMetricsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		sets    string
+		wantErr string
+	}{
+		{name: "no sets", sets: "", wantErr: "needs at least one required_metrics set"},
+		{name: "bad job pattern", sets: `[{job_name_pattern: "(", metrics: ["up"]}]`, wantErr: "invalid regex pattern"},
+		{name: "bad metric glob", sets: `[{metrics: ["[build"]}]`, wantErr: "invalid metric pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := `
+rules:
+  - rule_id: "PROM-REQ-01"
+    impact: "Low"
+    validators:
+      - name: "required"
+        type: "required_metrics"
+        data_source: "labels"
+`
+			if tt.sets != "" {
+				rules += "        required_metrics: " + tt.sets + "\n"
+			}
+			_, err := NewRuleEngine(writeRules(t, rules))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewRuleEngine() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasMatchingMetric(t *testing.T) {
+	metrics := []string{"target_info", "http_requests_total"}
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"http_requests_total", true},
+		{"http_*", true},
+		{"*_build_info", false},
+		{"*_build_info|target_info", true},
+		{"*_build_info|build_info", false},
+	}
+	for _, tt := range tests {
+		if got := hasMatchingMetric(metrics, tt.pattern); got != tt.want {
+			t.Errorf("hasMatchingMetric(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -1,16 +1,28 @@
 package engine
 
+import "regexp"
+
 // RulesConfig represents the complete rules configuration from YAML
 type RulesConfig struct {
-	ExclusionList []ExclusionEntry `yaml:"exclusion_list"`
-	Rules         []RuleDefinition `yaml:"rules"`
+	Include       []string          `yaml:"include,omitempty"` // Rule pack files merged into this config
+	ExclusionList []ExclusionEntry  `yaml:"exclusion_list"`
+	InclusionList []InclusionEntry  `yaml:"inclusion_list,omitempty" json:",omitempty"` // When set, only matching jobs are evaluated
+	Conventions   ConventionsConfig `yaml:"conventions,omitempty"`
+	Rules         []RuleDefinition  `yaml:"rules"`
 }
 
 // ExclusionEntry defines a job or job+metrics to exclude from evaluation
 type ExclusionEntry struct {
-	Job            string   `yaml:"job,omitempty"`              // Exact job name to exclude
-	JobNamePattern string   `yaml:"job_name_pattern,omitempty"` // Regex pattern to match job names
-	Metrics        []string `yaml:"metrics,omitempty"`          // Specific metrics to exclude
+	Job            string   `yaml:"job,omitempty" json:"job,omitempty"`                           // Exact job name to exclude
+	JobNamePattern string   `yaml:"job_name_pattern,omitempty" json:"job_name_pattern,omitempty"` // Regex pattern to match job names
+	Metrics        []string `yaml:"metrics,omitempty" json:"metrics,omitempty"`                   // Specific metrics to exclude
+}
+
+// InclusionEntry defines a job to evaluate when the inclusion list is used
+// With an inclusion list every other job is excluded; the exclusion list still applies to included jobs.
+type InclusionEntry struct {
+	Job            string `yaml:"job,omitempty" json:"job,omitempty"`                           // Exact job name to include
+	JobNamePattern string `yaml:"job_name_pattern,omitempty" json:"job_name_pattern,omitempty"` // Regex pattern to match job names
 }
 
 // RuleDefinition represents a declarative rule loaded from YAML
@@ -18,23 +30,72 @@ type RuleDefinition struct {
 	RuleID      string            `yaml:"rule_id"`
 	Description string            `yaml:"description"`
 	Impact      string            `yaml:"impact"`
+	AppliesTo   []string          `yaml:"applies_to,omitempty"`                      // Metric types the rule targets (counter, gauge, histogram, summary); empty = all
+	SpecRuleIDs []string          `yaml:"spec_rule_ids,omitempty" json:",omitempty"` // Instrumentation Score specification rules this rule implements
 	Validators  []ValidatorConfig `yaml:"validators"`
 }
 
 // ValidatorConfig defines a validation check
 type ValidatorConfig struct {
-	Name          string                 `yaml:"name"`
-	Type          string                 `yaml:"type"` // "cardinality", "labels", "label_count", "format"
-	DataSource    string                 `yaml:"data_source"`
-	UITitle       string                 `yaml:"ui_title,omitempty"`
-	UIDescription string                 `yaml:"ui_description,omitempty"`
-	Conditions    []ConditionConfig      `yaml:"conditions"`
-	Parameters    map[string]interface{} `yaml:"parameters,omitempty"`
+	Name       string                 `yaml:"name"`
+	Type       string                 `yaml:"type"` // "cardinality", "labels", "label_count", "label_values", "format", "required_metrics"
+	DataSource string                 `yaml:"data_source"`
+	UI         ValidatorUI            `yaml:"ui,omitempty"` // How reports present a failure, see Metadata
+	Conditions []ConditionConfig      `yaml:"conditions"`
+	Parameters map[string]interface{} `yaml:"parameters,omitempty"`
+
+	// Deprecated: use UI.Title and UI.Description; still read when the ui block leaves them empty
+	UITitle       string `yaml:"ui_title,omitempty"`
+	UIDescription string `yaml:"ui_description,omitempty"`
+
+	// required_metrics: metrics that must exist, per job; every matching set applies
+	RequiredMetrics []RequiredMetricSet `yaml:"required_metrics,omitempty"`
+}
+
+// ValidatorUI is how reports present a validator's failures
+// The text is English; MessageKeys name translations of it in locale catalogs.
+type ValidatorUI struct {
+	Title       string      `yaml:"title,omitempty" json:"title,omitempty"`             // Short name of the problem, e.g. "High Cardinality"
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"` // What is wrong with a failing metric
+	Remediation string      `yaml:"remediation,omitempty" json:"remediation,omitempty"` // How to fix it
+	Severity    string      `yaml:"severity,omitempty" json:"severity,omitempty"`       // Severity text, e.g. "Review recommended"; defaults to the rule's impact
+	DocURL      string      `yaml:"doc_url,omitempty" json:"doc_url,omitempty"`         // Documentation of the rule
+	MessageKeys MessageKeys `yaml:"message_keys,omitempty" json:"message_keys,omitempty"`
+}
+
+// MessageKeys name the locale catalog messages translating the text of a ValidatorUI
+// A field without a key, or a key missing from the catalog, falls back to translating the English text.
+type MessageKeys struct {
+	Title       string `yaml:"title,omitempty" json:"title,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Remediation string `yaml:"remediation,omitempty" json:"remediation,omitempty"`
+	Severity    string `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// RequiredMetricSet lists metrics a job must expose, for jobs matched by name or regex pattern
+// A set with neither job nor job_name_pattern applies to every job.
+type RequiredMetricSet struct {
+	Job            string   `yaml:"job,omitempty"`
+	JobNamePattern string   `yaml:"job_name_pattern,omitempty"`
+	Metrics        []string `yaml:"metrics"` // Exact names or globs such as "*_build_info"
+
+	pattern *regexp.Regexp
 }
 
 // ConditionConfig defines a validation condition
 type ConditionConfig struct {
 	Field    string      `yaml:"field"`
-	Operator string      `yaml:"operator"` // "matches", "contains", "gt", "lt", "gte", "lte", "eq", "not_contains"
+	Operator string      `yaml:"operator"` // "matches", "contains", "gt", "lt", "gte", "lte", "eq", "not_contains", "one_of"
 	Value    interface{} `yaml:"value"`
+
+	// label_count options: labels that should not count toward the limit
+	IgnoreStandardLabels bool     `yaml:"ignore_standard_labels,omitempty"` // Ignore StandardLabels (job, instance, cluster, namespace)
+	IgnoreLabels         []string `yaml:"ignore_labels,omitempty"`          // Additional label names to ignore
+
+	// label_values options: labels whose sampled values are checked; empty = every sampled label
+	Labels []string `yaml:"labels,omitempty"`
 }
+
+// StandardLabels are target/topology labels attached to every series of a job.
+// They inflate label counts uniformly, so label_count conditions can opt out of them.
+var StandardLabels = []string{"job", "instance", "cluster", "namespace"}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"instrumentation-score/internal/loaders"
+)
+
+// ScrapeHealthDataSource is the data source carrying per-target scrape health
+// Its records come from SetScrapeHealth; without it the source is empty and
+// rules using it do not affect the score.
+const ScrapeHealthDataSource = "scrape_health"
+
+// SetScrapeHealth supplies the scrape health of every target, used by the scrape_health data source
+func (e *RuleEngine) SetScrapeHealth(health []loaders.ScrapeHealthData) {
+	e.scrapeHealth = make(map[string][]loaders.ScrapeHealthData)
+	for _, h := range health {
+		e.scrapeHealth[h.Job] = append(e.scrapeHealth[h.Job], h)
+	}
+}
+
+// buildScrapeHealthRecords exposes one record per target, named after its instance
+func buildScrapeHealthRecords(health []loaders.ScrapeHealthData) []Record {
+	records := make([]Record, 0, len(health))
+	for _, h := range health {
+		timeoutRatio := 0.0
+		if h.ScrapeTimeoutSeconds > 0 {
+			timeoutRatio = h.ScrapeDurationSeconds / h.ScrapeTimeoutSeconds
+		}
+		sampleLimitRatio := 0.0
+		if h.SampleLimit > 0 {
+			sampleLimitRatio = float64(h.SamplesPostRelabeling) / float64(h.SampleLimit)
+		}
+
+		records = append(records, Record{
+			MetricName: h.Instance,
+			Fields: map[string]interface{}{
+				"instance":                              h.Instance,
+				"up_ratio":                              h.UpRatio,
+				"up_changes":                            h.UpChanges,
+				"scrape_duration_seconds":               h.ScrapeDurationSeconds,
+				"scrape_timeout_seconds":                h.ScrapeTimeoutSeconds,
+				"scrape_timeout_ratio":                  timeoutRatio,
+				"scrape_samples_post_metric_relabeling": h.SamplesPostRelabeling,
+				"sample_limit":                          h.SampleLimit,
+				"sample_limit_ratio":                    sampleLimitRatio,
+			},
+		})
+	}
+	return records
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+const scrapeHealthRules = `
+rules:
+  - rule_id: "PROM-TGT-01"
+    impact: "Important"
+    validators:
+      - name: "availability"
+        type: "scrape_health"
+        data_source: "scrape_health"
+        conditions:
+          - field: "up_ratio"
+            operator: "gte"
+            value: 0.99
+      - name: "flapping"
+        type: "scrape_health"
+        data_source: "scrape_health"
+        conditions:
+          - field: "up_changes"
+            operator: "lte"
+            value: 2
+      - name: "limits"
+        type: "scrape_health"
+        data_source: "scrape_health"
+        conditions:
+          - field: "scrape_timeout_ratio"
+            operator: "lt"
+            value: 0.8
+          - field: "sample_limit_ratio"
+            operator: "lt"
+            value: 0.9
+`
+
+func TestEvaluateJob_ScrapeHealth(t *testing.T) {
+	ruleEngine, err := NewRuleEngine(writeRules(t, scrapeHealthRules))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+	jobData := []loaders.JobMetricData{{Job: "api", MetricName: "http_requests_total", Cardinality: 10}}
+
+	// Without scrape health the rule has nothing to evaluate
+	results, err := ruleEngine.EvaluateJob(jobData)
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	if results[0].TotalMetrics != 0 {
+		t.Fatalf("TotalMetrics = %d without scrape health, want 0", results[0].TotalMetrics)
+	}
+
+	ruleEngine.SetScrapeHealth([]loaders.ScrapeHealthData{
+		{Job: "api", Instance: "healthy", UpRatio: 1, ScrapeDurationSeconds: 1, ScrapeTimeoutSeconds: 10, SamplesPostRelabeling: 100, SampleLimit: 1000},
+		{Job: "api", Instance: "flapping", UpRatio: 0.8, UpChanges: 6},
+		{Job: "api", Instance: "slow", UpRatio: 1, ScrapeDurationSeconds: 9, ScrapeTimeoutSeconds: 10},
+		{Job: "api", Instance: "limit-hit", UpRatio: 1, SamplesPostRelabeling: 1200, SampleLimit: 1000},
+		{Job: "other", Instance: "down", UpRatio: 0},
+	})
+
+	results, err = ruleEngine.EvaluateJob(jobData)
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	result := results[0]
+	if result.TotalMetrics != 12 || result.PassedMetrics != 8 {
+		t.Errorf("passed %d/%d, want 8/12", result.PassedMetrics, result.TotalMetrics)
+	}
+
+	wantFailed := map[string][]string{
+		"flapping":  {"availability", "flapping"},
+		"slow":      {"limits"},
+		"limit-hit": {"limits"},
+	}
+	for instance, validators := range wantFailed {
+		got := result.FailedMetrics[instance]
+		if len(got) != len(validators) {
+			t.Errorf("%s failed %v, want %v", instance, got, validators)
+			continue
+		}
+		for i := range got {
+			if got[i] != validators[i] {
+				t.Errorf("%s failed %v, want %v", instance, got, validators)
+			}
+		}
+	}
+	if _, ok := result.FailedMetrics["down"]; ok {
+		t.Error("targets of other jobs must not be evaluated")
+	}
+}
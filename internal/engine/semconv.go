@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// OpenTelemetry semantic convention checks
+// Names are compared in their Prometheus form (dots as underscores, see otelName), so the
+// same tables apply to metrics exported by the collector and to dotted OTLP names.
+
+// deprecatedSemconvAttributes maps deprecated attribute names to their current replacement
+var deprecatedSemconvAttributes = map[string]string{
+	"http.method":            "http.request.method",
+	"http.status_code":       "http.response.status_code",
+	"http.url":               "url.full",
+	"http.target":            "url.path",
+	"http.scheme":            "url.scheme",
+	"http.flavor":            "network.protocol.version",
+	"http.user_agent":        "user_agent.original",
+	"http.client_ip":         "client.address",
+	"net.peer.name":          "server.address",
+	"net.peer.port":          "server.port",
+	"net.host.name":          "server.address",
+	"net.host.port":          "server.port",
+	"net.sock.peer.addr":     "network.peer.address",
+	"net.sock.peer.port":     "network.peer.port",
+	"net.protocol.name":      "network.protocol.name",
+	"net.protocol.version":   "network.protocol.version",
+	"net.transport":          "network.transport",
+	"db.statement":           "db.query.text",
+	"db.operation":           "db.operation.name",
+	"deployment.environment": "deployment.environment.name",
+}
+
+// deprecatedSemconvMetrics maps deprecated metric names to their current replacement
+var deprecatedSemconvMetrics = map[string]string{
+	"http.server.duration":                "http.server.request.duration",
+	"http.client.duration":                "http.client.request.duration",
+	"http.server.request.size":            "http.server.request.body.size",
+	"http.server.response.size":           "http.server.response.body.size",
+	"http.client.request.size":            "http.client.request.body.size",
+	"http.client.response.size":           "http.client.response.body.size",
+	"db.client.connections.usage":         "db.client.connection.count",
+	"process.runtime.jvm.memory.usage":    "jvm.memory.used",
+	"process.runtime.jvm.threads.count":   "jvm.thread.count",
+	"process.runtime.jvm.cpu.utilization": "jvm.cpu.recent_utilization",
+}
+
+// requiredResourceAttributes must be present on target_info, each as one of the listed names
+// service.name and service.instance.id are not listed: exporters map them to job and instance.
+var requiredResourceAttributes = [][]string{
+	{"service.version"},
+	{"deployment.environment.name", "deployment.environment"},
+}
+
+// prometheusUnitSuffixes are appended by the Prometheus exporters and stripped before lookup
+var prometheusUnitSuffixes = []string{"_total", "_seconds", "_milliseconds", "_bytes", "_ratio"}
+
+// semconvIndex converts a semconv table to Prometheus-form keys
+func semconvIndex(table map[string]string) map[string]string {
+	index := make(map[string]string, len(table))
+	for name, replacement := range table {
+		index[otelName(name)] = replacement
+	}
+	return index
+}
+
+var (
+	deprecatedAttributeIndex = semconvIndex(deprecatedSemconvAttributes)
+	deprecatedMetricIndex    = semconvIndex(deprecatedSemconvMetrics)
+)
+
+// isOTelJob reports whether a job's metrics were produced by an OpenTelemetry SDK or collector
+// OTel exporters always emit target_info and tag series with the instrumentation scope.
+func isOTelJob(jobData []loaders.JobMetricData) bool {
+	for _, jm := range jobData {
+		if otelName(jm.MetricName) == "target_info" {
+			return true
+		}
+		for _, label := range jm.Labels {
+			if otelName(label) == "otel_scope_name" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deprecatedAttributes returns the deprecated attribute names among labels
+func deprecatedAttributes(labels []string) []string {
+	var deprecated []string
+	for _, label := range labels {
+		if _, ok := deprecatedAttributeIndex[otelName(label)]; ok {
+			deprecated = append(deprecated, label)
+		}
+	}
+	sort.Strings(deprecated)
+	return deprecated
+}
+
+// deprecatedMetricReplacement returns the current name of a deprecated metric, or ""
+func deprecatedMetricReplacement(metricName string) string {
+	name := otelName(metricName)
+	for _, suffix := range prometheusUnitSuffixes {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return deprecatedMetricIndex[name]
+}
+
+// missingResourceAttributes returns the required resource attributes absent from labels
+func missingResourceAttributes(labels []string) []string {
+	present := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		present[otelName(label)] = true
+	}
+
+	var missing []string
+	for _, names := range requiredResourceAttributes {
+		found := false
+		for _, name := range names {
+			if present[otelName(name)] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, names[0])
+		}
+	}
+	return missing
+}
+
+// buildSemconvRecords checks each metric of an OTel job against current semantic conventions
+// Jobs not produced by OpenTelemetry yield no records, so semconv rules do not affect them.
+func buildSemconvRecords(jobData []loaders.JobMetricData) (interface{}, error) {
+	records := []Record{}
+	if !isOTelJob(jobData) {
+		return records, nil
+	}
+
+	for _, jm := range jobData {
+		if otelName(jm.MetricName) == "target_info" {
+			continue
+		}
+		deprecated := deprecatedAttributes(jm.Labels)
+		replacement := deprecatedMetricReplacement(jm.MetricName)
+		records = append(records, Record{
+			MetricName: jm.MetricName,
+			Type:       jm.Type,
+			Fields: map[string]interface{}{
+				"deprecated_attributes":      deprecated,
+				"deprecated_attribute_count": len(deprecated),
+				"deprecated_metric_name":     replacement != "",
+				"replacement_metric_name":    replacement,
+			},
+		})
+	}
+	return records, nil
+}
+
+// buildOTelResourceRecords checks the resource attributes of an OTel job, exported on target_info
+// An OTel job without target_info gets a record with every required attribute missing.
+func buildOTelResourceRecords(jobData []loaders.JobMetricData) (interface{}, error) {
+	records := []Record{}
+	if !isOTelJob(jobData) {
+		return records, nil
+	}
+
+	var labels []string
+	for _, jm := range jobData {
+		if otelName(jm.MetricName) == "target_info" {
+			labels = append(labels, jm.Labels...)
+		}
+	}
+
+	missing := missingResourceAttributes(labels)
+	records = append(records, Record{
+		MetricName: "target_info",
+		Type:       "gauge",
+		Fields: map[string]interface{}{
+			"missing_resource_attributes":      missing,
+			"missing_resource_attribute_count": len(missing),
+			"deprecated_attribute_count":       len(deprecatedAttributes(labels)),
+		},
+	})
+	return records, nil
+}
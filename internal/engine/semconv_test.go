@@ -0,0 +1,263 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestBuildSemconvRecords(t *testing.T) {
+	tests := []struct {
+		name           string
+		jobData        []loaders.JobMetricData
+		wantRecords    int
+		wantDeprecated map[string][]string // metric -> deprecated attributes
+		wantRenamed    map[string]string   // metric -> replacement
+	}{
+		{
+			name: "non-OTel job yields no records",
+			jobData: []loaders.JobMetricData{
+				{MetricName: "http_requests_total", Labels: []string{"method", "http_method"}},
+			},
+			wantRecords: 0,
+		},
+		{
+			name: "legacy attributes and metric names",
+			jobData: []loaders.JobMetricData{
+				{MetricName: "target_info", Labels: []string{"service_version"}},
+				{MetricName: "http_server_duration_milliseconds", Type: "histogram", Labels: []string{"http_method", "http_status_code", "otel_scope_name"}},
+				{MetricName: "http_server_request_duration_seconds", Type: "histogram", Labels: []string{"http_request_method", "http_response_status_code"}},
+				{MetricName: "db.client.connections.usage", Labels: []string{"net.peer.name"}},
+			},
+			wantRecords: 3,
+			wantDeprecated: map[string][]string{
+				"http_server_duration_milliseconds":    {"http_method", "http_status_code"},
+				"http_server_request_duration_seconds": nil,
+				"db.client.connections.usage":          {"net.peer.name"},
+			},
+			wantRenamed: map[string]string{
+				"http_server_duration_milliseconds":    "http.server.request.duration",
+				"http_server_request_duration_seconds": "",
+				"db.client.connections.usage":          "db.client.connection.count",
+			},
+		},
+		{
+			name: "scope label alone marks the job as OTel",
+			jobData: []loaders.JobMetricData{
+				{MetricName: "http_client_duration", Labels: []string{"otel_scope_name"}},
+			},
+			wantRecords: 1,
+			wantDeprecated: map[string][]string{
+				"http_client_duration": nil,
+			},
+			wantRenamed: map[string]string{
+				"http_client_duration": "http.client.request.duration",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := buildSemconvRecords(tt.jobData)
+			if err != nil {
+				t.Fatalf("buildSemconvRecords() error = %v", err)
+			}
+			records := data.([]Record)
+			if len(records) != tt.wantRecords {
+				t.Fatalf("got %d records, want %d", len(records), tt.wantRecords)
+			}
+			for _, record := range records {
+				deprecated := record.Fields["deprecated_attributes"].([]string)
+				if !reflect.DeepEqual(deprecated, tt.wantDeprecated[record.MetricName]) {
+					t.Errorf("%s deprecated_attributes = %v, want %v", record.MetricName, deprecated, tt.wantDeprecated[record.MetricName])
+				}
+				if record.Fields["deprecated_attribute_count"] != len(deprecated) {
+					t.Errorf("%s deprecated_attribute_count = %v, want %d", record.MetricName, record.Fields["deprecated_attribute_count"], len(deprecated))
+				}
+				replacement := tt.wantRenamed[record.MetricName]
+				if record.Fields["replacement_metric_name"] != replacement || record.Fields["deprecated_metric_name"] != (replacement != "") {
+					t.Errorf("%s replacement = %v (deprecated %v), want %q", record.MetricName, record.Fields["replacement_metric_name"], record.Fields["deprecated_metric_name"], replacement)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildOTelResourceRecords(t *testing.T) {
+	tests := []struct {
+		name           string
+		jobData        []loaders.JobMetricData
+		wantRecords    int
+		wantMissing    []string
+		wantDeprecated int
+	}{
+		{
+			name:        "non-OTel job yields no records",
+			jobData:     []loaders.JobMetricData{{MetricName: "up"}},
+			wantRecords: 0,
+		},
+		{
+			name: "complete resource",
+			jobData: []loaders.JobMetricData{
+				{MetricName: "target_info", Labels: []string{"job", "instance", "service_version", "deployment_environment_name"}},
+			},
+			wantRecords: 1,
+		},
+		{
+			name: "deprecated environment attribute satisfies presence but is reported",
+			jobData: []loaders.JobMetricData{
+				{MetricName: "target_info", Labels: []string{"service.version", "deployment.environment"}},
+			},
+			wantRecords:    1,
+			wantDeprecated: 1,
+		},
+		{
+			name: "OTel job without target_info misses everything",
+			jobData: []loaders.JobMetricData{
+				{MetricName: "rpc_server_duration", Labels: []string{"otel_scope_name"}},
+			},
+			wantRecords: 1,
+			wantMissing: []string{"service.version", "deployment.environment.name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := buildOTelResourceRecords(tt.jobData)
+			if err != nil {
+				t.Fatalf("buildOTelResourceRecords() error = %v", err)
+			}
+			records := data.([]Record)
+			if len(records) != tt.wantRecords {
+				t.Fatalf("got %d records, want %d", len(records), tt.wantRecords)
+			}
+			if tt.wantRecords == 0 {
+				return
+			}
+			fields := records[0].Fields
+			if missing := fields["missing_resource_attributes"].([]string); !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("missing_resource_attributes = %v, want %v", missing, tt.wantMissing)
+			}
+			if fields["deprecated_attribute_count"] != tt.wantDeprecated {
+				t.Errorf("deprecated_attribute_count = %v, want %d", fields["deprecated_attribute_count"], tt.wantDeprecated)
+			}
+		})
+	}
+}
+
+func TestEvaluateJob_SemconvPack(t *testing.T) {
+	pack, err := filepath.Abs(filepath.Join("..", "..", "rules", "packs", "otel-semconv.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ruleEngine, err := NewRuleEngine(writeRules(t, "include:\n  - "+pack+"\nrules: []\n"))
+	if err != nil {
+		t.Fatalf("NewRuleEngine() error = %v", err)
+	}
+
+	otelJob := []loaders.JobMetricData{
+		{Job: "checkout", MetricName: "target_info", Labels: []string{"service_version", "deployment_environment_name"}},
+		{Job: "checkout", MetricName: "http_server_duration_milliseconds", Labels: []string{"http_method"}},
+		{Job: "checkout", MetricName: "http_server_request_duration_seconds", Labels: []string{"http_request_method"}},
+	}
+	results, err := ruleEngine.EvaluateJob(otelJob)
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	byID := make(map[string]RuleResult)
+	for _, result := range results {
+		byID[result.RuleID] = result
+	}
+	if got := byID["OTEL-SEM-01"]; got.PassedMetrics != 2 || got.TotalMetrics != 4 {
+		t.Errorf("OTEL-SEM-01 passed %d/%d, want 2/4", got.PassedMetrics, got.TotalMetrics)
+	}
+	if got := byID["OTEL-SEM-01"].FailedMetrics["http_server_duration_milliseconds"]; len(got) != 2 {
+		t.Errorf("http_server_duration_milliseconds failed %v, want both validators", got)
+	}
+	if got := byID["OTEL-SEM-02"]; got.PassedMetrics != 1 || got.TotalMetrics != 1 {
+		t.Errorf("OTEL-SEM-02 passed %d/%d, want 1/1", got.PassedMetrics, got.TotalMetrics)
+	}
+
+	// Prometheus-native jobs are unaffected by the pack
+	results, err = ruleEngine.EvaluateJob([]loaders.JobMetricData{{Job: "api", MetricName: "http_requests_total", Labels: []string{"http_method"}}})
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	for _, result := range results {
+		if result.TotalMetrics != 0 {
+			t.Errorf("%s evaluated %d metrics of a non-OTel job", result.RuleID, result.TotalMetrics)
+		}
+	}
+}
+
+func TestNewRuleEngine_Include(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("pack.yaml", `
+exclusion_list:
+  - job: "pack-excluded"
+rules:
+  - rule_id: "PACK-01"
+    impact: "Low"
+    validators:
+      - name: "pack_check"
+        type: "format"
+        data_source: "labels"
+        conditions:
+          - field: "metric_name"
+            operator: "matches"
+            value: "^[a-z_]+$"
+`)
+	write("nested.yaml", "include:\n  - pack.yaml\n")
+	write("conventions.yaml", "conventions:\n  pack: otel\n")
+
+	tests := []struct {
+		name      string
+		include   string
+		wantErr   string
+		wantRules int
+	}{
+		{name: "relative path", include: "pack.yaml", wantRules: 2},
+		{name: "missing pack", include: "absent.yaml", wantErr: "failed to read included rule pack"},
+		{name: "nested include", include: "nested.yaml", wantErr: "cannot include other packs"},
+		{name: "pack sets conventions", include: "conventions.yaml", wantErr: "cannot set conventions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			main := filepath.Join(dir, "rules.yaml")
+			write("rules.yaml", `
+include:
+  - `+tt.include+`
+rules:
+  - rule_id: "MAIN-01"
+    impact: "Low"
+    validators: []
+`)
+			ruleEngine, err := NewRuleEngine(main)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewRuleEngine() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRuleEngine() error = %v", err)
+			}
+			if len(ruleEngine.rules) != tt.wantRules {
+				t.Errorf("got %d rules, want %d", len(ruleEngine.rules), tt.wantRules)
+			}
+			if !ruleEngine.IsJobExcluded("pack-excluded") {
+				t.Error("pack exclusion was not merged")
+			}
+		})
+	}
+}
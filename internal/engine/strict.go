@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NewRuleEngineStrict is NewRuleEngine rejecting rules files and packs with unknown fields,
+// such as a misspelled pass_percentage, which NewRuleEngine silently ignores
+func NewRuleEngineStrict(rulesFile string) (*RuleEngine, error) {
+	return newRuleEngine(rulesFile, true, osReadFile, resolveRelative, defaultRegistry)
+}
+
+// decodeRulesConfig unmarshals a rules file or pack; in strict mode unknown fields are
+// errors, reported with the closest known field of the same block
+func decodeRulesConfig(data []byte, config *RulesConfig, strict bool) error {
+	if !strict {
+		return yaml.Unmarshal(data, config)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(config)
+	if errors.Is(err, io.EOF) {
+		return nil // Empty file, as yaml.Unmarshal accepts
+	}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		return &yaml.TypeError{Errors: suggestKnownFields(typeErr.Errors)}
+	}
+	return err
+}
+
+// unknownFieldPattern matches yaml.v3's error for a field KnownFields rejected
+var unknownFieldPattern = regexp.MustCompile(`^(line \d+: )field (\S+) not found in type (\S+)$`)
+
+// suggestKnownFields rewrites unknown field errors to name the block and, when one is
+// close enough to be a typo, the known field that was probably meant
+func suggestKnownFields(messages []string) []string {
+	fields := knownFields(reflect.TypeOf(RulesConfig{}), map[string][]string{})
+	rewritten := make([]string, len(messages))
+	for i, msg := range messages {
+		rewritten[i] = msg
+		match := unknownFieldPattern.FindStringSubmatch(msg)
+		if match == nil {
+			continue
+		}
+		block := strings.TrimPrefix(match[3], "engine.")
+		known, ok := fields[block]
+		if !ok {
+			continue
+		}
+		rewritten[i] = fmt.Sprintf("%sunknown field %q in %s", match[1], match[2], block)
+		if suggestion := closestField(match[2], known); suggestion != "" {
+			rewritten[i] += fmt.Sprintf(", did you mean %q?", suggestion)
+		} else {
+			rewritten[i] += fmt.Sprintf(" (known fields: %s)", strings.Join(known, ", "))
+		}
+	}
+	return rewritten
+}
+
+// knownFields collects the yaml field names of t and of the structs nested in it, by type name
+func knownFields(t reflect.Type, fields map[string][]string) map[string][]string {
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	if _, seen := fields[t.Name()]; seen {
+		return fields
+	}
+	fields[t.Name()] = nil
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		names = append(names, name)
+		knownFields(field.Type, fields)
+	}
+	sort.Strings(names)
+	fields[t.Name()] = names
+	return fields
+}
+
+// closestField returns the known field within typo distance of name, or ""
+func closestField(name string, known []string) string {
+	best, bestDistance := "", len(name)/3+1
+	if bestDistance < 2 {
+		bestDistance = 2
+	}
+	for _, candidate := range known {
+		if d := editDistance(name, candidate); d <= bestDistance && (best == "" || d < editDistance(name, best)) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeStrictRules(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "rules.yaml")
+}
+
+const strictRulesTemplate = `rules:
+  - rule_id: "TEST-01"
+    description: "Test rule"
+    impact: "Important"
+    validators:
+      - name: "cardinality_check"
+        type: "cardinality"
+        data_source: "cardinality"
+        conditions:
+          - field: "cardinality"
+            %s: "lt"
+            value: 10000
+`
+
+func TestNewRuleEngineStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr []string
+	}{
+		{
+			name:  "known fields",
+			files: map[string]string{"rules.yaml": strings.Replace(strictRulesTemplate, "%s", "operator", 1)},
+		},
+		{
+			name:    "misspelled field",
+			files:   map[string]string{"rules.yaml": strings.Replace(strictRulesTemplate, "%s", "operater", 1)},
+			wantErr: []string{"line 11:", `unknown field "operater" in ConditionConfig`, `did you mean "operator"?`},
+		},
+		{
+			name:    "unrelated field",
+			files:   map[string]string{"rules.yaml": "exclusion_list:\n  - job: api\n    threshold: 90\n"},
+			wantErr: []string{`unknown field "threshold" in ExclusionEntry (known fields: job, job_name_pattern, metrics)`},
+		},
+		{
+			name: "included pack",
+			files: map[string]string{
+				"rules.yaml": "include: [pack.yaml]\n",
+				"pack.yaml":  "rulez: []\n",
+			},
+			wantErr: []string{"included rule pack pack.yaml", `unknown field "rulez" in RulesConfig, did you mean "rules"?`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rulesFile := writeStrictRules(t, tt.files)
+			if _, err := NewRuleEngine(rulesFile); err != nil {
+				t.Fatalf("NewRuleEngine() error = %v, want unknown fields ignored", err)
+			}
+			_, err := NewRuleEngineStrict(rulesFile)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("NewRuleEngineStrict() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("NewRuleEngineStrict() expected an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("NewRuleEngineStrict() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClosestField(t *testing.T) {
+	known := []string{"field", "operator", "value", "ignore_labels", "labels"}
+	tests := map[string]string{
+		"operater":      "operator",
+		"valeu":         "value",
+		"ignore_label":  "ignore_labels",
+		"lables":        "labels",
+		"threshold":     "",
+		"pass_percentg": "",
+	}
+	for name, want := range tests {
+		if got := closestField(name, known); got != want {
+			t.Errorf("closestField(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
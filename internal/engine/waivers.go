@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"sort"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// Acknowledgement is an active waiver for a metric failing a rule
+type Acknowledgement struct {
+	MetricName string
+	Reason     string
+	Expires    string // YYYY-MM-DD, the last day the waiver applies
+	Exempt     bool   // Counted as passing in the score until it expires
+}
+
+// AcknowledgeFunc returns the active waiver for a metric failing a rule, if there is one
+type AcknowledgeFunc func(ruleID, metricName string) (Acknowledgement, bool)
+
+// ApplyWaivers records which failures a team has acknowledged
+// Acknowledged metrics still fail and stay in FailedMetrics, so reports can show them
+// distinctly. Exempt ones are credited back as passed, so they stop lowering the score
+// (and any gate on it) until their waiver expires. Call it before ApplyScoreDecay, which
+// does not penalize exempt failures.
+func ApplyWaivers(results []RuleResult, jobData []loaders.JobMetricData, acknowledge AcknowledgeFunc) {
+	cardinality := make(map[string]int64, len(jobData))
+	for _, jm := range jobData {
+		cardinality[jm.MetricName] += jm.Cardinality
+	}
+
+	for i := range results {
+		result := &results[i]
+		result.Acknowledged = nil
+		result.WaivedCredit = 0
+		for metricName, validators := range result.FailedMetrics {
+			ack, ok := acknowledge(result.RuleID, metricName)
+			if !ok {
+				continue
+			}
+			ack.MetricName = metricName
+			result.Acknowledged = append(result.Acknowledged, ack)
+			if !ack.Exempt {
+				continue
+			}
+			if result.TotalCardinality > 0 {
+				result.WaivedCredit += cardinality[metricName]
+			} else {
+				result.WaivedCredit += int64(len(validators))
+			}
+		}
+
+		// Never credit more than actually failed
+		failed := int64(result.TotalMetrics - result.PassedMetrics)
+		if result.TotalCardinality > 0 {
+			failed = result.TotalCardinality - result.PassedCardinality
+		}
+		if result.WaivedCredit > failed {
+			result.WaivedCredit = failed
+		}
+		sort.Slice(result.Acknowledged, func(a, b int) bool {
+			return result.Acknowledged[a].MetricName < result.Acknowledged[b].MetricName
+		})
+	}
+}
+
+// exemptMetrics returns the failed metrics of a result whose waiver exempts them from the score
+func exemptMetrics(result RuleResult) map[string]bool {
+	exempt := make(map[string]bool)
+	for _, ack := range result.Acknowledged {
+		if ack.Exempt {
+			exempt[ack.MetricName] = true
+		}
+	}
+	return exempt
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestApplyWaivers(t *testing.T) {
+	jobData := []loaders.JobMetricData{
+		{MetricName: "legacy_total", Cardinality: 50},
+		{MetricName: "acked_only", Cardinality: 10},
+		{MetricName: "unwaived", Cardinality: 40},
+	}
+	waivers := map[string]Acknowledgement{
+		"PROM-MET-01/legacy_total": {Reason: "migrating", Expires: "2099-01-01", Exempt: true},
+		"PROM-MET-01/acked_only":   {Reason: "known"},
+		"PROM-MET-02/legacy_total": {Reason: "migrating", Exempt: true},
+	}
+	acknowledge := func(ruleID, metricName string) (Acknowledgement, bool) {
+		ack, ok := waivers[ruleID+"/"+metricName]
+		return ack, ok
+	}
+
+	newResults := func() []RuleResult {
+		return []RuleResult{
+			{
+				RuleID: "PROM-MET-01", Impact: "Important",
+				FailedMetrics: map[string][]string{"legacy_total": {"a", "b"}, "acked_only": {"a"}, "unwaived": {"a"}},
+				PassedMetrics: 2, TotalMetrics: 6,
+			},
+			{
+				RuleID: "PROM-MET-02", Impact: "Critical",
+				FailedMetrics:     map[string][]string{"legacy_total": {"v"}},
+				PassedCardinality: 50, TotalCardinality: 100,
+			},
+		}
+	}
+
+	results := newResults()
+	ApplyWaivers(results, jobData, acknowledge)
+
+	if got := len(results[0].Acknowledged); got != 2 {
+		t.Fatalf("acknowledged %d failures, want 2", got)
+	}
+	if first := results[0].Acknowledged[0]; first.MetricName != "acked_only" || first.Exempt {
+		t.Errorf("first acknowledgement = %+v, want acked_only, not exempt", first)
+	}
+	if results[0].WaivedCredit != 2 {
+		t.Errorf("metric-scored rule credit = %d, want 2 (one per failed validator)", results[0].WaivedCredit)
+	}
+	if results[1].WaivedCredit != 50 {
+		t.Errorf("cardinality-scored rule credit = %d, want 50 series", results[1].WaivedCredit)
+	}
+	if len(results[0].FailedMetrics) != 3 {
+		t.Errorf("acknowledged metrics must stay failed, got %v", results[0].FailedMetrics)
+	}
+	if waived, plain := CalculateInstrumentationScore(results), CalculateInstrumentationScore(newResults()); waived <= plain {
+		t.Errorf("waived score %.2f, want above %.2f", waived, plain)
+	}
+
+	// Exempt failures are not penalized as chronic
+	ApplyScoreDecay(results, jobData, func(string, string) int { return 10 }, 2, 2)
+	if len(results[0].ChronicMetrics) != 2 || results[0].ChronicMetrics[0] != "acked_only" {
+		t.Errorf("chronic metrics = %v, want [acked_only unwaived]", results[0].ChronicMetrics)
+	}
+}
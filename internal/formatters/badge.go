@@ -0,0 +1,52 @@
+package formatters
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// badgeColors colors a badge by score category, like shields.io's brightgreen to red
+var badgeColors = map[string]string{
+	"Excellent":         "#4c1",
+	"Good":              "#97ca00",
+	"Needs Improvement": "#dfb317",
+	"Poor":              "#e05d44",
+}
+
+// Badge renders a flat SVG badge showing label and score, colored by score category
+// Text width is estimated from the character count, so the badge needs no font metrics.
+func Badge(label string, score float64) string {
+	value := fmt.Sprintf("%.1f%%", score)
+	labelWidth := badgeTextWidth(label)
+	valueWidth := badgeTextWidth(value)
+	width := labelWidth + valueWidth
+	color := badgeColors[getScoreCategory(score)]
+	label = html.EscapeString(label)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+"\n", width, label, value)
+	fmt.Fprintf(&b, "  <title>%s: %s</title>\n", label, value)
+	fmt.Fprintf(&b, `  <rect width="%d" height="20" rx="3" fill="#555"/>`+"\n", width)
+	fmt.Fprintf(&b, `  <rect x="%d" width="%d" height="20" rx="3" fill="%s"/>`+"\n", labelWidth, valueWidth, color)
+	b.WriteString(`  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` + "\n")
+	fmt.Fprintf(&b, `    <text x="%d" y="14">%s</text>`+"\n", labelWidth/2, label)
+	fmt.Fprintf(&b, `    <text x="%d" y="14">%s</text>`+"\n", labelWidth+valueWidth/2, value)
+	b.WriteString("  </g>\n</svg>\n")
+	return b.String()
+}
+
+// badgeTextWidth estimates the width of text at 11px Verdana plus padding
+func badgeTextWidth(text string) int {
+	return len([]rune(text))*7 + 10
+}
+
+// PrometheusOrganizationScore renders the organization score as a gauge labeled with its weighting
+func PrometheusOrganizationScore(score float64, weighting string) string {
+	var output strings.Builder
+	name := metricName("organization_score")
+	output.WriteString(fmt.Sprintf("# HELP %s Instrumentation quality score of the whole organization (0-100)\n", name))
+	output.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+	output.WriteString(fmt.Sprintf("%s %.2f\n\n", series(name, "weighting", weighting), score))
+	return output.String()
+}
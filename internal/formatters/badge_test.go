@@ -0,0 +1,43 @@
+package formatters
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBadge(t *testing.T) {
+	tests := []struct {
+		score float64
+		color string
+	}{
+		{95, "#4c1"},
+		{80, "#97ca00"},
+		{60, "#dfb317"},
+		{20, "#e05d44"},
+	}
+	for _, tt := range tests {
+		badge := Badge("instrumentation <score>", tt.score)
+		if !strings.Contains(badge, `fill="`+tt.color+`"`) {
+			t.Errorf("Badge(%v) is not colored %s:\n%s", tt.score, tt.color, badge)
+		}
+		if !strings.Contains(badge, "instrumentation &lt;score&gt;") {
+			t.Errorf("Badge() does not escape the label:\n%s", badge)
+		}
+	}
+	if badge := Badge("score", 87.25); !strings.Contains(badge, ">87.2%<") && !strings.Contains(badge, ">87.3%<") {
+		t.Errorf("Badge() does not show the score:\n%s", badge)
+	}
+}
+
+func TestPrometheusOrganizationScore(t *testing.T) {
+	t.Cleanup(func() { _ = SetMetricNaming(DefaultMetricPrefix, nil) })
+	if err := SetMetricNaming(DefaultMetricPrefix, map[string]string{"env": "prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := PrometheusOrganizationScore(82.5, "cardinality")
+	want := DefaultMetricPrefix + `_organization_score{weighting="cardinality",env="prod"} 82.50`
+	if !strings.Contains(got, want) {
+		t.Errorf("PrometheusOrganizationScore() =\n%s\nwant a line %s", got, want)
+	}
+}
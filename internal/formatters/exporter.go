@@ -0,0 +1,43 @@
+package formatters
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExporterRun describes the scoring runs of serve --exporter, so scrapes can tell how fresh the scores are
+type ExporterRun struct {
+	LastSuccess time.Time     // When the exported scores were collected
+	Duration    time.Duration // Of the latest run
+	Failed      bool          // The latest run failed and the scores are those of LastSuccess
+}
+
+// PrometheusExporterMetrics renders the freshness metadata of the exported scores
+// Alert on time() - instrumentation_score_last_run_timestamp to catch scores that stopped updating.
+func PrometheusExporterMetrics(run ExporterRun) string {
+	var output strings.Builder
+	gauge := func(name, help string, value float64) {
+		name = metricName(name)
+		output.WriteString(fmt.Sprintf("# HELP %s %s\n", name, help))
+		output.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+		output.WriteString(fmt.Sprintf("%s %s\n\n", series(name), formatGaugeValue(value)))
+	}
+
+	gauge("score_last_run_timestamp", "Unix time the exported scores were collected", float64(run.LastSuccess.Unix()))
+	gauge("score_last_run_duration_seconds", "Duration of the latest scoring run", run.Duration.Seconds())
+	success := 1.0
+	if run.Failed {
+		success = 0
+	}
+	gauge("score_last_run_success", "Whether the latest scoring run succeeded (1) or failed and older scores are exported (0)", success)
+	return output.String()
+}
+
+// formatGaugeValue writes whole numbers such as timestamps without a fraction
+func formatGaugeValue(value float64) string {
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.3f", value)
+}
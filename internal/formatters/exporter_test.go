@@ -0,0 +1,27 @@
+package formatters
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusExporterMetrics(t *testing.T) {
+	run := ExporterRun{LastSuccess: time.Unix(1760000000, 0), Duration: 1500 * time.Millisecond}
+	metrics := PrometheusExporterMetrics(run)
+	for _, want := range []string{
+		"# TYPE instrumentation_score_last_run_timestamp gauge\n",
+		"instrumentation_score_last_run_timestamp 1760000000\n",
+		"instrumentation_score_last_run_duration_seconds 1.500\n",
+		"instrumentation_score_last_run_success 1\n",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics lack %q:\n%s", want, metrics)
+		}
+	}
+
+	run.Failed = true
+	if metrics := PrometheusExporterMetrics(run); !strings.Contains(metrics, "instrumentation_score_last_run_success 0\n") {
+		t.Errorf("a failed run should export success 0:\n%s", metrics)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
-	"log"
+	"io"
 	"os"
 	"strings"
 
 	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/kube"
+	"instrumentation-score/internal/locale"
+	"instrumentation-score/internal/spec"
 	"instrumentation-score/web"
 
 	"gopkg.in/yaml.v3"
@@ -22,25 +25,48 @@ type OutputData struct {
 	Results     []engine.RuleResult `json:"rule_results"`
 }
 
+// reportLocale formats numbers and dates and translates the text and HTML reports
+var reportLocale = locale.Default
+
+// SetLocale sets the locale of the text and HTML reports
+// JSON, Prometheus and the other machine-readable formats are not affected.
+func SetLocale(l *locale.Locale) {
+	reportLocale = l
+}
+
 // PrometheusMetrics outputs results in Prometheus format
 func PrometheusMetrics(serviceName string, score float64, results []engine.RuleResult) {
-	fmt.Printf("# HELP instrumentation_score Overall instrumentation quality score (0-100)\n")
-	fmt.Printf("# TYPE instrumentation_score gauge\n")
-	fmt.Printf("instrumentation_score{service_name=\"%s\"} %.1f\n", serviceName, score)
+	PrometheusMetricsWithConfidence(serviceName, "", score, results)
+}
 
-	fmt.Printf("\n# HELP instrumentation_rule_checks_total Total number of rule checks\n")
-	fmt.Printf("# TYPE instrumentation_rule_checks_total counter\n")
+// PrometheusMetricsWithConfidence outputs results in Prometheus format with the confidence
+// level of the score as a label of score_confidence; an empty confidence leaves it out
+func PrometheusMetricsWithConfidence(serviceName string, confidence string, score float64, results []engine.RuleResult) {
+	name := metricName("score")
+	fmt.Printf("# HELP %s Overall instrumentation quality score (0-100)\n", name)
+	fmt.Printf("# TYPE %s gauge\n", name)
+	fmt.Printf("%s %.1f\n", series(name, "service_name", serviceName), score)
+
+	if confidence != "" {
+		name = metricName("score_confidence")
+		fmt.Printf("\n# HELP %s Confidence level of the score, lower when it rests on partial data\n", name)
+		fmt.Printf("# TYPE %s gauge\n", name)
+		fmt.Printf("%s 1\n", series(name, "service_name", serviceName, "level", confidence))
+	}
+
+	name = metricName("rule_checks_total")
+	fmt.Printf("\n# HELP %s Total number of rule checks\n", name)
+	fmt.Printf("# TYPE %s counter\n", name)
 	for _, result := range results {
-		fmt.Printf("instrumentation_rule_checks_total{service_name=\"%s\",rule_id=\"%s\",impact=\"%s\"} %d\n",
-			serviceName, result.RuleID, result.Impact, result.TotalChecks)
+		fmt.Printf("%s %d\n", series(name, "service_name", serviceName, "rule_id", result.RuleID, "impact", result.Impact), result.TotalChecks)
 	}
 
-	fmt.Printf("\n# HELP instrumentation_rule_failures_total Total number of rule failures\n")
-	fmt.Printf("# TYPE instrumentation_rule_failures_total counter\n")
+	name = metricName("rule_failures_total")
+	fmt.Printf("\n# HELP %s Total number of rule failures\n", name)
+	fmt.Printf("# TYPE %s counter\n", name)
 	for _, result := range results {
 		failures := result.TotalChecks - result.PassedChecks
-		fmt.Printf("instrumentation_rule_failures_total{service_name=\"%s\",rule_id=\"%s\",impact=\"%s\"} %d\n",
-			serviceName, result.RuleID, result.Impact, failures)
+		fmt.Printf("%s %d\n", series(name, "service_name", serviceName, "rule_id", result.RuleID, "impact", result.Impact), failures)
 	}
 }
 
@@ -52,6 +78,8 @@ type JobScoreData struct {
 	EstimatedCost    float64
 	Score            float64
 	RuleResults      []engine.RuleResult
+	SourceFile       string // Path of the job file, where SARIF results are located; empty when unknown
+	Confidence       string // Confidence level of the score, see score.Confidence; empty when unknown
 }
 
 // PrometheusMetricsWithSLO outputs per-job instrumentation score metrics for Cortex.io SLO tracking
@@ -66,18 +94,127 @@ func PrometheusMetricsWithSLO(jobs []JobScoreData) string {
 
 	// Instrumentation Quality Score (0-100 scale)
 	// Primary metric for SLO tracking in Cortex.io
-	output.WriteString("# HELP instrumentation_quality_score Instrumentation quality score per job (0-100)\n")
-	output.WriteString("# TYPE instrumentation_quality_score gauge\n")
+	name := metricName("quality_score")
+	output.WriteString(fmt.Sprintf("# HELP %s Instrumentation quality score per job (0-100)\n", name))
+	output.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+	for _, job := range jobs {
+		output.WriteString(fmt.Sprintf("%s %.2f\n", series(name, "job", job.JobName), job.Score))
+	}
+	output.WriteString("\n")
+
+	// The level is a label, so an SLO query can leave out scores resting on partial data
+	var rated []JobScoreData
+	for _, job := range jobs {
+		if job.Confidence != "" {
+			rated = append(rated, job)
+		}
+	}
+	if len(rated) > 0 {
+		name = metricName("quality_score_confidence")
+		output.WriteString(fmt.Sprintf("# HELP %s Confidence level of the instrumentation quality score per job\n", name))
+		output.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+		for _, job := range rated {
+			output.WriteString(fmt.Sprintf("%s 1\n", series(name, "job", job.JobName, "level", job.Confidence)))
+		}
+		output.WriteString("\n")
+	}
+
+	return output.String()
+}
+
+// scoreCategoryLabels are the category label values of instrumentation_quality_jobs, best first
+var scoreCategoryLabels = []struct {
+	category string
+	label    string
+}{
+	{"Excellent", "excellent"},
+	{"Good", "good"},
+	{"Needs Improvement", "needs_improvement"},
+	{"Poor", "poor"},
+}
+
+// PrometheusRuleMetrics outputs per-job, per-rule results and the number of jobs per score category
+// Every category is written, including empty ones, so dashboards and alerts see 0 rather
+// than a missing series.
+func PrometheusRuleMetrics(jobs []JobScoreData) string {
+	var output strings.Builder
+
+	name := metricName("rule_metrics")
+	output.WriteString(fmt.Sprintf("# HELP %s Metrics of the job evaluated by the rule\n", name))
+	output.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+	for _, job := range jobs {
+		for _, result := range job.RuleResults {
+			output.WriteString(fmt.Sprintf("%s %d\n",
+				series(name, "job", job.JobName, "rule_id", result.RuleID, "impact", result.Impact), result.TotalMetrics))
+		}
+	}
+	output.WriteString("\n")
+
+	name = metricName("rule_failed_metrics")
+	output.WriteString(fmt.Sprintf("# HELP %s Metrics of the job failing the rule\n", name))
+	output.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+	for _, job := range jobs {
+		for _, result := range job.RuleResults {
+			output.WriteString(fmt.Sprintf("%s %d\n",
+				series(name, "job", job.JobName, "rule_id", result.RuleID, "impact", result.Impact), len(result.FailedMetrics)))
+		}
+	}
+	output.WriteString("\n")
+
+	counts := make(map[string]int)
 	for _, job := range jobs {
-		output.WriteString(fmt.Sprintf("instrumentation_quality_score{job=\"%s\"} %.2f\n", job.JobName, job.Score))
+		counts[getScoreCategory(job.Score)]++
+	}
+	name = metricName("quality_jobs")
+	output.WriteString(fmt.Sprintf("# HELP %s Jobs per instrumentation score category\n", name))
+	output.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+	for _, category := range scoreCategoryLabels {
+		output.WriteString(fmt.Sprintf("%s %d\n", series(name, "category", category.label), counts[category.category]))
 	}
 	output.WriteString("\n")
 
 	return output.String()
 }
 
+// CRDManifests renders one InstrumentationScore custom resource per job as multi-document YAML
+// The resources match what the controller writes, so GitOps pipelines can commit score state
+// and review changes to it like any other manifest.
+func CRDManifests(jobs []JobScoreData, namespace string, minScore float64, timestamp string) (string, error) {
+	var output strings.Builder
+	for i, job := range jobs {
+		resource := kube.NewInstrumentationScore(job.JobName, namespace, job.JobName)
+		resource.Status = kube.InstrumentationScoreStatus{
+			Score:            job.Score,
+			MinScore:         minScore,
+			Passed:           job.Score >= minScore,
+			TotalMetrics:     job.TotalMetrics,
+			TotalCardinality: job.TotalCardinality,
+			LastEvaluated:    timestamp,
+			Confidence:       job.Confidence,
+		}
+		for _, result := range job.RuleResults {
+			resource.Status.Rules = append(resource.Status.Rules, kube.RuleStatus{
+				RuleID:       result.RuleID,
+				Impact:       result.Impact,
+				PassedChecks: result.PassedChecks,
+				TotalChecks:  result.TotalChecks,
+			})
+		}
+
+		data, err := yaml.Marshal(resource)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal %s: %w", job.JobName, err)
+		}
+		if i > 0 {
+			output.WriteString("---\n")
+		}
+		output.Write(data)
+	}
+	return output.String(), nil
+}
+
 // JSON outputs results in JSON format
-func JSON(serviceName string, score float64, results []engine.RuleResult) {
+func JSON(serviceName string, score float64, results []engine.RuleResult) error {
 	category := getScoreCategory(score)
 
 	output := OutputData{
@@ -89,30 +226,59 @@ func JSON(serviceName string, score float64, results []engine.RuleResult) {
 
 	jsonData, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
-		log.Fatalf("Error marshaling JSON: %v", err)
+		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
 	fmt.Println(string(jsonData))
+	return nil
 }
 
 // Text outputs results in human-readable text format
 func Text(serviceName string, score float64, results []engine.RuleResult) {
-	category := getScoreCategory(score)
+	category := localizedCategory(score)
 
 	fmt.Printf("Instrumentation Score Report for %s\n", serviceName)
 	fmt.Printf("=====================================\n\n")
-	fmt.Printf("Overall Score: %.1f/100 (%s)\n\n", score, category)
+	fmt.Printf("Overall Score: %s/100 (%s)\n\n", reportLocale.Float(score, 1), category)
 
 	fmt.Printf("Rule Evaluation Results:\n")
 	fmt.Printf("------------------------\n")
 
 	for _, result := range results {
 		passRate := float64(result.PassedMetrics) / float64(result.TotalMetrics) * 100
-		fmt.Printf("Rule %s (%s): %d/%d metrics passed (%.1f%%)\n",
-			result.RuleID, result.Impact, result.PassedMetrics, result.TotalMetrics, passRate)
+		fmt.Printf("Rule %s (%s): %s/%s metrics passed (%s%%)\n",
+			result.RuleID, result.Impact, reportLocale.Int(int64(result.PassedMetrics)), reportLocale.Int(int64(result.TotalMetrics)),
+			reportLocale.Float(passRate, 1))
 
 		if len(result.FailedChecks) > 0 {
-			fmt.Printf("  Failed validators: %v\n", result.FailedChecks)
+			fmt.Printf("  Failed validators:\n")
+		}
+		for _, name := range result.FailedChecks {
+			ui := localizedValidatorUI(result.ValidatorUI(name))
+			fmt.Printf("    - %s (%s)", ui.Title, ui.Severity)
+			if ui.Description != "" {
+				fmt.Printf(": %s", ui.Description)
+			}
+			fmt.Println()
+			if ui.Remediation != "" {
+				fmt.Printf("      Fix: %s\n", ui.Remediation)
+			}
+			if ui.DocURL != "" {
+				fmt.Printf("      Docs: %s\n", ui.DocURL)
+			}
+		}
+		for _, ack := range result.Acknowledged {
+			kind := "acknowledged"
+			if ack.Exempt {
+				kind = "waived"
+			}
+			fmt.Printf("  %s (%s until %s): %s\n", ack.MetricName, kind, ack.Expires, ack.Reason)
+		}
+		if len(result.ChronicMetrics) > 0 {
+			fmt.Printf("  Chronic failures (weighted): %v\n", result.ChronicMetrics)
+		}
+		for _, evalErr := range result.Errors {
+			fmt.Printf("  Evaluation error: %s\n", evalErr)
 		}
 		fmt.Println()
 	}
@@ -132,6 +298,36 @@ func getScoreCategory(score float64) string {
 	}
 }
 
+// localizedCategory returns the score category translated to the report locale
+func localizedCategory(score float64) string {
+	return reportLocale.T(getScoreCategory(score))
+}
+
+// localizedValidatorUI translates the text of a validator's metadata to the report locale
+func localizedValidatorUI(ui engine.ValidatorUI) engine.ValidatorUI {
+	ui.Title = reportLocale.Message(ui.MessageKeys.Title, ui.Title)
+	ui.Description = reportLocale.Message(ui.MessageKeys.Description, ui.Description)
+	ui.Remediation = reportLocale.Message(ui.MessageKeys.Remediation, ui.Remediation)
+	ui.Severity = reportLocale.Message(ui.MessageKeys.Severity, ui.Severity)
+	return ui
+}
+
+// validatorsUI collects the localized metadata of every validator evaluated for jobs, by name,
+// for the report's scripts to present failing metrics with
+func validatorsUI(jobs []JobHTMLData) map[string]engine.ValidatorUI {
+	validators := make(map[string]engine.ValidatorUI)
+	for _, job := range jobs {
+		for _, result := range job.Results {
+			for _, stat := range result.ValidatorStats {
+				if _, ok := validators[stat.Name]; !ok {
+					validators[stat.Name] = localizedValidatorUI(stat.UI)
+				}
+			}
+		}
+	}
+	return validators
+}
+
 // JobMetricDetail represents detailed metric information for HTML output
 type JobMetricDetail struct {
 	MetricName       string
@@ -140,25 +336,42 @@ type JobMetricDetail struct {
 	Status           string
 	FailedRules      []string
 	LabelCardinality string // JSON string of label->cardinality map
+	Waiver           string // Reason and expiry when every failure is acknowledged
+	Change           string // Since the previous run: history.MetricNewlyFailed, MetricStillFailed, MetricFixed or ""
 }
 
 // MultiJobHTMLData represents data for multi-job HTML reports
 type MultiJobHTMLData struct {
 	Jobs             []JobHTMLData
+	Rules            []RuleSummary // The jobs' results grouped by rule, for the rule view
 	TotalJobs        int
 	AverageScore     float64
 	TotalCost        float64
 	TotalCardinality int64
 	ShowCost         bool
 	Timestamp        string
+	PreviousRun      string // Timestamp of the run changes are shown against, empty without one
+	Selector         string // Label matchers the analysis was scoped to, empty for the whole fleet
+	Warnings         []string
+	SkippedJobs      []SkippedJob // Jobs left out of the report, so an incomplete run is visible
 	RulesConfigJSON  template.JS
+	ValidatorsJSON   template.JS // Localized metadata of each validator by name, see validatorsUI
+	ReportJSON       template.JS // The full report, for the export buttons; empty hides them
 	CSS              template.CSS
 	JS               template.JS
+	Conformance      *spec.Conformance // Specification rules and the rules implementing them, see SetConformance
+}
+
+// SkippedJob is a job file that could not be evaluated, with the reason
+type SkippedJob struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
 }
 
 // JobHTMLData represents a single job's data for HTML output
 type JobHTMLData struct {
 	JobName          string
+	ServiceVersion   string // Version detected from build info, empty if unknown
 	Score            float64
 	ScoreInt         int
 	Category         string
@@ -169,108 +382,168 @@ type JobHTMLData struct {
 	TotalCardinality int64
 	EstimatedCost    float64
 	ShowCost         bool
+	HasPrevious      bool    // Whether the job was evaluated in the previous run
+	ScoreDelta       float64 // Score change since the previous run
+	NewlyFailed      int     // Metrics failing now that did not fail in the previous run
+	RenamedFrom      string  // Name of the job in the previous run, when it was renamed since
+	Confidence       string  // Confidence level of the score, see score.Confidence; empty when unknown
 }
 
 // HTMLMultiJob outputs results for multiple jobs in a beautiful HTML report format
-func HTMLMultiJob(jobsData []JobHTMLData, avgScore float64, outputFile string) {
-	HTMLMultiJobWithCost(jobsData, avgScore, 0, 0, false, outputFile, "")
+func HTMLMultiJob(jobsData []JobHTMLData, avgScore float64, outputFile string) error {
+	return HTMLMultiJobWithCost(jobsData, avgScore, 0, 0, false, outputFile, "")
 }
 
 // HTMLMultiJobWithCost outputs results for multiple jobs with cost information
-func HTMLMultiJobWithCost(jobsData []JobHTMLData, avgScore float64, totalCost float64, totalCardinality int64, showCost bool, outputFile string, rulesConfigPath string) {
-	rulesConfigJSON := template.JS("{}")
+func HTMLMultiJobWithCost(jobsData []JobHTMLData, avgScore float64, totalCost float64, totalCardinality int64, showCost bool, outputFile string, rulesConfigPath string) error {
+	return HTMLMultiJobWithChanges(jobsData, avgScore, totalCost, totalCardinality, showCost, outputFile, rulesConfigPath, "")
+}
+
+// HTMLMultiJobWithChanges outputs results for multiple jobs annotated with the changes since a previous run
+// previousRun is the timestamp of that run; when empty no changes are shown.
+func HTMLMultiJobWithChanges(jobsData []JobHTMLData, avgScore float64, totalCost float64, totalCardinality int64, showCost bool, outputFile string, rulesConfigPath string, previousRun string) error {
+	var rulesConfig []byte
 	if rulesConfigPath != "" {
-		if rulesData, err := os.ReadFile(rulesConfigPath); err == nil {
-			var rules interface{}
-			if err := yaml.Unmarshal(rulesData, &rules); err == nil {
-				if jsonData, err := json.Marshal(rules); err == nil {
-					rulesConfigJSON = template.JS(jsonData)
-				}
+		rulesConfig, _ = os.ReadFile(rulesConfigPath)
+	}
+	return HTMLMultiJobWithData(jobsData, avgScore, totalCost, totalCardinality, showCost, outputFile, rulesConfig, previousRun, "", nil)
+}
+
+// HTMLMultiJobWithData outputs results for multiple jobs with the full report embedded as JSON
+// The page then offers the report as a JSON download and the jobs table as CSV, so readers
+// of a hosted dashboard need no other artifacts. A nil report embeds nothing.
+// rulesConfig is the YAML of the rules, whose titles and descriptions the page shows.
+func HTMLMultiJobWithData(jobsData []JobHTMLData, avgScore float64, totalCost float64, totalCardinality int64, showCost bool, outputFile string, rulesConfig []byte, previousRun string, selector string, report interface{}) error {
+	return HTMLMultiJobWithWarnings(jobsData, avgScore, totalCost, totalCardinality, showCost, outputFile, rulesConfig, previousRun, selector, nil, nil, report)
+}
+
+// HTMLMultiJobWithWarnings outputs results for multiple jobs above a notice listing the run's
+// warnings and the jobs that were skipped, so readers can tell when the report is incomplete
+func HTMLMultiJobWithWarnings(jobsData []JobHTMLData, avgScore float64, totalCost float64, totalCardinality int64, showCost bool, outputFile string, rulesConfig []byte, previousRun string, selector string, warnings []string, skipped []SkippedJob, report interface{}) error {
+	return writeHTMLFile(outputFile, func(w io.Writer) error {
+		return WriteHTMLMultiJob(w, jobsData, avgScore, totalCost, totalCardinality, showCost, rulesConfig, previousRun, selector, warnings, skipped, report)
+	})
+}
+
+// writeHTMLFile writes the report render renders to outputFile, or stdout when it is empty
+func writeHTMLFile(outputFile string, render func(w io.Writer) error) error {
+	if outputFile == "" {
+		return render(os.Stdout)
+	}
+	output, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create HTML file: %w", err)
+	}
+	if err := render(output); err != nil {
+		output.Close()
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("HTML report generated: %s\n", outputFile)
+	return nil
+}
+
+// WriteHTMLMultiJob renders the report HTMLMultiJobWithWarnings writes to a file to w instead,
+// e.g. an HTTP response
+func WriteHTMLMultiJob(w io.Writer, jobsData []JobHTMLData, avgScore float64, totalCost float64, totalCardinality int64, showCost bool, rulesConfig []byte, previousRun string, selector string, warnings []string, skipped []SkippedJob, report interface{}) error {
+	var reportJSON template.JS
+	if report != nil {
+		// json.Marshal escapes <, > and &, so the data cannot close the script element
+		data, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to marshal report data: %w", err)
+		}
+		reportJSON = template.JS(data)
+	}
+
+	rulesConfigJSON := template.JS("{}")
+	if len(rulesConfig) > 0 {
+		var rules interface{}
+		if err := yaml.Unmarshal(rulesConfig, &rules); err == nil {
+			if jsonData, err := json.Marshal(rules); err == nil {
+				rulesConfigJSON = template.JS(jsonData)
 			}
 		}
 	}
 
+	// json.Marshal escapes <, > and &, so rule texts cannot close the script element either
+	validatorsJSON, err := json.Marshal(validatorsUI(jobsData))
+	if err != nil {
+		return fmt.Errorf("failed to marshal validator metadata: %w", err)
+	}
+
 	data := MultiJobHTMLData{
 		Jobs:             jobsData,
+		Rules:            SummarizeRules(jobsData),
 		TotalJobs:        len(jobsData),
 		AverageScore:     avgScore,
 		TotalCost:        totalCost,
 		TotalCardinality: totalCardinality,
 		ShowCost:         showCost,
 		Timestamp:        fmt.Sprintf("%v", os.Getenv("TIMESTAMP")),
+		PreviousRun:      previousRun,
+		Selector:         selector,
+		Warnings:         warnings,
+		SkippedJobs:      skipped,
+		Conformance:      reportConformance,
 		RulesConfigJSON:  rulesConfigJSON,
+		ValidatorsJSON:   template.JS(validatorsJSON),
+		ReportJSON:       reportJSON,
 		CSS:              template.CSS(web.CSS),
 		JS:               template.JS(web.JS),
 	}
 
-	tmpl := template.Must(template.New("multi-job-report.html").Funcs(getTemplateFuncs()).ParseFS(web.Templates, "templates/multi-job-report.html"))
-
-	var output *os.File
-	var err error
-
-	if outputFile != "" {
-		output, err = os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
-		if err != nil {
-			log.Fatalf("Error creating HTML file: %v", err)
-		}
-		defer output.Close()
-	} else {
-		output = os.Stdout
-	}
-
-	err = tmpl.Execute(output, data)
+	tmpl, err := reportTemplate("multi-job-report.html")
 	if err != nil {
-		log.Fatalf("Error executing template: %v", err)
-	}
-
-	if outputFile != "" {
-		fmt.Printf("HTML report generated: %s\n", outputFile)
+		return err
 	}
+	return tmpl.Execute(w, data)
 }
 
 // HTML outputs results in a beautiful HTML report format
-func HTML(serviceName string, score float64, results []engine.RuleResult, outputFile string) {
-	category := getScoreCategory(score)
-
-	data := struct {
-		ServiceName string
-		Score       float64
-		ScoreInt    int
-		Category    string
-		StatusClass string
-		Results     []engine.RuleResult
-	}{
-		ServiceName: serviceName,
-		Score:       score,
-		ScoreInt:    int(score),
-		Category:    category,
-		StatusClass: getStatusClass(score),
-		Results:     results,
-	}
+func HTML(serviceName string, score float64, results []engine.RuleResult, outputFile string) error {
+	return HTMLWithVersion(serviceName, "", score, results, outputFile)
+}
 
-	tmpl := template.Must(template.New("single-job-report.html").Funcs(getTemplateFuncs()).ParseFS(web.Templates, "templates/single-job-report.html"))
+// HTMLWithVersion outputs an HTML report showing the service version the score was measured against
+func HTMLWithVersion(serviceName string, serviceVersion string, score float64, results []engine.RuleResult, outputFile string) error {
+	return HTMLWithConfidence(serviceName, serviceVersion, "", score, results, outputFile)
+}
 
-	var output *os.File
-	var err error
+// HTMLWithConfidence outputs an HTML report that also shows the confidence level of the score;
+// an empty confidence is not shown
+func HTMLWithConfidence(serviceName string, serviceVersion string, confidence string, score float64, results []engine.RuleResult, outputFile string) error {
+	category := localizedCategory(score)
 
-	if outputFile != "" {
-		output, err = os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
-		if err != nil {
-			log.Fatalf("Error creating HTML file: %v", err)
-		}
-		defer output.Close()
-	} else {
-		output = os.Stdout
+	data := struct {
+		ServiceName    string
+		ServiceVersion string
+		Confidence     string
+		Score          float64
+		ScoreInt       int
+		Category       string
+		StatusClass    string
+		Results        []engine.RuleResult
+	}{
+		ServiceName:    serviceName,
+		ServiceVersion: serviceVersion,
+		Confidence:     confidence,
+		Score:          score,
+		ScoreInt:       int(score),
+		Category:       category,
+		StatusClass:    getStatusClass(score),
+		Results:        results,
 	}
 
-	err = tmpl.Execute(output, data)
+	tmpl, err := reportTemplate("single-job-report.html")
 	if err != nil {
-		log.Fatalf("Error executing template: %v", err)
-	}
-
-	if outputFile != "" {
-		fmt.Printf("HTML report generated: %s\n", outputFile)
+		return err
 	}
+	return writeHTMLFile(outputFile, func(w io.Writer) error {
+		return tmpl.Execute(w, data)
+	})
 }
 
 func getStatusClass(score float64) string {
@@ -286,17 +559,10 @@ func getStatusClass(score float64) string {
 	}
 }
 
+// getTemplateFuncs returns TemplateFuncs plus the helpers of the built-in report templates
 func getTemplateFuncs() template.FuncMap {
-	return template.FuncMap{
-		"passRate": func(passed, total int) float64 {
-			if total == 0 {
-				return 0
-			}
-			return float64(passed) / float64(total) * 100
-		},
-		"lower": func(s string) string {
-			return strings.ToLower(s)
-		},
+	funcs := TemplateFuncs()
+	for name, fn := range map[string]interface{}{
 		"getImpactClass": func(impact string) string {
 			switch impact {
 			case "Critical":
@@ -321,5 +587,8 @@ func getTemplateFuncs() template.FuncMap {
 			}
 			return "status-failed"
 		},
+	} {
+		funcs[name] = fn
 	}
+	return funcs
 }
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"instrumentation-score/internal/engine"
 	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/locale"
+	"instrumentation-score/internal/spec"
 )
 
 func TestPrometheusMetrics(t *testing.T) {
@@ -66,7 +69,9 @@ func TestJSON(t *testing.T) {
 	}
 
 	// Call function
-	formatters.JSON(serviceName, score, results)
+	if err := formatters.JSON(serviceName, score, results); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
 
 	// Restore stdout
 	w.Close()
@@ -114,7 +119,11 @@ func TestText(t *testing.T) {
 	score := 87.5
 	results := []engine.RuleResult{
 		{RuleID: "TEST-001", Impact: "Important", PassedMetrics: 1, TotalMetrics: 1, FailedChecks: []string{}},
-		{RuleID: "TEST-002", Impact: "Critical", PassedMetrics: 1, TotalMetrics: 2, FailedChecks: []string{"check1"}},
+		{RuleID: "TEST-002", Impact: "Critical", PassedMetrics: 1, TotalMetrics: 2, FailedChecks: []string{"check1"},
+			Acknowledged: []engine.Acknowledgement{{MetricName: "legacy_total", Reason: "Renamed in v3", Expires: "2026-12-31", Exempt: true}},
+			ValidatorStats: []engine.ValidatorStat{{Name: "check1", UI: engine.ValidatorUI{
+				Title: "High Cardinality", Description: "Too many series.", Remediation: "Drop the user_id label.",
+				Severity: "Critical", DocURL: "https://example.com/TEST-002"}}}},
 	}
 
 	// Call function
@@ -136,6 +145,8 @@ func TestText(t *testing.T) {
 		"Rule Evaluation Results:",
 		"Rule TEST-001 (Important): 1/1 metrics passed (100.0%)",
 		"Rule TEST-002 (Critical): 1/2 metrics passed (50.0%)",
+		"legacy_total (waived until 2026-12-31): Renamed in v3",
+		"    - High Cardinality (Critical): Too many series.\n      Fix: Drop the user_id label.\n      Docs: https://example.com/TEST-002\n",
 	}
 
 	for _, line := range expectedLines {
@@ -201,3 +212,329 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestCRDManifests(t *testing.T) {
+	jobs := []formatters.JobScoreData{
+		{JobName: "api-service", TotalMetrics: 10, TotalCardinality: 500, Score: 82.5,
+			RuleResults: []engine.RuleResult{{RuleID: "PROM-MET-01", Impact: "Critical", PassedChecks: 2, TotalChecks: 3}}},
+		{JobName: "Batch/Worker", TotalMetrics: 3, Score: 40, Confidence: "low"},
+	}
+
+	output, err := formatters.CRDManifests(jobs, "observability", 75, "2025-11-02T16:00:00Z")
+	if err != nil {
+		t.Fatalf("CRDManifests() error = %v", err)
+	}
+
+	expected := []string{
+		"apiVersion: instrumentation-score.io/v1alpha1",
+		"kind: InstrumentationScore",
+		"name: api-service",
+		"namespace: observability",
+		"job: api-service",
+		"score: 82.5",
+		"passed: true",
+		"ruleId: PROM-MET-01",
+		"lastEvaluated: \"2025-11-02T16:00:00Z\"",
+		"---\n",
+		"name: batch-worker",
+		"job: Batch/Worker",
+		"passed: false",
+		"confidence: low",
+	}
+	for _, want := range expected {
+		if !contains(output, want) {
+			t.Errorf("Expected manifests to contain %q\nGot:\n%s", want, output)
+		}
+	}
+	if contains(output, "targetRef") {
+		t.Errorf("Expected no targetRef for offline results\nGot:\n%s", output)
+	}
+}
+
+func TestHTMLWithVersion(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.html")
+	results := []engine.RuleResult{
+		{RuleID: "TEST-001", Impact: "Important", PassedMetrics: 1, TotalMetrics: 1},
+	}
+
+	if err := formatters.HTMLWithVersion("test-service", "1.4.2", 100, results, outputFile); err != nil {
+		t.Fatalf("HTMLWithVersion() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !contains(string(data), "test-service version 1.4.2") {
+		t.Errorf("expected report to show the service version")
+	}
+}
+
+func TestHTMLWithConfidence(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.html")
+	results := []engine.RuleResult{
+		{RuleID: "TEST-001", Impact: "Important", PassedMetrics: 1, TotalMetrics: 1},
+	}
+
+	if err := formatters.HTMLWithConfidence("test-service", "", "low", 100, results, outputFile); err != nil {
+		t.Fatalf("HTMLWithConfidence() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !contains(string(data), "Score confidence: low") {
+		t.Errorf("expected report to show the score confidence")
+	}
+}
+
+func TestPrometheusMetricsWithSLO_Confidence(t *testing.T) {
+	jobs := []formatters.JobScoreData{{JobName: "api", Score: 80, Confidence: "high"}, {JobName: "worker", Score: 60, Confidence: "low"}}
+
+	output := formatters.PrometheusMetricsWithSLO(jobs)
+	for _, want := range []string{
+		"# TYPE instrumentation_quality_score_confidence gauge",
+		`instrumentation_quality_score_confidence{job="api",level="high"} 1`,
+		`instrumentation_quality_score_confidence{job="worker",level="low"} 1`,
+	} {
+		if !contains(output, want) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", want, output)
+		}
+	}
+
+	if output := formatters.PrometheusMetricsWithSLO([]formatters.JobScoreData{{JobName: "api", Score: 80}}); contains(output, "confidence") {
+		t.Errorf("Expected no confidence without a level\nGot:\n%s", output)
+	}
+}
+
+func TestHTMLMultiJobWithChanges(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.html")
+	jobs := []formatters.JobHTMLData{
+		{
+			JobName:     "api",
+			Score:       72.5,
+			HasPrevious: true,
+			ScoreDelta:  -4.3,
+			NewlyFailed: 1,
+			Metrics: []formatters.JobMetricDetail{
+				{MetricName: "http_requests", Status: "fail", FailedRules: []string{"check"}, Change: "new"},
+				{MetricName: "queue_depth", Status: "pass", Change: "fixed"},
+			},
+		},
+		{JobName: "worker", Score: 90},
+	}
+
+	if err := formatters.HTMLMultiJobWithChanges(jobs, 81.25, 0, 0, false, outputFile, "", "2025-11-02T16:00:00Z"); err != nil {
+		t.Fatalf("HTMLMultiJobWithChanges() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	output := string(data)
+	for _, want := range []string{
+		`<span aria-hidden="true">▼</span> 4.3`,
+		"1 newly failed metric",
+		`data-change="new"`,
+		"New failure",
+		"Fixed",
+		"new job",
+		"since Nov 2, 2025 16:00 UTC",
+	} {
+		if !contains(output, want) {
+			t.Errorf("expected report to contain %q", want)
+		}
+	}
+}
+
+func TestHTMLMultiJobWithData(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.html")
+	jobs := []formatters.JobHTMLData{{JobName: "api", Score: 80}}
+	report := map[string]interface{}{
+		"jobs": []map[string]interface{}{{"job_name": "</script><script>alert(1)</script>"}},
+	}
+
+	if err := formatters.HTMLMultiJobWithData(jobs, 80, 0, 0, false, outputFile, nil, "", `namespace="payments"`, report); err != nil {
+		t.Fatalf("HTMLMultiJobWithData() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	output := string(data)
+	if !contains(output, `window.REPORT_DATA = {"jobs":[{"job_name":"\u003c/script\u003e`) {
+		t.Errorf("expected the report data embedded with HTML characters escaped")
+	}
+	if !contains(output, `onclick="exportJobsCSV()"`) {
+		t.Errorf("expected export buttons")
+	}
+	if !contains(output, `Selector: <code>namespace=&#34;payments&#34;</code>`) {
+		t.Errorf("expected the selector in the header")
+	}
+
+	if err := formatters.HTMLMultiJob(jobs, 80, outputFile); err != nil {
+		t.Fatalf("HTMLMultiJob() error = %v", err)
+	}
+	data, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if contains(string(data), "REPORT_DATA =") || contains(string(data), `onclick="exportJobsCSV()"`) {
+		t.Errorf("expected no report data or export buttons without a report")
+	}
+}
+
+func TestHTMLMultiJobWithWarnings(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.html")
+	jobs := []formatters.JobHTMLData{{JobName: "api", Score: 80}}
+	skipped := []formatters.SkippedJob{{File: "billing.txt", Reason: "evaluation exceeded 5m0s (--job-timeout)"}}
+
+	if err := formatters.HTMLMultiJobWithWarnings(jobs, 80, 0, 0, false, outputFile, nil, "", "", []string{"waiver expired"}, skipped, nil); err != nil {
+		t.Fatalf("HTMLMultiJobWithWarnings() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	output := string(data)
+	for _, want := range []string{
+		"Incomplete run: 1 job skipped",
+		"<li><code>billing.txt</code>: evaluation exceeded 5m0s (--job-timeout)</li>",
+		"<li>waiver expired</li>",
+		"Skipped: 1",
+	} {
+		if !contains(output, want) {
+			t.Errorf("expected report to contain %q", want)
+		}
+	}
+
+	if err := formatters.HTMLMultiJobWithWarnings(jobs, 80, 0, 0, false, outputFile, nil, "", "", nil, nil, nil); err != nil {
+		t.Fatalf("HTMLMultiJobWithWarnings() error = %v", err)
+	}
+	data, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if contains(string(data), `class="run-warnings"`) {
+		t.Errorf("expected no warnings notice for a complete run")
+	}
+}
+
+func TestHTMLMultiJob_SpecConformance(t *testing.T) {
+	defer formatters.SetConformance(nil)
+	formatters.SetConformance(spec.CheckConformance("instrumentation-score/spec@main",
+		[]spec.Rule{{ID: "MET-001", Title: "Bounded cardinality", Impact: "Critical"}, {ID: "RES-001", Title: "Service name is set", Impact: "Critical"}},
+		[]engine.RuleDefinition{{RuleID: "PROM-MET-02", SpecRuleIDs: []string{"MET-001", "MET-999"}, Validators: []engine.ValidatorConfig{{Name: "check"}}}}))
+
+	outputFile := filepath.Join(t.TempDir(), "report.html")
+	if err := formatters.HTMLMultiJobWithWarnings([]formatters.JobHTMLData{{JobName: "api", Score: 80}}, 80, 0, 0, false, outputFile, nil, "", "", nil, nil, nil); err != nil {
+		t.Fatalf("HTMLMultiJobWithWarnings() error = %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, want := range []string{
+		"1 of 2 rules of the Instrumentation Score specification (instrumentation-score/spec@main)",
+		"<li><code>PROM-MET-02</code> declares <code>MET-999</code>, which is not in the specification</li>",
+		"Not covered",
+	} {
+		if !contains(string(data), want) {
+			t.Errorf("expected report to contain %q", want)
+		}
+	}
+
+	formatters.SetConformance(nil)
+	if err := formatters.HTMLMultiJobWithWarnings(nil, 0, 0, 0, false, outputFile, nil, "", "", nil, nil, nil); err != nil {
+		t.Fatalf("HTMLMultiJobWithWarnings() error = %v", err)
+	}
+	if data, _ := os.ReadFile(outputFile); contains(string(data), "spec-conformance") {
+		t.Error("expected no conformance section without SetConformance")
+	}
+}
+
+func TestPrometheusRuleMetrics(t *testing.T) {
+	jobs := []formatters.JobScoreData{
+		{JobName: "checkout", Score: 80, RuleResults: []engine.RuleResult{{
+			RuleID: "PROM-MET-01", Impact: "Critical", TotalMetrics: 12,
+			FailedMetrics: map[string][]string{"a": {"v"}, "b": {"v"}},
+		}}},
+		{JobName: "search", Score: 40},
+	}
+	output := formatters.PrometheusRuleMetrics(jobs)
+
+	for _, want := range []string{
+		"# TYPE instrumentation_rule_metrics gauge",
+		`instrumentation_rule_metrics{job="checkout",rule_id="PROM-MET-01",impact="Critical"} 12`,
+		`instrumentation_rule_failed_metrics{job="checkout",rule_id="PROM-MET-01",impact="Critical"} 2`,
+		`instrumentation_quality_jobs{category="good"} 1`,
+		`instrumentation_quality_jobs{category="poor"} 1`,
+		`instrumentation_quality_jobs{category="excellent"} 0`,
+	} {
+		if !contains(output, want) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", want, output)
+		}
+	}
+}
+
+func TestHTMLMultiJob_ValidatorMetadata(t *testing.T) {
+	defer formatters.SetLocale(locale.Default)
+	formatters.SetLocale(&locale.Locale{Tag: "de", Messages: map[string]string{"naming.title": "Namenskonvention"}})
+
+	outputFile := filepath.Join(t.TempDir(), "report.html")
+	results := []engine.RuleResult{{
+		RuleID: "PROM-MET-01", Impact: "Important", FailedChecks: []string{"format_check"},
+		ValidatorStats: []engine.ValidatorStat{{Name: "format_check", UI: engine.ValidatorUI{
+			Title: "Naming Convention", Remediation: "Use </script> snake_case.", Severity: "Important",
+			MessageKeys: engine.MessageKeys{Title: "naming.title"}}}},
+	}}
+	jobs := []formatters.JobHTMLData{{JobName: "api", Score: 80, Results: results}}
+
+	if err := formatters.HTMLMultiJobWithWarnings(jobs, 80, 0, 0, false, outputFile, nil, "", "", nil, nil, nil); err != nil {
+		t.Fatalf("HTMLMultiJobWithWarnings() error = %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	want := `window.VALIDATORS = {"format_check":{"title":"Namenskonvention","remediation":"Use \u003c/script\u003e snake_case.","severity":"Important","message_keys":{"title":"naming.title"}}};`
+	if !contains(string(data), want) {
+		t.Errorf("expected the localized validator metadata embedded as %s", want)
+	}
+
+	if err := formatters.HTML("api", 80, results, outputFile); err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+	data, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !contains(string(data), "<strong>Namenskonvention</strong> (Important)") || !contains(string(data), "Fix: Use &lt;/script&gt; snake_case.") {
+		t.Errorf("expected the single-job report to present the failed check with its metadata")
+	}
+}
+
+func TestHTML_Errors(t *testing.T) {
+	dir := t.TempDir()
+	results := []engine.RuleResult{{RuleID: "PROM-MET-01", Impact: "Important", PassedChecks: 1, TotalChecks: 1}}
+
+	if err := formatters.HTML("api", 80, results, filepath.Join(dir, "missing", "report.html")); err == nil {
+		t.Error("HTML() into a missing directory expected error")
+	}
+
+	defer formatters.SetHTMLTemplate("")
+	formatters.SetHTMLTemplate(filepath.Join(dir, "missing.html"))
+	if err := formatters.HTMLMultiJob(nil, 0, filepath.Join(dir, "report.html")); err == nil {
+		t.Error("HTMLMultiJob() with a missing template expected error")
+	}
+	broken := filepath.Join(dir, "broken.html")
+	os.WriteFile(broken, []byte("{{ .Jobs "), 0600)
+	formatters.SetHTMLTemplate(broken)
+	if err := formatters.HTML("api", 80, results, filepath.Join(dir, "report.html")); err == nil || !contains(err.Error(), "broken.html") {
+		t.Errorf("HTML() with an unparsable template error = %v, want it named", err)
+	}
+}
@@ -0,0 +1,82 @@
+package formatters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"instrumentation-score/internal/history"
+	"instrumentation-score/internal/orgscore"
+)
+
+// HistoryText renders a job's recorded scores as a table, one run per row, oldest first
+// Runs scored by different rules than the run before are marked, as their scores may not compare.
+func HistoryText(job string, points []history.TrendPoint) string {
+	var output strings.Builder
+	fmt.Fprintf(&output, "=== Score History for Job: %s ===\n\n", job)
+	if len(points) == 0 {
+		output.WriteString("No recorded runs\n")
+		return output.String()
+	}
+
+	rulesChanged := false
+	fmt.Fprintf(&output, "%-24s %8s %8s %9s %12s  %s\n", "RUN", "SCORE", "CHANGE", "METRICS", "SERIES", "FAILING RULES")
+	for i, point := range points {
+		change, marker := "", " "
+		if i > 0 {
+			change = formatDelta(point.Score-points[i-1].Score, 1)
+			if point.RulesHash != points[i-1].RulesHash {
+				marker, rulesChanged = "*", true
+			}
+		}
+		var failing []string
+		for _, rule := range point.Rules {
+			if rule.FailedMetrics > 0 {
+				failing = append(failing, fmt.Sprintf("%s (%d)", rule.RuleID, rule.FailedMetrics))
+			}
+		}
+		fmt.Fprintf(&output, "%-22s %s %7s%% %8s %9s %12s  %s\n",
+			reportLocale.Date(point.Timestamp), marker, reportLocale.Float(point.Score, 1), change,
+			reportLocale.Int(int64(point.TotalMetrics)), reportLocale.Int(point.TotalCardinality), strings.Join(failing, ", "))
+	}
+
+	first, last := points[0], points[len(points)-1]
+	fmt.Fprintf(&output, "\nTrend: %s → %s (%s) over %d runs\n",
+		reportLocale.Float(first.Score, 1), reportLocale.Float(last.Score, 1), formatDelta(last.Score-first.Score, 1), len(points))
+	if rulesChanged {
+		output.WriteString("* Scored by different rules than the run before; the score change may come from the rules\n")
+	}
+	return output.String()
+}
+
+// HistoryJobsText renders the recorded score change of every job, largest drop first
+func HistoryJobsText(jobs []history.JobSummary) string {
+	var output strings.Builder
+	output.WriteString("=== Score History ===\n\n")
+	if len(jobs) == 0 {
+		output.WriteString("No recorded runs\n")
+		return output.String()
+	}
+
+	sorted := append([]history.JobSummary(nil), jobs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Change() < sorted[j].Change() })
+
+	fmt.Fprintf(&output, "%-32s %6s %8s %8s %8s  %s\n", "JOB", "RUNS", "FIRST", "LATEST", "CHANGE", "LATEST RUN")
+	for _, job := range sorted {
+		fmt.Fprintf(&output, "%-32s %6d %7s%% %7s%% %8s  %s\n",
+			job.JobName, job.Runs, reportLocale.Float(job.FirstScore, 1), reportLocale.Float(job.LatestScore, 1),
+			formatDelta(job.Change(), 1), reportLocale.Date(job.LatestRun))
+	}
+
+	// The average alone hides whether most jobs improved or a few excellent ones lifted it
+	first, latest := history.ScoreDistributions(jobs)
+	fmt.Fprintf(&output, "\n%-12s %7s %7s %7s %7s %7s\n", "JOB SCORES", "MEAN", "MEDIAN", "P10", "P90", "STDDEV")
+	for _, row := range []struct {
+		name string
+		d    orgscore.Distribution
+	}{{"First", first}, {"Latest", latest}} {
+		fmt.Fprintf(&output, "%-12s %6s%% %6s%% %6s%% %6s%% %7s\n", row.name, reportLocale.Float(row.d.Mean, 1), reportLocale.Float(row.d.Median, 1),
+			reportLocale.Float(row.d.P10, 1), reportLocale.Float(row.d.P90, 1), reportLocale.Float(row.d.StdDev, 1))
+	}
+	return output.String()
+}
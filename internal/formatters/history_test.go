@@ -0,0 +1,57 @@
+package formatters_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/history"
+)
+
+func TestHistoryText(t *testing.T) {
+	start := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	points := []history.TrendPoint{
+		{Timestamp: start, RulesHash: "a", Score: 60, TotalMetrics: 10, TotalCardinality: 1200,
+			Rules: []history.RulePoint{{RuleID: "PROM-MET-01", FailedMetrics: 2}, {RuleID: "PROM-MET-02"}}},
+		{Timestamp: start.Add(24 * time.Hour), RulesHash: "a", Score: 72.5, TotalMetrics: 10, TotalCardinality: 1200},
+		{Timestamp: start.Add(48 * time.Hour), RulesHash: "b", Score: 70, TotalMetrics: 11, TotalCardinality: 1300},
+	}
+
+	output := formatters.HistoryText("api", points)
+	for _, want := range []string{
+		"=== Score History for Job: api ===",
+		"Nov 1, 2025 12:00 UTC       60.0%",
+		"PROM-MET-01 (2)\n",
+		"Nov 2, 2025 12:00 UTC       72.5%    +12.5",
+		"Nov 3, 2025 12:00 UTC  *    70.0%     -2.5",
+		"Trend: 60.0 → 70.0 (+10.0) over 3 runs",
+		"* Scored by different rules",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	if output := formatters.HistoryText("billing", nil); !strings.Contains(output, "No recorded runs") {
+		t.Errorf("expected a note for a job without runs, got:\n%s", output)
+	}
+}
+
+func TestHistoryJobsText(t *testing.T) {
+	latest := time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC)
+	output := formatters.HistoryJobsText([]history.JobSummary{
+		{JobName: "api", Runs: 3, FirstScore: 60, LatestScore: 70, LatestRun: latest},
+		{JobName: "worker", Runs: 2, FirstScore: 90, LatestScore: 80, LatestRun: latest},
+	})
+	worker, api := strings.Index(output, "worker"), strings.Index(output, "api")
+	if worker < 0 || api < 0 || worker > api {
+		t.Errorf("expected the dropping job first, got:\n%s", output)
+	}
+	if !strings.Contains(output, "-10.0  Nov 3, 2025 12:00 UTC") {
+		t.Errorf("expected the score change and latest run, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Latest         75.0%   75.0%   71.0%   79.0%     5.0\n") {
+		t.Errorf("expected the distribution of the latest scores, got:\n%s", output)
+	}
+}
@@ -0,0 +1,144 @@
+package formatters
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"instrumentation-score/internal/engine"
+)
+
+// junitTestSuites is the root of a JUnit XML report, the format CI test report viewers read
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitProblem `xml:"skipped,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit renders jobs as a JUnit XML report: each job is a test suite and each validator of
+// each rule a test case, failing with the names of the metrics it failed. Validators that
+// could not be evaluated are errors, and skipped job files are suites with a skipped case.
+func JUnit(jobs []JobScoreData, skipped []SkippedJob, timestamp string) (string, error) {
+	report := junitTestSuites{Name: "instrumentation-score"}
+	for _, job := range jobs {
+		suite := junitTestSuite{
+			Name:       job.JobName,
+			Timestamp:  timestamp,
+			Properties: []junitProperty{{Name: "score", Value: fmt.Sprintf("%.2f", job.Score)}},
+		}
+		if job.Confidence != "" {
+			suite.Properties = append(suite.Properties, junitProperty{Name: "confidence", Value: job.Confidence})
+		}
+		for _, rule := range job.RuleResults {
+			for _, stat := range rule.ValidatorStats {
+				suite.Cases = append(suite.Cases, junitValidatorCase(rule.RuleID, rule.Impact, stat, rule.FailedMetrics))
+			}
+			for _, message := range rule.Errors {
+				suite.Cases = append(suite.Cases, junitTestCase{
+					Name:      "evaluation error",
+					ClassName: rule.RuleID,
+					Time:      "0",
+					Error:     &junitProblem{Message: message, Type: "EvaluationError"},
+				})
+			}
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+	for _, job := range skipped {
+		report.Suites = append(report.Suites, junitTestSuite{
+			Name:      job.File,
+			Timestamp: timestamp,
+			Cases: []junitTestCase{{
+				Name:      "evaluate",
+				ClassName: job.File,
+				Time:      "0",
+				Skipped:   &junitProblem{Message: job.Reason},
+			}},
+		})
+	}
+
+	for i := range report.Suites {
+		suite := &report.Suites[i]
+		for _, testCase := range suite.Cases {
+			suite.Tests++
+			switch {
+			case testCase.Failure != nil:
+				suite.Failures++
+			case testCase.Error != nil:
+				suite.Errors++
+			case testCase.Skipped != nil:
+				suite.Skipped++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+// junitValidatorCase is the test case of one validator, failing when any metric failed it
+// failedMetrics maps metric names to the validators they failed, as in RuleResult.
+func junitValidatorCase(ruleID, impact string, stat engine.ValidatorStat, failedMetrics map[string][]string) junitTestCase {
+	testCase := junitTestCase{Name: stat.Name, ClassName: ruleID, Time: "0"}
+	if stat.TotalMetrics == 0 {
+		testCase.Skipped = &junitProblem{Message: "no metrics to evaluate"}
+		return testCase
+	}
+	if stat.PassedMetrics == stat.TotalMetrics {
+		return testCase
+	}
+
+	metrics := failedMetricsOf(stat.Name, failedMetrics)
+	message := fmt.Sprintf("%d of %d metrics failed", stat.TotalMetrics-stat.PassedMetrics, stat.TotalMetrics)
+	if stat.UI.Title != "" {
+		message = stat.UI.Title + ": " + message
+	}
+	text := strings.Join(metrics, "\n")
+	if stat.UI.Remediation != "" {
+		text += "\n\n" + stat.UI.Remediation
+	}
+	testCase.Failure = &junitProblem{Message: message, Type: impact, Text: text}
+	return testCase
+}
@@ -0,0 +1,73 @@
+package formatters
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/engine"
+)
+
+func TestJUnit(t *testing.T) {
+	jobs := []JobScoreData{{
+		JobName:    "api",
+		Score:      62.5,
+		Confidence: "medium",
+		RuleResults: []engine.RuleResult{
+			{
+				RuleID: "PROM-MET-01",
+				Impact: "Critical",
+				ValidatorStats: []engine.ValidatorStat{
+					{Name: "naming", PassedMetrics: 8, TotalMetrics: 10, UI: engine.ValidatorUI{Title: "Bad Name", Remediation: "Rename it"}},
+					{Name: "units", PassedMetrics: 10, TotalMetrics: 10},
+				},
+				FailedMetrics: map[string][]string{"requests": {"naming"}, "Latency": {"naming", "other"}},
+			},
+			{
+				RuleID:         "PROM-USE-01",
+				Impact:         "Normal",
+				ValidatorStats: []engine.ValidatorStat{{Name: "usage"}},
+				Errors:         []string{"rule PROM-USE-01 validator broken: data source unavailable"},
+			},
+		},
+	}}
+	skipped := []SkippedJob{{File: "huge.txt", Reason: "exceeds --max-job-lines"}}
+
+	out, err := JUnit(jobs, skipped, "2026-03-01T10:00:00Z")
+	if err != nil {
+		t.Fatalf("JUnit() error = %v", err)
+	}
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Errorf("JUnit() has no XML header:\n%s", out)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("JUnit() is not valid XML: %v", err)
+	}
+	if report.Tests != 5 || report.Failures != 1 || report.Errors != 1 || report.Skipped != 2 {
+		t.Errorf("totals = %d tests, %d failures, %d errors, %d skipped, want 5, 1, 1, 2",
+			report.Tests, report.Failures, report.Errors, report.Skipped)
+	}
+	if len(report.Suites) != 2 || report.Suites[0].Name != "api" || report.Suites[1].Name != "huge.txt" {
+		t.Fatalf("suites = %+v", report.Suites)
+	}
+
+	if properties := report.Suites[0].Properties; len(properties) != 2 || properties[1] != (junitProperty{Name: "confidence", Value: "medium"}) {
+		t.Errorf("properties = %+v, want score and confidence", properties)
+	}
+
+	failing := report.Suites[0].Cases[0]
+	if failing.Name != "naming" || failing.ClassName != "PROM-MET-01" || failing.Failure == nil {
+		t.Fatalf("first case = %+v, want the failing naming validator", failing)
+	}
+	if failing.Failure.Message != "Bad Name: 2 of 10 metrics failed" || failing.Failure.Type != "Critical" {
+		t.Errorf("failure = %+v", failing.Failure)
+	}
+	if want := "Latency\nrequests\n\nRename it"; failing.Failure.Text != want {
+		t.Errorf("failure text = %q, want %q", failing.Failure.Text, want)
+	}
+	if passing := report.Suites[0].Cases[1]; passing.Failure != nil || passing.Skipped != nil {
+		t.Errorf("passing case = %+v", passing)
+	}
+}
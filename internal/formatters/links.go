@@ -0,0 +1,49 @@
+package formatters
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// JobAnchor returns the stable HTML anchor of a job's section in the multi-job report
+// Job names made of lowercase letters, digits and dashes are used as they are, e.g.
+// "job-payments-service". Other names are reduced to those characters and suffixed with a
+// short hash of the full name, so anchors neither collide nor depend on the other jobs.
+func JobAnchor(jobName string) string {
+	var slug strings.Builder
+	clean := jobName != ""
+	dash := false
+	for _, r := range jobName {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			slug.WriteRune(r)
+			dash = false
+		case r >= 'A' && r <= 'Z':
+			slug.WriteRune(r + 'a' - 'A')
+			clean, dash = false, false
+		default:
+			if r != '-' {
+				clean = false
+			}
+			if !dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+	anchor := "job-" + strings.TrimSuffix(slug.String(), "-")
+	if clean && anchor == "job-"+jobName {
+		return anchor
+	}
+	sum := sha256.Sum256([]byte(jobName))
+	return strings.TrimSuffix(anchor, "-") + "-" + hex.EncodeToString(sum[:3])
+}
+
+// JobURL links to a job's section in the HTML report published at reportURL
+func JobURL(reportURL, jobName string) string {
+	if i := strings.IndexByte(reportURL, '#'); i >= 0 {
+		reportURL = reportURL[:i]
+	}
+	return reportURL + "#" + JobAnchor(jobName)
+}
@@ -0,0 +1,43 @@
+package formatters_test
+
+import (
+	"regexp"
+	"testing"
+
+	"instrumentation-score/internal/formatters"
+)
+
+func TestJobAnchor(t *testing.T) {
+	tests := []struct {
+		jobName string
+		want    string // Exact anchor, or a pattern when the anchor has a hash suffix
+	}{
+		{"payments-service", "^job-payments-service$"},
+		{"node-exporter2", "^job-node-exporter2$"},
+		{"Payments", "^job-payments-[0-9a-f]{6}$"},
+		{"serviceMonitor/monitoring/api/0", "^job-servicemonitor-monitoring-api-0-[0-9a-f]{6}$"},
+		{"batch|nightly", "^job-batch-nightly-[0-9a-f]{6}$"},
+		{"", "^job-[0-9a-f]{6}$"},
+	}
+	for _, tt := range tests {
+		got := formatters.JobAnchor(tt.jobName)
+		if !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("JobAnchor(%q) = %q, want %s", tt.jobName, got, tt.want)
+		}
+		if again := formatters.JobAnchor(tt.jobName); again != got {
+			t.Errorf("JobAnchor(%q) is not stable: %q, then %q", tt.jobName, got, again)
+		}
+	}
+
+	// Names reducing to the same characters still get distinct anchors
+	if formatters.JobAnchor("API") == formatters.JobAnchor("api") || formatters.JobAnchor("API") == formatters.JobAnchor("Api") {
+		t.Error("anchors of api, API and Api should differ")
+	}
+}
+
+func TestJobURL(t *testing.T) {
+	got := formatters.JobURL("https://reports.example.com/latest.html#job-old", "payments")
+	if got != "https://reports.example.com/latest.html#job-payments" {
+		t.Errorf("JobURL() = %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package formatters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultMetricPrefix starts the name of every exported metric, e.g. instrumentation_quality_score
+const DefaultMetricPrefix = "instrumentation"
+
+var (
+	metricNamePattern  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNamePattern   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// reservedLabels are set by the formatters themselves and cannot be static labels
+var reservedLabels = map[string]bool{"job": true, "service_name": true, "rule_id": true, "impact": true, "category": true}
+
+// metricPrefix and staticLabels apply to every exported series (see SetMetricNaming)
+var (
+	metricPrefix = DefaultMetricPrefix
+	staticLabels []string // Alternating label names and values, sorted by name
+)
+
+// SetMetricNaming sets the prefix of exported metric names and static labels added to
+// every series, such as env or cluster, to fit an organization's naming conventions
+// SLO queries generated for the metrics use the same names and labels.
+func SetMetricNaming(prefix string, labels map[string]string) error {
+	if !metricNamePattern.MatchString(prefix) {
+		return fmt.Errorf("invalid metric prefix %q: must match %s", prefix, metricNamePattern)
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		switch {
+		case !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__"):
+			return fmt.Errorf("invalid label name %q", name)
+		case reservedLabels[name]:
+			return fmt.Errorf("label %q is set by the exported metrics and cannot be overridden", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	metricPrefix = prefix
+	staticLabels = nil
+	for _, name := range names {
+		staticLabels = append(staticLabels, name, labels[name])
+	}
+	return nil
+}
+
+// metricName returns the exported name of a metric, given its name without the prefix
+func metricName(name string) string {
+	return metricPrefix + "_" + name
+}
+
+// series formats a metric name with the given label name/value pairs and the static labels
+func series(name string, labelPairs ...string) string {
+	pairs := append(append([]string{}, labelPairs...), staticLabels...)
+	if len(pairs) == 0 {
+		return name
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelValueReplacer.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
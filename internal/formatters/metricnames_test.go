@@ -0,0 +1,50 @@
+package formatters_test
+
+import (
+	"testing"
+
+	"instrumentation-score/internal/formatters"
+)
+
+func TestSetMetricNaming(t *testing.T) {
+	t.Cleanup(func() { formatters.SetMetricNaming(formatters.DefaultMetricPrefix, nil) })
+
+	if err := formatters.SetMetricNaming("acme_obs", map[string]string{"env": "prod", "cluster": `eu-"1"`}); err != nil {
+		t.Fatalf("SetMetricNaming() error = %v", err)
+	}
+	jobs := []formatters.JobScoreData{{JobName: "checkout", Score: 80}}
+
+	output := formatters.PrometheusMetricsWithSLO(jobs) + formatters.PrometheusPassingMetrics(jobs, 75)
+	for _, want := range []string{
+		"# TYPE acme_obs_quality_score gauge",
+		`acme_obs_quality_score{job="checkout",cluster="eu-\"1\"",env="prod"} 80.00`,
+		`acme_obs_quality_score_passing{job="checkout",cluster="eu-\"1\"",env="prod"} 1`,
+	} {
+		if !contains(output, want) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", want, output)
+		}
+	}
+
+	// SLO queries select the renamed series
+	sloth, err := formatters.Sloth(jobs, formatters.SLOOptions{Target: 75, Objective: 99})
+	if err != nil {
+		t.Fatalf("Sloth() error = %v", err)
+	}
+	if !contains(sloth, `acme_obs_quality_score_passing{job="checkout",cluster=`) {
+		t.Errorf("Sloth queries do not use the renamed metric:\n%s", sloth)
+	}
+
+	for _, tt := range []struct {
+		prefix string
+		labels map[string]string
+	}{
+		{"acme-obs", nil},
+		{"acme", map[string]string{"job": "x"}},
+		{"acme", map[string]string{"__name__": "x"}},
+		{"acme", map[string]string{"team-name": "x"}},
+	} {
+		if err := formatters.SetMetricNaming(tt.prefix, tt.labels); err == nil {
+			t.Errorf("SetMetricNaming(%q, %v) should fail", tt.prefix, tt.labels)
+		}
+	}
+}
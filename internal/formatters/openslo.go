@@ -0,0 +1,120 @@
+package formatters
+
+import (
+	"fmt"
+	"strings"
+
+	"instrumentation-score/internal/kube"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The OpenSLO v1 document subset needed for a ratio objective on instrumentation_quality_score
+type openSLODocument struct {
+	APIVersion string          `yaml:"apiVersion"`
+	Kind       string          `yaml:"kind"`
+	Metadata   openSLOMetadata `yaml:"metadata"`
+	Spec       openSLOSpec     `yaml:"spec"`
+}
+
+type openSLOMetadata struct {
+	Name        string            `yaml:"name"`
+	DisplayName string            `yaml:"displayName,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+}
+
+type openSLOSpec struct {
+	Description     string             `yaml:"description"`
+	Service         string             `yaml:"service"`
+	Indicator       openSLOIndicator   `yaml:"indicator"`
+	TimeWindow      []openSLOWindow    `yaml:"timeWindow"`
+	BudgetingMethod string             `yaml:"budgetingMethod"`
+	Objectives      []openSLOObjective `yaml:"objectives"`
+}
+
+type openSLOIndicator struct {
+	Metadata openSLOMetadata `yaml:"metadata"`
+	Spec     struct {
+		RatioMetric struct {
+			Counter bool               `yaml:"counter"`
+			Good    openSLOMetricQuery `yaml:"good"`
+			Total   openSLOMetricQuery `yaml:"total"`
+		} `yaml:"ratioMetric"`
+	} `yaml:"spec"`
+}
+
+type openSLOMetricQuery struct {
+	MetricSource struct {
+		Type string `yaml:"type"`
+		Spec struct {
+			Query string `yaml:"query"`
+		} `yaml:"spec"`
+	} `yaml:"metricSource"`
+}
+
+type openSLOWindow struct {
+	Duration  string `yaml:"duration"`
+	IsRolling bool   `yaml:"isRolling"`
+}
+
+type openSLOObjective struct {
+	DisplayName string  `yaml:"displayName"`
+	Target      float64 `yaml:"target"`
+}
+
+// OpenSLO renders one OpenSLO v1 SLO per job as multi-document YAML
+// The indicator is the ratio of instrumentation_quality_score (as exported by the
+// prometheus output) to its maximum of 100, so an objective of Target means the
+// job's score stays at or above Target over the window.
+func OpenSLO(jobs []JobScoreData, opts SLOOptions) (string, error) {
+	if opts.Target <= 0 || opts.Target > 100 {
+		return "", fmt.Errorf("SLO target must be between 0 and 100, got %.2f", opts.Target)
+	}
+
+	var output strings.Builder
+	for i, job := range jobs {
+		name := kube.ObjectName(job.JobName) + "-instrumentation-score"
+
+		doc := openSLODocument{
+			APIVersion: "openslo/v1",
+			Kind:       "SLO",
+			Metadata: openSLOMetadata{
+				Name:        name,
+				DisplayName: fmt.Sprintf("Instrumentation score of %s", job.JobName),
+				Labels:      map[string]string{"job": kube.ObjectName(job.JobName)},
+			},
+			Spec: openSLOSpec{
+				Description:     fmt.Sprintf("Instrumentation quality score of job %s stays at or above %.0f (currently %.1f)", job.JobName, opts.Target, job.Score),
+				Service:         job.JobName,
+				TimeWindow:      []openSLOWindow{{Duration: opts.Window, IsRolling: true}},
+				BudgetingMethod: "Occurrences",
+				Objectives: []openSLOObjective{{
+					DisplayName: fmt.Sprintf("Score at least %.0f", opts.Target),
+					Target:      opts.Target / 100,
+				}},
+			},
+		}
+
+		for key, value := range opts.Owners.RoutingLabels(job.JobName) {
+			doc.Metadata.Labels[key] = value
+		}
+
+		indicator := &doc.Spec.Indicator
+		indicator.Metadata.Name = name + "-ratio"
+		ratio := &indicator.Spec.RatioMetric
+		ratio.Good.MetricSource.Type = "Prometheus"
+		ratio.Good.MetricSource.Spec.Query = series(metricName("quality_score"), "job", job.JobName)
+		ratio.Total.MetricSource.Type = "Prometheus"
+		ratio.Total.MetricSource.Spec.Query = "vector(100)"
+
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal SLO for %s: %w", job.JobName, err)
+		}
+		if i > 0 {
+			output.WriteString("---\n")
+		}
+		output.Write(data)
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,62 @@
+package formatters_test
+
+import (
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/formatters"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestOpenSLO(t *testing.T) {
+	jobs := []formatters.JobScoreData{
+		{JobName: "api-service", Score: 82.5},
+		{JobName: "Batch/Worker", Score: 40},
+	}
+
+	output, err := formatters.OpenSLO(jobs, formatters.SLOOptions{Target: 75, Window: "28d"})
+	if err != nil {
+		t.Fatalf("OpenSLO() error = %v", err)
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(output))
+	var docs []map[string]interface{}
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			break
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 SLO documents, got %d\n%s", len(docs), output)
+	}
+
+	expected := []string{
+		"apiVersion: openslo/v1",
+		"kind: SLO",
+		"name: api-service-instrumentation-score",
+		"service: api-service",
+		`query: instrumentation_quality_score{job="api-service"}`,
+		"query: vector(100)",
+		"duration: 28d",
+		"budgetingMethod: Occurrences",
+		"target: 0.75",
+		"name: batch-worker-instrumentation-score",
+		`query: instrumentation_quality_score{job="Batch/Worker"}`,
+	}
+	for _, want := range expected {
+		if !contains(output, want) {
+			t.Errorf("Expected SLOs to contain %q\nGot:\n%s", want, output)
+		}
+	}
+}
+
+func TestOpenSLO_InvalidTarget(t *testing.T) {
+	for _, target := range []float64{0, -5, 101} {
+		if _, err := formatters.OpenSLO(nil, formatters.SLOOptions{Target: target, Window: "28d"}); err == nil {
+			t.Errorf("OpenSLO() with target %.0f: expected error", target)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginPrefix starts the names of executables found on PATH as output formats:
+// instrumentation-score-format-confluence provides --output confluence
+const PluginPrefix = "instrumentation-score-format-"
+
+// PluginTimeout bounds how long an exec plugin may run
+var PluginTimeout = 5 * time.Minute
+
+// Formatter renders a report in an output format
+// report is the report as evaluate's json output writes it: AllJobsReport for a run over
+// all jobs, JobScoreResult for a single job. Formatters depend only on that document, so
+// the same contract serves formatters compiled in and external plugins.
+type Formatter interface {
+	Format(report []byte, w io.Writer) error
+}
+
+// FormatterFunc adapts a function to Formatter
+type FormatterFunc func(report []byte, w io.Writer) error
+
+// Format calls f
+func (f FormatterFunc) Format(report []byte, w io.Writer) error {
+	return f(report, w)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Formatter)
+)
+
+// builtinFormats are implemented by evaluate itself and cannot be registered
+var builtinFormats = []string{"text", "json", "html", "prometheus", "crd", "openslo", "pyrra", "sloth", "template", "badge", "junit", "sarif"}
+
+// Register makes a formatter available as an output format, typically from an init function
+// of a package compiled into the binary. It panics when name is empty, built in or
+// registered twice, like database/sql.Register.
+func Register(name string, formatter Formatter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || formatter == nil {
+		panic("formatters: Register needs a name and a formatter")
+	}
+	for _, builtin := range builtinFormats {
+		if name == builtin {
+			panic("formatters: Register of built-in format " + name)
+		}
+	}
+	if _, dup := registry[name]; dup {
+		panic("formatters: Register called twice for format " + name)
+	}
+	registry[name] = formatter
+}
+
+// Registered returns the names of the registered formatters, sorted
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the formatter of an output format that is not built in: a registered one,
+// or else an exec plugin named PluginPrefix+name on PATH
+func Lookup(name string) (Formatter, bool) {
+	registryMu.RLock()
+	formatter, ok := registry[name]
+	registryMu.RUnlock()
+	if ok {
+		return formatter, true
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, false
+	}
+	path, err := exec.LookPath(PluginPrefix + name)
+	if err != nil {
+		return nil, false
+	}
+	return ExecFormatter{Name: name, Path: path}, true
+}
+
+// ExecFormatter runs an external program as a formatter
+// The program reads the JSON report on standard input and writes the output to standard
+// output; INSTRUMENTATION_SCORE_FORMAT holds the format name. A non-zero exit status fails
+// the format with the program's standard error.
+type ExecFormatter struct {
+	Name string
+	Path string
+	Args []string
+}
+
+// Format runs the program with report as its input
+func (e ExecFormatter) Format(report []byte, w io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), PluginTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Path, e.Args...)
+	cmd.Stdin = bytes.NewReader(report)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "INSTRUMENTATION_SCORE_FORMAT="+e.Name)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("plugin %s timed out after %s", e.Path, PluginTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("plugin %s failed: %w: %s", e.Path, err, message)
+		}
+		return fmt.Errorf("plugin %s failed: %w", e.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package formatters_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/formatters"
+)
+
+func TestRegister(t *testing.T) {
+	upper := formatters.FormatterFunc(func(report []byte, w io.Writer) error {
+		_, err := w.Write(bytes.ToUpper(report))
+		return err
+	})
+	formatters.Register("test-upper", upper)
+
+	formatter, ok := formatters.Lookup("test-upper")
+	if !ok {
+		t.Fatal("Lookup() of a registered format failed")
+	}
+	var out bytes.Buffer
+	if err := formatter.Format([]byte(`{"total_jobs":1}`), &out); err != nil || out.String() != `{"TOTAL_JOBS":1}` {
+		t.Errorf("Format() = %q, %v", out.String(), err)
+	}
+
+	found := false
+	for _, name := range formatters.Registered() {
+		found = found || name == "test-upper"
+	}
+	if !found {
+		t.Errorf("Registered() = %v, want test-upper", formatters.Registered())
+	}
+
+	for _, name := range []string{"test-upper", "json", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) expected panic", name)
+				}
+			}()
+			formatters.Register(name, upper)
+		}()
+	}
+}
+
+func TestLookup_ExecPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"format=$INSTRUMENTATION_SCORE_FORMAT\"\ncat\n"
+	if err := os.WriteFile(filepath.Join(dir, formatters.PluginPrefix+"echo"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	failing := "#!/bin/sh\necho 'no confluence token' >&2\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(dir, formatters.PluginPrefix+"failing"), []byte(failing), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	formatter, ok := formatters.Lookup("echo")
+	if !ok {
+		t.Fatal("Lookup() of a plugin on PATH failed")
+	}
+	var out bytes.Buffer
+	if err := formatter.Format([]byte(`{"jobs":[]}`), &out); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "format=echo\n{\"jobs\":[]}"; out.String() != want {
+		t.Errorf("Format() = %q, want %q", out.String(), want)
+	}
+
+	formatter, _ = formatters.Lookup("failing")
+	err := formatter.Format(nil, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "no confluence token") {
+		t.Errorf("Format() error = %v, want the plugin's stderr", err)
+	}
+
+	for _, name := range []string{"missing", "../echo"} {
+		if _, ok := formatters.Lookup(name); ok {
+			t.Errorf("Lookup(%q) found a formatter", name)
+		}
+	}
+}
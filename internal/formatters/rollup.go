@@ -0,0 +1,85 @@
+package formatters
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"instrumentation-score/internal/rollup"
+	"instrumentation-score/web"
+)
+
+// RollupText renders the organization rollup as a table, one business unit per row
+func RollupText(report rollup.Report) string {
+	var output strings.Builder
+	output.WriteString("=== Organization Instrumentation Score ===\n\n")
+	fmt.Fprintf(&output, "Score: %s%% (%s)\n", reportLocale.Float(report.AverageScore, 2), localizedCategory(report.AverageScore))
+	fmt.Fprintf(&output, "Jobs: %s across %d business units\n", reportLocale.Int(int64(report.TotalJobs)), len(report.Units)-report.MissingUnits)
+	fmt.Fprintf(&output, "Active Series: %s\n", reportLocale.Int(report.TotalCardinality))
+	if report.TotalCost > 0 {
+		fmt.Fprintf(&output, "Total Cost: $%s/month\n", reportLocale.Float(report.TotalCost, 2))
+	}
+	if d := report.Distribution; d != nil {
+		fmt.Fprintf(&output, "Job Scores: median %s%%, p10 %s%%, p90 %s%%, stddev %s",
+			reportLocale.Float(d.Median, 1), reportLocale.Float(d.P10, 1), reportLocale.Float(d.P90, 1), reportLocale.Float(d.StdDev, 1))
+		if d.Jobs < report.TotalJobs {
+			fmt.Fprintf(&output, " (%s of %s jobs; older manifests do not record job scores)", reportLocale.Int(int64(d.Jobs)), reportLocale.Int(int64(report.TotalJobs)))
+		}
+		output.WriteString("\n")
+	}
+	output.WriteString("\n")
+
+	fmt.Fprintf(&output, "%-24s %8s %8s %8s %6s %14s %12s  %s\n", "UNIT", "SCORE", "MEDIAN", "P90", "JOBS", "SERIES", "COST/MONTH", "EVALUATED")
+	for _, unit := range report.Units {
+		if unit.Error != "" {
+			fmt.Fprintf(&output, "%-24s unavailable: %s\n", unit.Unit, unit.Error)
+			continue
+		}
+		median, p90 := "-", "-"
+		if unit.Distribution != nil {
+			median, p90 = reportLocale.Float(unit.Distribution.Median, 1)+"%", reportLocale.Float(unit.Distribution.P90, 1)+"%"
+		}
+		fmt.Fprintf(&output, "%-24s %7s%% %8s %8s %6s %14s %12s  %s\n",
+			unit.Unit, reportLocale.Float(unit.AverageScore, 2), median, p90, reportLocale.Int(int64(unit.TotalJobs)), reportLocale.Int(unit.TotalCardinality),
+			"$"+reportLocale.Float(unit.TotalCost, 2), reportLocale.DateString(unit.Timestamp))
+	}
+	if report.MissingUnits > 0 {
+		fmt.Fprintf(&output, "\n%d unit(s) unavailable are left out of the totals\n", report.MissingUnits)
+	}
+	return output.String()
+}
+
+// RollupHTML renders the organization rollup as a standalone HTML page
+func RollupHTML(report rollup.Report) (string, error) {
+	funcs := getTemplateFuncs()
+	funcs["scoreBadgeClass"] = func(score float64) string {
+		return strings.Replace(getStatusClass(score), "status-", "score-", 1)
+	}
+	funcs["scoreBadgeLabel"] = func(score float64) string {
+		if score >= 50 && score < 75 {
+			return reportLocale.T("Needs Work")
+		}
+		return localizedCategory(score)
+	}
+
+	tmpl, err := template.New("rollup-report.html").Funcs(funcs).ParseFS(web.Templates, "templates/rollup-report.html")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse rollup template: %w", err)
+	}
+
+	data := struct {
+		Report   rollup.Report
+		Category string
+		CSS      template.CSS
+	}{
+		Report:   report,
+		Category: localizedCategory(report.AverageScore),
+		CSS:      template.CSS(web.CSS),
+	}
+
+	var output strings.Builder
+	if err := tmpl.Execute(&output, data); err != nil {
+		return "", fmt.Errorf("failed to render rollup report: %w", err)
+	}
+	return output.String(), nil
+}
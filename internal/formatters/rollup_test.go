@@ -0,0 +1,55 @@
+package formatters_test
+
+import (
+	"errors"
+	"testing"
+
+	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/rollup"
+	"instrumentation-score/internal/storage"
+)
+
+func testRollupReport() rollup.Report {
+	return rollup.NewReport([]rollup.UnitSummary{
+		rollup.Summarize(rollup.Unit{Name: "payments", Bucket: "payments-obs", Prefix: "reports"},
+			&storage.EvaluationManifest{RunID: "run-1", Timestamp: "2025-11-02T16:00:00Z", TotalJobs: 3, AverageScore: 92.5, TotalCardinality: 1200, TotalCost: 7.38,
+				JobScores: []float64{100, 95, 82.5}}),
+		rollup.Failed(rollup.Unit{Name: "search", Bucket: "search-obs"}, errors.New("no evaluation manifests found")),
+	}, "2025-11-03T08:00:00Z")
+}
+
+func TestRollupText(t *testing.T) {
+	output := formatters.RollupText(testRollupReport())
+	for _, want := range []string{
+		"Score: 92.50% (Excellent)",
+		"Jobs: 3 across 1 business units",
+		"Total Cost: $7.38/month",
+		"Job Scores: median 95.0%, p10 85.0%, p90 99.0%, stddev 7.4\n",
+		"payments",
+		"search                   unavailable: no evaluation manifests found",
+		"1 unit(s) unavailable",
+	} {
+		if !contains(output, want) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", want, output)
+		}
+	}
+}
+
+func TestRollupHTML(t *testing.T) {
+	output, err := formatters.RollupHTML(testRollupReport())
+	if err != nil {
+		t.Fatalf("RollupHTML() error = %v", err)
+	}
+	for _, want := range []string{
+		"Organization Instrumentation Score: 92.5%",
+		`title="s3://payments-obs/reports"`,
+		"score-excellent",
+		"Unavailable</span> no evaluation manifests found",
+		"$7.38",
+		"Job scores: median 95.0%, p10 85.0%, p90 99.0%, standard deviation 7.4</p>",
+	} {
+		if !contains(output, want) {
+			t.Errorf("Expected HTML to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package formatters
+
+import "sort"
+
+// impactRank orders impact levels from most to least severe
+var impactRank = map[string]int{"Critical": 0, "Important": 1, "Normal": 2, "Low": 3}
+
+// RuleSummary is one rule's results across all jobs, for the rule-centric HTML view
+type RuleSummary struct {
+	RuleID        string
+	Impact        string
+	TotalJobs     int // Jobs the rule was evaluated on
+	FailingJobs   []RuleJobFailure
+	FailedMetrics int // Failing metrics across all jobs
+	PassedMetrics int
+	TotalMetrics  int
+}
+
+// RuleJobFailure is a job failing a rule
+type RuleJobFailure struct {
+	JobName       string
+	FailedMetrics int
+}
+
+// SummarizeRules groups job results by rule
+// Rules failed by the most jobs come first, then by impact; the jobs failing a rule are
+// ordered by their number of failing metrics.
+func SummarizeRules(jobs []JobHTMLData) []RuleSummary {
+	byRule := make(map[string]*RuleSummary)
+	var ruleIDs []string
+	for _, job := range jobs {
+		for _, result := range job.Results {
+			summary, ok := byRule[result.RuleID]
+			if !ok {
+				summary = &RuleSummary{RuleID: result.RuleID, Impact: result.Impact}
+				byRule[result.RuleID] = summary
+				ruleIDs = append(ruleIDs, result.RuleID)
+			}
+			summary.TotalJobs++
+			summary.PassedMetrics += result.PassedMetrics
+			summary.TotalMetrics += result.TotalMetrics
+			if failed := len(result.FailedMetrics); failed > 0 {
+				summary.FailedMetrics += failed
+				summary.FailingJobs = append(summary.FailingJobs, RuleJobFailure{
+					JobName:       job.JobName,
+					FailedMetrics: failed,
+				})
+			}
+		}
+	}
+
+	summaries := make([]RuleSummary, 0, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		summary := byRule[ruleID]
+		sort.SliceStable(summary.FailingJobs, func(i, j int) bool {
+			return summary.FailingJobs[i].FailedMetrics > summary.FailingJobs[j].FailedMetrics
+		})
+		summaries = append(summaries, *summary)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if len(a.FailingJobs) != len(b.FailingJobs) {
+			return len(a.FailingJobs) > len(b.FailingJobs)
+		}
+		if impactRank[a.Impact] != impactRank[b.Impact] {
+			return impactRank[a.Impact] < impactRank[b.Impact]
+		}
+		return a.RuleID < b.RuleID
+	})
+	return summaries
+}
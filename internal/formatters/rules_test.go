@@ -0,0 +1,59 @@
+package formatters_test
+
+import (
+	"testing"
+
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/formatters"
+)
+
+func ruleResult(ruleID, impact string, total int, failed ...string) engine.RuleResult {
+	result := engine.RuleResult{
+		RuleID:        ruleID,
+		Impact:        impact,
+		TotalMetrics:  total,
+		PassedMetrics: total - len(failed),
+		FailedMetrics: make(map[string][]string),
+	}
+	for _, metric := range failed {
+		result.FailedMetrics[metric] = []string{"validator"}
+	}
+	return result
+}
+
+func TestSummarizeRules(t *testing.T) {
+	jobs := []formatters.JobHTMLData{
+		{JobName: "api", Results: []engine.RuleResult{
+			ruleResult("NAMING", "Important", 3, "a"),
+			ruleResult("LABELS", "Critical", 3),
+			ruleResult("UNITS", "Low", 3, "a"),
+		}},
+		{JobName: "payments", Results: []engine.RuleResult{
+			ruleResult("NAMING", "Important", 4, "a", "b", "c"),
+			ruleResult("LABELS", "Critical", 4, "b"),
+			ruleResult("UNITS", "Low", 4),
+		}},
+	}
+
+	summaries := formatters.SummarizeRules(jobs)
+	if len(summaries) != 3 {
+		t.Fatalf("got %d rules, want 3", len(summaries))
+	}
+
+	// NAMING fails in both jobs; LABELS and UNITS in one each, the Critical one first
+	var order []string
+	for _, summary := range summaries {
+		order = append(order, summary.RuleID)
+	}
+	if order[0] != "NAMING" || order[1] != "LABELS" || order[2] != "UNITS" {
+		t.Errorf("order = %v, want [NAMING LABELS UNITS]", order)
+	}
+
+	naming := summaries[0]
+	if naming.TotalJobs != 2 || naming.FailedMetrics != 4 || naming.PassedMetrics != 3 || naming.TotalMetrics != 7 {
+		t.Errorf("NAMING = %+v", naming)
+	}
+	if len(naming.FailingJobs) != 2 || naming.FailingJobs[0].JobName != "payments" {
+		t.Errorf("NAMING failing jobs = %+v, want payments first", naming.FailingJobs)
+	}
+}
@@ -0,0 +1,208 @@
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"instrumentation-score/internal/engine"
+)
+
+// SARIF 2.1.0, the format GitHub Code Scanning and other static analysis viewers read
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	sarifToolURI = "https://github.com/chit786/instrumentation-score"
+)
+
+// sarifMaxMetrics bounds the failed metrics named in a result's message
+const sarifMaxMetrics = 10
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name,omitempty"`
+	ShortDescription     sarifText           `json:"shortDescription"`
+	FullDescription      *sarifText          `json:"fullDescription,omitempty"`
+	Help                 *sarifText          `json:"help,omitempty"`
+	HelpURI              string              `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration  `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	Tags []string `json:"tags"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifText         `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFLevel is the SARIF level of a rule's impact: Critical and Important failures are
+// errors, Normal ones warnings and Low ones notes
+func SARIFLevel(impact string) string {
+	switch impact {
+	case "Critical", "Important":
+		return "error"
+	case "Normal":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// SARIF renders the failing validators of jobs as a SARIF log. Each validator that failed
+// in any job is a SARIF rule "<rule ID>/<validator>" with the level of the rule's impact,
+// and each job failing it a result located at the job's SourceFile (its name without one),
+// naming the failed metrics.
+func SARIF(jobs []JobScoreData, toolVersion string) (string, error) {
+	driver := sarifDriver{Name: "instrumentation-score", Version: toolVersion, InformationURI: sarifToolURI, Rules: []sarifRule{}}
+	ruleIndex := make(map[string]int)
+	results := []sarifResult{}
+
+	for _, job := range jobs {
+		uri := job.SourceFile
+		if uri == "" {
+			uri = job.JobName
+		}
+		for _, rule := range job.RuleResults {
+			for _, stat := range rule.ValidatorStats {
+				if stat.TotalMetrics == 0 || stat.PassedMetrics == stat.TotalMetrics {
+					continue
+				}
+				id := rule.RuleID + "/" + stat.Name
+				index, ok := ruleIndex[id]
+				if !ok {
+					index = len(driver.Rules)
+					ruleIndex[id] = index
+					driver.Rules = append(driver.Rules, sarifRuleOf(id, rule, stat.UI))
+				}
+
+				results = append(results, sarifResult{
+					RuleID:    id,
+					RuleIndex: index,
+					Level:     SARIFLevel(rule.Impact),
+					Message:   sarifText{Text: sarifMessage(job.JobName, stat, failedMetricsOf(stat.Name, rule.FailedMetrics))},
+					Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: uri},
+					}}},
+					// Keeps an alert open across runs while the job fails the validator
+					PartialFingerprints: map[string]string{"instrumentationScore/v1": job.JobName + ":" + id},
+				})
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// sarifRuleOf describes a failing validator of rule as a SARIF rule
+func sarifRuleOf(id string, rule engine.RuleResult, ui engine.ValidatorUI) sarifRule {
+	title := ui.Title
+	if title == "" {
+		title = id
+	}
+	sarif := sarifRule{
+		ID:                   id,
+		Name:                 strings.ReplaceAll(title, " ", ""),
+		ShortDescription:     sarifText{Text: title},
+		HelpURI:              ui.DocURL,
+		DefaultConfiguration: sarifConfiguration{Level: SARIFLevel(rule.Impact)},
+		Properties:           sarifRuleProperties{Tags: []string{"instrumentation", strings.ToLower(rule.Impact)}},
+	}
+	if ui.Description != "" {
+		sarif.FullDescription = &sarifText{Text: ui.Description}
+	}
+	if ui.Remediation != "" {
+		sarif.Help = &sarifText{Text: ui.Remediation}
+	}
+	return sarif
+}
+
+// failedMetricsOf returns the sorted metrics that failed validator
+// failedMetrics maps metric names to the validators they failed, as in RuleResult.
+func failedMetricsOf(validator string, failedMetrics map[string][]string) []string {
+	var metrics []string
+	for metric, validators := range failedMetrics {
+		for _, name := range validators {
+			if name == validator {
+				metrics = append(metrics, metric)
+				break
+			}
+		}
+	}
+	sort.Strings(metrics)
+	return metrics
+}
+
+// sarifMessage summarizes the failures of one validator in a job
+func sarifMessage(job string, stat engine.ValidatorStat, metrics []string) string {
+	message := fmt.Sprintf("%d of %d metrics of job %s failed", stat.TotalMetrics-stat.PassedMetrics, stat.TotalMetrics, job)
+	if stat.UI.Title != "" {
+		message = stat.UI.Title + ": " + message
+	}
+	if len(metrics) == 0 {
+		return message
+	}
+	more := ""
+	if len(metrics) > sarifMaxMetrics {
+		more = fmt.Sprintf(" and %d more", len(metrics)-sarifMaxMetrics)
+		metrics = metrics[:sarifMaxMetrics]
+	}
+	return message + ": " + strings.Join(metrics, ", ") + more
+}
@@ -0,0 +1,88 @@
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/engine"
+)
+
+func TestSARIF(t *testing.T) {
+	naming := engine.ValidatorUI{Title: "Bad Name", Remediation: "Rename it", DocURL: "https://example.com/naming"}
+	failedMetrics := map[string][]string{"requests": {"naming"}, "Latency": {"naming"}}
+	for i := 0; i < 12; i++ {
+		failedMetrics[fmt.Sprintf("extra_%02d", i)] = []string{"other"}
+	}
+	jobs := []JobScoreData{
+		{
+			JobName:    "api",
+			SourceFile: "reports/api.txt",
+			RuleResults: []engine.RuleResult{{
+				RuleID: "PROM-MET-01",
+				Impact: "Important",
+				ValidatorStats: []engine.ValidatorStat{
+					{Name: "naming", PassedMetrics: 8, TotalMetrics: 10, UI: naming},
+					{Name: "units", PassedMetrics: 10, TotalMetrics: 10},
+					{Name: "other", PassedMetrics: 0, TotalMetrics: 12},
+				},
+				FailedMetrics: failedMetrics,
+			}},
+		},
+		{
+			JobName: "billing",
+			RuleResults: []engine.RuleResult{{
+				RuleID:         "PROM-MET-01",
+				Impact:         "Important",
+				ValidatorStats: []engine.ValidatorStat{{Name: "naming", PassedMetrics: 4, TotalMetrics: 5, UI: naming}},
+			}},
+		},
+	}
+
+	out, err := SARIF(jobs, "1.2.3")
+	if err != nil {
+		t.Fatalf("SARIF() error = %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("SARIF() is not valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("SARIF() = version %q with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("rules = %+v, want one per failing validator", run.Tool.Driver.Rules)
+	}
+	rule := run.Tool.Driver.Rules[0]
+	if rule.ID != "PROM-MET-01/naming" || rule.DefaultConfiguration.Level != "error" || rule.Help.Text != "Rename it" || rule.HelpURI != naming.DocURL {
+		t.Errorf("rule = %+v", rule)
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("results = %d, want 3", len(run.Results))
+	}
+	first := run.Results[0]
+	if want := "Bad Name: 2 of 10 metrics of job api failed: Latency, requests"; first.Message.Text != want {
+		t.Errorf("message = %q, want %q", first.Message.Text, want)
+	}
+	if first.Locations[0].PhysicalLocation.ArtifactLocation.URI != "reports/api.txt" {
+		t.Errorf("location = %+v, want the job file", first.Locations)
+	}
+	if !strings.HasSuffix(run.Results[1].Message.Text, "extra_09 and 2 more") {
+		t.Errorf("message = %q, want at most %d metrics named", run.Results[1].Message.Text, sarifMaxMetrics)
+	}
+	if last := run.Results[2]; last.RuleIndex != 0 || last.Locations[0].PhysicalLocation.ArtifactLocation.URI != "billing" {
+		t.Errorf("result = %+v, want the shared rule located at the job name", last)
+	}
+}
+
+func TestSARIFLevel(t *testing.T) {
+	for impact, want := range map[string]string{"Critical": "error", "Important": "error", "Normal": "warning", "Low": "note"} {
+		if got := SARIFLevel(impact); got != want {
+			t.Errorf("SARIFLevel(%q) = %q, want %q", impact, got, want)
+		}
+	}
+}
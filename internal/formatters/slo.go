@@ -0,0 +1,190 @@
+package formatters
+
+import (
+	"fmt"
+	"strings"
+
+	"instrumentation-score/internal/kube"
+	"instrumentation-score/internal/ownership"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SLOOptions configures the score objective generated for each job
+type SLOOptions struct {
+	Target    float64            // Minimum score (0-100)
+	Window    string             // Rolling window, e.g. "28d"
+	Objective float64            // Percent of the window the score must be at or above Target (Pyrra, Sloth)
+	Namespace string             // Namespace of generated Kubernetes resources (Pyrra)
+	Owners    *ownership.Mapping // Optional; adds the owning team's routing labels
+}
+
+// passingMetricName names the 0/1 gauge per job telling whether the score meets the SLO target
+// Pyrra and Sloth objectives count the samples of this gauge, so it must be exported
+// alongside instrumentation_quality_score (see PrometheusPassingMetrics).
+func passingMetricName() string {
+	return metricName("quality_score_passing")
+}
+
+// PrometheusPassingMetrics outputs the passing gauge for every job against target
+func PrometheusPassingMetrics(jobs []JobScoreData, target float64) string {
+	var output strings.Builder
+	name := passingMetricName()
+	output.WriteString(fmt.Sprintf("# HELP %s Whether the job's instrumentation score is at or above %.2f (1) or not (0)\n", name, target))
+	output.WriteString(fmt.Sprintf("# TYPE %s gauge\n", name))
+	for _, job := range jobs {
+		passing := 0
+		if job.Score >= target {
+			passing = 1
+		}
+		output.WriteString(fmt.Sprintf("%s %d\n", series(name, "job", job.JobName), passing))
+	}
+	output.WriteString("\n")
+	return output.String()
+}
+
+// pyrraSLO is a pyrra.dev/v1alpha1 ServiceLevelObjective with a bool gauge indicator
+type pyrraSLO struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string            `yaml:"name"`
+		Namespace string            `yaml:"namespace,omitempty"`
+		Labels    map[string]string `yaml:"labels,omitempty"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Target      string `yaml:"target"`
+		Window      string `yaml:"window"`
+		Description string `yaml:"description"`
+		Indicator   struct {
+			BoolGauge struct {
+				Metric string `yaml:"metric"`
+			} `yaml:"bool_gauge"`
+		} `yaml:"indicator"`
+	} `yaml:"spec"`
+}
+
+// Pyrra renders one Pyrra ServiceLevelObjective per job as multi-document YAML
+// Routing labels of the job's owner are set with the pyrra.dev/ prefix, which Pyrra
+// copies onto the recording and alerting rules it generates.
+func Pyrra(jobs []JobScoreData, opts SLOOptions) (string, error) {
+	if err := validateObjective(opts); err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	for i, job := range jobs {
+		var slo pyrraSLO
+		slo.APIVersion = "pyrra.dev/v1alpha1"
+		slo.Kind = "ServiceLevelObjective"
+		slo.Metadata.Name = kube.ObjectName(job.JobName) + "-instrumentation-score"
+		slo.Metadata.Namespace = opts.Namespace
+		if labels := opts.Owners.RoutingLabels(job.JobName); labels != nil {
+			slo.Metadata.Labels = make(map[string]string, len(labels))
+			for key, value := range labels {
+				slo.Metadata.Labels["pyrra.dev/"+key] = value
+			}
+		}
+		slo.Spec.Target = formatPercent(opts.Objective)
+		slo.Spec.Window = opts.Window
+		slo.Spec.Description = sloDescription(job, opts)
+		slo.Spec.Indicator.BoolGauge.Metric = series(passingMetricName(), "job", job.JobName)
+
+		data, err := yaml.Marshal(slo)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal Pyrra SLO for %s: %w", job.JobName, err)
+		}
+		if i > 0 {
+			output.WriteString("---\n")
+		}
+		output.Write(data)
+	}
+	return output.String(), nil
+}
+
+// slothSpec is a Sloth prometheus/v1 spec with one SLO
+type slothSpec struct {
+	Version string     `yaml:"version"`
+	Service string     `yaml:"service"`
+	SLOs    []slothSLO `yaml:"slos"`
+}
+
+type slothSLO struct {
+	Name        string            `yaml:"name"`
+	Objective   float64           `yaml:"objective"`
+	Description string            `yaml:"description"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	SLI         struct {
+		Events struct {
+			ErrorQuery string `yaml:"error_query"`
+			TotalQuery string `yaml:"total_query"`
+		} `yaml:"events"`
+	} `yaml:"sli"`
+	Alerting struct {
+		Name        string            `yaml:"name"`
+		Labels      map[string]string `yaml:"labels,omitempty"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"alerting"`
+}
+
+// Sloth renders one Sloth spec per job as multi-document YAML
+// The owner's routing labels are set on the SLO and its alerts. Sloth chooses the
+// SLO window itself (30d by default), so opts.Window is not used.
+func Sloth(jobs []JobScoreData, opts SLOOptions) (string, error) {
+	if err := validateObjective(opts); err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	for i, job := range jobs {
+		selector := series(passingMetricName(), "job", job.JobName)
+
+		slo := slothSLO{
+			Name:        "instrumentation-score",
+			Objective:   opts.Objective,
+			Description: sloDescription(job, opts),
+			Labels:      opts.Owners.RoutingLabels(job.JobName),
+		}
+		// Every sample of the 0/1 gauge is an event; samples with value 0 are errors
+		slo.SLI.Events.ErrorQuery = fmt.Sprintf("sum(count_over_time(%s[{{.window}}])) - sum(sum_over_time(%s[{{.window}}]))", selector, selector)
+		slo.SLI.Events.TotalQuery = fmt.Sprintf("sum(count_over_time(%s[{{.window}}]))", selector)
+		slo.Alerting.Name = "InstrumentationScoreBelowTarget"
+		slo.Alerting.Labels = opts.Owners.RoutingLabels(job.JobName)
+		slo.Alerting.Annotations = map[string]string{
+			"summary": fmt.Sprintf("Instrumentation score of job %s is burning its error budget (target %.0f)", job.JobName, opts.Target),
+		}
+
+		spec := slothSpec{Version: "prometheus/v1", Service: job.JobName, SLOs: []slothSLO{slo}}
+		data, err := yaml.Marshal(spec)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal Sloth SLO for %s: %w", job.JobName, err)
+		}
+		if i > 0 {
+			output.WriteString("---\n")
+		}
+		output.Write(data)
+	}
+	return output.String(), nil
+}
+
+// validateObjective checks the options shared by the Pyrra and Sloth outputs
+func validateObjective(opts SLOOptions) error {
+	if opts.Target <= 0 || opts.Target > 100 {
+		return fmt.Errorf("SLO target must be between 0 and 100, got %.2f", opts.Target)
+	}
+	if opts.Objective <= 0 || opts.Objective >= 100 {
+		return fmt.Errorf("SLO objective must be between 0 and 100 (exclusive), got %.2f", opts.Objective)
+	}
+	return nil
+}
+
+// sloDescription describes a job's score objective
+func sloDescription(job JobScoreData, opts SLOOptions) string {
+	return fmt.Sprintf("Instrumentation quality score of job %s is at or above %.0f for %s%% of the time (currently %.1f)",
+		job.JobName, opts.Target, formatPercent(opts.Objective), job.Score)
+}
+
+// formatPercent formats a percentage without trailing zeros, e.g. 99 or 99.5
+func formatPercent(value float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", value), "0"), ".")
+}
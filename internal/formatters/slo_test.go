@@ -0,0 +1,100 @@
+package formatters_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/ownership"
+)
+
+func loadTestOwners(t *testing.T) *ownership.Mapping {
+	path := filepath.Join(t.TempDir(), "ownership.yaml")
+	content := "owners:\n  - team: payments\n    jobs: [checkout]\n    labels:\n      slack_channel: payments-alerts\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write ownership file: %v", err)
+	}
+	owners, err := ownership.Load(path)
+	if err != nil {
+		t.Fatalf("ownership.Load() error = %v", err)
+	}
+	return owners
+}
+
+func TestPrometheusPassingMetrics(t *testing.T) {
+	jobs := []formatters.JobScoreData{{JobName: "checkout", Score: 80}, {JobName: "search", Score: 60}}
+	output := formatters.PrometheusPassingMetrics(jobs, 75)
+
+	for _, want := range []string{
+		"# TYPE instrumentation_quality_score_passing gauge",
+		`instrumentation_quality_score_passing{job="checkout"} 1`,
+		`instrumentation_quality_score_passing{job="search"} 0`,
+	} {
+		if !contains(output, want) {
+			t.Errorf("Expected output to contain %q\nGot:\n%s", want, output)
+		}
+	}
+}
+
+func TestPyrra(t *testing.T) {
+	jobs := []formatters.JobScoreData{{JobName: "checkout", Score: 80}, {JobName: "search", Score: 60}}
+	opts := formatters.SLOOptions{Target: 75, Window: "28d", Objective: 99.5, Namespace: "monitoring", Owners: loadTestOwners(t)}
+
+	output, err := formatters.Pyrra(jobs, opts)
+	if err != nil {
+		t.Fatalf("Pyrra() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"apiVersion: pyrra.dev/v1alpha1",
+		"kind: ServiceLevelObjective",
+		"name: checkout-instrumentation-score",
+		"namespace: monitoring",
+		"pyrra.dev/team: payments",
+		"pyrra.dev/slack_channel: payments-alerts",
+		`target: "99.5"`,
+		"window: 28d",
+		`metric: instrumentation_quality_score_passing{job="checkout"}`,
+		"---\n",
+		"name: search-instrumentation-score",
+	} {
+		if !contains(output, want) {
+			t.Errorf("Expected Pyrra output to contain %q\nGot:\n%s", want, output)
+		}
+	}
+}
+
+func TestSloth(t *testing.T) {
+	jobs := []formatters.JobScoreData{{JobName: "checkout", Score: 80}}
+	opts := formatters.SLOOptions{Target: 75, Objective: 99, Owners: loadTestOwners(t)}
+
+	output, err := formatters.Sloth(jobs, opts)
+	if err != nil {
+		t.Fatalf("Sloth() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"version: prometheus/v1",
+		"service: checkout",
+		"objective: 99",
+		"team: payments",
+		`error_query: sum(count_over_time(instrumentation_quality_score_passing{job="checkout"}[{{.window}}])) - sum(sum_over_time(instrumentation_quality_score_passing{job="checkout"}[{{.window}}]))`,
+		`total_query: sum(count_over_time(instrumentation_quality_score_passing{job="checkout"}[{{.window}}]))`,
+		"name: InstrumentationScoreBelowTarget",
+	} {
+		if !contains(output, want) {
+			t.Errorf("Expected Sloth output to contain %q\nGot:\n%s", want, output)
+		}
+	}
+}
+
+func TestSLOOutputs_InvalidObjective(t *testing.T) {
+	opts := formatters.SLOOptions{Target: 75, Objective: 100}
+	if _, err := formatters.Pyrra(nil, opts); err == nil {
+		t.Error("Pyrra() with objective 100: expected error")
+	}
+	if _, err := formatters.Sloth(nil, opts); err == nil {
+		t.Error("Sloth() with objective 100: expected error")
+	}
+}
@@ -0,0 +1,433 @@
+package formatters
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/ownership"
+	"instrumentation-score/internal/spec"
+	"instrumentation-score/web"
+)
+
+// UnownedTeam is the team groupByTeam puts jobs without an owner in
+const UnownedTeam = "unowned"
+
+// templateOwners is the ownership mapping team and groupByTeam look jobs up in
+var templateOwners *ownership.Mapping
+
+// reportConformance is the specification conformance the multi-job HTML report shows
+var reportConformance *spec.Conformance
+
+// htmlTemplateFile replaces the built-in HTML report template when set
+var htmlTemplateFile string
+
+// SetOwnership sets the ownership mapping the team and groupByTeam template functions use
+func SetOwnership(m *ownership.Mapping) {
+	templateOwners = m
+}
+
+// SetConformance sets the specification conformance section of the multi-job HTML report
+// nil, the default, leaves the section out.
+func SetConformance(c *spec.Conformance) {
+	reportConformance = c
+}
+
+// SetHTMLTemplate replaces the built-in template of the HTML report with a file
+// The file is executed with the same data and TemplateFuncs as the built-in template, so
+// a copy of web/templates/multi-job-report.html (or single-job-report.html for --job-file
+// runs) is a starting point. An empty path restores the built-in template.
+func SetHTMLTemplate(path string) {
+	htmlTemplateFile = path
+}
+
+// TeamGroup is a team and its items, as groupByTeam returns them
+type TeamGroup struct {
+	Team         string
+	Items        []interface{}
+	AverageScore float64 // Average of the items' Score field, 0 when they have none
+}
+
+// TemplateFuncs returns the functions available to custom templates and overridden HTML
+// report templates. Their names, arguments and results are stable; new functions may be
+// added. Numbers may be any integer or float type.
+//
+// Arithmetic:
+//
+//	add a b, sub a b, mul a b     a+b, a-b, a*b
+//	div a b                       a/b, 0 when b is 0
+//	round x decimals              x rounded to decimals places
+//	abs x                         |x|
+//	percent part total            part/total*100, 0 when total is 0
+//	passRate passed total         same as percent
+//	delta current previous        current-previous
+//
+// Formatting, in the locale of the report:
+//
+//	formatInt n                   1,234
+//	formatFloat x decimals        1,234.57
+//	formatPercent x decimals      85.3%
+//	formatCost amount             $1,234.57
+//	formatDelta x decimals        +1.5, -2.0 or ±0.0
+//	formatDate timestamp          an RFC 3339 timestamp as a date
+//	category score                Excellent, Good, Needs Improvement or Poor
+//	t text                        text translated to the report language
+//	lang                          the report language tag
+//	lower s, upper s              s in lower or upper case
+//	jobAnchor job                 the HTML anchor of a job's section
+//	validatorUI result name       the Title, Description, Remediation, Severity and DocURL
+//	                              of a validator of a rule result, e.g. one of its FailedChecks
+//
+// Lists, of structs (fields by Go name or JSON name) or maps (by key):
+//
+//	sortBy field list             list sorted by field, ascending
+//	sortByDesc field list         list sorted by field, descending
+//	limit n list                  the first n items of list
+//	sum field list                the sum of field over list
+//	avg field list                the average of field over list, 0 when empty
+//	team job                      the team owning job, from --ownership ("unowned" without one)
+//	groupByTeam list              list grouped by the team owning each item's JobName, as
+//	                              TeamGroup values sorted by team
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"add": func(a, b interface{}) float64 { return toFloat(a) + toFloat(b) },
+		"sub": func(a, b interface{}) float64 { return toFloat(a) - toFloat(b) },
+		"mul": func(a, b interface{}) float64 { return toFloat(a) * toFloat(b) },
+		"div": func(a, b interface{}) float64 {
+			if toFloat(b) == 0 {
+				return 0
+			}
+			return toFloat(a) / toFloat(b)
+		},
+		"round": func(x interface{}, decimals int) float64 {
+			scale := math.Pow(10, float64(decimals))
+			return math.Round(toFloat(x)*scale) / scale
+		},
+		"abs":     func(x interface{}) float64 { return math.Abs(toFloat(x)) },
+		"percent": percent,
+		"passRate": func(passed, total int) float64 {
+			return percent(passed, total)
+		},
+		"delta": func(current, previous interface{}) float64 { return toFloat(current) - toFloat(previous) },
+
+		"formatInt": func(n interface{}) string {
+			switch v := n.(type) {
+			case int:
+				return reportLocale.Int(int64(v))
+			case int64:
+				return reportLocale.Int(v)
+			case string:
+				if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+					return reportLocale.Int(i)
+				}
+				return v
+			}
+			return reportLocale.Int(int64(toFloat(n)))
+		},
+		"formatFloat": func(x interface{}, decimals int) string {
+			return reportLocale.Float(toFloat(x), decimals)
+		},
+		"formatPercent": func(x interface{}, decimals int) string {
+			return reportLocale.Float(toFloat(x), decimals) + "%"
+		},
+		"formatCost": func(amount interface{}) string {
+			return "$" + reportLocale.Float(toFloat(amount), 2)
+		},
+		"formatDelta": func(x interface{}, decimals int) string {
+			return formatDelta(toFloat(x), decimals)
+		},
+		"formatDate": func(timestamp string) string {
+			return reportLocale.DateString(timestamp)
+		},
+		"category": func(score interface{}) string {
+			return localizedCategory(toFloat(score))
+		},
+		"t": func(message string) string {
+			return reportLocale.T(message)
+		},
+		"lang": func() string {
+			return reportLocale.Tag
+		},
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"jobAnchor": JobAnchor,
+		"validatorUI": func(result engine.RuleResult, name string) engine.ValidatorUI {
+			return localizedValidatorUI(result.ValidatorUI(name))
+		},
+
+		"sortBy": func(field string, list interface{}) ([]interface{}, error) {
+			return sortItems(field, list, false)
+		},
+		"sortByDesc": func(field string, list interface{}) ([]interface{}, error) {
+			return sortItems(field, list, true)
+		},
+		"limit": func(n int, list interface{}) ([]interface{}, error) {
+			items, err := listItems(list)
+			if err != nil {
+				return nil, err
+			}
+			if n >= 0 && n < len(items) {
+				items = items[:n]
+			}
+			return items, nil
+		},
+		"sum": func(field string, list interface{}) (float64, error) {
+			total, _, err := sumField(field, list)
+			return total, err
+		},
+		"avg": func(field string, list interface{}) (float64, error) {
+			total, count, err := sumField(field, list)
+			if err != nil || count == 0 {
+				return 0, err
+			}
+			return total / float64(count), nil
+		},
+		"team":        teamOf,
+		"groupByTeam": groupByTeam,
+	}
+}
+
+// RenderTemplate executes the template in templateFile with data, the report as evaluate's
+// json output describes it, and TemplateFuncs
+// Files ending in .html or .htm are HTML templates, whose output is escaped for HTML;
+// anything else, e.g. Markdown or Confluence storage format, is rendered as text.
+func RenderTemplate(templateFile string, data interface{}, w io.Writer) error {
+	content, err := os.ReadFile(templateFile)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	name := filepath.Base(templateFile)
+
+	if ext := strings.ToLower(filepath.Ext(templateFile)); ext == ".html" || ext == ".htm" {
+		tmpl, err := template.New(name).Funcs(TemplateFuncs()).Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		return tmpl.Execute(w, data)
+	}
+	tmpl, err := texttemplate.New(name).Funcs(TemplateFuncs()).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl.Execute(w, data)
+}
+
+// reportTemplate returns the HTML report template name, or the file set with SetHTMLTemplate
+func reportTemplate(name string) (*template.Template, error) {
+	tmpl := template.New(name).Funcs(getTemplateFuncs())
+	if htmlTemplateFile == "" {
+		return template.Must(tmpl.ParseFS(web.Templates, "templates/"+name)), nil
+	}
+	content, err := os.ReadFile(htmlTemplateFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTML template: %w", err)
+	}
+	if _, err := tmpl.Parse(string(content)); err != nil {
+		return nil, fmt.Errorf("failed to parse HTML template %s: %w", htmlTemplateFile, err)
+	}
+	return tmpl, nil
+}
+
+// percent returns part as a percentage of total, 0 when total is 0
+func percent(part, total interface{}) float64 {
+	if toFloat(total) == 0 {
+		return 0
+	}
+	return toFloat(part) / toFloat(total) * 100
+}
+
+// formatDelta formats a change with its sign, ±0 when it rounds to zero
+func formatDelta(value float64, decimals int) string {
+	scale := math.Pow(10, float64(decimals))
+	switch {
+	case math.Round(value*scale) > 0:
+		return "+" + reportLocale.Float(value, decimals)
+	case math.Round(value*scale) < 0:
+		return reportLocale.Float(value, decimals)
+	}
+	return "±" + reportLocale.Float(0, decimals)
+}
+
+// toFloat converts a number of any integer or float type, 0 for anything else
+func toFloat(n interface{}) float64 {
+	v := reflect.ValueOf(n)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return 0
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return 0
+}
+
+// listItems returns the elements of a slice or array
+func listItems(list interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(list)
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", list)
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// fieldOf returns field of item: a struct field by Go or JSON name, or a map value by key
+func fieldOf(item interface{}, field string) (interface{}, bool) {
+	v := reflect.ValueOf(item)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if f := v.FieldByName(field); f.IsValid() && f.CanInterface() {
+			return f.Interface(), true
+		}
+		for i := 0; i < v.NumField(); i++ {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			if name == field && v.Field(i).CanInterface() {
+				return v.Field(i).Interface(), true
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			if value := v.MapIndex(reflect.ValueOf(field).Convert(v.Type().Key())); value.IsValid() {
+				return value.Interface(), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// sortItems returns the items of list sorted by field; numbers compare as numbers,
+// anything else as text
+func sortItems(field string, list interface{}, descending bool) ([]interface{}, error) {
+	items, err := listItems(list)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]interface{}, len(items))
+	for i, item := range items {
+		key, ok := fieldOf(item, field)
+		if !ok {
+			return nil, fmt.Errorf("sortBy: item %d has no field %s", i, field)
+		}
+		keys[i] = key
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := keys[order[i]], keys[order[j]]
+		if descending {
+			a, b = b, a
+		}
+		if isNumber(a) && isNumber(b) {
+			return toFloat(a) < toFloat(b)
+		}
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	})
+
+	sorted := make([]interface{}, len(items))
+	for i, index := range order {
+		sorted[i] = items[index]
+	}
+	return sorted, nil
+}
+
+// isNumber reports whether v is of an integer or float type
+func isNumber(v interface{}) bool {
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// sumField returns the sum of field over the items of list, and the number of items
+func sumField(field string, list interface{}) (float64, int, error) {
+	items, err := listItems(list)
+	if err != nil {
+		return 0, 0, err
+	}
+	var total float64
+	for i, item := range items {
+		value, ok := fieldOf(item, field)
+		if !ok {
+			return 0, 0, fmt.Errorf("item %d has no field %s", i, field)
+		}
+		total += toFloat(value)
+	}
+	return total, len(items), nil
+}
+
+// teamOf returns the team owning job, UnownedTeam when there is none
+func teamOf(job string) string {
+	if owner, ok := templateOwners.OwnerOf(job); ok {
+		return owner.Team
+	}
+	return UnownedTeam
+}
+
+// groupByTeam groups the items of list by the team owning their JobName
+func groupByTeam(list interface{}) ([]TeamGroup, error) {
+	items, err := listItems(list)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]*TeamGroup)
+	var teams []string
+	for i, item := range items {
+		job, ok := fieldOf(item, "JobName")
+		if !ok {
+			return nil, fmt.Errorf("groupByTeam: item %d has no field JobName", i)
+		}
+		team := teamOf(fmt.Sprint(job))
+		group, ok := groups[team]
+		if !ok {
+			group = &TeamGroup{Team: team}
+			groups[team] = group
+			teams = append(teams, team)
+		}
+		group.Items = append(group.Items, item)
+	}
+	sort.Strings(teams)
+
+	result := make([]TeamGroup, 0, len(teams))
+	for _, team := range teams {
+		group := groups[team]
+		if total, count, err := sumField("Score", group.Items); err == nil && count > 0 {
+			group.AverageScore = total / float64(count)
+		}
+		result = append(result, *group)
+	}
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package formatters_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/formatters"
+	"instrumentation-score/internal/ownership"
+)
+
+type templateJob struct {
+	JobName       string  `json:"job_name"`
+	Score         float64 `json:"score"`
+	EstimatedCost float64 `json:"estimated_cost"`
+}
+
+type templateReport struct {
+	AverageScore float64
+	Previous     float64
+	TotalCost    float64
+	Jobs         []templateJob
+}
+
+func renderTemplate(t *testing.T, name, content string, data interface{}) (string, error) {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err := formatters.RenderTemplate(path, data, &out)
+	return out.String(), err
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	mappingFile := filepath.Join(t.TempDir(), "ownership.yaml")
+	os.WriteFile(mappingFile, []byte("owners:\n  - team: payments\n    jobs: [checkout, billing]\n  - team: search\n    jobs: [indexer]\n"), 0600)
+	mapping, err := ownership.Load(mappingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatters.SetOwnership(mapping)
+	defer formatters.SetOwnership(nil)
+
+	report := templateReport{
+		AverageScore: 72.5,
+		Previous:     75,
+		TotalCost:    1234.5,
+		Jobs: []templateJob{
+			{JobName: "checkout", Score: 80, EstimatedCost: 1000},
+			{JobName: "indexer", Score: 55, EstimatedCost: 200},
+			{JobName: "legacy", Score: 70, EstimatedCost: 4.5},
+			{JobName: "billing", Score: 85},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"arithmetic", `{{add 1 2}} {{sub 5 1.5}} {{mul 2 3}} {{div 1 4}} {{div 1 0}} {{round 2.345 2}} {{abs -3}}`, "3 3.5 6 0.25 0 2.35 3"},
+		{"percentages", `{{percent 1 4}} {{passRate 3 4}} {{percent 1 0}} {{formatPercent 85.26 1}}`, "25 75 0 85.3%"},
+		{"cost", `{{formatCost .TotalCost}} {{formatInt 1234567}}`, "$1,234.50 1,234,567"},
+		{"deltas", `{{formatDelta (delta .AverageScore .Previous) 1}} {{formatDelta 1.25 1}} {{formatDelta 0.01 1}}`, "-2.5 +1.2 ±0.0"},
+		{"category", `{{category .AverageScore}} {{category 95}}`, "Needs Improvement Excellent"},
+		{"sort ascending", `{{range sortBy "Score" .Jobs}}{{.JobName}} {{end}}`, "indexer legacy checkout billing "},
+		{"sort descending by JSON name", `{{range sortByDesc "estimated_cost" .Jobs}}{{.JobName}} {{end}}`, "checkout indexer legacy billing "},
+		{"sort by text", `{{range sortBy "JobName" .Jobs}}{{.JobName}} {{end}}`, "billing checkout indexer legacy "},
+		{"limit", `{{range limit 2 (sortBy "Score" .Jobs)}}{{.JobName}} {{end}}`, "indexer legacy "},
+		{"sum and avg", `{{sum "EstimatedCost" .Jobs}} {{avg "Score" .Jobs}}`, "1204.5 72.5"},
+		{"team", `{{team "checkout"}} {{team "legacy"}}`, "payments unowned"},
+		{
+			"group by team",
+			`{{range groupByTeam .Jobs}}{{.Team}}={{formatFloat .AverageScore 1}}({{range .Items}}{{.JobName}} {{end}}) {{end}}`,
+			"payments=82.5(checkout billing ) search=55.0(indexer ) unowned=70.0(legacy ) ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate(t, "report.md", tt.template, report)
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate_Maps(t *testing.T) {
+	jobs := []map[string]interface{}{{"job_name": "a", "score": 90.0}, {"job_name": "b", "score": 40.0}}
+	got, err := renderTemplate(t, "report.txt", `{{range sortBy "score" .}}{{index . "job_name"}}{{end}}`, jobs)
+	if err != nil || got != "ba" {
+		t.Errorf("RenderTemplate() = %q, %v, want ba", got, err)
+	}
+}
+
+func TestRenderTemplate_Errors(t *testing.T) {
+	report := templateReport{Jobs: []templateJob{{JobName: "checkout"}}}
+	for name, content := range map[string]string{
+		"unknown field": `{{sortBy "Owner" .Jobs}}`,
+		"not a list":    `{{sum "Score" .AverageScore}}`,
+		"parse error":   `{{range}}`,
+	} {
+		if _, err := renderTemplate(t, "report.md", content, report); err == nil {
+			t.Errorf("%s: RenderTemplate() expected error", name)
+		}
+	}
+}
+
+func TestRenderTemplate_HTMLEscapes(t *testing.T) {
+	got, err := renderTemplate(t, "report.html", `<p>{{.}}</p>`, "<script>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "<script>") {
+		t.Errorf("RenderTemplate() = %q, want the value escaped", got)
+	}
+	got, _ = renderTemplate(t, "report.md", `{{.}}`, "<b>")
+	if got != "<b>" {
+		t.Errorf("text RenderTemplate() = %q, want <b>", got)
+	}
+}
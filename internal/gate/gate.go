@@ -0,0 +1,112 @@
+// Package gate decides whether an evaluate run passes a CI quality gate
+package gate
+
+import (
+	"fmt"
+	"sort"
+
+	"instrumentation-score/internal/history"
+)
+
+// Exit codes of a run that failed its gate; other errors exit 1, like log.Fatal
+const (
+	ExitBelowThreshold = 2 // A score is below its --fail-below threshold
+	ExitRegression     = 3 // Only regressions since the baseline, no score below its threshold
+)
+
+// Thresholds configure the gate; zero values disable their check
+type Thresholds struct {
+	MinScore     float64            // Minimum organization score, or the job's score when one job was evaluated
+	MinJobScore  float64            // Minimum score of every job
+	JobMinScores map[string]float64 // Minimum score of a specific job, overriding File and MinJobScore
+	File         *ThresholdsFile    // Minimum scores by job name or pattern, overriding MinJobScore
+	Regression   bool               // Fail when a score dropped since the baseline
+	Tolerance    float64            // Points a score may drop before it is a regression
+}
+
+// JobScore is a job's score in a Run
+type JobScore struct {
+	Name  string
+	Score float64
+}
+
+// Run is the part of an evaluate run the gate checks
+type Run struct {
+	Score        float64 // Organization score, or the job's score when one job was evaluated
+	AverageScore float64 // Compared with the baseline's average when more than one job was evaluated
+	Jobs         []JobScore
+}
+
+// Violation is a threshold or baseline the run did not meet
+type Violation struct {
+	Job        string  // Empty for the organization score or the average score
+	Score      float64 // Current score
+	Limit      float64 // Threshold, or the baseline score for regressions
+	Regression bool
+}
+
+// String describes the violation in one line
+func (v Violation) String() string {
+	subject := "organization score"
+	if v.Regression && v.Job == "" {
+		subject = "average score"
+	} else if v.Job != "" {
+		subject = "job " + v.Job
+	}
+	if v.Regression {
+		return fmt.Sprintf("%s dropped %.1f points since the baseline, from %.1f to %.1f", subject, v.Limit-v.Score, v.Limit, v.Score)
+	}
+	return fmt.Sprintf("%s %.1f is below %.1f", subject, v.Score, v.Limit)
+}
+
+// Check returns the thresholds run does not meet, and its regressions since baseline
+// Jobs missing from baseline are new and cannot regress; baseline may be nil without
+// Thresholds.Regression.
+func Check(run Run, thresholds Thresholds, baseline *history.PreviousRun) []Violation {
+	var violations []Violation
+	if thresholds.MinScore > 0 && run.Score < thresholds.MinScore {
+		violations = append(violations, Violation{Score: run.Score, Limit: thresholds.MinScore})
+	}
+
+	jobs := append([]JobScore(nil), run.Jobs...)
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	for _, job := range jobs {
+		limit := thresholds.MinJobScore
+		if jobLimit, ok := thresholds.File.MinScore(job.Name); ok {
+			limit = jobLimit
+		}
+		if jobLimit, ok := thresholds.JobMinScores[job.Name]; ok {
+			limit = jobLimit
+		}
+		if limit > 0 && job.Score < limit {
+			violations = append(violations, Violation{Job: job.Name, Score: job.Score, Limit: limit})
+		}
+	}
+
+	if !thresholds.Regression || baseline == nil {
+		return violations
+	}
+	if len(run.Jobs) > 1 && baseline.AverageScore-run.AverageScore > thresholds.Tolerance {
+		violations = append(violations, Violation{Score: run.AverageScore, Limit: baseline.AverageScore, Regression: true})
+	}
+	for _, job := range jobs {
+		previous, ok := baseline.Job(job.Name)
+		if ok && previous.Score-job.Score > thresholds.Tolerance {
+			violations = append(violations, Violation{Job: job.Name, Score: job.Score, Limit: previous.Score, Regression: true})
+		}
+	}
+	return violations
+}
+
+// ExitCode returns the exit code of a run with violations, 0 when there are none
+// A score below its threshold takes precedence over regressions.
+func ExitCode(violations []Violation) int {
+	code := 0
+	for _, v := range violations {
+		if !v.Regression {
+			return ExitBelowThreshold
+		}
+		code = ExitRegression
+	}
+	return code
+}
@@ -0,0 +1,99 @@
+package gate
+
+import (
+	"reflect"
+	"testing"
+
+	"instrumentation-score/internal/history"
+)
+
+func TestCheck(t *testing.T) {
+	run := Run{
+		Score:        72,
+		AverageScore: 70,
+		Jobs:         []JobScore{{"checkout", 85}, {"payments", 55}, {"search", 70}},
+	}
+	baseline := &history.PreviousRun{
+		AverageScore: 74,
+		Jobs: map[string]history.PreviousJob{
+			"checkout": {Score: 86},
+			"payments": {Score: 65},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		thresholds Thresholds
+		want       []Violation
+		wantCode   int
+	}{
+		{name: "no thresholds", thresholds: Thresholds{}},
+		{name: "organization score met", thresholds: Thresholds{MinScore: 70}},
+		{
+			name:       "organization score below",
+			thresholds: Thresholds{MinScore: 75},
+			want:       []Violation{{Score: 72, Limit: 75}},
+			wantCode:   ExitBelowThreshold,
+		},
+		{
+			name:       "per-job thresholds",
+			thresholds: Thresholds{MinJobScore: 60, JobMinScores: map[string]float64{"checkout": 90, "payments": 50}},
+			want:       []Violation{{Job: "checkout", Score: 85, Limit: 90}},
+			wantCode:   ExitBelowThreshold,
+		},
+		{
+			name:       "regressions beyond tolerance",
+			thresholds: Thresholds{Regression: true, Tolerance: 2},
+			want: []Violation{
+				{Score: 70, Limit: 74, Regression: true},
+				{Job: "payments", Score: 55, Limit: 65, Regression: true},
+			},
+			wantCode: ExitRegression,
+		},
+		{
+			name:       "threshold wins over regression",
+			thresholds: Thresholds{MinJobScore: 60, Regression: true, Tolerance: 5},
+			want: []Violation{
+				{Job: "payments", Score: 55, Limit: 60},
+				{Job: "payments", Score: 55, Limit: 65, Regression: true},
+			},
+			wantCode: ExitBelowThreshold,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(run, tt.thresholds, baseline)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+			if code := ExitCode(got); code != tt.wantCode {
+				t.Errorf("ExitCode() = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCheck_SingleJobSkipsAverage(t *testing.T) {
+	run := Run{Score: 60, AverageScore: 60, Jobs: []JobScore{{"checkout", 60}}}
+	baseline := &history.PreviousRun{AverageScore: 90, Jobs: map[string]history.PreviousJob{"checkout": {Score: 60}}}
+	if got := Check(run, Thresholds{Regression: true}, baseline); got != nil {
+		t.Errorf("Check() = %+v, want no regressions", got)
+	}
+}
+
+func TestViolation_String(t *testing.T) {
+	tests := []struct {
+		violation Violation
+		want      string
+	}{
+		{Violation{Score: 72, Limit: 75}, "organization score 72.0 is below 75.0"},
+		{Violation{Job: "api", Score: 40, Limit: 50}, "job api 40.0 is below 50.0"},
+		{Violation{Score: 70, Limit: 74, Regression: true}, "average score dropped 4.0 points since the baseline, from 74.0 to 70.0"},
+	}
+	for _, tt := range tests {
+		if got := tt.violation.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
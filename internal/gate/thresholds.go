@@ -0,0 +1,91 @@
+package gate
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ThresholdsFile holds the minimum scores of jobs, by name or regex pattern, so mature
+// services and legacy ones are held to different bars in one run
+//
+// Example thresholds.yaml:
+//
+//	default: 60                      # Jobs matching no entry; optional
+//	jobs:
+//	  - job: checkout
+//	    min_score: 90
+//	  - job_name_pattern: "^legacy-"
+//	    min_score: 50
+type ThresholdsFile struct {
+	Default float64        `yaml:"default"`
+	Jobs    []JobThreshold `yaml:"jobs"` // The first match wins
+}
+
+// JobThreshold is the minimum score of jobs matched by exact name or regex pattern
+type JobThreshold struct {
+	Job            string  `yaml:"job,omitempty"`
+	JobNamePattern string  `yaml:"job_name_pattern,omitempty"`
+	MinScore       float64 `yaml:"min_score"`
+
+	pattern *regexp.Regexp
+}
+
+// LoadThresholdsFile reads and validates a thresholds file
+func LoadThresholdsFile(filename string) (*ThresholdsFile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read thresholds file: %w", err)
+	}
+
+	var file ThresholdsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse thresholds file: %w", err)
+	}
+	if err := file.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &file, nil
+}
+
+// compile validates the entries and compiles their job patterns
+func (f *ThresholdsFile) compile() error {
+	if f.Default < 0 || f.Default > 100 {
+		return fmt.Errorf("default: score %.1f is not between 0 and 100", f.Default)
+	}
+	for i := range f.Jobs {
+		threshold := &f.Jobs[i]
+		if threshold.MinScore < 0 || threshold.MinScore > 100 {
+			return fmt.Errorf("jobs[%d]: min_score %.1f is not between 0 and 100", i, threshold.MinScore)
+		}
+		if threshold.JobNamePattern != "" {
+			pattern, err := regexp.Compile(threshold.JobNamePattern)
+			if err != nil {
+				return fmt.Errorf("invalid regex pattern in jobs[%d]: %w", i, err)
+			}
+			threshold.pattern = pattern
+		} else if threshold.Job == "" {
+			return fmt.Errorf("jobs[%d]: job or job_name_pattern is required", i)
+		}
+	}
+	return nil
+}
+
+// MinScore returns the minimum score of a job: its first matching entry, else the default
+// It reports false when neither applies.
+func (f *ThresholdsFile) MinScore(job string) (float64, bool) {
+	if f == nil {
+		return 0, false
+	}
+	for _, threshold := range f.Jobs {
+		if threshold.Job != "" && threshold.Job == job {
+			return threshold.MinScore, true
+		}
+		if threshold.pattern != nil && threshold.pattern.MatchString(job) {
+			return threshold.MinScore, true
+		}
+	}
+	return f.Default, f.Default > 0
+}
@@ -0,0 +1,93 @@
+package gate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeThresholds(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "thresholds.yaml")
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestThresholdsFile_MinScore(t *testing.T) {
+	file, err := LoadThresholdsFile(writeThresholds(t, `default: 60
+jobs:
+  - job: legacy-billing
+    min_score: 30
+  - job_name_pattern: "^legacy-"
+    min_score: 50
+  - job: checkout
+    min_score: 90
+`))
+	if err != nil {
+		t.Fatalf("LoadThresholdsFile() error = %v", err)
+	}
+
+	tests := []struct {
+		job    string
+		want   float64
+		wantOK bool
+	}{
+		{"checkout", 90, true},
+		{"legacy-billing", 30, true}, // The first match wins
+		{"legacy-search", 50, true},
+		{"search", 60, true},
+	}
+	for _, tt := range tests {
+		got, ok := file.MinScore(tt.job)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("MinScore(%q) = %v, %v, want %v, %v", tt.job, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	var none *ThresholdsFile
+	if _, ok := none.MinScore("checkout"); ok {
+		t.Error("MinScore() of a nil file should not apply")
+	}
+}
+
+func TestCheck_ThresholdsFile(t *testing.T) {
+	file, err := LoadThresholdsFile(writeThresholds(t, "jobs:\n  - job_name_pattern: \"^legacy-\"\n    min_score: 50\n  - job: checkout\n    min_score: 90\n"))
+	if err != nil {
+		t.Fatalf("LoadThresholdsFile() error = %v", err)
+	}
+	run := Run{Jobs: []JobScore{{"checkout", 85}, {"legacy-billing", 45}, {"legacy-search", 55}, {"search", 65}}}
+	thresholds := Thresholds{MinJobScore: 70, File: file, JobMinScores: map[string]float64{"legacy-billing": 40}}
+
+	want := []Violation{
+		{Job: "checkout", Score: 85, Limit: 90},
+		{Job: "search", Score: 65, Limit: 70}, // No entry matches, --fail-below-job applies
+	}
+	if got := Check(run, thresholds, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadThresholdsFile_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no job":        "jobs:\n  - min_score: 50\n",
+		"bad pattern":   "jobs:\n  - job_name_pattern: \"[\"\n    min_score: 50\n",
+		"score too big": "jobs:\n  - job: api\n    min_score: 150\n",
+		"bad default":   "default: -1\n",
+		"not yaml":      "jobs: [\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadThresholdsFile(writeThresholds(t, content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := LoadThresholdsFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil || !strings.Contains(err.Error(), "failed to read") {
+		t.Errorf("LoadThresholdsFile() error = %v, want a read error", err)
+	}
+}
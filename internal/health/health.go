@@ -0,0 +1,89 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check verifies one dependency, returning why it is unavailable
+type Check struct {
+	Name string
+	Run  func() error
+}
+
+// Status is the JSON body of /healthz and /readyz
+type Status struct {
+	Status string            `json:"status"` // "ok" or "failing"
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Server answers Kubernetes liveness and readiness probes for a long-running command
+// /healthz fails when the work loop has not completed a pass for longer than MaxPassAge,
+// so a stuck process gets restarted. /readyz runs every check, so a process whose
+// dependencies are unreachable or whose configuration became invalid is reported.
+type Server struct {
+	MaxPassAge time.Duration // 0 disables the liveness staleness check
+	checks     []Check
+	mu         sync.Mutex
+	lastPass   time.Time
+}
+
+// NewServer returns a Server running checks for readiness
+// The start time counts as the first pass, so a slow first pass is not a failure.
+func NewServer(maxPassAge time.Duration, checks ...Check) *Server {
+	return &Server{MaxPassAge: maxPassAge, checks: checks, lastPass: time.Now()}
+}
+
+// PassCompleted records that the work loop finished a pass, successful or not
+func (s *Server) PassCompleted() {
+	s.mu.Lock()
+	s.lastPass = time.Now()
+	s.mu.Unlock()
+}
+
+// Handler serves /healthz and /readyz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.serveHealthz)
+	mux.HandleFunc("/readyz", s.serveReadyz)
+	return mux
+}
+
+func (s *Server) serveHealthz(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	age := time.Since(s.lastPass)
+	s.mu.Unlock()
+
+	status := Status{Status: "ok"}
+	if s.MaxPassAge > 0 && age > s.MaxPassAge {
+		status = Status{Status: "failing", Checks: map[string]string{
+			"loop": fmt.Sprintf("no pass completed for %s (limit %s)", age.Round(time.Second), s.MaxPassAge),
+		}}
+	}
+	writeStatus(w, status)
+}
+
+func (s *Server) serveReadyz(w http.ResponseWriter, r *http.Request) {
+	status := Status{Status: "ok", Checks: make(map[string]string)}
+	for _, check := range s.checks {
+		if err := check.Run(); err != nil {
+			status.Status = "failing"
+			status.Checks[check.Name] = err.Error()
+		} else {
+			status.Checks[check.Name] = "ok"
+		}
+	}
+	writeStatus(w, status)
+}
+
+// writeStatus writes status as JSON, with 503 when it is failing
+func writeStatus(w http.ResponseWriter, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+	if status.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
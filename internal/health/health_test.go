@@ -0,0 +1,58 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, handler http.Handler, path string) (int, Status) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	var status Status
+	if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+		t.Fatalf("%s: invalid body %q: %v", path, recorder.Body.String(), err)
+	}
+	return recorder.Code, status
+}
+
+func TestServer_Readyz(t *testing.T) {
+	rulesErr := error(nil)
+	server := NewServer(0,
+		Check{Name: "kubernetes", Run: func() error { return nil }},
+		Check{Name: "rules", Run: func() error { return rulesErr }},
+	)
+
+	if code, status := get(t, server.Handler(), "/readyz"); code != http.StatusOK || status.Checks["rules"] != "ok" {
+		t.Errorf("/readyz = %d %+v, want 200 with every check ok", code, status)
+	}
+
+	rulesErr = errors.New("invalid rules")
+	code, status := get(t, server.Handler(), "/readyz")
+	if code != http.StatusServiceUnavailable || status.Status != "failing" {
+		t.Errorf("/readyz = %d %+v, want 503", code, status)
+	}
+	if status.Checks["rules"] != "invalid rules" || status.Checks["kubernetes"] != "ok" {
+		t.Errorf("checks = %v", status.Checks)
+	}
+}
+
+func TestServer_Healthz(t *testing.T) {
+	server := NewServer(time.Minute)
+	if code, _ := get(t, server.Handler(), "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz = %d right after start, want 200", code)
+	}
+
+	server.lastPass = time.Now().Add(-2 * time.Minute)
+	if code, status := get(t, server.Handler(), "/healthz"); code != http.StatusServiceUnavailable || status.Checks["loop"] == "" {
+		t.Errorf("/healthz = %d %+v, want 503 for a stale loop", code, status)
+	}
+
+	server.PassCompleted()
+	if code, _ := get(t, server.Handler(), "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz = %d after a pass, want 200", code)
+	}
+}
@@ -0,0 +1,110 @@
+package history
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// RenameSimilarity is the share of metric names a job that disappeared and a job that
+// appeared since the previous run must have in common to be taken for a rename
+const RenameSimilarity = 0.9
+
+// fingerprintSize is the number of values in a Fingerprint
+const fingerprintSize = 64
+
+// Fingerprint summarizes a job's metric names, so runs can recognize a job by what it
+// exports without storing every name. It is a MinHash signature: the share of equal
+// positions of two fingerprints estimates the share of metric names the jobs have in common.
+type Fingerprint []uint32
+
+// MetricFingerprint returns the fingerprint of a job exporting metricNames
+func MetricFingerprint(metricNames []string) Fingerprint {
+	if len(metricNames) == 0 {
+		return nil
+	}
+	fingerprint := make(Fingerprint, fingerprintSize)
+	for i := range fingerprint {
+		fingerprint[i] = math.MaxUint32
+	}
+	for _, name := range metricNames {
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		sum := h.Sum64()
+		for i := range fingerprint {
+			if v := uint32(mix(sum ^ uint64(i)*0x9e3779b97f4a7c15)); v < fingerprint[i] {
+				fingerprint[i] = v
+			}
+		}
+	}
+	return fingerprint
+}
+
+// mix is the splitmix64 finalizer, deriving the independent hashes of each position
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// Similarity estimates the share of metric names two jobs have in common, from 0 to 1
+func (f Fingerprint) Similarity(other Fingerprint) float64 {
+	if len(f) == 0 || len(f) != len(other) {
+		return 0
+	}
+	equal := 0
+	for i := range f {
+		if f[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(f))
+}
+
+// DetectRenames pairs jobs of previous that are missing from current with new jobs of
+// current exporting nearly the same metrics, and returns the previous name of each
+// renamed job. A pair must be each other's only best match: when several jobs with
+// the same metrics disappeared or appeared, none of them is taken for a rename.
+func DetectRenames(previous, current map[string]Fingerprint) map[string]string {
+	var gone, added []string
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			gone = append(gone, name)
+		}
+	}
+	for name := range current {
+		if _, ok := previous[name]; !ok {
+			added = append(added, name)
+		}
+	}
+
+	// bestMatch returns the candidate most similar to fingerprint, or "" when none reaches
+	// RenameSimilarity or several are equally similar
+	bestMatch := func(fingerprint Fingerprint, candidates []string, fingerprints map[string]Fingerprint) string {
+		best, bestSimilarity, tied := "", 0.0, false
+		for _, candidate := range candidates {
+			similarity := fingerprint.Similarity(fingerprints[candidate])
+			switch {
+			case similarity < RenameSimilarity || similarity < bestSimilarity:
+			case similarity == bestSimilarity:
+				tied = true
+			default:
+				best, bestSimilarity, tied = candidate, similarity, false
+			}
+		}
+		if tied {
+			return ""
+		}
+		return best
+	}
+
+	renames := make(map[string]string)
+	for _, name := range added {
+		old := bestMatch(current[name], gone, previous)
+		if old != "" && bestMatch(previous[old], added, current) == name {
+			renames[name] = old
+		}
+	}
+	return renames
+}
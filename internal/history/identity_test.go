@@ -0,0 +1,91 @@
+package history
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// metricNames returns count metric names starting at first
+func metricNames(prefix string, first, count int) []string {
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s_metric_%d", prefix, first+i)
+	}
+	return names
+}
+
+func TestFingerprintSimilarity(t *testing.T) {
+	base := MetricFingerprint(metricNames("app", 0, 200))
+
+	tests := []struct {
+		name  string
+		other Fingerprint
+		min   float64
+		max   float64
+	}{
+		{"same metrics", MetricFingerprint(metricNames("app", 0, 200)), 1, 1},
+		{"a few metrics added", MetricFingerprint(metricNames("app", 0, 205)), RenameSimilarity, 1},
+		{"half the metrics", MetricFingerprint(metricNames("app", 100, 200)), 0.15, 0.55},
+		{"other metrics", MetricFingerprint(metricNames("db", 0, 200)), 0, 0.1},
+		{"no metrics", MetricFingerprint(nil), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.Similarity(tt.other); got < tt.min || got > tt.max {
+				t.Errorf("Similarity() = %v, want %v to %v", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestDetectRenames(t *testing.T) {
+	api := MetricFingerprint(metricNames("api", 0, 100))
+	db := MetricFingerprint(metricNames("db", 0, 100))
+	probe := MetricFingerprint([]string{"probe_success", "probe_duration_seconds"})
+
+	tests := []struct {
+		name     string
+		previous map[string]Fingerprint
+		current  map[string]Fingerprint
+		want     map[string]string
+	}{
+		{
+			name:     "renamed job",
+			previous: map[string]Fingerprint{"api": api, "db": db},
+			current:  map[string]Fingerprint{"api-v2": api, "db": db},
+			want:     map[string]string{"api-v2": "api"},
+		},
+		{
+			name:     "job replaced by a different one",
+			previous: map[string]Fingerprint{"api": api},
+			current:  map[string]Fingerprint{"db": db},
+			want:     map[string]string{},
+		},
+		{
+			name:     "job still present is not renamed",
+			previous: map[string]Fingerprint{"api": api},
+			current:  map[string]Fingerprint{"api": api, "api-copy": api},
+			want:     map[string]string{},
+		},
+		{
+			name:     "ambiguous matches",
+			previous: map[string]Fingerprint{"probe-a": probe, "probe-b": probe},
+			current:  map[string]Fingerprint{"probe-c": probe},
+			want:     map[string]string{},
+		},
+		{
+			name:     "reports without fingerprints",
+			previous: map[string]Fingerprint{"api": nil},
+			current:  map[string]Fingerprint{"api-v2": api},
+			want:     map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectRenames(tt.previous, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectRenames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
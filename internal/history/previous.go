@@ -0,0 +1,114 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"instrumentation-score/internal/engine"
+)
+
+// Metric changes since the previous run, see PreviousRun.MetricChange
+const (
+	MetricNewlyFailed = "new"   // Fails now but passed (or did not exist) in the previous run
+	MetricStillFailed = "still" // Failed in the previous run too
+	MetricFixed       = "fixed" // Failed in the previous run and passes now
+)
+
+// PreviousRun is what an earlier evaluate JSON report says about each job
+// It is compared with the current run to annotate what changed.
+type PreviousRun struct {
+	Timestamp    string
+	AverageScore float64
+	Jobs         map[string]PreviousJob
+	Rules        map[string]RulePassRate // Each rule's results over all jobs, see Regressions
+
+	renames map[string]string // Current job name -> name in the previous run, see DetectRenames
+}
+
+// PreviousJob is a job's result in the previous run
+type PreviousJob struct {
+	Score         float64
+	FailedMetrics map[string]bool
+	Fingerprint   Fingerprint // Of the job's metric names; nil in reports written before fingerprints
+}
+
+// previousReport is the part of an all-jobs JSON report a PreviousRun is read from
+type previousReport struct {
+	Timestamp    string  `json:"timestamp"`
+	AverageScore float64 `json:"average_score"`
+	Jobs         []struct {
+		JobName       string              `json:"job_name"`
+		Score         float64             `json:"instrumentation_score"`
+		FailedMetrics []string            `json:"failed_metrics"`
+		Fingerprint   Fingerprint         `json:"metric_fingerprint"`
+		Rules         []engine.RuleResult `json:"rules"`
+	} `json:"jobs"`
+}
+
+// LoadPreviousRun reads the JSON report of an earlier evaluate --job-dir run
+func LoadPreviousRun(filename string) (*PreviousRun, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous report: %w", err)
+	}
+	var report previousReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse previous report %s: %w", filename, err)
+	}
+	if report.Jobs == nil {
+		return nil, fmt.Errorf("previous report %s has no jobs; use the JSON report of an evaluate --job-dir run", filename)
+	}
+
+	run := &PreviousRun{Timestamp: report.Timestamp, AverageScore: report.AverageScore, Jobs: make(map[string]PreviousJob, len(report.Jobs))}
+	jobResults := make([][]engine.RuleResult, 0, len(report.Jobs))
+	for _, job := range report.Jobs {
+		jobResults = append(jobResults, job.Rules)
+		failed := make(map[string]bool, len(job.FailedMetrics))
+		for _, metric := range job.FailedMetrics {
+			failed[metric] = true
+		}
+		run.Jobs[job.JobName] = PreviousJob{Score: job.Score, FailedMetrics: failed, Fingerprint: job.Fingerprint}
+	}
+	run.Rules = RulePassRates(jobResults)
+	return run, nil
+}
+
+// Job returns a job's previous result, and whether it was evaluated in the previous run
+// A job renamed since then has the result of its previous name, see DetectRenames.
+func (p *PreviousRun) Job(name string) (PreviousJob, bool) {
+	if old, ok := p.renames[name]; ok {
+		name = old
+	}
+	job, ok := p.Jobs[name]
+	return job, ok
+}
+
+// DetectRenames recognizes jobs renamed since the previous run by their metrics, given
+// the fingerprints of the current run's jobs, and links them to their previous results
+// It returns the previous name of each renamed job.
+func (p *PreviousRun) DetectRenames(current map[string]Fingerprint) map[string]string {
+	previous := make(map[string]Fingerprint, len(p.Jobs))
+	for name, job := range p.Jobs {
+		previous[name] = job.Fingerprint
+	}
+	p.renames = DetectRenames(previous, current)
+	return p.renames
+}
+
+// MetricChange classifies a metric of a job evaluated in the previous run
+// It returns MetricNewlyFailed, MetricStillFailed, MetricFixed, or "" when the metric
+// passed both times.
+func (j PreviousJob) MetricChange(metricName string, failed bool) string {
+	failedBefore := j.FailedMetrics[metricName]
+	switch {
+	case failed && failedBefore:
+		return MetricStillFailed
+	case failed:
+		return MetricNewlyFailed
+	case failedBefore:
+		return MetricFixed
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,83 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPreviousRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "previous.json")
+	report := `{
+  "timestamp": "2025-11-02T16:00:00Z",
+  "average_score": 91.25,
+  "jobs": [
+    {"job_name": "api", "instrumentation_score": 82.5, "failed_metrics": ["a", "b"],
+     "rules": [{"RuleID": "PROM-MET-02", "Impact": "Critical", "PassedMetrics": 8, "TotalMetrics": 10}]},
+    {"job_name": "db", "instrumentation_score": 100,
+     "rules": [{"RuleID": "PROM-MET-02", "Impact": "Critical", "PassedMetrics": 10, "TotalMetrics": 10}]}
+  ]
+}`
+	if err := os.WriteFile(path, []byte(report), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := LoadPreviousRun(path)
+	if err != nil {
+		t.Fatalf("LoadPreviousRun() error = %v", err)
+	}
+	if run.Timestamp != "2025-11-02T16:00:00Z" || run.AverageScore != 91.25 {
+		t.Errorf("Timestamp = %q, AverageScore = %v", run.Timestamp, run.AverageScore)
+	}
+	if rule := run.Rules["PROM-MET-02"]; rule.Passed != 18 || rule.Total != 20 || rule.Impact != "Critical" {
+		t.Errorf("Rules[PROM-MET-02] = %+v, want 18 of 20 passed over both jobs", rule)
+	}
+
+	api, ok := run.Job("api")
+	if !ok || api.Score != 82.5 {
+		t.Fatalf("Job(api) = %+v, %v", api, ok)
+	}
+	if _, ok := run.Job("web"); ok {
+		t.Error("Job(web) found, want a job missing from the previous run")
+	}
+
+	tests := []struct {
+		metric string
+		failed bool
+		want   string
+	}{
+		{"a", true, MetricStillFailed},
+		{"b", false, MetricFixed},
+		{"c", true, MetricNewlyFailed},
+		{"d", false, ""},
+	}
+	for _, tt := range tests {
+		if got := api.MetricChange(tt.metric, tt.failed); got != tt.want {
+			t.Errorf("MetricChange(%s, %v) = %q, want %q", tt.metric, tt.failed, got, tt.want)
+		}
+	}
+
+	single := filepath.Join(dir, "single.json")
+	if err := os.WriteFile(single, []byte(`{"job_name": "api", "instrumentation_score": 80}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPreviousRun(single); err == nil {
+		t.Error("LoadPreviousRun() of a single-job report should fail")
+	}
+}
+
+func TestPreviousRun_DetectRenames(t *testing.T) {
+	fingerprint := MetricFingerprint([]string{"http_requests_total", "http_errors_total"})
+	run := &PreviousRun{Jobs: map[string]PreviousJob{
+		"api": {Score: 70, Fingerprint: fingerprint},
+	}}
+
+	renames := run.DetectRenames(map[string]Fingerprint{"api-v2": fingerprint})
+	if renames["api-v2"] != "api" {
+		t.Fatalf("DetectRenames() = %v, want api-v2 renamed from api", renames)
+	}
+	if job, ok := run.Job("api-v2"); !ok || job.Score != 70 {
+		t.Errorf("Job(api-v2) = %+v, %v; want the result of api", job, ok)
+	}
+}
@@ -0,0 +1,90 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+
+	"instrumentation-score/internal/engine"
+)
+
+// RulePassRate is a rule's result summed over every job of a run
+type RulePassRate struct {
+	Impact string
+	Passed int // Metrics that passed the rule
+	Total  int // Metrics the rule evaluated
+}
+
+// Rate returns the percentage of metrics that passed, 100 when the rule evaluated none
+func (r RulePassRate) Rate() float64 {
+	if r.Total == 0 {
+		return 100
+	}
+	return float64(r.Passed) / float64(r.Total) * 100
+}
+
+// RulePassRates sums the results of each rule over the jobs of a run
+func RulePassRates(jobs [][]engine.RuleResult) map[string]RulePassRate {
+	rates := make(map[string]RulePassRate)
+	for _, results := range jobs {
+		for _, result := range results {
+			rate := rates[result.RuleID]
+			rate.Impact = result.Impact
+			rate.Passed += result.PassedMetrics
+			rate.Total += result.TotalMetrics
+			rates[result.RuleID] = rate
+		}
+	}
+	return rates
+}
+
+// RegressionThresholds are how far a run may fall behind the previous one before it is
+// a severe regression; a zero threshold disables its check
+type RegressionThresholds struct {
+	ScoreDrop    float64 // Points the average score may drop
+	PassRateDrop float64 // Percentage points the org-wide pass rate of a Critical rule may drop
+}
+
+// Regression is a drop past a RegressionThresholds limit
+type Regression struct {
+	RuleID   string  // Rule whose pass rate dropped; empty when the average score dropped
+	Previous float64 // Average score or pass rate in the previous run
+	Current  float64
+}
+
+// String describes the regression in one line
+func (r Regression) String() string {
+	if r.RuleID == "" {
+		return fmt.Sprintf("average score dropped %.1f points, from %.1f to %.1f", r.Previous-r.Current, r.Previous, r.Current)
+	}
+	return fmt.Sprintf("Critical rule %s pass rate dropped %.1f points, from %.1f%% to %.1f%%", r.RuleID, r.Previous-r.Current, r.Previous, r.Current)
+}
+
+// Regressions compares the average score and rule pass rates of the current run with the
+// previous run, and returns the drops larger than thresholds allow
+// Critical rules that did not run in the previous run are skipped.
+func (p *PreviousRun) Regressions(averageScore float64, rules map[string]RulePassRate, thresholds RegressionThresholds) []Regression {
+	var regressions []Regression
+	if thresholds.ScoreDrop > 0 && p.AverageScore-averageScore > thresholds.ScoreDrop {
+		regressions = append(regressions, Regression{Previous: p.AverageScore, Current: averageScore})
+	}
+	if thresholds.PassRateDrop <= 0 {
+		return regressions
+	}
+
+	ruleIDs := make([]string, 0, len(rules))
+	for ruleID := range rules {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Strings(ruleIDs)
+	for _, ruleID := range ruleIDs {
+		current := rules[ruleID]
+		previous, ok := p.Rules[ruleID]
+		if current.Impact != "Critical" || !ok || previous.Total == 0 {
+			continue
+		}
+		if previous.Rate()-current.Rate() > thresholds.PassRateDrop {
+			regressions = append(regressions, Regression{RuleID: ruleID, Previous: previous.Rate(), Current: current.Rate()})
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"instrumentation-score/internal/engine"
+)
+
+func TestRulePassRates(t *testing.T) {
+	rates := RulePassRates([][]engine.RuleResult{
+		{{RuleID: "A", Impact: "Critical", PassedMetrics: 3, TotalMetrics: 4}, {RuleID: "B", Impact: "Low"}},
+		{{RuleID: "A", Impact: "Critical", PassedMetrics: 5, TotalMetrics: 6}},
+	})
+
+	if got := rates["A"]; got.Passed != 8 || got.Total != 10 || got.Rate() != 80 {
+		t.Errorf("rates[A] = %+v (%.1f%%), want 8 of 10", got, got.Rate())
+	}
+	if got := rates["B"].Rate(); got != 100 {
+		t.Errorf("rate of a rule without metrics = %v, want 100", got)
+	}
+}
+
+func TestPreviousRun_Regressions(t *testing.T) {
+	previous := &PreviousRun{
+		AverageScore: 90,
+		Rules: map[string]RulePassRate{
+			"CRIT-1": {Impact: "Critical", Passed: 95, Total: 100},
+			"CRIT-2": {Impact: "Critical", Passed: 90, Total: 100},
+			"IMP-1":  {Impact: "Important", Passed: 100, Total: 100},
+		},
+	}
+	current := map[string]RulePassRate{
+		"CRIT-1": {Impact: "Critical", Passed: 40, Total: 100}, // Collapsed
+		"CRIT-2": {Impact: "Critical", Passed: 85, Total: 100}, // Within the threshold
+		"CRIT-3": {Impact: "Critical", Passed: 0, Total: 10},   // New rule, nothing to compare
+		"IMP-1":  {Impact: "Important", Passed: 0, Total: 100}, // Not Critical
+	}
+
+	tests := []struct {
+		name       string
+		average    float64
+		thresholds RegressionThresholds
+		want       []Regression
+	}{
+		{"disabled", 50, RegressionThresholds{}, nil},
+		{"score drop within threshold", 85, RegressionThresholds{ScoreDrop: 10}, nil},
+		{"score drop", 70, RegressionThresholds{ScoreDrop: 10}, []Regression{{Previous: 90, Current: 70}}},
+		{"pass rate collapse", 90, RegressionThresholds{PassRateDrop: 20}, []Regression{{RuleID: "CRIT-1", Previous: 95, Current: 40}}},
+		{"both", 70, RegressionThresholds{ScoreDrop: 10, PassRateDrop: 2}, []Regression{
+			{Previous: 90, Current: 70},
+			{RuleID: "CRIT-1", Previous: 95, Current: 40},
+			{RuleID: "CRIT-2", Previous: 90, Current: 85},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := previous.Regressions(tt.average, current, tt.thresholds); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Regressions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegression_String(t *testing.T) {
+	if got, want := (Regression{Previous: 90, Current: 70}).String(), "average score dropped 20.0 points, from 90.0 to 70.0"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := (Regression{RuleID: "CRIT-1", Previous: 95, Current: 40}).String(), "Critical rule CRIT-1 pass rate dropped 55.0 points, from 95.0% to 40.0%"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
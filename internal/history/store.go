@@ -0,0 +1,332 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/orgscore"
+
+	_ "modernc.org/sqlite" // Pure Go driver, so release binaries stay statically linked
+)
+
+// schemaVersion is stored in PRAGMA user_version; bump it with a migration in migrate
+const schemaVersion = 2
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp     TEXT NOT NULL,
+	rules_hash    TEXT NOT NULL DEFAULT '',
+	average_score REAL NOT NULL
+);
+CREATE TABLE IF NOT EXISTS job_scores (
+	run_id            INTEGER NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+	job               TEXT NOT NULL,
+	score             REAL NOT NULL,
+	total_metrics     INTEGER NOT NULL,
+	total_cardinality INTEGER NOT NULL,
+	fingerprint       TEXT NOT NULL DEFAULT '',
+	source            TEXT NOT NULL DEFAULT '',
+	renamed_from      TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (run_id, job)
+);
+CREATE INDEX IF NOT EXISTS job_scores_by_job ON job_scores (job, run_id);
+CREATE TABLE IF NOT EXISTS rule_results (
+	run_id         INTEGER NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+	job            TEXT NOT NULL,
+	rule_id        TEXT NOT NULL,
+	impact         TEXT NOT NULL,
+	passed_metrics INTEGER NOT NULL,
+	total_metrics  INTEGER NOT NULL,
+	failed_metrics INTEGER NOT NULL,
+	PRIMARY KEY (run_id, job, rule_id)
+);
+CREATE TABLE IF NOT EXISTS failed_metrics (
+	run_id      INTEGER NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+	job         TEXT NOT NULL,
+	rule_id     TEXT NOT NULL,
+	metric_name TEXT NOT NULL,
+	PRIMARY KEY (run_id, job, rule_id, metric_name)
+);
+`
+
+// migrations bring a database of the version before each index up to the next; the tables
+// they do not change are created by schema
+var migrations = map[int]string{
+	1: `ALTER TABLE job_scores ADD COLUMN fingerprint TEXT NOT NULL DEFAULT '';
+ALTER TABLE job_scores ADD COLUMN source TEXT NOT NULL DEFAULT '';
+ALTER TABLE job_scores ADD COLUMN renamed_from TEXT NOT NULL DEFAULT '';`,
+}
+
+// Store is a SQLite database of evaluate runs, for following scores over time
+type Store struct {
+	db *sql.DB
+}
+
+// Run is an evaluate run as recorded in a Store
+type Run struct {
+	Timestamp    time.Time
+	RulesHash    string // Runs with different hashes were scored by different rules
+	AverageScore float64
+	Jobs         []JobRun
+}
+
+// JobRun is a job's result in a recorded run
+type JobRun struct {
+	JobName          string
+	Score            float64
+	TotalMetrics     int
+	TotalCardinality int64
+	Rules            []engine.RuleResult
+	Fingerprint      Fingerprint // Recognizes the job when renamed, see LatestFingerprints
+	Source           string      // Identifies the evaluated data, e.g. job file and modification time; see FailureStreaks
+	RenamedFrom      string      // Name of the job in earlier runs, whose failures its streaks continue
+}
+
+// TrendPoint is a job's score in one recorded run
+type TrendPoint struct {
+	RunID            int64       `json:"run_id"`
+	Timestamp        time.Time   `json:"timestamp"`
+	RulesHash        string      `json:"rules_hash,omitempty"`
+	Score            float64     `json:"instrumentation_score"`
+	TotalMetrics     int         `json:"total_metrics"`
+	TotalCardinality int64       `json:"total_cardinality"`
+	Rules            []RulePoint `json:"rules"`
+}
+
+// RulePoint is a rule's result for a job in one recorded run
+type RulePoint struct {
+	RuleID        string `json:"rule_id"`
+	Impact        string `json:"impact"`
+	PassedMetrics int    `json:"passed_metrics"`
+	TotalMetrics  int    `json:"total_metrics"`
+	FailedMetrics int    `json:"failed_metrics"`
+}
+
+// JobSummary is a job's latest recorded score and its first one in the queried period
+type JobSummary struct {
+	JobName     string    `json:"job_name"`
+	Runs        int       `json:"runs"`
+	FirstScore  float64   `json:"first_score"`
+	LatestScore float64   `json:"latest_score"`
+	LatestRun   time.Time `json:"latest_run"`
+}
+
+// Change is the score change over the period
+func (s JobSummary) Change() float64 {
+	return s.LatestScore - s.FirstScore
+}
+
+// ScoreDistributions returns how the first and the latest scores of jobs in the period spread,
+// showing whether the typical job improved and not only the average
+func ScoreDistributions(jobs []JobSummary) (first, latest orgscore.Distribution) {
+	firstScores := make([]float64, 0, len(jobs))
+	latestScores := make([]float64, 0, len(jobs))
+	for _, job := range jobs {
+		firstScores = append(firstScores, job.FirstScore)
+		latestScores = append(latestScores, job.LatestScore)
+	}
+	return orgscore.Distribute(firstScores), orgscore.Distribute(latestScores)
+}
+
+// OpenStore opens the history database at path, creating it when it does not exist
+func OpenStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	// SQLite allows one writer; a single connection also keeps the pragmas below in effect
+	db.SetMaxOpenConns(1)
+	store := &Store{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare history database %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// migrate creates the schema, refusing databases written by a newer version
+func (s *Store) migrate() error {
+	if _, err := s.db.Exec("PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000"); err != nil {
+		return err
+	}
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d)", version, schemaVersion)
+	}
+	for ; version > 0 && version < schemaVersion; version++ {
+		if _, err := s.db.Exec(migrations[version]); err != nil {
+			return fmt.Errorf("failed to migrate from schema version %d: %w", version, err)
+		}
+	}
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion))
+	return err
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record stores a run with its jobs and their rule results, returning the run's ID
+func (s *Store) Record(run Run) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record run: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO runs (timestamp, rules_hash, average_score) VALUES (?, ?, ?)",
+		formatTimestamp(run.Timestamp), run.RulesHash, run.AverageScore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record run: %w", err)
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record run: %w", err)
+	}
+
+	for _, job := range run.Jobs {
+		var fingerprint []byte
+		if len(job.Fingerprint) > 0 {
+			fingerprint, _ = json.Marshal(job.Fingerprint)
+		}
+		if _, err := tx.Exec("INSERT INTO job_scores (run_id, job, score, total_metrics, total_cardinality, fingerprint, source, renamed_from) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			runID, job.JobName, job.Score, job.TotalMetrics, job.TotalCardinality, string(fingerprint), job.Source, job.RenamedFrom); err != nil {
+			return 0, fmt.Errorf("failed to record job %s: %w", job.JobName, err)
+		}
+		for _, rule := range job.Rules {
+			if _, err := tx.Exec("INSERT INTO rule_results (run_id, job, rule_id, impact, passed_metrics, total_metrics, failed_metrics) VALUES (?, ?, ?, ?, ?, ?, ?)",
+				runID, job.JobName, rule.RuleID, rule.Impact, rule.PassedMetrics, rule.TotalMetrics, len(rule.FailedMetrics)); err != nil {
+				return 0, fmt.Errorf("failed to record rule %s of job %s: %w", rule.RuleID, job.JobName, err)
+			}
+			for metricName := range rule.FailedMetrics {
+				if _, err := tx.Exec("INSERT INTO failed_metrics (run_id, job, rule_id, metric_name) VALUES (?, ?, ?, ?)",
+					runID, job.JobName, rule.RuleID, metricName); err != nil {
+					return 0, fmt.Errorf("failed to record failures of rule %s of job %s: %w", rule.RuleID, job.JobName, err)
+				}
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to record run: %w", err)
+	}
+	return runID, nil
+}
+
+// Trend returns a job's recorded scores since a time (zero for all), oldest first
+// limit keeps only the most recent runs; 0 returns all of them.
+func (s *Store) Trend(job string, since time.Time, limit int) ([]TrendPoint, error) {
+	query := `SELECT r.id, r.timestamp, r.rules_hash, j.score, j.total_metrics, j.total_cardinality
+		FROM job_scores j JOIN runs r ON r.id = j.run_id
+		WHERE j.job = ? AND r.timestamp >= ?
+		ORDER BY r.timestamp DESC, r.id DESC`
+	args := []interface{}{job, formatTimestamp(since)}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history of job %s: %w", job, err)
+	}
+	defer rows.Close()
+
+	var points []TrendPoint
+	byRun := make(map[int64]int)
+	for rows.Next() {
+		var point TrendPoint
+		var timestamp string
+		if err := rows.Scan(&point.RunID, &timestamp, &point.RulesHash, &point.Score, &point.TotalMetrics, &point.TotalCardinality); err != nil {
+			return nil, fmt.Errorf("failed to read history of job %s: %w", job, err)
+		}
+		point.Timestamp = parseTimestamp(timestamp)
+		byRun[point.RunID] = len(points)
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history of job %s: %w", job, err)
+	}
+	rows.Close() // Frees the only connection for the next query
+	if len(points) == 0 {
+		return nil, nil
+	}
+
+	ruleRows, err := s.db.Query(`SELECT run_id, rule_id, impact, passed_metrics, total_metrics, failed_metrics
+		FROM rule_results WHERE job = ? AND run_id >= ? ORDER BY rule_id`, job, points[len(points)-1].RunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule history of job %s: %w", job, err)
+	}
+	defer ruleRows.Close()
+	for ruleRows.Next() {
+		var runID int64
+		var rule RulePoint
+		if err := ruleRows.Scan(&runID, &rule.RuleID, &rule.Impact, &rule.PassedMetrics, &rule.TotalMetrics, &rule.FailedMetrics); err != nil {
+			return nil, fmt.Errorf("failed to read rule history of job %s: %w", job, err)
+		}
+		if i, ok := byRun[runID]; ok {
+			points[i].Rules = append(points[i].Rules, rule)
+		}
+	}
+	if err := ruleRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rule history of job %s: %w", job, err)
+	}
+
+	// Queried newest first so limit keeps the latest runs
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	return points, nil
+}
+
+// Jobs summarizes the recorded scores of every job since a time (zero for all), by job name
+func (s *Store) Jobs(since time.Time) ([]JobSummary, error) {
+	rows, err := s.db.Query(`SELECT j.job, r.timestamp, j.score
+		FROM job_scores j JOIN runs r ON r.id = j.run_id
+		WHERE r.timestamp >= ?
+		ORDER BY j.job, r.timestamp, r.id`, formatTimestamp(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job history: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []JobSummary
+	for rows.Next() {
+		var job, timestamp string
+		var score float64
+		if err := rows.Scan(&job, &timestamp, &score); err != nil {
+			return nil, fmt.Errorf("failed to read job history: %w", err)
+		}
+		if len(summaries) == 0 || summaries[len(summaries)-1].JobName != job {
+			summaries = append(summaries, JobSummary{JobName: job, FirstScore: score})
+		}
+		summary := &summaries[len(summaries)-1]
+		summary.Runs++
+		summary.LatestScore = score
+		summary.LatestRun = parseTimestamp(timestamp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read job history: %w", err)
+	}
+	return summaries, nil
+}
+
+// formatTimestamp stores times in UTC with a fixed width, so they sort as text
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func parseTimestamp(timestamp string) time.Time {
+	t, _ := time.Parse("2006-01-02T15:04:05.000Z", timestamp)
+	return t
+}
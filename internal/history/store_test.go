@@ -0,0 +1,121 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/engine"
+)
+
+func TestStore_RecordAndTrend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore() error = %v", err)
+	}
+
+	start := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	for i, score := range []float64{60, 72.5, 81} {
+		run := Run{
+			Timestamp:    start.Add(time.Duration(i) * 24 * time.Hour),
+			RulesHash:    "abc",
+			AverageScore: score,
+			Jobs: []JobRun{
+				{JobName: "api", Score: score, TotalMetrics: 10 + i, TotalCardinality: 1000, Rules: []engine.RuleResult{
+					{RuleID: "PROM-MET-01", Impact: "Important", PassedMetrics: 8 + i, TotalMetrics: 10 + i,
+						FailedMetrics: map[string][]string{"BadName": {"format_check"}}},
+				}},
+				{JobName: "worker", Score: 90},
+			},
+		}
+		if _, err := store.Record(run); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	store.Close()
+
+	// Reopening keeps the recorded runs
+	store, err = OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore() of an existing database error = %v", err)
+	}
+	defer store.Close()
+
+	points, err := store.Trend("api", time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("Trend() error = %v", err)
+	}
+	if len(points) != 3 || points[0].Score != 60 || points[2].Score != 81 {
+		t.Fatalf("Trend() = %+v, want three runs oldest first", points)
+	}
+	if !points[2].Timestamp.Equal(start.Add(48*time.Hour)) || points[2].TotalMetrics != 12 || points[2].RulesHash != "abc" {
+		t.Errorf("unexpected latest point %+v", points[2])
+	}
+	want := RulePoint{RuleID: "PROM-MET-01", Impact: "Important", PassedMetrics: 10, TotalMetrics: 12, FailedMetrics: 1}
+	if len(points[2].Rules) != 1 || points[2].Rules[0] != want {
+		t.Errorf("rules = %+v, want %+v", points[2].Rules, want)
+	}
+
+	latest, err := store.Trend("api", time.Time{}, 2)
+	if err != nil || len(latest) != 2 || latest[0].Score != 72.5 {
+		t.Errorf("Trend() with a limit = %+v, %v, want the two latest runs", latest, err)
+	}
+	since, err := store.Trend("api", start.Add(36*time.Hour), 0)
+	if err != nil || len(since) != 1 || since[0].Score != 81 {
+		t.Errorf("Trend() since a time = %+v, %v, want the last run", since, err)
+	}
+	if missing, err := store.Trend("billing", time.Time{}, 0); err != nil || len(missing) != 0 {
+		t.Errorf("Trend() of an unknown job = %+v, %v", missing, err)
+	}
+
+	jobs, err := store.Jobs(time.Time{})
+	if err != nil {
+		t.Fatalf("Jobs() error = %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].JobName != "api" || jobs[0].Runs != 3 || jobs[0].Change() != 21 || jobs[1].Change() != 0 {
+		t.Errorf("Jobs() = %+v", jobs)
+	}
+}
+
+func TestOpenStore_NewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore() error = %v", err)
+	}
+	if _, err := store.db.Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	if _, err := OpenStore(path); err == nil {
+		t.Error("expected an error opening a database with a newer schema")
+	}
+}
+
+func TestOpenStore_MigratesVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore() error = %v", err)
+	}
+	// Recreate the version 1 table, without fingerprints and sources
+	if _, err := store.db.Exec(`DROP TABLE job_scores; DROP TABLE failed_metrics;
+		CREATE TABLE job_scores (run_id INTEGER NOT NULL, job TEXT NOT NULL, score REAL NOT NULL,
+			total_metrics INTEGER NOT NULL, total_cardinality INTEGER NOT NULL, PRIMARY KEY (run_id, job));
+		PRAGMA user_version = 1`); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore() of a version 1 database error = %v", err)
+	}
+	defer store.Close()
+	recordRuns(t, store, JobRun{JobName: "api", Source: "run-1", Rules: []engine.RuleResult{failing("R1", "a")}})
+	if streaks, err := store.FailureStreaks("api", "", 5); err != nil || streaks["R1"]["a"] != 1 {
+		t.Errorf("FailureStreaks() after migrating = %v, %v", streaks, err)
+	}
+}
@@ -0,0 +1,128 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// maxRenames bounds how many renames of a job FailureStreaks follows into earlier names
+const maxRenames = 10
+
+// FailureStreaks returns for how many consecutive recorded runs of job, newest first and at
+// most runs of them, each of its metrics failed each rule: rule ID -> metric name -> runs.
+// Runs recording the data identified by source, the evaluation about to be recorded, and
+// consecutive runs of the same data are counted once, so evaluating the same analysis output
+// again does not lengthen the streaks. A job recorded as renamed continues the streaks of
+// the runs under its earlier name.
+func (s *Store) FailureStreaks(job, source string, runs int) (map[string]map[string]int, error) {
+	type failure struct{ ruleID, metricName string }
+	streaks := make(map[failure]int)
+	var alive map[failure]bool // Failures unbroken in every run counted so far
+	counted := 0
+	lastSource := source
+
+	// count extends the streaks with one run's failures
+	count := func(runSource string, failures map[failure]bool) bool {
+		if runSource != "" && runSource == lastSource {
+			return true
+		}
+		lastSource = runSource
+		if counted == 0 {
+			alive = failures
+		}
+		for f := range alive {
+			if failures[f] {
+				streaks[f]++
+			} else {
+				delete(alive, f)
+			}
+		}
+		counted++
+		return counted < runs && len(alive) > 0
+	}
+
+	// Runs before the oldest run of the current name, once a rename is followed
+	beforeTimestamp, beforeID := "", int64(0)
+	more := true
+	for renames := 0; more && job != "" && renames <= maxRenames; renames++ {
+		query := `SELECT j.run_id, r.timestamp, j.source, j.renamed_from, f.rule_id, f.metric_name
+			FROM job_scores j JOIN runs r ON r.id = j.run_id
+			LEFT JOIN failed_metrics f ON f.run_id = j.run_id AND f.job = j.job
+			WHERE j.job = ?`
+		args := []interface{}{job}
+		if beforeTimestamp != "" {
+			query += " AND (r.timestamp < ? OR (r.timestamp = ? AND r.id < ?))"
+			args = append(args, beforeTimestamp, beforeTimestamp, beforeID)
+		}
+		rows, err := s.db.Query(query+" ORDER BY r.timestamp DESC, r.id DESC", args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query failures of job %s: %w", job, err)
+		}
+
+		runID, runSource, renamedFrom := int64(-1), "", ""
+		var failures map[failure]bool
+		for more && rows.Next() {
+			var id int64
+			var timestamp, rowSource, rowRenamedFrom string
+			var ruleID, metricName *string
+			if err := rows.Scan(&id, &timestamp, &rowSource, &rowRenamedFrom, &ruleID, &metricName); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to read failures of job %s: %w", job, err)
+			}
+			if id != runID {
+				if failures != nil {
+					more = count(runSource, failures)
+				}
+				runID, runSource, renamedFrom, failures = id, rowSource, rowRenamedFrom, make(map[failure]bool)
+				beforeTimestamp, beforeID = timestamp, id
+			}
+			if ruleID != nil && metricName != nil {
+				failures[failure{*ruleID, *metricName}] = true
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read failures of job %s: %w", job, err)
+		}
+		if more && failures != nil {
+			more = count(runSource, failures)
+		}
+		// Only the oldest run of a name records the rename it continues
+		job = renamedFrom
+	}
+
+	result := make(map[string]map[string]int)
+	for f, n := range streaks {
+		if result[f.ruleID] == nil {
+			result[f.ruleID] = make(map[string]int)
+		}
+		result[f.ruleID][f.metricName] = n
+	}
+	return result, nil
+}
+
+// LatestFingerprints returns the metric fingerprints of the jobs of the latest recorded run,
+// to recognize the jobs renamed since, see DetectRenames
+func (s *Store) LatestFingerprints() (map[string]Fingerprint, error) {
+	rows, err := s.db.Query(`SELECT job, fingerprint FROM job_scores
+		WHERE fingerprint != '' AND run_id = (SELECT id FROM runs ORDER BY timestamp DESC, id DESC LIMIT 1)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job fingerprints: %w", err)
+	}
+	defer rows.Close()
+
+	fingerprints := make(map[string]Fingerprint)
+	for rows.Next() {
+		var job, data string
+		if err := rows.Scan(&job, &data); err != nil {
+			return nil, fmt.Errorf("failed to read job fingerprints: %w", err)
+		}
+		var fingerprint Fingerprint
+		if err := json.Unmarshal([]byte(data), &fingerprint); err != nil {
+			continue
+		}
+		fingerprints[job] = fingerprint
+	}
+	return fingerprints, rows.Err()
+}
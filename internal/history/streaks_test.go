@@ -0,0 +1,127 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/engine"
+)
+
+func failing(ruleID string, metrics ...string) engine.RuleResult {
+	result := engine.RuleResult{RuleID: ruleID, FailedMetrics: make(map[string][]string)}
+	for _, metric := range metrics {
+		result.FailedMetrics[metric] = []string{"validator"}
+	}
+	return result
+}
+
+// recordRuns records one run per element of jobs, a day apart
+func recordRuns(t *testing.T, store *Store, jobs ...JobRun) {
+	t.Helper()
+	start := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	for i, job := range jobs {
+		run := Run{Timestamp: start.Add(time.Duration(i) * 24 * time.Hour), Jobs: []JobRun{job}}
+		if _, err := store.Record(run); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+}
+
+func TestStore_FailureStreaks(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenStore() error = %v", err)
+	}
+	defer store.Close()
+
+	recordRuns(t, store,
+		JobRun{JobName: "api", Source: "run-0", Rules: []engine.RuleResult{failing("R1", "b")}},
+		JobRun{JobName: "api", Source: "run-1", Rules: []engine.RuleResult{failing("R1", "a", "b")}},
+		JobRun{JobName: "api", Source: "run-2", Rules: []engine.RuleResult{failing("R1", "a")}},
+		JobRun{JobName: "api", Source: "run-2", Rules: []engine.RuleResult{failing("R1", "a")}}, // Same data again
+		JobRun{JobName: "api", Source: "run-3", Rules: []engine.RuleResult{failing("R1", "a", "b"), failing("R2", "a")}},
+	)
+
+	streaks, err := store.FailureStreaks("api", "run-4", 10)
+	if err != nil {
+		t.Fatalf("FailureStreaks() error = %v", err)
+	}
+	tests := []struct {
+		rule, metric string
+		want         int
+	}{
+		{"R1", "a", 3},
+		{"R1", "b", 1}, // Passed in run-2, so the streak restarted
+		{"R2", "a", 1},
+		{"R2", "b", 0},
+	}
+	for _, tt := range tests {
+		if got := streaks[tt.rule][tt.metric]; got != tt.want {
+			t.Errorf("streak of %s/%s = %d, want %d", tt.rule, tt.metric, got, tt.want)
+		}
+	}
+
+	// The run being evaluated again is not counted a second time
+	again, err := store.FailureStreaks("api", "run-3", 10)
+	if err != nil || again["R1"]["a"] != 2 || again["R2"]["a"] != 0 {
+		t.Errorf("FailureStreaks() of recorded data = %v, %v, want run-3 left out", again, err)
+	}
+	limited, err := store.FailureStreaks("api", "run-4", 2)
+	if err != nil || limited["R1"]["a"] != 2 {
+		t.Errorf("FailureStreaks() limited to 2 runs = %v, %v", limited, err)
+	}
+	if unknown, err := store.FailureStreaks("unknown", "", 10); err != nil || len(unknown) != 0 {
+		t.Errorf("FailureStreaks() of unknown job = %v, %v", unknown, err)
+	}
+}
+
+func TestStore_FailureStreaksFollowRenames(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenStore() error = %v", err)
+	}
+	defer store.Close()
+
+	recordRuns(t, store,
+		JobRun{JobName: "api", Source: "run-0", Rules: []engine.RuleResult{failing("R1", "a")}},
+		JobRun{JobName: "api", Source: "run-1", Rules: []engine.RuleResult{failing("R1", "a")}},
+		JobRun{JobName: "api-v2", Source: "run-2", RenamedFrom: "api", Rules: []engine.RuleResult{failing("R1", "a")}},
+		JobRun{JobName: "api-v2", Source: "run-3", Rules: []engine.RuleResult{failing("R1", "a")}},
+	)
+
+	streaks, err := store.FailureStreaks("api-v2", "run-4", 10)
+	if err != nil {
+		t.Fatalf("FailureStreaks() error = %v", err)
+	}
+	if got := streaks["R1"]["a"]; got != 4 {
+		t.Errorf("streak of R1/a across the rename = %d, want 4", got)
+	}
+}
+
+func TestStore_LatestFingerprints(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenStore() error = %v", err)
+	}
+	defer store.Close()
+
+	old := MetricFingerprint([]string{"x", "y"})
+	fingerprint := MetricFingerprint([]string{"a", "b", "c"})
+	recordRuns(t, store,
+		JobRun{JobName: "legacy", Fingerprint: old},
+		JobRun{JobName: "api", Fingerprint: fingerprint},
+	)
+
+	fingerprints, err := store.LatestFingerprints()
+	if err != nil {
+		t.Fatalf("LatestFingerprints() error = %v", err)
+	}
+	if len(fingerprints) != 1 || fingerprints["api"].Similarity(fingerprint) != 1 {
+		t.Errorf("LatestFingerprints() = %v, want api of the latest run", fingerprints)
+	}
+	renames := DetectRenames(fingerprints, map[string]Fingerprint{"api-v2": fingerprint})
+	if renames["api-v2"] != "api" {
+		t.Errorf("DetectRenames() = %v, want api-v2 renamed from api", renames)
+	}
+}
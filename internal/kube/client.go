@@ -0,0 +1,218 @@
+package kube
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// In-cluster service account locations, as mounted by the kubelet
+const (
+	serviceAccountDir       = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountToken     = serviceAccountDir + "/token"
+	serviceAccountCA        = serviceAccountDir + "/ca.crt"
+	serviceAccountNamespace = serviceAccountDir + "/namespace"
+)
+
+// Client is a minimal Kubernetes API client covering the resources the tool reads and writes
+// It talks to the REST API directly so the binary does not pull in client-go, and reads the
+// kubectl config files client-go would for tokens and client certificates, see NewKubeconfigClient.
+type Client struct {
+	BaseURL   string
+	Namespace string // Namespace the process runs in, when known
+	tokenFile string
+	token     string
+	http      *http.Client
+}
+
+// StatusError is returned for non-2xx API responses
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d - kubernetes API - error: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the API server
+func IsNotFound(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+// NewClientFromEnv creates a client from KUBE_API_SERVER (with optional KUBE_TOKEN and
+// KUBE_CA_FILE), then the KUBECONFIG files, then the in-cluster service account, and then
+// ~/.kube/config, the order client-go based tools look for a cluster in
+// KUBE_API_SERVER=http://127.0.0.1:8001 works with `kubectl proxy` for local use.
+func NewClientFromEnv() (*Client, error) {
+	if server := os.Getenv("KUBE_API_SERVER"); server != "" {
+		return newClient(server, os.Getenv("KUBE_TOKEN"), "", os.Getenv("KUBE_CA_FILE"), os.Getenv("KUBE_NAMESPACE"))
+	}
+	if paths := os.Getenv("KUBECONFIG"); paths != "" {
+		return NewKubeconfigClient(paths)
+	}
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			path := filepath.Join(home, ".kube", "config")
+			if _, err := os.Stat(path); err == nil {
+				return NewKubeconfigClient(path)
+			}
+		}
+	}
+	return NewInClusterClient()
+}
+
+// NewInClusterClient creates a client using the pod's service account
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster: KUBERNETES_SERVICE_HOST/PORT are not set (set KUBE_API_SERVER to use an external API server)")
+	}
+
+	namespace := ""
+	if data, err := os.ReadFile(serviceAccountNamespace); err == nil {
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	return newClient("https://"+joinHostPort(host, port), "", serviceAccountToken, serviceAccountCA, namespace)
+}
+
+func newClient(server, token, tokenFile, caFile, namespace string) (*Client, error) {
+	var tlsConfig *tls.Config
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		tlsConfig = &tls.Config{RootCAs: pool}
+	}
+	return newClientTLS(server, token, tokenFile, namespace, tlsConfig), nil
+}
+
+// newClientTLS creates a client connecting with tlsConfig, or the default TLS settings when nil
+func newClientTLS(server, token, tokenFile, namespace string, tlsConfig *tls.Config) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &Client{
+		BaseURL:   strings.TrimSuffix(server, "/"),
+		Namespace: namespace,
+		token:     token,
+		tokenFile: tokenFile,
+		http:      &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}
+}
+
+// joinHostPort formats host:port, bracketing IPv6 addresses
+func joinHostPort(host, port string) string {
+	if strings.Contains(host, ":") {
+		return "[" + host + "]:" + port
+	}
+	return host + ":" + port
+}
+
+// bearerToken returns the configured token; token files are re-read because kubelet rotates them
+func (c *Client) bearerToken() string {
+	if c.tokenFile != "" {
+		if data, err := os.ReadFile(c.tokenFile); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	return c.token
+}
+
+// Get decodes the resource at path into out
+func (c *Client) Get(path string, out interface{}) error {
+	return c.do("GET", path, "", nil, out)
+}
+
+// Post creates a resource at path
+func (c *Client) Post(path string, body, out interface{}) error {
+	return c.do("POST", path, "application/json", body, out)
+}
+
+// Put replaces the resource at path
+func (c *Client) Put(path string, body, out interface{}) error {
+	return c.do("PUT", path, "application/json", body, out)
+}
+
+// MergePatch applies a JSON merge patch to the resource at path
+func (c *Client) MergePatch(path string, patch, out interface{}) error {
+	return c.do("PATCH", path, "application/merge-patch+json", patch, out)
+}
+
+func (c *Client) do(method, path, contentType string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("request creation failed: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token := c.bearerToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		message := string(data)
+		if json.Unmarshal(data, &status) == nil && status.Message != "" {
+			message = status.Message
+		}
+		return &StatusError{StatusCode: resp.StatusCode, Message: message}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// namespacedPath builds a collection path, cluster-wide when namespace is empty
+// prefix is "/api/v1" for core resources or "/apis/<group>/<version>" otherwise.
+func namespacedPath(prefix, namespace, resource string) string {
+	if namespace == "" {
+		return fmt.Sprintf("%s/%s", prefix, resource)
+	}
+	return fmt.Sprintf("%s/namespaces/%s/%s", prefix, namespace, resource)
+}
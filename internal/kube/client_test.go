@@ -0,0 +1,66 @@
+package kube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GetAndErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/pods":
+			w.Write([]byte(`{"items":[{"metadata":{"name":"api-0","namespace":"default"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind":"Status","message":"the server could not find the requested resource"}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("KUBE_API_SERVER", server.URL)
+	t.Setenv("KUBE_TOKEN", "test-token")
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv() error = %v", err)
+	}
+
+	var pods PodList
+	if err := client.Get(namespacedPath("/api/v1", "default", "pods"), &pods); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(pods.Items) != 1 || pods.Items[0].Metadata.Name != "api-0" {
+		t.Errorf("unexpected pods %+v", pods.Items)
+	}
+
+	err = client.Get("/apis/monitoring.coreos.com/v1/servicemonitors", &ServiceMonitorList{})
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestNewInClusterClient_OutsideCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+	if _, err := NewInClusterClient(); err == nil {
+		t.Error("expected error outside a cluster")
+	}
+}
+
+func TestNamespacedPath(t *testing.T) {
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{"", "/api/v1/pods"},
+		{"prod", "/api/v1/namespaces/prod/pods"},
+	}
+	for _, tt := range tests {
+		if got := namespacedPath("/api/v1", tt.namespace, "pods"); got != tt.want {
+			t.Errorf("namespacedPath(%q) = %q, want %q", tt.namespace, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package kube
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"instrumentation-score/internal/collectors"
+)
+
+// Deployment annotations honored by the controller
+const (
+	// AnnotationEnabled opts a Deployment in to scoring (value "true")
+	AnnotationEnabled = "instrumentation-score/enabled"
+	// AnnotationMinScore overrides the controller's --min-score for one Deployment
+	AnnotationMinScore = "instrumentation-score/min-score"
+	// AnnotationMetricsPort and AnnotationMetricsPath override the pods' prometheus.io annotations
+	AnnotationMetricsPort = "instrumentation-score/port"
+	AnnotationMetricsPath = "instrumentation-score/path"
+)
+
+// InstrumentationScore custom resource coordinates (see deploy/crd.yaml)
+const (
+	ScoreGroupVersion = "instrumentation-score.io/v1alpha1"
+	ScoreKind         = "InstrumentationScore"
+	scoreResource     = "instrumentationscores"
+)
+
+// Event reasons recorded on scored Deployments
+const (
+	ReasonEvaluated      = "InstrumentationScoreEvaluated"
+	ReasonBelowThreshold = "InstrumentationScoreBelowThreshold"
+	ReasonFailed         = "InstrumentationScoreFailed"
+)
+
+// ScoreResult is what a Scorer reports for one workload
+type ScoreResult struct {
+	Score            float64
+	TotalMetrics     int
+	TotalCardinality int64
+	Rules            []RuleStatus
+	RulesVersion     string // Version of the rules the score was calculated with
+	Confidence       string // high, medium or low; lower when pods failed to scrape or metrics were excluded
+}
+
+// Scorer scrapes the given targets of a job and scores their metrics
+type Scorer func(job string, targets []collectors.ScrapeTarget) (*ScoreResult, error)
+
+// ControllerOptions controls which Deployments are scored and how results are judged
+type ControllerOptions struct {
+	Namespaces []string // Empty means all namespaces
+	Annotation string   // Opt-in annotation (default AnnotationEnabled)
+	MinScore   float64  // Scores below this are reported as failing
+}
+
+// DeploymentScore is the outcome of scoring one Deployment
+type DeploymentScore struct {
+	Namespace string
+	Name      string
+	Job       string
+	Score     float64
+	MinScore  float64
+	Passed    bool
+	Err       error // Set when the Deployment could not be scored
+}
+
+// Controller periodically scores opted-in Deployments and publishes the results
+// as InstrumentationScore resources and events on the Deployment.
+type Controller struct {
+	client     *Client
+	scorer     Scorer
+	opts       ControllerOptions
+	crdMissing bool
+	now        func() time.Time
+}
+
+// NewController creates a controller that scores workloads with scorer
+func NewController(client *Client, scorer Scorer, opts ControllerOptions) *Controller {
+	if opts.Annotation == "" {
+		opts.Annotation = AnnotationEnabled
+	}
+	return &Controller{client: client, scorer: scorer, opts: opts, now: time.Now}
+}
+
+// Reconcile scores every opted-in Deployment once
+// Failures to score or publish a single Deployment are reported in its result or as
+// warnings; only failing to list Deployments aborts the pass.
+func (c *Controller) Reconcile() ([]DeploymentScore, []string, error) {
+	namespaces := c.opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var results []DeploymentScore
+	var warnings []string
+	podsByNamespace := make(map[string][]Pod)
+
+	for _, namespace := range namespaces {
+		var deployments DeploymentList
+		if err := c.client.Get(namespacedPath("/apis/apps/v1", namespace, "deployments"), &deployments); err != nil {
+			return nil, nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+
+		for _, deployment := range deployments.Items {
+			if deployment.Metadata.Annotations[c.opts.Annotation] != "true" {
+				continue
+			}
+
+			ns := deployment.Metadata.Namespace
+			pods, ok := podsByNamespace[ns]
+			if !ok {
+				var list PodList
+				if err := c.client.Get(namespacedPath("/api/v1", ns, "pods"), &list); err != nil {
+					return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+				}
+				pods = list.Items
+				podsByNamespace[ns] = pods
+			}
+
+			result, publishWarnings := c.reconcileDeployment(deployment, pods)
+			results = append(results, result)
+			warnings = append(warnings, publishWarnings...)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Namespace != results[j].Namespace {
+			return results[i].Namespace < results[j].Namespace
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, warnings, nil
+}
+
+// reconcileDeployment scores one Deployment and publishes the status and an event
+func (c *Controller) reconcileDeployment(deployment Deployment, pods []Pod) (DeploymentScore, []string) {
+	meta := deployment.Metadata
+	result := DeploymentScore{
+		Namespace: meta.Namespace,
+		Name:      meta.Name,
+		Job:       deploymentJobName(deployment),
+		MinScore:  c.opts.MinScore,
+	}
+
+	var warnings []string
+	if value := meta.Annotations[AnnotationMinScore]; value != "" {
+		minScore, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("deployment %s/%s: invalid %s annotation %q, using %.1f", meta.Namespace, meta.Name, AnnotationMinScore, value, c.opts.MinScore))
+		} else {
+			result.MinScore = minScore
+		}
+	}
+
+	status := InstrumentationScoreStatus{
+		MinScore:      result.MinScore,
+		LastEvaluated: c.now().UTC().Format(time.RFC3339),
+	}
+
+	var score *ScoreResult
+	targets, err := deploymentTargets(deployment, pods, result.Job)
+	if err == nil {
+		score, err = c.scorer(result.Job, targets)
+	}
+
+	eventType, reason := "Normal", ReasonEvaluated
+	if err != nil {
+		result.Err = err
+		eventType, reason = "Warning", ReasonFailed
+		status.Message = err.Error()
+	} else {
+		result.Score = score.Score
+		result.Passed = score.Score >= result.MinScore
+		status.Score = score.Score
+		status.Passed = result.Passed
+		status.TotalMetrics = score.TotalMetrics
+		status.TotalCardinality = score.TotalCardinality
+		status.Rules = score.Rules
+		status.RulesVersion = score.RulesVersion
+		status.Confidence = score.Confidence
+		status.Message = fmt.Sprintf("Instrumentation score %.1f (minimum %.1f) across %d metrics from %d pods", score.Score, result.MinScore, score.TotalMetrics, len(targets))
+		if !result.Passed {
+			eventType, reason = "Warning", ReasonBelowThreshold
+		}
+	}
+
+	if err := c.writeStatus(deployment, result.Job, status); err != nil {
+		warnings = append(warnings, fmt.Sprintf("deployment %s/%s: failed to write %s status: %v", meta.Namespace, meta.Name, ScoreKind, err))
+	}
+	if err := c.recordEvent(deployment, eventType, reason, status.Message); err != nil {
+		warnings = append(warnings, fmt.Sprintf("deployment %s/%s: failed to record event: %v", meta.Namespace, meta.Name, err))
+	}
+	return result, warnings
+}
+
+// deploymentJobName returns the job a Deployment's metrics are reported under
+func deploymentJobName(deployment Deployment) string {
+	if job := deployment.Metadata.Annotations[AnnotationJob]; job != "" {
+		return job
+	}
+	return deployment.Metadata.Name
+}
+
+// deploymentTargets builds a scrape target for every running pod selected by the Deployment
+func deploymentTargets(deployment Deployment, pods []Pod, job string) ([]collectors.ScrapeTarget, error) {
+	selector := deployment.Spec.Selector
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+		return nil, fmt.Errorf("deployment has an empty selector")
+	}
+	annotations := deployment.Metadata.Annotations
+
+	var targets []collectors.ScrapeTarget
+	for _, pod := range pods {
+		meta := pod.Metadata
+		if meta.Namespace != deployment.Metadata.Namespace || !selector.Matches(meta.Labels) {
+			continue
+		}
+		if pod.Status.Phase != "Running" || pod.Status.PodIP == "" {
+			continue
+		}
+
+		port := podMetricsPort(pod)
+		if value := annotations[AnnotationMetricsPort]; value != "" {
+			port, _ = strconv.Atoi(value)
+		}
+		if port == 0 {
+			continue
+		}
+
+		scheme := meta.Annotations[AnnotationScheme]
+		if scheme == "" {
+			scheme = "http"
+		}
+		path := annotations[AnnotationMetricsPath]
+		if path == "" {
+			path = meta.Annotations[AnnotationPath]
+		}
+		if path == "" {
+			path = "/metrics"
+		}
+
+		host := joinHostPort(pod.Status.PodIP, strconv.Itoa(port))
+		targets = append(targets, collectors.ScrapeTarget{
+			Job:      job,
+			URL:      (&url.URL{Scheme: scheme, Host: host, Path: path}).String(),
+			Instance: host,
+			Labels: map[string]string{
+				"namespace": meta.Namespace,
+				"pod":       meta.Name,
+			},
+		})
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no running pods with a metrics port (set %s or %s)", AnnotationMetricsPort, AnnotationPort)
+	}
+	for i := range targets {
+		if err := targets[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// writeStatus creates the Deployment's InstrumentationScore if needed and updates its status
+// When the CRD is not installed the status is skipped from then on and only events are recorded.
+func (c *Controller) writeStatus(deployment Deployment, job string, status InstrumentationScoreStatus) error {
+	if c.crdMissing {
+		return nil
+	}
+
+	meta := deployment.Metadata
+	collection := namespacedPath("/apis/"+ScoreGroupVersion, meta.Namespace, scoreResource)
+	path := collection + "/" + meta.Name
+
+	err := c.client.Get(path, &InstrumentationScore{})
+	if IsNotFound(err) {
+		resource := NewInstrumentationScore(meta.Name, meta.Namespace, job)
+		resource.Metadata.OwnerReferences = []OwnerReference{{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       meta.Name,
+			UID:        meta.UID,
+		}}
+		resource.Spec.TargetRef = &ObjectReference{Kind: "Deployment", Name: meta.Name, Namespace: meta.Namespace}
+		err = c.client.Post(collection, resource, nil)
+		if IsNotFound(err) {
+			c.crdMissing = true
+			return fmt.Errorf("%s CRD not installed (apply deploy/crd.yaml), recording events only", ScoreKind)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.client.MergePatch(path+"/status", map[string]interface{}{"status": status}, nil)
+}
+
+// recordEvent attaches an event to the Deployment
+func (c *Controller) recordEvent(deployment Deployment, eventType, reason, message string) error {
+	meta := deployment.Metadata
+	timestamp := c.now().UTC().Format(time.RFC3339)
+
+	event := Event{
+		APIVersion: "v1",
+		Kind:       "Event",
+		Metadata:   EventMeta{GenerateName: meta.Name + ".", Namespace: meta.Namespace},
+		InvolvedObject: ObjectReference{
+			Kind:      "Deployment",
+			Name:      meta.Name,
+			Namespace: meta.Namespace,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		FirstTimestamp: timestamp,
+		LastTimestamp:  timestamp,
+		Count:          1,
+	}
+	event.Source.Component = "instrumentation-score"
+
+	return c.client.Post(namespacedPath("/api/v1", meta.Namespace, "events"), event, nil)
+}
@@ -0,0 +1,209 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/collectors"
+)
+
+const testDeployments = `{"items":[
+  {"metadata":{"name":"api","namespace":"prod","uid":"uid-api",
+    "annotations":{"instrumentation-score/enabled":"true","instrumentation-score/port":"9090"}},
+   "spec":{"selector":{"matchLabels":{"app":"api"}}}},
+  {"metadata":{"name":"worker","namespace":"prod",
+    "annotations":{"instrumentation-score/enabled":"true","instrumentation-score/min-score":"90","instrumentation-score/job":"worker-job"}},
+   "spec":{"selector":{"matchLabels":{"app":"worker"}}}},
+  {"metadata":{"name":"idle","namespace":"prod","annotations":{"instrumentation-score/enabled":"true"}},
+   "spec":{"selector":{"matchLabels":{"app":"idle"}}}},
+  {"metadata":{"name":"ignored","namespace":"prod"},
+   "spec":{"selector":{"matchLabels":{"app":"ignored"}}}}
+]}`
+
+const testControllerPods = `{"items":[
+  {"metadata":{"name":"api-0","namespace":"prod","labels":{"app":"api"}},
+   "status":{"phase":"Running","podIP":"10.0.0.1"}},
+  {"metadata":{"name":"api-1","namespace":"prod","labels":{"app":"api"}},
+   "status":{"phase":"Running","podIP":"10.0.0.2"}},
+  {"metadata":{"name":"worker-0","namespace":"prod","labels":{"app":"worker"},"annotations":{"prometheus.io/path":"/stats"}},
+   "spec":{"containers":[{"name":"worker","ports":[{"name":"metrics","containerPort":9100}]}]},
+   "status":{"phase":"Running","podIP":"10.0.0.3"}},
+  {"metadata":{"name":"idle-0","namespace":"prod","labels":{"app":"idle"}},
+   "status":{"phase":"Running","podIP":"10.0.0.4"}}
+]}`
+
+// fakeAPIServer serves deployments and pods and records writes
+type fakeAPIServer struct {
+	mu          sync.Mutex
+	deployments string // Deployment list served, testDeployments by default
+	pods        string // Pod list served, testControllerPods by default
+	crd         bool
+	scores      map[string]bool // existing InstrumentationScore names
+	statuses    map[string]InstrumentationScoreStatus
+	created     []string
+	eventReason map[string]string
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const scorePrefix = "/apis/instrumentation-score.io/v1alpha1/namespaces/prod/instrumentationscores"
+	body, _ := io.ReadAll(r.Body)
+	path := r.URL.Path
+
+	switch {
+	case r.Method == "GET" && path == "/apis/apps/v1/namespaces/prod/deployments":
+		w.Write([]byte(f.deployments))
+	case r.Method == "GET" && path == "/api/v1/namespaces/prod/pods":
+		w.Write([]byte(f.pods))
+	case r.Method == "POST" && path == "/api/v1/namespaces/prod/events":
+		var event Event
+		json.Unmarshal(body, &event)
+		f.eventReason[event.InvolvedObject.Name] = event.Reason
+		w.Write([]byte("{}"))
+	case !f.crd && strings.HasPrefix(path, scorePrefix):
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == "GET" && strings.HasPrefix(path, scorePrefix+"/"):
+		if !f.scores[strings.TrimPrefix(path, scorePrefix+"/")] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("{}"))
+	case r.Method == "POST" && path == scorePrefix:
+		var resource InstrumentationScore
+		json.Unmarshal(body, &resource)
+		f.scores[resource.Metadata.Name] = true
+		f.created = append(f.created, resource.Metadata.Name)
+		w.Write([]byte("{}"))
+	case r.Method == "PATCH" && strings.HasSuffix(path, "/status"):
+		var patch struct {
+			Status InstrumentationScoreStatus `json:"status"`
+		}
+		json.Unmarshal(body, &patch)
+		name := strings.TrimSuffix(strings.TrimPrefix(path, scorePrefix+"/"), "/status")
+		f.statuses[name] = patch.Status
+		w.Write([]byte("{}"))
+	default:
+		http.Error(w, fmt.Sprintf("unexpected %s %s", r.Method, path), http.StatusBadRequest)
+	}
+}
+
+func newFakeAPIServer(crd bool) *fakeAPIServer {
+	return &fakeAPIServer{
+		deployments: testDeployments,
+		pods:        testControllerPods,
+		crd:         crd,
+		scores:      map[string]bool{"api": true},
+		statuses:    make(map[string]InstrumentationScoreStatus),
+		eventReason: make(map[string]string),
+	}
+}
+
+func TestControllerReconcile(t *testing.T) {
+	fake := newFakeAPIServer(true)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := newClient(server.URL, "", "", "", "")
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+
+	scored := make(map[string][]string)
+	scorer := func(job string, targets []collectors.ScrapeTarget) (*ScoreResult, error) {
+		for _, target := range targets {
+			scored[job] = append(scored[job], target.URL)
+		}
+		return &ScoreResult{Score: 80, TotalMetrics: 12, Rules: []RuleStatus{{RuleID: "PROM-MET-01", Impact: "Critical", PassedChecks: 1, TotalChecks: 1}}, RulesVersion: "3f2a9c01b7d4", Confidence: "medium"}, nil
+	}
+
+	controller := NewController(client, scorer, ControllerOptions{Namespaces: []string{"prod"}, MinScore: 75})
+	controller.now = func() time.Time { return time.Date(2025, 11, 2, 16, 0, 0, 0, time.UTC) }
+
+	results, warnings, err := controller.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 opted-in deployments, got %d", len(results))
+	}
+
+	wantURLs := map[string][]string{
+		"api":        {"http://10.0.0.1:9090/metrics", "http://10.0.0.2:9090/metrics"},
+		"worker-job": {"http://10.0.0.3:9100/stats"},
+	}
+	for job, urls := range wantURLs {
+		if strings.Join(scored[job], ",") != strings.Join(urls, ",") {
+			t.Errorf("job %s scraped %v, want %v", job, scored[job], urls)
+		}
+	}
+
+	byName := make(map[string]DeploymentScore)
+	for _, result := range results {
+		byName[result.Name] = result
+	}
+	if r := byName["api"]; !r.Passed || r.Err != nil || r.Job != "api" {
+		t.Errorf("api result = %+v, want passed", r)
+	}
+	if r := byName["worker"]; r.Passed || r.MinScore != 90 {
+		t.Errorf("worker result = %+v, want failing against min-score 90", r)
+	}
+	if r := byName["idle"]; r.Err == nil {
+		t.Errorf("idle result = %+v, want error for pods without a metrics port", r)
+	}
+
+	if strings.Join(fake.created, ",") != "worker,idle" {
+		t.Errorf("created %v, want worker and idle (api already exists)", fake.created)
+	}
+	if status := fake.statuses["api"]; status.Score != 80 || !status.Passed || status.LastEvaluated != "2025-11-02T16:00:00Z" || len(status.Rules) != 1 || status.RulesVersion != "3f2a9c01b7d4" || status.Confidence != "medium" {
+		t.Errorf("api status = %+v", status)
+	}
+	if status := fake.statuses["idle"]; status.Message == "" || status.Passed {
+		t.Errorf("idle status = %+v, want failure message", status)
+	}
+
+	wantReasons := map[string]string{"api": ReasonEvaluated, "worker": ReasonBelowThreshold, "idle": ReasonFailed}
+	for name, reason := range wantReasons {
+		if fake.eventReason[name] != reason {
+			t.Errorf("event reason for %s = %q, want %q", name, fake.eventReason[name], reason)
+		}
+	}
+}
+
+func TestControllerReconcile_CRDMissing(t *testing.T) {
+	fake := newFakeAPIServer(false)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := newClient(server.URL, "", "", "", "")
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+
+	scorer := func(job string, targets []collectors.ScrapeTarget) (*ScoreResult, error) {
+		return &ScoreResult{Score: 100}, nil
+	}
+	controller := NewController(client, scorer, ControllerOptions{Namespaces: []string{"prod"}})
+
+	_, warnings, err := controller.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "CRD not installed") {
+		t.Errorf("expected a single CRD warning, got %v", warnings)
+	}
+	if len(fake.eventReason) != 3 {
+		t.Errorf("expected events for all 3 deployments, got %v", fake.eventReason)
+	}
+}
@@ -0,0 +1,301 @@
+package kube
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"instrumentation-score/internal/collectors"
+)
+
+// Pod annotations honored by pod discovery (the common prometheus.io convention)
+const (
+	AnnotationScrape = "prometheus.io/scrape"
+	AnnotationPort   = "prometheus.io/port"
+	AnnotationPath   = "prometheus.io/path"
+	AnnotationScheme = "prometheus.io/scheme"
+	// AnnotationJob overrides the job name a pod's metrics are reported under
+	AnnotationJob = "instrumentation-score/job"
+)
+
+// Discovery sources
+const (
+	SourcePods            = "pods"
+	SourceServiceMonitors = "servicemonitors"
+)
+
+// defaultMetricsPortNames are container port names scraped when no port annotation is set
+var defaultMetricsPortNames = []string{"metrics", "http-metrics"}
+
+// DiscoveryOptions controls which targets are discovered
+type DiscoveryOptions struct {
+	Namespaces []string // Empty means all namespaces
+	Sources    []string // SourcePods and/or SourceServiceMonitors
+	JobLabel   string   // Pod label used as job name (default app.kubernetes.io/name, then app)
+}
+
+// DiscoverTargets lists scrape targets from annotated pods and ServiceMonitors
+// Targets found by more than one source are returned once. Non-fatal problems
+// (e.g. the ServiceMonitor CRD not being installed) are returned as warnings.
+func DiscoverTargets(client *Client, opts DiscoveryOptions) ([]collectors.ScrapeTarget, []string, error) {
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	sources := opts.Sources
+	if len(sources) == 0 {
+		sources = []string{SourcePods, SourceServiceMonitors}
+	}
+
+	var targets []collectors.ScrapeTarget
+	var warnings []string
+	for _, source := range sources {
+		for _, namespace := range namespaces {
+			var found []collectors.ScrapeTarget
+			var sourceWarnings []string
+			var err error
+			switch source {
+			case SourcePods:
+				found, sourceWarnings, err = discoverPods(client, namespace, opts.JobLabel)
+			case SourceServiceMonitors:
+				found, sourceWarnings, err = discoverServiceMonitors(client, namespace)
+			default:
+				return nil, nil, fmt.Errorf("unknown discovery source %q (available: %s, %s)", source, SourcePods, SourceServiceMonitors)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			targets = append(targets, found...)
+			warnings = append(warnings, sourceWarnings...)
+		}
+	}
+
+	targets = dedupeTargets(targets)
+	for i := range targets {
+		if err := targets[i].Validate(); err != nil {
+			return nil, nil, fmt.Errorf("discovered invalid target: %w", err)
+		}
+	}
+	return targets, warnings, nil
+}
+
+// discoverPods returns a target for every running pod annotated with prometheus.io/scrape=true
+func discoverPods(client *Client, namespace, jobLabel string) ([]collectors.ScrapeTarget, []string, error) {
+	var pods PodList
+	if err := client.Get(namespacedPath("/api/v1", namespace, "pods"), &pods); err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var targets []collectors.ScrapeTarget
+	var warnings []string
+	for _, pod := range pods.Items {
+		meta := pod.Metadata
+		if meta.Annotations[AnnotationScrape] != "true" || pod.Status.Phase != "Running" || pod.Status.PodIP == "" {
+			continue
+		}
+
+		port := podMetricsPort(pod)
+		if port == 0 {
+			warnings = append(warnings, fmt.Sprintf("pod %s/%s: no %s annotation or metrics container port, skipping", meta.Namespace, meta.Name, AnnotationPort))
+			continue
+		}
+
+		scheme := meta.Annotations[AnnotationScheme]
+		if scheme == "" {
+			scheme = "http"
+		}
+		path := meta.Annotations[AnnotationPath]
+		if path == "" {
+			path = "/metrics"
+		}
+
+		host := joinHostPort(pod.Status.PodIP, strconv.Itoa(port))
+		targets = append(targets, collectors.ScrapeTarget{
+			Job:      podJobName(pod, jobLabel),
+			URL:      (&url.URL{Scheme: scheme, Host: host, Path: path}).String(),
+			Instance: host,
+			Labels: map[string]string{
+				"namespace": meta.Namespace,
+				"pod":       meta.Name,
+			},
+		})
+	}
+	return targets, warnings, nil
+}
+
+// podMetricsPort returns the annotated port, or the first container port with a metrics name
+func podMetricsPort(pod Pod) int {
+	if value := pod.Metadata.Annotations[AnnotationPort]; value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return 0
+		}
+		return port
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if contains(defaultMetricsPortNames, port.Name) {
+				return port.ContainerPort
+			}
+		}
+	}
+	return 0
+}
+
+// podJobName picks the job a pod reports under: explicit annotation, job label, app labels, pod name
+func podJobName(pod Pod, jobLabel string) string {
+	meta := pod.Metadata
+	if job := meta.Annotations[AnnotationJob]; job != "" {
+		return job
+	}
+	candidates := []string{"app.kubernetes.io/name", "app"}
+	if jobLabel != "" {
+		candidates = append([]string{jobLabel}, candidates...)
+	}
+	for _, label := range candidates {
+		if value := meta.Labels[label]; value != "" {
+			return value
+		}
+	}
+	return meta.Name
+}
+
+// discoverServiceMonitors resolves ServiceMonitors to the endpoint addresses of their services
+func discoverServiceMonitors(client *Client, namespace string) ([]collectors.ScrapeTarget, []string, error) {
+	var monitors ServiceMonitorList
+	err := client.Get(namespacedPath("/apis/monitoring.coreos.com/v1", namespace, "servicemonitors"), &monitors)
+	if IsNotFound(err) {
+		return nil, []string{"ServiceMonitor CRD not found, skipping ServiceMonitor discovery"}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list servicemonitors: %w", err)
+	}
+
+	var targets []collectors.ScrapeTarget
+	var warnings []string
+	servicesByNamespace := make(map[string][]Service)
+
+	for _, monitor := range monitors.Items {
+		for _, ns := range monitorNamespaces(monitor) {
+			services, ok := servicesByNamespace[ns]
+			if !ok {
+				var list ServiceList
+				if err := client.Get(namespacedPath("/api/v1", ns, "services"), &list); err != nil {
+					return nil, nil, fmt.Errorf("failed to list services: %w", err)
+				}
+				services = list.Items
+				servicesByNamespace[ns] = services
+			}
+
+			for _, service := range services {
+				if !monitor.Spec.Selector.Matches(service.Metadata.Labels) {
+					continue
+				}
+				found, err := serviceTargets(client, monitor, service)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("servicemonitor %s/%s: %v", monitor.Metadata.Namespace, monitor.Metadata.Name, err))
+					continue
+				}
+				targets = append(targets, found...)
+			}
+		}
+	}
+	return targets, warnings, nil
+}
+
+// monitorNamespaces returns the namespaces a ServiceMonitor selects services from
+// "" means all namespaces.
+func monitorNamespaces(monitor ServiceMonitor) []string {
+	selector := monitor.Spec.NamespaceSelector
+	switch {
+	case selector.Any:
+		return []string{""}
+	case len(selector.MatchNames) > 0:
+		return selector.MatchNames
+	default:
+		return []string{monitor.Metadata.Namespace}
+	}
+}
+
+// serviceTargets builds targets for each endpoint address of a service matched by a monitor
+func serviceTargets(client *Client, monitor ServiceMonitor, service Service) ([]collectors.ScrapeTarget, error) {
+	var endpoints Endpoints
+	path := fmt.Sprintf("/api/v1/namespaces/%s/endpoints/%s", service.Metadata.Namespace, service.Metadata.Name)
+	if err := client.Get(path, &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to get endpoints for service %s: %w", service.Metadata.Name, err)
+	}
+
+	job := service.Metadata.Name
+	if monitor.Spec.JobLabel != "" {
+		if value := service.Metadata.Labels[monitor.Spec.JobLabel]; value != "" {
+			job = value
+		}
+	}
+
+	var targets []collectors.ScrapeTarget
+	for _, endpoint := range monitor.Spec.Endpoints {
+		scheme := endpoint.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+		metricsPath := endpoint.Path
+		if metricsPath == "" {
+			metricsPath = "/metrics"
+		}
+
+		for _, subset := range endpoints.Subsets {
+			for _, port := range subset.Ports {
+				if endpoint.Port != "" && port.Name != endpoint.Port {
+					continue
+				}
+				for _, address := range subset.Addresses {
+					host := joinHostPort(address.IP, strconv.Itoa(port.Port))
+					target := collectors.ScrapeTarget{
+						Job:             job,
+						URL:             (&url.URL{Scheme: scheme, Host: host, Path: metricsPath}).String(),
+						Instance:        host,
+						BearerTokenFile: endpoint.BearerTokenFile,
+						Labels: map[string]string{
+							"namespace": service.Metadata.Namespace,
+							"service":   service.Metadata.Name,
+						},
+					}
+					if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+						target.Labels["pod"] = address.TargetRef.Name
+					}
+					if endpoint.TLSConfig != nil {
+						target.TLS = &collectors.TLSConfig{
+							InsecureSkipVerify: endpoint.TLSConfig.InsecureSkipVerify,
+							ServerName:         endpoint.TLSConfig.ServerName,
+							CAFile:             endpoint.TLSConfig.CAFile,
+						}
+					}
+					targets = append(targets, target)
+				}
+			}
+		}
+	}
+	return targets, nil
+}
+
+// dedupeTargets drops targets whose URL was already discovered, keeping the first
+func dedupeTargets(targets []collectors.ScrapeTarget) []collectors.ScrapeTarget {
+	seen := make(map[string]bool, len(targets))
+	unique := make([]collectors.ScrapeTarget, 0, len(targets))
+	for _, target := range targets {
+		if seen[target.URL] {
+			continue
+		}
+		seen[target.URL] = true
+		unique = append(unique, target)
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		if unique[i].Job != unique[j].Job {
+			return unique[i].Job < unique[j].Job
+		}
+		return strings.Compare(unique[i].URL, unique[j].URL) < 0
+	})
+	return unique
+}
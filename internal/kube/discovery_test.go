@@ -0,0 +1,126 @@
+package kube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testPods = `{"items":[
+  {"metadata":{"name":"api-0","namespace":"prod","labels":{"app.kubernetes.io/name":"api"},
+    "annotations":{"prometheus.io/scrape":"true","prometheus.io/port":"9090","prometheus.io/path":"/custom"}},
+   "status":{"phase":"Running","podIP":"10.0.0.1"}},
+  {"metadata":{"name":"worker-0","namespace":"prod","labels":{"app":"worker"},
+    "annotations":{"prometheus.io/scrape":"true"}},
+   "spec":{"containers":[{"name":"worker","ports":[{"name":"http","containerPort":8080},{"name":"metrics","containerPort":9100}]}]},
+   "status":{"phase":"Running","podIP":"10.0.0.2"}},
+  {"metadata":{"name":"pending-0","namespace":"prod","annotations":{"prometheus.io/scrape":"true","prometheus.io/port":"9090"}},
+   "status":{"phase":"Pending"}},
+  {"metadata":{"name":"noport-0","namespace":"prod","annotations":{"prometheus.io/scrape":"true"}},
+   "status":{"phase":"Running","podIP":"10.0.0.3"}},
+  {"metadata":{"name":"unannotated-0","namespace":"prod"},"status":{"phase":"Running","podIP":"10.0.0.4"}}
+]}`
+
+const testServiceMonitors = `{"items":[
+  {"metadata":{"name":"checkout","namespace":"prod"},
+   "spec":{"jobLabel":"app.kubernetes.io/component","selector":{"matchLabels":{"team":"payments"}},
+     "endpoints":[{"port":"web","path":"/metrics"}]}}
+]}`
+
+const testServices = `{"items":[
+  {"metadata":{"name":"checkout-svc","namespace":"prod","labels":{"team":"payments","app.kubernetes.io/component":"checkout"}}},
+  {"metadata":{"name":"other-svc","namespace":"prod","labels":{"team":"search"}}}
+]}`
+
+const testEndpoints = `{"metadata":{"name":"checkout-svc"},"subsets":[
+  {"addresses":[{"ip":"10.0.1.1","targetRef":{"kind":"Pod","name":"checkout-0"}},{"ip":"10.0.0.1"}],
+   "ports":[{"name":"web","port":9090},{"name":"grpc","port":9000}]}
+]}`
+
+func newTestAPIServer(t *testing.T, serviceMonitorsInstalled bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/prod/pods":
+			w.Write([]byte(testPods))
+		case "/apis/monitoring.coreos.com/v1/namespaces/prod/servicemonitors":
+			if !serviceMonitorsInstalled {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(testServiceMonitors))
+		case "/api/v1/namespaces/prod/services":
+			w.Write([]byte(testServices))
+		case "/api/v1/namespaces/prod/endpoints/checkout-svc":
+			w.Write([]byte(testEndpoints))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestDiscoverTargets(t *testing.T) {
+	server := newTestAPIServer(t, true)
+	defer server.Close()
+
+	client, err := newClient(server.URL, "", "", "", "")
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+
+	targets, warnings, err := DiscoverTargets(client, DiscoveryOptions{Namespaces: []string{"prod"}})
+	if err != nil {
+		t.Fatalf("DiscoverTargets() error = %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected one warning for the pod without a port, got %v", warnings)
+	}
+
+	want := []struct {
+		job string
+		url string
+	}{
+		{"api", "http://10.0.0.1:9090/custom"},
+		{"checkout", "http://10.0.0.1:9090/metrics"},
+		{"checkout", "http://10.0.1.1:9090/metrics"},
+		{"worker", "http://10.0.0.2:9100/metrics"},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("expected %d targets, got %d: %+v", len(want), len(targets), targets)
+	}
+	for i, w := range want {
+		if targets[i].Job != w.job || targets[i].URL != w.url {
+			t.Errorf("target %d = %s %s, want %s %s", i, targets[i].Job, targets[i].URL, w.job, w.url)
+		}
+		if targets[i].Timeout == 0 {
+			t.Errorf("target %d: expected default timeout to be applied", i)
+		}
+	}
+	if targets[2].Labels["pod"] != "checkout-0" || targets[2].Labels["service"] != "checkout-svc" {
+		t.Errorf("expected pod and service labels on endpoint target, got %v", targets[2].Labels)
+	}
+}
+
+func TestDiscoverTargets_ServiceMonitorCRDMissing(t *testing.T) {
+	server := newTestAPIServer(t, false)
+	defer server.Close()
+
+	client, _ := newClient(server.URL, "", "", "", "")
+	targets, warnings, err := DiscoverTargets(client, DiscoveryOptions{
+		Namespaces: []string{"prod"},
+		Sources:    []string{SourceServiceMonitors},
+	})
+	if err != nil {
+		t.Fatalf("DiscoverTargets() error = %v", err)
+	}
+	if len(targets) != 0 || len(warnings) != 1 {
+		t.Errorf("expected no targets and one warning, got %d targets, warnings %v", len(targets), warnings)
+	}
+}
+
+func TestDiscoverTargets_UnknownSource(t *testing.T) {
+	client, _ := newClient("http://127.0.0.1:0", "", "", "", "")
+	if _, _, err := DiscoverTargets(client, DiscoveryOptions{Sources: []string{"nodes"}}); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubeconfigFile is the part of a kubectl config file the client supports: servers, CAs,
+// bearer tokens and client certificates. Exec plugins and auth providers are not supported.
+type kubeconfigFile struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string            `yaml:"name"`
+		Cluster kubeconfigCluster `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string            `yaml:"name"`
+		Context kubeconfigContext `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string         `yaml:"name"`
+		User kubeconfigUser `yaml:"user"`
+	} `yaml:"users"`
+}
+
+type kubeconfigCluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthority     string `yaml:"certificate-authority"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+	TLSServerName            string `yaml:"tls-server-name"`
+	dir                      string // Directory of the file defining it, for relative paths
+}
+
+type kubeconfigContext struct {
+	Cluster   string `yaml:"cluster"`
+	User      string `yaml:"user"`
+	Namespace string `yaml:"namespace"`
+}
+
+type kubeconfigUser struct {
+	Token                 string    `yaml:"token"`
+	TokenFile             string    `yaml:"tokenFile"`
+	ClientCertificate     string    `yaml:"client-certificate"`
+	ClientCertificateData string    `yaml:"client-certificate-data"`
+	ClientKey             string    `yaml:"client-key"`
+	ClientKeyData         string    `yaml:"client-key-data"`
+	Exec                  yaml.Node `yaml:"exec"`
+	AuthProvider          yaml.Node `yaml:"auth-provider"`
+	dir                   string
+}
+
+// kubeconfig is the merge of the files of a KUBECONFIG list: like kubectl, the first file
+// setting the current context, and the first file defining a name, win
+type kubeconfig struct {
+	currentContext string
+	clusters       map[string]kubeconfigCluster
+	contexts       map[string]kubeconfigContext
+	users          map[string]kubeconfigUser
+}
+
+// NewKubeconfigClient creates a client for the current context of the kubectl config files
+// in paths, a KUBECONFIG list
+func NewKubeconfigClient(paths string) (*Client, error) {
+	config := kubeconfig{
+		clusters: make(map[string]kubeconfigCluster),
+		contexts: make(map[string]kubeconfigContext),
+		users:    make(map[string]kubeconfigUser),
+	}
+	loaded := 0
+	for _, path := range filepath.SplitList(paths) {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+		}
+		var file kubeconfigFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+		}
+		config.add(file, filepath.Dir(path))
+		loaded++
+	}
+	if loaded == 0 {
+		return nil, fmt.Errorf("no kubeconfig found in %s", paths)
+	}
+	return config.client()
+}
+
+// add merges file, found in dir, into the config
+func (c *kubeconfig) add(file kubeconfigFile, dir string) {
+	if c.currentContext == "" {
+		c.currentContext = file.CurrentContext
+	}
+	for _, cluster := range file.Clusters {
+		if _, ok := c.clusters[cluster.Name]; !ok {
+			cluster.Cluster.dir = dir
+			c.clusters[cluster.Name] = cluster.Cluster
+		}
+	}
+	for _, context := range file.Contexts {
+		if _, ok := c.contexts[context.Name]; !ok {
+			c.contexts[context.Name] = context.Context
+		}
+	}
+	for _, user := range file.Users {
+		if _, ok := c.users[user.Name]; !ok {
+			user.User.dir = dir
+			c.users[user.Name] = user.User
+		}
+	}
+}
+
+// client creates a client for the current context
+func (c *kubeconfig) client() (*Client, error) {
+	if c.currentContext == "" {
+		return nil, fmt.Errorf("kubeconfig has no current-context")
+	}
+	context, ok := c.contexts[c.currentContext]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig context %s is not defined", c.currentContext)
+	}
+	cluster, ok := c.clusters[context.Cluster]
+	if !ok || cluster.Server == "" {
+		return nil, fmt.Errorf("kubeconfig cluster %s of context %s is not defined", context.Cluster, c.currentContext)
+	}
+	user := c.users[context.User]
+	if !user.Exec.IsZero() || !user.AuthProvider.IsZero() {
+		return nil, fmt.Errorf("kubeconfig user %s authenticates with an exec plugin or auth provider, which is not supported: use a token or client certificate, or KUBE_API_SERVER with kubectl proxy", context.User)
+	}
+
+	tlsConfig := &tls.Config{ServerName: cluster.TLSServerName, InsecureSkipVerify: cluster.InsecureSkipTLSVerify}
+	caPEM, err := kubeconfigData(cluster.CertificateAuthorityData, cluster.CertificateAuthority, cluster.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA of cluster %s: %w", context.Cluster, err)
+	}
+	if caPEM != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in the CA of cluster %s", context.Cluster)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certPEM, err := kubeconfigData(user.ClientCertificateData, user.ClientCertificate, user.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client certificate of user %s: %w", context.User, err)
+	}
+	keyPEM, err := kubeconfigData(user.ClientKeyData, user.ClientKey, user.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client key of user %s: %w", context.User, err)
+	}
+	if certPEM != nil || keyPEM != nil {
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate of user %s: %w", context.User, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	tokenFile := ""
+	if user.TokenFile != "" {
+		tokenFile = resolveKubeconfigPath(user.TokenFile, user.dir)
+	}
+	return newClientTLS(cluster.Server, user.Token, tokenFile, context.Namespace, tlsConfig), nil
+}
+
+// kubeconfigData returns base64 inline data, or the content of the file at path, or nil for neither
+func kubeconfigData(data, path, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	}
+	if path != "" {
+		return os.ReadFile(resolveKubeconfigPath(path, dir))
+	}
+	return nil, nil
+}
+
+// resolveKubeconfigPath resolves a path relative to the directory of the kubeconfig defining it
+func resolveKubeconfigPath(path, dir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
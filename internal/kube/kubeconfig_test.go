@@ -0,0 +1,104 @@
+package kube
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewClientFromEnv_Kubeconfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer file-token" {
+			t.Errorf("expected the token of the token file, got %q", got)
+		}
+		w.Write([]byte(`{"items":[{"metadata":{"name":"api-0","namespace":"prod"}}]}`))
+	}))
+	defer server.Close()
+
+	caData := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("file-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The context and user come from the second file of the list
+	first := filepath.Join(dir, "config")
+	second := filepath.Join(dir, "users")
+	writeFile(t, first, fmt.Sprintf(`current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+`, server.URL, caData))
+	writeFile(t, second, `current-context: other
+contexts:
+- name: prod
+  context: {cluster: prod, user: admin, namespace: prod}
+users:
+- name: admin
+  user: {tokenFile: token}
+`)
+
+	t.Setenv("KUBE_API_SERVER", "")
+	t.Setenv("KUBECONFIG", strings.Join([]string{first, filepath.Join(dir, "missing"), second}, string(filepath.ListSeparator)))
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv() error = %v", err)
+	}
+	if client.Namespace != "prod" {
+		t.Errorf("Namespace = %q, want the context's prod", client.Namespace)
+	}
+	var pods PodList
+	if err := client.Get(namespacedPath("/api/v1", client.Namespace, "pods"), &pods); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(pods.Items) != 1 {
+		t.Errorf("unexpected pods %+v", pods.Items)
+	}
+}
+
+func TestNewKubeconfigClient_Errors(t *testing.T) {
+	dir := t.TempDir()
+	exec := filepath.Join(dir, "exec")
+	writeFile(t, exec, `current-context: eks
+clusters:
+- name: eks
+  cluster: {server: "https://example.com"}
+contexts:
+- name: eks
+  context: {cluster: eks, user: aws}
+users:
+- name: aws
+  user:
+    exec: {command: aws}
+`)
+	noContext := filepath.Join(dir, "no-context")
+	writeFile(t, noContext, "clusters: []\n")
+
+	tests := []struct {
+		paths string
+		want  string
+	}{
+		{exec, "exec plugin"},
+		{noContext, "no current-context"},
+		{filepath.Join(dir, "missing"), "no kubeconfig found"},
+	}
+	for _, tt := range tests {
+		if _, err := NewKubeconfigClient(tt.paths); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewKubeconfigClient(%s) error = %v, want %q", filepath.Base(tt.paths), err, tt.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,165 @@
+package kube
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// microTimeFormat is the layout of Lease times
+const microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// LeaderElector holds a Lease so only one of several replicas does the work
+// Like client-go, expiry is judged by when this replica last saw the lease change rather
+// than by the holder's renew timestamp, so clock skew between nodes does not matter.
+type LeaderElector struct {
+	client        *Client
+	namespace     string
+	name          string
+	identity      string
+	leaseDuration time.Duration
+
+	mu            sync.Mutex
+	leader        bool
+	observedRenew string    // RenewTime of the lease as last read
+	observedAt    time.Time // When observedRenew was first seen
+}
+
+// NewLeaderElector returns an elector for the Lease name in namespace, held as identity
+func NewLeaderElector(client *Client, namespace, name, identity string, leaseDuration time.Duration) *LeaderElector {
+	return &LeaderElector{
+		client:        client,
+		namespace:     namespace,
+		name:          name,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+	}
+}
+
+// IsLeader reports whether the last acquire or renew succeeded
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Run tries to acquire or renew the lease every third of the lease duration until stop
+// is closed, then releases it so another replica can take over without waiting.
+func (e *LeaderElector) Run(stop <-chan struct{}, onChange func(leader bool)) {
+	ticker := time.NewTicker(e.leaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		wasLeader := e.IsLeader()
+		leader, err := e.TryAcquireOrRenew()
+		if err != nil {
+			fmt.Printf("WARNING: leader election: %v\n", err)
+		}
+		if leader != wasLeader && onChange != nil {
+			onChange(leader)
+		}
+
+		select {
+		case <-stop:
+			if err := e.Release(); err != nil {
+				fmt.Printf("WARNING: releasing leader lease: %v\n", err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// TryAcquireOrRenew creates, renews or takes over the lease and reports whether this
+// replica holds it. Conflicting writes by other replicas make it lose, not fail.
+func (e *LeaderElector) TryAcquireOrRenew() (bool, error) {
+	leader, err := e.tryAcquireOrRenew(time.Now())
+	e.mu.Lock()
+	e.leader = leader
+	e.mu.Unlock()
+	return leader, err
+}
+
+func (e *LeaderElector) tryAcquireOrRenew(now time.Time) (bool, error) {
+	path := namespacedPath("/apis/coordination.k8s.io/v1", e.namespace, "leases")
+	nowString := now.UTC().Format(microTimeFormat)
+
+	var lease Lease
+	err := e.client.Get(path+"/"+e.name, &lease)
+	if IsNotFound(err) {
+		lease = Lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   ObjectMeta{Name: e.name, Namespace: e.namespace},
+			Spec: LeaseSpec{
+				HolderIdentity:       e.identity,
+				LeaseDurationSeconds: int(e.leaseDuration / time.Second),
+				AcquireTime:          nowString,
+				RenewTime:            nowString,
+			},
+		}
+		return e.write(e.client.Post(path, lease, nil))
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get lease %s/%s: %w", e.namespace, e.name, err)
+	}
+
+	e.mu.Lock()
+	if lease.Spec.RenewTime != e.observedRenew || e.observedAt.IsZero() {
+		e.observedRenew, e.observedAt = lease.Spec.RenewTime, now
+	}
+	observedAt := e.observedAt
+	e.mu.Unlock()
+
+	duration := time.Duration(lease.Spec.LeaseDurationSeconds) * time.Second
+	held := lease.Spec.HolderIdentity != "" && lease.Spec.HolderIdentity != e.identity
+	if held && now.Before(observedAt.Add(duration)) {
+		return false, nil
+	}
+
+	if lease.Spec.HolderIdentity != e.identity {
+		lease.Spec.HolderIdentity = e.identity
+		lease.Spec.AcquireTime = nowString
+		lease.Spec.LeaseTransitions++
+	}
+	lease.Spec.LeaseDurationSeconds = int(e.leaseDuration / time.Second)
+	lease.Spec.RenewTime = nowString
+	// The resourceVersion read above makes the API server reject the write if another
+	// replica updated the lease in between
+	return e.write(e.client.Put(path+"/"+e.name, lease, nil))
+}
+
+// write turns the result of writing the lease into leadership
+func (e *LeaderElector) write(err error) (bool, error) {
+	if statusErr, ok := err.(*StatusError); ok && statusErr.StatusCode == http.StatusConflict {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to write lease %s/%s: %w", e.namespace, e.name, err)
+	}
+	return true, nil
+}
+
+// Release gives up the lease if this replica holds it
+func (e *LeaderElector) Release() error {
+	if !e.IsLeader() {
+		return nil
+	}
+	e.mu.Lock()
+	e.leader = false
+	e.mu.Unlock()
+
+	path := namespacedPath("/apis/coordination.k8s.io/v1", e.namespace, "leases") + "/" + e.name
+	var lease Lease
+	if err := e.client.Get(path, &lease); err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity != e.identity {
+		return nil
+	}
+	lease.Spec.HolderIdentity = ""
+	lease.Spec.LeaseDurationSeconds = 1
+	lease.Spec.RenewTime = time.Now().UTC().Format(microTimeFormat)
+	_, err := e.write(e.client.Put(path, lease, nil))
+	return err
+}
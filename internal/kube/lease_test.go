@@ -0,0 +1,106 @@
+package kube
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLeaseServer stores one Lease, rejecting writes with a stale resourceVersion
+type fakeLeaseServer struct {
+	mu    sync.Mutex
+	lease *Lease
+}
+
+func (f *fakeLeaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const path = "/apis/coordination.k8s.io/v1/namespaces/ops/leases"
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == "GET" && r.URL.Path == path+"/controller":
+		if f.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(f.lease)
+	case r.Method == "POST" && r.URL.Path == path:
+		if f.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.store(body)
+	case r.Method == "PUT" && r.URL.Path == path+"/controller":
+		var lease Lease
+		json.Unmarshal(body, &lease)
+		if lease.Metadata.ResourceVersion != f.lease.Metadata.ResourceVersion {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.store(body)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (f *fakeLeaseServer) store(body []byte) {
+	var lease Lease
+	json.Unmarshal(body, &lease)
+	version := 1
+	if f.lease != nil {
+		version, _ = strconv.Atoi(f.lease.Metadata.ResourceVersion)
+		version++
+	}
+	lease.Metadata.ResourceVersion = strconv.Itoa(version)
+	f.lease = &lease
+}
+
+func TestLeaderElector(t *testing.T) {
+	fake := &fakeLeaseServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	client, err := newClient(server.URL, "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewLeaderElector(client, "ops", "controller", "pod-a", 15*time.Second)
+	b := NewLeaderElector(client, "ops", "controller", "pod-b", 15*time.Second)
+	now := time.Now()
+
+	if leader, err := a.tryAcquireOrRenew(now); !leader || err != nil {
+		t.Fatalf("a acquire = %v, %v; want the new lease", leader, err)
+	}
+	if leader, err := b.tryAcquireOrRenew(now); leader || err != nil {
+		t.Fatalf("b acquire = %v, %v; want standby", leader, err)
+	}
+	if leader, _ := a.tryAcquireOrRenew(now.Add(5 * time.Second)); !leader {
+		t.Error("a should renew its lease")
+	}
+
+	// b sees the renewal and waits a full lease duration from then
+	if leader, _ := b.tryAcquireOrRenew(now.Add(10 * time.Second)); leader {
+		t.Error("b took over a lease renewed 5s ago")
+	}
+	if leader, _ := b.tryAcquireOrRenew(now.Add(26 * time.Second)); !leader {
+		t.Error("b should take over the lease once a stopped renewing")
+	}
+	if fake.lease.Spec.HolderIdentity != "pod-b" || fake.lease.Spec.LeaseTransitions != 1 {
+		t.Errorf("lease = %+v, want held by pod-b after one transition", fake.lease.Spec)
+	}
+
+	// A release hands the lease over without waiting for it to expire
+	b.leader = true
+	if err := b.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if leader, _ := a.tryAcquireOrRenew(now.Add(27 * time.Second)); !leader {
+		t.Error("a should acquire a released lease immediately")
+	}
+}
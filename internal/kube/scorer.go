@@ -0,0 +1,90 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"instrumentation-score/internal/collectors"
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/loaders"
+	"instrumentation-score/pkg/score"
+)
+
+// ScrapeScorer returns a scorer that scrapes a job's targets into a temporary per-job file
+// and scores it with the current rules, like evaluate scores the files analyze writes
+func ScrapeScorer(rules *engine.ReloadingEngine) Scorer {
+	return func(job string, targets []collectors.ScrapeTarget) (*ScoreResult, error) {
+		ruleEngine, rulesVersion := rules.Current()
+
+		dir, err := os.MkdirTemp("", "instrumentation-score-controller-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		writer := collectors.NewJobFileWriter(dir, collectors.DefaultMaxOpenJobFiles)
+		written, scrapeErrors, err := collectors.NewScraper(targets).ScrapeToWriter(context.Background(), writer)
+		closeErr := writer.Close()
+		if err != nil {
+			return nil, err
+		}
+		if closeErr != nil {
+			return nil, closeErr
+		}
+		if written == 0 {
+			if len(scrapeErrors) > 0 {
+				return nil, fmt.Errorf("no metrics scraped: %s", scrapeErrors[0].Error)
+			}
+			return nil, fmt.Errorf("no metrics scraped")
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+		if err != nil || len(files) != 1 {
+			return nil, fmt.Errorf("expected one job file for %s, found %d", job, len(files))
+		}
+		metrics, warnings, err := loaders.LoadJobMetricReportWithWarnings(files[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(metrics) == 0 {
+			return nil, fmt.Errorf("no metrics found for job %s", job)
+		}
+
+		result, err := score.EvaluateJob(context.Background(), ruleEngine, metrics[0].Job, metrics, score.Options{})
+		if err != nil {
+			return nil, err
+		}
+
+		var ruleStatuses []RuleStatus
+		for _, rule := range result.RuleResults {
+			ruleStatuses = append(ruleStatuses, RuleStatus{
+				RuleID:       rule.RuleID,
+				Impact:       rule.Impact,
+				PassedChecks: rule.PassedChecks,
+				TotalChecks:  rule.TotalChecks,
+			})
+		}
+		return &ScoreResult{
+			Score:            result.Score,
+			TotalMetrics:     result.Metrics,
+			TotalCardinality: result.TotalCardinality,
+			Rules:            ruleStatuses,
+			RulesVersion:     rulesVersion,
+			Confidence:       scrapeConfidence(result, loaders.SkippedRecords(warnings), len(scrapeErrors), len(targets)),
+		}, nil
+	}
+}
+
+// scrapeConfidence rates a scraped score by the excluded and skipped metrics of the job,
+// with pods that failed to scrape missing from it like metrics that were not collected
+func scrapeConfidence(result score.JobScore, skipped, failedTargets, targets int) string {
+	excluded := result.Metrics - len(result.Evaluated)
+	metricsMissing := float64(excluded+skipped) / float64(result.Metrics+skipped)
+	targetsMissing := float64(failedTargets) / float64(targets)
+	missing := 1 - (1-metricsMissing)*(1-targetsMissing)
+	// Rounded, so a fraction on a threshold is not rated by floating point error
+	return score.Confidence(math.Round(missing*10000) / 10000)
+}
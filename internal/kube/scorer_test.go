@@ -0,0 +1,79 @@
+package kube
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"instrumentation-score/internal/engine"
+)
+
+const testScorerRules = `
+rules:
+- rule_id: "TEST-MET-01"
+  description: "Series per metric"
+  impact: "Critical"
+  validators:
+    - name: "cardinality_check"
+      type: "cardinality"
+      data_source: "cardinality"
+      conditions:
+        - field: "count"
+          operator: "lt"
+          value: 1000
+      threshold:
+        pass_percentage: 90.0
+`
+
+func TestScrapeScorer_ControllerPass(t *testing.T) {
+	metricsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# TYPE http_requests_total counter\n" +
+			"http_requests_total{method=\"GET\"} 1\n" +
+			"http_requests_total{method=\"POST\"} 2\n" +
+			"process_open_fds 12\n"))
+	}))
+	defer metricsServer.Close()
+	target, _ := url.Parse(metricsServer.URL)
+
+	fake := newFakeAPIServer(true)
+	fake.deployments = fmt.Sprintf(`{"items":[{"metadata":{"name":"api","namespace":"prod",
+		"annotations":{"instrumentation-score/enabled":"true","instrumentation-score/port":%q}},
+		"spec":{"selector":{"matchLabels":{"app":"api"}}}}]}`, target.Port())
+	fake.pods = fmt.Sprintf(`{"items":[{"metadata":{"name":"api-0","namespace":"prod","labels":{"app":"api"}},
+		"status":{"phase":"Running","podIP":%q}}]}`, target.Hostname())
+	apiServer := httptest.NewServer(fake)
+	defer apiServer.Close()
+
+	client, err := newClient(apiServer.URL, "", "", "", "")
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(rulesFile, []byte(testScorerRules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := engine.NewReloadingEngine(rulesFile)
+	if err != nil {
+		t.Fatalf("NewReloadingEngine() error = %v", err)
+	}
+
+	controller := NewController(client, ScrapeScorer(rules), ControllerOptions{Namespaces: []string{"prod"}, MinScore: 75})
+	results, warnings, err := controller.Reconcile()
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if len(results) != 1 || results[0].Err != nil || !results[0].Passed || results[0].Score != 100 {
+		t.Fatalf("results = %+v, want api passing with 100", results)
+	}
+	status := fake.statuses["api"]
+	if status.TotalMetrics != 2 || status.Confidence != "high" || len(status.Rules) != 1 || status.RulesVersion == "" {
+		t.Errorf("api status = %+v, want 2 metrics scored with high confidence", status)
+	}
+}
@@ -0,0 +1,296 @@
+package kube
+
+import "strings"
+
+// The structs below cover only the fields this tool reads; unknown fields are ignored.
+
+// ObjectMeta is the subset of Kubernetes object metadata used by the tool
+type ObjectMeta struct {
+	Name            string            `json:"name" yaml:"name"`
+	Namespace       string            `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	UID             string            `json:"uid,omitempty" yaml:"uid,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty" yaml:"resourceVersion,omitempty"`
+	OwnerReferences []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
+}
+
+// OwnerReference makes an object garbage-collected together with its owner
+type OwnerReference struct {
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Name       string `json:"name" yaml:"name"`
+	UID        string `json:"uid" yaml:"uid"`
+}
+
+// ObjectReference points at another object
+type ObjectReference struct {
+	Kind      string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// LabelSelector selects objects by labels
+type LabelSelector struct {
+	MatchLabels      map[string]string          `json:"matchLabels,omitempty"`
+	MatchExpressions []LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+// LabelSelectorRequirement is a set-based selector term
+type LabelSelectorRequirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"` // In, NotIn, Exists, DoesNotExist
+	Values   []string `json:"values,omitempty"`
+}
+
+// Matches reports whether labels satisfy the selector
+// An empty selector matches everything, as in Kubernetes.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for key, value := range s.MatchLabels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	for _, req := range s.MatchExpressions {
+		value, exists := labels[req.Key]
+		switch req.Operator {
+		case "In":
+			if !exists || !contains(req.Values, value) {
+				return false
+			}
+		case "NotIn":
+			if exists && contains(req.Values, value) {
+				return false
+			}
+		case "Exists":
+			if !exists {
+				return false
+			}
+		case "DoesNotExist":
+			if exists {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Pod is the subset of a Pod used for scrape discovery
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Containers []struct {
+			Name  string          `json:"name"`
+			Ports []ContainerPort `json:"ports,omitempty"`
+		} `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		Phase string `json:"phase"`
+		PodIP string `json:"podIP"`
+	} `json:"status"`
+}
+
+// ContainerPort is a port exposed by a container
+type ContainerPort struct {
+	Name          string `json:"name,omitempty"`
+	ContainerPort int    `json:"containerPort"`
+}
+
+// PodList is a list of pods
+type PodList struct {
+	Items []Pod `json:"items"`
+}
+
+// Service is the subset of a Service used for ServiceMonitor matching
+type Service struct {
+	Metadata ObjectMeta `json:"metadata"`
+}
+
+// ServiceList is a list of services
+type ServiceList struct {
+	Items []Service `json:"items"`
+}
+
+// Endpoints lists the addresses backing a service
+type Endpoints struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Subsets  []struct {
+		Addresses []struct {
+			IP        string           `json:"ip"`
+			TargetRef *ObjectReference `json:"targetRef,omitempty"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name,omitempty"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+// ServiceMonitor is the subset of the Prometheus Operator ServiceMonitor used for discovery
+type ServiceMonitor struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		JobLabel          string        `json:"jobLabel,omitempty"`
+		Selector          LabelSelector `json:"selector"`
+		NamespaceSelector struct {
+			Any        bool     `json:"any,omitempty"`
+			MatchNames []string `json:"matchNames,omitempty"`
+		} `json:"namespaceSelector"`
+		Endpoints []ServiceMonitorEndpoint `json:"endpoints"`
+	} `json:"spec"`
+}
+
+// ServiceMonitorEndpoint describes one scrape endpoint of a ServiceMonitor
+type ServiceMonitorEndpoint struct {
+	Port            string `json:"port,omitempty"`
+	Path            string `json:"path,omitempty"`
+	Scheme          string `json:"scheme,omitempty"`
+	BearerTokenFile string `json:"bearerTokenFile,omitempty"`
+	TLSConfig       *struct {
+		InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+		ServerName         string `json:"serverName,omitempty"`
+		CAFile             string `json:"caFile,omitempty"`
+	} `json:"tlsConfig,omitempty"`
+}
+
+// ServiceMonitorList is a list of ServiceMonitors
+type ServiceMonitorList struct {
+	Items []ServiceMonitor `json:"items"`
+}
+
+// Deployment is the subset of an apps/v1 Deployment used by the controller
+type Deployment struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Selector LabelSelector `json:"selector"`
+	} `json:"spec"`
+}
+
+// DeploymentList is a list of deployments
+type DeploymentList struct {
+	Items []Deployment `json:"items"`
+}
+
+// Event is a core/v1 Event attached to an object
+type Event struct {
+	APIVersion     string          `json:"apiVersion"`
+	Kind           string          `json:"kind"`
+	Metadata       EventMeta       `json:"metadata"`
+	InvolvedObject ObjectReference `json:"involvedObject"`
+	Reason         string          `json:"reason"`
+	Message        string          `json:"message"`
+	Type           string          `json:"type"` // Normal or Warning
+	Source         struct {
+		Component string `json:"component"`
+	} `json:"source"`
+	FirstTimestamp string `json:"firstTimestamp"`
+	LastTimestamp  string `json:"lastTimestamp"`
+	Count          int    `json:"count"`
+}
+
+// EventMeta is the metadata of a new event; the API server fills in the name
+type EventMeta struct {
+	GenerateName string `json:"generateName"`
+	Namespace    string `json:"namespace"`
+}
+
+// InstrumentationScore is the custom resource holding a workload's latest score
+// It carries yaml tags too so evaluation results can be written as manifests.
+type InstrumentationScore struct {
+	APIVersion string                     `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string                     `json:"kind" yaml:"kind"`
+	Metadata   ObjectMeta                 `json:"metadata" yaml:"metadata"`
+	Spec       InstrumentationScoreSpec   `json:"spec" yaml:"spec"`
+	Status     InstrumentationScoreStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// NewInstrumentationScore returns an InstrumentationScore for job with a valid object name
+func NewInstrumentationScore(name, namespace, job string) InstrumentationScore {
+	return InstrumentationScore{
+		APIVersion: ScoreGroupVersion,
+		Kind:       ScoreKind,
+		Metadata:   ObjectMeta{Name: ObjectName(name), Namespace: namespace},
+		Spec:       InstrumentationScoreSpec{Job: job},
+	}
+}
+
+// ObjectName converts s to a valid object name (DNS-1123 subdomain)
+// Job names such as "kubernetes-pods/api" or "API_Service" are lowercased and
+// invalid characters replaced with '-'.
+func ObjectName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	name := b.String()
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+// InstrumentationScoreSpec identifies the scored workload
+type InstrumentationScoreSpec struct {
+	Job       string           `json:"job" yaml:"job"`
+	TargetRef *ObjectReference `json:"targetRef,omitempty" yaml:"targetRef,omitempty"`
+}
+
+// InstrumentationScoreStatus is the result of the latest evaluation
+type InstrumentationScoreStatus struct {
+	Score            float64      `json:"score" yaml:"score"`
+	MinScore         float64      `json:"minScore,omitempty" yaml:"minScore,omitempty"`
+	Passed           bool         `json:"passed" yaml:"passed"`
+	TotalMetrics     int          `json:"totalMetrics" yaml:"totalMetrics"`
+	TotalCardinality int64        `json:"totalCardinality" yaml:"totalCardinality"`
+	Rules            []RuleStatus `json:"rules,omitempty" yaml:"rules,omitempty"`
+	LastEvaluated    string       `json:"lastEvaluated" yaml:"lastEvaluated"`
+	RulesVersion     string       `json:"rulesVersion,omitempty" yaml:"rulesVersion,omitempty"`
+	Confidence       string       `json:"confidence,omitempty" yaml:"confidence,omitempty"` // high, medium or low; low when the score rests on partial data
+	Message          string       `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// RuleStatus summarizes one rule of an evaluation
+type RuleStatus struct {
+	RuleID       string `json:"ruleId" yaml:"ruleId"`
+	Impact       string `json:"impact" yaml:"impact"`
+	PassedChecks int    `json:"passedChecks" yaml:"passedChecks"`
+	TotalChecks  int    `json:"totalChecks" yaml:"totalChecks"`
+}
+
+// Lease is a coordination.k8s.io/v1 Lease, used for leader election
+type Lease struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   ObjectMeta `json:"metadata"`
+	Spec       LeaseSpec  `json:"spec"`
+}
+
+// LeaseSpec records the current holder of a Lease
+// Times are MicroTime strings (RFC 3339 with microseconds).
+type LeaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
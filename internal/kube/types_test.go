@@ -0,0 +1,51 @@
+package kube
+
+import "testing"
+
+func TestLabelSelector_Matches(t *testing.T) {
+	labels := map[string]string{"app": "api", "tier": "backend"}
+
+	tests := []struct {
+		name     string
+		selector LabelSelector
+		want     bool
+	}{
+		{"empty matches all", LabelSelector{}, true},
+		{"match labels", LabelSelector{MatchLabels: map[string]string{"app": "api"}}, true},
+		{"match labels mismatch", LabelSelector{MatchLabels: map[string]string{"app": "web"}}, false},
+		{"in", LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "tier", Operator: "In", Values: []string{"backend", "db"}}}}, true},
+		{"not in", LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "tier", Operator: "NotIn", Values: []string{"backend"}}}}, false},
+		{"exists", LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "app", Operator: "Exists"}}}, true},
+		{"does not exist", LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "canary", Operator: "DoesNotExist"}}}, true},
+		{"unknown operator", LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "app", Operator: "Gt"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector.Matches(labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"api-service", "api-service"},
+		{"API_Service", "api-service"},
+		{"kubernetes-pods/api", "kubernetes-pods-api"},
+		{"-node.exporter-", "node.exporter"},
+		{"///", "unnamed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := ObjectName(tt.in); got != tt.want {
+				t.Errorf("ObjectName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
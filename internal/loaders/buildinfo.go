@@ -0,0 +1,135 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Build info reports sit next to the per-job files of an analysis run, like scrape health
+// reports, and record the version each job was running when it was analyzed.
+
+const (
+	// BuildInfoFileName is the build info report written into a job metrics directory
+	BuildInfoFileName = "build_info.report"
+	// BuildInfoColumnHeader names the build info report columns
+	BuildInfoColumnHeader = "JOB|METRIC_NAME|VERSION|REVISION|BRANCH"
+	// TargetInfoMetric carries OpenTelemetry resource attributes, including service.version
+	TargetInfoMetric = "target_info"
+)
+
+// versionLabels are the labels holding a version, in order of preference
+var versionLabels = []string{"version", "service_version", "app_version"}
+
+// BuildInfoData is the version information one build info series exposes for a job
+type BuildInfoData struct {
+	Job        string
+	MetricName string
+	Version    string
+	Revision   string
+	Branch     string
+}
+
+// IsBuildInfoMetric reports whether a metric carries version labels (*_build_info or target_info)
+func IsBuildInfoMetric(metricName string) bool {
+	return metricName == "build_info" || strings.HasSuffix(metricName, "_build_info") || metricName == TargetInfoMetric
+}
+
+// BuildInfoFromLabels extracts version labels from a build info series
+// It returns false when the series has neither a version nor a revision.
+func BuildInfoFromLabels(job, metricName string, labels map[string]string) (BuildInfoData, bool) {
+	info := BuildInfoData{
+		Job:        job,
+		MetricName: metricName,
+		Revision:   labels["revision"],
+		Branch:     labels["branch"],
+	}
+	for _, label := range versionLabels {
+		if value := labels[label]; value != "" {
+			info.Version = value
+			break
+		}
+	}
+	return info, info.Version != "" || info.Revision != ""
+}
+
+// ServiceVersions returns the detected version of each job
+// Jobs running several versions (e.g. mid-rollout) list them all, sorted and comma-separated.
+// The revision stands in for jobs that expose no version.
+func ServiceVersions(data []BuildInfoData) map[string]string {
+	byJob := make(map[string]map[string]bool)
+	for _, info := range data {
+		version := info.Version
+		if version == "" {
+			version = info.Revision
+			if len(version) > 12 {
+				version = version[:12]
+			}
+		}
+		if version == "" {
+			continue
+		}
+		if byJob[info.Job] == nil {
+			byJob[info.Job] = make(map[string]bool)
+		}
+		byJob[info.Job][version] = true
+	}
+
+	versions := make(map[string]string, len(byJob))
+	for job, set := range byJob {
+		list := make([]string, 0, len(set))
+		for version := range set {
+			list = append(list, version)
+		}
+		sort.Strings(list)
+		versions[job] = strings.Join(list, ", ")
+	}
+	return versions
+}
+
+// FormatBuildInfoLine renders a record as a build info report line
+func FormatBuildInfoLine(data BuildInfoData) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s\n",
+		EscapeField(data.Job),
+		EscapeField(data.MetricName),
+		EscapeField(data.Version),
+		EscapeField(data.Revision),
+		EscapeField(data.Branch))
+}
+
+// LoadBuildInfoReport loads a build info report, skipping malformed lines
+func LoadBuildInfoReport(filename string) ([]BuildInfoData, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadBuildInfoReport(file)
+}
+
+// ReadBuildInfoReport parses a build info report from r, skipping malformed lines
+func ReadBuildInfoReport(r io.Reader) ([]BuildInfoData, error) {
+	var data []BuildInfoData
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == BuildInfoColumnHeader {
+			continue
+		}
+
+		parts := splitEscaped(line, '|')
+		if len(parts) != 5 {
+			continue
+		}
+		data = append(data, BuildInfoData{
+			Job:        unescapeField(parts[0]),
+			MetricName: unescapeField(parts[1]),
+			Version:    unescapeField(parts[2]),
+			Revision:   unescapeField(parts[3]),
+			Branch:     unescapeField(parts[4]),
+		})
+	}
+	return data, scanErr(scanner)
+}
@@ -0,0 +1,83 @@
+package loaders
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildInfoFromLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   BuildInfoData
+		wantOK bool
+	}{
+		{
+			name:   "prometheus build info",
+			labels: map[string]string{"version": "2.45.0", "revision": "8ef767e", "branch": "HEAD", "goversion": "go1.21"},
+			want:   BuildInfoData{Job: "job", MetricName: "m", Version: "2.45.0", Revision: "8ef767e", Branch: "HEAD"},
+			wantOK: true,
+		},
+		{
+			name:   "otel service version",
+			labels: map[string]string{"service_version": "1.4.0", "service_name": "checkout"},
+			want:   BuildInfoData{Job: "job", MetricName: "m", Version: "1.4.0"},
+			wantOK: true,
+		},
+		{
+			name:   "no version labels",
+			labels: map[string]string{"goversion": "go1.21"},
+			want:   BuildInfoData{Job: "job", MetricName: "m"},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := BuildInfoFromLabels("job", "m", tt.labels)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("BuildInfoFromLabels() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestServiceVersions(t *testing.T) {
+	got := ServiceVersions([]BuildInfoData{
+		{Job: "api", Version: "1.3.0"},
+		{Job: "api", Version: "1.2.0"},
+		{Job: "api", Version: "1.3.0"},
+		{Job: "worker", Revision: "0123456789abcdef"},
+		{Job: "empty"},
+	})
+	want := map[string]string{"api": "1.2.0, 1.3.0", "worker": "0123456789ab"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceVersions() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadBuildInfoReport(t *testing.T) {
+	want := []BuildInfoData{
+		{Job: "api", MetricName: "api_build_info", Version: "1.2.0", Revision: "abc", Branch: "main"},
+		{Job: "batch|nightly", MetricName: "target_info", Version: "v2:rc1"},
+	}
+	content := BuildInfoColumnHeader + "\n"
+	for _, info := range want {
+		content += FormatBuildInfoLine(info)
+	}
+	content += "malformed|line\n"
+
+	path := filepath.Join(t.TempDir(), BuildInfoFileName)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadBuildInfoReport(path)
+	if err != nil {
+		t.Fatalf("LoadBuildInfoReport() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadBuildInfoReport() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SeriesChurnFileName is the series churn report written into a job metrics directory
+	SeriesChurnFileName = "series_churn.report"
+	// SeriesChurnColumnHeader names the series churn report columns
+	SeriesChurnColumnHeader = "JOB|METRIC_NAME|ACTIVE_SERIES|WINDOW_SERIES|NEW_SERIES|WINDOW_SECONDS"
+)
+
+// SeriesChurnData describes how quickly the series of one metric of a job turn over
+// Churn costs Prometheus index and head memory even when the active series count is steady.
+type SeriesChurnData struct {
+	Job           string
+	MetricName    string
+	ActiveSeries  int64 // Series present at the end of the window
+	WindowSeries  int64 // Series present at any point of the window
+	NewSeries     int64 // Active series that did not exist at the start of the window
+	WindowSeconds int64 // Length of the window
+}
+
+// FormatSeriesChurnLine renders a record as a series churn report line
+func FormatSeriesChurnLine(data SeriesChurnData) string {
+	return fmt.Sprintf("%s|%s|%d|%d|%d|%d\n",
+		EscapeField(data.Job),
+		EscapeField(data.MetricName),
+		data.ActiveSeries,
+		data.WindowSeries,
+		data.NewSeries,
+		data.WindowSeconds)
+}
+
+// LoadSeriesChurnReport loads a series churn report, skipping malformed lines
+func LoadSeriesChurnReport(filename string) ([]SeriesChurnData, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadSeriesChurnReport(file)
+}
+
+// ReadSeriesChurnReport parses a series churn report from r, skipping malformed lines
+func ReadSeriesChurnReport(r io.Reader) ([]SeriesChurnData, error) {
+	var data []SeriesChurnData
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == SeriesChurnColumnHeader {
+			continue
+		}
+
+		parts := splitEscaped(line, '|')
+		if len(parts) != 6 {
+			continue
+		}
+
+		var counts [4]int64
+		valid := true
+		for i, part := range parts[2:] {
+			v, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			counts[i] = v
+		}
+		if !valid {
+			continue
+		}
+
+		data = append(data, SeriesChurnData{
+			Job:           unescapeField(parts[0]),
+			MetricName:    unescapeField(parts[1]),
+			ActiveSeries:  counts[0],
+			WindowSeries:  counts[1],
+			NewSeries:     counts[2],
+			WindowSeconds: counts[3],
+		})
+	}
+	return data, scanErr(scanner)
+}
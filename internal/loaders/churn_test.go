@@ -0,0 +1,27 @@
+package loaders
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSeriesChurnReport(t *testing.T) {
+	content := SeriesChurnColumnHeader + "\n" +
+		"api|http_requests_total|1200|5000|3800|3600\n" +
+		"batch\\|nightly|jobs_total|3|3|0|3600\n" +
+		"broken|line\n" +
+		"api|up|not-a-number|1|0|3600\n"
+
+	got, err := ReadSeriesChurnReport(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ReadSeriesChurnReport() error = %v", err)
+	}
+	want := []SeriesChurnData{
+		{Job: "api", MetricName: "http_requests_total", ActiveSeries: 1200, WindowSeries: 5000, NewSeries: 3800, WindowSeconds: 3600},
+		{Job: "batch|nightly", MetricName: "jobs_total", ActiveSeries: 3, WindowSeries: 3, WindowSeconds: 3600},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadSeriesChurnReport() = %+v, want %+v", got, want)
+	}
+}
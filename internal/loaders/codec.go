@@ -0,0 +1,127 @@
+package loaders
+
+import (
+	"sort"
+	"strings"
+)
+
+// Per-job files separate fields with '|', list items with ',' and label
+// cardinality pairs with ':'. Sampled label values follow their label name, each
+// after a ':' (status:ok:error). Since format v2 any of these characters (and '\')
+// inside a value is escaped with a backslash so job names such as "batch|nightly"
+// or label names containing commas survive a round trip.
+//
+// Every file starts with a format header ("#format=v2") followed by the column
+// header. Files without a format header are legacy v1 files and are read
+// verbatim. Binaries that predate versioning treat the header as a comment.
+
+const (
+	// FormatHeaderPrefix starts the format version line of a per-job file
+	FormatHeaderPrefix = "#format="
+	// FormatVersion is the version written by WritePerJobFiles
+	FormatVersion = "v2"
+	// ColumnHeader names the per-job file columns
+	ColumnHeader = "JOB|METRIC_NAME|LABELS|CARDINALITY|LABEL_CARDINALITY|TYPE|LABEL_VALUES"
+
+	legacyFormatVersion = "v1"
+)
+
+// FileHeader returns the header lines written at the top of every per-job file
+func FileHeader() string {
+	return FormatHeaderPrefix + FormatVersion + "\n" + ColumnHeader + "\n"
+}
+
+// fieldCodec splits and unescapes fields for one format version
+type fieldCodec struct {
+	split    func(s string, sep byte) []string
+	unescape func(s string) string
+}
+
+// jobFileFormats maps each readable format version to its codec
+var jobFileFormats = map[string]fieldCodec{
+	legacyFormatVersion: {
+		split:    func(s string, sep byte) []string { return strings.Split(s, string(sep)) },
+		unescape: func(s string) string { return s },
+	},
+	"v2": {split: splitEscaped, unescape: unescapeField},
+}
+
+// supportedFormatVersions returns the readable format versions in sorted order
+func supportedFormatVersions() []string {
+	versions := make([]string, 0, len(jobFileFormats))
+	for version := range jobFileFormats {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+var fieldEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`|`, `\|`,
+	`,`, `\,`,
+	`:`, `\:`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// EscapeField escapes a value for writing into a per-job file field
+func EscapeField(value string) string {
+	return fieldEscaper.Replace(value)
+}
+
+// JoinEscaped escapes each value and joins them with sep
+func JoinEscaped(values []string, sep string) string {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = EscapeField(value)
+	}
+	return strings.Join(escaped, sep)
+}
+
+// splitEscaped splits s on every sep that is not preceded by an escaping backslash
+// Escape sequences are preserved in the returned parts so they can be split further.
+func splitEscaped(s string, sep byte) []string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return strings.Split(s, string(sep))
+	}
+
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescapeField reverses EscapeField
+func unescapeField(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
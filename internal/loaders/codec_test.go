@@ -0,0 +1,159 @@
+package loaders
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestEscapeFieldRoundTrip(t *testing.T) {
+	tests := []string{
+		"plain",
+		"batch|nightly",
+		"a,b",
+		"key:value",
+		`back\slash`,
+		"multi\nline",
+		`\|,:`,
+		"",
+	}
+
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			if got := unescapeField(EscapeField(value)); got != value {
+				t.Errorf("round trip of %q = %q", value, got)
+			}
+		})
+	}
+}
+
+func TestSplitEscaped(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		sep   byte
+		want  []string
+	}{
+		{"no escapes", "a|b|c", '|', []string{"a", "b", "c"}},
+		{"escaped separator", `a\|b|c`, '|', []string{`a\|b`, "c"}},
+		{"escaped backslash before separator", `a\\|b`, '|', []string{`a\\`, "b"}},
+		{"trailing empty field", "a|", '|', []string{"a", ""}},
+		{"nested separator kept", `x\,y,z`, ',', []string{`x\,y`, "z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitEscaped(tt.input, tt.sep); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitEscaped(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadJobMetricReport_EscapedFields(t *testing.T) {
+	line := EscapeField("batch|nightly") + "|http_requests_total|" +
+		JoinEscaped([]string{"path,full", "status"}, ",") + "|42|" +
+		EscapeField("path,full") + ":7,status:3|counter"
+
+	content := FileHeader() + line + "\n"
+
+	tmpFile, err := os.CreateTemp("", "test_job_metrics_*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	tmpFile.Close()
+
+	data, warnings, err := LoadJobMetricReportWithWarnings(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load job metric report: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+	if len(data) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(data))
+	}
+
+	got := data[0]
+	if got.Job != "batch|nightly" {
+		t.Errorf("Expected job 'batch|nightly', got %q", got.Job)
+	}
+	if !reflect.DeepEqual(got.Labels, []string{"path,full", "status"}) {
+		t.Errorf("Expected labels [path,full status], got %q", got.Labels)
+	}
+	if got.Cardinality != 42 || got.Type != "counter" {
+		t.Errorf("Expected cardinality 42 and type counter, got %d %q", got.Cardinality, got.Type)
+	}
+	if got.LabelCardinality["path,full"] != 7 || got.LabelCardinality["status"] != 3 {
+		t.Errorf("Unexpected label cardinality %v", got.LabelCardinality)
+	}
+}
+
+func TestLoadJobMetricReport_FormatVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantJob string
+		wantErr bool
+	}{
+		{
+			name:    "legacy file reads backslashes verbatim",
+			content: "JOB|METRIC_NAME|LABELS|CARDINALITY\n" + `win\service|up|instance|1` + "\n",
+			wantJob: `win\service`,
+		},
+		{
+			name:    "v2 file unescapes values",
+			content: FileHeader() + `win\\service|up|instance|1` + "\n",
+			wantJob: `win\service`,
+		},
+		{
+			name:    "unknown version is rejected",
+			content: FormatHeaderPrefix + "v99\n" + ColumnHeader + "\napi|up|instance|1\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "test_job_metrics_*.txt")
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatalf("Failed to write test data: %v", err)
+			}
+			tmpFile.Close()
+
+			data, err := LoadJobMetricReport(tmpFile.Name())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadJobMetricReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(data) != 1 || data[0].Job != tt.wantJob {
+				t.Errorf("Expected one record for job %q, got %+v", tt.wantJob, data)
+			}
+		})
+	}
+}
+
+func FuzzEscapeField(f *testing.F) {
+	f.Add("batch|nightly", "a,b")
+	f.Add(`back\slash`, "key:value\n")
+	f.Add(`\`, "")
+
+	f.Fuzz(func(t *testing.T, a, b string) {
+		parts := splitEscaped(JoinEscaped([]string{a, b}, "|"), '|')
+		if len(parts) != 2 || unescapeField(parts[0]) != a || unescapeField(parts[1]) != b {
+			t.Fatalf("round trip of %q, %q = %q", a, b, parts)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Collection gap reports sit next to the per-job files of an analysis run and list the
+// metrics analyze failed to collect, so evaluate can tell a complete job from a partial one.
+
+const (
+	// CollectionGapsFileName is the collection gap report written into a job metrics directory
+	CollectionGapsFileName = "collection_gaps.report"
+	// CollectionGapsColumnHeader names the collection gap report columns
+	CollectionGapsColumnHeader = "JOB|METRIC_NAME|OPERATION|ERROR"
+	// AllJobs is the job of a gap that happened before the metric's jobs were known
+	AllJobs = "*"
+	// collectionGapsMetricsPrefix starts the line recording how many metrics the run collected
+	collectionGapsMetricsPrefix = "#metrics="
+)
+
+// CollectionGap is a metric of a job that could not be collected
+type CollectionGap struct {
+	Job        string // AllJobs when the metric failed for every job
+	MetricName string
+	Operation  string
+	Error      string
+}
+
+// CollectionGaps are the gaps of an analysis run
+type CollectionGaps struct {
+	Metrics int // Metric names the run tried to collect, 0 if unknown
+	Gaps    []CollectionGap
+}
+
+// Missing returns the number of metrics that failed to collect for job, not counting AllJobs gaps
+func (g CollectionGaps) Missing(job string) int {
+	metrics := make(map[string]bool)
+	for _, gap := range g.Gaps {
+		if gap.Job == job {
+			metrics[gap.MetricName] = true
+		}
+	}
+	return len(metrics)
+}
+
+// UnknownJobsFraction returns the fraction of the run's metrics that failed for every job;
+// each job is expected to miss that fraction of its own metrics
+func (g CollectionGaps) UnknownJobsFraction() float64 {
+	if g.Metrics == 0 {
+		return 0
+	}
+	return float64(g.Missing(AllJobs)) / float64(g.Metrics)
+}
+
+// FormatCollectionGapLine renders a gap as a collection gap report line
+func FormatCollectionGapLine(gap CollectionGap) string {
+	return fmt.Sprintf("%s|%s|%s|%s\n",
+		EscapeField(gap.Job),
+		EscapeField(gap.MetricName),
+		EscapeField(gap.Operation),
+		EscapeField(gap.Error))
+}
+
+// FormatCollectionGapsHeader renders the first lines of a collection gap report
+func FormatCollectionGapsHeader(metrics int) string {
+	return fmt.Sprintf("%s%d\n%s\n", collectionGapsMetricsPrefix, metrics, CollectionGapsColumnHeader)
+}
+
+// LoadCollectionGapsReport loads a collection gap report, skipping malformed lines
+func LoadCollectionGapsReport(filename string) (CollectionGaps, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return CollectionGaps{}, err
+	}
+	defer file.Close()
+	return ReadCollectionGapsReport(file)
+}
+
+// ReadCollectionGapsReport parses a collection gap report from r, skipping malformed lines
+func ReadCollectionGapsReport(r io.Reader) (CollectionGaps, error) {
+	var gaps CollectionGaps
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if metrics, ok := strings.CutPrefix(line, collectionGapsMetricsPrefix); ok {
+			gaps.Metrics, _ = strconv.Atoi(metrics)
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") || line == CollectionGapsColumnHeader {
+			continue
+		}
+
+		parts := splitEscaped(line, '|')
+		if len(parts) != 4 {
+			continue
+		}
+		gaps.Gaps = append(gaps.Gaps, CollectionGap{
+			Job:        unescapeField(parts[0]),
+			MetricName: unescapeField(parts[1]),
+			Operation:  unescapeField(parts[2]),
+			Error:      unescapeField(parts[3]),
+		})
+	}
+	return gaps, scanErr(scanner)
+}
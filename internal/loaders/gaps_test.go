@@ -0,0 +1,42 @@
+package loaders
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCollectionGapsReport(t *testing.T) {
+	gaps := []CollectionGap{
+		{Job: "api", MetricName: "http_requests_total", Operation: "labels", Error: "HTTP 503 | unavailable"},
+		{Job: "api", MetricName: "http_requests_total", Operation: "cardinality", Error: "timeout"},
+		{Job: "api", MetricName: "db_queries_total", Operation: "labels", Error: "timeout"},
+		{Job: AllJobs, MetricName: "huge_metric", Operation: "fetch_job_data", Error: "timeout"},
+	}
+	report := FormatCollectionGapsHeader(20)
+	for _, gap := range gaps {
+		report += FormatCollectionGapLine(gap)
+	}
+	report += "malformed line\n"
+
+	got, err := ReadCollectionGapsReport(strings.NewReader(report))
+	if err != nil {
+		t.Fatalf("ReadCollectionGapsReport() error = %v", err)
+	}
+	if got.Metrics != 20 || !reflect.DeepEqual(got.Gaps, gaps) {
+		t.Errorf("ReadCollectionGapsReport() = %+v", got)
+	}
+
+	if missing := got.Missing("api"); missing != 2 {
+		t.Errorf("Missing(api) = %d, want 2 metrics", missing)
+	}
+	if missing := got.Missing("web"); missing != 0 {
+		t.Errorf("Missing(web) = %d, want 0", missing)
+	}
+	if fraction := got.UnknownJobsFraction(); fraction != 0.05 {
+		t.Errorf("UnknownJobsFraction() = %v, want 0.05", fraction)
+	}
+	if fraction := (CollectionGaps{Gaps: gaps}).UnknownJobsFraction(); fraction != 0 {
+		t.Errorf("UnknownJobsFraction() without a metric count = %v, want 0", fraction)
+	}
+}
@@ -0,0 +1,103 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Scrape health reports sit next to the per-job files of an analysis run. They use
+// the per-job field escaping but are not named *.txt, so job globs skip them.
+
+const (
+	// ScrapeHealthFileName is the scrape health report written into a job metrics directory
+	ScrapeHealthFileName = "scrape_health.report"
+	// ScrapeHealthColumnHeader names the scrape health report columns
+	ScrapeHealthColumnHeader = "JOB|INSTANCE|UP_RATIO|UP_CHANGES|SCRAPE_DURATION_SECONDS|SCRAPE_TIMEOUT_SECONDS|SAMPLES_POST_METRIC_RELABELING|SAMPLE_LIMIT"
+)
+
+// ScrapeHealthData describes how reliably one target was scraped over the collection window
+type ScrapeHealthData struct {
+	Job                   string
+	Instance              string
+	UpRatio               float64 // Fraction of scrapes that succeeded (avg_over_time(up))
+	UpChanges             int     // Number of up/down transitions (changes(up))
+	ScrapeDurationSeconds float64 // Slowest scrape in the window
+	ScrapeTimeoutSeconds  float64 // Configured scrape timeout, 0 if unknown
+	SamplesPostRelabeling int64   // Most samples ingested by one scrape
+	SampleLimit           int64   // Configured sample_limit, 0 if unknown or unlimited
+}
+
+// FormatScrapeHealthLine renders a record as a scrape health report line
+func FormatScrapeHealthLine(data ScrapeHealthData) string {
+	return fmt.Sprintf("%s|%s|%s|%d|%s|%s|%d|%d\n",
+		EscapeField(data.Job),
+		EscapeField(data.Instance),
+		strconv.FormatFloat(data.UpRatio, 'f', -1, 64),
+		data.UpChanges,
+		strconv.FormatFloat(data.ScrapeDurationSeconds, 'f', -1, 64),
+		strconv.FormatFloat(data.ScrapeTimeoutSeconds, 'f', -1, 64),
+		data.SamplesPostRelabeling,
+		data.SampleLimit)
+}
+
+// LoadScrapeHealthReport loads a scrape health report, skipping malformed lines
+func LoadScrapeHealthReport(filename string) ([]ScrapeHealthData, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadScrapeHealthReport(file)
+}
+
+// ReadScrapeHealthReport parses a scrape health report from r, skipping malformed lines
+func ReadScrapeHealthReport(r io.Reader) ([]ScrapeHealthData, error) {
+	var data []ScrapeHealthData
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == ScrapeHealthColumnHeader {
+			continue
+		}
+
+		parts := splitEscaped(line, '|')
+		if len(parts) != 8 {
+			continue
+		}
+
+		record := ScrapeHealthData{
+			Job:      unescapeField(parts[0]),
+			Instance: unescapeField(parts[1]),
+		}
+		var parseErr error
+		parseFloat := func(s string) float64 {
+			v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil && parseErr == nil {
+				parseErr = err
+			}
+			return v
+		}
+		parseInt := func(s string) int64 {
+			v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil && parseErr == nil {
+				parseErr = err
+			}
+			return v
+		}
+		record.UpRatio = parseFloat(parts[2])
+		record.UpChanges = int(parseInt(parts[3]))
+		record.ScrapeDurationSeconds = parseFloat(parts[4])
+		record.ScrapeTimeoutSeconds = parseFloat(parts[5])
+		record.SamplesPostRelabeling = parseInt(parts[6])
+		record.SampleLimit = parseInt(parts[7])
+		if parseErr != nil {
+			continue
+		}
+
+		data = append(data, record)
+	}
+	return data, scanErr(scanner)
+}
@@ -0,0 +1,34 @@
+package loaders
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadScrapeHealthReport(t *testing.T) {
+	content := ScrapeHealthColumnHeader + "\n" +
+		"api|10.0.0.1:8080|1|0|0.25|10|1200|0\n" +
+		"batch\\|nightly|host\\:9100|0.9|4|1.5|0|500|1000\n" +
+		"# comment\n" +
+		"broken|line\n" +
+		"api|10.0.0.2:8080|not-a-number|0|0|0|0|0\n"
+
+	path := filepath.Join(t.TempDir(), ScrapeHealthFileName)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadScrapeHealthReport(path)
+	if err != nil {
+		t.Fatalf("LoadScrapeHealthReport() error = %v", err)
+	}
+	want := []ScrapeHealthData{
+		{Job: "api", Instance: "10.0.0.1:8080", UpRatio: 1, ScrapeDurationSeconds: 0.25, ScrapeTimeoutSeconds: 10, SamplesPostRelabeling: 1200},
+		{Job: "batch|nightly", Instance: "host:9100", UpRatio: 0.9, UpChanges: 4, ScrapeDurationSeconds: 1.5, SamplesPostRelabeling: 500, SampleLimit: 1000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadScrapeHealthReport() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package loaders
+
+import "strings"
+
+// Interner deduplicates strings so that job, label and type names repeated across
+// millions of records share one copy
+// The zero value is not usable; create one with make(Interner). It is not safe for
+// concurrent use, and lives as long as the data it built, e.g. one file or one query.
+type Interner map[string]string
+
+// Intern returns the shared copy of s
+// The first copy is cloned, so it does not keep the larger string s was sliced from alive.
+func (in Interner) Intern(s string) string {
+	if shared, ok := in[s]; ok {
+		return shared
+	}
+	s = strings.Clone(s)
+	in[s] = s
+	return s
+}
+
+// InternAll replaces every value with its shared copy, in place
+func (in Interner) InternAll(values []string) []string {
+	for i, value := range values {
+		values[i] = in.Intern(value)
+	}
+	return values
+}
@@ -0,0 +1,28 @@
+package loaders
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestInterner(t *testing.T) {
+	names := make(Interner)
+	line := "api-service|http_requests_total"
+
+	first := names.Intern(line[:11])
+	if first != "api-service" {
+		t.Fatalf("Intern() = %q", first)
+	}
+	if unsafe.StringData(first) == unsafe.StringData(line) {
+		t.Error("the first copy should not keep the line it was sliced from alive")
+	}
+	if again := names.Intern(strings.Clone("api-service")); unsafe.StringData(again) != unsafe.StringData(first) {
+		t.Error("equal strings should share the first copy")
+	}
+
+	labels := names.InternAll([]string{strings.Clone("api-service"), "method"})
+	if unsafe.StringData(labels[0]) != unsafe.StringData(first) || len(names) != 2 {
+		t.Errorf("InternAll() = %q with %d shared strings, want 2", labels, len(names))
+	}
+}
@@ -0,0 +1,70 @@
+package loaders
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// File name suffixes of a job's legacy cardinality and labels report pair
+const (
+	LegacyCardinalitySuffix = "_cardinality.txt"
+	LegacyLabelsSuffix      = "_labels.txt"
+)
+
+// LegacyJobName derives a job name from a legacy cardinality report file name:
+// api_cardinality.txt is job api, other names lose their extension
+func LegacyJobName(filename string) string {
+	base := filepath.Base(filename)
+	if strings.HasSuffix(base, LegacyCardinalitySuffix) {
+		return strings.TrimSuffix(base, LegacyCardinalitySuffix)
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// LegacyLabelsFile returns the labels report paired with a legacy cardinality report
+func LegacyLabelsFile(cardinalityFile string) string {
+	return strings.TrimSuffix(cardinalityFile, LegacyCardinalitySuffix) + LegacyLabelsSuffix
+}
+
+// MergeLegacyReports combines a job's separate cardinality and labels reports into
+// per-job metric data. Metrics missing from the labels report have no labels, and
+// metrics missing from the cardinality report have a cardinality of 0.
+func MergeLegacyReports(job string, cardinality []CardinalityData, labels []LabelsData) []JobMetricData {
+	index := make(map[string]int, len(cardinality))
+	data := make([]JobMetricData, 0, len(cardinality))
+	for _, c := range cardinality {
+		if i, ok := index[c.MetricName]; ok {
+			data[i].Cardinality += c.Count
+			continue
+		}
+		index[c.MetricName] = len(data)
+		data = append(data, JobMetricData{Job: job, MetricName: c.MetricName, Cardinality: c.Count, Type: c.Type})
+	}
+	for _, l := range labels {
+		i, ok := index[l.MetricName]
+		if !ok {
+			index[l.MetricName] = len(data)
+			data = append(data, JobMetricData{Job: job, MetricName: l.MetricName, Type: l.Type})
+			i = len(data) - 1
+		}
+		data[i].Labels = append(data[i].Labels, l.Labels...)
+		if data[i].Type == "" {
+			data[i].Type = l.Type
+		}
+	}
+	return data
+}
+
+// LoadLegacyReports loads a job's cardinality and labels report pair as per-job metric data
+func LoadLegacyReports(job, cardinalityFile, labelsFile string) ([]JobMetricData, error) {
+	cardinality, err := LoadCardinalityReport(cardinalityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cardinality report: %w", err)
+	}
+	labels, err := LoadLabelsReport(labelsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load labels report: %w", err)
+	}
+	return MergeLegacyReports(job, cardinality, labels), nil
+}
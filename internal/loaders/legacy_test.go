@@ -0,0 +1,47 @@
+package loaders
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadLegacyReports(t *testing.T) {
+	dir := t.TempDir()
+	cardinalityFile := filepath.Join(dir, "api_cardinality.txt")
+	labelsFile := LegacyLabelsFile(cardinalityFile)
+	if err := os.WriteFile(cardinalityFile, []byte("# metric|count\nhttp_requests_total|120\nup|3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(labelsFile, []byte("http_requests_total|\"method,status\"\nbuild_info|\"version\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := LegacyJobName(cardinalityFile); got != "api" {
+		t.Errorf("LegacyJobName() = %q, want api", got)
+	}
+	if got := LegacyJobName("exports/payments.txt"); got != "payments" {
+		t.Errorf("LegacyJobName() = %q, want payments", got)
+	}
+	if labelsFile != filepath.Join(dir, "api_labels.txt") {
+		t.Errorf("LegacyLabelsFile() = %q", labelsFile)
+	}
+
+	got, err := LoadLegacyReports("api", cardinalityFile, labelsFile)
+	if err != nil {
+		t.Fatalf("LoadLegacyReports() error = %v", err)
+	}
+	want := []JobMetricData{
+		{Job: "api", MetricName: "http_requests_total", Cardinality: 120, Labels: []string{"method", "status"}},
+		{Job: "api", MetricName: "up", Cardinality: 3},
+		{Job: "api", MetricName: "build_info", Labels: []string{"version"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadLegacyReports() = %+v, want %+v", got, want)
+	}
+
+	if _, err := LoadLegacyReports("api", cardinalityFile, filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("expected an error for a missing labels report")
+	}
+}
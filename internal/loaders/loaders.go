@@ -2,21 +2,78 @@ package loaders
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 )
 
+// DefaultMaxLineBytes is the longest line the report readers accept unless SetMaxLineBytes
+// changes it. Job file lines with sampled label values of many labels run long.
+const DefaultMaxLineBytes = 10 * 1024 * 1024
+
+// maxLineBytes is the longest line the report readers accept, see SetMaxLineBytes
+var maxLineBytes = DefaultMaxLineBytes
+
+// SetMaxLineBytes sets the longest line the report readers accept; a longer line fails the
+// read. n <= 0 restores DefaultMaxLineBytes.
+func SetMaxLineBytes(n int) {
+	if n <= 0 {
+		n = DefaultMaxLineBytes
+	}
+	maxLineBytes = n
+}
+
+// newLineScanner returns a scanner of the lines of r, up to maxLineBytes long
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	return scanner
+}
+
+// ContextReader returns a reader of r failing with ctx's error once ctx is done, so a report
+// reader given it stops reading a large file when its caller gave up
+func ContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// scanErr returns the error that stopped scanner, naming the limit when a line exceeded it
+func scanErr(scanner *bufio.Scanner) error {
+	err := scanner.Err()
+	if errors.Is(err, bufio.ErrTooLong) {
+		return fmt.Errorf("line longer than %d bytes: %w", maxLineBytes, err)
+	}
+	return err
+}
+
 // CardinalityData represents metric cardinality information
 type CardinalityData struct {
 	MetricName string
 	Count      int64
+	Type       string // Metric TYPE (counter, gauge, histogram, summary) or "" if unknown
 }
 
 // LabelsData represents metric labels information
 type LabelsData struct {
-	MetricName string
-	Labels     []string
+	MetricName  string
+	Labels      []string
+	Type        string              // Metric TYPE (counter, gauge, histogram, summary) or "" if unknown
+	LabelValues map[string][]string // Sampled values per label, nil when not collected
 }
 
 // JobMetricData represents complete metric data per job
@@ -25,7 +82,9 @@ type JobMetricData struct {
 	MetricName       string
 	Labels           []string
 	Cardinality      int64
-	LabelCardinality map[string]int64 // Per-label cardinality (label_name -> cardinality)
+	LabelCardinality map[string]int64    // Per-label cardinality (label_name -> cardinality)
+	Type             string              // Metric TYPE from metadata, "" for files written before types were collected
+	LabelValues      map[string][]string // Sampled values per label (label_name -> values), nil when not collected
 }
 
 // LoadCardinalityReport loads metrics cardinality data from file
@@ -35,9 +94,13 @@ func LoadCardinalityReport(filename string) ([]CardinalityData, error) {
 		return nil, err
 	}
 	defer file.Close()
+	return ReadCardinalityReport(file)
+}
 
+// ReadCardinalityReport parses metrics cardinality data (METRIC|COUNT lines) from r
+func ReadCardinalityReport(r io.Reader) ([]CardinalityData, error) {
 	var data []CardinalityData
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(r)
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -61,7 +124,7 @@ func LoadCardinalityReport(filename string) ([]CardinalityData, error) {
 		})
 	}
 
-	return data, scanner.Err()
+	return data, scanErr(scanner)
 }
 
 // LoadLabelsReport loads metrics labels data from file
@@ -71,9 +134,13 @@ func LoadLabelsReport(filename string) ([]LabelsData, error) {
 		return nil, err
 	}
 	defer file.Close()
+	return ReadLabelsReport(file)
+}
 
+// ReadLabelsReport parses metrics labels data (METRIC|"label1,label2" lines) from r
+func ReadLabelsReport(r io.Reader) ([]LabelsData, error) {
 	var data []LabelsData
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(r)
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -106,80 +173,197 @@ func LoadLabelsReport(filename string) ([]LabelsData, error) {
 		})
 	}
 
-	return data, scanner.Err()
+	return data, scanErr(scanner)
+}
+
+// ParseWarning describes a line that was skipped or only partially parsed
+type ParseWarning struct {
+	File    string
+	Line    int
+	Reason  string
+	Skipped bool // The line's record was dropped rather than partially parsed
+}
+
+// SkippedRecords returns the number of records warnings dropped, leaving them out of the job's metrics
+func SkippedRecords(warnings []ParseWarning) int {
+	skipped := 0
+	for _, warning := range warnings {
+		if warning.Skipped {
+			skipped++
+		}
+	}
+	return skipped
+}
+
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("%s:%d: %s", w.File, w.Line, w.Reason)
 }
 
 // LoadJobMetricReport loads per-job metric data from file
+// Malformed lines are skipped silently; use LoadJobMetricReportWithWarnings to inspect them.
 func LoadJobMetricReport(filename string) ([]JobMetricData, error) {
+	data, _, err := LoadJobMetricReportWithWarnings(filename)
+	return data, err
+}
+
+// LoadJobMetricReportWithWarnings loads per-job metric data and reports every line
+// that was skipped or partially parsed, with its line number and reason
+func LoadJobMetricReportWithWarnings(filename string) ([]JobMetricData, []ParseWarning, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer file.Close()
+	return ReadJobMetricReport(file, filename)
+}
 
+// ReadJobMetricReport parses per-job metric data from r, like LoadJobMetricReportWithWarnings
+// filename only names the source in warnings and errors.
+func ReadJobMetricReport(r io.Reader, filename string) ([]JobMetricData, []ParseWarning, error) {
 	var data []JobMetricData
-	scanner := bufio.NewScanner(file)
+	var warnings []ParseWarning
+	scanner := newLineScanner(r)
+	lineNum := 0
+	// Every line repeats the job name and most label names, so share them between records
+	names := make(Interner)
+
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, ParseWarning{File: filename, Line: lineNum, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	// The first line is either a format header followed by the column header,
+	// or the column header of a legacy file written before versioning
+	version := legacyFormatVersion
+	if scanner.Scan() {
+		lineNum++
+		if first := strings.TrimSpace(scanner.Text()); strings.HasPrefix(first, FormatHeaderPrefix) {
+			version = strings.TrimSpace(strings.TrimPrefix(first, FormatHeaderPrefix))
+			if scanner.Scan() {
+				lineNum++
+			}
+		}
+	}
 
-	// Skip header line (JOB|METRIC_NAME|LABELS|CARDINALITY)
-	scanner.Scan()
+	codec, ok := jobFileFormats[version]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: unsupported per-job file format %q (supported: %s)", filename, version, strings.Join(supportedFormatVersions(), ", "))
+	}
 
 	for scanner.Scan() {
+		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
-		parts := strings.Split(line, "|")
-		if len(parts) < 4 {
-			continue
+		if record, ok := parseJobMetricLine(line, codec, names, warn); ok {
+			data = append(data, record)
+		} else if len(warnings) > 0 {
+			warnings[len(warnings)-1].Skipped = true
 		}
+	}
 
-		cardinality, err := strconv.ParseInt(strings.TrimSpace(parts[3]), 10, 64)
-		if err != nil {
-			continue
-		}
+	// Files can contain the same job/metric pair twice (overlapping shards, appended reruns)
+	return MergeJobMetricData(data), warnings, scanErr(scanner)
+}
 
-		labelsStr := strings.TrimSpace(parts[2])
-		labels := strings.Split(labelsStr, ",")
+// parseJobMetricLine parses a single per-job record using the codec of the file's format version
+// It returns false when the line is unusable; recoverable problems are reported through warn.
+// The record's strings are copies or shared through names, never slices of line.
+func parseJobMetricLine(line string, codec fieldCodec, names Interner, warn func(format string, args ...interface{})) (JobMetricData, bool) {
+	parts := codec.split(line, '|')
+	if len(parts) < 4 {
+		warn("expected at least 4 '|'-separated fields, got %d", len(parts))
+		return JobMetricData{}, false
+	}
 
-		// Clean up labels
-		var cleanLabels []string
+	cardinalityStr := strings.TrimSpace(parts[3])
+	cardinality, err := strconv.ParseInt(cardinalityStr, 10, 64)
+	if err != nil {
+		warn("invalid cardinality %q", cardinalityStr)
+		return JobMetricData{}, false
+	}
+
+	jobName := codec.unescape(strings.TrimSpace(parts[0]))
+	metricName := codec.unescape(strings.TrimSpace(parts[1]))
+	if jobName == "" || metricName == "" {
+		warn("missing job or metric name")
+		return JobMetricData{}, false
+	}
+
+	// Clean up labels
+	var cleanLabels []string
+	if labelsField := strings.TrimSpace(parts[2]); labelsField != "" {
+		labels := codec.split(labelsField, ',')
+		cleanLabels = make([]string, 0, len(labels))
 		for _, label := range labels {
-			cleanLabel := strings.TrimSpace(label)
+			cleanLabel := codec.unescape(strings.TrimSpace(label))
 			if cleanLabel != "" {
-				cleanLabels = append(cleanLabels, cleanLabel)
+				cleanLabels = append(cleanLabels, names.Intern(cleanLabel))
 			}
 		}
+		if len(cleanLabels) == 0 {
+			cleanLabels = nil
+		}
+	}
 
-		// Parse per-label cardinality if present (5th column)
-		var labelCardinality map[string]int64
-		if len(parts) >= 5 && strings.TrimSpace(parts[4]) != "" {
-			labelCardinality = make(map[string]int64)
-			labelCardStr := strings.TrimSpace(parts[4])
-			// Format: label1:count1,label2:count2,...
-			labelCardParts := strings.Split(labelCardStr, ",")
-			for _, part := range labelCardParts {
-				kv := strings.Split(part, ":")
-				if len(kv) == 2 {
-					labelName := strings.TrimSpace(kv[0])
-					count, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
-					if err == nil {
-						labelCardinality[labelName] = count
-					}
-				}
+	// Parse per-label cardinality if present (5th column)
+	var labelCardinality map[string]int64
+	if len(parts) >= 5 && strings.TrimSpace(parts[4]) != "" {
+		// Format: label1:count1,label2:count2,...
+		entries := codec.split(strings.TrimSpace(parts[4]), ',')
+		labelCardinality = make(map[string]int64, len(entries))
+		for _, part := range entries {
+			kv := codec.split(part, ':')
+			if len(kv) != 2 {
+				warn("invalid label cardinality entry %q", part)
+				continue
+			}
+			count, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+			if err != nil {
+				warn("invalid label cardinality entry %q", part)
+				continue
 			}
+			labelCardinality[names.Intern(codec.unescape(strings.TrimSpace(kv[0])))] = count
 		}
+	}
 
-		data = append(data, JobMetricData{
-			Job:              strings.TrimSpace(parts[0]),
-			MetricName:       strings.TrimSpace(parts[1]),
-			Labels:           cleanLabels,
-			Cardinality:      cardinality,
-			LabelCardinality: labelCardinality,
-		})
+	// Parse metric type if present (6th column)
+	var metricType string
+	if len(parts) >= 6 {
+		metricType = names.Intern(codec.unescape(strings.TrimSpace(parts[5])))
+	}
+
+	// Parse sampled label values if present (7th column)
+	var labelValues map[string][]string
+	if len(parts) >= 7 && strings.TrimSpace(parts[6]) != "" {
+		// Format: label1:value1:value2,label2:value1,...
+		entries := codec.split(strings.TrimSpace(parts[6]), ',')
+		labelValues = make(map[string][]string, len(entries))
+		for _, part := range entries {
+			fields := codec.split(part, ':')
+			label := codec.unescape(strings.TrimSpace(fields[0]))
+			if label == "" || len(fields) < 2 {
+				warn("invalid label values entry %q", part)
+				continue
+			}
+			values := make([]string, 0, len(fields)-1)
+			for _, value := range fields[1:] {
+				values = append(values, names.Intern(codec.unescape(value)))
+			}
+			labelValues[names.Intern(label)] = values
+		}
 	}
 
-	return data, scanner.Err()
+	return JobMetricData{
+		Job:              names.Intern(jobName),
+		MetricName:       strings.Clone(metricName),
+		Labels:           cleanLabels,
+		Cardinality:      cardinality,
+		LabelCardinality: labelCardinality,
+		Type:             metricType,
+		LabelValues:      labelValues,
+	}, true
 }
 
 // ConvertJobMetricToCardinality converts JobMetricData to CardinalityData
@@ -189,6 +373,7 @@ func ConvertJobMetricToCardinality(jobData []JobMetricData) []CardinalityData {
 		data = append(data, CardinalityData{
 			MetricName: jm.MetricName,
 			Count:      jm.Cardinality,
+			Type:       jm.Type,
 		})
 	}
 	return data
@@ -199,9 +384,128 @@ func ConvertJobMetricToLabels(jobData []JobMetricData) []LabelsData {
 	var data []LabelsData
 	for _, jm := range jobData {
 		data = append(data, LabelsData{
-			MetricName: jm.MetricName,
-			Labels:     jm.Labels,
+			MetricName:  jm.MetricName,
+			Labels:      jm.Labels,
+			Type:        jm.Type,
+			LabelValues: jm.LabelValues,
 		})
 	}
 	return data
 }
+
+// ExceedsLineLimit reports whether a file has more than maxLines non-empty lines
+// It stops reading as soon as the limit is crossed, so it is cheap for huge files.
+func ExceedsLineLimit(filename string, maxLines int) (bool, error) {
+	if maxLines <= 0 {
+		return false, nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	return ReaderExceedsLineLimit(file, maxLines)
+}
+
+// ReaderExceedsLineLimit reports whether r has more than maxLines non-empty lines, like ExceedsLineLimit
+func ReaderExceedsLineLimit(r io.Reader, maxLines int) (bool, error) {
+	if maxLines <= 0 {
+		return false, nil
+	}
+
+	lines := 0
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		lines++
+		if lines > maxLines {
+			return true, nil
+		}
+	}
+
+	return false, scanErr(scanner)
+}
+
+// MergeJobMetricData collapses records for the same job and metric into one
+// Duplicates appear when collection shards overlap or a run is retried; the merged
+// record keeps the highest cardinality, the union of labels, the highest per-label
+// cardinality, the union of sampled label values and the first known type.
+// First-seen order is preserved.
+func MergeJobMetricData(jobData []JobMetricData) []JobMetricData {
+	type key struct{ job, metric string }
+	index := make(map[key]int, len(jobData))
+	merged := make([]JobMetricData, 0, len(jobData))
+
+	for _, jm := range jobData {
+		k := key{jm.Job, jm.MetricName}
+		i, seen := index[k]
+		if !seen {
+			index[k] = len(merged)
+			merged = append(merged, jm)
+			continue
+		}
+
+		existing := &merged[i]
+		if jm.Cardinality > existing.Cardinality {
+			existing.Cardinality = jm.Cardinality
+		}
+		existing.Labels = MergeLabels(existing.Labels, jm.Labels)
+		existing.LabelCardinality = MergeLabelCardinality(existing.LabelCardinality, jm.LabelCardinality)
+		existing.LabelValues = MergeLabelValues(existing.LabelValues, jm.LabelValues)
+		if existing.Type == "" {
+			existing.Type = jm.Type
+		}
+	}
+
+	return merged
+}
+
+// MergeLabels returns the union of two label lists, keeping the order of first appearance
+func MergeLabels(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var union []string
+	for _, labels := range [][]string{a, b} {
+		for _, label := range labels {
+			if !seen[label] {
+				seen[label] = true
+				union = append(union, label)
+			}
+		}
+	}
+	return union
+}
+
+// MergeLabelCardinality combines per-label cardinality maps, keeping the highest count per label
+func MergeLabelCardinality(a, b map[string]int64) map[string]int64 {
+	if len(b) == 0 {
+		return a
+	}
+	merged := make(map[string]int64, len(a)+len(b))
+	for label, count := range a {
+		merged[label] = count
+	}
+	for label, count := range b {
+		if count > merged[label] {
+			merged[label] = count
+		}
+	}
+	return merged
+}
+
+// MergeLabelValues combines sampled label values, keeping the union of each label's values
+func MergeLabelValues(a, b map[string][]string) map[string][]string {
+	if len(b) == 0 {
+		return a
+	}
+	merged := make(map[string][]string, len(a)+len(b))
+	for label, values := range a {
+		merged[label] = values
+	}
+	for label, values := range b {
+		merged[label] = MergeLabels(merged[label], values)
+	}
+	return merged
+}
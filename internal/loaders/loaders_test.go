@@ -1,8 +1,16 @@
 package loaders
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"unsafe"
 )
 
 func TestLoadCardinalityReport(t *testing.T) {
@@ -217,3 +225,338 @@ func TestLoadJobMetricReport_InvalidFile(t *testing.T) {
 		t.Error("Expected error for nonexistent file")
 	}
 }
+
+func TestLoadJobMetricReport_WithType(t *testing.T) {
+	content := `JOB|METRIC_NAME|LABELS|CARDINALITY|LABEL_CARDINALITY|TYPE
+api-service|http_requests_total|method,status|1500|method:5,status:3|counter
+api-service|process_open_fds|instance|10||gauge
+api-service|legacy_metric|instance|10|`
+
+	tmpFile, err := os.CreateTemp("", "test_job_metrics_*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	tmpFile.Close()
+
+	data, err := LoadJobMetricReport(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load job metric report: %v", err)
+	}
+
+	expectedTypes := []string{"counter", "gauge", ""}
+	if len(data) != len(expectedTypes) {
+		t.Fatalf("Expected %d items, got %d", len(expectedTypes), len(data))
+	}
+	for i, expected := range expectedTypes {
+		if data[i].Type != expected {
+			t.Errorf("Expected type '%s' for %s, got '%s'", expected, data[i].MetricName, data[i].Type)
+		}
+	}
+
+	if data[0].LabelCardinality["method"] != 5 {
+		t.Errorf("Expected method label cardinality 5, got %d", data[0].LabelCardinality["method"])
+	}
+
+	cardinalityData := ConvertJobMetricToCardinality(data)
+	if cardinalityData[0].Type != "counter" {
+		t.Errorf("Expected type to be carried into CardinalityData, got '%s'", cardinalityData[0].Type)
+	}
+	labelsData := ConvertJobMetricToLabels(data)
+	if labelsData[1].Type != "gauge" {
+		t.Errorf("Expected type to be carried into LabelsData, got '%s'", labelsData[1].Type)
+	}
+}
+
+func TestExceedsLineLimit(t *testing.T) {
+	content := "JOB|METRIC_NAME|LABELS|CARDINALITY\napi|m1||1\napi|m2||1\n\napi|m3||1\n"
+
+	tmpFile, err := os.CreateTemp("", "test_job_metrics_*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	tmpFile.Close()
+
+	tests := []struct {
+		name     string
+		maxLines int
+		want     bool
+	}{
+		{"disabled", 0, false},
+		{"under limit", 10, false},
+		{"at limit", 4, false},
+		{"over limit", 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExceedsLineLimit(tmpFile.Name(), tt.maxLines)
+			if err != nil {
+				t.Fatalf("ExceedsLineLimit() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ExceedsLineLimit(%d) = %v, want %v", tt.maxLines, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadJobMetricReportWithWarnings(t *testing.T) {
+	content := `JOB|METRIC_NAME|LABELS|CARDINALITY|LABEL_CARDINALITY|TYPE
+api-service|http_requests_total|method,status|1500|method:5,status|counter
+api-service|truncated_line
+# comment lines are ignored
+
+api-service|bad_cardinality|method|many||gauge
+|missing_job|method|10||gauge
+api-service|process_open_fds|instance|10||gauge`
+
+	tmpFile, err := os.CreateTemp("", "test_job_metrics_*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	tmpFile.Close()
+
+	data, warnings, err := LoadJobMetricReportWithWarnings(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load job metric report: %v", err)
+	}
+
+	if len(data) != 2 {
+		t.Errorf("Expected 2 valid records, got %d", len(data))
+	}
+
+	expected := []struct {
+		line    int
+		reason  string
+		skipped bool
+	}{
+		{2, `invalid label cardinality entry "status"`, false},
+		{3, "expected at least 4 '|'-separated fields, got 2", true},
+		{6, `invalid cardinality "many"`, true},
+		{7, "missing job or metric name", true},
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %d: %v", len(expected), len(warnings), warnings)
+	}
+	for i, want := range expected {
+		if warnings[i].Line != want.line || warnings[i].Reason != want.reason || warnings[i].Skipped != want.skipped {
+			t.Errorf("warning %d = line %d %q skipped %v, want line %d %q skipped %v",
+				i, warnings[i].Line, warnings[i].Reason, warnings[i].Skipped, want.line, want.reason, want.skipped)
+		}
+	}
+	if skipped := SkippedRecords(warnings); skipped != 3 {
+		t.Errorf("SkippedRecords() = %d, want 3", skipped)
+	}
+}
+
+func TestLoadJobMetricReport_MergesDuplicates(t *testing.T) {
+	content := `JOB|METRIC_NAME|LABELS|CARDINALITY|LABEL_CARDINALITY|TYPE
+api-service|http_requests_total|method|1500|method:5|
+api-service|process_open_fds|instance|10||gauge
+api-service|http_requests_total|method,status|1200|method:7,status:3|counter`
+
+	tmpFile, err := os.CreateTemp("", "test_job_metrics_*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	tmpFile.Close()
+
+	data, err := LoadJobMetricReport(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load job metric report: %v", err)
+	}
+
+	if len(data) != 2 {
+		t.Fatalf("Expected 2 merged records, got %d", len(data))
+	}
+
+	merged := data[0]
+	if merged.MetricName != "http_requests_total" || merged.Cardinality != 1500 {
+		t.Errorf("Expected http_requests_total with max cardinality 1500, got %s %d", merged.MetricName, merged.Cardinality)
+	}
+	if len(merged.Labels) != 2 || merged.Labels[1] != "status" {
+		t.Errorf("Expected label union [method status], got %v", merged.Labels)
+	}
+	if merged.LabelCardinality["method"] != 7 || merged.LabelCardinality["status"] != 3 {
+		t.Errorf("Expected max per-label cardinality, got %v", merged.LabelCardinality)
+	}
+	if merged.Type != "counter" {
+		t.Errorf("Expected first known type 'counter', got '%s'", merged.Type)
+	}
+}
+
+func TestReadJobMetricReport_LabelValues(t *testing.T) {
+	content := FileHeader() +
+		`api|http_requests_total|method,status|4|method:2,status:2|counter|method:GET:POST,status:OK :ok` + "\n" +
+		`api|http_requests_total|method,status|4|method:2,status:2|counter|status:ok:Ok,reason:a\:b` + "\n" +
+		`api|up|instance|1|instance:1|gauge|instance` + "\n"
+
+	data, warnings, err := ReadJobMetricReport(strings.NewReader(content), "api.txt")
+	if err != nil {
+		t.Fatalf("ReadJobMetricReport() error = %v", err)
+	}
+	if len(data) != 2 {
+		t.Fatalf("expected 2 merged records, got %d", len(data))
+	}
+	want := map[string][]string{"method": {"GET", "POST"}, "status": {"OK ", "ok", "Ok"}, "reason": {"a:b"}}
+	if !reflect.DeepEqual(data[0].LabelValues, want) {
+		t.Errorf("LabelValues = %q, want %q", data[0].LabelValues, want)
+	}
+	if len(data[1].LabelValues) != 0 {
+		t.Errorf("expected no values for an entry without any, got %q", data[1].LabelValues)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Reason, "invalid label values entry") {
+		t.Errorf("expected a warning for the entry without values, got %v", warnings)
+	}
+	if labels := ConvertJobMetricToLabels(data); !reflect.DeepEqual(labels[0].LabelValues, want) {
+		t.Errorf("expected values to be carried into LabelsData, got %q", labels[0].LabelValues)
+	}
+}
+
+func FuzzReadJobMetricReport(f *testing.F) {
+	f.Add("JOB|METRIC_NAME|LABELS|CARDINALITY\napi|http_requests_total|method,status|1500\n")
+	f.Add(FileHeader() + `batch\|nightly|up|a\,b,c|3|a\,b:2,c:1|gauge` + "\n")
+	f.Add(FileHeader() + "api|up||1\napi|up|job|2|job:1|counter\n")
+	f.Add("#format=v9\n" + ColumnHeader + "\n")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		data, _, err := ReadJobMetricReport(strings.NewReader(content), "fuzz.txt")
+		if err != nil {
+			return
+		}
+
+		seen := make(map[[2]string]bool)
+		for _, record := range data {
+			if record.Job == "" || record.MetricName == "" {
+				t.Fatalf("record without a job or metric name: %+v", record)
+			}
+			key := [2]string{record.Job, record.MetricName}
+			if seen[key] {
+				t.Fatalf("duplicate record for %q/%q was not merged", record.Job, record.MetricName)
+			}
+			seen[key] = true
+			for _, label := range record.Labels {
+				if label == "" {
+					t.Fatalf("empty label in %+v", record)
+				}
+			}
+		}
+	})
+}
+
+func FuzzLoadLabelsReport(f *testing.F) {
+	f.Add(`"http_requests_total"|"method,status,path"`)
+	f.Add("up|\n# comment\n\nbad line\nx|a,,b")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		filename := filepath.Join(t.TempDir(), "labels.txt")
+		if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := LoadLabelsReport(filename)
+		if err != nil {
+			return
+		}
+		for _, record := range data {
+			for _, label := range record.Labels {
+				if label == "" || label != strings.TrimSpace(label) {
+					t.Fatalf("label %q of %q is not trimmed", label, record.MetricName)
+				}
+			}
+		}
+	})
+}
+
+func TestReadJobMetricReport_SharesNames(t *testing.T) {
+	content := FileHeader() +
+		"api|http_requests_total|method,status|10|method:2|counter\n" +
+		"api|http_errors_total|method|3|method:2|counter\n"
+
+	data, _, err := ReadJobMetricReport(strings.NewReader(content), "api.txt")
+	if err != nil || len(data) != 2 {
+		t.Fatalf("ReadJobMetricReport() = %d records, %v", len(data), err)
+	}
+
+	first, second := data[0], data[1]
+	if unsafe.StringData(first.Job) != unsafe.StringData(second.Job) ||
+		unsafe.StringData(first.Labels[0]) != unsafe.StringData(second.Labels[0]) ||
+		unsafe.StringData(first.Type) != unsafe.StringData(second.Type) {
+		t.Error("expected records to share their job, label and type names")
+	}
+	if cap(first.Labels) != len(first.Labels) {
+		t.Errorf("labels have capacity %d for %d labels", cap(first.Labels), len(first.Labels))
+	}
+}
+
+func TestReadJobMetricReport_LongLine(t *testing.T) {
+	// Sampled values of many labels make a line longer than bufio's default 64 KiB
+	var values []string
+	for i := 0; i < 20000; i++ {
+		values = append(values, fmt.Sprintf("v%d", i))
+	}
+	content := FileHeader() + "api|http_requests_total|path|20000|path:20000|counter|path:" + strings.Join(values, ":") + "\n"
+	if len(content) < 100*1024 {
+		t.Fatalf("test line is only %d bytes", len(content))
+	}
+
+	data, _, err := ReadJobMetricReport(strings.NewReader(content), "api.txt")
+	if err != nil {
+		t.Fatalf("ReadJobMetricReport() error = %v", err)
+	}
+	if len(data) != 1 || len(data[0].LabelValues["path"]) != 20000 {
+		t.Fatalf("ReadJobMetricReport() = %d records, want 1 with 20000 path values", len(data))
+	}
+
+	SetMaxLineBytes(64 * 1024)
+	defer SetMaxLineBytes(0)
+	if _, _, err := ReadJobMetricReport(strings.NewReader(content), "api.txt"); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("ReadJobMetricReport() over the line limit error = %v, want bufio.ErrTooLong", err)
+	}
+}
+
+// cancelAfterRead cancels its context once the first chunk was read
+type cancelAfterRead struct {
+	r      *strings.Reader
+	cancel context.CancelFunc
+}
+
+func (c *cancelAfterRead) Read(p []byte) (int, error) {
+	defer c.cancel()
+	return c.r.Read(p[:16])
+}
+
+func TestContextReader(t *testing.T) {
+	content := "JOB|METRIC_NAME|LABELS|CARDINALITY\napi|m1||1\napi|m2||1\napi|m3||1\n"
+
+	data, _, err := ReadJobMetricReport(ContextReader(context.Background(), strings.NewReader(content)), "api.txt")
+	if err != nil || len(data) != 3 {
+		t.Fatalf("ReadJobMetricReport() = %d records, %v, want 3", len(data), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := ContextReader(ctx, &cancelAfterRead{r: strings.NewReader(content), cancel: cancel})
+	if _, _, err := ReadJobMetricReport(r, "api.txt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadJobMetricReport() once cancelled error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,78 @@
+package loaders
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Metric usage reports sit next to the per-job files of an analysis run and list every
+// metric referenced by a dashboard or Prometheus rule, with the references, or found in
+// a query log, with the number of queries. Metrics missing from the report are not used
+// anywhere that was scanned.
+
+const (
+	// MetricUsageFileName is the metric usage report written into a job metrics directory
+	MetricUsageFileName = "metric_usage.report"
+	// MetricUsageColumnHeader names the metric usage report columns
+	MetricUsageColumnHeader = "METRIC_NAME|REFERENCES|QUERY_COUNT"
+
+	legacyMetricUsageColumnHeader = "METRIC_NAME|REFERENCES"
+)
+
+// MetricUsageData lists where one metric is referenced
+type MetricUsageData struct {
+	MetricName string
+	References []string // e.g. "dashboard:API Overview", "alert:HighErrorRate", "record:job:rate5m"
+	QueryCount int64    // Queries selecting the metric in the ingested query logs
+}
+
+// FormatMetricUsageLine renders a record as a metric usage report line
+func FormatMetricUsageLine(data MetricUsageData) string {
+	return fmt.Sprintf("%s|%s|%d\n", EscapeField(data.MetricName), JoinEscaped(data.References, ","), data.QueryCount)
+}
+
+// LoadMetricUsageReport loads a metric usage report, skipping malformed lines
+// Reports written before query logs were supported have no QUERY_COUNT column.
+func LoadMetricUsageReport(filename string) ([]MetricUsageData, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadMetricUsageReport(file)
+}
+
+// ReadMetricUsageReport parses a metric usage report from r, skipping malformed lines
+func ReadMetricUsageReport(r io.Reader) ([]MetricUsageData, error) {
+	var data []MetricUsageData
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == MetricUsageColumnHeader || line == legacyMetricUsageColumnHeader {
+			continue
+		}
+
+		parts := splitEscaped(line, '|')
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			continue
+		}
+		record := MetricUsageData{MetricName: unescapeField(parts[0])}
+		if len(parts) == 3 {
+			count, err := strconv.ParseInt(strings.TrimSpace(parts[2]), 10, 64)
+			if err != nil {
+				continue
+			}
+			record.QueryCount = count
+		}
+		if parts[1] != "" {
+			for _, reference := range splitEscaped(parts[1], ',') {
+				record.References = append(record.References, unescapeField(reference))
+			}
+		}
+		data = append(data, record)
+	}
+	return data, scanErr(scanner)
+}
@@ -0,0 +1,36 @@
+package loaders
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadMetricUsageReport(t *testing.T) {
+	want := []MetricUsageData{
+		{MetricName: "http_requests_total", References: []string{"alert:HighErrorRate", "dashboard:API, Overview"}},
+		{MetricName: "queue_depth", References: []string{"record:job:queue_depth:max"}, QueryCount: 42},
+		{MetricName: "adhoc_total", QueryCount: 3},
+		{MetricName: "orphan"},
+	}
+	content := MetricUsageColumnHeader + "\n"
+	for _, usage := range want {
+		content += FormatMetricUsageLine(usage)
+	}
+	content += "malformed|line|extra\n"
+	content += "legacy_total|dashboard\\:Old\n"
+	want = append(want, MetricUsageData{MetricName: "legacy_total", References: []string{"dashboard:Old"}})
+
+	path := filepath.Join(t.TempDir(), MetricUsageFileName)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadMetricUsageReport(path)
+	if err != nil {
+		t.Fatalf("LoadMetricUsageReport() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadMetricUsageReport() = %+v, want %+v", got, want)
+	}
+}
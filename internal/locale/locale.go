@@ -0,0 +1,207 @@
+package locale
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Locale formats numbers and dates and translates the text of human-readable reports
+// JSON and other machine-readable outputs are never localized.
+type Locale struct {
+	Tag        string            `yaml:"locale"`              // BCP 47 language tag, e.g. de or pt-BR
+	Thousands  string            `yaml:"thousands_separator"` // Separator between groups of three digits
+	Decimal    string            `yaml:"decimal_separator"`
+	DateFormat string            `yaml:"date_format"` // Go time layout
+	Messages   map[string]string `yaml:"messages"`    // English text or message key -> translation
+}
+
+// Default is the English locale reports used before localization
+var Default = &Locale{
+	Tag:        "en",
+	Thousands:  ",",
+	Decimal:    ".",
+	DateFormat: "Jan 2, 2006 15:04 MST",
+}
+
+// builtin are the locales available without a catalog file
+var builtin = map[string]*Locale{
+	"en": Default,
+	"de": {
+		Tag:        "de",
+		Thousands:  ".",
+		Decimal:    ",",
+		DateFormat: "02.01.2006 15:04 MST",
+		Messages: map[string]string{
+			"Excellent":         "Ausgezeichnet",
+			"Good":              "Gut",
+			"Needs Improvement": "Verbesserungswürdig",
+			"Needs Work":        "Verbesserungswürdig",
+			"Poor":              "Mangelhaft",
+		},
+	},
+	"fr": {
+		Tag:        "fr",
+		Thousands:  "\u202f", // Narrow no-break space, so numbers do not wrap
+		Decimal:    ",",
+		DateFormat: "02/01/2006 15:04 MST",
+		Messages: map[string]string{
+			"Excellent":         "Excellent",
+			"Good":              "Bon",
+			"Needs Improvement": "À améliorer",
+			"Needs Work":        "À améliorer",
+			"Poor":              "Insuffisant",
+		},
+	},
+	"es": {
+		Tag:        "es",
+		Thousands:  ".",
+		Decimal:    ",",
+		DateFormat: "02/01/2006 15:04 MST",
+		Messages: map[string]string{
+			"Excellent":         "Excelente",
+			"Good":              "Bueno",
+			"Needs Improvement": "Mejorable",
+			"Needs Work":        "Mejorable",
+			"Poor":              "Deficiente",
+		},
+	},
+}
+
+// Get returns the built-in locale for a language tag, matching de-DE to de
+func Get(tag string) (*Locale, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	if l, ok := builtin[normalized]; ok {
+		return l, nil
+	}
+	if language, _, found := strings.Cut(normalized, "-"); found {
+		if l, ok := builtin[language]; ok {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown locale %q (built in: en, de, fr, es; use a catalog file for others)", tag)
+}
+
+// Load returns the locale for tag, with the settings and messages of an optional catalog file
+// layered over it. A catalog naming a locale that is not built in starts from English formatting.
+func Load(tag, catalogFile string) (*Locale, error) {
+	if catalogFile == "" {
+		return Get(tag)
+	}
+
+	data, err := os.ReadFile(catalogFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read locale catalog: %w", err)
+	}
+	var catalog Locale
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse locale catalog %s: %w", catalogFile, err)
+	}
+	if catalog.Tag == "" {
+		catalog.Tag = tag
+	}
+
+	base, err := Get(catalog.Tag)
+	if err != nil {
+		base = Default
+	}
+	merged := *base
+	merged.Tag = catalog.Tag
+	if catalog.Thousands != "" {
+		merged.Thousands = catalog.Thousands
+	}
+	if catalog.Decimal != "" {
+		merged.Decimal = catalog.Decimal
+	}
+	if catalog.DateFormat != "" {
+		merged.DateFormat = catalog.DateFormat
+	}
+	merged.Messages = make(map[string]string, len(base.Messages)+len(catalog.Messages))
+	for message, translation := range base.Messages {
+		merged.Messages[message] = translation
+	}
+	for message, translation := range catalog.Messages {
+		merged.Messages[message] = translation
+	}
+	return &merged, nil
+}
+
+// T translates a message, returning it unchanged when the catalog has no translation
+func (l *Locale) T(message string) string {
+	if translation, ok := l.Messages[message]; ok && translation != "" {
+		return translation
+	}
+	return message
+}
+
+// Message translates text by its catalog key, falling back to translating the text itself
+// Keys let rule authors translate long texts, such as rule descriptions, without repeating them.
+func (l *Locale) Message(key, text string) string {
+	if key != "" {
+		if translation, ok := l.Messages[key]; ok && translation != "" {
+			return translation
+		}
+	}
+	return l.T(text)
+}
+
+// Int formats an integer with thousands separators
+func (l *Locale) Int(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	return sign + l.group(digits)
+}
+
+// Float formats a number with a fixed number of decimals and thousands separators
+func (l *Locale) Float(f float64, decimals int) string {
+	formatted := strconv.FormatFloat(f, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(formatted, ".")
+	if !hasFraction {
+		return sign + l.group(whole)
+	}
+	return sign + l.group(whole) + l.Decimal + fraction
+}
+
+// group inserts thousands separators into a string of digits
+func (l *Locale) group(digits string) string {
+	if len(digits) <= 3 || l.Thousands == "" {
+		return digits
+	}
+	var grouped strings.Builder
+	first := len(digits) % 3
+	if first > 0 {
+		grouped.WriteString(digits[:first])
+	}
+	for i := first; i < len(digits); i += 3 {
+		if grouped.Len() > 0 {
+			grouped.WriteString(l.Thousands)
+		}
+		grouped.WriteString(digits[i : i+3])
+	}
+	return grouped.String()
+}
+
+// Date formats a time with the locale's date format
+func (l *Locale) Date(t time.Time) string {
+	return t.Format(l.DateFormat)
+}
+
+// DateString formats an RFC 3339 timestamp, returning any other string unchanged
+func (l *Locale) DateString(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return l.Date(t)
+}
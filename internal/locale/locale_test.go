@@ -0,0 +1,126 @@
+package locale
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLocale_Numbers(t *testing.T) {
+	german, err := Get("de-DE")
+	if err != nil {
+		t.Fatalf("Get(de-DE) error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"small int", Default.Int(999), "999"},
+		{"grouped int", Default.Int(1234567), "1,234,567"},
+		{"negative int", Default.Int(-1234), "-1,234"},
+		{"float", Default.Float(12345.678, 2), "12,345.68"},
+		{"no decimals", Default.Float(1000, 0), "1,000"},
+		{"german int", german.Int(1234567), "1.234.567"},
+		{"german float", german.Float(87.5, 1), "87,5"},
+		{"german grouped float", german.Float(-4321.5, 2), "-4.321,50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocale_Dates(t *testing.T) {
+	french, err := Get("fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2025, 11, 2, 16, 0, 0, 0, time.UTC)
+
+	if got := Default.Date(date); got != "Nov 2, 2025 16:00 UTC" {
+		t.Errorf("Default.Date() = %q", got)
+	}
+	if got := french.DateString("2025-11-02T16:00:00Z"); got != "02/11/2025 16:00 UTC" {
+		t.Errorf("DateString() = %q", got)
+	}
+	if got := french.DateString("20251102_160000"); got != "20251102_160000" {
+		t.Errorf("DateString() of a non-RFC 3339 string = %q, want it unchanged", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	catalog := filepath.Join(t.TempDir(), "pt-BR.yaml")
+	content := `locale: pt-BR
+thousands_separator: "."
+decimal_separator: ","
+messages:
+  Excellent: Excelente
+  Poor: Ruim
+`
+	if err := os.WriteFile(catalog, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Load("en", catalog)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if l.Tag != "pt-BR" {
+		t.Errorf("Tag = %q, want pt-BR", l.Tag)
+	}
+	if got := l.Float(1234.5, 1); got != "1.234,5" {
+		t.Errorf("Float() = %q, want 1.234,5", got)
+	}
+	if l.DateFormat != Default.DateFormat {
+		t.Errorf("DateFormat = %q, want the English default", l.DateFormat)
+	}
+	if l.T("Excellent") != "Excelente" || l.T("Good") != "Good" {
+		t.Errorf("T() = %q, %q, want Excelente and the untranslated Good", l.T("Excellent"), l.T("Good"))
+	}
+
+	// A catalog for a built-in locale overrides only what it sets
+	override := filepath.Join(t.TempDir(), "de.yaml")
+	if err := os.WriteFile(override, []byte("messages:\n  Poor: Schwach\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l, err = Load("de", override)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if l.T("Poor") != "Schwach" || l.T("Good") != "Gut" || l.Decimal != "," {
+		t.Errorf("merged locale = %+v", l)
+	}
+	if builtin["de"].Messages["Poor"] != "Mangelhaft" {
+		t.Error("Load() modified the built-in locale")
+	}
+
+	if _, err := Load("xx", ""); err == nil {
+		t.Error("Load() of an unknown locale without a catalog should fail")
+	}
+}
+
+func TestLocale_Message(t *testing.T) {
+	l := &Locale{Messages: map[string]string{
+		"rules.cardinality.title": "Hohe Kardinalität",
+		"Too Many Labels":         "Zu viele Labels",
+	}}
+	tests := []struct {
+		key, text, want string
+	}{
+		{"rules.cardinality.title", "High Cardinality", "Hohe Kardinalität"},
+		{"rules.labels.title", "Too Many Labels", "Zu viele Labels"},
+		{"", "Too Many Labels", "Zu viele Labels"},
+		{"rules.missing", "Unused Metric", "Unused Metric"},
+	}
+	for _, tt := range tests {
+		if got := l.Message(tt.key, tt.text); got != tt.want {
+			t.Errorf("Message(%q, %q) = %q, want %q", tt.key, tt.text, got, tt.want)
+		}
+	}
+}
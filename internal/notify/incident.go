@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Environment variables holding the credentials of the incident integrations
+const (
+	PagerDutyRoutingKeyEnv = "PAGERDUTY_ROUTING_KEY"
+	OpsgenieAPIKeyEnv      = "OPSGENIE_API_KEY"
+)
+
+// API endpoints incidents are sent to by default
+const (
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	OpsgenieAlertsURL  = "https://api.opsgenie.com/v2/alerts" // https://api.eu.opsgenie.com/v2/alerts for EU accounts
+)
+
+// opsgenieMessageLimit is the longest alert message Opsgenie accepts
+const opsgenieMessageLimit = 130
+
+// Incident is a severe regression raised in an on-call system
+type Incident struct {
+	Summary   string            // One line, e.g. "Instrumentation score regressed: average score dropped 12.0 points"
+	Details   map[string]string // Shown with the incident
+	DedupKey  string            // Incidents with the same key are merged into one
+	Source    string            // What raised the incident, e.g. the evaluated job directory
+	ReportURL string            // Linked from the incident when set
+}
+
+// Alerter raises incidents in an on-call system
+type Alerter interface {
+	Name() string
+	Trigger(incident Incident) error
+}
+
+// PagerDuty triggers incidents through the PagerDuty Events API v2
+type PagerDuty struct {
+	RoutingKey string // Integration key of the service to page
+	URL        string
+	Client     *http.Client
+	Attempts   int
+	RetryDelay time.Duration
+}
+
+// NewPagerDuty returns a PagerDuty alerter for the service with routingKey
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		RoutingKey: routingKey,
+		URL:        PagerDutyEventsURL,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		Attempts:   3,
+		RetryDelay: time.Second,
+	}
+}
+
+// Name returns "PagerDuty"
+func (p *PagerDuty) Name() string {
+	return "PagerDuty"
+}
+
+// Trigger sends a trigger event for incident
+func (p *PagerDuty) Trigger(incident Incident) error {
+	type link struct {
+		Href string `json:"href"`
+		Text string `json:"text"`
+	}
+	event := struct {
+		RoutingKey  string `json:"routing_key"`
+		EventAction string `json:"event_action"`
+		DedupKey    string `json:"dedup_key,omitempty"`
+		Payload     struct {
+			Summary       string            `json:"summary"`
+			Source        string            `json:"source"`
+			Severity      string            `json:"severity"`
+			CustomDetails map[string]string `json:"custom_details,omitempty"`
+		} `json:"payload"`
+		Links []link `json:"links,omitempty"`
+	}{RoutingKey: p.RoutingKey, EventAction: "trigger", DedupKey: incident.DedupKey}
+	event.Payload.Summary = incident.Summary
+	event.Payload.Source = incident.Source
+	event.Payload.Severity = "critical"
+	event.Payload.CustomDetails = incident.Details
+	if incident.ReportURL != "" {
+		event.Links = []link{{Href: incident.ReportURL, Text: "Instrumentation score report"}}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return deliver(p.Client, p.Attempts, p.RetryDelay, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", p.URL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
+
+// Opsgenie creates alerts through the Opsgenie Alert API
+type Opsgenie struct {
+	APIKey     string // Key of an API integration
+	URL        string
+	Client     *http.Client
+	Attempts   int
+	RetryDelay time.Duration
+}
+
+// NewOpsgenie returns an Opsgenie alerter for the API integration with apiKey
+func NewOpsgenie(apiKey string) *Opsgenie {
+	return &Opsgenie{
+		APIKey:     apiKey,
+		URL:        OpsgenieAlertsURL,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		Attempts:   3,
+		RetryDelay: time.Second,
+	}
+}
+
+// Name returns "Opsgenie"
+func (o *Opsgenie) Name() string {
+	return "Opsgenie"
+}
+
+// Trigger creates a P2 alert for incident
+func (o *Opsgenie) Trigger(incident Incident) error {
+	message := incident.Summary
+	if len(message) > opsgenieMessageLimit {
+		message = message[:opsgenieMessageLimit-3] + "..."
+	}
+	details := incident.Details
+	if incident.ReportURL != "" {
+		details = make(map[string]string, len(incident.Details)+1)
+		for name, value := range incident.Details {
+			details[name] = value
+		}
+		details["report_url"] = incident.ReportURL
+	}
+	alert := struct {
+		Message     string            `json:"message"`
+		Alias       string            `json:"alias,omitempty"`
+		Description string            `json:"description,omitempty"`
+		Source      string            `json:"source,omitempty"`
+		Priority    string            `json:"priority"`
+		Details     map[string]string `json:"details,omitempty"`
+	}{
+		Message:     message,
+		Alias:       incident.DedupKey,
+		Description: incident.Summary,
+		Source:      incident.Source,
+		Priority:    "P2",
+		Details:     details,
+	}
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return deliver(o.Client, o.Attempts, o.RetryDelay, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", o.URL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "GenieKey "+o.APIKey)
+		return req, nil
+	})
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testIncident = Incident{
+	Summary:   "Instrumentation score regressed: average score dropped 20.0 points, from 90.0 to 70.0",
+	Details:   map[string]string{"average_score": "70.0"},
+	DedupKey:  "instrumentation-score-regression",
+	Source:    "reports/job_metrics_20251102_160000",
+	ReportURL: "https://reports.example.com/latest.html",
+}
+
+func TestPagerDuty_Trigger(t *testing.T) {
+	var event map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	pagerDuty := NewPagerDuty("routing-key")
+	pagerDuty.URL = server.URL
+	if err := pagerDuty.Trigger(testIncident); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+
+	payload, _ := event["payload"].(map[string]interface{})
+	if event["routing_key"] != "routing-key" || event["event_action"] != "trigger" || event["dedup_key"] != testIncident.DedupKey {
+		t.Errorf("unexpected event %v", event)
+	}
+	if payload["summary"] != testIncident.Summary || payload["severity"] != "critical" || payload["source"] != testIncident.Source {
+		t.Errorf("unexpected payload %v", payload)
+	}
+	if links, _ := event["links"].([]interface{}); len(links) != 1 {
+		t.Errorf("links = %v, want the report URL", event["links"])
+	}
+}
+
+func TestOpsgenie_Trigger(t *testing.T) {
+	var alert map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "GenieKey api-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	opsgenie := NewOpsgenie("api-key")
+	opsgenie.URL = server.URL
+	incident := testIncident
+	incident.Summary = strings.Repeat("x", 200)
+	if err := opsgenie.Trigger(incident); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+
+	if message, _ := alert["message"].(string); len(message) != opsgenieMessageLimit {
+		t.Errorf("message has %d characters, want it truncated to %d", len(message), opsgenieMessageLimit)
+	}
+	if alert["alias"] != testIncident.DedupKey || alert["priority"] != "P2" || alert["description"] != incident.Summary {
+		t.Errorf("unexpected alert %v", alert)
+	}
+	if details, _ := alert["details"].(map[string]interface{}); details["report_url"] != testIncident.ReportURL || details["average_score"] != "70.0" {
+		t.Errorf("details = %v", alert["details"])
+	}
+	if _, ok := testIncident.Details["report_url"]; ok {
+		t.Error("Trigger() modified the incident's details")
+	}
+}
+
+func TestOpsgenie_TriggerRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	opsgenie := NewOpsgenie("wrong-key")
+	opsgenie.URL = server.URL
+	if err := opsgenie.Trigger(testIncident); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Trigger() error = %v, want HTTP 401", err)
+	}
+}
@@ -0,0 +1,236 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Standard OpenTelemetry exporter settings read from the environment
+const (
+	OTLPEndpointEnv     = "OTEL_EXPORTER_OTLP_ENDPOINT"      // Base URL; logs are sent to <endpoint>/v1/logs
+	OTLPLogsEndpointEnv = "OTEL_EXPORTER_OTLP_LOGS_ENDPOINT" // Full logs URL, overriding OTLPEndpointEnv
+	OTLPHeadersEnv      = "OTEL_EXPORTER_OTLP_HEADERS"       // key1=value1,key2=value2, e.g. the backend's API key
+)
+
+// RunEventName is the event.name attribute of run log records
+const RunEventName = "instrumentation_score.run"
+
+// OTLPLogs exports one OTLP log record per run over OTLP/HTTP with JSON encoding,
+// making evaluation runs queryable alongside other platform events
+type OTLPLogs struct {
+	URL         string
+	Headers     map[string]string
+	ServiceName string // service.name resource attribute
+	Client      *http.Client
+	Attempts    int
+	RetryDelay  time.Duration
+}
+
+// NewOTLPLogs returns an exporter posting to the OTLP logs url with headers
+func NewOTLPLogs(url string, headers map[string]string) *OTLPLogs {
+	return &OTLPLogs{
+		URL:         url,
+		Headers:     headers,
+		ServiceName: "instrumentation-score",
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		Attempts:    3,
+		RetryDelay:  time.Second,
+	}
+}
+
+// OTLPLogsURL returns the logs URL configured by the standard OTLP environment variables, or ""
+func OTLPLogsURL(getenv func(string) string) string {
+	if logsURL := getenv(OTLPLogsEndpointEnv); logsURL != "" {
+		return logsURL
+	}
+	if endpoint := getenv(OTLPEndpointEnv); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/logs"
+	}
+	return ""
+}
+
+// ParseOTLPHeaders parses the key1=value1,key2=value2 format of OTEL_EXPORTER_OTLP_HEADERS
+// Values are URL-decoded, as the OpenTelemetry specification requires.
+func ParseOTLPHeaders(value string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: expected key=value", OTLPHeadersEnv, pair)
+		}
+		decoded, err := url.QueryUnescape(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value for %s: %w", OTLPHeadersEnv, name, err)
+		}
+		headers[strings.TrimSpace(name)] = decoded
+	}
+	return headers, nil
+}
+
+// Export sends summary as a log record, of severity ERROR for failed runs and WARN for
+// runs with regressions
+func (o *OTLPLogs) Export(summary RunSummary) error {
+	body, err := json.Marshal(o.logsRequest(summary, time.Now()))
+	if err != nil {
+		return err
+	}
+	err = deliver(o.Client, o.Attempts, o.RetryDelay, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", o.URL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range o.Headers {
+			req.Header.Set(name, value)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("OTLP logs %s: %w", o.URL, err)
+	}
+	return nil
+}
+
+// otlpAnyValue is an OTLP AnyValue; exactly one field is set
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"` // int64 is a decimal string in OTLP JSON
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpResourceLogs struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+// otlpLogsRequest is the body of an OTLP/HTTP logs export request
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+// Severity numbers of the OpenTelemetry log data model
+const (
+	severityInfo  = 9
+	severityWarn  = 13
+	severityError = 17
+)
+
+// logsRequest builds the export request carrying summary's log record, observed at now
+func (o *OTLPLogs) logsRequest(summary RunSummary, now time.Time) otlpLogsRequest {
+	record := otlpLogRecord{
+		ObservedTimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+		SeverityNumber:       severityInfo,
+		SeverityText:         "INFO",
+		Body:                 stringValue(fmt.Sprintf("Evaluated %d jobs, average score %.1f", summary.TotalJobs, summary.AverageScore)),
+	}
+	record.TimeUnixNano = record.ObservedTimeUnixNano
+	if finished, err := time.Parse(time.RFC3339, summary.Timestamp); err == nil {
+		record.TimeUnixNano = strconv.FormatInt(finished.UnixNano(), 10)
+	}
+
+	attributes := []otlpKeyValue{
+		{"event.name", stringValue(RunEventName)},
+		{"run.status", stringValue(summary.Status)},
+		{"run.duration_seconds", doubleValue(summary.Duration)},
+	}
+	switch {
+	case summary.Status == StatusFailure:
+		record.SeverityNumber, record.SeverityText = severityError, "ERROR"
+		record.Body = stringValue("Evaluation failed: " + summary.Error)
+		attributes = append(attributes, otlpKeyValue{"error.message", stringValue(summary.Error)})
+	case len(summary.Regressions) > 0:
+		record.SeverityNumber, record.SeverityText = severityWarn, "WARN"
+	}
+	if summary.Status != StatusFailure {
+		attributes = append(attributes,
+			otlpKeyValue{"score.average", doubleValue(summary.AverageScore)},
+			otlpKeyValue{"jobs.total", intValue(int64(summary.TotalJobs))},
+			otlpKeyValue{"cardinality.total", intValue(summary.TotalCardinality)},
+		)
+	}
+	if summary.OrgScoreWeighting != "" {
+		attributes = append(attributes,
+			otlpKeyValue{"score.organization", doubleValue(summary.OrgScore)},
+			otlpKeyValue{"score.organization_weighting", stringValue(summary.OrgScoreWeighting)},
+		)
+	}
+	if summary.MinScore > 0 {
+		attributes = append(attributes, otlpKeyValue{"jobs.below_min_score", intValue(int64(len(summary.JobsBelowMin)))})
+	}
+	if len(summary.Regressions) > 0 {
+		attributes = append(attributes, otlpKeyValue{"regressions", arrayValue(summary.Regressions)})
+	}
+	if len(summary.Warnings) > 0 {
+		attributes = append(attributes, otlpKeyValue{"warnings", arrayValue(summary.Warnings)})
+	}
+	if summary.ReportURL != "" {
+		attributes = append(attributes, otlpKeyValue{"report.url", stringValue(summary.ReportURL)})
+	}
+	record.Attributes = attributes
+
+	var scopeLogs otlpScopeLogs
+	scopeLogs.Scope.Name = "instrumentation-score"
+	scopeLogs.LogRecords = []otlpLogRecord{record}
+	var resourceLogs otlpResourceLogs
+	resourceLogs.Resource.Attributes = []otlpKeyValue{{"service.name", stringValue(o.ServiceName)}}
+	resourceLogs.ScopeLogs = []otlpScopeLogs{scopeLogs}
+	return otlpLogsRequest{ResourceLogs: []otlpResourceLogs{resourceLogs}}
+}
+
+func stringValue(s string) otlpAnyValue {
+	return otlpAnyValue{StringValue: &s}
+}
+
+func intValue(i int64) otlpAnyValue {
+	s := strconv.FormatInt(i, 10)
+	return otlpAnyValue{IntValue: &s}
+}
+
+func doubleValue(f float64) otlpAnyValue {
+	return otlpAnyValue{DoubleValue: &f}
+}
+
+func arrayValue(values []string) otlpAnyValue {
+	array := &otlpArrayValue{Values: make([]otlpAnyValue, len(values))}
+	for i, value := range values {
+		array.Values[i] = stringValue(value)
+	}
+	return otlpAnyValue{ArrayValue: array}
+}
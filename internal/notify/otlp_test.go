@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOTLPLogsExport(t *testing.T) {
+	var received otlpLogsRequest
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		apiKey = r.Header.Get("Api-Key")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPLogs(server.URL+"/v1/logs", map[string]string{"Api-Key": "secret"})
+	err := exporter.Export(RunSummary{
+		Status:           StatusSuccess,
+		Timestamp:        "2026-03-01T10:00:00Z",
+		TotalJobs:        3,
+		AverageScore:     71.5,
+		TotalCardinality: 12000,
+		Regressions:      []string{"job api dropped 5.0 points since the baseline, from 80.0 to 75.0"},
+	})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if apiKey != "secret" {
+		t.Errorf("Api-Key header = %q, want the configured header", apiKey)
+	}
+
+	if len(received.ResourceLogs) != 1 || len(received.ResourceLogs[0].ScopeLogs) != 1 {
+		t.Fatalf("received %+v, want one resource and scope", received)
+	}
+	records := received.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 1 {
+		t.Fatalf("records = %d, want 1", len(records))
+	}
+	record := records[0]
+	if record.SeverityText != "WARN" || record.TimeUnixNano != "1772359200000000000" {
+		t.Errorf("record = %+v, want a WARN record at the run's timestamp", record)
+	}
+	attributes := make(map[string]otlpAnyValue)
+	for _, attribute := range record.Attributes {
+		attributes[attribute.Key] = attribute.Value
+	}
+	if v := attributes["event.name"].StringValue; v == nil || *v != RunEventName {
+		t.Errorf("event.name = %v", v)
+	}
+	if v := attributes["jobs.total"].IntValue; v == nil || *v != "3" {
+		t.Errorf("jobs.total = %v, want \"3\"", v)
+	}
+	if v := attributes["score.average"].DoubleValue; v == nil || *v != 71.5 {
+		t.Errorf("score.average = %v", v)
+	}
+	if v := attributes["regressions"].ArrayValue; v == nil || len(v.Values) != 1 {
+		t.Errorf("regressions = %+v", v)
+	}
+}
+
+func TestOTLPLogsRequest_Failure(t *testing.T) {
+	request := NewOTLPLogs("", nil).logsRequest(RunSummary{Status: StatusFailure, Error: "no job files"}, time.Unix(0, 0))
+	record := request.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if record.SeverityNumber != severityError || *record.Body.StringValue != "Evaluation failed: no job files" {
+		t.Errorf("record = %+v, want an ERROR record", record)
+	}
+	for _, attribute := range record.Attributes {
+		if attribute.Key == "score.average" {
+			t.Error("failed runs should not report a score")
+		}
+	}
+}
+
+func TestOTLPLogsURL(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{OTLPEndpointEnv: "http://collector:4318/"}, "http://collector:4318/v1/logs"},
+		{map[string]string{OTLPEndpointEnv: "http://collector:4318", OTLPLogsEndpointEnv: "https://logs.example.com/otlp"}, "https://logs.example.com/otlp"},
+	}
+	for _, tt := range tests {
+		if got := OTLPLogsURL(func(name string) string { return tt.env[name] }); got != tt.want {
+			t.Errorf("OTLPLogsURL(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestParseOTLPHeaders(t *testing.T) {
+	got, err := ParseOTLPHeaders("api-key=abc%3D%3D, x-tenant = platform,")
+	if err != nil {
+		t.Fatalf("ParseOTLPHeaders() error = %v", err)
+	}
+	if want := map[string]string{"api-key": "abc==", "x-tenant": "platform"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseOTLPHeaders() = %v, want %v", got, want)
+	}
+	if _, err := ParseOTLPHeaders("no-value"); err == nil {
+		t.Error("expected an error for an entry without =")
+	}
+}
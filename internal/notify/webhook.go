@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SecretEnv holds the secret callback payloads are signed with
+const SecretEnv = "INSTRUMENTATION_SCORE_CALLBACK_SECRET"
+
+// Headers sent with every callback
+const (
+	SignatureHeader = "X-Instrumentation-Score-Signature" // "sha256=" and the hex HMAC of timestamp "." body
+	TimestampHeader = "X-Instrumentation-Score-Timestamp" // Unix seconds the payload was signed at
+	EventHeader     = "X-Instrumentation-Score-Event"     // Always "run.completed"
+)
+
+// Run statuses reported in RunSummary.Status
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// RunSummary is the JSON body posted when a run finishes
+type RunSummary struct {
+	Status            string       `json:"status"`
+	Error             string       `json:"error,omitempty"` // Why a failed run stopped
+	Timestamp         string       `json:"timestamp"`
+	Duration          float64      `json:"duration_seconds"`
+	TotalJobs         int          `json:"total_jobs"`
+	AverageScore      float64      `json:"average_score"`
+	OrgScore          float64      `json:"organization_score,omitempty"`
+	OrgScoreWeighting string       `json:"organization_score_weighting,omitempty"` // jobs, cardinality or team_size
+	TotalCardinality  int64        `json:"total_cardinality"`
+	MinScore          float64      `json:"min_score,omitempty"`
+	JobsBelowMin      []JobScore   `json:"jobs_below_min_score,omitempty"`
+	Teams             []TeamDigest `json:"teams,omitempty"` // Per-team digests, with --ownership
+	ReportURL         string       `json:"report_url,omitempty"`
+	Warnings          []string     `json:"warnings,omitempty"`
+	Regressions       []string     `json:"regressions,omitempty"` // Score drops since the --previous-report baseline
+}
+
+// JobScore is a job's score in a RunSummary
+type JobScore struct {
+	JobName  string   `json:"job_name"`
+	Score    float64  `json:"score"`
+	Team     string   `json:"team,omitempty"`
+	Contacts []string `json:"contacts,omitempty"` // Emails of the team's directory group
+}
+
+// TeamDigest summarizes the jobs of one team, so a receiver can route it to the team
+type TeamDigest struct {
+	Team         string            `json:"team"`
+	Contacts     []string          `json:"contacts,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"` // Routing labels, e.g. slack_channel
+	TotalJobs    int               `json:"total_jobs"`
+	AverageScore float64           `json:"average_score"`
+	JobsBelowMin []JobScore        `json:"jobs_below_min_score,omitempty"`
+}
+
+// Webhooks posts run summaries to callback URLs
+type Webhooks struct {
+	URLs       []string
+	Secret     string // Unsigned when empty
+	Client     *http.Client
+	Attempts   int           // Tries per URL, for network errors and 5xx or 429 responses
+	RetryDelay time.Duration // Doubled after each failed try
+}
+
+// NewWebhooks returns Webhooks for urls, signing payloads with secret
+func NewWebhooks(urls []string, secret string) *Webhooks {
+	return &Webhooks{
+		URLs:       urls,
+		Secret:     secret,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		Attempts:   3,
+		RetryDelay: time.Second,
+	}
+}
+
+// Send posts summary to every URL and returns an error per URL that could not be notified
+// One URL failing does not stop the others from being called.
+func (w *Webhooks) Send(summary RunSummary) []error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, url := range w.URLs {
+		if err := w.post(url, body); err != nil {
+			errs = append(errs, fmt.Errorf("callback %s: %w", url, err))
+		}
+	}
+	return errs
+}
+
+// post delivers body to url, retrying transient failures
+func (w *Webhooks) post(url string, body []byte) error {
+	return deliver(w.Client, w.Attempts, w.RetryDelay, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(EventHeader, "run.completed")
+		if w.Secret != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set(TimestampHeader, timestamp)
+			req.Header.Set(SignatureHeader, Sign(w.Secret, timestamp, body))
+		}
+		return req, nil
+	})
+}
+
+// deliver sends the request newRequest builds, retrying network errors and 5xx or 429
+// responses up to attempts times and doubling delay after each failed try
+func deliver(client *http.Client, attempts int, delay time.Duration, newRequest func() (*http.Request, error)) error {
+	var lastErr error
+	for attempt := 0; attempt < max(attempts, 1); attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+			lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+		default:
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+	}
+	return lastErr
+}
+
+// Sign returns the signature header value of a payload sent at timestamp
+// Receivers recompute it with the shared secret and compare in constant time; including
+// the timestamp lets them reject replayed requests.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
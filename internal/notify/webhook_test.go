@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhooksSend(t *testing.T) {
+	calls := 0
+	var received RunSummary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		want := Sign("secret", r.Header.Get(TimestampHeader), body)
+		if got := r.Header.Get(SignatureHeader); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer rejecting.Close()
+
+	webhooks := NewWebhooks([]string{server.URL, rejecting.URL}, "secret")
+	webhooks.RetryDelay = 0
+	errs := webhooks.Send(RunSummary{
+		Status:       StatusSuccess,
+		TotalJobs:    2,
+		JobsBelowMin: []JobScore{{JobName: "api", Score: 42}},
+	})
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want a retry after the 503", calls)
+	}
+	if received.Status != StatusSuccess || len(received.JobsBelowMin) != 1 || received.JobsBelowMin[0].JobName != "api" {
+		t.Errorf("received %+v", received)
+	}
+	if len(errs) != 1 {
+		t.Errorf("errs = %v, want one error for the 403 URL", errs)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	if got := Sign("secret", "1700000000", []byte("{}")); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+	if Sign("secret", "1", []byte("{}")) == Sign("secret", "2", []byte("{}")) {
+		t.Error("signatures of different timestamps should differ")
+	}
+}
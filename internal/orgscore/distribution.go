@@ -0,0 +1,58 @@
+package orgscore
+
+import (
+	"math"
+	"sort"
+)
+
+// Distribution describes the spread of job scores, which an average hides: a few
+// excellent jobs can lift the mean while most jobs are poorly instrumented
+type Distribution struct {
+	Jobs   int     `json:"jobs"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P10    float64 `json:"p10"` // 10% of jobs score below this, the poorly instrumented tail
+	P90    float64 `json:"p90"`
+	StdDev float64 `json:"stddev"` // Population standard deviation
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// Distribute computes the distribution of scores; it is empty without scores
+// Percentiles interpolate linearly between the nearest scores.
+func Distribute(scores []float64) Distribution {
+	if len(scores) == 0 {
+		return Distribution{}
+	}
+	sorted := append([]float64(nil), scores...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, score := range sorted {
+		sum += score
+	}
+	mean := sum / float64(len(sorted))
+	var squares float64
+	for _, score := range sorted {
+		squares += (score - mean) * (score - mean)
+	}
+
+	return Distribution{
+		Jobs:   len(sorted),
+		Mean:   mean,
+		Median: percentile(sorted, 50),
+		P10:    percentile(sorted, 10),
+		P90:    percentile(sorted, 90),
+		StdDev: math.Sqrt(squares / float64(len(sorted))),
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the p-th percentile of sorted, which is not empty
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,35 @@
+package orgscore
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistribute(t *testing.T) {
+	// Two excellent jobs lift the mean well above the typical job
+	got := Distribute([]float64{98, 40, 35, 100, 30, 45, 50, 38, 42, 32})
+
+	want := Distribution{Jobs: 10, Mean: 51, Median: 41, P10: 31.8, P90: 98.2, Min: 30, Max: 100}
+	for name, pair := range map[string][2]float64{
+		"Mean":   {got.Mean, want.Mean},
+		"Median": {got.Median, want.Median},
+		"P10":    {got.P10, want.P10},
+		"P90":    {got.P90, want.P90},
+		"Min":    {got.Min, want.Min},
+		"Max":    {got.Max, want.Max},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, pair[0], pair[1])
+		}
+	}
+	if got.Jobs != 10 || math.Abs(got.StdDev-24.6495) > 1e-4 {
+		t.Errorf("Jobs = %d, StdDev = %v, want 10 and 24.6495", got.Jobs, got.StdDev)
+	}
+
+	if one := Distribute([]float64{70}); one.Median != 70 || one.P90 != 70 || one.StdDev != 0 {
+		t.Errorf("Distribute(70) = %+v", one)
+	}
+	if empty := Distribute(nil); empty != (Distribution{}) {
+		t.Errorf("Distribute(nil) = %+v, want empty", empty)
+	}
+}
@@ -0,0 +1,104 @@
+// Package orgscore combines the scores of every job into one organization score
+package orgscore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Weightings decide how much each job counts toward the organization score
+const (
+	WeightJobs        = "jobs"        // Every job counts once: the average score
+	WeightCardinality = "cardinality" // Every active series counts once, so large jobs dominate
+	WeightTeamSize    = "team_size"   // Every team's average counts by its number of members
+)
+
+// DefaultWeighting is used when no weighting is configured
+const DefaultWeighting = WeightJobs
+
+// Weightings returns the supported weightings
+func Weightings() []string {
+	return []string{WeightJobs, WeightCardinality, WeightTeamSize}
+}
+
+// Job is what the organization score knows about one evaluated job
+type Job struct {
+	Score       float64
+	Cardinality int64
+	Team        string // "" when the job has no owner
+	TeamSize    int    // Members of the team's directory group, 0 when unknown
+}
+
+// Score is the organization score and how it was weighted
+type Score struct {
+	Score     float64 `json:"score"`
+	Weighting string  `json:"weighting"`
+	Jobs      int     `json:"jobs"`
+	Teams     int     `json:"teams,omitempty"` // Teams counted, for team_size weighting
+}
+
+// Compute combines the job scores with the given weighting
+// With team_size weighting, jobs without an owner form one more group of size 1, and
+// teams of unknown size count as one member, so no job drops out of the score.
+func Compute(jobs []Job, weighting string) (Score, error) {
+	if weighting == "" {
+		weighting = DefaultWeighting
+	}
+	score := Score{Weighting: weighting, Jobs: len(jobs)}
+
+	var weighted, totalWeight float64
+	switch weighting {
+	case WeightJobs:
+		for _, job := range jobs {
+			weighted += job.Score
+			totalWeight++
+		}
+	case WeightCardinality:
+		for _, job := range jobs {
+			weighted += job.Score * float64(job.Cardinality)
+			totalWeight += float64(job.Cardinality)
+		}
+	case WeightTeamSize:
+		type team struct {
+			scoreSum float64
+			jobs     int
+			size     int
+		}
+		teams := make(map[string]*team)
+		for _, job := range jobs {
+			t, ok := teams[job.Team]
+			if !ok {
+				t = &team{}
+				teams[job.Team] = t
+			}
+			t.scoreSum += job.Score
+			t.jobs++
+			if job.TeamSize > t.size {
+				t.size = job.TeamSize
+			}
+		}
+		names := make([]string, 0, len(teams))
+		for name := range teams {
+			names = append(names, name)
+		}
+		sort.Strings(names) // Sum in a fixed order so the score is reproducible
+		for _, name := range names {
+			t := teams[name]
+			size := float64(t.size)
+			if size < 1 {
+				size = 1
+			}
+			weighted += t.scoreSum / float64(t.jobs) * size
+			totalWeight += size
+		}
+		score.Teams = len(teams)
+	default:
+		return Score{}, fmt.Errorf("unknown organization score weighting %q (available: %s)", weighting, strings.Join(Weightings(), ", "))
+	}
+
+	if totalWeight > 0 {
+		score.Score = weighted / totalWeight
+	}
+	return score, nil
+}
@@ -0,0 +1,46 @@
+package orgscore
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	jobs := []Job{
+		{Score: 90, Cardinality: 9000, Team: "payments", TeamSize: 8},
+		{Score: 70, Cardinality: 1000, Team: "payments", TeamSize: 8},
+		{Score: 40, Cardinality: 0, Team: "search", TeamSize: 2},
+		{Score: 100, Cardinality: 0},
+	}
+
+	tests := []struct {
+		weighting string
+		want      float64
+	}{
+		{"", 75},                // (90+70+40+100)/4
+		{WeightJobs, 75},        //
+		{WeightCardinality, 88}, // (90×9000 + 70×1000) / 10000
+		{WeightTeamSize, (80*8 + 40*2 + 100) / 11.0}, // payments 80 × 8, search 40 × 2, unowned 100 × 1
+	}
+	for _, tt := range tests {
+		t.Run(tt.weighting, func(t *testing.T) {
+			got, err := Compute(jobs, tt.weighting)
+			if err != nil {
+				t.Fatalf("Compute() error = %v", err)
+			}
+			if math.Abs(got.Score-tt.want) > 1e-9 {
+				t.Errorf("Compute(%q) = %v, want %v", tt.weighting, got.Score, tt.want)
+			}
+			if got.Jobs != 4 {
+				t.Errorf("Jobs = %d, want 4", got.Jobs)
+			}
+		})
+	}
+
+	if _, err := Compute(jobs, "headcount"); err == nil {
+		t.Error("expected an error for an unknown weighting")
+	}
+	if got, err := Compute([]Job{{Score: 50}}, WeightCardinality); err != nil || got.Score != 0 {
+		t.Errorf("expected 0 without any series, got %v (err %v)", got.Score, err)
+	}
+}
@@ -0,0 +1,179 @@
+package ownership
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Directory providers
+const (
+	ProviderLDAP = "ldap"
+	ProviderOkta = "okta"
+)
+
+// Environment variables holding directory credentials
+const (
+	LDAPPasswordEnv = "LDAP_BIND_PASSWORD"
+	OktaTokenEnv    = "OKTA_API_TOKEN"
+)
+
+// DefaultGroupTemplate names the directory group of a team when neither the owner nor
+// the directory sets one
+const DefaultGroupTemplate = "{team}"
+
+// Directory resolves teams to the members of their LDAP or Okta group, so notifications
+// reach the people in the directory without a second contact list
+//
+// Example:
+//
+//	directory:
+//	  provider: okta
+//	  url: https://example.okta.com
+//	  group: "team-{team}"
+//	  labels:
+//	    slack_channel: slackChannel   # Routing label <- group attribute
+type Directory struct {
+	Provider string            `yaml:"provider"` // ldap or okta
+	URL      string            `yaml:"url"`      // ldap://, ldaps:// or the Okta org URL
+	Group    string            `yaml:"group"`    // Group of a team, {team} is replaced with its name
+	Labels   map[string]string `yaml:"labels"`   // Routing labels read from group attributes
+
+	// LDAP only
+	BaseDN          string `yaml:"base_dn"`          // Where groups are searched
+	BindDN          string `yaml:"bind_dn"`          // Bound with the password in LDAP_BIND_PASSWORD over TLS; anonymous when empty
+	GroupAttribute  string `yaml:"group_attribute"`  // Attribute holding the group name (default: cn)
+	MemberAttribute string `yaml:"member_attribute"` // Attribute listing member DNs (default: member)
+	EmailAttribute  string `yaml:"email_attribute"`  // Attribute of members holding their email (default: mail)
+}
+
+// Group describes a directory group
+type Group struct {
+	Emails     []string          // Email addresses of the members
+	Attributes map[string]string // The group attributes Directory.Labels asked for
+}
+
+// groupSource looks groups up by name
+type groupSource interface {
+	Group(name string, attributes []string) (Group, error)
+}
+
+// validate checks the directory settings and fills in defaults
+func (d *Directory) validate() error {
+	switch d.Provider {
+	case ProviderLDAP:
+		if !strings.HasPrefix(d.URL, "ldap://") && !strings.HasPrefix(d.URL, "ldaps://") {
+			return fmt.Errorf("directory: ldap url must start with ldap:// or ldaps://")
+		}
+		if d.BaseDN == "" {
+			return fmt.Errorf("directory: base_dn is required for ldap")
+		}
+		if d.GroupAttribute == "" {
+			d.GroupAttribute = "cn"
+		}
+		if d.MemberAttribute == "" {
+			d.MemberAttribute = "member"
+		}
+		if d.EmailAttribute == "" {
+			d.EmailAttribute = "mail"
+		}
+	case ProviderOkta:
+		if !strings.HasPrefix(d.URL, "https://") && !strings.HasPrefix(d.URL, "http://") {
+			return fmt.Errorf("directory: okta url must be the https:// URL of the Okta org")
+		}
+	default:
+		return fmt.Errorf("directory: unknown provider %q, use %s or %s", d.Provider, ProviderLDAP, ProviderOkta)
+	}
+	if d.Group == "" {
+		d.Group = DefaultGroupTemplate
+	}
+	return nil
+}
+
+// SecretEnv returns the environment variable holding the provider's credential
+func (d *Directory) SecretEnv() string {
+	if d.Provider == ProviderOkta {
+		return OktaTokenEnv
+	}
+	return LDAPPasswordEnv
+}
+
+// groupName returns the directory group of owner
+func (d *Directory) groupName(owner Owner) string {
+	if owner.Group != "" {
+		return owner.Group
+	}
+	return strings.ReplaceAll(d.Group, "{team}", owner.Team)
+}
+
+// ResolveContacts looks up the directory group of every team and sets the owners' Contacts,
+// and the labels the directory maps from group attributes
+// Labels set in the ownership file take precedence. A team whose group cannot be resolved
+// keeps what the file says; its error is returned with the others, so callers can warn
+// and continue. Mappings without a directory are left unchanged.
+func (m *Mapping) ResolveContacts(secret string) []error {
+	if m == nil || m.Directory == nil {
+		return nil
+	}
+	var source groupSource
+	switch m.Directory.Provider {
+	case ProviderLDAP:
+		source = newLDAPDirectory(m.Directory, secret)
+	case ProviderOkta:
+		source = newOktaDirectory(m.Directory, secret)
+	}
+	return m.resolveContacts(source)
+}
+
+func (m *Mapping) resolveContacts(source groupSource) []error {
+	attributes := make([]string, 0, len(m.Directory.Labels))
+	for _, attribute := range m.Directory.Labels {
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+
+	owners := append([]Owner{}, m.Owners...)
+	if m.DefaultTeam != "" {
+		owners = append(owners, Owner{Team: m.DefaultTeam})
+	}
+
+	groups := make(map[string]Group) // By team, so a team listed twice is looked up once
+	var errs []error
+	for _, owner := range owners {
+		if _, ok := groups[owner.Team]; ok {
+			continue
+		}
+		name := m.Directory.groupName(owner)
+		group, err := source.Group(name, attributes)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("team %s: group %s: %w", owner.Team, name, err))
+			continue
+		}
+		groups[owner.Team] = group
+	}
+
+	apply := func(owner *Owner) {
+		group, ok := groups[owner.Team]
+		if !ok {
+			return
+		}
+		owner.Contacts = group.Emails
+		for label, attribute := range m.Directory.Labels {
+			value := group.Attributes[attribute]
+			if _, set := owner.Labels[label]; set || value == "" {
+				continue
+			}
+			if owner.Labels == nil {
+				owner.Labels = make(map[string]string)
+			}
+			owner.Labels[label] = value
+		}
+	}
+	for i := range m.Owners {
+		apply(&m.Owners[i])
+	}
+	if m.DefaultTeam != "" {
+		apply(&m.defaultOwner)
+	}
+	return errs
+}
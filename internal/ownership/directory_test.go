@@ -0,0 +1,101 @@
+package ownership
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fakeGroups is a groupSource backed by a map
+type fakeGroups struct {
+	groups  map[string]Group
+	queried []string
+}
+
+func (f *fakeGroups) Group(name string, attributes []string) (Group, error) {
+	f.queried = append(f.queried, name)
+	group, ok := f.groups[name]
+	if !ok {
+		return Group{}, fmt.Errorf("not found")
+	}
+	return group, nil
+}
+
+func TestMapping_ResolveContacts(t *testing.T) {
+	mapping, err := Load(writeMapping(t, testMapping+`
+  - team: ledger
+    jobs: ["ledger"]
+    group: finance-engineering
+directory:
+  provider: okta
+  url: https://example.okta.com
+  group: "team-{team}"
+  labels:
+    slack_channel: slackChannel
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	source := &fakeGroups{groups: map[string]Group{
+		"team-payments": {
+			Emails:     []string{"ana@example.com", "bo@example.com"},
+			Attributes: map[string]string{"slackChannel": "#payments-directory"},
+		},
+		"team-platform": {
+			Emails:     []string{"ops@example.com"},
+			Attributes: map[string]string{"slackChannel": "#platform"},
+		},
+		"finance-engineering": {Emails: []string{"fin@example.com"}},
+	}}
+	errs := mapping.resolveContacts(source)
+	if len(errs) != 1 {
+		t.Fatalf("resolveContacts() errors = %v, want only the search team's", errs)
+	}
+	if want := []string{"team-payments", "team-search", "finance-engineering", "team-platform"}; !reflect.DeepEqual(source.queried, want) {
+		t.Errorf("queried groups = %v, want %v", source.queried, want)
+	}
+
+	tests := []struct {
+		job          string
+		wantContacts []string
+		wantLabels   map[string]string
+	}{
+		{
+			job:          "checkout",
+			wantContacts: []string{"ana@example.com", "bo@example.com"},
+			// The channel in the ownership file wins over the directory's
+			wantLabels: map[string]string{"team": "payments", "slack_channel": "#payments-alerts"},
+		},
+		{job: "search-indexer", wantContacts: nil, wantLabels: map[string]string{"team": "search"}},
+		{job: "ledger", wantContacts: []string{"fin@example.com"}, wantLabels: map[string]string{"team": "ledger"}},
+		{
+			job:          "node-exporter",
+			wantContacts: []string{"ops@example.com"},
+			wantLabels:   map[string]string{"team": "platform", "slack_channel": "#platform"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.job, func(t *testing.T) {
+			if got := mapping.ContactsOf(tt.job); !reflect.DeepEqual(got, tt.wantContacts) {
+				t.Errorf("ContactsOf() = %v, want %v", got, tt.wantContacts)
+			}
+			if got := mapping.RoutingLabels(tt.job); !reflect.DeepEqual(got, tt.wantLabels) {
+				t.Errorf("RoutingLabels() = %v, want %v", got, tt.wantLabels)
+			}
+		})
+	}
+}
+
+func TestMapping_ResolveContactsWithoutDirectory(t *testing.T) {
+	mapping, err := Load(writeMapping(t, testMapping))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if errs := mapping.ResolveContacts(""); errs != nil {
+		t.Errorf("ResolveContacts() = %v, want nil", errs)
+	}
+	if contacts := mapping.ContactsOf("checkout"); contacts != nil {
+		t.Errorf("ContactsOf() = %v, want nil", contacts)
+	}
+}
@@ -0,0 +1,422 @@
+package ownership
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// ldapDirectory looks groups up with LDAP searches
+// Only the operations it needs are implemented: StartTLS, simple bind, search and unbind.
+type ldapDirectory struct {
+	directory *Directory
+	password  string
+	timeout   time.Duration
+	rootCAs   *x509.CertPool // Trusted for TLS, the system roots when nil
+}
+
+func newLDAPDirectory(d *Directory, password string) *ldapDirectory {
+	return &ldapDirectory{directory: d, password: password, timeout: 30 * time.Second}
+}
+
+// LDAP scopes, result codes and extended operations used by the client
+const (
+	ldapStartTLSOID     = "1.3.6.1.4.1.1466.20037"
+	ldapScopeBase       = 0
+	ldapScopeSubtree    = 2
+	ldapResultSuccess   = 0
+	ldapResultNoSuchObj = 32
+)
+
+// ldapResultError is a non-success LDAP result
+type ldapResultError struct {
+	code    int64
+	message string
+}
+
+func (e *ldapResultError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("ldap result code %d", e.code)
+	}
+	return fmt.Sprintf("ldap result code %d: %s", e.code, e.message)
+}
+
+// ldapEntry is a search result: a DN and its attribute values
+type ldapEntry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// ldapConn is an LDAP connection, one request at a time
+type ldapConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	messageID int64
+}
+
+// Group returns the email addresses of the members of the group whose group_attribute is
+// name, searched under base_dn
+func (l *ldapDirectory) Group(name string, attributes []string) (Group, error) {
+	d := l.directory
+	conn, err := l.dial()
+	if err != nil {
+		return Group{}, err
+	}
+	defer conn.close()
+
+	if err := conn.bind(d.BindDN, l.password); err != nil {
+		return Group{}, fmt.Errorf("ldap bind failed: %w", err)
+	}
+
+	filter := berSequence(0xa3, berString(0x04, d.GroupAttribute), berString(0x04, name)) // equalityMatch
+	entries, err := conn.search(d.BaseDN, ldapScopeSubtree, filter, append([]string{d.MemberAttribute}, attributes...))
+	if err != nil {
+		return Group{}, fmt.Errorf("ldap search failed: %w", err)
+	}
+	if len(entries) == 0 {
+		return Group{}, fmt.Errorf("not found under %s", d.BaseDN)
+	}
+
+	group := Group{Attributes: make(map[string]string)}
+	for _, attribute := range attributes {
+		if values := entries[0].attributes[attribute]; len(values) > 0 {
+			group.Attributes[attribute] = values[0]
+		}
+	}
+
+	present := berString(0x87, "objectClass") // (objectClass=*)
+	for _, member := range entries[0].attributes[d.MemberAttribute] {
+		found, err := conn.search(member, ldapScopeBase, present, []string{d.EmailAttribute})
+		var result *ldapResultError
+		if errors.As(err, &result) && result.code == ldapResultNoSuchObj {
+			continue // Stale membership
+		}
+		if err != nil {
+			return Group{}, fmt.Errorf("ldap lookup of %s failed: %w", member, err)
+		}
+		for _, entry := range found {
+			if emails := entry.attributes[d.EmailAttribute]; len(emails) > 0 {
+				group.Emails = append(group.Emails, emails[0])
+			}
+		}
+	}
+	return group, nil
+}
+
+// dial connects to the directory URL, over TLS for ldaps://
+// A connection to an ldap:// URL that binds with a DN is upgraded with StartTLS, so the
+// password is never sent in cleartext: a server refusing StartTLS fails the lookup.
+func (l *ldapDirectory) dial() (*ldapConn, error) {
+	u, err := url.Parse(l.directory.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap url: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "389"
+		if u.Scheme == "ldaps" {
+			port = "636"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), RootCAs: l.rootCAs}
+	dialer := &net.Dialer{Timeout: l.timeout}
+	var conn net.Conn
+	if u.Scheme == "ldaps" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", host, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(l.timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c := &ldapConn{conn: conn, reader: bufio.NewReader(conn)}
+	if u.Scheme != "ldaps" && l.directory.BindDN != "" {
+		if err := c.startTLS(tlsConfig); err != nil {
+			c.conn.Close()
+			return nil, fmt.Errorf("refusing to bind as %s without TLS, StartTLS failed: %w", l.directory.BindDN, err)
+		}
+	}
+	return c, nil
+}
+
+// startTLS upgrades the connection to TLS with the StartTLS extended operation
+func (c *ldapConn) startTLS(config *tls.Config) error {
+	if err := c.send(berSequence(0x77, berString(0x80, ldapStartTLSOID))); err != nil {
+		return err
+	}
+	response, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if response.tag != 0x78 {
+		return fmt.Errorf("unexpected extended response tag 0x%x", response.tag)
+	}
+	if err := ldapResult(response); err != nil {
+		return err
+	}
+	conn := tls.Client(c.conn, config)
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+	return nil
+}
+
+// bind authenticates with a simple bind, anonymously when dn is empty
+func (c *ldapConn) bind(dn, password string) error {
+	request := berSequence(0x60, berInt(0x02, 3), berString(0x04, dn), berString(0x80, password))
+	if err := c.send(request); err != nil {
+		return err
+	}
+	response, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if response.tag != 0x61 {
+		return fmt.Errorf("unexpected bind response tag 0x%x", response.tag)
+	}
+	return ldapResult(response)
+}
+
+// search returns the entries under base matching filter, with the requested attributes
+func (c *ldapConn) search(base string, scope int64, filter []byte, attributes []string) ([]ldapEntry, error) {
+	names := make([][]byte, len(attributes))
+	for i, attribute := range attributes {
+		names[i] = berString(0x04, attribute)
+	}
+	request := berSequence(0x63,
+		berString(0x04, base),
+		berInt(0x0a, scope),
+		berInt(0x0a, 0), // Never dereference aliases
+		berInt(0x02, 0), // No size limit
+		berInt(0x02, 0), // No time limit
+		[]byte{0x01, 0x01, 0x00},
+		filter,
+		berSequence(0x30, names...),
+	)
+	if err := c.send(request); err != nil {
+		return nil, err
+	}
+
+	var entries []ldapEntry
+	for {
+		response, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch response.tag {
+		case 0x64: // SearchResultEntry
+			entry, err := parseLDAPEntry(response)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case 0x65: // SearchResultDone
+			return entries, ldapResult(response)
+		}
+	}
+}
+
+// close unbinds and closes the connection
+func (c *ldapConn) close() {
+	c.send([]byte{0x42, 0x00})
+	c.conn.Close()
+}
+
+// send writes protocolOp as the next LDAP message
+func (c *ldapConn) send(protocolOp []byte) error {
+	c.messageID++
+	_, err := c.conn.Write(berSequence(0x30, berInt(0x02, c.messageID), protocolOp))
+	return err
+}
+
+// receive reads the protocolOp of the response to the last message sent
+func (c *ldapConn) receive() (berElement, error) {
+	for {
+		message, err := readBER(c.reader)
+		if err != nil {
+			return berElement{}, fmt.Errorf("failed to read ldap response: %w", err)
+		}
+		parts, err := message.children()
+		if err != nil || len(parts) < 2 {
+			return berElement{}, fmt.Errorf("malformed ldap message")
+		}
+		if parts[0].int() == c.messageID {
+			return parts[1], nil
+		}
+	}
+}
+
+// ldapResult returns the error of an LDAPResult, nil on success
+func ldapResult(op berElement) error {
+	parts, err := op.children()
+	if err != nil || len(parts) < 3 {
+		return fmt.Errorf("malformed ldap result")
+	}
+	if code := parts[0].int(); code != ldapResultSuccess {
+		return &ldapResultError{code: code, message: string(parts[2].data)}
+	}
+	return nil
+}
+
+// parseLDAPEntry decodes a SearchResultEntry
+func parseLDAPEntry(op berElement) (ldapEntry, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) < 2 {
+		return ldapEntry{}, fmt.Errorf("malformed ldap search entry")
+	}
+	entry := ldapEntry{dn: string(parts[0].data), attributes: make(map[string][]string)}
+	attributes, err := parts[1].children()
+	if err != nil {
+		return ldapEntry{}, err
+	}
+	for _, attribute := range attributes {
+		fields, err := attribute.children()
+		if err != nil || len(fields) < 2 {
+			return ldapEntry{}, fmt.Errorf("malformed ldap attribute")
+		}
+		values, err := fields[1].children()
+		if err != nil {
+			return ldapEntry{}, err
+		}
+		for _, value := range values {
+			entry.attributes[string(fields[0].data)] = append(entry.attributes[string(fields[0].data)], string(value.data))
+		}
+	}
+	return entry, nil
+}
+
+// berElement is a BER encoded element: its tag and content
+type berElement struct {
+	tag  byte
+	data []byte
+}
+
+// children decodes the elements of a constructed element
+func (e berElement) children() ([]berElement, error) {
+	var elements []berElement
+	rest := e.data
+	for len(rest) > 0 {
+		if len(rest) < 2 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		length, header, err := berLength(rest[1:])
+		if err != nil {
+			return nil, err
+		}
+		end := 1 + header + length
+		if end > len(rest) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		elements = append(elements, berElement{tag: rest[0], data: rest[1+header : end]})
+		rest = rest[end:]
+	}
+	return elements, nil
+}
+
+// int decodes the content of an INTEGER or ENUMERATED element
+func (e berElement) int() int64 {
+	var n int64
+	for i, b := range e.data {
+		if i == 0 && b&0x80 != 0 {
+			n = -1
+		}
+		n = n<<8 | int64(b)
+	}
+	return n
+}
+
+// readBER reads one element
+func readBER(r *bufio.Reader) (berElement, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return berElement{}, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return berElement{}, err
+	}
+	header := []byte{first}
+	if first&0x80 != 0 {
+		extra := make([]byte, first&0x7f)
+		if _, err := io.ReadFull(r, extra); err != nil {
+			return berElement{}, err
+		}
+		header = append(header, extra...)
+	}
+	length, _, err := berLength(header)
+	if err != nil {
+		return berElement{}, err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return berElement{}, err
+	}
+	return berElement{tag: tag, data: data}, nil
+}
+
+// berLength decodes a length, returning it and the number of bytes it took
+func berLength(b []byte) (int, int, error) {
+	if b[0]&0x80 == 0 {
+		return int(b[0]), 1, nil
+	}
+	n := int(b[0] & 0x7f)
+	if n == 0 || n > 4 || len(b) < 1+n {
+		return 0, 0, fmt.Errorf("unsupported ber length")
+	}
+	length := 0
+	for _, octet := range b[1 : 1+n] {
+		length = length<<8 | int(octet)
+	}
+	return length, 1 + n, nil
+}
+
+// berSequence encodes a constructed element from encoded elements
+func berSequence(tag byte, elements ...[]byte) []byte {
+	var content []byte
+	for _, element := range elements {
+		content = append(content, element...)
+	}
+	return berEncode(tag, content)
+}
+
+// berString encodes an OCTET STRING, or a string with a context tag
+func berString(tag byte, s string) []byte {
+	return berEncode(tag, []byte(s))
+}
+
+// berInt encodes an INTEGER or ENUMERATED
+func berInt(tag byte, n int64) []byte {
+	content := []byte{byte(n)}
+	for n > 0x7f || n < -0x80 {
+		n >>= 8
+		content = append([]byte{byte(n)}, content...)
+	}
+	return berEncode(tag, content)
+}
+
+// berEncode encodes an element with a definite length
+func berEncode(tag byte, content []byte) []byte {
+	length := len(content)
+	if length < 0x80 {
+		return append([]byte{tag, byte(length)}, content...)
+	}
+	var octets []byte
+	for n := length; n > 0; n >>= 8 {
+		octets = append([]byte{byte(n)}, octets...)
+	}
+	header := append([]byte{tag, 0x80 | byte(len(octets))}, octets...)
+	return append(header, content...)
+}
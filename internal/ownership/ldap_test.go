@@ -0,0 +1,201 @@
+package ownership
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// testLDAPServer answers StartTLS, binds and searches from a fixed directory
+type testLDAPServer struct {
+	entries        map[string]map[string][]string
+	tlsConfig      *tls.Config // StartTLS is refused when nil
+	cleartextBinds atomic.Int32
+}
+
+// serve answers the requests of one connection
+func (s *testLDAPServer) serve(listener net.Listener) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { conn.Close() }()
+	reader := bufio.NewReader(conn)
+	secure := false
+
+	reply := func(id berElement, ops ...[]byte) {
+		for _, op := range ops {
+			conn.Write(berSequence(0x30, berInt(0x02, id.int()), op))
+		}
+	}
+	result := func(tag byte, code int64) []byte {
+		return berSequence(tag, berInt(0x0a, code), berString(0x04, ""), berString(0x04, ""))
+	}
+	entry := func(dn string, attributes map[string][]string) []byte {
+		var encoded [][]byte
+		for name, values := range attributes {
+			var vals [][]byte
+			for _, value := range values {
+				vals = append(vals, berString(0x04, value))
+			}
+			encoded = append(encoded, berSequence(0x30, berString(0x04, name), berSequence(0x31, vals...)))
+		}
+		return berSequence(0x64, berString(0x04, dn), berSequence(0x30, encoded...))
+	}
+
+	for {
+		message, err := readBER(reader)
+		if err != nil {
+			return
+		}
+		parts, _ := message.children()
+		id, op := parts[0], parts[1]
+		fields, _ := op.children()
+		switch op.tag {
+		case 0x77: // Extended, StartTLS
+			if s.tlsConfig == nil {
+				reply(id, result(0x78, 2)) // protocolError
+				continue
+			}
+			reply(id, result(0x78, 0))
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, reader, secure = tlsConn, bufio.NewReader(tlsConn), true
+		case 0x60: // Bind
+			dn, password := string(fields[1].data), string(fields[2].data)
+			if !secure && password != "" {
+				s.cleartextBinds.Add(1)
+			}
+			code := int64(49) // invalidCredentials
+			if dn == "" || dn == "cn=reader,dc=example,dc=com" && password == "secret" {
+				code = 0
+			}
+			reply(id, result(0x61, code))
+		case 0x63: // Search
+			base := string(fields[0].data)
+			if fields[1].int() == ldapScopeSubtree {
+				filter, _ := fields[6].children()
+				dn := "cn=" + string(filter[1].data) + ",ou=groups,dc=example,dc=com"
+				if attributes, ok := s.entries[dn]; ok && string(filter[0].data) == "cn" {
+					reply(id, entry(dn, attributes))
+				}
+				reply(id, result(0x65, 0))
+			} else if attributes, ok := s.entries[base]; ok {
+				reply(id, entry(base, attributes), result(0x65, 0))
+			} else {
+				reply(id, result(0x65, ldapResultNoSuchObj))
+			}
+		case 0x42: // Unbind
+			return
+		}
+	}
+}
+
+func TestLDAPDirectory_Group(t *testing.T) {
+	entries := map[string]map[string][]string{
+		"cn=team-payments,ou=groups,dc=example,dc=com": {
+			"member": {
+				"uid=ana,ou=people,dc=example,dc=com",
+				"uid=gone,ou=people,dc=example,dc=com",
+				"uid=bo,ou=people,dc=example,dc=com",
+			},
+			"description": {"Payments engineering"},
+		},
+		"uid=ana,ou=people,dc=example,dc=com": {"mail": {"ana@example.com"}},
+		"uid=bo,ou=people,dc=example,dc=com":  {"mail": {"bo@example.com"}},
+	}
+
+	// The certificate of a test TLS server, valid for 127.0.0.1, serves StartTLS
+	certificates := httptest.NewTLSServer(nil)
+	certificates.Close()
+	serverTLS := &tls.Config{Certificates: certificates.TLS.Certificates}
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(certificates.Certificate())
+
+	members := Group{
+		Emails:     []string{"ana@example.com", "bo@example.com"},
+		Attributes: map[string]string{"description": "Payments engineering"},
+	}
+	tests := []struct {
+		name      string
+		group     string
+		bindDN    string
+		password  string
+		serverTLS *tls.Config
+		want      Group
+		wantErr   bool
+	}{
+		{name: "group members", group: "team-payments", bindDN: "cn=reader,dc=example,dc=com", password: "secret", serverTLS: serverTLS, want: members},
+		{name: "anonymous without TLS", group: "team-payments", want: members},
+		{name: "unknown group", group: "team-unknown", bindDN: "cn=reader,dc=example,dc=com", password: "secret", serverTLS: serverTLS, wantErr: true},
+		{name: "wrong password", group: "team-payments", bindDN: "cn=reader,dc=example,dc=com", password: "wrong", serverTLS: serverTLS, wantErr: true},
+		{name: "StartTLS refused", group: "team-payments", bindDN: "cn=reader,dc=example,dc=com", password: "secret", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer listener.Close()
+			server := &testLDAPServer{entries: entries, tlsConfig: tt.serverTLS}
+			done := make(chan struct{})
+			go func() {
+				server.serve(listener)
+				close(done)
+			}()
+
+			directory := &Directory{
+				Provider: ProviderLDAP,
+				URL:      "ldap://" + listener.Addr().String(),
+				BaseDN:   "ou=groups,dc=example,dc=com",
+				BindDN:   tt.bindDN,
+			}
+			if err := directory.validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			ldap := newLDAPDirectory(directory, tt.password)
+			ldap.rootCAs = rootCAs
+			got, err := ldap.Group(tt.group, []string{"description"})
+			<-done
+			if n := server.cleartextBinds.Load(); n > 0 {
+				t.Errorf("the password was sent without TLS in %d binds", n)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Group() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Group() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBERRoundTrip(t *testing.T) {
+	long := make([]byte, 300)
+	for _, n := range []int64{0, 3, 127, 128, 255, 256, 65536, -1} {
+		if got := (berElement{data: berInt(0x02, n)[2:]}).int(); got != n {
+			t.Errorf("berInt(%d) decodes to %d", n, got)
+		}
+	}
+
+	encoded := berSequence(0x30, berString(0x04, "dn"), berEncode(0x04, long))
+	element, err := readBER(bufio.NewReader(bytes.NewReader(encoded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	children, err := element.children()
+	if err != nil || len(children) != 2 || string(children[0].data) != "dn" || len(children[1].data) != 300 {
+		t.Errorf("children() = %v, %v", children, err)
+	}
+}
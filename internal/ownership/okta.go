@@ -0,0 +1,101 @@
+package ownership
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// oktaDirectory looks groups up with the Okta groups API
+type oktaDirectory struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newOktaDirectory(d *Directory, token string) *oktaDirectory {
+	return &oktaDirectory{
+		baseURL: strings.TrimSuffix(d.URL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// oktaNextLink matches the next page in a Link header
+var oktaNextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// Group returns the members of the Okta group whose profile name is name
+// Attributes are read from the group profile, including custom profile attributes.
+func (o *oktaDirectory) Group(name string, attributes []string) (Group, error) {
+	search := url.Values{"search": {fmt.Sprintf("profile.name eq %q", name)}}
+	var groups []struct {
+		ID      string                 `json:"id"`
+		Profile map[string]interface{} `json:"profile"`
+	}
+	if _, err := o.get(o.baseURL+"/api/v1/groups?"+search.Encode(), &groups); err != nil {
+		return Group{}, err
+	}
+	if len(groups) == 0 {
+		return Group{}, fmt.Errorf("not found in okta")
+	}
+
+	group := Group{Attributes: make(map[string]string)}
+	for _, attribute := range attributes {
+		if value, ok := groups[0].Profile[attribute].(string); ok {
+			group.Attributes[attribute] = value
+		}
+	}
+
+	next := o.baseURL + "/api/v1/groups/" + url.PathEscape(groups[0].ID) + "/users?limit=200"
+	for next != "" {
+		var users []struct {
+			Status  string `json:"status"`
+			Profile struct {
+				Email string `json:"email"`
+			} `json:"profile"`
+		}
+		link, err := o.get(next, &users)
+		if err != nil {
+			return Group{}, err
+		}
+		for _, user := range users {
+			if user.Profile.Email != "" && user.Status != "DEPROVISIONED" && user.Status != "SUSPENDED" {
+				group.Emails = append(group.Emails, user.Profile.Email)
+			}
+		}
+		next = ""
+		if match := oktaNextLink.FindStringSubmatch(link); match != nil {
+			next = match[1]
+		}
+	}
+	return group, nil
+}
+
+// get decodes the JSON response to an API request into v, returning its Link headers
+func (o *oktaDirectory) get(requestURL string, v interface{}) (string, error) {
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "SSWS "+o.token)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("okta request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("okta returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("failed to decode okta response: %w", err)
+	}
+	return strings.Join(resp.Header.Values("Link"), ", "), nil
+}
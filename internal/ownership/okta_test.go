@@ -0,0 +1,51 @@
+package ownership
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOktaDirectory_Group(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "SSWS secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch {
+		case r.URL.Path == "/api/v1/groups":
+			if r.URL.Query().Get("search") != `profile.name eq "team-payments"` {
+				fmt.Fprint(w, `[]`)
+				return
+			}
+			fmt.Fprint(w, `[{"id":"00g1","profile":{"name":"team-payments","slackChannel":"#payments"}}]`)
+		case r.URL.Path == "/api/v1/groups/00g1/users" && r.URL.Query().Get("after") == "":
+			w.Header().Add("Link", fmt.Sprintf(`<%s/api/v1/groups/00g1/users?limit=200>; rel="self"`, server.URL))
+			w.Header().Add("Link", fmt.Sprintf(`<%s/api/v1/groups/00g1/users?after=u2&limit=200>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"status":"ACTIVE","profile":{"email":"ana@example.com"}},{"status":"DEPROVISIONED","profile":{"email":"old@example.com"}}]`)
+		case r.URL.Path == "/api/v1/groups/00g1/users":
+			fmt.Fprint(w, `[{"status":"ACTIVE","profile":{"email":"bo@example.com"}}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	okta := newOktaDirectory(&Directory{URL: server.URL + "/"}, "secret")
+	group, err := okta.Group("team-payments", []string{"slackChannel"})
+	if err != nil {
+		t.Fatalf("Group() error = %v", err)
+	}
+	if want := []string{"ana@example.com", "bo@example.com"}; !reflect.DeepEqual(group.Emails, want) {
+		t.Errorf("Emails = %v, want %v", group.Emails, want)
+	}
+	if got := group.Attributes["slackChannel"]; got != "#payments" {
+		t.Errorf("slackChannel = %q, want #payments", got)
+	}
+
+	if _, err := okta.Group("team-unknown", nil); err == nil {
+		t.Error("Group() of an unknown group expected error")
+	}
+}
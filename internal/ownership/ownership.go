@@ -0,0 +1,134 @@
+package ownership
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mapping assigns jobs to the teams that own them
+//
+// Example ownership.yaml:
+//
+//	default_team: platform
+//	owners:
+//	  - team: payments
+//	    jobs: ["checkout", "billing"]
+//	    job_pattern: "^payments-.*"
+//	    labels:
+//	      slack_channel: "#payments-alerts"
+//	directory:               # Optional, see Directory
+//	  provider: ldap
+//	  url: ldaps://ldap.example.com
+//	  base_dn: ou=groups,dc=example,dc=com
+type Mapping struct {
+	DefaultTeam string     `yaml:"default_team"`
+	Owners      []Owner    `yaml:"owners"`
+	Directory   *Directory `yaml:"directory"`
+
+	patterns     []*regexp.Regexp // Compiled job_pattern per owner, nil when unset
+	defaultOwner Owner            // Owner of unmatched jobs, when default_team is set
+}
+
+// Owner is a team and the jobs it owns
+type Owner struct {
+	Team       string            `yaml:"team"`
+	Jobs       []string          `yaml:"jobs"`
+	JobPattern string            `yaml:"job_pattern"`
+	Labels     map[string]string `yaml:"labels"` // Extra routing labels, e.g. for Alertmanager
+	Group      string            `yaml:"group"`  // Directory group of the team, overriding directory.group
+
+	Contacts []string `yaml:"-"` // Email addresses resolved from the directory group
+}
+
+// Load reads and validates an ownership mapping file
+func Load(filename string) (*Mapping, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ownership file: %w", err)
+	}
+
+	var mapping Mapping
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse ownership file: %w", err)
+	}
+	if err := mapping.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &mapping, nil
+}
+
+// compile validates the owners and compiles their job patterns
+func (m *Mapping) compile() error {
+	if m.Directory != nil {
+		if err := m.Directory.validate(); err != nil {
+			return err
+		}
+	}
+	m.defaultOwner = Owner{Team: m.DefaultTeam}
+	m.patterns = make([]*regexp.Regexp, len(m.Owners))
+	for i, owner := range m.Owners {
+		if owner.Team == "" {
+			return fmt.Errorf("owners[%d]: team is required", i)
+		}
+		if len(owner.Jobs) == 0 && owner.JobPattern == "" {
+			return fmt.Errorf("owners[%d] (%s): jobs or job_pattern is required", i, owner.Team)
+		}
+		if owner.JobPattern != "" {
+			pattern, err := regexp.Compile(owner.JobPattern)
+			if err != nil {
+				return fmt.Errorf("owners[%d] (%s): invalid job_pattern: %w", i, owner.Team, err)
+			}
+			m.patterns[i] = pattern
+		}
+	}
+	return nil
+}
+
+// OwnerOf returns the owner of a job
+// Exact job names take precedence over patterns; among patterns the first match wins.
+// Unmatched jobs belong to default_team, if set. A nil mapping owns nothing.
+func (m *Mapping) OwnerOf(job string) (Owner, bool) {
+	if m == nil {
+		return Owner{}, false
+	}
+	for _, owner := range m.Owners {
+		for _, name := range owner.Jobs {
+			if name == job {
+				return owner, true
+			}
+		}
+	}
+	for i, owner := range m.Owners {
+		if m.patterns[i] != nil && m.patterns[i].MatchString(job) {
+			return owner, true
+		}
+	}
+	if m.DefaultTeam != "" {
+		return m.defaultOwner, true
+	}
+	return Owner{}, false
+}
+
+// RoutingLabels returns the labels used to route a job's alerts: team plus the owner's labels
+// It returns nil for jobs without an owner.
+func (m *Mapping) RoutingLabels(job string) map[string]string {
+	owner, ok := m.OwnerOf(job)
+	if !ok {
+		return nil
+	}
+	labels := map[string]string{"team": owner.Team}
+	for key, value := range owner.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// ContactsOf returns the email addresses resolved for the owner of a job
+// It returns nil for jobs without an owner and before ResolveContacts.
+func (m *Mapping) ContactsOf(job string) []string {
+	owner, _ := m.OwnerOf(job)
+	return owner.Contacts
+}
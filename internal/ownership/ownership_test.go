@@ -0,0 +1,94 @@
+package ownership
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testMapping = `
+default_team: platform
+owners:
+  - team: payments
+    jobs: ["checkout"]
+    job_pattern: "^payments-.*"
+    labels:
+      slack_channel: "#payments-alerts"
+  - team: search
+    job_pattern: "^(search|payments-search)"
+`
+
+func writeMapping(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "ownership.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write mapping: %v", err)
+	}
+	return path
+}
+
+func TestMapping_OwnerOf(t *testing.T) {
+	mapping, err := Load(writeMapping(t, testMapping))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		job  string
+		want string
+	}{
+		{"checkout", "payments"},
+		{"payments-api", "payments"},
+		{"payments-search", "payments"}, // first matching pattern wins
+		{"search-indexer", "search"},
+		{"node-exporter", "platform"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.job, func(t *testing.T) {
+			owner, ok := mapping.OwnerOf(tt.job)
+			if !ok || owner.Team != tt.want {
+				t.Errorf("OwnerOf(%q) = %q, %v, want %q", tt.job, owner.Team, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapping_RoutingLabels(t *testing.T) {
+	mapping, err := Load(writeMapping(t, testMapping))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got := mapping.RoutingLabels("checkout")
+	want := map[string]string{"team": "payments", "slack_channel": "#payments-alerts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RoutingLabels() = %v, want %v", got, want)
+	}
+
+	var none *Mapping
+	if labels := none.RoutingLabels("checkout"); labels != nil {
+		t.Errorf("nil mapping RoutingLabels() = %v, want nil", labels)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"missing team", "owners:\n  - jobs: [api]\n"},
+		{"missing jobs", "owners:\n  - team: payments\n"},
+		{"invalid pattern", "owners:\n  - team: payments\n    job_pattern: \"(\"\n"},
+		{"unknown directory provider", "directory:\n  provider: ad\n  url: ldap://ldap\n"},
+		{"ldap without base_dn", "directory:\n  provider: ldap\n  url: ldap://ldap\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(writeMapping(t, tt.content)); err == nil {
+				t.Error("Load() expected error")
+			}
+		})
+	}
+}
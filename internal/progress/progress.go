@@ -0,0 +1,160 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// barWidth is the number of cells of the bar drawn on terminals
+const barWidth = 24
+
+// Bar reports the progress of a fixed number of items
+// On a terminal it redraws one line with a bar, the elapsed time, an estimate of the time
+// left and the current item. Elsewhere, e.g. in CI logs, it prints a line at every 10%
+// instead, so logs are not flooded with carriage returns.
+type Bar struct {
+	label    string
+	total    int
+	done     int
+	out      io.Writer
+	terminal bool
+	started  time.Time
+	now      func() time.Time
+	printed  int // Last 10% step printed when not on a terminal
+}
+
+// NewBar returns a bar for total items writing to stdout
+func NewBar(label string, total int) *Bar {
+	return newBar(label, total, os.Stdout, isTerminal(os.Stdout), time.Now)
+}
+
+func newBar(label string, total int, out io.Writer, terminal bool, now func() time.Time) *Bar {
+	return &Bar{label: label, total: total, out: out, terminal: terminal, started: now(), now: now}
+}
+
+// isTerminal reports whether f is a character device, such as an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start reports that item, the next one, is being processed
+func (b *Bar) Start(item string) {
+	if !b.terminal || b.total == 0 {
+		return
+	}
+	b.draw(item)
+}
+
+// Done marks one item processed
+func (b *Bar) Done() {
+	b.done++
+	if b.total == 0 {
+		return
+	}
+	if b.terminal {
+		b.draw("")
+		return
+	}
+	step := b.done * 10 / b.total
+	if step != b.printed {
+		b.printed = step
+		fmt.Fprintf(b.out, "%s: %d/%d (%d%%) %s\n", b.label, b.done, b.total, b.done*100/b.total, formatDuration(b.now().Sub(b.started)))
+	}
+}
+
+// Finish ends the bar's line
+func (b *Bar) Finish() {
+	if b.terminal && b.total > 0 {
+		fmt.Fprintln(b.out)
+	}
+}
+
+// draw redraws the bar's line
+func (b *Bar) draw(item string) {
+	elapsed := b.now().Sub(b.started)
+	filled := b.done * barWidth / b.total
+	line := fmt.Sprintf("\r%s [%s%s] %d/%d %3d%% %s", b.label,
+		strings.Repeat("#", filled), strings.Repeat(".", barWidth-filled), b.done, b.total, b.done*100/b.total, formatDuration(elapsed))
+	if b.done > 0 && b.done < b.total {
+		left := time.Duration(float64(elapsed) / float64(b.done) * float64(b.total-b.done))
+		line += ", ~" + formatDuration(left) + " left"
+	}
+	if item != "" {
+		line += "  " + item
+	}
+	fmt.Fprint(b.out, line+"\x1b[K")
+}
+
+// formatDuration formats d as 1.2s below a minute and as 3m04s above
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// Timer records how long the phases of a run take, e.g. load, evaluate, format and upload
+type Timer struct {
+	phases    []string
+	durations map[string]time.Duration
+	current   string
+	started   time.Time
+	now       func() time.Time
+}
+
+// NewTimer returns a timer with no phase started
+func NewTimer() *Timer {
+	return &Timer{durations: make(map[string]time.Duration), now: time.Now}
+}
+
+// Start ends the current phase and starts phase
+// A phase started again accumulates its time.
+func (t *Timer) Start(phase string) {
+	t.Stop()
+	if _, seen := t.durations[phase]; !seen {
+		t.phases = append(t.phases, phase)
+		t.durations[phase] = 0
+	}
+	t.current = phase
+	t.started = t.now()
+}
+
+// Stop ends the current phase, if any
+func (t *Timer) Stop() {
+	if t.current == "" {
+		return
+	}
+	t.durations[t.current] += t.now().Sub(t.started)
+	t.current = ""
+}
+
+// Seconds returns the time spent in each phase so far, in seconds, including the running phase
+func (t *Timer) Seconds() map[string]float64 {
+	seconds := make(map[string]float64, len(t.phases))
+	for _, phase := range t.phases {
+		d := t.durations[phase]
+		if phase == t.current {
+			d += t.now().Sub(t.started)
+		}
+		seconds[phase] = d.Seconds()
+	}
+	return seconds
+}
+
+// Summary formats the phase times in the order the phases started,
+// e.g. "load 0.4s, evaluate 2.1s, format 0.3s (total 2.8s)"
+func (t *Timer) Summary() string {
+	seconds := t.Seconds()
+	parts := make([]string, 0, len(t.phases))
+	var total time.Duration
+	for _, phase := range t.phases {
+		d := time.Duration(seconds[phase] * float64(time.Second))
+		total += d
+		parts = append(parts, phase+" "+formatDuration(d))
+	}
+	return fmt.Sprintf("%s (total %s)", strings.Join(parts, ", "), formatDuration(total))
+}
@@ -0,0 +1,90 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock advances by step every time it is read
+func fakeClock(step time.Duration) func() time.Time {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestBar_Log(t *testing.T) {
+	var out bytes.Buffer
+	bar := newBar("Evaluating jobs", 20, &out, false, fakeClock(time.Second))
+	for i := 0; i < 20; i++ {
+		bar.Start("job")
+		bar.Done()
+	}
+	bar.Finish()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("printed %d lines, want one per 10%%:\n%s", len(lines), out.String())
+	}
+	if !strings.HasPrefix(lines[0], "Evaluating jobs: 2/20 (10%)") || !strings.HasPrefix(lines[9], "Evaluating jobs: 20/20 (100%)") {
+		t.Errorf("lines = %q", lines)
+	}
+	if strings.Contains(out.String(), "\r") {
+		t.Error("log output contains carriage returns")
+	}
+}
+
+func TestBar_Terminal(t *testing.T) {
+	var out bytes.Buffer
+	bar := newBar("Evaluating jobs", 4, &out, true, fakeClock(time.Second))
+	bar.Done()
+	bar.Start("payments-api")
+	bar.Finish()
+
+	last := out.String()[strings.LastIndex(out.String(), "\r"):]
+	for _, want := range []string{"[######..................] 1/4  25%", "left", "payments-api"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("line %q does not contain %q", last, want)
+		}
+	}
+	if !strings.HasSuffix(out.String(), "\n") {
+		t.Error("Finish() did not end the line")
+	}
+}
+
+func TestTimer(t *testing.T) {
+	timer := NewTimer()
+	timer.now = fakeClock(500 * time.Millisecond)
+
+	timer.Start("load")     // 0.5s
+	timer.Start("evaluate") // 1.0s: load took 0.5s
+	timer.Start("format")   // 1.5s: evaluate took 0.5s
+	timer.Start("evaluate") // 2.0s: format took 0.5s
+	timer.Stop()            // 2.5s: evaluate took another 0.5s
+
+	got := timer.Seconds()
+	want := map[string]float64{"load": 0.5, "evaluate": 1, "format": 0.5}
+	for phase, seconds := range want {
+		if got[phase] != seconds {
+			t.Errorf("Seconds()[%s] = %v, want %v", phase, got[phase], seconds)
+		}
+	}
+	if summary := timer.Summary(); summary != "load 0.5s, evaluate 1.0s, format 0.5s (total 2.0s)" {
+		t.Errorf("Summary() = %q", summary)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		1500 * time.Millisecond: "1.5s",
+		64 * time.Second:        "1m04s",
+		11 * time.Minute:        "11m00s",
+	} {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%s) = %s, want %s", d, got, want)
+		}
+	}
+}
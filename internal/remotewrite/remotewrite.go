@@ -0,0 +1,270 @@
+// Package remotewrite pushes samples to a Prometheus remote_write endpoint (Prometheus,
+// Mimir, Cortex, Thanos Receive, VictoriaMetrics, ...), the protocol 1.0 wire format:
+// a snappy-compressed protobuf WriteRequest
+package remotewrite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables holding the credentials, so they stay out of shell history and CI logs
+const (
+	PasswordEnv    = "REMOTE_WRITE_PASSWORD"
+	BearerTokenEnv = "REMOTE_WRITE_BEARER_TOKEN"
+)
+
+// MaxSeriesPerRequest splits large pushes, like Prometheus' default max_samples_per_send
+const MaxSeriesPerRequest = 2000
+
+// Label is a label of a series; "__name__" holds the metric name
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Series is one sample of a series
+type Series struct {
+	Labels []Label
+	Value  float64
+}
+
+// Client pushes series to a remote_write URL
+type Client struct {
+	URL         string
+	Username    string // Basic auth, with Password
+	Password    string
+	BearerToken string // Sent instead of basic auth when set
+	Headers     map[string]string
+	HTTP        *http.Client
+	Attempts    int           // Tries per request, for network errors and 5xx or 429 responses
+	RetryDelay  time.Duration // Doubled after each failed try
+}
+
+// NewClient creates a client retrying each request 3 times
+func NewClient(url string) *Client {
+	return &Client{
+		URL:        url,
+		HTTP:       &http.Client{Timeout: 30 * time.Second},
+		Attempts:   3,
+		RetryDelay: time.Second,
+	}
+}
+
+// Push sends the series, all with the sample timestamp at, in requests of at most
+// MaxSeriesPerRequest series
+func (c *Client) Push(series []Series, at time.Time) error {
+	for start := 0; start < len(series); start += MaxSeriesPerRequest {
+		end := min(start+MaxSeriesPerRequest, len(series))
+		body := snappyEncode(encodeWriteRequest(series[start:end], at.UnixMilli()))
+		if err := c.send(body); err != nil {
+			return fmt.Errorf("remote write to %s failed: %w", c.URL, err)
+		}
+	}
+	return nil
+}
+
+// send posts one encoded WriteRequest, retrying what a retry can fix
+func (c *Client) send(body []byte) error {
+	delay := c.RetryDelay
+	var lastErr error
+	for attempt := 0; attempt < max(c.Attempts, 1); attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := http.NewRequest("POST", c.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("Content-Encoding", "snappy")
+		req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+		req.Header.Set("User-Agent", "instrumentation-score")
+		for name, value := range c.Headers {
+			req.Header.Set(name, value)
+		}
+		if c.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+		} else if c.Username != "" {
+			req.SetBasicAuth(c.Username, c.Password)
+		}
+
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+
+		err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+		switch {
+		case resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+			lastErr = err
+		default:
+			// The endpoint rejected the data, e.g. out-of-order samples; sending it again fails the same way
+			return err
+		}
+	}
+	return lastErr
+}
+
+// ParseText reads the samples of the Prometheus text exposition format, as written by
+// evaluate --output prometheus. Comments are skipped; sample timestamps are not supported,
+// as Push sets one timestamp for every sample.
+func ParseText(text string) ([]Series, error) {
+	var series []Series
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		s, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+// parseSample parses `name{label="value",...} value`
+func parseSample(line string) (Series, error) {
+	nameEnd := strings.IndexAny(line, "{ ")
+	if nameEnd <= 0 {
+		return Series{}, fmt.Errorf("invalid sample %q", line)
+	}
+	labels := []Label{{Name: "__name__", Value: line[:nameEnd]}}
+	rest := line[nameEnd:]
+
+	if strings.HasPrefix(rest, "{") {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, " ,")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			eq := strings.Index(rest, `="`)
+			if eq <= 0 {
+				return Series{}, fmt.Errorf("invalid labels in %q", line)
+			}
+			name := strings.TrimSpace(rest[:eq])
+			value, remaining, err := unquoteLabelValue(rest[eq+2:])
+			if err != nil {
+				return Series{}, fmt.Errorf("%w in %q", err, line)
+			}
+			labels = append(labels, Label{Name: name, Value: value})
+			rest = remaining
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) != 1 {
+		return Series{}, fmt.Errorf("expected one value in %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Series{}, fmt.Errorf("invalid value in %q: %w", line, err)
+	}
+
+	// Remote write requires labels sorted by name
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return Series{Labels: labels, Value: value}, nil
+}
+
+// unquoteLabelValue reads an escaped label value up to its closing quote, returning the rest
+func unquoteLabelValue(s string) (string, string, error) {
+	var value strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return value.String(), s[i+1:], nil
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", fmt.Errorf("unterminated label value")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				value.WriteByte('\n')
+			default:
+				value.WriteByte(s[i])
+			}
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated label value")
+}
+
+// encodeWriteRequest encodes the prometheus.WriteRequest protobuf message:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []Series, timestampMillis int64) []byte {
+	var request []byte
+	for _, s := range series {
+		var ts []byte
+		for _, label := range s.Labels {
+			var l []byte
+			l = appendString(l, 1, label.Name)
+			l = appendString(l, 2, label.Value)
+			ts = appendBytes(ts, 1, l)
+		}
+		var sample []byte
+		sample = append(sample, 1<<3|1) // Field 1, 64-bit
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.Value))
+		sample = append(sample, 2<<3|0) // Field 2, varint
+		sample = binary.AppendUvarint(sample, uint64(timestampMillis))
+		ts = appendBytes(ts, 2, sample)
+		request = appendBytes(request, 1, ts)
+	}
+	return request
+}
+
+// appendBytes appends a length-delimited protobuf field
+func appendBytes(b []byte, field int, value []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|2))
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendString(b []byte, field int, value string) []byte {
+	return appendBytes(b, field, []byte(value))
+}
+
+// snappyEncode frames data in the snappy block format remote write requires. It emits
+// literals only: valid for every snappy decoder, and score pushes are small enough that
+// compressing them is not worth a dependency.
+func snappyEncode(data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		chunk := data[:min(len(data), 1<<16)]
+		data = data[len(chunk):]
+		n := len(chunk) - 1
+		if n < 60 {
+			out = append(out, byte(n<<2))
+		} else if n < 1<<8 {
+			out = append(out, 60<<2, byte(n))
+		} else {
+			out = append(out, 61<<2, byte(n), byte(n>>8))
+		}
+		out = append(out, chunk...)
+	}
+	return out
+}
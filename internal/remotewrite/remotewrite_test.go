@@ -0,0 +1,174 @@
+package remotewrite
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const exposition = `# HELP instrumentation_quality_score Instrumentation quality score per job (0-100)
+# TYPE instrumentation_quality_score gauge
+instrumentation_quality_score{job="api",env="prod"} 90.74
+
+instrumentation_rule_failed_metrics{job="say \"hi\"\n",rule_id="PROM-MET-01"} 3
+instrumentation_organization_score 71.5
+`
+
+func TestParseText(t *testing.T) {
+	series, err := ParseText(exposition)
+	if err != nil {
+		t.Fatalf("ParseText() error = %v", err)
+	}
+	want := []Series{
+		{Labels: []Label{{"__name__", "instrumentation_quality_score"}, {"env", "prod"}, {"job", "api"}}, Value: 90.74},
+		{Labels: []Label{{"__name__", "instrumentation_rule_failed_metrics"}, {"job", "say \"hi\"\n"}, {"rule_id", "PROM-MET-01"}}, Value: 3},
+		{Labels: []Label{{"__name__", "instrumentation_organization_score"}}, Value: 71.5},
+	}
+	if !reflect.DeepEqual(series, want) {
+		t.Errorf("ParseText() = %+v\nwant %+v", series, want)
+	}
+
+	for _, invalid := range []string{`metric{job="api} 1`, `metric{job} 1`, `metric NaN? 1`, `{job="a"} 1`} {
+		if _, err := ParseText(invalid); err == nil {
+			t.Errorf("ParseText(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestPush(t *testing.T) {
+	var requests atomic.Int32
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		user, password, _ := r.BasicAuth()
+		if r.Header.Get("Content-Encoding") != "snappy" || user != "tenant" || password != "secret" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = snappyDecode(t, body)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.Username, client.Password = "tenant", "secret"
+	client.RetryDelay = time.Millisecond
+	series := []Series{{Labels: []Label{{"__name__", "up"}, {"job", "api"}}, Value: 1.5}}
+	if err := client.Push(series, time.UnixMilli(1700000000123)); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want a retry after the 503", requests.Load())
+	}
+
+	// WriteRequest.timeseries[0]
+	timeseries := fields(t, fields(t, received)[0].bytes)
+	if len(timeseries) != 3 {
+		t.Fatalf("timeseries has %d fields, want 2 labels and a sample", len(timeseries))
+	}
+	label := fields(t, timeseries[1].bytes)
+	if string(label[0].bytes) != "job" || string(label[1].bytes) != "api" {
+		t.Errorf("label = %q=%q", label[0].bytes, label[1].bytes)
+	}
+	sample := fields(t, timeseries[2].bytes)
+	if math.Float64frombits(sample[0].fixed64) != 1.5 || sample[1].varint != 1700000000123 {
+		t.Errorf("sample = %v at %d", math.Float64frombits(sample[0].fixed64), sample[1].varint)
+	}
+}
+
+func TestPush_Rejected(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.BearerToken = "token"
+	err := client.Push([]Series{{Labels: []Label{{"__name__", "up"}}, Value: 1}}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "HTTP 400: out of order sample") {
+		t.Errorf("Push() error = %v, want the endpoint's message", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("requests = %d, want no retry of a rejected request", requests.Load())
+	}
+}
+
+func TestSnappyEncode(t *testing.T) {
+	data := []byte(strings.Repeat("instrumentation_quality_score ", 5000))
+	if got := snappyDecode(t, snappyEncode(data)); string(got) != string(data) {
+		t.Errorf("snappy round trip lost data: %d bytes, want %d", len(got), len(data))
+	}
+}
+
+// snappyDecode decodes the literal-only snappy blocks snappyEncode writes
+func snappyDecode(t *testing.T, data []byte) []byte {
+	t.Helper()
+	length, n := binary.Uvarint(data)
+	data = data[n:]
+	var out []byte
+	for len(data) > 0 {
+		tag := data[0]
+		if tag&3 != 0 {
+			t.Fatalf("unexpected copy element %x", tag)
+		}
+		size, header := int(tag>>2)+1, 1
+		switch tag >> 2 {
+		case 60:
+			size, header = int(data[1])+1, 2
+		case 61:
+			size, header = int(data[1])|int(data[2])<<8+1, 3
+		}
+		out = append(out, data[header:header+size]...)
+		data = data[header+size:]
+	}
+	if uint64(len(out)) != length {
+		t.Fatalf("decoded %d bytes, header says %d", len(out), length)
+	}
+	return out
+}
+
+type field struct {
+	bytes   []byte
+	varint  uint64
+	fixed64 uint64
+}
+
+// fields decodes the fields of a protobuf message, in order
+func fields(t *testing.T, data []byte) []field {
+	t.Helper()
+	var result []field
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		data = data[n:]
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(data)
+			result = append(result, field{varint: v})
+			data = data[n:]
+		case 1:
+			result = append(result, field{fixed64: binary.LittleEndian.Uint64(data)})
+			data = data[8:]
+		case 2:
+			size, n := binary.Uvarint(data)
+			result = append(result, field{bytes: data[n : n+int(size)]})
+			data = data[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return result
+}
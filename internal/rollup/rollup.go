@@ -0,0 +1,165 @@
+package rollup
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"instrumentation-score/internal/orgscore"
+	"instrumentation-score/internal/storage"
+)
+
+// Config lists the business units whose latest evaluations are rolled up into one report
+//
+// Example units.yaml:
+//
+//	units:
+//	  - name: payments
+//	    bucket: payments-observability
+//	    prefix: instrumentation-reports
+//	  - name: search
+//	    bucket: search-observability
+//	    prefix: reports/prod
+//	    region: us-east-1                                         # Optional, default eu-west-1
+//	    role_arn: arn:aws:iam::123456789012:role/score-reader     # Optional, for other accounts
+type Config struct {
+	Units []Unit `yaml:"units"`
+}
+
+// Unit is where one business unit uploads its evaluations (evaluate --s3-upload)
+type Unit struct {
+	Name    string `yaml:"name"`
+	Bucket  string `yaml:"bucket"`
+	Prefix  string `yaml:"prefix"`
+	Region  string `yaml:"region"`
+	RoleARN string `yaml:"role_arn"`
+}
+
+// Load reads and validates a units file
+func Load(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read units file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse units file: %w", err)
+	}
+	if len(config.Units) == 0 {
+		return nil, fmt.Errorf("%s: no units defined", filename)
+	}
+	seen := make(map[string]bool)
+	for i := range config.Units {
+		unit := &config.Units[i]
+		if unit.Name == "" {
+			return nil, fmt.Errorf("%s: units[%d]: name is required", filename, i)
+		}
+		if unit.Bucket == "" {
+			return nil, fmt.Errorf("%s: units[%d] (%s): bucket is required", filename, i, unit.Name)
+		}
+		if seen[unit.Name] {
+			return nil, fmt.Errorf("%s: units[%d]: duplicate unit %s", filename, i, unit.Name)
+		}
+		seen[unit.Name] = true
+		if unit.Region == "" {
+			unit.Region = "eu-west-1"
+		}
+	}
+	return &config, nil
+}
+
+// Source is the S3 location of the unit's evaluations
+func (u Unit) Source() string {
+	return fmt.Sprintf("s3://%s/%s", u.Bucket, u.Prefix)
+}
+
+// UnitSummary is the latest evaluation of one business unit
+type UnitSummary struct {
+	Unit             string  `json:"unit"`
+	Source           string  `json:"source"`
+	RunID            string  `json:"run_id,omitempty"`
+	Timestamp        string  `json:"timestamp,omitempty"`
+	TotalJobs        int     `json:"total_jobs"`
+	AverageScore     float64 `json:"average_score"`
+	TotalCardinality int64   `json:"total_cardinality"`
+	TotalCost        float64 `json:"total_cost,omitempty"`
+	Error            string  `json:"error,omitempty"` // Why the latest evaluation could not be read
+
+	// How the unit's job scores spread, when its manifest records them
+	Distribution *orgscore.Distribution `json:"score_distribution,omitempty"`
+	scores       []float64
+}
+
+// Summarize describes a unit from the manifest of its latest evaluation
+func Summarize(unit Unit, manifest *storage.EvaluationManifest) UnitSummary {
+	return UnitSummary{
+		Unit:             unit.Name,
+		Source:           unit.Source(),
+		RunID:            manifest.RunID,
+		Timestamp:        manifest.Timestamp,
+		TotalJobs:        manifest.TotalJobs,
+		AverageScore:     manifest.AverageScore,
+		TotalCardinality: manifest.TotalCardinality,
+		TotalCost:        manifest.TotalCost,
+		Distribution:     unitDistribution(manifest),
+		scores:           manifest.JobScores,
+	}
+}
+
+// unitDistribution is the distribution of a unit's job scores, computed from them when the
+// manifest lists them, and nil for manifests of releases that recorded neither
+func unitDistribution(manifest *storage.EvaluationManifest) *orgscore.Distribution {
+	if len(manifest.JobScores) > 0 {
+		distribution := orgscore.Distribute(manifest.JobScores)
+		return &distribution
+	}
+	return manifest.ScoreDistribution
+}
+
+// Failed describes a unit whose latest evaluation could not be read
+func Failed(unit Unit, err error) UnitSummary {
+	return UnitSummary{Unit: unit.Name, Source: unit.Source(), Error: err.Error()}
+}
+
+// Report is the organization-wide rollup of the latest evaluation of every unit
+type Report struct {
+	Timestamp        string        `json:"timestamp"`
+	Units            []UnitSummary `json:"units"`
+	TotalJobs        int           `json:"total_jobs"`
+	AverageScore     float64       `json:"average_score"` // Over all jobs, so larger units weigh more
+	TotalCardinality int64         `json:"total_cardinality"`
+	TotalCost        float64       `json:"total_cost,omitempty"`
+	MissingUnits     int           `json:"missing_units,omitempty"` // Units left out of the totals
+
+	// How job scores spread over all units, since a few excellent jobs can mask many poor ones
+	// Only units whose manifests list job scores count: Jobs is below TotalJobs when some do not.
+	Distribution *orgscore.Distribution `json:"score_distribution,omitempty"`
+}
+
+// NewReport totals the units that could be read
+func NewReport(units []UnitSummary, timestamp string) Report {
+	report := Report{Timestamp: timestamp, Units: units}
+	var scoreSum float64
+	var scores []float64
+	for _, unit := range units {
+		if unit.Error != "" {
+			report.MissingUnits++
+			continue
+		}
+		scores = append(scores, unit.scores...)
+		report.TotalJobs += unit.TotalJobs
+		report.TotalCardinality += unit.TotalCardinality
+		report.TotalCost += unit.TotalCost
+		scoreSum += unit.AverageScore * float64(unit.TotalJobs)
+	}
+	if report.TotalJobs > 0 {
+		report.AverageScore = scoreSum / float64(report.TotalJobs)
+	}
+	if len(scores) > 0 {
+		distribution := orgscore.Distribute(scores)
+		report.Distribution = &distribution
+	}
+	return report
+}
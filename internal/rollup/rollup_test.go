@@ -0,0 +1,99 @@
+package rollup
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/storage"
+)
+
+func writeUnits(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "units.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write units file: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	config, err := Load(writeUnits(t, `units:
+  - name: payments
+    bucket: payments-obs
+    prefix: reports
+  - name: search
+    bucket: search-obs
+    region: us-east-1
+    role_arn: arn:aws:iam::123456789012:role/reader
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(config.Units) != 2 {
+		t.Fatalf("Load() returned %d units, want 2", len(config.Units))
+	}
+	if config.Units[0].Region != "eu-west-1" {
+		t.Errorf("default region = %q, want eu-west-1", config.Units[0].Region)
+	}
+	if config.Units[1].RoleARN == "" || config.Units[1].Region != "us-east-1" {
+		t.Errorf("units[1] = %+v", config.Units[1])
+	}
+	if got := config.Units[0].Source(); got != "s3://payments-obs/reports" {
+		t.Errorf("Source() = %q", got)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"no units", "units: []\n", "no units"},
+		{"missing name", "units:\n  - bucket: b\n", "name is required"},
+		{"missing bucket", "units:\n  - name: a\n", "bucket is required"},
+		{"duplicate", "units:\n  - name: a\n    bucket: b\n  - name: a\n    bucket: c\n", "duplicate unit a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeUnits(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	payments := Unit{Name: "payments", Bucket: "p"}
+	search := Unit{Name: "search", Bucket: "s"}
+	ads := Unit{Name: "ads", Bucket: "a"}
+
+	report := NewReport([]UnitSummary{
+		Summarize(payments, &storage.EvaluationManifest{RunID: "r1", TotalJobs: 30, AverageScore: 90, TotalCardinality: 1000, TotalCost: 6}),
+		Summarize(search, &storage.EvaluationManifest{RunID: "r2", TotalJobs: 10, AverageScore: 50, TotalCardinality: 500, TotalCost: 3,
+			JobScores: []float64{20, 30, 40, 50, 60, 60, 60, 60, 60, 60}}),
+		Failed(ads, errors.New("access denied")),
+	}, "2025-11-02T16:00:00Z")
+
+	if report.TotalJobs != 40 || report.TotalCardinality != 1500 || report.TotalCost != 9 {
+		t.Errorf("totals = %d jobs, %d series, %.2f cost", report.TotalJobs, report.TotalCardinality, report.TotalCost)
+	}
+	// (30*90 + 10*50) / 40
+	if math.Abs(report.AverageScore-80) > 0.001 {
+		t.Errorf("AverageScore = %v, want 80 (weighted by jobs)", report.AverageScore)
+	}
+	// Only search records its job scores
+	if d := report.Distribution; d == nil || d.Jobs != 10 || d.Median != 60 || d.Min != 20 {
+		t.Errorf("Distribution = %+v, want search's 10 job scores", d)
+	}
+	if report.Units[0].Distribution != nil || report.Units[1].Distribution == nil || report.Units[1].Distribution.P10 != 29 {
+		t.Errorf("unit distributions = %+v, %+v", report.Units[0].Distribution, report.Units[1].Distribution)
+	}
+	if report.MissingUnits != 1 || report.Units[2].Error != "access denied" {
+		t.Errorf("MissingUnits = %d, units[2] = %+v", report.MissingUnits, report.Units[2])
+	}
+}
@@ -0,0 +1,139 @@
+package runconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"instrumentation-score/internal/engine"
+)
+
+// FileName is the snapshot analyze writes into a job metrics directory
+const FileName = "run_config.json"
+
+// redacted replaces secret values in snapshots
+const redacted = "[redacted]"
+
+// secretNames are substrings of flag and environment variable names holding credentials
+// Callback URLs are included because webhook URLs commonly embed their token.
+var secretNames = []string{"token", "password", "secret", "login", "key", "callback"}
+
+// Snapshot is the normalized effective configuration of a run
+// Snapshots of two runs can be diffed to explain why their results differ. Every value is
+// a string and maps are written sorted, so identical configurations produce identical JSON.
+type Snapshot struct {
+	Command    string                  `json:"command"`
+	Flags      map[string]string       `json:"flags"`                // Every flag with its effective value, defaults included
+	Env        map[string]string       `json:"env,omitempty"`        // Environment variables the command reads that are set
+	Files      map[string]string       `json:"files,omitempty"`      // Configuration files read, by path, with the sha256 of their content
+	RulesHash  string                  `json:"rules_hash,omitempty"` // sha256 of the effective rules, included packs merged
+	Exclusions []engine.ExclusionEntry `json:"exclusions,omitempty"`
+	Inclusions []engine.InclusionEntry `json:"inclusions,omitempty"`
+	CostModel  *CostModel              `json:"cost_model,omitempty"`
+	Analysis   *Snapshot               `json:"analysis,omitempty"` // The analyze run that wrote the evaluated job files
+}
+
+// CostModel is how estimated costs were calculated
+type CostModel struct {
+	UnitPrice float64 `json:"unit_price"`
+	Unit      string  `json:"unit"`
+}
+
+// Capture records the effective value of every flag and of the listed environment variables
+// Credentials are redacted: values of names containing token, password, secret, login, key or
+// callback, and the password of URLs.
+func Capture(command string, flags *pflag.FlagSet, envNames []string) *Snapshot {
+	snapshot := &Snapshot{Command: command, Flags: make(map[string]string)}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		snapshot.Flags[flag.Name] = redact(flag.Name, flag.Value.String())
+	})
+	for _, name := range envNames {
+		if value, ok := os.LookupEnv(name); ok {
+			if snapshot.Env == nil {
+				snapshot.Env = make(map[string]string)
+			}
+			snapshot.Env[name] = redact(name, value)
+		}
+	}
+	return snapshot
+}
+
+// redact hides the value of a secret setting
+func redact(name, value string) string {
+	if value == "" || value == "[]" {
+		return value
+	}
+	lower := strings.ToLower(name)
+	for _, secret := range secretNames {
+		if strings.Contains(lower, secret) {
+			return redacted
+		}
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return u.String()
+		}
+	}
+	return value
+}
+
+// AddFile records the sha256 of a configuration file the run read
+// Unset paths are skipped; unreadable files are recorded as such.
+func (s *Snapshot) AddFile(path string) {
+	if path == "" {
+		return
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]string)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		s.Files[path] = "unreadable: " + err.Error()
+		return
+	}
+	sum := sha256.Sum256(data)
+	s.Files[path] = hex.EncodeToString(sum[:])
+}
+
+// Load reads a snapshot file
+func Load(filename string) (*Snapshot, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	snapshot, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return snapshot, nil
+}
+
+// Read parses a snapshot from r
+func Read(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse run configuration: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Write saves the snapshot as indented JSON
+func (s *Snapshot) Write(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run configuration: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		return fmt.Errorf("failed to write run configuration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Release assets besides the binary archives
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig" // Ed25519 signature of checksums.txt
+)
+
+// DefaultReleaseURL is the release endpoint of the project on GitHub
+const DefaultReleaseURL = "https://api.github.com/repos/chit786/instrumentation-score/releases/latest"
+
+// maxDownloadBytes bounds the size of a downloaded asset
+const maxDownloadBytes = 256 << 20
+
+// Release is a published release, in the shape of the GitHub releases API
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Asset returns the asset called name
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// ArchiveName returns the name of the release archive for a platform,
+// e.g. instrumentation-score-linux-arm64.tar.gz
+func ArchiveName(goos, goarch string) string {
+	if goos == "windows" {
+		return fmt.Sprintf("instrumentation-score-%s-%s.zip", goos, goarch)
+	}
+	return fmt.Sprintf("instrumentation-score-%s-%s.tar.gz", goos, goarch)
+}
+
+// Client fetches releases and their assets
+type Client struct {
+	HTTP  *http.Client
+	Token string // Sent as a bearer token to GitHub when set, e.g. GITHUB_TOKEN against API rate limits
+}
+
+// tokenHosts are the hosts Client.Token is sent to; mirrors and asset hosts never see it
+var tokenHosts = map[string]bool{"api.github.com": true, "github.com": true}
+
+// Latest returns the release the endpoint at url describes
+func (c *Client) Latest(url string) (*Release, error) {
+	data, err := c.Download(url)
+	if err != nil {
+		return nil, err
+	}
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release from %s: %w", url, err)
+	}
+	if release.Tag == "" {
+		return nil, fmt.Errorf("release from %s has no tag_name", url)
+	}
+	return &release, nil
+}
+
+// Download returns the body of url
+func (c *Client) Download(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" && req.URL.Scheme == "https" && tokenHosts[req.URL.Hostname()] {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if len(data) > maxDownloadBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxDownloadBytes)
+	}
+	return data, nil
+}
+
+// VerifyChecksum checks archive against its SHA-256 in checksums, in sha256sum format
+func VerifyChecksum(archive, checksums []byte, name string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(archive)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s", name)
+}
+
+// VerifySignature checks the Ed25519 signature of checksums, raw or base64 encoded,
+// against publicKey, the base64 encoded raw public key
+func VerifySignature(checksums, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key: want %d base64 encoded bytes", ed25519.PublicKeySize)
+	}
+	if len(signature) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+		if err != nil || len(decoded) != ed25519.SignatureSize {
+			return fmt.Errorf("invalid signature")
+		}
+		signature = decoded
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, signature) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// ExtractBinary returns the instrumentation-score binary in a release archive,
+// a .zip when name ends with .zip and a .tar.gz otherwise
+func ExtractBinary(archive []byte, name string) ([]byte, error) {
+	if strings.HasSuffix(name, ".zip") {
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		for _, file := range reader.File {
+			if !isBinary(file.Name) {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxDownloadBytes))
+		}
+		return nil, fmt.Errorf("no binary in %s", name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer gz.Close()
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no binary in %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if header.Typeflag == tar.TypeReg && isBinary(header.Name) {
+			return io.ReadAll(io.LimitReader(reader, maxDownloadBytes))
+		}
+	}
+}
+
+// isBinary reports whether an archive entry is the instrumentation-score binary
+func isBinary(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), "instrumentation-score")
+}
+
+// Replace atomically replaces the executable at path with binary, keeping its permissions
+// The new binary is written next to the old one and renamed over it. A running
+// executable cannot be replaced on Windows, so it is moved aside to <path>.old first.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".instrumentation-score-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err == nil {
+		return nil
+	}
+	old := path + ".old"
+	os.Remove(old)
+	if err := os.Rename(path, old); err != nil {
+		return fmt.Errorf("failed to move %s aside: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Rename(old, path)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// Newer reports whether version latest is newer than current
+// Versions are compared as vMAJOR.MINOR.PATCH; a pre-release is older than its release.
+// A current version that is not a release, such as "dev", is never older.
+func Newer(latest, current string) bool {
+	l, lPre, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, cPre, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return cPre != "" && (lPre == "" || lPre > cPre)
+}
+
+// parseVersion splits v1.2.3-rc.1 into its numbers and pre-release
+func parseVersion(version string) ([3]int, string, bool) {
+	var numbers [3]int
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "+") // Build metadata does not order versions
+	core, pre, _ := strings.Cut(version, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return numbers, "", false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return numbers, "", false
+		}
+		numbers[i] = n
+	}
+	return numbers, pre, true
+}
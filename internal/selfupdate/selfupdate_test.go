@@ -0,0 +1,191 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tarGz(t *testing.T, name string, content []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// roundTripFunc answers the requests of an http.Client, whatever their host
+type roundTripFunc func(*http.Request) *httptest.ResponseRecorder
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req).Result(), nil
+}
+
+func TestClient_Latest(t *testing.T) {
+	var authorization string
+	client := &Client{Token: "token", HTTP: &http.Client{Transport: roundTripFunc(func(r *http.Request) *httptest.ResponseRecorder {
+		authorization = r.Header.Get("Authorization")
+		rec := httptest.NewRecorder()
+		fmt.Fprint(rec, `{"tag_name":"v1.4.0","assets":[{"name":"checksums.txt","browser_download_url":"https://example.com/checksums.txt"}]}`)
+		return rec
+	})}}
+
+	release, err := client.Latest("https://api.github.com/repos/chit786/instrumentation-score/releases/latest")
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if release.Tag != "v1.4.0" {
+		t.Errorf("Tag = %s, want v1.4.0", release.Tag)
+	}
+	if asset, ok := release.Asset(ChecksumsAsset); !ok || asset.URL != "https://example.com/checksums.txt" {
+		t.Errorf("Asset(%s) = %+v, %v", ChecksumsAsset, asset, ok)
+	}
+	if authorization != "Bearer token" {
+		t.Errorf("Authorization to api.github.com = %q, want the token", authorization)
+	}
+
+	for _, url := range []string{
+		"https://mirror.example.com/releases/latest",
+		"https://objects.githubusercontent.com/checksums.txt",
+		"http://api.github.com/repos/chit786/instrumentation-score/releases/latest",
+	} {
+		if _, err := client.Download(url); err != nil {
+			t.Fatalf("Download(%s) error = %v", url, err)
+		}
+		if authorization != "" {
+			t.Errorf("Authorization to %s = %q, want none", url, authorization)
+		}
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	archive := []byte("archive")
+	sum := sha256.Sum256(archive)
+	checksums := []byte(fmt.Sprintf("0000  other.tar.gz\n%s  instrumentation-score-linux-amd64.tar.gz\n", hex.EncodeToString(sum[:])))
+
+	if err := VerifyChecksum(archive, checksums, "instrumentation-score-linux-amd64.tar.gz"); err != nil {
+		t.Errorf("VerifyChecksum() error = %v", err)
+	}
+	if err := VerifyChecksum([]byte("tampered"), checksums, "instrumentation-score-linux-amd64.tar.gz"); err == nil {
+		t.Error("VerifyChecksum() of a tampered archive expected error")
+	}
+	if err := VerifyChecksum(archive, checksums, "instrumentation-score-darwin-arm64.tar.gz"); err == nil {
+		t.Error("VerifyChecksum() without a checksum expected error")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := base64.StdEncoding.EncodeToString(public)
+	checksums := []byte("abc  instrumentation-score-linux-amd64.tar.gz\n")
+	signature := ed25519.Sign(private, checksums)
+
+	tests := []struct {
+		name      string
+		checksums []byte
+		signature []byte
+		key       string
+		wantErr   bool
+	}{
+		{name: "raw signature", checksums: checksums, signature: signature, key: key},
+		{name: "base64 signature", checksums: checksums, signature: []byte(base64.StdEncoding.EncodeToString(signature) + "\n"), key: key},
+		{name: "tampered checksums", checksums: []byte("def  instrumentation-score-linux-amd64.tar.gz\n"), signature: signature, key: key, wantErr: true},
+		{name: "invalid key", checksums: checksums, signature: signature, key: "not-a-key", wantErr: true},
+		{name: "invalid signature", checksums: checksums, signature: []byte("short"), key: key, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifySignature(tt.checksums, tt.signature, tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractBinary(t *testing.T) {
+	binary := []byte("#!binary")
+
+	got, err := ExtractBinary(tarGz(t, "instrumentation-score-linux-amd64", binary), "instrumentation-score-linux-amd64.tar.gz")
+	if err != nil || !bytes.Equal(got, binary) {
+		t.Errorf("ExtractBinary(tar.gz) = %q, %v", got, err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("instrumentation-score-windows-amd64.exe")
+	w.Write(binary)
+	zw.Close()
+	got, err = ExtractBinary(buf.Bytes(), "instrumentation-score-windows-amd64.zip")
+	if err != nil || !bytes.Equal(got, binary) {
+		t.Errorf("ExtractBinary(zip) = %q, %v", got, err)
+	}
+
+	if _, err := ExtractBinary(tarGz(t, "README.md", binary), "instrumentation-score-linux-amd64.tar.gz"); err == nil {
+		t.Error("ExtractBinary() of an archive without the binary expected error")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instrumentation-score")
+	if err := os.WriteFile(path, []byte("old"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("binary = %q, %v, want new", data, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0750 {
+		t.Errorf("mode = %v, %v, want 0750", info.Mode().Perm(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the binary", len(entries))
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.4.0", "v1.3.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v2.0.0", "v1.99.99", true},
+		{"v1.4.0", "v1.4.0", false},
+		{"v1.3.0", "v1.4.0", false},
+		{"v1.4.0", "v1.4.0-rc.1", true},
+		{"v1.4.0-rc.2", "v1.4.0-rc.1", true},
+		{"v1.4.0-rc.1", "v1.4.0", false},
+		{"1.4.0", "v1.3.0+build.5", true},
+		{"v1.4.0", "dev", false},
+		{"latest", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("Newer(%s, %s) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
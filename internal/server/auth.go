@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AllTeams in the teams of a user or token grants access to every job, including jobs no team owns
+const AllTeams = "*"
+
+// AuthConfig configures who may use the API and which teams' jobs they see
+// Secrets are stored as hex SHA-256 digests, e.g. from `printf %s "$TOKEN" | sha256sum`.
+//
+// Example auth.yaml:
+//
+//	users:                                 # HTTP basic auth, e.g. for the dashboard
+//	  - name: ana
+//	    password_sha256: 9f86d081884c7d65...
+//	    teams: [payments]
+//	tokens:                                # Bearer tokens, e.g. for CI and Prometheus
+//	  - name: ci
+//	    token_sha256: 60303ae22b998861...
+//	    teams: ["*"]
+//	oidc:                                  # Bearer ID or access tokens of an OIDC provider
+//	  issuer: https://login.example.com
+//	  audience: instrumentation-score
+//	  teams_claim: groups
+type AuthConfig struct {
+	Users  []CredentialConfig `yaml:"users"`
+	Tokens []CredentialConfig `yaml:"tokens"`
+	OIDC   *OIDCConfig        `yaml:"oidc"`
+}
+
+// CredentialConfig is a basic auth user or a bearer token and the teams it may see
+type CredentialConfig struct {
+	Name           string   `yaml:"name"`
+	PasswordSHA256 string   `yaml:"password_sha256"` // Users only
+	TokenSHA256    string   `yaml:"token_sha256"`    // Tokens only
+	Teams          []string `yaml:"teams"`           // Owning teams of the visible jobs, "*" for all
+}
+
+// OIDCConfig verifies the signed JWTs of an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer     string `yaml:"issuer"`      // Discovered at {issuer}/.well-known/openid-configuration
+	Audience   string `yaml:"audience"`    // Required in the aud claim
+	TeamsClaim string `yaml:"teams_claim"` // Claim listing the user's teams (default: groups)
+	NameClaim  string `yaml:"name_claim"`  // Claim naming the user in logs (default: email, then sub)
+}
+
+// Principal is an authenticated user or client
+type Principal struct {
+	Name  string
+	Teams []string
+}
+
+// CanSee reports whether the principal may see the jobs of team; "" is a job no team owns
+func (p Principal) CanSee(team string) bool {
+	for _, t := range p.Teams {
+		if t == AllTeams || (team != "" && t == team) {
+			return true
+		}
+	}
+	return false
+}
+
+// errUnauthenticated is returned for a request without valid credentials
+var errUnauthenticated = errors.New("authentication required")
+
+// Auth authenticates API requests
+type Auth struct {
+	users  map[string]credential
+	tokens []credential
+	oidc   *oidcVerifier
+}
+
+type credential struct {
+	name   string
+	digest []byte
+	teams  []string
+}
+
+// LoadAuth reads and validates an auth configuration file
+func LoadAuth(filename string) (*Auth, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config: %w", err)
+	}
+	var config AuthConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse auth config: %w", err)
+	}
+	auth, err := NewAuth(config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return auth, nil
+}
+
+// NewAuth creates an authenticator from config, which must configure at least one credential
+func NewAuth(config AuthConfig) (*Auth, error) {
+	auth := &Auth{users: make(map[string]credential)}
+	for i, user := range config.Users {
+		c, err := newCredential(user, user.PasswordSHA256)
+		if err != nil {
+			return nil, fmt.Errorf("users[%d]: %w", i, err)
+		}
+		if _, ok := auth.users[user.Name]; ok {
+			return nil, fmt.Errorf("users[%d]: duplicate user %s", i, user.Name)
+		}
+		auth.users[user.Name] = c
+	}
+	for i, token := range config.Tokens {
+		c, err := newCredential(token, token.TokenSHA256)
+		if err != nil {
+			return nil, fmt.Errorf("tokens[%d]: %w", i, err)
+		}
+		auth.tokens = append(auth.tokens, c)
+	}
+	if config.OIDC != nil {
+		verifier, err := newOIDCVerifier(*config.OIDC)
+		if err != nil {
+			return nil, fmt.Errorf("oidc: %w", err)
+		}
+		auth.oidc = verifier
+	}
+	if len(auth.users) == 0 && len(auth.tokens) == 0 && auth.oidc == nil {
+		return nil, errors.New("no users, tokens or oidc configured")
+	}
+	return auth, nil
+}
+
+// newCredential validates a user or token with its hex SHA-256 secret digest
+func newCredential(config CredentialConfig, secretSHA256 string) (credential, error) {
+	if config.Name == "" {
+		return credential{}, errors.New("name is required")
+	}
+	digest, err := hex.DecodeString(secretSHA256)
+	if err != nil || len(digest) != sha256.Size {
+		return credential{}, fmt.Errorf("%s: the secret must be a hex SHA-256 digest", config.Name)
+	}
+	if len(config.Teams) == 0 {
+		return credential{}, fmt.Errorf("%s: teams is required, use [\"*\"] for every job", config.Name)
+	}
+	return credential{name: config.Name, digest: digest, teams: config.Teams}, nil
+}
+
+// Authenticate returns the principal of the request's basic or bearer credentials
+func (a *Auth) Authenticate(r *http.Request) (Principal, error) {
+	if user, password, ok := r.BasicAuth(); ok {
+		c, found := a.users[user]
+		if !found {
+			c = credential{digest: make([]byte, sha256.Size)} // Compared anyway, so unknown users take as long
+		}
+		if secretMatches(password, c.digest) && found {
+			return Principal{Name: c.name, Teams: c.teams}, nil
+		}
+		return Principal{}, errUnauthenticated
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return Principal{}, errUnauthenticated
+	}
+	for _, c := range a.tokens {
+		if secretMatches(token, c.digest) {
+			return Principal{Name: c.name, Teams: c.teams}, nil
+		}
+	}
+	if a.oidc != nil && strings.Count(token, ".") == 2 {
+		principal, err := a.oidc.verify(r.Context(), token)
+		if err != nil {
+			return Principal{}, fmt.Errorf("%w: %v", errUnauthenticated, err)
+		}
+		return principal, nil
+	}
+	return Principal{}, errUnauthenticated
+}
+
+// challenge is the WWW-Authenticate header of a 401 response
+func (a *Auth) challenge() string {
+	if len(a.users) > 0 {
+		return `Basic realm="instrumentation-score"`
+	}
+	return `Bearer realm="instrumentation-score"`
+}
+
+// secretMatches compares the digest of secret with digest in constant time
+func secretMatches(secret string, digest []byte) bool {
+	sum := sha256.Sum256([]byte(secret))
+	return subtle.ConstantTimeCompare(sum[:], digest) == 1
+}
+
+type principalKey struct{}
+
+// principalFrom returns the principal authenticated for a request, and false without auth
+func principalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func digest(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func authHandler(t *testing.T, config AuthConfig) http.Handler {
+	t.Helper()
+	auth, err := NewAuth(config)
+	if err != nil {
+		t.Fatalf("NewAuth() error = %v", err)
+	}
+	return Handler(Options{
+		Evaluate: func(job string, metrics []loaders.JobMetricData, rules interface{}) (interface{}, error) {
+			return map[string]interface{}{"job_name": job}, nil
+		},
+		JobScore: func(job string) (interface{}, error) {
+			return map[string]interface{}{"job_name": job}, nil
+		},
+		Dashboard: func(w io.Writer, visible func(job string) bool) error {
+			for _, job := range []string{"checkout", "search"} {
+				if visible(job) {
+					io.WriteString(w, job+";")
+				}
+			}
+			return nil
+		},
+		Metrics: func(w io.Writer) error {
+			_, err := io.WriteString(w, "instrumentation_quality_score 80\n")
+			return err
+		},
+		Auth: auth,
+		TeamOf: func(job string) string {
+			return map[string]string{"checkout": "payments", "search": "discovery"}[job]
+		},
+	})
+}
+
+func TestHandler_Auth(t *testing.T) {
+	handler := authHandler(t, AuthConfig{
+		Users:  []CredentialConfig{{Name: "ana", PasswordSHA256: digest("pw"), Teams: []string{"payments"}}},
+		Tokens: []CredentialConfig{{Name: "prometheus", TokenSHA256: digest("all-jobs"), Teams: []string{AllTeams}}},
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		user, pass string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "healthz is open", method: "GET", target: "/healthz", wantStatus: http.StatusOK},
+		{name: "no credentials", method: "GET", target: "/jobs/checkout/score", wantStatus: http.StatusUnauthorized},
+		{name: "wrong password", method: "GET", target: "/jobs/checkout/score", user: "ana", pass: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "unknown user", method: "GET", target: "/jobs/checkout/score", user: "bo", pass: "pw", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", method: "GET", target: "/jobs/checkout/score", token: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "own team's job", method: "GET", target: "/jobs/checkout/score", user: "ana", pass: "pw", wantStatus: http.StatusOK},
+		{name: "other team's job", method: "GET", target: "/jobs/search/score", user: "ana", pass: "pw", wantStatus: http.StatusForbidden},
+		{name: "job without owner", method: "GET", target: "/jobs/legacy/score", user: "ana", pass: "pw", wantStatus: http.StatusForbidden},
+		{name: "evaluate other team's job", method: "POST", target: "/evaluate?job=search", user: "ana", pass: "pw", wantStatus: http.StatusForbidden},
+		{name: "evaluate own team's job", method: "POST", target: "/evaluate?job=checkout", user: "ana", pass: "pw", wantStatus: http.StatusOK},
+		{name: "token for every team", method: "GET", target: "/jobs/legacy/score", token: "all-jobs", wantStatus: http.StatusOK},
+		{name: "metrics need every team", method: "GET", target: "/metrics", user: "ana", pass: "pw", wantStatus: http.StatusForbidden},
+		{name: "metrics", method: "GET", target: "/metrics", token: "all-jobs", wantStatus: http.StatusOK},
+		{name: "dashboard of own team", method: "GET", target: "/", user: "ana", pass: "pw", wantStatus: http.StatusOK, wantBody: "checkout;"},
+		{name: "dashboard of every team", method: "GET", target: "/", token: "all-jobs", wantStatus: http.StatusOK, wantBody: "checkout;search;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader("http_requests_total 1\n"))
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNewAuth_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config AuthConfig
+	}{
+		{"nothing configured", AuthConfig{}},
+		{"plain text secret", AuthConfig{Tokens: []CredentialConfig{{Name: "ci", TokenSHA256: "secret", Teams: []string{"*"}}}}},
+		{"no teams", AuthConfig{Users: []CredentialConfig{{Name: "ana", PasswordSHA256: digest("pw")}}}},
+		{"oidc without audience", AuthConfig{OIDC: &OIDCConfig{Issuer: "https://login.example.com"}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewAuth(tt.config); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// testIssuer is an OIDC provider publishing one RSA key
+type testIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testIssuer{key: key}
+	issuer.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.URL, "jwks_uri": issuer.URL + "/keys"})
+		case "/keys":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kty": "RSA", "kid": "k1", "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// token signs claims with the issuer's key
+func (i *testIssuer) token(t *testing.T, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestHandler_AuthOIDC(t *testing.T) {
+	issuer := newTestIssuer(t)
+	handler := authHandler(t, AuthConfig{OIDC: &OIDCConfig{Issuer: issuer.URL, Audience: "instrumentation-score"}})
+
+	valid := map[string]interface{}{
+		"iss": issuer.URL, "aud": []string{"instrumentation-score"}, "sub": "ana",
+		"exp": time.Now().Add(time.Hour).Unix(), "groups": []string{"payments", "*"},
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := make(map[string]interface{})
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[key] = value
+		return claims
+	}
+
+	// The signature of a valid token with the claims of one for another team
+	validParts := strings.Split(issuer.token(t, "k1", valid), ".")
+	otherParts := strings.Split(issuer.token(t, "k1", with("groups", "discovery")), ".")
+	tampered := validParts[0] + "." + otherParts[1] + "." + validParts[2]
+
+	tests := []struct {
+		name       string
+		token      string
+		target     string
+		wantStatus int
+	}{
+		{"own team's job", issuer.token(t, "k1", valid), "/jobs/checkout/score", http.StatusOK},
+		{"other team's job", issuer.token(t, "k1", valid), "/jobs/search/score", http.StatusForbidden},
+		{"a * group grants nothing", issuer.token(t, "k1", valid), "/metrics", http.StatusForbidden},
+		{"expired", issuer.token(t, "k1", with("exp", time.Now().Add(-time.Hour).Unix())), "/jobs/checkout/score", http.StatusUnauthorized},
+		{"other audience", issuer.token(t, "k1", with("aud", "grafana")), "/jobs/checkout/score", http.StatusUnauthorized},
+		{"other issuer", issuer.token(t, "k1", with("iss", "https://evil.example.com")), "/jobs/checkout/score", http.StatusUnauthorized},
+		{"unknown key", issuer.token(t, "k2", valid), "/jobs/checkout/score", http.StatusUnauthorized},
+		{"claims of another token", tampered, "/jobs/search/score", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
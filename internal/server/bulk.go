@@ -0,0 +1,185 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMaxBulkBytes bounds a tarball posted to /runs, and the files extracted from it
+const DefaultMaxBulkBytes = 1 << 30
+
+// BulkRequest is a POST /runs: job files extracted from a posted tarball into Dir, or the S3
+// URI of a prefix holding them
+type BulkRequest struct {
+	Dir   string
+	S3URI string
+}
+
+// BulkEvaluator scores the job files of a bulk request into the report of its run; ctx is done
+// when the server shuts down
+type BulkEvaluator func(ctx context.Context, request BulkRequest) (RunReport, error)
+
+// errBulkTooLarge is returned by extractTarball once the extracted files exceed the limit
+var errBulkTooLarge = errors.New("too large")
+
+// serveBulk extracts the gzip tarball of job files posted to /runs, or reads the S3 URI of
+// a JSON body, and queues a run scoring them; clients poll the run it answers 202 with
+func (s *server) serveBulk(w http.ResponseWriter, r *http.Request) {
+	if s.opts.EvaluateBulk == nil {
+		writeError(w, http.StatusNotFound, "bulk evaluation is not enabled")
+		return
+	}
+	if r.ContentLength > s.opts.MaxBulkBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("body is larger than %d bytes", s.opts.MaxBulkBytes))
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, s.opts.MaxBulkBytes)
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		var source struct {
+			S3URI string `json:"s3_uri"`
+		}
+		if err := json.NewDecoder(io.LimitReader(body, 64<<10)).Decode(&source); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+			return
+		}
+		if err := s.checkS3URI(source.S3URI); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.submitBulk(w, BulkRequest{S3URI: source.S3URI}, func() {})
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "instrumentation-score-bulk-")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := extractTarball(body, dir, s.opts.MaxBulkBytes); err != nil {
+		os.RemoveAll(dir)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, errBulkTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("tarball is larger than %d bytes", s.opts.MaxBulkBytes))
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.submitBulk(w, BulkRequest{Dir: jobFilesDir(dir)}, func() { os.RemoveAll(dir) })
+}
+
+// submitBulk queues a run scoring request, calling cleanup once it finished, and answers 202
+// with the run
+func (s *server) submitBulk(w http.ResponseWriter, request BulkRequest, cleanup func()) {
+	id, err := s.opts.Runs.Submit(Run{Kind: RunBulk}, func(ctx context.Context) (RunReport, error) {
+		defer cleanup()
+		return s.opts.EvaluateBulk(ctx, request)
+	})
+	if err != nil {
+		cleanup()
+		w.Header().Set("Retry-After", runQueueRetrySeconds)
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	run, _, _ := s.opts.Runs.Get(id)
+	w.Header().Set("Location", "/runs/"+id)
+	writeJSON(w, http.StatusAccepted, run)
+}
+
+// checkS3URI accepts s3://bucket/prefix URIs of the buckets in BulkS3Buckets
+func (s *server) checkS3URI(uri string) error {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	bucket, _, _ := strings.Cut(rest, "/")
+	if !ok || bucket == "" {
+		return fmt.Errorf("invalid s3_uri %q, use s3://bucket/prefix", uri)
+	}
+	for _, allowed := range s.opts.BulkS3Buckets {
+		if bucket == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("bucket %s is not one this server reads job files from", bucket)
+}
+
+// extractTarball extracts the files and directories of a gzip tarball into dir, refusing
+// links and names outside dir, and stops once more than limit bytes were extracted
+func extractTarball(r io.Reader, dir string, limit int64) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("body is not a gzip tarball: %w", err)
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+	var extracted int64
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tarball: %w", err)
+		}
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("tarball entry %q is outside the archive", header.Name)
+		}
+		target := filepath.Join(dir, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if extracted += header.Size; extracted > limit {
+				return errBulkTooLarge
+			}
+			if err := extractFile(archive, target); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("tarball entry %q is not a file or directory", header.Name)
+		}
+	}
+}
+
+// extractFile writes the current tarball entry to target
+func extractFile(archive *tar.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, archive); err != nil {
+		file.Close()
+		return fmt.Errorf("invalid tarball: %w", err)
+	}
+	return file.Close()
+}
+
+// jobFilesDir returns the directory of the job files extracted into dir: dir itself, or the
+// single directory it contains, such as the job_metrics_TIMESTAMP directory analyze writes
+func jobFilesDir(dir string) string {
+	for {
+		if files, _ := filepath.Glob(filepath.Join(dir, "*.txt")); len(files) > 0 {
+			return dir
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+			return dir
+		}
+		dir = filepath.Join(dir, entries[0].Name())
+	}
+}
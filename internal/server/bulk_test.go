@@ -0,0 +1,156 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarball is a gzip tarball of entries; a name ending in / is a directory and a name
+// starting with "link:" a symlink
+func tarball(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for name, content := range entries {
+		header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		switch {
+		case strings.HasSuffix(name, "/"):
+			header.Typeflag, header.Size = tar.TypeDir, 0
+		case strings.HasPrefix(name, "link:"):
+			header.Name, header.Typeflag, header.Linkname, header.Size = strings.TrimPrefix(name, "link:"), tar.TypeSymlink, content, 0
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			archive.Write([]byte(content))
+		}
+	}
+	archive.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestExtractTarball(t *testing.T) {
+	dir := t.TempDir()
+	data := tarball(t, map[string]string{
+		"job_metrics_20251102_160000/":             "",
+		"job_metrics_20251102_160000/api.txt":      "api",
+		"job_metrics_20251102_160000/checkout.txt": "checkout",
+	})
+	if err := extractTarball(bytes.NewReader(data), dir, 1024); err != nil {
+		t.Fatalf("extractTarball() error = %v", err)
+	}
+	jobDir := jobFilesDir(dir)
+	if jobDir != filepath.Join(dir, "job_metrics_20251102_160000") {
+		t.Errorf("jobFilesDir() = %s, want the run directory", jobDir)
+	}
+	if content, _ := os.ReadFile(filepath.Join(jobDir, "checkout.txt")); string(content) != "checkout" {
+		t.Errorf("checkout.txt = %q", content)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"parent directory", tarball(t, map[string]string{"../escaped.txt": "x"}), "outside the archive"},
+		{"absolute path", tarball(t, map[string]string{"/tmp/escaped.txt": "x"}), "outside the archive"},
+		{"symlink", tarball(t, map[string]string{"link:jobs.txt": "/etc/passwd"}), "not a file or directory"},
+		{"too large", tarball(t, map[string]string{"a.txt": strings.Repeat("a", 600), "b.txt": strings.Repeat("b", 600)}), "too large"},
+		{"not gzip", []byte("a.txt"), "not a gzip tarball"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := extractTarball(bytes.NewReader(tt.data), t.TempDir(), 1024)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("extractTarball() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escaped.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("a tarball entry was written outside the directory")
+	}
+}
+
+func TestHandler_Bulk(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := NewRuns(ctx, 0, 1, 0)
+	var extracted string
+	handler := Handler(Options{
+		Runs: runs,
+		EvaluateBulk: func(ctx context.Context, request BulkRequest) (RunReport, error) {
+			if request.S3URI != "" {
+				return report(request.S3URI), nil
+			}
+			extracted = request.Dir
+			files, _ := filepath.Glob(filepath.Join(request.Dir, "*.txt"))
+			var jobs []string
+			for _, file := range files {
+				jobs = append(jobs, strings.TrimSuffix(filepath.Base(file), ".txt"))
+			}
+			return report(jobs...), nil
+		},
+		MaxBulkBytes:  4096,
+		BulkS3Buckets: []string{"metrics"},
+	})
+	post := func(contentType string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/runs", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("application/gzip", tarball(t, map[string]string{"api.txt": "a", "checkout.txt": "c"}))
+	if rec.Code != http.StatusAccepted || !strings.HasPrefix(rec.Header().Get("Location"), "/runs/") {
+		t.Fatalf("POST /runs = %d %s (Location %q), want 202", rec.Code, rec.Body.String(), rec.Header().Get("Location"))
+	}
+	id := strings.TrimPrefix(rec.Header().Get("Location"), "/runs/")
+	if run, err := runs.Wait(context.Background(), id); err != nil || run.Kind != RunBulk || run.Status != RunDone {
+		t.Fatalf("bulk run = %+v, %v", run, err)
+	}
+	if rec, body := do(t, handler, "GET", "/runs/"+id+"/report", ""); rec.Code != http.StatusOK || len(body["jobs"].([]interface{})) != 2 {
+		t.Errorf("GET /runs/{id}/report = %d %v, want both jobs", rec.Code, body)
+	}
+	if _, err := os.Stat(extracted); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("extracted files %s not removed after the run", extracted)
+	}
+
+	rec = post("application/json", []byte(`{"s3_uri": "s3://metrics/ci/job_metrics_20251102_160000"}`))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /runs with an S3 URI = %d %s", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantStatus  int
+	}{
+		{"other bucket", "application/json", []byte(`{"s3_uri": "s3://secrets/keys"}`), http.StatusBadRequest},
+		{"not an S3 URI", "application/json", []byte(`{"s3_uri": "/etc"}`), http.StatusBadRequest},
+		{"path traversal", "application/gzip", tarball(t, map[string]string{"../a.txt": "a"}), http.StatusBadRequest},
+		{"too large", "application/gzip", bytes.Repeat([]byte("a"), 5000), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		if rec := post(tt.contentType, tt.body); rec.Code != tt.wantStatus {
+			t.Errorf("%s: POST /runs = %d %s, want %d", tt.name, rec.Code, rec.Body.String(), tt.wantStatus)
+		}
+	}
+
+	if rec, _ := do(t, Handler(Options{Runs: runs}), "POST", "/runs", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("POST /runs without bulk evaluation = %d, want 404", rec.Code)
+	}
+}
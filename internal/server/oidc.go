@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bounds of the OIDC token checks
+const (
+	oidcClockSkew     = time.Minute      // Tolerated for exp and nbf
+	oidcRefreshPeriod = 5 * time.Minute  // Least time between key set fetches for unknown key IDs
+	oidcFetchTimeout  = 10 * time.Second // Of the discovery document and key set requests
+)
+
+// oidcVerifier verifies RS256 and ES256 signed JWTs with the keys the issuer publishes
+// Only the checks a resource server needs are implemented: signature, iss, aud, exp and nbf.
+type oidcVerifier struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	jwksURI string
+	keys    map[string]crypto.PublicKey // By key ID
+	fetched time.Time
+}
+
+func newOIDCVerifier(config OIDCConfig) (*oidcVerifier, error) {
+	if !strings.HasPrefix(config.Issuer, "https://") && !strings.HasPrefix(config.Issuer, "http://") {
+		return nil, errors.New("issuer must be an http(s) URL")
+	}
+	if config.Audience == "" {
+		return nil, errors.New("audience is required")
+	}
+	if config.TeamsClaim == "" {
+		config.TeamsClaim = "groups"
+	}
+	return &oidcVerifier{config: config, client: &http.Client{Timeout: oidcFetchTimeout}}, nil
+}
+
+// verify checks a JWT and returns the principal its claims name
+func (v *oidcVerifier) verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("invalid token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid token signature: %w", err)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return Principal{}, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Alg, key, digest[:], signature); err != nil {
+		return Principal{}, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("invalid token claims: %w", err)
+	}
+	now := time.Now()
+	if claims["iss"] != v.config.Issuer {
+		return Principal{}, fmt.Errorf("token issued by %v, not %s", claims["iss"], v.config.Issuer)
+	}
+	if !containsString(claims["aud"], v.config.Audience) {
+		return Principal{}, fmt.Errorf("token is not for audience %s", v.config.Audience)
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return Principal{}, errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(oidcClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return Principal{}, errors.New("token not valid yet")
+	}
+
+	// "*" is only granted by the auth config, never by a claim
+	var principal Principal
+	for _, team := range stringsClaim(claims[v.config.TeamsClaim]) {
+		if team != AllTeams {
+			principal.Teams = append(principal.Teams, team)
+		}
+	}
+	for _, claim := range []string{v.config.NameClaim, "email", "sub"} {
+		if name, ok := claims[claim].(string); ok && claim != "" && name != "" {
+			principal.Name = name
+			break
+		}
+	}
+	return principal, nil
+}
+
+// key returns the public key with ID kid, fetching the key set again for an unknown ID
+// at most every oidcRefreshPeriod, so tokens with made-up IDs cannot flood the issuer
+func (v *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if v.keys == nil || time.Since(v.fetched) >= oidcRefreshPeriod {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the keys of %s: %w", v.config.Issuer, err)
+		}
+		v.keys, v.fetched = keys, time.Now()
+	}
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// fetchKeys discovers the issuer's key set URL, once, and reads the keys from it
+func (v *oidcVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	if v.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, strings.TrimSuffix(v.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.Issuer != v.config.Issuer || discovery.JWKSURI == "" {
+			return nil, fmt.Errorf("discovery document of issuer %q has no jwks_uri", discovery.Issuer)
+		}
+		v.jwksURI = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, v.jwksURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch {
+		case k.Kty == "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil || len(e) > 4 {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case k.Kty == "EC" && k.Crv == "P-256":
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			if key.Curve.IsOnCurve(key.X, key.Y) {
+				keys[k.Kid] = key
+			}
+		}
+	}
+	return keys, nil
+}
+
+// getJSON decodes the JSON response to a GET of url into v
+func (v *oidcVerifier) getJSON(ctx context.Context, url string, into interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+// verifySignature checks an RS256 or ES256 signature of digest
+func verifySignature(alg string, key crypto.PublicKey, digest, signature []byte) error {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature) != nil {
+			return errors.New("invalid token signature")
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return errors.New("invalid token signature")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid token signature")
+		}
+	default:
+		return fmt.Errorf("unsupported token algorithm %q, use RS256 or ES256", alg)
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// stringsClaim returns a claim that is a string or a list of strings as a list
+func stringsClaim(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// containsString reports whether a string or list of strings claim contains s
+func containsString(claim interface{}, s string) bool {
+	for _, value := range stringsClaim(claim) {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
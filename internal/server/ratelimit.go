@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets is how many clients the rate limiter tracks before forgetting those whose
+// bucket refilled, which a new request would find full anyway
+const maxIdleBuckets = 10000
+
+// rateLimiter is a token bucket per client: each holds up to burst requests and refills at
+// perMinute requests a minute
+type rateLimiter struct {
+	perMinute float64
+	burst     float64
+	now       func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(perMinute)))
+	}
+	return &rateLimiter{perMinute: perMinute, burst: float64(burst), now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// allow takes a request from client's bucket, or returns how long until one is available
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if len(l.buckets) >= maxIdleBuckets {
+		l.forgetFull(now)
+	}
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Minutes()*l.perMinute)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.perMinute * float64(time.Minute))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// forgetFull drops the buckets that refilled by now
+func (l *rateLimiter) forgetFull(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Minutes()*l.perMinute >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// clientOf identifies the client of a request for rate limiting: the authenticated principal,
+// else the remote address
+func clientOf(r *http.Request) string {
+	if principal, ok := principalFrom(r.Context()); ok {
+		return "principal:" + principal.Name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "address:" + host
+}
+
+// withRateLimit answers 429 with Retry-After to clients over RateLimit, when it is set
+func (s *server) withRateLimit(next http.HandlerFunc) http.HandlerFunc {
+	if s.limiter == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := s.limiter.allow(clientOf(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, fmt.Sprintf("rate limit of %g requests per minute exceeded", s.limiter.perMinute))
+			return
+		}
+		next(w, r)
+	}
+}
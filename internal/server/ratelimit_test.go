@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2025, 11, 5, 10, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(6, 2)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.allow("ci"); !ok {
+			t.Fatalf("request %d of the burst refused", i+1)
+		}
+	}
+	if ok, wait := limiter.allow("ci"); ok || wait != 10*time.Second {
+		t.Errorf("allow() after the burst = %v, %v, want refused for 10s", ok, wait)
+	}
+	if ok, _ := limiter.allow("other"); !ok {
+		t.Error("another client should have its own bucket")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, _ := limiter.allow("ci"); !ok {
+		t.Error("expected a request once the bucket refilled one")
+	}
+	if ok, _ := limiter.allow("ci"); ok {
+		t.Error("expected a single request to have refilled")
+	}
+
+	// Only a minute's worth is kept however long the client waits
+	now = now.Add(time.Hour)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if ok, _ := limiter.allow("ci"); ok {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("allowed %d requests after an hour, want the burst of 2", allowed)
+	}
+}
+
+func TestHandler_Limits(t *testing.T) {
+	handler := Handler(Options{
+		Evaluate: func(job string, metrics []loaders.JobMetricData, rules interface{}) (interface{}, error) {
+			return map[string]interface{}{"job_name": job}, nil
+		},
+		MaxBodyBytes: 1024,
+		RateLimit:    1,
+		RateBurst:    2,
+	})
+	post := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/evaluate?job=a", strings.NewReader("a 1\n"))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := post("192.0.2.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := post("192.0.2.1:5678")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("request over the limit = %d (Retry-After %q), want 429 after 60s", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := post("192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("another client = %d, want 200", rec.Code)
+	}
+	if rec, _ := do(t, handler, "GET", "/healthz", ""); rec.Code != http.StatusOK {
+		t.Errorf("/healthz = %d, want it never limited", rec.Code)
+	}
+
+	// A declared length over the limit is refused before the body is read
+	req := httptest.NewRequest("POST", "/evaluate?job=a", strings.NewReader("a 1\n"))
+	req.RemoteAddr = "192.0.2.3:1234"
+	req.ContentLength = 1 << 30
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "larger than 1024 bytes") {
+		t.Errorf("oversized request = %d %s, want 413", rec.Code, rec.Body.String())
+	}
+}
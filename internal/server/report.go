@@ -0,0 +1,216 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Page sizes of /api/v1/report
+const (
+	DefaultReportLimit = 100
+	MaxReportLimit     = 1000
+)
+
+// ReportJob is a job of a report as /api/v1/report filters and sorts it
+type ReportJob struct {
+	Name     string
+	Team     string // Owning team, "" for none
+	Category string // Band of the score, e.g. Good
+	Score    float64
+	Cost     float64
+	Entry    interface{} // The job's entry in the report, served as is
+}
+
+// JobReport is a report /api/v1/report pages through: the one of Options.Report, or the
+// report of a run when it implements it
+type JobReport interface {
+	ReportJobs() []ReportJob
+}
+
+// Reporter returns the report of every job in --job-dir; serveReport keeps the visible ones
+type Reporter func() (JobReport, error)
+
+// reportQuery is the page, order and filters of a /api/v1/report request
+type reportQuery struct {
+	offset, limit      int
+	sort               string // score, cost or name, "-" first for descending; "" keeps the report's order
+	team, category     string
+	minScore, maxScore float64
+}
+
+// reportPage is the body of /api/v1/report
+type reportPage struct {
+	Total  int           `json:"total"` // Jobs matching the filters, on every page
+	Offset int           `json:"offset"`
+	Limit  int           `json:"limit"`
+	Jobs   []interface{} `json:"jobs"`
+	Next   string        `json:"next,omitempty"` // Query of the next page, until the last
+}
+
+// parseReportQuery reads the parameters of a /api/v1/report request
+func parseReportQuery(values url.Values) (reportQuery, error) {
+	q := reportQuery{sort: values.Get("sort"), team: values.Get("team"), category: values.Get("category")}
+	var err error
+	if q.offset, err = intParam(values, "offset", 0, 0, math.MaxInt); err != nil {
+		return q, err
+	}
+	if q.limit, err = intParam(values, "limit", DefaultReportLimit, 1, MaxReportLimit); err != nil {
+		return q, err
+	}
+	if q.minScore, err = floatParam(values, "min_score", 0); err != nil {
+		return q, err
+	}
+	if q.maxScore, err = floatParam(values, "max_score", 100); err != nil {
+		return q, err
+	}
+	switch strings.TrimPrefix(q.sort, "-") {
+	case "", "score", "cost", "name":
+	default:
+		return q, fmt.Errorf("unknown sort %q, use score, cost or name, with - first for descending", q.sort)
+	}
+	return q, nil
+}
+
+// intParam reads the integer parameter name, between min and max, or def when it is not set
+func intParam(values url.Values, name string, def, min, max int) (int, error) {
+	text := values.Get(name)
+	if text == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be a whole number from %d to %d", name, min, max)
+	}
+	return n, nil
+}
+
+// floatParam reads the number parameter name, or def when it is not set
+func floatParam(values url.Values, name string, def float64) (float64, error) {
+	text := values.Get(name)
+	if text == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return n, nil
+}
+
+// matches reports whether job passes the filters of q
+func (q reportQuery) matches(job ReportJob) bool {
+	return (q.team == "" || job.Team == q.team) &&
+		(q.category == "" || strings.EqualFold(job.Category, q.category)) &&
+		job.Score >= q.minScore && job.Score <= q.maxScore
+}
+
+// order sorts jobs as q asks, by name among equals
+func (q reportQuery) order(jobs []ReportJob) {
+	if q.sort == "" {
+		return
+	}
+	descending := strings.HasPrefix(q.sort, "-")
+	key := strings.TrimPrefix(q.sort, "-")
+	value := func(job ReportJob) float64 {
+		if key == "cost" {
+			return job.Cost
+		}
+		return job.Score
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		if key != "name" && value(a) != value(b) {
+			return (value(a) < value(b)) != descending
+		}
+		if key == "name" && descending {
+			return a.Name > b.Name
+		}
+		return a.Name < b.Name
+	})
+}
+
+// serveReport serves a page of the jobs of a report, sorted and filtered:
+//
+//	GET /api/v1/report[?run=ID]  The jobs of --job-dir, or of a finished run
+//	  offset, limit              The page, limit 1 to MaxReportLimit (default DefaultReportLimit)
+//	  sort                       score, cost or name, - first for descending (default: report order)
+//	  team, category             Only the jobs of a team, or of a score category such as Good
+//	  min_score, max_score       Only the jobs scoring within these bounds, inclusive
+func (s *server) serveReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	query, err := parseReportQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	visible := func(job string) bool { return s.visible(r, job) }
+	var report JobReport
+	if id := r.URL.Query().Get("run"); id != "" {
+		var ok bool
+		if report, ok = s.runJobReport(w, r, id); !ok {
+			return
+		}
+	} else {
+		if s.opts.Report == nil {
+			writeError(w, http.StatusNotFound, "no job directory is served, use run=ID for the report of a run")
+			return
+		}
+		if report, err = s.opts.Report(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	var jobs []ReportJob
+	for _, job := range report.ReportJobs() {
+		if visible(job.Name) && query.matches(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	query.order(jobs)
+
+	page := reportPage{Total: len(jobs), Offset: query.offset, Limit: query.limit, Jobs: []interface{}{}}
+	start := min(query.offset, len(jobs))
+	end := start + min(query.limit, len(jobs)-start)
+	for _, job := range jobs[start:end] {
+		page.Jobs = append(page.Jobs, job.Entry)
+	}
+	if end < len(jobs) {
+		values := r.URL.Query()
+		values.Set("offset", strconv.Itoa(end))
+		page.Next = r.URL.Path + "?" + values.Encode()
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// runJobReport returns the job report of run id, or writes why there is none
+func (s *server) runJobReport(w http.ResponseWriter, r *http.Request, id string) (JobReport, bool) {
+	if s.opts.Runs == nil {
+		writeError(w, http.StatusNotFound, "no runs are queued")
+		return nil, false
+	}
+	run, report, ok := s.opts.Runs.Get(id)
+	if !ok || (run.Job != "" && !s.visible(r, run.Job)) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no run %s", id))
+		return nil, false
+	}
+	if report == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("run %s is %s and has no report", id, run.Status))
+		return nil, false
+	}
+	jobs, ok := report(func(job string) bool { return s.visible(r, job) }).(JobReport)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("run %s scored a single job, use /runs/%s/report", id, id))
+		return nil, false
+	}
+	return jobs, true
+}
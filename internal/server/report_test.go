@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// jobReport is a JobReport whose entries are the names of its jobs
+type jobReport []ReportJob
+
+func (r jobReport) ReportJobs() []ReportJob {
+	jobs := make([]ReportJob, len(r))
+	for i, job := range r {
+		job.Entry = job.Name
+		jobs[i] = job
+	}
+	return jobs
+}
+
+var testJobs = jobReport{
+	{Name: "checkout", Team: "payments", Category: "Good", Score: 80, Cost: 30},
+	{Name: "search", Team: "discovery", Category: "Poor", Score: 20, Cost: 50},
+	{Name: "billing", Team: "payments", Category: "Excellent", Score: 95, Cost: 10},
+	{Name: "legacy", Category: "Good", Score: 80, Cost: 5},
+}
+
+// names returns the entries of a decoded report page
+func names(body map[string]interface{}) string {
+	var jobs []string
+	for _, job := range body["jobs"].([]interface{}) {
+		jobs = append(jobs, job.(string))
+	}
+	return strings.Join(jobs, ",")
+}
+
+func TestHandler_Report(t *testing.T) {
+	handler := Handler(Options{Report: func() (JobReport, error) { return testJobs, nil }})
+
+	tests := []struct {
+		name      string
+		target    string
+		wantJobs  string
+		wantTotal float64
+		wantNext  string
+	}{
+		{name: "report order", target: "/api/v1/report", wantJobs: "checkout,search,billing,legacy", wantTotal: 4},
+		{name: "first page", target: "/api/v1/report?sort=name&limit=3", wantJobs: "billing,checkout,legacy", wantTotal: 4, wantNext: "/api/v1/report?limit=3&offset=3&sort=name"},
+		{name: "last page", target: "/api/v1/report?sort=name&limit=3&offset=3", wantJobs: "search", wantTotal: 4},
+		{name: "exact last page", target: "/api/v1/report?sort=name&limit=2&offset=2", wantJobs: "legacy,search", wantTotal: 4},
+		{name: "past the end", target: "/api/v1/report?offset=10", wantJobs: "", wantTotal: 4},
+		{name: "largest offset", target: "/api/v1/report?offset=9223372036854775807&limit=1000", wantJobs: "", wantTotal: 4},
+		{name: "score descending, ties by name", target: "/api/v1/report?sort=-score", wantJobs: "billing,checkout,legacy,search", wantTotal: 4},
+		{name: "cost", target: "/api/v1/report?sort=cost", wantJobs: "legacy,billing,checkout,search", wantTotal: 4},
+		{name: "team", target: "/api/v1/report?team=payments&sort=-cost", wantJobs: "checkout,billing", wantTotal: 2},
+		{name: "category", target: "/api/v1/report?category=good", wantJobs: "checkout,legacy", wantTotal: 2},
+		{name: "score bounds", target: "/api/v1/report?min_score=20&max_score=80&sort=score", wantJobs: "search,checkout,legacy", wantTotal: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, body := do(t, handler, "GET", tt.target, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+			}
+			if got := names(body); got != tt.wantJobs {
+				t.Errorf("jobs = %s, want %s", got, tt.wantJobs)
+			}
+			if body["total"] != tt.wantTotal {
+				t.Errorf("total = %v, want %v", body["total"], tt.wantTotal)
+			}
+			if next, _ := body["next"].(string); next != tt.wantNext {
+				t.Errorf("next = %q, want %q", next, tt.wantNext)
+			}
+		})
+	}
+
+	for _, target := range []string{
+		"/api/v1/report?limit=0",
+		"/api/v1/report?limit=1001",
+		"/api/v1/report?offset=-1",
+		"/api/v1/report?min_score=high",
+		"/api/v1/report?sort=owner",
+	} {
+		if rec, _ := do(t, handler, "GET", target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", target, rec.Code)
+		}
+	}
+	if rec, _ := do(t, handler, "POST", "/api/v1/report", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", rec.Code)
+	}
+	if rec, _ := do(t, Handler(Options{}), "GET", "/api/v1/report", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET without a job directory = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandler_ReportOfRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := NewRuns(ctx, 0, 1, 0)
+	bulk := finish(t, runs, Run{Kind: RunBulk}, func(ctx context.Context) (RunReport, error) {
+		return func(visible func(job string) bool) interface{} { return testJobs }, nil
+	})
+	single := finish(t, runs, Run{Kind: RunEvaluation, Job: "checkout"}, func(ctx context.Context) (RunReport, error) {
+		return report("checkout"), nil
+	})
+	handler := Handler(Options{Runs: runs})
+
+	rec, body := do(t, handler, "GET", "/api/v1/report?run="+bulk+"&sort=-score&limit=1", "")
+	if rec.Code != http.StatusOK || names(body) != "billing" || body["total"] != 4.0 {
+		t.Errorf("report of the bulk run = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(t, handler, "GET", "/api/v1/report?run="+single, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("report of a single job's run = %d, want 400", rec.Code)
+	}
+	if rec, _ := do(t, handler, "GET", "/api/v1/report?run=nope", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("report of an unknown run = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandler_ReportVisibility(t *testing.T) {
+	auth, err := NewAuth(AuthConfig{
+		Users: []CredentialConfig{{Name: "ana", PasswordSHA256: digest("pw"), Teams: []string{"payments"}}},
+	})
+	if err != nil {
+		t.Fatalf("NewAuth() error = %v", err)
+	}
+	handler := Handler(Options{
+		Report: func() (JobReport, error) { return testJobs, nil },
+		Auth:   auth,
+		TeamOf: func(job string) string {
+			for _, j := range testJobs {
+				if j.Name == job {
+					return j.Team
+				}
+			}
+			return ""
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/report?sort=name", nil)
+	req.SetBasicAuth("ana", "pw")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"total":2`) || !strings.Contains(rec.Body.String(), `"jobs":["billing","checkout"]`) {
+		t.Errorf("report for a payments user = %d %s, want only the payments jobs", rec.Code, rec.Body.String())
+	}
+}
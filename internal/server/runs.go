@@ -0,0 +1,295 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the run queue, see NewRuns
+const (
+	DefaultMaxRuns       = 100 // Finished runs kept for /runs
+	DefaultRunQueue      = 100 // Runs waiting for a worker before submissions are refused
+	DefaultRunWorkers    = 4   // Runs executed at the same time
+	runQueueRetrySeconds = "30"
+)
+
+// Statuses of a run
+const (
+	RunQueued  = "queued"
+	RunRunning = "running"
+	RunDone    = "done"
+	RunFailed  = "failed"
+)
+
+// Kinds of run
+const (
+	RunSchedule   = "schedule"   // A scheduled collection
+	RunEvaluation = "evaluation" // An /evaluate or /jobs/{job}/score request
+	RunBulk       = "bulk"       // Job files posted to /runs
+)
+
+// ErrQueueFull is returned by Submit when as many runs as the queue holds are already waiting
+var ErrQueueFull = errors.New("the run queue is full")
+
+// Run describes a run of the server: a scheduled analyze and evaluate cycle or an evaluation request
+type Run struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Schedule   string     `json:"schedule,omitempty"` // Name of the schedule that submitted it
+	Job        string     `json:"job,omitempty"`      // Job an evaluation request scores
+	Status     string     `json:"status"`
+	QueuedAt   time.Time  `json:"queued_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// RunReport renders the report of a finished run with the jobs visible reports true for
+type RunReport func(visible func(job string) bool) interface{}
+
+// Work is what a run does, returning its report; ctx is done when the server shuts down
+type Work func(ctx context.Context) (RunReport, error)
+
+// Runs queues runs for a fixed number of workers, so overlapping schedules and requests
+// wait their turn in order instead of competing for the CPU and Prometheus, and keeps the
+// latest finished runs for /runs
+type Runs struct {
+	ctx   context.Context
+	queue chan *runEntry
+
+	mu      sync.Mutex
+	max     int
+	nextID  int
+	entries []*runEntry // Oldest first
+}
+
+type runEntry struct {
+	run    Run
+	work   Work
+	report RunReport     // Set once the run is done
+	done   chan struct{} // Closed once the run finished
+}
+
+// NewRuns starts workers executing submitted runs until ctx is done, queueing up to queued
+// runs and keeping up to max finished ones; values <= 0 use the defaults
+func NewRuns(ctx context.Context, max, workers, queued int) *Runs {
+	if max <= 0 {
+		max = DefaultMaxRuns
+	}
+	if workers <= 0 {
+		workers = DefaultRunWorkers
+	}
+	if queued <= 0 {
+		queued = DefaultRunQueue
+	}
+	r := &Runs{ctx: ctx, queue: make(chan *runEntry, queued), max: max}
+	for i := 0; i < workers; i++ {
+		go r.worker()
+	}
+	return r
+}
+
+// Submit queues work as run, of which Kind, Schedule and Job are used, and returns its ID
+func (r *Runs) Submit(run Run, work Work) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	run.ID = fmt.Sprintf("%s-%d", time.Now().Format("20060102T150405"), r.nextID)
+	run.Status, run.QueuedAt = RunQueued, time.Now()
+	run.StartedAt, run.FinishedAt, run.Error = nil, nil, ""
+	entry := &runEntry{run: run, work: work, done: make(chan struct{})}
+	select {
+	case r.queue <- entry:
+	default:
+		return "", ErrQueueFull
+	}
+	r.entries = append(r.entries, entry)
+	r.prune()
+	return run.ID, nil
+}
+
+// Wait waits until run id finished and returns it, or ctx's error once ctx is done first
+func (r *Runs) Wait(ctx context.Context, id string) (Run, error) {
+	r.mu.Lock()
+	entry := r.find(id)
+	r.mu.Unlock()
+	if entry == nil {
+		return Run{}, fmt.Errorf("no run %s", id)
+	}
+	select {
+	case <-entry.done:
+	case <-ctx.Done():
+		return Run{}, ctx.Err()
+	case <-r.ctx.Done():
+		return Run{}, r.ctx.Err()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return entry.run, nil
+}
+
+// worker executes queued runs in order until the context of the runs is done
+func (r *Runs) worker() {
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case entry := <-r.queue:
+			r.mu.Lock()
+			now := time.Now()
+			entry.run.Status, entry.run.StartedAt = RunRunning, &now
+			r.mu.Unlock()
+
+			report, err := entry.work(r.ctx)
+
+			r.mu.Lock()
+			finished := time.Now()
+			entry.run.FinishedAt = &finished
+			if err != nil {
+				entry.run.Status, entry.run.Error = RunFailed, err.Error()
+			} else {
+				entry.run.Status, entry.report = RunDone, report
+			}
+			close(entry.done)
+			r.prune()
+			r.mu.Unlock()
+		}
+	}
+}
+
+// List returns the runs, newest first
+func (r *Runs) List() []Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := make([]Run, 0, len(r.entries))
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		runs = append(runs, r.entries[i].run)
+	}
+	return runs
+}
+
+// Get returns run id and its report, nil until the run is done
+func (r *Runs) Get(id string) (Run, RunReport, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := r.find(id)
+	if entry == nil {
+		return Run{}, nil, false
+	}
+	return entry.run, entry.report, true
+}
+
+// find returns the entry of run id, nil when unknown or pruned
+func (r *Runs) find(id string) *runEntry {
+	for _, entry := range r.entries {
+		if entry.run.ID == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+// prune drops the oldest finished runs beyond max; queued and running ones are always kept
+func (r *Runs) prune() {
+	finished := 0
+	for _, entry := range r.entries {
+		if entry.run.FinishedAt != nil {
+			finished++
+		}
+	}
+	kept := r.entries[:0]
+	for _, entry := range r.entries {
+		if entry.run.FinishedAt != nil && finished > r.max {
+			finished--
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	r.entries = kept
+}
+
+// runQueued runs evaluate for job as a queued evaluation run and returns its result and
+// error; ok is false when the response was already written because it could not run
+func (s *server) runQueued(w http.ResponseWriter, r *http.Request, job string, evaluate func() (interface{}, error)) (result interface{}, ok bool, err error) {
+	if s.opts.Runs == nil {
+		result, err = evaluate()
+		return result, true, err
+	}
+	id, submitErr := s.opts.Runs.Submit(Run{Kind: RunEvaluation, Job: job}, func(ctx context.Context) (RunReport, error) {
+		if err := r.Context().Err(); err != nil {
+			return nil, fmt.Errorf("request cancelled while queued: %w", err)
+		}
+		result, err = evaluate()
+		if err != nil {
+			return nil, err
+		}
+		return func(func(job string) bool) interface{} { return result }, nil
+	})
+	if errors.Is(submitErr, ErrQueueFull) {
+		w.Header().Set("Retry-After", runQueueRetrySeconds)
+		writeError(w, http.StatusServiceUnavailable, submitErr.Error())
+		return nil, false, nil
+	}
+	w.Header().Set("X-Run-ID", id)
+	if _, waitErr := s.opts.Runs.Wait(r.Context(), id); waitErr != nil {
+		writeError(w, http.StatusServiceUnavailable, waitErr.Error())
+		return nil, false, nil
+	}
+	return result, true, err
+}
+
+// serveRuns serves the run list, a run, and the report of a finished run, and queues bulk runs:
+//
+//	POST /runs              Score a gzip tarball of job files, or the S3 prefix of a JSON s3_uri
+//	GET /runs               The runs, newest first, without evaluations of jobs the caller may not see
+//	GET /runs/{id}          A run's status
+//	GET /runs/{id}/report   The report of a done run, with the jobs the caller may see
+func (s *server) serveRuns(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Runs == nil {
+		writeError(w, http.StatusNotFound, "no runs are queued")
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/runs"), "/")
+	if r.Method == http.MethodPost && path == "" {
+		s.withRateLimit(s.serveBulk)(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	if path == "" {
+		runs := []Run{}
+		for _, run := range s.opts.Runs.List() {
+			if run.Job == "" || s.visible(r, run.Job) {
+				runs = append(runs, run)
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"runs": runs})
+		return
+	}
+	id, rest, _ := strings.Cut(path, "/")
+	if rest != "" && rest != "report" {
+		writeError(w, http.StatusNotFound, "use /runs/{id} or /runs/{id}/report")
+		return
+	}
+	run, report, ok := s.opts.Runs.Get(id)
+	if !ok || (run.Job != "" && !s.visible(r, run.Job)) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no run %s", id))
+		return
+	}
+	if rest == "" {
+		writeJSON(w, http.StatusOK, run)
+		return
+	}
+	if report == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("run %s is %s and has no report", id, run.Status))
+		return
+	}
+	writeJSON(w, http.StatusOK, report(func(job string) bool { return s.visible(r, job) }))
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// report is the report of a run listing the visible ones of jobs
+func report(jobs ...string) RunReport {
+	return func(visible func(job string) bool) interface{} {
+		shown := []string{}
+		for _, job := range jobs {
+			if visible(job) {
+				shown = append(shown, job)
+			}
+		}
+		return map[string]interface{}{"jobs": shown}
+	}
+}
+
+// finish submits work and waits until it finished
+func finish(t *testing.T, runs *Runs, run Run, work Work) string {
+	t.Helper()
+	id, err := runs.Submit(run, work)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := runs.Wait(context.Background(), id); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	return id
+}
+
+func TestRuns_Queue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := NewRuns(ctx, 0, 1, 1)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	first, err := runs.Submit(Run{Kind: RunSchedule, Schedule: "nightly"}, func(ctx context.Context) (RunReport, error) {
+		close(started)
+		<-release
+		return report("checkout"), nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-started
+	second, err := runs.Submit(Run{Kind: RunSchedule, Schedule: "hourly"}, func(ctx context.Context) (RunReport, error) {
+		return nil, errors.New("collection failed")
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := runs.Submit(Run{Kind: RunSchedule}, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit() to a full queue error = %v, want ErrQueueFull", err)
+	}
+
+	if run, _, _ := runs.Get(first); run.Status != RunRunning || run.StartedAt == nil {
+		t.Errorf("first run = %+v, want running", run)
+	}
+	if run, _, _ := runs.Get(second); run.Status != RunQueued || run.StartedAt != nil {
+		t.Errorf("second run = %+v, want queued behind the first", run)
+	}
+
+	close(release)
+	run, err := runs.Wait(context.Background(), second)
+	if err != nil || run.Status != RunFailed || run.Error != "collection failed" {
+		t.Errorf("Wait() = %+v, %v, want the failed second run", run, err)
+	}
+	if run, report, _ := runs.Get(first); run.Status != RunDone || report == nil || run.FinishedAt == nil {
+		t.Errorf("first run = %+v, want done with a report", run)
+	}
+}
+
+func TestRuns_Prune(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := NewRuns(ctx, 2, 1, 0)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, finish(t, runs, Run{Kind: RunSchedule}, func(ctx context.Context) (RunReport, error) { return nil, nil }))
+	}
+	list := runs.List()
+	if len(list) != 2 || list[0].ID != ids[2] || list[1].ID != ids[1] {
+		t.Fatalf("List() = %+v, want the 2 latest runs, newest first", list)
+	}
+	if _, _, ok := runs.Get(ids[0]); ok {
+		t.Error("expected the oldest finished run to be pruned")
+	}
+}
+
+func TestHandler_Runs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := NewRuns(ctx, 0, 1, 0)
+	done := finish(t, runs, Run{Kind: RunSchedule, Schedule: "nightly"}, func(ctx context.Context) (RunReport, error) {
+		return report("checkout", "search"), nil
+	})
+	failed := finish(t, runs, Run{Kind: RunSchedule, Schedule: "hourly"}, func(ctx context.Context) (RunReport, error) {
+		return nil, errors.New("collection failed")
+	})
+
+	handler := Handler(Options{Runs: runs})
+	rec, body := do(t, handler, "GET", "/runs", "")
+	if rec.Code != http.StatusOK || len(body["runs"].([]interface{})) != 2 {
+		t.Fatalf("GET /runs = %d %v", rec.Code, body)
+	}
+	if rec, body := do(t, handler, "GET", "/runs/"+failed, ""); rec.Code != http.StatusOK || body["status"] != RunFailed || body["schedule"] != "hourly" {
+		t.Errorf("GET /runs/{id} = %d %v", rec.Code, body)
+	}
+	if rec, body := do(t, handler, "GET", "/runs/"+done+"/report", ""); rec.Code != http.StatusOK || len(body["jobs"].([]interface{})) != 2 {
+		t.Errorf("GET /runs/{id}/report = %d %v", rec.Code, body)
+	}
+
+	tests := []struct {
+		method, target string
+		wantStatus     int
+	}{
+		{"GET", "/runs/" + failed + "/report", http.StatusNotFound},
+		{"GET", "/runs/unknown", http.StatusNotFound},
+		{"GET", "/runs/" + done + "/logs", http.StatusNotFound},
+		{"DELETE", "/runs/" + done, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec, _ := do(t, handler, tt.method, tt.target, ""); rec.Code != tt.wantStatus {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, rec.Code, tt.wantStatus)
+		}
+	}
+	if rec, _ := do(t, Handler(Options{}), "GET", "/runs", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without runs, got %d", rec.Code)
+	}
+}
+
+func TestHandler_EvaluateQueued(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := NewRuns(ctx, 0, 1, 1)
+	handler := Handler(Options{
+		Evaluate: func(job string, metrics []loaders.JobMetricData, rules interface{}) (interface{}, error) {
+			return map[string]interface{}{"job_name": job}, nil
+		},
+		Runs: runs,
+	})
+
+	rec, body := do(t, handler, "POST", "/evaluate?job=checkout", "http_requests_total 1\n")
+	id := rec.Header().Get("X-Run-ID")
+	if rec.Code != http.StatusOK || body["job_name"] != "checkout" || id == "" {
+		t.Fatalf("POST /evaluate = %d %v (run %q)", rec.Code, body, id)
+	}
+	if run, _, _ := runs.Get(id); run.Kind != RunEvaluation || run.Job != "checkout" || run.Status != RunDone {
+		t.Errorf("run of the request = %+v", run)
+	}
+
+	// One run executing and one waiting: the next request is refused
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	if _, err := runs.Submit(Run{Kind: RunSchedule}, func(ctx context.Context) (RunReport, error) {
+		close(started)
+		<-release
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if _, err := runs.Submit(Run{Kind: RunSchedule}, func(ctx context.Context) (RunReport, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+	rec, body = do(t, handler, "POST", "/evaluate?job=checkout", "http_requests_total 1\n")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" || !strings.Contains(body["error"].(string), "queue is full") {
+		t.Errorf("POST /evaluate with a full queue = %d %v, want 503 with Retry-After", rec.Code, body)
+	}
+}
@@ -0,0 +1,419 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"instrumentation-score/internal/collectors"
+	"instrumentation-score/internal/health"
+	"instrumentation-score/internal/loaders"
+)
+
+// DefaultMaxBodyBytes bounds the metrics posted to /evaluate
+const DefaultMaxBodyBytes = 32 << 20
+
+// maxRulesBytes bounds an inline rules document posted to /evaluate
+const maxRulesBytes = 1 << 20
+
+// Inputs accepted by POST /evaluate in its input parameter
+const (
+	InputExposition = "exposition" // Prometheus text exposition, as served on /metrics
+	InputJobFile    = "job-file"   // A per-job file written by analyze
+)
+
+// ErrNotFound is returned by a JobScorer for a job without collected metrics
+var ErrNotFound = errors.New("job not found")
+
+// Evaluator scores metrics of job posted to /evaluate, against the rules a RulesLoader
+// returned for the request, or the server's rules when nil
+type Evaluator func(job string, metrics []loaders.JobMetricData, rules interface{}) (interface{}, error)
+
+// RulesOverride is the rules an /evaluate request asks to be scored against instead of the
+// server's: a named profile of the server, or an inline rules document
+type RulesOverride struct {
+	Profile  string
+	Document []byte
+}
+
+// RulesLoader loads the rules of an override and returns them with their version
+type RulesLoader func(override RulesOverride) (rules interface{}, version string, err error)
+
+// JobScorer scores the collected metrics of job
+type JobScorer func(job string) (interface{}, error)
+
+// Dashboard writes the HTML dashboard of the collected jobs visible reports true for to w
+type Dashboard func(w io.Writer, visible func(job string) bool) error
+
+// Exporter writes the scores served on /metrics in the Prometheus text format to w
+type Exporter func(w io.Writer) error
+
+// Options configures the API
+type Options struct {
+	Evaluate     Evaluator
+	LoadRules    RulesLoader   // nil refuses rules overrides on /evaluate
+	JobScore     JobScorer     // nil serves 404 on /jobs/{job}/score, e.g. without collected job files
+	Dashboard    Dashboard     // nil serves 404 on /
+	Report       Reporter      // nil serves /api/v1/report for runs only
+	Metrics      Exporter      // nil serves 404 on /metrics
+	Runs         *Runs         // Queues /evaluate and /jobs/{job}/score requests, and lists them on /runs; nil runs them at once
+	RulesVersion func() string // Version of the rules in effect, reported in the X-Rules-Version header
+	MaxBodyBytes int64         // 0 uses DefaultMaxBodyBytes
+
+	// RateLimit bounds the /evaluate, /jobs/{job}/score and POST /runs requests of each
+	// client, the authenticated principal or else the remote address, to this many a minute
+	// after a burst of RateBurst; 0 does not limit them, and a RateBurst of 0 allows a
+	// minute's worth
+	RateLimit float64
+	RateBurst int
+
+	// EvaluateBulk scores the job files posted to /runs, which needs Runs; nil serves 404.
+	// MaxBulkBytes bounds a posted tarball, 0 using DefaultMaxBulkBytes, and an S3 URI may
+	// only name one of BulkS3Buckets.
+	EvaluateBulk  BulkEvaluator
+	MaxBulkBytes  int64
+	BulkS3Buckets []string
+
+	// Auth requires credentials on every endpoint but the probes, nil serves everyone. An
+	// authenticated principal only sees the jobs of its teams, as TeamOf names the owners,
+	// and /metrics, which exports every job, needs access to all of them.
+	Auth   *Auth
+	TeamOf func(job string) string // Owning team of a job, "" for none; nil owns nothing
+
+	// Probes serves /healthz and /readyz, which need no credentials; nil serves /healthz with
+	// the rules version only, and 404 on /readyz
+	Probes *health.Server
+}
+
+// Handler serves the scoring API:
+//
+//	POST /evaluate?job=NAME[&input=exposition|job-file]  Score the metrics in the body, against
+//	     [&profile=NAME] or a multipart rules part          the named or posted rules if given
+//	GET  /jobs/{job}/score                               Score the collected metrics of a job
+//	GET  /metrics                                        Scores in the Prometheus text format
+//	POST /runs                                           Queue scoring a tarball or S3 prefix of job files
+//	GET  /runs[/{id}[/report]]                           Queued, running and finished runs and their reports
+//	GET  /api/v1/report[?run=ID]                         A page of the jobs of a report, sorted and filtered
+//	GET  /healthz                                        Liveness, with the rules version without Probes
+//	GET  /readyz                                         Readiness, running the checks of Probes
+//	GET  /                                               HTML dashboard of the collected jobs
+func Handler(opts Options) http.Handler {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if opts.MaxBulkBytes <= 0 {
+		opts.MaxBulkBytes = DefaultMaxBulkBytes
+	}
+	s := &server{opts: opts}
+	if opts.RateLimit > 0 {
+		s.limiter = newRateLimiter(opts.RateLimit, opts.RateBurst)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/evaluate", s.withRateLimit(s.serveEvaluate))
+	mux.HandleFunc("/jobs/", s.withRateLimit(s.serveJobScore))
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	mux.HandleFunc("/runs", s.serveRuns)
+	mux.HandleFunc("/runs/", s.serveRuns)
+	mux.HandleFunc("/api/v1/report", s.serveReport)
+	if opts.Probes != nil {
+		mux.Handle("/healthz", opts.Probes.Handler())
+		mux.Handle("/readyz", opts.Probes.Handler())
+	} else {
+		mux.HandleFunc("/healthz", s.serveHealthz)
+	}
+	mux.HandleFunc("/", s.serveDashboard)
+	return s.withRulesVersion(s.withAuth(mux))
+}
+
+type server struct {
+	opts    Options
+	limiter *rateLimiter // nil without a RateLimit
+}
+
+// errorResponse is the JSON body of every failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// withRulesVersion sets X-Rules-Version on every response
+func (s *server) withRulesVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.RulesVersion != nil {
+			w.Header().Set("X-Rules-Version", s.opts.RulesVersion())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withAuth authenticates every request but the probes when Auth is set
+func (s *server) withAuth(next http.Handler) http.Handler {
+	if s.opts.Auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, err := s.opts.Auth.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", s.opts.Auth.challenge())
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// visible reports whether the request's principal may see job, true without auth
+func (s *server) visible(r *http.Request, job string) bool {
+	principal, ok := principalFrom(r.Context())
+	if !ok {
+		return true
+	}
+	team := ""
+	if s.opts.TeamOf != nil {
+		team = s.opts.TeamOf(job)
+	}
+	return principal.CanSee(team)
+}
+
+// forbidden writes the 403 of a job the request's principal may not see
+func forbidden(w http.ResponseWriter, job string) {
+	writeError(w, http.StatusForbidden, fmt.Sprintf("job %s is not owned by your teams", job))
+}
+
+func (s *server) serveEvaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+
+	if r.ContentLength > s.opts.MaxBodyBytes {
+		// Refused before reading, so a large upload is not received only to be discarded
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("body is larger than %d bytes", s.opts.MaxBodyBytes))
+		return
+	}
+
+	job := r.URL.Query().Get("job")
+	input := r.URL.Query().Get("input")
+	override := RulesOverride{Profile: r.URL.Query().Get("profile")}
+	body := http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes)
+	var metrics []loaders.JobMetricData
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		metrics, job, override.Document, err = readMultipart(r, body, input, job)
+	} else {
+		metrics, job, err = readMetrics(body, input, job)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("body is larger than %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(metrics) == 0 {
+		writeError(w, http.StatusBadRequest, "no metrics in the body")
+		return
+	}
+	if !s.visible(r, job) {
+		forbidden(w, job)
+		return
+	}
+
+	var rules interface{}
+	if override.Profile != "" || override.Document != nil {
+		if override.Profile != "" && override.Document != nil {
+			writeError(w, http.StatusBadRequest, "use either the profile parameter or a rules part, not both")
+			return
+		}
+		if s.opts.LoadRules == nil {
+			writeError(w, http.StatusBadRequest, "this server does not accept rules overrides")
+			return
+		}
+		var version string
+		if rules, version, err = s.opts.LoadRules(override); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid rules: %v", err))
+			return
+		}
+		w.Header().Set("X-Rules-Version", version)
+	}
+
+	result, ok, err := s.runQueued(w, r, job, func() (interface{}, error) { return s.opts.Evaluate(job, metrics, rules) })
+	if !ok {
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// readMetrics reads the metrics of an /evaluate body in input format, and returns them with
+// the job they are scored as
+func readMetrics(body io.Reader, input, job string) ([]loaders.JobMetricData, string, error) {
+	switch input {
+	case "", InputExposition:
+		if job == "" {
+			return nil, "", errors.New("the job parameter is required for an exposition")
+		}
+		metrics, err := collectors.ReadExposition(body, job, "request")
+		return metrics, job, err
+	case InputJobFile:
+		metrics, _, err := loaders.ReadJobMetricReport(body, "request")
+		if err == nil && job == "" && len(metrics) > 0 {
+			job = metrics[0].Job
+		}
+		return metrics, job, err
+	default:
+		return nil, "", fmt.Errorf("unknown input %q, use %s or %s", input, InputExposition, InputJobFile)
+	}
+}
+
+// readMultipart reads a multipart/form-data /evaluate body: a metrics part read as readMetrics
+// does, and an optional rules part with a rules document to score them against
+func readMultipart(r *http.Request, body io.ReadCloser, input, job string) (metrics []loaders.JobMetricData, metricsJob string, rules []byte, err error) {
+	r.Body = body
+	parts, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	read := false
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", nil, err
+		}
+		switch part.FormName() {
+		case "metrics":
+			if metrics, metricsJob, err = readMetrics(part, input, job); err != nil {
+				return nil, "", nil, err
+			}
+			read = true
+		case "rules":
+			if rules, err = io.ReadAll(io.LimitReader(part, maxRulesBytes+1)); err != nil {
+				return nil, "", nil, err
+			}
+			if len(rules) > maxRulesBytes {
+				return nil, "", nil, fmt.Errorf("the rules part is larger than %d bytes", maxRulesBytes)
+			}
+		default:
+			return nil, "", nil, fmt.Errorf("unknown part %q, use metrics and rules", part.FormName())
+		}
+	}
+	if !read {
+		return nil, "", nil, errors.New("no metrics part in the body")
+	}
+	return metrics, metricsJob, rules, nil
+}
+
+func (s *server) serveJobScore(w http.ResponseWriter, r *http.Request) {
+	job, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/score")
+	if !ok || job == "" || strings.Contains(job, "/") {
+		writeError(w, http.StatusNotFound, "use /jobs/{job}/score")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	if s.opts.JobScore == nil {
+		writeError(w, http.StatusNotFound, "no job directory is served")
+		return
+	}
+	if !s.visible(r, job) {
+		forbidden(w, job)
+		return
+	}
+
+	result, ok, err := s.runQueued(w, r, job, func() (interface{}, error) { return s.opts.JobScore(job) })
+	if !ok {
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no metrics collected for job %s", job))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Metrics == nil {
+		writeError(w, http.StatusNotFound, "no scores are exported")
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	if principal, ok := principalFrom(r.Context()); ok && !principal.CanSee("") {
+		writeError(w, http.StatusForbidden, "/metrics exports every job, which needs access to all teams")
+		return
+	}
+
+	var metrics strings.Builder
+	if err := s.opts.Metrics(&metrics); err != nil {
+		// E.g. before the first scoring run completed; the scrape fails rather than reporting no jobs
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	io.WriteString(w, metrics.String())
+}
+
+func (s *server) serveHealthz(w http.ResponseWriter, r *http.Request) {
+	status := map[string]string{"status": "ok"}
+	if s.opts.RulesVersion != nil {
+		status["rules_version"] = s.opts.RulesVersion()
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *server) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" || s.opts.Dashboard == nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+
+	// Rendered fully before writing, so a failure is still reported with an error status
+	var page strings.Builder
+	visible := func(job string) bool { return s.visible(r, job) }
+	if err := s.opts.Dashboard(&page, visible); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, page.String())
+}
+
+// writeJSON writes body as JSON with status
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes message as a JSON error with status
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"instrumentation-score/internal/health"
+	"instrumentation-score/internal/loaders"
+)
+
+func testHandler() http.Handler {
+	return Handler(Options{
+		Evaluate: func(job string, metrics []loaders.JobMetricData, rules interface{}) (interface{}, error) {
+			if job == "broken" {
+				return nil, errors.New("evaluation failed")
+			}
+			return map[string]interface{}{"job_name": job, "total_metrics": len(metrics)}, nil
+		},
+		JobScore: func(job string) (interface{}, error) {
+			if job != "api" {
+				return nil, ErrNotFound
+			}
+			return map[string]interface{}{"job_name": job, "score": 80}, nil
+		},
+		Dashboard: func(w io.Writer, visible func(job string) bool) error {
+			_, err := io.WriteString(w, "<html>dashboard</html>")
+			return err
+		},
+		RulesVersion: func() string { return "abc123" },
+		MaxBodyBytes: 1024,
+	})
+}
+
+func do(t *testing.T, handler http.Handler, method, target, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	var decoded map[string]interface{}
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+			t.Fatalf("invalid JSON body %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec, decoded
+}
+
+func TestHandler_Evaluate(t *testing.T) {
+	exposition := "# TYPE http_requests_total counter\nhttp_requests_total{path=\"/\"} 1\nhttp_requests_total{path=\"/a\"} 2\n"
+	jobFile := "#format=v2\nJOB|METRIC_NAME|LABELS|CARDINALITY|LABEL_CARDINALITY|TYPE\ncheckout|http_requests_total|path|2|path:2|counter\n"
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantJob    string
+		wantError  string
+	}{
+		{"exposition", "POST", "/evaluate?job=checkout", exposition, http.StatusOK, "checkout", ""},
+		{"job file names the job", "POST", "/evaluate?input=job-file", jobFile, http.StatusOK, "checkout", ""},
+		{"exposition needs a job", "POST", "/evaluate", exposition, http.StatusBadRequest, "", "job parameter is required"},
+		{"unknown input", "POST", "/evaluate?job=a&input=otlp", exposition, http.StatusBadRequest, "", `unknown input "otlp"`},
+		{"empty body", "POST", "/evaluate?job=a", "", http.StatusBadRequest, "", "no metrics"},
+		{"body too large", "POST", "/evaluate?job=a", strings.Repeat("a 1\n", 500), http.StatusRequestEntityTooLarge, "", "larger than 1024 bytes"},
+		{"evaluation error", "POST", "/evaluate?job=broken", exposition, http.StatusUnprocessableEntity, "", "evaluation failed"},
+		{"wrong method", "GET", "/evaluate?job=a", "", http.StatusMethodNotAllowed, "", "use POST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, body := do(t, testHandler(), tt.method, tt.target, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantJob != "" && body["job_name"] != tt.wantJob {
+				t.Errorf("job_name = %v, want %s", body["job_name"], tt.wantJob)
+			}
+			if tt.wantError != "" && !strings.Contains(body["error"].(string), tt.wantError) {
+				t.Errorf("error = %v, want it to contain %q", body["error"], tt.wantError)
+			}
+			if rec.Header().Get("X-Rules-Version") != "abc123" {
+				t.Errorf("expected the rules version header, got %q", rec.Header().Get("X-Rules-Version"))
+			}
+		})
+	}
+}
+
+func TestHandler_EvaluateRulesOverride(t *testing.T) {
+	handler := Handler(Options{
+		Evaluate: func(job string, metrics []loaders.JobMetricData, rules interface{}) (interface{}, error) {
+			if rules == nil {
+				rules = "server"
+			}
+			return map[string]interface{}{"job_name": job, "rules": rules}, nil
+		},
+		LoadRules: func(override RulesOverride) (interface{}, string, error) {
+			switch {
+			case override.Profile == "strict":
+				return "strict", "strict1", nil
+			case strings.HasPrefix(string(override.Document), "rules:"):
+				return "inline", "inline1", nil
+			}
+			return nil, "", errors.New("unknown rules")
+		},
+		RulesVersion: func() string { return "abc123" },
+	})
+	form := func(parts ...string) (string, string) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for i := 0; i < len(parts); i += 2 {
+			part, _ := writer.CreateFormFile(parts[i], parts[i])
+			io.WriteString(part, parts[i+1])
+		}
+		writer.Close()
+		return body.String(), writer.FormDataContentType()
+	}
+	exposition := "http_requests_total 1\n"
+
+	tests := []struct {
+		name        string
+		target      string
+		parts       []string // Multipart name and content pairs; nil posts the exposition alone
+		wantStatus  int
+		wantRules   string
+		wantVersion string
+	}{
+		{"server rules", "/evaluate?job=a", nil, http.StatusOK, "server", "abc123"},
+		{"profile", "/evaluate?job=a&profile=strict", nil, http.StatusOK, "strict", "strict1"},
+		{"inline rules", "/evaluate?job=a", []string{"rules", "rules: []", "metrics", exposition}, http.StatusOK, "inline", "inline1"},
+		{"metrics only", "/evaluate?job=a", []string{"metrics", exposition}, http.StatusOK, "server", "abc123"},
+		{"unknown profile", "/evaluate?job=a&profile=lax", nil, http.StatusBadRequest, "", ""},
+		{"invalid rules", "/evaluate?job=a", []string{"metrics", exposition, "rules", "{"}, http.StatusBadRequest, "", ""},
+		{"profile and rules", "/evaluate?job=a&profile=strict", []string{"metrics", exposition, "rules", "rules: []"}, http.StatusBadRequest, "", ""},
+		{"no metrics part", "/evaluate?job=a", []string{"rules", "rules: []"}, http.StatusBadRequest, "", ""},
+		{"unknown part", "/evaluate?job=a", []string{"metrics", exposition, "extra", ""}, http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, strings.NewReader(exposition))
+			if tt.parts != nil {
+				body, contentType := form(tt.parts...)
+				req = httptest.NewRequest("POST", tt.target, strings.NewReader(body))
+				req.Header.Set("Content-Type", contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantRules == "" {
+				return
+			}
+			var body map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body["rules"] != tt.wantRules || rec.Header().Get("X-Rules-Version") != tt.wantVersion {
+				t.Errorf("scored with %v (version %s), want %s (version %s)", body["rules"], rec.Header().Get("X-Rules-Version"), tt.wantRules, tt.wantVersion)
+			}
+		})
+	}
+
+	if rec, _ := do(t, testHandler(), "POST", "/evaluate?job=a&profile=strict", exposition); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an override without a rules loader, got %d", rec.Code)
+	}
+}
+
+func TestHandler_JobScore(t *testing.T) {
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{"/jobs/api/score", http.StatusOK},
+		{"/jobs/billing/score", http.StatusNotFound},
+		{"/jobs/api", http.StatusNotFound},
+		{"/jobs/team/api/score", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec, body := do(t, testHandler(), "GET", tt.target, "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("GET %s: status = %d, want %d", tt.target, rec.Code, tt.wantStatus)
+		}
+		if tt.wantStatus == http.StatusOK && body["score"] != float64(80) {
+			t.Errorf("GET %s: unexpected body %v", tt.target, body)
+		}
+	}
+
+	withoutJobs := Handler(Options{})
+	if rec, _ := do(t, withoutJobs, "GET", "/jobs/api/score", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a job scorer, got %d", rec.Code)
+	}
+	if rec, _ := do(t, withoutJobs, "GET", "/", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a dashboard, got %d", rec.Code)
+	}
+}
+
+func TestHandler_Probes(t *testing.T) {
+	rulesErr := error(nil)
+	auth, err := NewAuth(AuthConfig{Users: []CredentialConfig{{Name: "ana", PasswordSHA256: digest("pw"), Teams: []string{AllTeams}}}})
+	if err != nil {
+		t.Fatalf("NewAuth() error = %v", err)
+	}
+	handler := Handler(Options{
+		Auth:   auth,
+		Probes: health.NewServer(0, health.Check{Name: "rules", Run: func() error { return rulesErr }}),
+	})
+
+	if rec, body := do(t, handler, "GET", "/readyz", ""); rec.Code != http.StatusOK || body["status"] != "ok" {
+		t.Errorf("/readyz = %d %v, want 200 without credentials", rec.Code, body)
+	}
+	rulesErr = errors.New("invalid rules")
+	rec, body := do(t, handler, "GET", "/readyz", "")
+	if checks, _ := body["checks"].(map[string]interface{}); rec.Code != http.StatusServiceUnavailable || checks["rules"] != "invalid rules" {
+		t.Errorf("/readyz = %d %v, want 503 naming the rules check", rec.Code, body)
+	}
+	if rec, _ := do(t, handler, "GET", "/healthz", ""); rec.Code != http.StatusOK {
+		t.Errorf("/healthz = %d, want 200 while only readiness fails", rec.Code)
+	}
+	if rec, _ := do(t, Handler(Options{}), "GET", "/readyz", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("/readyz without probes = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandler_HealthzAndDashboard(t *testing.T) {
+	rec, body := do(t, testHandler(), "GET", "/healthz", "")
+	if rec.Code != http.StatusOK || body["status"] != "ok" || body["rules_version"] != "abc123" {
+		t.Errorf("unexpected /healthz response %d %v", rec.Code, body)
+	}
+
+	rec, _ = do(t, testHandler(), "GET", "/", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "<html>dashboard</html>" {
+		t.Errorf("unexpected dashboard response %d %q", rec.Code, rec.Body.String())
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("expected an HTML content type, got %q", rec.Header().Get("Content-Type"))
+	}
+
+	failing := Handler(Options{Dashboard: func(w io.Writer, visible func(job string) bool) error { return errors.New("no jobs") }})
+	if rec, body := do(t, failing, "GET", "/", ""); rec.Code != http.StatusInternalServerError || body["error"] != "no jobs" {
+		t.Errorf("expected a 500 with the error, got %d %v", rec.Code, body)
+	}
+	if rec, _ := do(t, testHandler(), "GET", "/missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown path, got %d", rec.Code)
+	}
+}
+
+func TestHandler_Metrics(t *testing.T) {
+	if rec, _ := do(t, testHandler(), "GET", "/metrics", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without an exporter, got %d", rec.Code)
+	}
+
+	exporter := Handler(Options{Metrics: func(w io.Writer) error {
+		_, err := io.WriteString(w, "instrumentation_quality_score{job=\"api\"} 80.00\n")
+		return err
+	}})
+	rec, _ := do(t, exporter, "GET", "/metrics", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `job="api"`) {
+		t.Errorf("unexpected /metrics response %d %q", rec.Code, rec.Body.String())
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("expected the Prometheus text content type, got %q", rec.Header().Get("Content-Type"))
+	}
+
+	notReady := Handler(Options{Metrics: func(w io.Writer) error { return errors.New("no scoring run completed yet") }})
+	if rec, body := do(t, notReady, "GET", "/metrics", ""); rec.Code != http.StatusServiceUnavailable || body["error"] != "no scoring run completed yet" {
+		t.Errorf("expected a 503 with the error, got %d %v", rec.Code, body)
+	}
+}
@@ -0,0 +1,115 @@
+package spec
+
+import (
+	"sort"
+	"strings"
+
+	"instrumentation-score/internal/engine"
+)
+
+// Conformance statuses of a specification rule
+const (
+	StatusImplemented = "implemented" // A rule with validators declares it in spec_rule_ids
+	StatusPlaceholder = "placeholder" // Only rules without validators declare it, e.g. imported by rules import spec
+	StatusNotCovered  = "not_covered" // No rule declares it
+)
+
+// Conformance maps each specification rule to the rules implementing it, the basis of a
+// claim of compliance with the specification
+type Conformance struct {
+	Origin      string             `json:"origin"` // Where the specification was read from, e.g. instrumentation-score/spec@main
+	Implemented int                `json:"implemented"`
+	Total       int                `json:"total"`
+	Percentage  float64            `json:"percentage"` // Share of the specification's rules implemented
+	Rules       []ConformanceRule  `json:"rules"`
+	Unknown     []UnknownReference `json:"unknown_references,omitempty"` // spec_rule_ids the specification does not define
+}
+
+// ConformanceRule is the conformance of one specification rule
+type ConformanceRule struct {
+	SpecRuleID    string   `json:"spec_rule_id"`
+	Title         string   `json:"title"`
+	Impact        string   `json:"impact"`
+	Source        string   `json:"source,omitempty"`
+	Status        string   `json:"status"`                   // StatusImplemented, StatusPlaceholder or StatusNotCovered
+	ImplementedBy []string `json:"implemented_by,omitempty"` // Rules with validators declaring the spec rule
+	Placeholders  []string `json:"placeholders,omitempty"`   // Rules without validators declaring it
+}
+
+// URL returns the rule's page in the specification, empty when it was read from a local checkout
+func (r ConformanceRule) URL() string {
+	if strings.HasPrefix(r.Source, "https://") || strings.HasPrefix(r.Source, "http://") {
+		return r.Source
+	}
+	return ""
+}
+
+// UnknownReference is a rule declaring a specification rule ID the specification lacks,
+// usually a typo or a rule removed from the specification
+type UnknownReference struct {
+	RuleID     string `json:"rule_id"`
+	SpecRuleID string `json:"spec_rule_id"`
+}
+
+// CheckConformance maps the specification's rules to the rules of a config by their
+// spec_rule_ids. A specification rule only counts as implemented when a rule with
+// validators declares it: placeholders do not check anything.
+func CheckConformance(origin string, rules []Rule, defs []engine.RuleDefinition) *Conformance {
+	report := &Conformance{Origin: origin, Total: len(rules), Rules: make([]ConformanceRule, 0, len(rules))}
+
+	known := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		known[rule.ID] = true
+	}
+	implementedBy := make(map[string][]string)
+	placeholders := make(map[string][]string)
+	for _, def := range defs {
+		for _, id := range def.SpecRuleIDs {
+			switch {
+			case !known[id]:
+				report.Unknown = append(report.Unknown, UnknownReference{RuleID: def.RuleID, SpecRuleID: id})
+			case len(def.Validators) == 0:
+				placeholders[id] = append(placeholders[id], def.RuleID)
+			default:
+				implementedBy[id] = append(implementedBy[id], def.RuleID)
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		entry := ConformanceRule{
+			SpecRuleID:    rule.ID,
+			Title:         rule.Title,
+			Impact:        rule.Impact,
+			Source:        rule.Source,
+			Status:        StatusNotCovered,
+			ImplementedBy: implementedBy[rule.ID],
+			Placeholders:  placeholders[rule.ID],
+		}
+		switch {
+		case len(entry.ImplementedBy) > 0:
+			entry.Status = StatusImplemented
+			report.Implemented++
+		case len(entry.Placeholders) > 0:
+			entry.Status = StatusPlaceholder
+		}
+		report.Rules = append(report.Rules, entry)
+	}
+	sort.SliceStable(report.Rules, func(i, j int) bool { return report.Rules[i].SpecRuleID < report.Rules[j].SpecRuleID })
+
+	if report.Total > 0 {
+		report.Percentage = float64(report.Implemented) / float64(report.Total) * 100
+	}
+	return report
+}
+
+// Uncovered returns the specification rules no rule with validators implements
+func (c *Conformance) Uncovered() []ConformanceRule {
+	var uncovered []ConformanceRule
+	for _, rule := range c.Rules {
+		if rule.Status != StatusImplemented {
+			uncovered = append(uncovered, rule)
+		}
+	}
+	return uncovered
+}
@@ -0,0 +1,53 @@
+package spec
+
+import (
+	"reflect"
+	"testing"
+
+	"instrumentation-score/internal/engine"
+)
+
+func TestCheckConformance(t *testing.T) {
+	rules := []Rule{
+		{ID: "RES-001", Title: "Service name is set", Impact: "Critical"},
+		{ID: "MET-001", Title: "Bounded cardinality", Impact: "Critical"},
+		{ID: "SPA-001", Title: "Spans have names", Impact: "Normal"},
+		{ID: "LOG-001", Title: "Logs have severity", Impact: "Important"},
+	}
+	validator := []engine.ValidatorConfig{{Name: "check", Type: "cardinality"}}
+	defs := []engine.RuleDefinition{
+		{RuleID: "PROM-MET-02", SpecRuleIDs: []string{"MET-001"}, Validators: validator},
+		{RuleID: "PROM-MET-03", SpecRuleIDs: []string{"MET-001", "MET-999"}, Validators: validator},
+		{RuleID: "SPA-001", SpecRuleIDs: []string{"SPA-001"}, Validators: []engine.ValidatorConfig{}},
+		{RuleID: "PROM-USE-01", Validators: validator},
+	}
+
+	report := CheckConformance("spec@main", rules, defs)
+	if report.Implemented != 1 || report.Total != 4 || report.Percentage != 25 {
+		t.Errorf("totals = %d of %d (%.1f%%), want 1 of 4 (25%%)", report.Implemented, report.Total, report.Percentage)
+	}
+
+	statuses := make(map[string]ConformanceRule)
+	for _, rule := range report.Rules {
+		statuses[rule.SpecRuleID] = rule
+	}
+	if got := statuses["MET-001"]; got.Status != StatusImplemented || !reflect.DeepEqual(got.ImplementedBy, []string{"PROM-MET-02", "PROM-MET-03"}) {
+		t.Errorf("MET-001 = %+v, want implemented by both rules", got)
+	}
+	if got := statuses["SPA-001"]; got.Status != StatusPlaceholder || got.Placeholders[0] != "SPA-001" {
+		t.Errorf("SPA-001 = %+v, want a placeholder", got)
+	}
+	if got := statuses["RES-001"]; got.Status != StatusNotCovered {
+		t.Errorf("RES-001 = %+v, want not covered", got)
+	}
+	if report.Rules[0].SpecRuleID != "LOG-001" {
+		t.Errorf("rules are not sorted by ID: %+v", report.Rules)
+	}
+
+	if want := []UnknownReference{{RuleID: "PROM-MET-03", SpecRuleID: "MET-999"}}; !reflect.DeepEqual(report.Unknown, want) {
+		t.Errorf("Unknown = %+v, want %+v", report.Unknown, want)
+	}
+	if uncovered := report.Uncovered(); len(uncovered) != 3 {
+		t.Errorf("Uncovered() = %+v, want the 3 rules without validators", uncovered)
+	}
+}
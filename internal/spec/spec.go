@@ -0,0 +1,340 @@
+// Package spec imports the rules of the Instrumentation Score specification
+// (https://github.com/instrumentation-score/spec) into this tool's rules schema
+package spec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"instrumentation-score/internal/engine"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Where the specification's rules are fetched from by default
+const (
+	DefaultRepo   = "instrumentation-score/spec"
+	DefaultRef    = "main"
+	DefaultAPIURL = "https://api.github.com"
+	RulesDir      = "rules" // Directory of the specification holding one Markdown file per rule
+)
+
+// maxRuleBytes bounds the size of a rule file
+const maxRuleBytes = 1 << 20
+
+// Rule is a specification rule, as documented in its Markdown file
+type Rule struct {
+	ID          string
+	Title       string
+	Description string
+	Rationale   string
+	Target      string // What the rule checks, e.g. Resource, Span, Metric
+	Criteria    string
+	Impact      string // Critical, Important, Normal or Low
+	Source      string // File or URL the rule was read from
+}
+
+// impacts are the impact levels of the specification, the keys of the score weights
+var impacts = []string{"Critical", "Important", "Normal", "Low"}
+
+var (
+	idPattern      = regexp.MustCompile(`^[A-Z][A-Z0-9]*(-[A-Z0-9]+)+$`)
+	headingPattern = regexp.MustCompile(`^#+\s+(?:([A-Z][A-Z0-9]*(?:-[A-Z0-9]+)+)\s*[:—-]\s*)?(.+?)\s*$`)
+	fieldPattern   = regexp.MustCompile(`^\*\*([A-Za-z ]+):\*\*\s*(.*)$`)
+)
+
+// Parse reads a rule from its Markdown file in the specification's format:
+// "**Rule ID:**", "**Description:**", "**Rationale:**", "**Target:**", "**Criteria:**" and
+// "**Impact:**" fields, the ID also accepted from a "# ID: Title" heading or the file name.
+// It returns false for Markdown files that are not rules, such as a README.
+func Parse(source string, markdown []byte) (Rule, bool, error) {
+	rule := Rule{Source: source}
+	fields := map[string]*string{
+		"rule id":     &rule.ID,
+		"description": &rule.Description,
+		"rationale":   &rule.Rationale,
+		"target":      &rule.Target,
+		"criteria":    &rule.Criteria,
+		"impact":      &rule.Impact,
+	}
+
+	var current *string // Field the following lines continue, nil outside known fields
+	inCode := false
+	scanner := bufio.NewScanner(bytes.NewReader(markdown))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			current = nil
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			if rule.Title == "" {
+				rule.Title = match[2]
+				if match[1] != "" && rule.ID == "" {
+					rule.ID = match[1]
+				}
+			}
+			current = nil
+			continue
+		}
+		if match := fieldPattern.FindStringSubmatch(line); match != nil {
+			current = fields[strings.ToLower(strings.TrimSpace(match[1]))]
+			if current != nil {
+				*current = match[2]
+			}
+			continue
+		}
+		if current != nil {
+			*current = strings.TrimSpace(*current + "\n" + line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return rule, false, fmt.Errorf("%s: %w", source, err)
+	}
+
+	if rule.ID == "" {
+		rule.ID = strings.TrimSuffix(path.Base(source), path.Ext(source))
+	}
+	rule.ID = strings.Trim(strings.TrimSpace(rule.ID), "`")
+	if !idPattern.MatchString(rule.ID) || rule.Impact == "" {
+		return rule, false, nil
+	}
+	for _, field := range fields {
+		*field = strings.TrimSpace(*field)
+	}
+	impact, err := normalizeImpact(rule.Impact)
+	if err != nil {
+		return rule, false, fmt.Errorf("%s: rule %s: %w", source, rule.ID, err)
+	}
+	rule.Impact = impact
+	return rule, true, nil
+}
+
+// normalizeImpact returns the impact level matching value, ignoring case and emphasis
+func normalizeImpact(value string) (string, error) {
+	value = strings.Trim(strings.TrimSpace(value), "*_`")
+	for _, impact := range impacts {
+		if strings.EqualFold(value, impact) {
+			return impact, nil
+		}
+	}
+	return "", fmt.Errorf("impact %q is not one of %s", value, strings.Join(impacts, ", "))
+}
+
+// LoadDir reads the rules of a local checkout of the specification from dir in fsys
+func LoadDir(fsys fs.FS, dir string) ([]Rule, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		rule, ok, err := Parse(name, data)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no specification rules found in %s", dir)
+	}
+	return sortRules(rules), nil
+}
+
+// Client fetches the specification's rules from GitHub
+type Client struct {
+	HTTP   *http.Client
+	APIURL string // GitHub API, DefaultAPIURL or a GitHub Enterprise /api/v3 URL
+	Token  string // Sent as a bearer token when set, e.g. GITHUB_TOKEN against API rate limits
+}
+
+// Fetch returns the rules in the RulesDir of repo ("owner/name") at ref
+func (c *Client) Fetch(repo, ref string) ([]Rule, error) {
+	listURL := fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s", strings.TrimSuffix(c.APIURL, "/"), repo, RulesDir, ref)
+	data, err := c.get(listURL)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Name        string `json:"name"`
+		Type        string `json:"type"`
+		DownloadURL string `json:"download_url"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse rules listing from %s: %w", listURL, err)
+	}
+
+	var rules []Rule
+	for _, entry := range entries {
+		if entry.Type != "file" || path.Ext(entry.Name) != ".md" || entry.DownloadURL == "" {
+			continue
+		}
+		markdown, err := c.get(entry.DownloadURL)
+		if err != nil {
+			return nil, err
+		}
+		rule, ok, err := Parse(entry.Name, markdown)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rule.Source = fmt.Sprintf("https://github.com/%s/blob/%s/%s/%s", repo, ref, RulesDir, entry.Name)
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no specification rules found in %s", listURL)
+	}
+	return sortRules(rules), nil
+}
+
+// get returns the body of url
+func (c *Client) get(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRuleBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if len(data) > maxRuleBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxRuleBytes)
+	}
+	return data, nil
+}
+
+func sortRules(rules []Rule) []Rule {
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// Import is the result of converting specification rules against an existing rule set
+type Import struct {
+	Implemented map[string][]string // Spec rule ID -> IDs of existing rules declaring it in spec_rule_ids
+	Imported    []Rule              // Spec rules no existing rule implements, converted to placeholders
+}
+
+// Convert maps spec rules to the existing rules that implement them, by their spec_rule_ids
+// Rules no existing rule implements are imported as placeholders: rules with the spec's ID,
+// description and impact but no validators, which do not affect scores until validators
+// are added.
+func Convert(rules []Rule, existing []engine.RuleDefinition) Import {
+	result := Import{Implemented: make(map[string][]string)}
+	for _, rule := range existing {
+		for _, id := range rule.SpecRuleIDs {
+			result.Implemented[id] = append(result.Implemented[id], rule.RuleID)
+		}
+	}
+	for _, rule := range rules {
+		if _, ok := result.Implemented[rule.ID]; !ok {
+			result.Imported = append(result.Imported, rule)
+		}
+	}
+	return result
+}
+
+// Pack renders the imported rules as a rule pack for the include list of a rules file
+// Each rule is preceded by a comment with the spec's target and criteria, the starting
+// point for writing its validators.
+func (i Import) Pack(origin string) ([]byte, error) {
+	config := engine.RulesConfig{Rules: make([]engine.RuleDefinition, 0, len(i.Imported))}
+	for _, rule := range i.Imported {
+		description := rule.Description
+		if description == "" {
+			description = rule.Title
+		}
+		config.Rules = append(config.Rules, engine.RuleDefinition{
+			RuleID:      rule.ID,
+			Description: description,
+			Impact:      rule.Impact,
+			SpecRuleIDs: []string{rule.ID},
+			Validators:  []engine.ValidatorConfig{},
+		})
+	}
+
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return nil, err
+	}
+	doc.HeadComment = "Instrumentation Score specification rules, imported from " + origin + "\n" +
+		"Rules without validators are placeholders: they do not affect scores until\n" +
+		"validators implementing their criteria are added."
+	for _, node := range nodeValue(&doc, "rules").Content {
+		id := nodeValue(node, "rule_id").Value
+		for _, rule := range i.Imported {
+			if rule.ID == id {
+				node.HeadComment = ruleComment(rule)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), encoder.Close()
+}
+
+// ruleComment describes a spec rule in the comment above its placeholder
+func ruleComment(rule Rule) string {
+	lines := []string{rule.ID + ": " + rule.Title}
+	if rule.Target != "" {
+		lines = append(lines, "Target: "+strings.ReplaceAll(rule.Target, "\n", " "))
+	}
+	if rule.Criteria != "" {
+		lines = append(lines, "Criteria:")
+		for _, line := range strings.Split(rule.Criteria, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+	lines = append(lines, "Source: "+rule.Source)
+	return strings.Join(lines, "\n")
+}
+
+// nodeValue returns the value of key in the mapping node, or of the document's root mapping
+func nodeValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return &yaml.Node{}
+}
@@ -0,0 +1,166 @@
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"instrumentation-score/internal/engine"
+
+	"gopkg.in/yaml.v3"
+)
+
+const resourceRule = `# RES-001: Service name is set
+
+**Rule ID:** RES-001
+
+**Description:** The service.name resource attribute must be set.
+
+**Rationale:** Telemetry without a service name cannot be attributed.
+
+**Target:** Resource
+
+**Criteria:**
+- service.name MUST be present
+- service.name MUST NOT be unknown_service
+
+**Impact:** critical
+
+**Examples:**
+
+` + "```yaml\n**Impact:** Low\n```\n"
+
+func TestParse(t *testing.T) {
+	rule, ok, err := Parse("rules/RES-001.md", []byte(resourceRule))
+	if err != nil || !ok {
+		t.Fatalf("Parse() = %v, %v", ok, err)
+	}
+	want := Rule{
+		ID:          "RES-001",
+		Title:       "Service name is set",
+		Description: "The service.name resource attribute must be set.",
+		Rationale:   "Telemetry without a service name cannot be attributed.",
+		Target:      "Resource",
+		Criteria:    "- service.name MUST be present\n- service.name MUST NOT be unknown_service",
+		Impact:      "Critical", // Normalized, and not overridden by the example
+		Source:      "rules/RES-001.md",
+	}
+	if rule != want {
+		t.Errorf("Parse() = %+v\nwant %+v", rule, want)
+	}
+
+	// The ID may come from the heading or the file name alone
+	rule, ok, err = Parse("SPA-002.md", []byte("# Spans have names\n\n**Impact:** Low\n"))
+	if err != nil || !ok || rule.ID != "SPA-002" || rule.Title != "Spans have names" {
+		t.Errorf("Parse() = %+v, %v, %v, want SPA-002 from the file name", rule, ok, err)
+	}
+
+	if _, ok, err := Parse("README.md", []byte("# Rules\n\nOne file per rule.\n")); ok || err != nil {
+		t.Errorf("Parse(README.md) = %v, %v, want no rule", ok, err)
+	}
+	if _, _, err := Parse("LOG-001.md", []byte("**Impact:** Severe\n")); err == nil {
+		t.Error("Parse() should reject an unknown impact")
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"spec/rules/RES-001.md": {Data: []byte(resourceRule)},
+		"spec/rules/LOG-001.md": {Data: []byte("# LOG-001: Logs have severity\n\n**Impact:** Important\n")},
+		"spec/rules/README.md":  {Data: []byte("# Rules\n")},
+	}
+	rules, err := LoadDir(fsys, "spec/rules")
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if len(rules) != 2 || rules[0].ID != "LOG-001" || rules[1].ID != "RES-001" {
+		t.Errorf("LoadDir() = %+v, want LOG-001 and RES-001 sorted", rules)
+	}
+	if _, err := LoadDir(fstest.MapFS{}, "rules"); err == nil {
+		t.Error("LoadDir() of an empty directory should fail")
+	}
+}
+
+func TestClientFetch(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/instrumentation-score/spec/contents/rules":
+			if r.URL.Query().Get("ref") != "v1.0" {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode([]map[string]string{
+				{"name": "RES-001.md", "type": "file", "download_url": server.URL + "/raw/RES-001.md"},
+				{"name": "README.md", "type": "file", "download_url": server.URL + "/raw/README.md"},
+				{"name": "images", "type": "dir"},
+			})
+		case "/raw/RES-001.md":
+			w.Write([]byte(resourceRule))
+		case "/raw/README.md":
+			w.Write([]byte("# Rules\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{HTTP: server.Client(), APIURL: server.URL, Token: "token"}
+	rules, err := client.Fetch(DefaultRepo, "v1.0")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(rules) != 1 || rules[0].ID != "RES-001" {
+		t.Fatalf("Fetch() = %+v", rules)
+	}
+	if want := "https://github.com/instrumentation-score/spec/blob/v1.0/rules/RES-001.md"; rules[0].Source != want {
+		t.Errorf("Source = %q, want %q", rules[0].Source, want)
+	}
+	if _, err := client.Fetch(DefaultRepo, "missing"); err == nil {
+		t.Error("Fetch() of a missing ref should fail")
+	}
+}
+
+func TestConvertAndPack(t *testing.T) {
+	rules := []Rule{
+		{ID: "MET-001", Title: "Bounded cardinality", Impact: "Critical", Source: "MET-001.md"},
+		{ID: "RES-001", Title: "Service name is set", Description: "service.name is set", Target: "Resource",
+			Criteria: "- service.name MUST be present", Impact: "Critical", Source: "RES-001.md"},
+	}
+	existing := []engine.RuleDefinition{{RuleID: "PROM-MET-02", SpecRuleIDs: []string{"MET-001"}}}
+
+	result := Convert(rules, existing)
+	if got := result.Implemented["MET-001"]; len(got) != 1 || got[0] != "PROM-MET-02" {
+		t.Errorf("Implemented = %v, want MET-001 implemented by PROM-MET-02", result.Implemented)
+	}
+	if len(result.Imported) != 1 || result.Imported[0].ID != "RES-001" {
+		t.Fatalf("Imported = %+v, want only RES-001", result.Imported)
+	}
+
+	pack, err := result.Pack("instrumentation-score/spec@main")
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	for _, want := range []string{"# Instrumentation Score specification rules, imported from instrumentation-score/spec@main",
+		"# RES-001: Service name is set", "#   - service.name MUST be present", "spec_rule_ids:"} {
+		if !strings.Contains(string(pack), want) {
+			t.Errorf("Pack() does not contain %q:\n%s", want, pack)
+		}
+	}
+
+	var config engine.RulesConfig
+	if err := yaml.Unmarshal(pack, &config); err != nil {
+		t.Fatalf("Pack() is not a rules config: %v", err)
+	}
+	if len(config.Rules) != 1 || config.Rules[0].RuleID != "RES-001" || config.Rules[0].Description != "service.name is set" ||
+		config.Rules[0].SpecRuleIDs[0] != "RES-001" {
+		t.Errorf("Pack() rules = %+v", config.Rules)
+	}
+}
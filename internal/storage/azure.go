@@ -0,0 +1,345 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Azure Storage settings read from the environment, named like the Azure CLI's
+const (
+	AzureAccountEnv   = "AZURE_STORAGE_ACCOUNT"
+	AzureKeyEnv       = "AZURE_STORAGE_KEY"       // Shared key of the account
+	AzureSASTokenEnv  = "AZURE_STORAGE_SAS_TOKEN" // SAS token, used instead of a shared key
+	AzureContainerEnv = "AZURE_STORAGE_CONTAINER"
+)
+
+// azureAPIVersion is the Blob service REST API version requests are made with
+const azureAPIVersion = "2021-08-06"
+
+// AzureBlobClient uploads and downloads blobs of one Azure Storage container over the
+// Blob service REST API, authorized with the account's shared key or a SAS token
+type AzureBlobClient struct {
+	account   string
+	container string
+	prefix    string
+	key       []byte // Decoded shared key; nil with a SAS token
+	sasToken  string
+	endpoint  string // https://<account>.blob.core.windows.net
+	client    *http.Client
+}
+
+// NewAzureBlobClient creates a client for container in account, reading the shared key from
+// AZURE_STORAGE_KEY or a SAS token from AZURE_STORAGE_SAS_TOKEN
+func NewAzureBlobClient(account, container, prefix string) (*AzureBlobClient, error) {
+	return newAzureBlobClient(account, container, prefix, os.Getenv(AzureKeyEnv), os.Getenv(AzureSASTokenEnv))
+}
+
+func newAzureBlobClient(account, container, prefix, key, sasToken string) (*AzureBlobClient, error) {
+	if account == "" {
+		return nil, fmt.Errorf("Azure storage account is required")
+	}
+	if container == "" {
+		return nil, fmt.Errorf("Azure container name is required")
+	}
+	c := &AzureBlobClient{
+		account:   account,
+		container: container,
+		prefix:    strings.Trim(prefix, "/"),
+		sasToken:  strings.TrimPrefix(sasToken, "?"),
+		endpoint:  fmt.Sprintf("https://%s.blob.core.windows.net", account),
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}
+	if key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", AzureKeyEnv, err)
+		}
+		c.key = decoded
+	}
+	if c.key == nil && c.sasToken == "" {
+		return nil, fmt.Errorf("Azure credentials are required: set %s or %s", AzureKeyEnv, AzureSASTokenEnv)
+	}
+	return c, nil
+}
+
+// UploadFile uploads a local file as a block blob
+func (c *AzureBlobClient) UploadFile(ctx context.Context, localPath, key string) error {
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	return c.UploadContent(ctx, content, key)
+}
+
+// UploadContent uploads content as a block blob
+func (c *AzureBlobClient) UploadContent(ctx context.Context, content []byte, key string) error {
+	blob := c.buildKey(key)
+	header := http.Header{}
+	header.Set("x-ms-blob-type", "BlockBlob")
+	if _, err := c.do(ctx, http.MethodPut, blob, nil, header, content); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", c.URI(key), err)
+	}
+	return nil
+}
+
+// UploadDirectory uploads every file under localDir below prefix
+func (c *AzureBlobClient) UploadDirectory(ctx context.Context, localDir, prefix string) ([]string, error) {
+	var uploaded []string
+	err := filepath.Walk(localDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, localPath)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		key := path.Join(prefix, filepath.ToSlash(rel))
+		if err := c.UploadFile(ctx, localPath, key); err != nil {
+			return err
+		}
+		uploaded = append(uploaded, key)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload directory: %w", err)
+	}
+	return uploaded, nil
+}
+
+// DownloadContent downloads a blob
+func (c *AzureBlobClient) DownloadContent(ctx context.Context, key string) ([]byte, error) {
+	content, err := c.do(ctx, http.MethodGet, c.buildKey(key), nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", c.URI(key), err)
+	}
+	return content, nil
+}
+
+// DownloadDirectory downloads every blob below prefix into localDir
+func (c *AzureBlobClient) DownloadDirectory(ctx context.Context, prefix, localDir string) ([]string, error) {
+	full := c.buildKey(prefix)
+	if full != "" && !strings.HasSuffix(full, "/") {
+		full += "/" // Only blobs inside the directory, not siblings sharing its name as a prefix
+	}
+	blobs, err := c.listBlobs(ctx, full)
+	if err != nil {
+		return nil, err
+	}
+
+	var downloaded []string
+	for _, blob := range blobs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(blob, full), "/")
+		if rel == "" {
+			continue
+		}
+		// A blob name is untrusted: it must not write outside localDir
+		if !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return nil, fmt.Errorf("refusing to download %s: its path escapes %s", blob, localDir)
+		}
+		content, err := c.do(ctx, http.MethodGet, blob, nil, nil, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to download %s: %v\n", blob, err)
+			continue
+		}
+		localPath := filepath.Join(localDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(localPath, content, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", localPath, err)
+		}
+		downloaded = append(downloaded, localPath)
+	}
+	if len(downloaded) == 0 {
+		return nil, fmt.Errorf("no files found in %s", c.URI(prefix))
+	}
+	return downloaded, nil
+}
+
+// ListFiles lists the names of the blobs below prefix
+func (c *AzureBlobClient) ListFiles(ctx context.Context, prefix string) ([]string, error) {
+	return c.listBlobs(ctx, c.buildKey(prefix))
+}
+
+// URI returns the URL of the blob key
+func (c *AzureBlobClient) URI(key string) string {
+	return c.endpoint + "/" + c.container + "/" + c.buildKey(key)
+}
+
+// listBlobsResult is the part of a List Blobs response the client reads
+type listBlobsResult struct {
+	Blobs []struct {
+		Name string `xml:"Name"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// listBlobs lists every blob name under a full prefix, following continuation markers
+func (c *AzureBlobClient) listBlobs(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	marker := ""
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		body, err := c.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs in %s: %w", c.URI(""), err)
+		}
+		var result listBlobsResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse blob listing: %w", err)
+		}
+		for _, blob := range result.Blobs {
+			names = append(names, blob.Name)
+		}
+		if result.NextMarker == "" {
+			return names, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// do sends a request for a blob of the container (the container itself when blob is
+// empty) and returns the response body, failing on any non-2xx status
+func (c *AzureBlobClient) do(ctx context.Context, method, blob string, query url.Values, header http.Header, body []byte) ([]byte, error) {
+	resource := "/" + c.container
+	if blob != "" {
+		resource += "/" + blob
+	}
+	resource = (&url.URL{Path: resource}).EscapedPath()
+	target, err := url.Parse(c.endpoint + resource)
+	if err != nil {
+		return nil, err
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	target.RawQuery = query.Encode()
+	if c.key == nil {
+		if target.RawQuery != "" {
+			target.RawQuery += "&"
+		}
+		target.RawQuery += c.sasToken
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	if len(body) > 0 {
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	if c.key != nil {
+		req.Header.Set("Authorization", "SharedKey "+c.account+":"+c.signature(method, resource, query, req.Header, len(body)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(azureErrorCode(data)))
+	}
+	return data, nil
+}
+
+// azureErrorCode returns the error code of a Blob service error response, or the body
+func azureErrorCode(body []byte) string {
+	var failure struct {
+		Code string `xml:"Code"`
+	}
+	if xml.Unmarshal(body, &failure) == nil && failure.Code != "" {
+		return failure.Code
+	}
+	return string(body)
+}
+
+// signature computes the Shared Key signature of a request
+// See "Authorize with Shared Key" in the Azure Storage REST API reference.
+func (c *AzureBlobClient) signature(method, resource string, query url.Values, header http.Header, contentLength int) string {
+	length := ""
+	if contentLength > 0 {
+		length = strconv.Itoa(contentLength)
+	}
+
+	var msHeaders []string
+	for name := range header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower+":"+strings.TrimSpace(header.Get(name)))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	canonicalResource := "/" + c.account + resource
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		params = append(params, strings.ToLower(name)+":"+strings.Join(sorted, ","))
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		canonicalResource += "\n" + param
+	}
+
+	stringToSign := strings.Join([]string{
+		method,
+		header.Get("Content-Encoding"),
+		header.Get("Content-Language"),
+		length,
+		header.Get("Content-MD5"),
+		header.Get("Content-Type"),
+		"", // Date, replaced by x-ms-date
+		header.Get("If-Modified-Since"),
+		header.Get("If-Match"),
+		header.Get("If-None-Match"),
+		header.Get("If-Unmodified-Since"),
+		header.Get("Range"),
+	}, "\n") + "\n" + strings.Join(msHeaders, "\n") + "\n" + canonicalResource
+
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// buildKey places key below the client's prefix
+func (c *AzureBlobClient) buildKey(key string) string {
+	key = strings.TrimPrefix(key, "/")
+	if c.prefix == "" {
+		return key
+	}
+	return path.Join(c.prefix, key)
+}
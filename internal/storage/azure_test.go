@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeBlobService is an in-memory Azure Blob container listing one blob per page
+type fakeBlobService struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+	auth  []string // Authorization header, or the SAS signature, of every request
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("x-ms-version") == "" || r.Header.Get("x-ms-date") == "" {
+		http.Error(w, "missing x-ms headers", http.StatusBadRequest)
+		return
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		f.auth = append(f.auth, auth)
+	} else {
+		f.auth = append(f.auth, "sig="+r.URL.Query().Get("sig"))
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/reports/")
+	switch {
+	case r.Method == http.MethodPut:
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			http.Error(w, "missing blob type", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.blobs[name] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.URL.Query().Get("comp") == "list":
+		var names []string
+		for blob := range f.blobs {
+			if strings.HasPrefix(blob, r.URL.Query().Get("prefix")) && blob > r.URL.Query().Get("marker") {
+				names = append(names, blob)
+			}
+		}
+		sort.Strings(names)
+		fmt.Fprint(w, "<EnumerationResults><Blobs>")
+		if len(names) > 0 {
+			fmt.Fprintf(w, "<Blob><Name>%s</Name></Blob>", names[0])
+		}
+		fmt.Fprint(w, "</Blobs><NextMarker>")
+		if len(names) > 1 {
+			fmt.Fprint(w, names[0])
+		}
+		fmt.Fprint(w, "</NextMarker></EnumerationResults>")
+	default:
+		content, ok := f.blobs[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>BlobNotFound</Code></Error>")
+			return
+		}
+		w.Write(content)
+	}
+}
+
+func newFakeAzureClient(t *testing.T, prefix, key, sasToken string) (*AzureBlobClient, *fakeBlobService) {
+	t.Helper()
+	service := &fakeBlobService{blobs: make(map[string][]byte)}
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+
+	client, err := newAzureBlobClient("acct", "reports", prefix, key, sasToken)
+	if err != nil {
+		t.Fatalf("newAzureBlobClient() error = %v", err)
+	}
+	client.endpoint = server.URL
+	return client, service
+}
+
+func TestAzureBlobClient_UploadAndDownload(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("account-key"))
+	client, service := newFakeAzureClient(t, "team", key, "")
+
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"api.txt": "api", "nested/web.txt": "web"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	uploaded, err := client.UploadDirectory(context.Background(), src, "job_metrics_1")
+	if err != nil {
+		t.Fatalf("UploadDirectory() error = %v", err)
+	}
+	sort.Strings(uploaded)
+	if want := []string{"job_metrics_1/api.txt", "job_metrics_1/nested/web.txt"}; !reflect.DeepEqual(uploaded, want) {
+		t.Errorf("UploadDirectory() = %v, want %v", uploaded, want)
+	}
+	// A sibling sharing the directory's name as a prefix is not downloaded with it
+	if err := client.UploadContent(context.Background(), []byte("other"), "job_metrics_10/other.txt"); err != nil {
+		t.Fatalf("UploadContent() error = %v", err)
+	}
+	if _, ok := service.blobs["team/job_metrics_1/nested/web.txt"]; !ok {
+		t.Errorf("blobs = %v, want keys below the client prefix", service.blobs)
+	}
+
+	dst := t.TempDir()
+	downloaded, err := client.DownloadDirectory(context.Background(), "job_metrics_1", dst)
+	if err != nil {
+		t.Fatalf("DownloadDirectory() error = %v", err)
+	}
+	if len(downloaded) != 2 {
+		t.Errorf("DownloadDirectory() = %v, want 2 files", downloaded)
+	}
+	if content, err := os.ReadFile(filepath.Join(dst, "nested", "web.txt")); err != nil || string(content) != "web" {
+		t.Errorf("downloaded nested/web.txt = %q, %v", content, err)
+	}
+
+	for _, auth := range service.auth {
+		if !strings.HasPrefix(auth, "SharedKey acct:") {
+			t.Errorf("Authorization = %q, want a SharedKey signature", auth)
+		}
+	}
+	if got := client.URI("job_metrics_1/api.txt"); got != client.endpoint+"/reports/team/job_metrics_1/api.txt" {
+		t.Errorf("URI() = %q", got)
+	}
+
+	if _, err := client.DownloadContent(context.Background(), "missing.txt"); err == nil || !strings.Contains(err.Error(), "BlobNotFound") {
+		t.Errorf("DownloadContent() error = %v, want BlobNotFound", err)
+	}
+}
+
+func TestAzureBlobClient_SASToken(t *testing.T) {
+	client, service := newFakeAzureClient(t, "", "", "?sv=2021-08-06&sig=abc")
+	if err := client.UploadContent(context.Background(), []byte("{}"), "manifest.json"); err != nil {
+		t.Fatalf("UploadContent() error = %v", err)
+	}
+	files, err := client.ListFiles(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListFiles() error = %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"manifest.json"}) {
+		t.Errorf("ListFiles() = %v", files)
+	}
+	for _, auth := range service.auth {
+		if auth != "sig=abc" {
+			t.Errorf("request authorized with %q, want the SAS token", auth)
+		}
+	}
+}
+
+func TestNewAzureBlobClient_Validation(t *testing.T) {
+	tests := []struct {
+		name                         string
+		account, container, key, sas string
+	}{
+		{"no account", "", "reports", "a2V5", ""},
+		{"no container", "acct", "", "a2V5", ""},
+		{"no credentials", "acct", "reports", "", ""},
+		{"invalid key", "acct", "reports", "not base64!", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newAzureBlobClient(tt.account, tt.container, "", tt.key, tt.sas); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestAzureBlobClient_DownloadDirectoryRejectsTraversal(t *testing.T) {
+	client, service := newFakeAzureClient(t, "", "", "sv=2022-11-02&sig=abc")
+	service.blobs["job_metrics_1/api.txt"] = []byte("api")
+	service.blobs["job_metrics_1/../../escaped.txt"] = []byte("escaped")
+
+	root := t.TempDir()
+	dst := filepath.Join(root, "download")
+	if _, err := client.DownloadDirectory(context.Background(), "job_metrics_1", dst); err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Errorf("DownloadDirectory() error = %v, want the traversal rejected", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped.txt")); !os.IsNotExist(err) {
+		t.Errorf("blob written outside the download directory: %v", err)
+	}
+}
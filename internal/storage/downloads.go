@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DownloadDirPattern names the temporary directories DownloadEvaluationSource downloads into
+const DownloadDirPattern = "instrumentation-score-s3-*"
+
+// StaleDownloadAge is how old a download directory must be before CleanupStaleDownloads removes it
+// Younger directories may belong to an evaluation that is still running.
+const StaleDownloadAge = 24 * time.Hour
+
+// keepMarker marks a download directory kept on purpose, which CleanupStaleDownloads leaves alone
+const keepMarker = ".keep"
+
+// isDownloadDir reports whether dir is a temporary download directory
+func isDownloadDir(dir string) bool {
+	matched, _ := filepath.Match(DownloadDirPattern, filepath.Base(dir))
+	return matched
+}
+
+// RemoveDownload removes a directory created by DownloadEvaluationSource
+// Any other path is refused, so a mistaken argument cannot delete user data.
+func RemoveDownload(dir string) error {
+	if !isDownloadDir(dir) {
+		return fmt.Errorf("refusing to remove %s: not an S3 download directory", dir)
+	}
+	return os.RemoveAll(dir)
+}
+
+// KeepDownload marks a download directory as kept, so later runs do not clean it up as stale
+func KeepDownload(dir string) error {
+	if !isDownloadDir(dir) {
+		return fmt.Errorf("%s is not an S3 download directory", dir)
+	}
+	return os.WriteFile(filepath.Join(dir, keepMarker), nil, 0600)
+}
+
+// CleanupStaleDownloads removes the download directories in tempDir older than maxAge
+// They are left behind by runs that crashed or were killed before removing their download.
+// Directories marked by KeepDownload are skipped. It returns the directories removed.
+func CleanupStaleDownloads(tempDir string, maxAge time.Duration, now time.Time) ([]string, error) {
+	dirs, err := filepath.Glob(filepath.Join(tempDir, DownloadDirPattern))
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	var errs []error
+	for _, dir := range dirs {
+		info, err := os.Lstat(dir)
+		if err != nil || !info.IsDir() || now.Sub(info.ModTime()) < maxAge {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, keepMarker)); err == nil {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed = append(removed, dir)
+	}
+	return removed, errors.Join(errs...)
+}
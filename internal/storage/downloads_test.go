@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanupStaleDownloads(t *testing.T) {
+	tempDir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-2 * StaleDownloadAge)
+
+	mkdir := func(name string, modTime time.Time) string {
+		dir := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "job.txt"), []byte("metric|job|1|1|"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	stale := mkdir("instrumentation-score-s3-111", old)
+	recent := mkdir("instrumentation-score-s3-222", now)
+	kept := mkdir("instrumentation-score-s3-333", now)
+	if err := KeepDownload(kept); err != nil {
+		t.Fatalf("KeepDownload() error = %v", err)
+	}
+	if err := os.Chtimes(kept, old, old); err != nil {
+		t.Fatal(err)
+	}
+	unrelated := mkdir("other-tool-111", old)
+
+	removed, err := CleanupStaleDownloads(tempDir, StaleDownloadAge, now)
+	if err != nil {
+		t.Fatalf("CleanupStaleDownloads() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0] != stale {
+		t.Errorf("removed = %v, want [%s]", removed, stale)
+	}
+
+	tests := []struct {
+		dir    string
+		exists bool
+	}{
+		{stale, false},
+		{recent, true},
+		{kept, true},
+		{unrelated, true},
+	}
+	for _, tt := range tests {
+		_, err := os.Stat(tt.dir)
+		if exists := err == nil; exists != tt.exists {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(tt.dir), exists, tt.exists)
+		}
+	}
+}
+
+func TestRemoveDownload(t *testing.T) {
+	tempDir := t.TempDir()
+
+	other := filepath.Join(tempDir, "reports")
+	if err := os.Mkdir(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveDownload(other); err == nil {
+		t.Error("RemoveDownload() of a non-download directory should fail")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("non-download directory was removed: %v", err)
+	}
+
+	download := filepath.Join(tempDir, "instrumentation-score-s3-123")
+	if err := os.Mkdir(download, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveDownload(download); err != nil {
+		t.Fatalf("RemoveDownload() error = %v", err)
+	}
+	if _, err := os.Stat(download); !os.IsNotExist(err) {
+		t.Errorf("download directory still exists: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// LatestEvaluation reads the manifest of the most recent evaluation uploaded under prefix
+// roleARN, when set, is assumed to read a bucket in another account.
+func LatestEvaluation(bucket, prefix, region, roleARN string) (*EvaluationManifest, error) {
+	client, err := NewS3ClientWithRole(bucket, "", region, roleARN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	manifest, err := latestEvaluation(client, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("s3://%s/%s: %w", bucket, strings.Trim(prefix, "/"), err)
+	}
+	return manifest, nil
+}
+
+// latestEvaluation finds <prefix>/evaluations/<run-id>/manifest.json uploaded last and parses it
+func latestEvaluation(store objectStore, prefix string) (*EvaluationManifest, error) {
+	evaluations := path.Join(strings.Trim(prefix, "/"), "evaluations") + "/"
+	objects, err := store.listObjects(evaluations)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *s3.Object
+	for _, object := range objects {
+		key := aws.StringValue(object.Key)
+		rest := strings.TrimPrefix(key, evaluations)
+		if strings.Count(rest, "/") != 1 || path.Base(rest) != "manifest.json" {
+			continue
+		}
+		if latest == nil || aws.TimeValue(object.LastModified).After(aws.TimeValue(latest.LastModified)) {
+			latest = object
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no evaluation manifests found under %s", evaluations)
+	}
+
+	data, err := store.getObject(aws.StringValue(latest.Key))
+	if err != nil {
+		return nil, err
+	}
+	var manifest EvaluationManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", aws.StringValue(latest.Key), err)
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestEvaluation(t *testing.T) {
+	base := time.Date(2025, 11, 2, 16, 0, 0, 0, time.UTC)
+	store := newFakeStore(map[string]string{
+		"unit-a/evaluations/run1/manifest.json":     `{"run_id":"run1","total_jobs":3,"average_score":70}`,
+		"unit-a/evaluations/run2/manifest.json":     `{"run_id":"run2","total_jobs":4,"average_score":80}`,
+		"unit-a/evaluations/run2/report.json":       `{}`,
+		"unit-a/evaluations/run3/old/manifest.json": `{"run_id":"nested"}`,
+		"unit-b/evaluations/run9/manifest.json":     `{"run_id":"run9"}`,
+	})
+	store.modified = map[string]time.Time{
+		"unit-a/evaluations/run1/manifest.json":     base,
+		"unit-a/evaluations/run2/manifest.json":     base.Add(time.Hour),
+		"unit-a/evaluations/run2/report.json":       base.Add(2 * time.Hour),
+		"unit-a/evaluations/run3/old/manifest.json": base.Add(3 * time.Hour),
+		"unit-b/evaluations/run9/manifest.json":     base.Add(4 * time.Hour),
+	}
+
+	manifest, err := latestEvaluation(store, "/unit-a/")
+	if err != nil {
+		t.Fatalf("latestEvaluation() error = %v", err)
+	}
+	if manifest.RunID != "run2" || manifest.TotalJobs != 4 || manifest.AverageScore != 80 {
+		t.Errorf("latestEvaluation() = %+v, want run2 with 4 jobs at 80", manifest)
+	}
+
+	if _, err := latestEvaluation(store, "unit-c"); err == nil {
+		t.Error("latestEvaluation() of a prefix without evaluations should fail")
+	}
+}
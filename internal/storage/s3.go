@@ -2,6 +2,7 @@ package storage
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -41,6 +43,32 @@ func NewS3Client(bucket, prefix, region string) (*S3Client, error) {
 	}, nil
 }
 
+// NewS3ClientWithRole creates a client that assumes roleARN, to read buckets in other accounts
+// An empty roleARN uses the default credentials, like NewS3Client.
+func NewS3ClientWithRole(bucket, prefix, region, roleARN string) (*S3Client, error) {
+	if roleARN == "" {
+		return NewS3Client(bucket, prefix, region)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("S3 bucket name is required")
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	svc := s3.New(sess, &aws.Config{Credentials: stscreds.NewCredentials(sess, roleARN)})
+	return &S3Client{
+		bucket:   bucket,
+		prefix:   prefix,
+		uploader: s3manager.NewUploaderWithClient(svc),
+		s3Svc:    svc,
+	}, nil
+}
+
 func NewS3ClientFromEnv() (*S3Client, error) {
 	bucket := os.Getenv("S3_BUCKET")
 	prefix := os.Getenv("S3_PREFIX")
@@ -53,7 +81,7 @@ func NewS3ClientFromEnv() (*S3Client, error) {
 	return NewS3Client(bucket, prefix, region)
 }
 
-func (c *S3Client) UploadFile(localPath, s3Key string) error {
+func (c *S3Client) UploadFile(ctx context.Context, localPath, s3Key string) error {
 	file, err := os.Open(localPath)
 	if err != nil {
 		return fmt.Errorf("failed to open file %s: %w", localPath, err)
@@ -61,7 +89,7 @@ func (c *S3Client) UploadFile(localPath, s3Key string) error {
 	defer file.Close()
 
 	key := c.buildKey(s3Key)
-	_, err = c.uploader.Upload(&s3manager.UploadInput{
+	_, err = c.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
 		Body:   file,
@@ -73,7 +101,7 @@ func (c *S3Client) UploadFile(localPath, s3Key string) error {
 	return nil
 }
 
-func (c *S3Client) UploadDirectory(localDir, s3Prefix string) ([]string, error) {
+func (c *S3Client) UploadDirectory(ctx context.Context, localDir, s3Prefix string) ([]string, error) {
 	var uploadedFiles []string
 
 	err := filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
@@ -93,7 +121,7 @@ func (c *S3Client) UploadDirectory(localDir, s3Prefix string) ([]string, error)
 		s3Key := filepath.Join(s3Prefix, relPath)
 		s3Key = strings.ReplaceAll(s3Key, "\\", "/")
 
-		if err := c.UploadFile(path, s3Key); err != nil {
+		if err := c.UploadFile(ctx, path, s3Key); err != nil {
 			return err
 		}
 
@@ -108,7 +136,7 @@ func (c *S3Client) UploadDirectory(localDir, s3Prefix string) ([]string, error)
 	return uploadedFiles, nil
 }
 
-func (c *S3Client) DownloadFile(s3Key, localPath string) error {
+func (c *S3Client) DownloadFile(ctx context.Context, s3Key, localPath string) error {
 	key := c.buildKey(s3Key)
 
 	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
@@ -122,7 +150,7 @@ func (c *S3Client) DownloadFile(s3Key, localPath string) error {
 	defer file.Close()
 
 	downloader := s3manager.NewDownloaderWithClient(c.s3Svc)
-	_, err = downloader.Download(file, &s3.GetObjectInput{
+	_, err = downloader.DownloadWithContext(ctx, file, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
 	})
@@ -133,15 +161,18 @@ func (c *S3Client) DownloadFile(s3Key, localPath string) error {
 	return nil
 }
 
-func (c *S3Client) DownloadDirectory(s3Prefix, localDir string) ([]string, error) {
+func (c *S3Client) DownloadDirectory(ctx context.Context, s3Prefix, localDir string) ([]string, error) {
 	var downloadedFiles []string
 
 	prefix := c.buildKey(s3Prefix)
-	err := c.s3Svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+	err := c.s3Svc.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
 		Bucket: aws.String(c.bucket),
 		Prefix: aws.String(prefix),
 	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
 		for _, obj := range page.Contents {
+			if ctx.Err() != nil {
+				return false
+			}
 			s3Key := aws.StringValue(obj.Key)
 
 			relPath := strings.TrimPrefix(s3Key, prefix)
@@ -152,7 +183,7 @@ func (c *S3Client) DownloadDirectory(s3Prefix, localDir string) ([]string, error
 
 			localPath := filepath.Join(localDir, relPath)
 
-			if err := c.DownloadFile(strings.TrimPrefix(s3Key, c.prefix+"/"), localPath); err != nil {
+			if err := c.DownloadFile(ctx, strings.TrimPrefix(s3Key, c.prefix+"/"), localPath); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to download %s: %v\n", s3Key, err)
 				continue
 			}
@@ -162,6 +193,9 @@ func (c *S3Client) DownloadDirectory(s3Prefix, localDir string) ([]string, error
 		return true
 	})
 
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects in s3://%s/%s: %w", c.bucket, prefix, err)
 	}
@@ -173,11 +207,11 @@ func (c *S3Client) DownloadDirectory(s3Prefix, localDir string) ([]string, error
 	return downloadedFiles, nil
 }
 
-func (c *S3Client) ListFiles(s3Prefix string) ([]string, error) {
+func (c *S3Client) ListFiles(ctx context.Context, s3Prefix string) ([]string, error) {
 	var files []string
 
 	prefix := c.buildKey(s3Prefix)
-	err := c.s3Svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+	err := c.s3Svc.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
 		Bucket: aws.String(c.bucket),
 		Prefix: aws.String(prefix),
 	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
@@ -194,9 +228,18 @@ func (c *S3Client) ListFiles(s3Prefix string) ([]string, error) {
 	return files, nil
 }
 
-func (c *S3Client) FileExists(s3Key string) (bool, error) {
+// CheckAccess verifies the bucket exists and the credentials may access it
+func (c *S3Client) CheckAccess(ctx context.Context) error {
+	_, err := c.s3Svc.HeadBucketWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(c.bucket)})
+	if err != nil {
+		return fmt.Errorf("cannot access bucket %s: %w", c.bucket, err)
+	}
+	return nil
+}
+
+func (c *S3Client) FileExists(ctx context.Context, s3Key string) (bool, error) {
 	key := c.buildKey(s3Key)
-	_, err := c.s3Svc.HeadObject(&s3.HeadObjectInput{
+	_, err := c.s3Svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
 	})
@@ -209,9 +252,9 @@ func (c *S3Client) FileExists(s3Key string) (bool, error) {
 	return true, nil
 }
 
-func (c *S3Client) UploadContent(content []byte, s3Key string) error {
+func (c *S3Client) UploadContent(ctx context.Context, content []byte, s3Key string) error {
 	key := c.buildKey(s3Key)
-	_, err := c.uploader.Upload(&s3manager.UploadInput{
+	_, err := c.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
 		Body:   bytes.NewReader(content),
@@ -222,12 +265,12 @@ func (c *S3Client) UploadContent(content []byte, s3Key string) error {
 	return nil
 }
 
-func (c *S3Client) DownloadContent(s3Key string) ([]byte, error) {
+func (c *S3Client) DownloadContent(ctx context.Context, s3Key string) ([]byte, error) {
 	key := c.buildKey(s3Key)
 
 	buff := &aws.WriteAtBuffer{}
 	downloader := s3manager.NewDownloaderWithClient(c.s3Svc)
-	_, err := downloader.Download(buff, &s3.GetObjectInput{
+	_, err := downloader.DownloadWithContext(ctx, buff, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
 	})
@@ -259,6 +302,11 @@ func (c *S3Client) GetS3URI(key string) string {
 	return fmt.Sprintf("s3://%s/%s", c.bucket, fullKey)
 }
 
+// URI returns the s3:// URI of key, like GetS3URI
+func (c *S3Client) URI(key string) string {
+	return c.GetS3URI(key)
+}
+
 func CopyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
@@ -0,0 +1,236 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// objectStore is the part of S3 an S3FS reads from
+type objectStore interface {
+	listObjects(prefix string) ([]*s3.Object, error)
+	getObject(key string) ([]byte, error)
+}
+
+// listObjects lists every object under a full key prefix
+func (c *S3Client) listObjects(prefix string) ([]*s3.Object, error) {
+	var objects []*s3.Object
+	err := c.s3Svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		objects = append(objects, page.Contents...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects in s3://%s/%s: %w", c.bucket, prefix, err)
+	}
+	return objects, nil
+}
+
+// getObject reads a whole object by its full key
+func (c *S3Client) getObject(key string) ([]byte, error) {
+	output, err := c.s3Svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3://%s/%s: %w", c.bucket, key, err)
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// S3FS reads the objects under an S3 prefix as a read-only file system, without a local copy
+// Objects are listed once when it is created. Prefetch downloads objects ahead of use with
+// bounded concurrency, so evaluation can start on the first object while the rest arrive.
+type S3FS struct {
+	store   objectStore
+	objects map[string]*s3.Object // Name relative to the prefix -> listing entry
+
+	mu       sync.Mutex
+	fetches  map[string]*objectFetch // Downloaded or in-flight objects, kept until released
+	released map[string]bool
+}
+
+// objectFetch is one object download
+type objectFetch struct {
+	done  chan struct{}
+	data  []byte
+	err   error
+	slots chan struct{} // The prefetch slots it holds one of, nil when not prefetched
+}
+
+// NewS3FS lists the objects under a prefix for streaming evaluation
+func NewS3FS(config EvaluationDownloadConfig) (*S3FS, error) {
+	client, err := NewS3Client(config.Bucket, "", config.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	fsys, err := newS3FS(client, config.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(fsys.objects) == 0 {
+		return nil, fmt.Errorf("no files found in s3://%s/%s", config.Bucket, config.Prefix)
+	}
+	return fsys, nil
+}
+
+// newS3FS lists the objects under prefix in store
+func newS3FS(store objectStore, prefix string) (*S3FS, error) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	objects, err := store.listObjects(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys := &S3FS{
+		store:    store,
+		objects:  make(map[string]*s3.Object),
+		fetches:  make(map[string]*objectFetch),
+		released: make(map[string]bool),
+	}
+	for _, object := range objects {
+		name := strings.TrimPrefix(aws.StringValue(object.Key), prefix)
+		if fs.ValidPath(name) && name != "." {
+			fsys.objects[name] = object
+		}
+	}
+	return fsys, nil
+}
+
+// Glob returns the names of the objects matching pattern, implementing fs.GlobFS
+func (f *S3FS) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range f.objects {
+		if ok, _ := path.Match(pattern, name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Stat describes an object from the listing, implementing fs.StatFS
+func (f *S3FS) Stat(name string) (fs.FileInfo, error) {
+	object, ok := f.objects[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return objectInfo{name: name, object: object}, nil
+}
+
+// Open reads an object, waiting for its prefetch if one is in flight
+func (f *S3FS) Open(name string) (fs.File, error) {
+	object, ok := f.objects[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	f.mu.Lock()
+	fetch, ok := f.fetches[name]
+	if !ok {
+		fetch = f.fetch(object)
+		if !f.released[name] {
+			// Kept until released, so a later prefetch does not download it again
+			f.fetches[name] = fetch
+		}
+	}
+	f.mu.Unlock()
+
+	<-fetch.done
+	if fetch.err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fetch.err}
+	}
+	return &objectFile{info: objectInfo{name: name, object: object}, Reader: bytes.NewReader(fetch.data)}, nil
+}
+
+// fetch starts downloading an object
+func (f *S3FS) fetch(object *s3.Object) *objectFetch {
+	fetch := &objectFetch{done: make(chan struct{})}
+	go func() {
+		defer close(fetch.done)
+		fetch.data, fetch.err = f.store.getObject(aws.StringValue(object.Key))
+	}()
+	return fetch
+}
+
+// Prefetch downloads objects in the background, in order, keeping at most concurrency of them
+// downloaded or in flight until they are released. Every prefetched name must be released.
+func (f *S3FS) Prefetch(names []string, concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	go func() {
+		for _, name := range names {
+			object, ok := f.objects[name]
+			if !ok {
+				continue
+			}
+			slots <- struct{}{}
+			f.mu.Lock()
+			if _, ok := f.fetches[name]; ok || f.released[name] {
+				// Already opened, or done with, before its turn came
+				<-slots
+			} else {
+				fetch := f.fetch(object)
+				fetch.slots = slots
+				f.fetches[name] = fetch
+			}
+			f.mu.Unlock()
+		}
+	}()
+}
+
+// Release drops an object from memory, making room for the next prefetch
+// Opening the object again downloads it again.
+func (f *S3FS) Release(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.released[name] = true
+	if fetch, ok := f.fetches[name]; ok {
+		delete(f.fetches, name)
+		if fetch.slots != nil {
+			<-fetch.slots
+		}
+	}
+}
+
+// objectFile is an open object
+type objectFile struct {
+	info objectInfo
+	*bytes.Reader
+}
+
+func (o *objectFile) Stat() (fs.FileInfo, error) { return o.info, nil }
+func (o *objectFile) Close() error               { return nil }
+
+// objectInfo describes an object from its listing entry
+type objectInfo struct {
+	name   string
+	object *s3.Object
+}
+
+func (i objectInfo) Name() string       { return path.Base(i.name) }
+func (i objectInfo) Size() int64        { return aws.Int64Value(i.object.Size) }
+func (i objectInfo) Mode() fs.FileMode  { return 0444 }
+func (i objectInfo) ModTime() time.Time { return aws.TimeValue(i.object.LastModified) }
+func (i objectInfo) IsDir() bool        { return false }
+func (i objectInfo) Sys() interface{}   { return i.object }
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// fakeStore is an in-memory objectStore counting reads
+type fakeStore struct {
+	mu       sync.Mutex
+	objects  map[string]string
+	modified map[string]time.Time // Listed modification times, a fixed date when unset
+	reads    map[string]int
+}
+
+func newFakeStore(objects map[string]string) *fakeStore {
+	return &fakeStore{objects: objects, reads: make(map[string]int)}
+}
+
+func (s *fakeStore) listObjects(prefix string) ([]*s3.Object, error) {
+	var objects []*s3.Object
+	for key, data := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			modified, ok := s.modified[key]
+			if !ok {
+				modified = time.Date(2025, 11, 2, 16, 0, 0, 0, time.UTC)
+			}
+			objects = append(objects, &s3.Object{
+				Key:          aws.String(key),
+				Size:         aws.Int64(int64(len(data))),
+				LastModified: aws.Time(modified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+func (s *fakeStore) getObject(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads[key]++
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("no such key %s", key)
+	}
+	return []byte(data), nil
+}
+
+func (s *fakeStore) readCount(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads[key]
+}
+
+func TestS3FS_GlobAndStat(t *testing.T) {
+	store := newFakeStore(map[string]string{
+		"runs/20251102/b.txt":             "b",
+		"runs/20251102/a.txt":             "aa",
+		"runs/20251102/build_info.report": "x",
+		"runs/other/c.txt":                "c",
+	})
+	fsys, err := newS3FS(store, "/runs/20251102/")
+	if err != nil {
+		t.Fatalf("newS3FS() error = %v", err)
+	}
+
+	files, err := fs.Glob(fsys, "*.txt")
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	if strings.Join(files, ",") != "a.txt,b.txt" {
+		t.Errorf("Glob() = %v, want [a.txt b.txt]", files)
+	}
+
+	info, err := fs.Stat(fsys, "a.txt")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Name() != "a.txt" || info.Size() != 2 || info.ModTime().IsZero() {
+		t.Errorf("Stat() = %s %d %v, want a.txt 2 and a modification time", info.Name(), info.Size(), info.ModTime())
+	}
+	if _, err := fs.Stat(fsys, "c.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(c.txt) error = %v, want fs.ErrNotExist", err)
+	}
+	if store.readCount("runs/20251102/a.txt") != 0 {
+		t.Error("Glob and Stat should not read objects")
+	}
+}
+
+func TestS3FS_Open(t *testing.T) {
+	store := newFakeStore(map[string]string{"job.txt": "metric|job|1|10|label"})
+	fsys, err := newS3FS(store, "")
+	if err != nil {
+		t.Fatalf("newS3FS() error = %v", err)
+	}
+
+	file, err := fsys.Open("job.txt")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(data) != "metric|job|1|10|label" {
+		t.Errorf("read %q, %v", data, err)
+	}
+
+	if _, err := fsys.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(missing.txt) error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestS3FS_PrefetchAndRelease(t *testing.T) {
+	objects := make(map[string]string)
+	var names []string
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("job%d.txt", i)
+		objects[name] = name
+		names = append(names, name)
+	}
+	store := newFakeStore(objects)
+	fsys, err := newS3FS(store, "")
+	if err != nil {
+		t.Fatalf("newS3FS() error = %v", err)
+	}
+
+	fsys.Prefetch(names, 2)
+	for _, name := range names {
+		file, err := fsys.Open(name)
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", name, err)
+		}
+		data, _ := io.ReadAll(file)
+		if string(data) != name {
+			t.Errorf("Open(%s) read %q", name, data)
+		}
+		// Opening again before release reuses the download
+		if _, err := fsys.Open(name); err != nil {
+			t.Fatalf("Open(%s) again error = %v", name, err)
+		}
+		fsys.Release(name)
+	}
+
+	for _, name := range names {
+		if got := store.readCount(name); got != 1 {
+			t.Errorf("%s read %d times, want 1", name, got)
+		}
+	}
+
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	if len(fsys.fetches) != 0 {
+		t.Errorf("%d objects kept after release, want 0", len(fsys.fetches))
+	}
+}
@@ -1,21 +1,51 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 	"time"
+
+	"instrumentation-score/internal/encryption"
+	"instrumentation-score/internal/orgscore"
+	"instrumentation-score/internal/runconfig"
 )
 
 // AnalysisUploadConfig contains configuration for uploading analysis results
 type AnalysisUploadConfig struct {
-	Bucket       string
-	Prefix       string
-	Region       string
-	JobMetricsDir string
-	ErrorFile    string
-	Timestamp    string
+	Bucket          string
+	Prefix          string
+	Region          string
+	JobMetricsDir   string
+	ErrorFile       string
+	SlowMetricsFile string
+	QueriesFile     string // PromQL query snapshot of the run
+	Timestamp       string
+	Store           ResultStore // Uploaded to instead of S3 when set
+}
+
+// ResultStore is object storage analysis and evaluation results are uploaded to and
+// downloaded from: S3 (S3Client) or Azure Blob Storage (AzureBlobClient)
+type ResultStore interface {
+	UploadFile(ctx context.Context, localPath, key string) error
+	UploadDirectory(ctx context.Context, localDir, prefix string) ([]string, error)
+	UploadContent(ctx context.Context, content []byte, key string) error
+	DownloadDirectory(ctx context.Context, prefix, localDir string) ([]string, error)
+	URI(key string) string
+}
+
+// resultStore returns store, or an S3 client for bucket when store is nil
+func resultStore(store ResultStore, bucket, prefix, region string) (ResultStore, error) {
+	if store != nil {
+		return store, nil
+	}
+	client, err := NewS3Client(bucket, prefix, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	return client, nil
 }
 
 // EvaluationUploadConfig contains configuration for uploading evaluation results
@@ -27,8 +57,14 @@ type EvaluationUploadConfig struct {
 	JSONFile       string
 	HTMLFile       string
 	PrometheusFile string
+	CRDFile        string
+	OpenSLOFile    string
+	PyrraFile      string
+	SlothFile      string
+	BadgeFile      string
 	OutputFormats  []string
 	Manifest       *EvaluationManifest
+	Store          ResultStore // Uploaded to instead of S3 when set
 }
 
 // EvaluationDownloadConfig contains configuration for downloading from S3
@@ -36,86 +72,125 @@ type EvaluationDownloadConfig struct {
 	Bucket string
 	Prefix string
 	Region string
+	Store  ResultStore // Downloaded from instead of S3 when set
 }
 
 // EvaluationManifest contains metadata about an evaluation run
 type EvaluationManifest struct {
-	Timestamp        string  `json:"timestamp"`
-	RunID            string  `json:"run_id"`
-	TotalJobs        int     `json:"total_jobs"`
-	AverageScore     float64 `json:"average_score"`
-	TotalCardinality int64   `json:"total_cardinality"`
-	TotalCost        float64 `json:"total_cost,omitempty"`
-	RulesConfig      string  `json:"rules_config"`
-	OutputFormats    string  `json:"output_formats"`
-	SourceType       string  `json:"source_type"`
-	SourcePath       string  `json:"source_path,omitempty"`
-	Files            struct {
+	Timestamp         string  `json:"timestamp"`
+	RunID             string  `json:"run_id"`
+	TotalJobs         int     `json:"total_jobs"`
+	AverageScore      float64 `json:"average_score"`
+	OrgScore          float64 `json:"organization_score"`
+	OrgScoreWeighting string  `json:"organization_score_weighting,omitempty"` // How job scores were weighted into OrgScore
+	TotalCardinality  int64   `json:"total_cardinality"`
+	TotalCost         float64 `json:"total_cost,omitempty"`
+	RulesConfig       string  `json:"rules_config"`
+	OutputFormats     string  `json:"output_formats"`
+	SourceType        string  `json:"source_type"`
+	SourcePath        string  `json:"source_path,omitempty"`
+	Files             struct {
 		JSON       string `json:"json,omitempty"`
 		HTML       string `json:"html,omitempty"`
 		Prometheus string `json:"prometheus,omitempty"`
+		CRD        string `json:"crd,omitempty"`
+		OpenSLO    string `json:"openslo,omitempty"`
+		Pyrra      string `json:"pyrra,omitempty"`
+		Sloth      string `json:"sloth,omitempty"`
+		Badge      string `json:"badge,omitempty"`
 		Manifest   string `json:"manifest"`
 	} `json:"files"`
+	Config     *runconfig.Snapshot `json:"config,omitempty"`          // Effective configuration of the run
+	Encryption string              `json:"encryption,omitempty"`      // How the JSON and HTML reports are encrypted, when they are
+	Timings    map[string]float64  `json:"timings_seconds,omitempty"` // Seconds per phase of the run; upload is filled in here
+
+	// How job scores spread around AverageScore, and the scores, so rollups can combine units
+	ScoreDistribution *orgscore.Distribution `json:"score_distribution,omitempty"`
+	JobScores         []float64              `json:"job_scores,omitempty"`
 }
 
-// UploadAnalysisResults uploads analysis results to S3
-func UploadAnalysisResults(config AnalysisUploadConfig) error {
-	s3Client, err := NewS3Client(config.Bucket, config.Prefix, config.Region)
+// UploadAnalysisResults uploads analysis results to S3, or config.Store
+func UploadAnalysisResults(ctx context.Context, config AnalysisUploadConfig) error {
+	s3Client, err := resultStore(config.Store, config.Bucket, config.Prefix, config.Region)
 	if err != nil {
-		return fmt.Errorf("failed to create S3 client: %w", err)
+		return err
 	}
 
 	s3Prefix := fmt.Sprintf("job_metrics_%s", config.Timestamp)
-	uploadedFiles, err := s3Client.UploadDirectory(config.JobMetricsDir, s3Prefix)
+	uploadedFiles, err := s3Client.UploadDirectory(ctx, config.JobMetricsDir, s3Prefix)
 	if err != nil {
 		return fmt.Errorf("failed to upload job metrics directory: %w", err)
 	}
 
-	fmt.Printf("Uploaded %d job metric files to %s\n", len(uploadedFiles), s3Client.GetS3URI(s3Prefix))
+	fmt.Printf("Uploaded %d job metric files to %s\n", len(uploadedFiles), s3Client.URI(s3Prefix))
 
 	if _, err := os.Stat(config.ErrorFile); err == nil {
 		errorS3Key := fmt.Sprintf("metrics_errors_%s.txt", config.Timestamp)
-		if err := s3Client.UploadFile(config.ErrorFile, errorS3Key); err != nil {
+		if err := s3Client.UploadFile(ctx, config.ErrorFile, errorS3Key); err != nil {
 			fmt.Printf("WARNING: Failed to upload error file: %v\n", err)
 		} else {
-			fmt.Printf("Uploaded error file to %s\n", s3Client.GetS3URI(errorS3Key))
+			fmt.Printf("Uploaded error file to %s\n", s3Client.URI(errorS3Key))
 		}
 	}
 
-	fmt.Printf("\nS3 Location: s3://%s/%s/job_metrics_%s/\n", config.Bucket, config.Prefix, config.Timestamp)
+	if config.SlowMetricsFile != "" {
+		if _, err := os.Stat(config.SlowMetricsFile); err == nil {
+			slowS3Key := fmt.Sprintf("slow_metrics_%s.txt", config.Timestamp)
+			if err := s3Client.UploadFile(ctx, config.SlowMetricsFile, slowS3Key); err != nil {
+				fmt.Printf("WARNING: Failed to upload slow metrics report: %v\n", err)
+			} else {
+				fmt.Printf("Uploaded slow metrics report to %s\n", s3Client.URI(slowS3Key))
+			}
+		}
+	}
+
+	if config.QueriesFile != "" {
+		if _, err := os.Stat(config.QueriesFile); err == nil {
+			queriesS3Key := fmt.Sprintf("queries_%s.txt", config.Timestamp)
+			if err := s3Client.UploadFile(ctx, config.QueriesFile, queriesS3Key); err != nil {
+				fmt.Printf("WARNING: Failed to upload query snapshot: %v\n", err)
+			} else {
+				fmt.Printf("Uploaded query snapshot to %s\n", s3Client.URI(queriesS3Key))
+			}
+		}
+	}
+
+	fmt.Printf("\nLocation: %s/\n", s3Client.URI(s3Prefix))
 	return nil
 }
 
 // DownloadEvaluationSource downloads job metrics from S3 for evaluation
-func DownloadEvaluationSource(config EvaluationDownloadConfig) (string, error) {
-	s3Client, err := NewS3Client(config.Bucket, config.Prefix, config.Region)
+// The caller removes the returned directory with RemoveDownload when done, or keeps it with KeepDownload.
+func DownloadEvaluationSource(ctx context.Context, config EvaluationDownloadConfig) (string, error) {
+	s3Client, err := resultStore(config.Store, config.Bucket, config.Prefix, config.Region)
 	if err != nil {
-		return "", fmt.Errorf("failed to create S3 client: %w", err)
+		return "", err
 	}
 
-	tmpDir, err := os.MkdirTemp("", "instrumentation-score-s3-*")
+	tmpDir, err := os.MkdirTemp("", DownloadDirPattern)
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
-	fmt.Printf("Downloading job metrics from S3...\n")
-	fmt.Printf("S3 Location: s3://%s/%s\n", config.Bucket, config.Prefix)
+	fmt.Printf("Downloading job metrics...\n")
+	fmt.Printf("Location: %s\n", s3Client.URI(config.Prefix))
 
-	downloadedFiles, err := s3Client.DownloadDirectory(config.Prefix, tmpDir)
+	downloadedFiles, err := s3Client.DownloadDirectory(ctx, config.Prefix, tmpDir)
 	if err != nil {
 		os.RemoveAll(tmpDir)
-		return "", fmt.Errorf("failed to download from S3: %w", err)
+		return "", fmt.Errorf("failed to download job metrics: %w", err)
 	}
 
 	fmt.Printf("Downloaded %d files\n", len(downloadedFiles))
 	return tmpDir, nil
 }
 
-// UploadEvaluationResults uploads evaluation results to S3 with manifest
-func UploadEvaluationResults(config EvaluationUploadConfig) error {
-	s3Client, err := NewS3Client(config.Bucket, config.Prefix, config.Region)
+// UploadEvaluationResults uploads evaluation results with manifest to S3, or config.Store
+func UploadEvaluationResults(ctx context.Context, config EvaluationUploadConfig) error {
+	started := time.Now()
+	s3Client, err := resultStore(config.Store, config.Bucket, config.Prefix, config.Region)
 	if err != nil {
-		return fmt.Errorf("failed to create S3 client: %w", err)
+		return err
 	}
 
 	// Generate run ID if not provided
@@ -138,35 +213,88 @@ func UploadEvaluationResults(config EvaluationUploadConfig) error {
 
 	// Upload JSON if provided
 	if config.JSONFile != "" && contains(config.OutputFormats, "json") {
-		s3Key := fmt.Sprintf("%s/report.json", s3Prefix)
-		if err := s3Client.UploadFile(config.JSONFile, s3Key); err != nil {
+		s3Key := fmt.Sprintf("%s/report.json%s", s3Prefix, encryptedSuffix(config.JSONFile))
+		if err := s3Client.UploadFile(ctx, config.JSONFile, s3Key); err != nil {
 			return fmt.Errorf("failed to upload JSON: %w", err)
 		}
 		config.Manifest.Files.JSON = s3Key
-		fmt.Printf("✅ Uploaded JSON report to %s\n", s3Client.GetS3URI(s3Key))
+		fmt.Printf("✅ Uploaded JSON report to %s\n", s3Client.URI(s3Key))
 	}
 
 	// Upload HTML if provided
 	if config.HTMLFile != "" && contains(config.OutputFormats, "html") {
-		s3Key := fmt.Sprintf("%s/dashboard.html", s3Prefix)
-		if err := s3Client.UploadFile(config.HTMLFile, s3Key); err != nil {
+		s3Key := fmt.Sprintf("%s/dashboard.html%s", s3Prefix, encryptedSuffix(config.HTMLFile))
+		if err := s3Client.UploadFile(ctx, config.HTMLFile, s3Key); err != nil {
 			return fmt.Errorf("failed to upload HTML: %w", err)
 		}
 		config.Manifest.Files.HTML = s3Key
-		fmt.Printf("✅ Uploaded HTML dashboard to %s\n", s3Client.GetS3URI(s3Key))
+		fmt.Printf("✅ Uploaded HTML dashboard to %s\n", s3Client.URI(s3Key))
 	}
 
 	// Upload Prometheus metrics if provided
 	if config.PrometheusFile != "" && contains(config.OutputFormats, "prometheus") {
 		s3Key := fmt.Sprintf("%s/metrics.prom", s3Prefix)
-		if err := s3Client.UploadFile(config.PrometheusFile, s3Key); err != nil {
+		if err := s3Client.UploadFile(ctx, config.PrometheusFile, s3Key); err != nil {
 			return fmt.Errorf("failed to upload Prometheus metrics: %w", err)
 		}
 		config.Manifest.Files.Prometheus = s3Key
-		fmt.Printf("✅ Uploaded Prometheus metrics to %s\n", s3Client.GetS3URI(s3Key))
+		fmt.Printf("✅ Uploaded Prometheus metrics to %s\n", s3Client.URI(s3Key))
+	}
+
+	// Upload InstrumentationScore manifests if provided
+	if config.CRDFile != "" && contains(config.OutputFormats, "crd") {
+		s3Key := fmt.Sprintf("%s/instrumentationscores.yaml", s3Prefix)
+		if err := s3Client.UploadFile(ctx, config.CRDFile, s3Key); err != nil {
+			return fmt.Errorf("failed to upload CRD manifests: %w", err)
+		}
+		config.Manifest.Files.CRD = s3Key
+		fmt.Printf("✅ Uploaded InstrumentationScore manifests to %s\n", s3Client.URI(s3Key))
 	}
 
-	// Upload manifest
+	// Upload OpenSLO documents if provided
+	if config.OpenSLOFile != "" && contains(config.OutputFormats, "openslo") {
+		s3Key := fmt.Sprintf("%s/openslo.yaml", s3Prefix)
+		if err := s3Client.UploadFile(ctx, config.OpenSLOFile, s3Key); err != nil {
+			return fmt.Errorf("failed to upload OpenSLO documents: %w", err)
+		}
+		config.Manifest.Files.OpenSLO = s3Key
+		fmt.Printf("✅ Uploaded OpenSLO documents to %s\n", s3Client.URI(s3Key))
+	}
+
+	// Upload Pyrra SLOs if provided
+	if config.PyrraFile != "" && contains(config.OutputFormats, "pyrra") {
+		s3Key := fmt.Sprintf("%s/pyrra.yaml", s3Prefix)
+		if err := s3Client.UploadFile(ctx, config.PyrraFile, s3Key); err != nil {
+			return fmt.Errorf("failed to upload Pyrra SLOs: %w", err)
+		}
+		config.Manifest.Files.Pyrra = s3Key
+		fmt.Printf("✅ Uploaded Pyrra SLOs to %s\n", s3Client.URI(s3Key))
+	}
+
+	// Upload Sloth SLOs if provided
+	if config.SlothFile != "" && contains(config.OutputFormats, "sloth") {
+		s3Key := fmt.Sprintf("%s/sloth.yaml", s3Prefix)
+		if err := s3Client.UploadFile(ctx, config.SlothFile, s3Key); err != nil {
+			return fmt.Errorf("failed to upload Sloth SLOs: %w", err)
+		}
+		config.Manifest.Files.Sloth = s3Key
+		fmt.Printf("✅ Uploaded Sloth SLOs to %s\n", s3Client.URI(s3Key))
+	}
+
+	// Upload the score badge if provided
+	if config.BadgeFile != "" && contains(config.OutputFormats, "badge") {
+		s3Key := fmt.Sprintf("%s/badge.svg", s3Prefix)
+		if err := s3Client.UploadFile(ctx, config.BadgeFile, s3Key); err != nil {
+			return fmt.Errorf("failed to upload score badge: %w", err)
+		}
+		config.Manifest.Files.Badge = s3Key
+		fmt.Printf("✅ Uploaded score badge to %s\n", s3Client.URI(s3Key))
+	}
+
+	// Upload manifest, with the time the reports took to upload when the run is timed
+	if config.Manifest.Timings != nil {
+		config.Manifest.Timings["upload"] = time.Since(started).Seconds()
+	}
 	manifestS3Key := fmt.Sprintf("%s/manifest.json", s3Prefix)
 	config.Manifest.Files.Manifest = manifestS3Key
 	manifestData, err := json.MarshalIndent(config.Manifest, "", "  ")
@@ -174,16 +302,19 @@ func UploadEvaluationResults(config EvaluationUploadConfig) error {
 		return fmt.Errorf("failed to marshal manifest: %w", err)
 	}
 
-	if err := s3Client.UploadContent(manifestData, manifestS3Key); err != nil {
+	if err := s3Client.UploadContent(ctx, manifestData, manifestS3Key); err != nil {
 		return fmt.Errorf("failed to upload manifest: %w", err)
 	}
-	fmt.Printf("✅ Uploaded manifest to %s\n", s3Client.GetS3URI(manifestS3Key))
+	fmt.Printf("✅ Uploaded manifest to %s\n", s3Client.URI(manifestS3Key))
 
-	fmt.Printf("\n📦 Evaluation Package: s3://%s/%s/\n", config.Bucket, s3Prefix)
+	fmt.Printf("\n📦 Evaluation Package: %s/\n", s3Client.URI(s3Prefix))
 	fmt.Printf("   Run ID: %s\n", runID)
 	fmt.Printf("   Timestamp: %s\n", config.Manifest.Timestamp)
 	fmt.Printf("   Total Jobs: %d\n", config.Manifest.TotalJobs)
 	fmt.Printf("   Average Score: %.2f%%\n", config.Manifest.AverageScore)
+	if config.Manifest.OrgScoreWeighting != "" {
+		fmt.Printf("   Organization Score: %.2f%% (weighted by %s)\n", config.Manifest.OrgScore, config.Manifest.OrgScoreWeighting)
+	}
 	if config.Manifest.TotalCost > 0 {
 		fmt.Printf("   Total Cost: $%.2f/month\n", config.Manifest.TotalCost)
 	}
@@ -191,6 +322,14 @@ func UploadEvaluationResults(config EvaluationUploadConfig) error {
 	return nil
 }
 
+// encryptedSuffix keeps the extension of an encrypted report file in its S3 key
+func encryptedSuffix(localPath string) string {
+	if strings.HasSuffix(localPath, encryption.Extension) {
+		return encryption.Extension
+	}
+	return ""
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if strings.EqualFold(s, item) {
@@ -199,4 +338,3 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
-
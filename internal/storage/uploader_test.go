@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -159,7 +160,7 @@ func TestUploadAnalysisResults_InvalidConfig(t *testing.T) {
 		Timestamp:     "20251102_160000",
 	}
 
-	err := UploadAnalysisResults(config)
+	err := UploadAnalysisResults(context.Background(), config)
 	if err == nil {
 		t.Errorf("expected error for empty bucket")
 	}
@@ -177,7 +178,7 @@ func TestUploadAnalysisResults_NonExistentDirectory(t *testing.T) {
 
 	// This will fail when trying to upload non-existent directory
 	// We expect an error
-	err := UploadAnalysisResults(config)
+	err := UploadAnalysisResults(context.Background(), config)
 	if err == nil {
 		t.Errorf("expected error for non-existent directory")
 	}
@@ -190,7 +191,7 @@ func TestDownloadEvaluationSource_InvalidConfig(t *testing.T) {
 		Region: "eu-west-1",
 	}
 
-	_, err := DownloadEvaluationSource(config)
+	_, err := DownloadEvaluationSource(context.Background(), config)
 	if err == nil {
 		t.Errorf("expected error for empty bucket")
 	}
@@ -205,7 +206,7 @@ func TestUploadEvaluationResults_InvalidConfig(t *testing.T) {
 		OutputFormats: []string{"html"},
 	}
 
-	err := UploadEvaluationResults(config)
+	err := UploadEvaluationResults(context.Background(), config)
 	if err == nil {
 		t.Errorf("expected error for empty bucket")
 	}
@@ -241,7 +242,7 @@ func TestUploadEvaluationResults_AutoGenerateRunID(t *testing.T) {
 
 	// This will fail because we don't have real AWS credentials
 	// But we can verify the config is valid
-	err = UploadEvaluationResults(config)
+	err = UploadEvaluationResults(context.Background(), config)
 	if err == nil {
 		t.Skip("Skipping actual upload - requires AWS credentials")
 	}
@@ -454,7 +455,7 @@ func TestUploadEvaluationResults_MultipleFormats(t *testing.T) {
 	}
 
 	// This will fail without AWS credentials, but validates config
-	err = UploadEvaluationResults(config)
+	err = UploadEvaluationResults(context.Background(), config)
 	if err == nil {
 		t.Skip("Skipping actual upload - requires AWS credentials")
 	}
@@ -0,0 +1,208 @@
+package usage
+
+import (
+	"sort"
+	"strings"
+)
+
+// PromQL is scanned rather than parsed: the scanner only needs to tell metric names
+// apart from functions, keywords, label names, durations and strings. Queries that
+// fail to parse in Prometheus (Grafana variables, partial expressions) still yield
+// the metric names they mention.
+
+// promqlKeywords are identifiers that are never metric names when not followed by "("
+var promqlKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "bool": true, "offset": true, "atan2": true,
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+	"sum": true, "min": true, "max": true, "avg": true, "group": true, "stddev": true, "stdvar": true,
+	"count": true, "count_values": true, "bottomk": true, "topk": true, "quantile": true,
+	"limitk": true, "limit_ratio": true, "inf": true, "nan": true,
+}
+
+// groupingKeywords are followed by a parenthesized list of label names
+var groupingKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+}
+
+// MetricNames returns the metric names a PromQL expression selects, sorted and without duplicates
+// Names given as {__name__="..."} are included; {__name__=~"..."} regex selectors are not resolved.
+func MetricNames(expr string) []string {
+	seen := make(map[string]bool)
+	s := &scanner{src: expr}
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			s.skipString()
+		case c == '#':
+			s.skipLine()
+		case c == '{':
+			if name := s.selectorName(); name != "" {
+				seen[name] = true
+			}
+		case c == '[':
+			s.skipPast(']')
+		case c == '$':
+			s.skipVariable()
+		case isDigit(c) || (c == '.' && s.pos+1 < len(s.src) && isDigit(s.src[s.pos+1])):
+			s.skipNumber()
+		case isIdentStart(c):
+			ident := s.ident()
+			next := s.peekNonSpace()
+			switch {
+			case groupingKeywords[ident]:
+				if next == '(' {
+					s.skipSpace()
+					s.skipPast(')')
+				}
+			case next == '(' || promqlKeywords[ident]:
+				// Function call, aggregation or operator keyword
+			default:
+				seen[ident] = true
+			}
+		default:
+			s.pos++
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type scanner struct {
+	src string
+	pos int
+}
+
+func isDigit(c byte) bool      { return c >= '0' && c <= '9' }
+func isIdentStart(c byte) bool { return c == '_' || c == ':' || (c|0x20 >= 'a' && c|0x20 <= 'z') }
+func isIdentChar(c byte) bool  { return isIdentStart(c) || isDigit(c) }
+
+// ident consumes an identifier
+func (s *scanner) ident() string {
+	start := s.pos
+	for s.pos < len(s.src) && isIdentChar(s.src[s.pos]) {
+		s.pos++
+	}
+	return s.src[start:s.pos]
+}
+
+// skipString consumes a quoted string, honouring backslash escapes except in raw strings
+func (s *scanner) skipString() string {
+	quote := s.src[s.pos]
+	s.pos++
+	var b strings.Builder
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		s.pos++
+		switch {
+		case c == quote:
+			return b.String()
+		case c == '\\' && quote != '`' && s.pos < len(s.src):
+			b.WriteByte(s.src[s.pos])
+			s.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// skipNumber consumes a number or duration such as 0.5, 1e3, 0x1f or 1h30m
+func (s *scanner) skipNumber() {
+	for s.pos < len(s.src) && (isIdentChar(s.src[s.pos]) || s.src[s.pos] == '.') {
+		s.pos++
+	}
+}
+
+// skipVariable consumes a Grafana variable: $name, ${name} or ${name:format}
+func (s *scanner) skipVariable() {
+	s.pos++
+	if s.pos < len(s.src) && s.src[s.pos] == '{' {
+		s.skipPast('}')
+		return
+	}
+	s.ident()
+}
+
+// skipLine consumes a comment up to the end of the line
+func (s *scanner) skipLine() {
+	for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+		s.pos++
+	}
+}
+
+// skipPast consumes up to and including the closing byte of the bracket at pos, skipping strings
+func (s *scanner) skipPast(closing byte) {
+	s.pos++
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == closing:
+			s.pos++
+			return
+		case c == '"' || c == '\'' || c == '`':
+			s.skipString()
+		default:
+			s.pos++
+		}
+	}
+}
+
+// selectorName consumes a {...} label matcher list and returns its exact __name__ matcher, if any
+func (s *scanner) selectorName() string {
+	s.pos++
+	var name string
+	for s.pos < len(s.src) && s.src[s.pos] != '}' {
+		c := s.src[s.pos]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			// Prometheus 3 allows quoted names: {"my.metric", "my.label"="x"}
+			value := s.skipString()
+			s.skipSpace()
+			if s.pos < len(s.src) && strings.IndexByte("=!~", s.src[s.pos]) < 0 {
+				name = value
+			}
+		case isIdentStart(c):
+			label := s.ident()
+			s.skipSpace()
+			op := ""
+			for s.pos < len(s.src) && strings.IndexByte("=!~", s.src[s.pos]) >= 0 {
+				op += string(s.src[s.pos])
+				s.pos++
+			}
+			s.skipSpace()
+			if s.pos < len(s.src) && strings.IndexByte("\"'`", s.src[s.pos]) >= 0 {
+				value := s.skipString()
+				if label == "__name__" && op == "=" {
+					name = value
+				}
+			}
+		default:
+			s.pos++
+		}
+	}
+	s.pos++
+	return name
+}
+
+// skipSpace consumes whitespace
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.src) && strings.IndexByte(" \t\r\n", s.src[s.pos]) >= 0 {
+		s.pos++
+	}
+}
+
+// peekNonSpace returns the next non-whitespace byte without consuming it, or 0
+func (s *scanner) peekNonSpace() byte {
+	for i := s.pos; i < len(s.src); i++ {
+		if strings.IndexByte(" \t\r\n", s.src[i]) < 0 {
+			return s.src[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package usage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetricNames(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{
+			name: "rate with matchers",
+			expr: `sum by (job, code) (rate(http_requests_total{job="api", code=~"5.."}[5m]))`,
+			want: []string{"http_requests_total"},
+		},
+		{
+			name: "histogram quantile",
+			expr: `histogram_quantile(0.99, sum(rate(http_request_duration_seconds_bucket[$__rate_interval])) by (le))`,
+			want: []string{"http_request_duration_seconds_bucket"},
+		},
+		{
+			name: "binary operation with vector matching",
+			expr: `errors_total / on(instance) group_left(version) app_build_info > bool 0.05 offset 1h`,
+			want: []string{"app_build_info", "errors_total"},
+		},
+		{
+			name: "recording rule names and name matchers",
+			expr: `job:http_requests:rate5m unless {__name__="legacy_requests", job!="x"} or {"otel.metric"}`,
+			want: []string{"job:http_requests:rate5m", "legacy_requests", "otel.metric"},
+		},
+		{
+			name: "grafana variables and strings",
+			expr: `label_replace(up{job="$job", instance=~"${instance:regex}"}, "host", "$1", "instance", "(.*):.*") * 1e3`,
+			want: []string{"up"},
+		},
+		{
+			name: "aggregation keywords and functions only",
+			expr: `vector(1) + time()`,
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetricNames(tt.expr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MetricNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package usage
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Query logs are read line by line, detecting the format of each line:
+//   - Prometheus query log (global.query_log_file): JSON with params.query
+//   - Mimir, Cortex and Thanos query-frontend logs: logfmt with param_query= or query=
+//   - Usage analytics exports: "metric_name,query_count" CSV rows
+// Other lines are ignored, so logs mixing queries with unrelated messages can be fed as is.
+
+var (
+	logfmtQuery = regexp.MustCompile(`(?:^|\s)(?:param_query|query)=("(?:[^"\\]|\\.)*"|\S+)`)
+	usageRow    = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)\s*,\s*([0-9]+)\s*$`)
+)
+
+// ParseQueryLog counts the queries selecting each metric in a query log
+func ParseQueryLog(r io.Reader) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := usageRow.FindStringSubmatch(line); m != nil {
+			count, err := strconv.ParseInt(m[2], 10, 64)
+			if err == nil {
+				counts[m[1]] += count
+			}
+			continue
+		}
+		for _, name := range MetricNames(queryFromLogLine(line)) {
+			counts[name]++
+		}
+	}
+	return counts, scanner.Err()
+}
+
+// queryFromLogLine returns the PromQL query a log line records, or ""
+func queryFromLogLine(line string) string {
+	if strings.HasPrefix(line, "{") {
+		var entry struct {
+			Params struct {
+				Query string `json:"query"`
+			} `json:"params"`
+			Query      string `json:"query"`
+			ParamQuery string `json:"param_query"`
+		}
+		if json.Unmarshal([]byte(line), &entry) != nil {
+			return ""
+		}
+		for _, query := range []string{entry.Params.Query, entry.ParamQuery, entry.Query} {
+			if query != "" {
+				return query
+			}
+		}
+		return ""
+	}
+
+	m := logfmtQuery.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	if strings.HasPrefix(m[1], `"`) {
+		if query, err := strconv.Unquote(m[1]); err == nil {
+			return query
+		}
+		return strings.Trim(m[1], `"`)
+	}
+	return m[1]
+}
+
+// LoadQueryLogs counts metric queries across the query logs matching glob patterns
+// Files ending in .gz are decompressed.
+func LoadQueryLogs(patterns []string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		for _, file := range files {
+			fileCounts, err := loadQueryLog(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			for name, count := range fileCounts {
+				counts[name] += count
+			}
+		}
+	}
+	return counts, nil
+}
+
+// loadQueryLog counts metric queries in one query log file
+func loadQueryLog(filename string) (map[string]int64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(filename, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	return ParseQueryLog(reader)
+}
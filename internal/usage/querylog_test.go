@@ -0,0 +1,58 @@
+package usage
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testQueryLog = `{"params":{"end":"2024-01-01T00:00:00Z","query":"rate(http_requests_total[5m])","start":"2024-01-01T00:00:00Z","step":15},"ts":"2024-01-01T00:00:00Z"}
+{"params":{"query":"sum(http_requests_total) / sum(up)"}}
+level=info ts=2024-01-01T00:00:00Z caller=handler.go msg="query stats" param_query="histogram_quantile(0.9, rate(http_request_duration_seconds_bucket{job=\"api\"}[5m]))" response_time=12ms
+level=info msg="slow query" query=up
+level=info msg="unrelated message"
+metric_name,query_count
+queue_depth,12
+`
+
+func TestParseQueryLog(t *testing.T) {
+	got, err := ParseQueryLog(strings.NewReader(testQueryLog))
+	if err != nil {
+		t.Fatalf("ParseQueryLog() error = %v", err)
+	}
+	want := map[string]int64{
+		"http_requests_total":                  2,
+		"up":                                   2,
+		"http_request_duration_seconds_bucket": 1,
+		"queue_depth":                          12,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseQueryLog() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadQueryLogs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "query.log"), []byte(testQueryLog), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(filepath.Join(dir, "query.log.1.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte("queue_depth,8\n"))
+	gz.Close()
+	file.Close()
+
+	got, err := LoadQueryLogs([]string{filepath.Join(dir, "query.log*")})
+	if err != nil {
+		t.Fatalf("LoadQueryLogs() error = %v", err)
+	}
+	if got["queue_depth"] != 20 || got["up"] != 2 {
+		t.Errorf("LoadQueryLogs() = %v, want queue_depth 20 and up 2", got)
+	}
+}
@@ -0,0 +1,295 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"instrumentation-score/internal/loaders"
+)
+
+// Expression is a PromQL query found in a dashboard or rule
+type Expression struct {
+	Source string // Where the query was found, e.g. "dashboard:API Overview" or "alert:HighErrorRate"
+	Query  string
+}
+
+// Source name prefixes
+const (
+	DashboardSource = "dashboard:"
+	AlertSource     = "alert:"
+	RecordSource    = "record:"
+)
+
+// nonPrometheusDatasources are datasource types whose query expressions are not PromQL
+var nonPrometheusDatasources = map[string]bool{
+	"loki": true, "tempo": true, "elasticsearch": true, "influxdb": true, "graphite": true,
+	"cloudwatch": true, "grafana-pyroscope-datasource": true, "mysql": true, "postgres": true,
+}
+
+var (
+	labelValuesQuery = regexp.MustCompile(`^\s*label_values\((.*),\s*[a-zA-Z_][a-zA-Z0-9_]*\s*\)\s*$`)
+	queryResultQuery = regexp.MustCompile(`^\s*query_result\((.*)\)\s*$`)
+)
+
+// DashboardExpressions extracts the PromQL queries of a Grafana dashboard
+// data is the dashboard model or a /api/dashboards/uid/<uid> response wrapping it. Panel
+// targets, nested row panels, annotations and query variables are all collected.
+func DashboardExpressions(data []byte) ([]Expression, error) {
+	var model map[string]interface{}
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse dashboard: %w", err)
+	}
+	if wrapped, ok := model["dashboard"].(map[string]interface{}); ok {
+		model = wrapped
+	}
+
+	title, _ := model["title"].(string)
+	source := DashboardSource + title
+	var expressions []Expression
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if isNonPrometheus(n["datasource"]) {
+				return
+			}
+			if expr, ok := n["expr"].(string); ok && expr != "" {
+				expressions = append(expressions, Expression{Source: source, Query: expr})
+			}
+			if n["type"] == "query" {
+				if query := variableQuery(n["query"]); query != "" {
+					expressions = append(expressions, Expression{Source: source, Query: query})
+				}
+			}
+			for _, child := range n {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range n {
+				walk(child)
+			}
+		}
+	}
+	walk(model)
+	return expressions, nil
+}
+
+// isNonPrometheus reports whether a datasource reference names a non-Prometheus datasource type
+func isNonPrometheus(datasource interface{}) bool {
+	ref, ok := datasource.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	dsType, _ := ref["type"].(string)
+	return nonPrometheusDatasources[dsType]
+}
+
+// variableQuery returns the PromQL a query variable evaluates
+// label_values(expr, label) and query_result(expr) are unwrapped; label_values(label)
+// and metrics(regex) reference no metric.
+func variableQuery(query interface{}) string {
+	var q string
+	switch v := query.(type) {
+	case string:
+		q = v
+	case map[string]interface{}:
+		q, _ = v["query"].(string)
+	}
+	if m := labelValuesQuery.FindStringSubmatch(q); m != nil {
+		return m[1]
+	}
+	if m := queryResultQuery.FindStringSubmatch(q); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// ruleFile is a Prometheus rule file, or the spec of a PrometheusRule resource
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+	Spec   struct {
+		Groups []ruleGroup `yaml:"groups"`
+	} `yaml:"spec"`
+}
+
+type ruleGroup struct {
+	Rules []struct {
+		Alert  string `yaml:"alert"`
+		Record string `yaml:"record"`
+		Expr   string `yaml:"expr"`
+	} `yaml:"rules"`
+}
+
+// RuleFileExpressions extracts alerting and recording rule queries from a Prometheus rule file
+// Kubernetes PrometheusRule manifests are accepted too, including multi-document files.
+func RuleFileExpressions(data []byte) ([]Expression, error) {
+	var expressions []Expression
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var file ruleFile
+		if err := decoder.Decode(&file); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse rule file: %w", err)
+		}
+		for _, group := range append(file.Groups, file.Spec.Groups...) {
+			for _, rule := range group.Rules {
+				if rule.Expr == "" {
+					continue
+				}
+				source := AlertSource + rule.Alert
+				if rule.Record != "" {
+					source = RecordSource + rule.Record
+				}
+				expressions = append(expressions, Expression{Source: source, Query: rule.Expr})
+			}
+		}
+	}
+	return expressions, nil
+}
+
+// LoadFiles reads dashboards (*.json) and rule files (*.yml, *.yaml) matching glob patterns
+func LoadFiles(patterns []string) ([]Expression, error) {
+	var expressions []Expression
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", file, err)
+			}
+
+			var found []Expression
+			switch strings.ToLower(filepath.Ext(file)) {
+			case ".json":
+				found, err = DashboardExpressions(data)
+			case ".yml", ".yaml":
+				found, err = RuleFileExpressions(data)
+			default:
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			expressions = append(expressions, found...)
+		}
+	}
+	return expressions, nil
+}
+
+// Report lists every metric the expressions reference, or queryCounts counts, with its usage
+func Report(expressions []Expression, queryCounts map[string]int64) []loaders.MetricUsageData {
+	references := make(map[string]map[string]bool)
+	for _, expr := range expressions {
+		for _, name := range MetricNames(expr.Query) {
+			if references[name] == nil {
+				references[name] = make(map[string]bool)
+			}
+			references[name][expr.Source] = true
+		}
+	}
+	for name := range queryCounts {
+		if references[name] == nil {
+			references[name] = make(map[string]bool)
+		}
+	}
+
+	data := make([]loaders.MetricUsageData, 0, len(references))
+	for name, sources := range references {
+		usage := loaders.MetricUsageData{MetricName: name, QueryCount: queryCounts[name]}
+		for source := range sources {
+			usage.References = append(usage.References, source)
+		}
+		sort.Strings(usage.References)
+		data = append(data, usage)
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].MetricName < data[j].MetricName })
+	return data
+}
+
+// familySuffixes are the series suffixes of histograms and summaries
+// A dashboard using http_request_duration_seconds_bucket keeps _sum and _count
+// in use too: they are exported, and dropped, together.
+var familySuffixes = []string{"_bucket", "_sum", "_count"}
+
+// metricFamily strips a histogram or summary series suffix
+func metricFamily(name string) string {
+	for _, suffix := range familySuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+// Index answers which sources reference a metric and how often it is queried
+type Index struct {
+	references    map[string][]string
+	families      map[string][]string
+	queries       map[string]int64
+	familyQueries map[string]int64
+	hasQueryLog   bool
+}
+
+// NewIndex indexes a metric usage report
+func NewIndex(data []loaders.MetricUsageData) *Index {
+	index := &Index{
+		references:    make(map[string][]string, len(data)),
+		families:      make(map[string][]string, len(data)),
+		queries:       make(map[string]int64),
+		familyQueries: make(map[string]int64),
+	}
+	for _, usage := range data {
+		family := metricFamily(usage.MetricName)
+		index.references[usage.MetricName] = append(index.references[usage.MetricName], usage.References...)
+		index.families[family] = append(index.families[family], usage.References...)
+		if usage.QueryCount > 0 {
+			index.queries[usage.MetricName] += usage.QueryCount
+			index.familyQueries[family] += usage.QueryCount
+			index.hasQueryLog = true
+		}
+	}
+	return index
+}
+
+// References returns the sources referencing a metric, or any series of its histogram or summary
+func (i *Index) References(metricName string) []string {
+	if refs, ok := i.references[metricName]; ok && len(refs) > 0 {
+		return refs
+	}
+	return i.families[metricFamily(metricName)]
+}
+
+// QueryCount returns how often a metric, or its histogram or summary, was queried
+func (i *Index) QueryCount(metricName string) int64 {
+	if count, ok := i.queries[metricName]; ok {
+		return count
+	}
+	return i.familyQueries[metricFamily(metricName)]
+}
+
+// HasQueryLog reports whether the report includes query log counts
+// Without them a query count of 0 means unknown rather than never queried.
+func (i *Index) HasQueryLog() bool {
+	return i.hasQueryLog
+}
+
+// Used reports whether any dashboard or rule references a metric, or any query selected it
+// Build info metrics count as used: they identify the running version rather than being queried.
+func (i *Index) Used(metricName string) bool {
+	return len(i.References(metricName)) > 0 || i.QueryCount(metricName) > 0 || loaders.IsBuildInfoMetric(metricName)
+}
@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"instrumentation-score/internal/loaders"
+)
+
+const testDashboard = `{
+  "dashboard": {
+    "title": "API Overview",
+    "panels": [
+      {"type": "timeseries", "targets": [{"expr": "rate(http_requests_total[5m])"}]},
+      {"type": "row", "panels": [
+        {"type": "stat", "targets": [{"expr": "sum(queue_depth)"}]}
+      ]},
+      {"type": "logs", "datasource": {"type": "loki", "uid": "logs"}, "targets": [{"expr": "{app=\"api\"} |= \"error\" | json"}]}
+    ],
+    "templating": {"list": [
+      {"type": "query", "name": "job", "query": {"query": "label_values(up{env=\"prod\"}, job)"}},
+      {"type": "query", "name": "env", "query": "label_values(env)"}
+    ]}
+  },
+  "meta": {}
+}`
+
+const testRules = `
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+spec:
+  groups:
+    - name: api
+      rules:
+        - alert: HighErrorRate
+          expr: rate(http_errors_total[5m]) > 1
+---
+groups:
+  - name: recording
+    rules:
+      - record: job:queue_depth:max
+        expr: max by (job) (queue_depth)
+`
+
+func TestDashboardExpressions(t *testing.T) {
+	got, err := DashboardExpressions([]byte(testDashboard))
+	if err != nil {
+		t.Fatalf("DashboardExpressions() error = %v", err)
+	}
+	names := make(map[string]bool)
+	for _, expr := range got {
+		if expr.Source != "dashboard:API Overview" {
+			t.Errorf("Source = %q", expr.Source)
+		}
+		for _, name := range MetricNames(expr.Query) {
+			names[name] = true
+		}
+	}
+	want := map[string]bool{"http_requests_total": true, "queue_depth": true, "up": true}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("dashboard metrics = %v, want %v", names, want)
+	}
+}
+
+func TestLoadFilesAndReport(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "api.json"), []byte(testDashboard), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(testRules), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	expressions, err := LoadFiles([]string{filepath.Join(dir, "*")})
+	if err != nil {
+		t.Fatalf("LoadFiles() error = %v", err)
+	}
+	got := Report(expressions, map[string]int64{"queue_depth": 7, "adhoc_total": 2})
+	want := []loaders.MetricUsageData{
+		{MetricName: "adhoc_total", QueryCount: 2},
+		{MetricName: "http_errors_total", References: []string{"alert:HighErrorRate"}},
+		{MetricName: "http_requests_total", References: []string{"dashboard:API Overview"}},
+		{MetricName: "queue_depth", References: []string{"dashboard:API Overview", "record:job:queue_depth:max"}, QueryCount: 7},
+		{MetricName: "up", References: []string{"dashboard:API Overview"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Report() = %+v, want %+v", got, want)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	index := NewIndex([]loaders.MetricUsageData{
+		{MetricName: "http_request_duration_seconds_bucket", References: []string{"dashboard:Latency"}},
+		{MetricName: "up", References: []string{"alert:TargetDown"}},
+		{MetricName: "adhoc_total", QueryCount: 4},
+	})
+	if !index.HasQueryLog() || index.QueryCount("adhoc_total") != 4 {
+		t.Errorf("QueryCount(adhoc_total) = %d, want 4", index.QueryCount("adhoc_total"))
+	}
+
+	tests := []struct {
+		metric string
+		want   bool
+	}{
+		{"up", true},
+		{"http_request_duration_seconds_bucket", true},
+		{"http_request_duration_seconds_count", true},
+		{"adhoc_total", true},
+		{"app_build_info", true},
+		{"process_cpu_seconds_total", false},
+	}
+	for _, tt := range tests {
+		if got := index.Used(tt.metric); got != tt.want {
+			t.Errorf("Used(%q) = %v, want %v", tt.metric, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package waivers
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DateLayout is the format of waiver expiry dates
+const DateLayout = "2006-01-02"
+
+// File lists the failing metrics teams have acknowledged
+// It is meant to live in version control, so every waiver is reviewed and expires.
+//
+// Example waivers.yaml:
+//
+//	waivers:
+//	  - job: checkout
+//	    metric: legacy_requests
+//	    rule: PROM-MET-01       # Optional, every rule when omitted
+//	    reason: "Renamed in v3, dashboards migrate next quarter"
+//	    ticket: OBS-1234        # Optional
+//	    expires: 2026-12-31
+//	    exempt: true            # Stop counting the failure in the score until it expires
+type File struct {
+	Waivers []Waiver `yaml:"waivers"`
+}
+
+// Waiver acknowledges a metric failing one rule, or all rules, in a job
+type Waiver struct {
+	Job        string `yaml:"job"`
+	JobPattern string `yaml:"job_pattern"`
+	Metric     string `yaml:"metric"`
+	Rule       string `yaml:"rule"`
+	Reason     string `yaml:"reason"`
+	Ticket     string `yaml:"ticket"`
+	Expires    string `yaml:"expires"`
+	Exempt     bool   `yaml:"exempt"`
+
+	pattern *regexp.Regexp
+	expiry  time.Time // Start of the day after Expires
+}
+
+// Load reads and validates a waivers file
+func Load(filename string) (*File, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read waivers file: %w", err)
+	}
+
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse waivers file: %w", err)
+	}
+	if err := file.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &file, nil
+}
+
+// compile validates the waivers, compiles their job patterns and parses their expiry dates
+func (f *File) compile() error {
+	for i := range f.Waivers {
+		w := &f.Waivers[i]
+		if w.Job == "" && w.JobPattern == "" {
+			return fmt.Errorf("waivers[%d]: job or job_pattern is required", i)
+		}
+		if w.Metric == "" {
+			return fmt.Errorf("waivers[%d]: metric is required", i)
+		}
+		if w.Reason == "" {
+			return fmt.Errorf("waivers[%d] (%s): reason is required", i, w.Metric)
+		}
+		if w.Expires == "" {
+			return fmt.Errorf("waivers[%d] (%s): expires is required", i, w.Metric)
+		}
+		expires, err := time.Parse(DateLayout, w.Expires)
+		if err != nil {
+			return fmt.Errorf("waivers[%d] (%s): invalid expires %q, want YYYY-MM-DD", i, w.Metric, w.Expires)
+		}
+		w.expiry = expires.AddDate(0, 0, 1)
+		if w.JobPattern != "" {
+			pattern, err := regexp.Compile(w.JobPattern)
+			if err != nil {
+				return fmt.Errorf("waivers[%d] (%s): invalid job_pattern: %w", i, w.Metric, err)
+			}
+			w.pattern = pattern
+		}
+	}
+	return nil
+}
+
+// matches reports whether a waiver covers a job's metric failing a rule, ignoring expiry
+func (w *Waiver) matches(job, ruleID, metricName string) bool {
+	if w.Metric != metricName || (w.Rule != "" && w.Rule != ruleID) {
+		return false
+	}
+	if w.Job != "" {
+		return w.Job == job
+	}
+	return w.pattern.MatchString(job)
+}
+
+// Expired reports whether the waiver no longer applies at now
+// A waiver applies through the whole day it expires, in UTC.
+func (w *Waiver) Expired(now time.Time) bool {
+	return !now.UTC().Before(w.expiry)
+}
+
+// Lookup returns the active waiver for a job's metric failing a rule
+// The first matching waiver that has not expired wins. A nil file waives nothing.
+func (f *File) Lookup(job, ruleID, metricName string, now time.Time) (Waiver, bool) {
+	if f == nil {
+		return Waiver{}, false
+	}
+	for i := range f.Waivers {
+		w := &f.Waivers[i]
+		if w.matches(job, ruleID, metricName) && !w.Expired(now) {
+			return *w, true
+		}
+	}
+	return Waiver{}, false
+}
+
+// Expired returns the waivers that no longer apply at now
+func (f *File) Expired(now time.Time) []Waiver {
+	if f == nil {
+		return nil
+	}
+	var expired []Waiver
+	for _, w := range f.Waivers {
+		if w.Expired(now) {
+			expired = append(expired, w)
+		}
+	}
+	return expired
+}
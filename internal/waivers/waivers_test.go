@@ -0,0 +1,94 @@
+package waivers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWaivers = `
+waivers:
+  - job: checkout
+    metric: legacy_requests
+    rule: PROM-MET-01
+    reason: "Renamed in v3"
+    expires: 2026-12-31
+    exempt: true
+  - job_pattern: "^payments-.*"
+    metric: legacy_requests
+    reason: "Known"
+    expires: 2026-06-30
+`
+
+func writeWaivers(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "waivers.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write waivers: %v", err)
+	}
+	return path
+}
+
+func TestFile_Lookup(t *testing.T) {
+	file, err := Load(writeWaivers(t, testWaivers))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	june := time.Date(2026, 6, 30, 23, 0, 0, 0, time.UTC)
+	july := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		job, rule    string
+		metric       string
+		now          time.Time
+		want, exempt bool
+	}{
+		{"exact job and rule", "checkout", "PROM-MET-01", "legacy_requests", june, true, true},
+		{"other rule", "checkout", "PROM-MET-02", "legacy_requests", june, false, false},
+		{"other metric", "checkout", "PROM-MET-01", "requests_total", june, false, false},
+		{"pattern, any rule, last day", "payments-api", "PROM-MET-02", "legacy_requests", june, true, false},
+		{"pattern, expired", "payments-api", "PROM-MET-02", "legacy_requests", july, false, false},
+		{"unmatched job", "search", "PROM-MET-01", "legacy_requests", june, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waiver, ok := file.Lookup(tt.job, tt.rule, tt.metric, tt.now)
+			if ok != tt.want || waiver.Exempt != tt.exempt {
+				t.Errorf("Lookup() = %+v, %v, want found %v exempt %v", waiver, ok, tt.want, tt.exempt)
+			}
+		})
+	}
+
+	if expired := file.Expired(july); len(expired) != 1 || expired[0].JobPattern != "^payments-.*" {
+		t.Errorf("Expired() = %+v, want the payments waiver", expired)
+	}
+
+	var none *File
+	if _, ok := none.Lookup("checkout", "PROM-MET-01", "legacy_requests", june); ok {
+		t.Error("nil file waived a failure")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"missing job", "waivers:\n  - metric: m\n    reason: r\n    expires: 2026-01-01\n", "job or job_pattern is required"},
+		{"missing reason", "waivers:\n  - job: j\n    metric: m\n    expires: 2026-01-01\n", "reason is required"},
+		{"missing expiry", "waivers:\n  - job: j\n    metric: m\n    reason: r\n", "expires is required"},
+		{"bad expiry", "waivers:\n  - job: j\n    metric: m\n    reason: r\n    expires: next year\n", "invalid expires"},
+		{"bad pattern", "waivers:\n  - job_pattern: \"[\"\n    metric: m\n    reason: r\n    expires: 2026-01-01\n", "invalid job_pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeWaivers(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"embed"
 	"log"
 
 	"instrumentation-score/cmd"
 )
 
+// defaultRules are the rules evaluate uses when no rules file is given or found
+//
+//go:embed rules_config.yaml rules/packs/*.yaml
+var defaultRules embed.FS
+
 func main() {
+	cmd.SetDefaultRules(defaultRules)
 	if err := cmd.Execute(); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,416 @@
+// Package client calls the HTTP API of instrumentation-score serve, for tools scoring services
+// without running the CLI. Network errors, 429 and 5xx responses are retried, waiting as long
+// as the server's Retry-After asks.
+//
+//	c := client.New("https://scores.example.com")
+//	c.Token = os.Getenv("SCORE_TOKEN")
+//	result, err := c.Evaluate(ctx, client.EvaluateRequest{Job: "checkout", Metrics: exposition})
+//	...
+//	fmt.Printf("%s: %.1f (rules %s)\n", result.JobName, result.Score, result.RulesVersion)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"instrumentation-score/internal/server"
+	"instrumentation-score/pkg/score"
+)
+
+// Inputs of EvaluateRequest
+const (
+	InputExposition = server.InputExposition // Prometheus text exposition, as served on /metrics
+	InputJobFile    = server.InputJobFile    // A per-job file written by analyze
+)
+
+// Statuses of a Run
+const (
+	RunQueued  = server.RunQueued
+	RunRunning = server.RunRunning
+	RunDone    = server.RunDone
+	RunFailed  = server.RunFailed
+)
+
+// Run is a run of the server: a scheduled collection or an evaluation request
+type Run = server.Run
+
+// EvaluateRequest is a POST /evaluate
+type EvaluateRequest struct {
+	Job     string // Required for an exposition; defaults to the job in a job file
+	Input   string // InputExposition (default) or InputJobFile
+	Metrics []byte
+
+	// Score against the server's rules profile of this name, or against this rules document,
+	// instead of the server's rules
+	Profile string
+	Rules   []byte
+}
+
+// JobResult is the score of a job, in the shape of a job in evaluate's JSON report
+type JobResult struct {
+	JobName          string             `json:"job_name"`
+	ServiceVersion   string             `json:"service_version,omitempty"`
+	TotalMetrics     int                `json:"total_metrics"`
+	TotalCardinality int64              `json:"total_cardinality"`
+	EstimatedCost    float64            `json:"estimated_cost,omitempty"`
+	Score            float64            `json:"instrumentation_score"`
+	ScoreBreakdown   *score.Breakdown   `json:"score_breakdown,omitempty"`
+	RuleResults      []score.RuleResult `json:"rules"`
+	FailedMetrics    []string           `json:"failed_metrics,omitempty"`
+	MetricsBreakdown map[string]int     `json:"metrics_breakdown"`
+	Warnings         []string           `json:"warnings,omitempty"` // Validators that failed to evaluate, counted as failed
+
+	RulesVersion string `json:"-"` // Version of the rules the job was scored against
+	RunID        string `json:"-"` // Run of the request on the server, see Client.Run
+}
+
+// Report is the report of a finished run, in the shape of evaluate's JSON report
+type Report struct {
+	Timestamp        string      `json:"timestamp"`
+	TotalJobs        int         `json:"total_jobs"`
+	AverageScore     float64     `json:"average_score"`
+	TotalCost        float64     `json:"total_cost,omitempty"`
+	TotalCardinality int64       `json:"total_cardinality"`
+	Selector         string      `json:"selector,omitempty"`
+	Jobs             []JobResult `json:"jobs"`
+	Warnings         []string    `json:"warnings,omitempty"`
+}
+
+// ReportQuery selects a page of GET /api/v1/report; zero fields are left to the server
+type ReportQuery struct {
+	Run           string // Page through the report of this run instead of the jobs of --job-dir
+	Offset, Limit int
+	Sort          string // score, cost or name, "-" first for descending
+
+	// Only the jobs of a team, of a score category such as Good, or scoring within bounds
+	Team     string
+	Category string
+	MinScore *float64
+	MaxScore *float64
+}
+
+// ReportPage is a page of GET /api/v1/report
+type ReportPage struct {
+	Total  int         `json:"total"` // Jobs matching the query, on every page
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Jobs   []JobResult `json:"jobs"`
+	Next   string      `json:"next,omitempty"` // Path of the next page, "" on the last
+}
+
+// ErrNotFound is matched by the error of a job, run or report the server does not have
+var ErrNotFound = errors.New("not found")
+
+// Error is an error response of the server
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// Client calls a serve API
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// Credentials: a bearer token, else HTTP basic auth when Username is set
+	Token    string
+	Username string
+	Password string
+
+	Attempts   int           // Tries per call, for network errors and 5xx or 429 responses
+	RetryDelay time.Duration // Doubled after each failed try, unless the response sets Retry-After
+}
+
+// New returns a Client of the server at baseURL, e.g. http://localhost:9090
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+		Attempts:   3,
+		RetryDelay: time.Second,
+	}
+}
+
+// Evaluate scores the metrics of a request
+func (c *Client) Evaluate(ctx context.Context, request EvaluateRequest) (*JobResult, error) {
+	query := url.Values{}
+	if request.Job != "" {
+		query.Set("job", request.Job)
+	}
+	if request.Input != "" {
+		query.Set("input", request.Input)
+	}
+	if request.Profile != "" {
+		query.Set("profile", request.Profile)
+	}
+	body, contentType := request.Metrics, "text/plain"
+	if request.Rules != nil {
+		var err error
+		if body, contentType, err = multipartBody(request.Metrics, request.Rules); err != nil {
+			return nil, err
+		}
+	}
+
+	var result JobResult
+	resp, err := c.do(ctx, "POST", "/evaluate?"+query.Encode(), body, contentType, &result)
+	if err != nil {
+		return nil, err
+	}
+	result.RulesVersion, result.RunID = resp.Header.Get("X-Rules-Version"), resp.Header.Get("X-Run-ID")
+	return &result, nil
+}
+
+// multipartBody is the /evaluate body of metrics scored against a rules document
+func multipartBody(metrics, rules []byte) ([]byte, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		name string
+		data []byte
+	}{{"metrics", metrics}, {"rules", rules}} {
+		w, err := writer.CreateFormFile(part.name, part.name)
+		if err != nil {
+			return nil, "", err
+		}
+		w.Write(part.data)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// JobScore scores the collected metrics of job on the server; the error matches ErrNotFound
+// for a job without collected metrics
+func (c *Client) JobScore(ctx context.Context, job string) (*JobResult, error) {
+	var result JobResult
+	resp, err := c.do(ctx, "GET", "/jobs/"+url.PathEscape(job)+"/score", nil, "", &result)
+	if err != nil {
+		return nil, err
+	}
+	result.RulesVersion, result.RunID = resp.Header.Get("X-Rules-Version"), resp.Header.Get("X-Run-ID")
+	return &result, nil
+}
+
+// Runs returns the runs of the server, newest first
+func (c *Client) Runs(ctx context.Context) ([]Run, error) {
+	var list struct {
+		Runs []Run `json:"runs"`
+	}
+	if _, err := c.do(ctx, "GET", "/runs", nil, "", &list); err != nil {
+		return nil, err
+	}
+	return list.Runs, nil
+}
+
+// Run returns run id
+func (c *Client) Run(ctx context.Context, id string) (Run, error) {
+	var run Run
+	_, err := c.do(ctx, "GET", "/runs/"+url.PathEscape(id), nil, "", &run)
+	return run, err
+}
+
+// RunReport returns the report of run id; the error matches ErrNotFound until the run is done
+func (c *Client) RunReport(ctx context.Context, id string) (*Report, error) {
+	var report Report
+	if _, err := c.do(ctx, "GET", "/runs/"+url.PathEscape(id)+"/report", nil, "", &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Report returns a page of the jobs query selects; see ReportPages for every page
+func (c *Client) Report(ctx context.Context, query ReportQuery) (*ReportPage, error) {
+	values := url.Values{}
+	for name, value := range map[string]string{"run": query.Run, "sort": query.Sort, "team": query.Team, "category": query.Category} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	if query.Offset > 0 {
+		values.Set("offset", strconv.Itoa(query.Offset))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.MinScore != nil {
+		values.Set("min_score", strconv.FormatFloat(*query.MinScore, 'f', -1, 64))
+	}
+	if query.MaxScore != nil {
+		values.Set("max_score", strconv.FormatFloat(*query.MaxScore, 'f', -1, 64))
+	}
+	return c.reportPage(ctx, "/api/v1/report?"+values.Encode())
+}
+
+// ReportPages calls page with each page of the jobs query selects, from query.Offset on, until
+// the last page or an error of page
+func (c *Client) ReportPages(ctx context.Context, query ReportQuery, page func(*ReportPage) error) error {
+	next, err := c.Report(ctx, query)
+	for err == nil {
+		if err = page(next); err != nil || next.Next == "" {
+			return err
+		}
+		next, err = c.reportPage(ctx, next.Next)
+	}
+	return err
+}
+
+// reportPage gets the report page at path
+func (c *Client) reportPage(ctx context.Context, path string) (*ReportPage, error) {
+	var page ReportPage
+	if _, err := c.do(ctx, "GET", path, nil, "", &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// SubmitTarball queues a run scoring the job files in a gzip tarball and returns it queued;
+// see WaitRun
+func (c *Client) SubmitTarball(ctx context.Context, tarball []byte) (Run, error) {
+	var run Run
+	_, err := c.do(ctx, "POST", "/runs", tarball, "application/gzip", &run)
+	return run, err
+}
+
+// SubmitS3 queues a run scoring the job files under an s3://bucket/prefix URI the server may read
+func (c *Client) SubmitS3(ctx context.Context, uri string) (Run, error) {
+	body, err := json.Marshal(map[string]string{"s3_uri": uri})
+	if err != nil {
+		return Run{}, err
+	}
+	var run Run
+	_, err = c.do(ctx, "POST", "/runs", body, "application/json", &run)
+	return run, err
+}
+
+// WaitRun polls run id every interval until it is done or failed, and returns it
+func (c *Client) WaitRun(ctx context.Context, id string, interval time.Duration) (Run, error) {
+	for {
+		run, err := c.Run(ctx, id)
+		if err != nil || run.Status == RunDone || run.Status == RunFailed {
+			return run, err
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return run, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// RulesVersion returns the version of the rules the server scores with
+func (c *Client) RulesVersion(ctx context.Context) (string, error) {
+	var health struct {
+		RulesVersion string `json:"rules_version"`
+	}
+	_, err := c.do(ctx, "GET", "/healthz", nil, "", &health)
+	return health.RulesVersion, err
+}
+
+// do sends a request, retrying network errors and 5xx or 429 responses, and decodes the JSON
+// response into result
+func (c *Client) do(ctx context.Context, method, path string, body []byte, contentType string, result interface{}) (*http.Response, error) {
+	delay := c.RetryDelay
+	var lastErr error
+	for attempt := 0; attempt < max(c.Attempts, 1); attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			case <-timer.C:
+			}
+			delay = c.RetryDelay << attempt
+		}
+
+		resp, err := c.send(ctx, method, path, body, contentType)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode >= 300 {
+			var errBody struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(data, &errBody) != nil || errBody.Error == "" {
+				errBody.Error = strings.TrimSpace(string(data))
+			}
+			lastErr = &Error{StatusCode: resp.StatusCode, Message: errBody.Error}
+			if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+				return nil, lastErr
+			}
+			if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = wait
+			}
+			continue
+		}
+		if err := json.Unmarshal(data, result); err != nil {
+			return nil, fmt.Errorf("invalid response from %s: %w", path, err)
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+// send sends one request
+func (c *Client) send(ctx context.Context, method, path string, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// retryAfter parses a Retry-After header, in seconds or an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
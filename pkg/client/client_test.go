@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"instrumentation-score/internal/loaders"
+	"instrumentation-score/internal/server"
+)
+
+// newTestServer serves the API, answering the first failures requests 503
+func newTestServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	handler := server.Handler(server.Options{
+		Evaluate: func(job string, metrics []loaders.JobMetricData, rules interface{}) (interface{}, error) {
+			score := 80.0
+			if rules != nil {
+				score = 40
+			}
+			return map[string]interface{}{"job_name": job, "total_metrics": len(metrics), "instrumentation_score": score}, nil
+		},
+		LoadRules: func(override server.RulesOverride) (interface{}, string, error) {
+			return "strict", "strict1", nil
+		},
+		JobScore: func(job string) (interface{}, error) { return nil, server.ErrNotFound },
+		EvaluateBulk: func(ctx context.Context, request server.BulkRequest) (server.RunReport, error) {
+			return func(visible func(job string) bool) interface{} {
+				return map[string]interface{}{"total_jobs": 1, "jobs": []interface{}{map[string]interface{}{"job_name": request.S3URI}}}
+			}, nil
+		},
+		Report: func() (server.JobReport, error) {
+			return testReport{"checkout": 80, "search": 40, "billing": 95}, nil
+		},
+		BulkS3Buckets: []string{"metrics"},
+		RulesVersion:  func() string { return "abc123" },
+		Runs:          server.NewRuns(ctx, 0, 1, 0),
+	})
+
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"error": "the run queue is full"}`, http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &calls
+}
+
+// testReport is a report of jobs and their scores
+type testReport map[string]float64
+
+func (r testReport) ReportJobs() []server.ReportJob {
+	var jobs []server.ReportJob
+	for job, score := range r {
+		jobs = append(jobs, server.ReportJob{Name: job, Score: score, Entry: map[string]interface{}{"job_name": job, "instrumentation_score": score}})
+	}
+	return jobs
+}
+
+func newTestClient(url string) *Client {
+	c := New(url)
+	c.RetryDelay = time.Millisecond
+	return c
+}
+
+func TestClient_Evaluate(t *testing.T) {
+	ts, calls := newTestServer(t, 2)
+	c := newTestClient(ts.URL)
+
+	result, err := c.Evaluate(context.Background(), EvaluateRequest{Job: "checkout", Metrics: []byte("a 1\nb 2\n")})
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if result.JobName != "checkout" || result.TotalMetrics != 2 || result.Score != 80 || result.RulesVersion != "abc123" {
+		t.Errorf("Evaluate() = %+v", result)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 2 retries of the 503", calls.Load())
+	}
+
+	run, err := c.Run(context.Background(), result.RunID)
+	if err != nil || run.Status != RunDone || run.Job != "checkout" {
+		t.Errorf("Run(%q) = %+v, %v", result.RunID, run, err)
+	}
+
+	strict, err := c.Evaluate(context.Background(), EvaluateRequest{Job: "checkout", Metrics: []byte("a 1\n"), Rules: []byte("rules: []")})
+	if err != nil || strict.Score != 40 || strict.RulesVersion != "strict1" {
+		t.Errorf("Evaluate() with rules = %+v, %v, want scored against them", strict, err)
+	}
+}
+
+func TestClient_Bulk(t *testing.T) {
+	ts, _ := newTestServer(t, 0)
+	c := newTestClient(ts.URL)
+
+	run, err := c.SubmitS3(context.Background(), "s3://metrics/ci")
+	if err != nil || run.ID == "" {
+		t.Fatalf("SubmitS3() = %+v, %v", run, err)
+	}
+	if run, err = c.WaitRun(context.Background(), run.ID, time.Millisecond); err != nil || run.Status != RunDone {
+		t.Fatalf("WaitRun() = %+v, %v", run, err)
+	}
+	report, err := c.RunReport(context.Background(), run.ID)
+	if err != nil || report.TotalJobs != 1 || report.Jobs[0].JobName != "s3://metrics/ci" {
+		t.Errorf("RunReport() = %+v, %v", report, err)
+	}
+
+	var apiErr *Error
+	if _, err := c.SubmitTarball(context.Background(), []byte("not a tarball")); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("SubmitTarball() error = %v, want a 400", err)
+	}
+}
+
+func TestClient_Report(t *testing.T) {
+	ts, _ := newTestServer(t, 0)
+	c := newTestClient(ts.URL)
+
+	min := 50.0
+	page, err := c.Report(context.Background(), ReportQuery{Sort: "-score", MinScore: &min})
+	if err != nil || page.Total != 2 || len(page.Jobs) != 2 || page.Jobs[0].JobName != "billing" || page.Next != "" {
+		t.Fatalf("Report() = %+v, %v", page, err)
+	}
+
+	var jobs []string
+	err = c.ReportPages(context.Background(), ReportQuery{Sort: "name", Limit: 2}, func(page *ReportPage) error {
+		for _, job := range page.Jobs {
+			jobs = append(jobs, job.JobName)
+		}
+		return nil
+	})
+	if err != nil || strings.Join(jobs, ",") != "billing,checkout,search" {
+		t.Errorf("ReportPages() read %v, %v, want every job in order", jobs, err)
+	}
+
+	var apiErr *Error
+	if _, err := c.Report(context.Background(), ReportQuery{Sort: "owner"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Report() with an unknown sort error = %v, want a 400", err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	ts, calls := newTestServer(t, 0)
+	c := newTestClient(ts.URL)
+
+	_, err := c.JobScore(context.Background(), "checkout")
+	if !errors.Is(err, ErrNotFound) || calls.Load() != 1 {
+		t.Errorf("JobScore() error = %v after %d calls, want ErrNotFound without retries", err, calls.Load())
+	}
+	var apiErr *Error
+	if _, err := c.Evaluate(context.Background(), EvaluateRequest{Metrics: []byte("a 1\n")}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Evaluate() without a job error = %v, want a 400", err)
+	}
+
+	failing, calls := newTestServer(t, 10)
+	c = newTestClient(failing.URL)
+	if _, err := c.RulesVersion(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message != "the run queue is full" {
+		t.Errorf("RulesVersion() error = %v, want the last 503", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3 attempts", calls.Load())
+	}
+
+	badGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer badGateway.Close()
+	c = newTestClient(badGateway.URL)
+	c.Attempts, c.RetryDelay = 5, time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Runs(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Runs() error = %v, want the context's while waiting to retry", err)
+	}
+}
+
+func TestClient_Credentials(t *testing.T) {
+	var authorization atomic.Value
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		w.Write([]byte(`{"rules_version": "abc123"}`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(ts.URL + "/")
+	c.Token = "secret"
+	if version, err := c.RulesVersion(context.Background()); err != nil || version != "abc123" {
+		t.Fatalf("RulesVersion() = %q, %v", version, err)
+	}
+	if authorization.Load() != "Bearer secret" {
+		t.Errorf("Authorization = %v, want the bearer token", authorization.Load())
+	}
+
+	c.Token, c.Username, c.Password = "", "ana", "pw"
+	c.RulesVersion(context.Background())
+	if authorization.Load() != "Basic YW5hOnB3" {
+		t.Errorf("Authorization = %v, want basic auth", authorization.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if wait, ok := retryAfter("30"); !ok || wait != 30*time.Second {
+		t.Errorf("retryAfter(30) = %v, %v", wait, ok)
+	}
+	if wait, ok := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); !ok || wait <= 58*time.Second || wait > time.Minute {
+		t.Errorf("retryAfter(date) = %v, %v", wait, ok)
+	}
+	for _, value := range []string{"", "soon", "-1"} {
+		if _, ok := retryAfter(value); ok {
+			t.Errorf("retryAfter(%q) should be ignored", value)
+		}
+	}
+}
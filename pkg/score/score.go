@@ -0,0 +1,252 @@
+// Package score scores the instrumentation quality of jobs, for Go services embedding the
+// rule engine the CLI runs. Functions return errors rather than exiting, and print nothing.
+//
+//	rules, err := score.LoadRules("rules_config.yaml")
+//	...
+//	metrics, err := score.ReadExposition(resp.Body, "checkout")
+//	...
+//	result, err := score.EvaluateJob(ctx, rules, "checkout", metrics, score.Options{})
+//	fmt.Printf("%s: %.1f (%s)\n", result.Job, result.Score, result.Category)
+package score
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"instrumentation-score/internal/collectors"
+	"instrumentation-score/internal/engine"
+	"instrumentation-score/internal/loaders"
+)
+
+// Rules are the rules jobs are scored against, with their exclusions and conventions
+type Rules = engine.RuleEngine
+
+// Metric is a metric of a job: its labels, series count and metadata
+type Metric = loaders.JobMetricData
+
+// RuleResult is how a job's metrics did on one rule
+type RuleResult = engine.RuleResult
+
+// Breakdown explains a score: the weight and points of every rule
+type Breakdown = engine.ScoreBreakdown
+
+// LoadRules reads a rules file and the rule packs it includes
+func LoadRules(path string) (*Rules, error) {
+	return engine.NewRuleEngine(path)
+}
+
+// LoadRulesFS reads a rules file from fsys, such as rules embedded in a binary
+func LoadRulesFS(fsys fs.FS, path string) (*Rules, error) {
+	return engine.NewRuleEngineFS(fsys, path)
+}
+
+// ReadExposition reads metrics of job in the Prometheus text format, as served on /metrics
+func ReadExposition(r io.Reader, job string) ([]Metric, error) {
+	return collectors.ReadExposition(r, job, "library")
+}
+
+// ReadJobFile reads a per-job file written by analyze
+func ReadJobFile(r io.Reader) ([]Metric, error) {
+	metrics, _, err := loaders.ReadJobMetricReport(r, "job file")
+	return metrics, err
+}
+
+// ErrExcluded is matched by the error of a job the rules exclude, entirely or all its metrics
+var ErrExcluded = errors.New("excluded from evaluation")
+
+// ErrNoJobs is returned by Evaluate when no job could be scored
+var ErrNoJobs = errors.New("no jobs were successfully evaluated")
+
+// exclusionError is returned for excluded jobs, matching ErrExcluded
+type exclusionError struct {
+	job        string
+	allMetrics bool // The job is included, but the exclusion list removes every metric
+}
+
+func (e *exclusionError) Error() string {
+	if e.allMetrics {
+		return fmt.Sprintf("no metrics remaining after exclusion filtering for job %s", e.job)
+	}
+	return fmt.Sprintf("job %s is excluded from evaluation", e.job)
+}
+
+func (e *exclusionError) Is(target error) bool {
+	return target == ErrExcluded
+}
+
+// Options adjusts how jobs are scored
+type Options struct {
+	CostPerSeries float64 // Estimated cost of one series; 0 leaves EstimatedCost out
+
+	// Adjust, when set, may change the rule results of a job before its score is calculated,
+	// e.g. to waive accepted failures. metrics are the metrics left after exclusions.
+	Adjust func(job string, results []RuleResult, metrics []Metric)
+}
+
+// JobScore is the score of one job
+type JobScore struct {
+	Job              string
+	Metrics          int // Metrics of the job, excluded ones included
+	TotalCardinality int64
+	EstimatedCost    float64
+	Score            float64 // 0-100
+	Category         string  // See Category
+	Breakdown        Breakdown
+	RuleResults      []RuleResult
+	FailedMetrics    []string       // Metrics failing any rule, in order of first failure
+	PassedChecks     map[string]int // Rule ID -> checks passed
+	Warnings         []string       // Validators that failed; they count as failing every metric of the job
+
+	Evaluated []Metric // The metrics left after exclusions, which were scored
+}
+
+// EvaluateJob scores the metrics of job
+// The error matches ErrExcluded when the rules exclude the job or all of its metrics. Once ctx is
+// done the rules are no longer evaluated and ctx's error is returned, without calling Adjust.
+func EvaluateJob(ctx context.Context, rules *Rules, job string, metrics []Metric, opts Options) (JobScore, error) {
+	if err := ctx.Err(); err != nil {
+		return JobScore{}, err
+	}
+	if len(metrics) == 0 {
+		return JobScore{}, fmt.Errorf("no metrics found for job %s", job)
+	}
+	if rules.IsJobExcluded(job) {
+		return JobScore{}, &exclusionError{job: job}
+	}
+	evaluated := rules.FilterExcludedJobData(job, metrics)
+	if len(evaluated) == 0 {
+		return JobScore{}, &exclusionError{job: job, allMetrics: true}
+	}
+
+	results, err := rules.EvaluateJobContext(ctx, evaluated)
+	var warnings []string
+	var evalErrors engine.EvaluationErrors
+	if errors.As(err, &evalErrors) {
+		// Validators that failed count as failed, the results of the others are kept
+		for _, evalErr := range evalErrors {
+			warnings = append(warnings, evalErr.Error())
+		}
+	} else if err != nil {
+		return JobScore{}, err
+	}
+	if opts.Adjust != nil {
+		opts.Adjust(job, results, evaluated)
+	}
+	breakdown := engine.ExplainScore(results)
+
+	result := JobScore{
+		Job:          job,
+		Metrics:      len(metrics),
+		Score:        breakdown.Score,
+		Category:     Category(breakdown.Score),
+		Breakdown:    breakdown,
+		RuleResults:  results,
+		PassedChecks: make(map[string]int),
+		Warnings:     warnings,
+		Evaluated:    evaluated,
+	}
+	for _, metric := range evaluated {
+		result.TotalCardinality += metric.Cardinality
+	}
+	if opts.CostPerSeries > 0 {
+		result.EstimatedCost = float64(result.TotalCardinality) * opts.CostPerSeries
+	}
+	failed := make(map[string]bool)
+	for _, ruleResult := range results {
+		for metricName := range ruleResult.FailedMetrics {
+			if !failed[metricName] {
+				result.FailedMetrics = append(result.FailedMetrics, metricName)
+				failed[metricName] = true
+			}
+		}
+		result.PassedChecks[ruleResult.RuleID] = ruleResult.PassedChecks
+	}
+	return result, nil
+}
+
+// Job is the metrics of one job to score
+type Job struct {
+	Name    string
+	Metrics []Metric
+}
+
+// SkippedJob is a job that could not be scored
+type SkippedJob struct {
+	Job string
+	Err error
+}
+
+// Report is the scores of several jobs
+type Report struct {
+	Jobs             []JobScore
+	Excluded         []string // Jobs the rules exclude
+	Skipped          []SkippedJob
+	AverageScore     float64
+	TotalCardinality int64
+	TotalCost        float64
+}
+
+// Evaluate scores every job; jobs that fail are reported in Skipped rather than failing the rest
+// The error is ErrNoJobs when no job was scored, or the context's error when it is done.
+func Evaluate(ctx context.Context, rules *Rules, jobs []Job, opts Options) (Report, error) {
+	var report Report
+	var totalScore float64
+	for _, job := range jobs {
+		result, err := EvaluateJob(ctx, rules, job.Name, job.Metrics, opts)
+		switch {
+		case ctx.Err() != nil:
+			return report, ctx.Err()
+		case errors.Is(err, ErrExcluded):
+			report.Excluded = append(report.Excluded, job.Name)
+			continue
+		case err != nil:
+			report.Skipped = append(report.Skipped, SkippedJob{Job: job.Name, Err: err})
+			continue
+		}
+		report.Jobs = append(report.Jobs, result)
+		totalScore += result.Score
+		report.TotalCardinality += result.TotalCardinality
+		report.TotalCost += result.EstimatedCost
+	}
+	if len(report.Jobs) == 0 {
+		return report, ErrNoJobs
+	}
+	report.AverageScore = totalScore / float64(len(report.Jobs))
+	return report, nil
+}
+
+// Category names the band of a score as the specification does: Excellent (90 and above),
+// Good (75), Needs Improvement (50) or Poor
+func Category(score float64) string {
+	switch {
+	case score >= 90:
+		return "Excellent"
+	case score >= 75:
+		return "Good"
+	case score >= 50:
+		return "Needs Improvement"
+	}
+	return "Poor"
+}
+
+// Confidence levels of a score, see Confidence
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Confidence rates how far a score can be trusted from the fraction of the job's metrics it
+// is missing, uncollected or excluded: high below 10%, medium below 30%, low otherwise
+func Confidence(missingFraction float64) string {
+	switch {
+	case missingFraction < 0.1:
+		return ConfidenceHigh
+	case missingFraction < 0.3:
+		return ConfidenceMedium
+	}
+	return ConfidenceLow
+}
@@ -0,0 +1,163 @@
+package score
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const rulesYAML = `
+exclusion_list:
+  - job: "batch"
+  - job: "api"
+    metrics: ["debug_info"]
+  - job: "legacy"
+    metrics: ["legacy_requests_total"]
+rules:
+- rule_id: "TEST-MET-01"
+  description: "Series per metric"
+  impact: "Critical"
+  validators:
+    - name: "cardinality_check"
+      type: "cardinality"
+      data_source: "cardinality"
+      conditions:
+        - field: "count"
+          operator: "lt"
+          value: 1000
+      threshold:
+        pass_percentage: 90.0
+`
+
+func loadTestRules(t *testing.T) *Rules {
+	t.Helper()
+	rules, err := LoadRulesFS(fstest.MapFS{"rules.yaml": {Data: []byte(rulesYAML)}}, "rules.yaml")
+	if err != nil {
+		t.Fatalf("LoadRulesFS() error = %v", err)
+	}
+	return rules
+}
+
+func TestEvaluateJob(t *testing.T) {
+	rules := loadTestRules(t)
+	metrics := []Metric{
+		{Job: "api", MetricName: "http_requests_total", Cardinality: 40},
+		{Job: "api", MetricName: "http_request_duration_seconds", Cardinality: 5000},
+		{Job: "api", MetricName: "debug_info", Cardinality: 99999},
+	}
+
+	var adjusted []Metric
+	result, err := EvaluateJob(context.Background(), rules, "api", metrics, Options{
+		CostPerSeries: 0.01,
+		Adjust:        func(job string, results []RuleResult, metrics []Metric) { adjusted = metrics },
+	})
+	if err != nil {
+		t.Fatalf("EvaluateJob() error = %v", err)
+	}
+	if result.Metrics != 3 || len(result.Evaluated) != 2 || len(adjusted) != 2 {
+		t.Errorf("Metrics = %d, Evaluated = %d, adjusted = %d; want 3 metrics, debug_info excluded", result.Metrics, len(result.Evaluated), len(adjusted))
+	}
+	if result.TotalCardinality != 5040 || result.EstimatedCost != 50.4 {
+		t.Errorf("TotalCardinality = %d, EstimatedCost = %v", result.TotalCardinality, result.EstimatedCost)
+	}
+	if len(result.FailedMetrics) != 1 || result.FailedMetrics[0] != "http_request_duration_seconds" {
+		t.Errorf("FailedMetrics = %v", result.FailedMetrics)
+	}
+	if result.Category != Category(result.Score) || result.Score != result.Breakdown.Score {
+		t.Errorf("Score = %v (%s), breakdown %v", result.Score, result.Category, result.Breakdown.Score)
+	}
+}
+
+func TestEvaluateJob_Errors(t *testing.T) {
+	rules := loadTestRules(t)
+
+	_, err := EvaluateJob(context.Background(), rules, "batch", []Metric{{Job: "batch", MetricName: "jobs_total", Cardinality: 1}}, Options{})
+	if !errors.Is(err, ErrExcluded) || err.Error() != "job batch is excluded from evaluation" {
+		t.Errorf("EvaluateJob() of an excluded job error = %v", err)
+	}
+	_, err = EvaluateJob(context.Background(), rules, "legacy", []Metric{{Job: "legacy", MetricName: "legacy_requests_total", Cardinality: 1}}, Options{})
+	if !errors.Is(err, ErrExcluded) || !strings.Contains(err.Error(), "no metrics remaining after exclusion filtering") {
+		t.Errorf("EvaluateJob() of a job without remaining metrics error = %v", err)
+	}
+	if _, err := EvaluateJob(context.Background(), rules, "api", nil, Options{}); err == nil || errors.Is(err, ErrExcluded) {
+		t.Errorf("EvaluateJob() without metrics error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := EvaluateJob(ctx, rules, "api", []Metric{{Job: "api", MetricName: "up", Cardinality: 1}}, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("EvaluateJob() with a canceled context error = %v", err)
+	}
+
+	// Canceled while the rules are evaluated, the job is not adjusted
+	adjusted := false
+	_, err = EvaluateJob(&cancelAfter{Context: context.Background(), checks: 1}, rules, "api", []Metric{{Job: "api", MetricName: "up", Cardinality: 1}}, Options{
+		Adjust: func(string, []RuleResult, []Metric) { adjusted = true },
+	})
+	if !errors.Is(err, context.Canceled) || adjusted {
+		t.Errorf("EvaluateJob() canceled during evaluation error = %v, adjusted = %v", err, adjusted)
+	}
+}
+
+// cancelAfter is a context canceled once Err has been checked checks times
+type cancelAfter struct {
+	context.Context
+	checks int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.checks == 0 {
+		return context.Canceled
+	}
+	c.checks--
+	return nil
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := loadTestRules(t)
+	exposition := "# TYPE up gauge\nup 1\n"
+	metrics, err := ReadExposition(strings.NewReader(exposition), "web")
+	if err != nil {
+		t.Fatalf("ReadExposition() error = %v", err)
+	}
+
+	report, err := Evaluate(context.Background(), rules, []Job{
+		{Name: "web", Metrics: metrics},
+		{Name: "batch", Metrics: []Metric{{Job: "batch", MetricName: "jobs_total", Cardinality: 1}}},
+		{Name: "empty"},
+	}, Options{})
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if len(report.Jobs) != 1 || report.Jobs[0].Job != "web" || report.AverageScore != report.Jobs[0].Score {
+		t.Errorf("Jobs = %+v, AverageScore = %v", report.Jobs, report.AverageScore)
+	}
+	if len(report.Excluded) != 1 || report.Excluded[0] != "batch" {
+		t.Errorf("Excluded = %v", report.Excluded)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].Job != "empty" {
+		t.Errorf("Skipped = %v", report.Skipped)
+	}
+
+	if _, err := Evaluate(context.Background(), rules, []Job{{Name: "batch", Metrics: []Metric{{Job: "batch", MetricName: "jobs_total"}}}}, Options{}); !errors.Is(err, ErrNoJobs) {
+		t.Errorf("Evaluate() of excluded jobs only error = %v, want ErrNoJobs", err)
+	}
+}
+
+func TestCategory(t *testing.T) {
+	for score, want := range map[float64]string{100: "Excellent", 90: "Excellent", 89.9: "Good", 75: "Good", 50: "Needs Improvement", 49.9: "Poor", 0: "Poor"} {
+		if got := Category(score); got != want {
+			t.Errorf("Category(%v) = %q, want %q", score, got, want)
+		}
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	for fraction, want := range map[float64]string{0: ConfidenceHigh, 0.09: ConfidenceHigh, 0.1: ConfidenceMedium, 0.29: ConfidenceMedium, 0.3: ConfidenceLow, 1: ConfidenceLow} {
+		if got := Confidence(fraction); got != want {
+			t.Errorf("Confidence(%v) = %q, want %q", fraction, got, want)
+		}
+	}
+}
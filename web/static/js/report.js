@@ -1,33 +1,109 @@
-// Get validator info from rules config
+// Numbers are formatted for the report's locale, set by --locale on the <html> element
+const reportLocale = document.documentElement.lang || undefined;
+
+// Escape text from the rules config before inserting it as HTML
+function escapeHtml(text) {
+    return String(text).replace(/[&<>"']/g, c => ({'&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'})[c]);
+}
+
+// Get validator info: title, description, remediation, severity and doc link
+// VALIDATORS holds the metadata of the evaluated validators, localized; rules config
+// lookups cover reports written before it was embedded.
 function getValidatorInfo(validatorName) {
-    if (!window.RULES_CONFIG || !Array.isArray(window.RULES_CONFIG)) {
+    const fallback = {
+        title: validatorName,
+        description: 'Rule validation failed.'
+    };
+    if (window.VALIDATORS && window.VALIDATORS[validatorName]) {
+        const ui = window.VALIDATORS[validatorName];
         return {
-            title: validatorName,
-            description: 'Rule validation failed.'
+            title: ui.title || validatorName,
+            description: ui.description || fallback.description,
+            remediation: ui.remediation || '',
+            severity: ui.severity || '',
+            docUrl: ui.doc_url || ''
         };
     }
+    if (!window.RULES_CONFIG || !Array.isArray(window.RULES_CONFIG)) {
+        return fallback;
+    }
     
     for (const rule of window.RULES_CONFIG) {
         if (rule.validators) {
             for (const validator of rule.validators) {
                 if (validator.name === validatorName) {
+                    const ui = validator.ui || {};
                     return {
-                        title: validator.ui_title || validatorName,
-                        description: validator.ui_description || 'Rule validation failed.'
+                        title: ui.title || validator.ui_title || validatorName,
+                        description: ui.description || validator.ui_description || fallback.description,
+                        remediation: ui.remediation || '',
+                        severity: ui.severity || rule.impact || '',
+                        docUrl: ui.doc_url || ''
                     };
                 }
             }
         }
     }
     
-    return {
-        title: validatorName,
-        description: 'Rule validation failed.'
+    return fallback;
+}
+
+// Report views: the job-centric sections, or all jobs' results grouped by rule
+function showView(view, keepHash) {
+    document.getElementById('jobs-view').hidden = view !== 'jobs';
+    document.getElementById('rules-view').hidden = view !== 'rules';
+    document.querySelectorAll('.view-tab').forEach(tab => {
+        const selected = tab.getAttribute('aria-controls') === view + '-view';
+        tab.classList.toggle('active', selected);
+        tab.setAttribute('aria-selected', selected ? 'true' : 'false');
+        tab.tabIndex = selected ? 0 : -1;
+    });
+    if (!keepHash) {
+        const active = document.querySelector('.job-section.active');
+        setHash(view === 'rules' ? 'rules' : (active ? active.id : ''));
+    }
+    window.scrollTo(0, 0);
+}
+
+// Deep links: the URL hash names the job section shown (its stable anchor), or "rules"
+function setHash(hash) {
+    if (window.location.hash !== '#' + hash) {
+        history.pushState(null, '', '#' + hash);
+    }
+}
+
+function routeFromHash() {
+    const hash = decodeURIComponent(window.location.hash.slice(1));
+    if (hash === 'rules') {
+        showView('rules', true);
+        return;
+    }
+    const section = hash ? document.getElementById(hash) : null;
+    if (section && section.classList.contains('job-section')) {
+        showJob(hash, true);
+    }
+}
+
+window.addEventListener('hashchange', routeFromHash);
+document.addEventListener('DOMContentLoaded', routeFromHash);
+
+function copyJobLink(jobId, button) {
+    const url = window.location.href.split('#')[0] + '#' + jobId;
+    const done = () => {
+        const label = button.innerHTML;
+        button.textContent = 'Link copied';
+        setTimeout(() => { button.innerHTML = label; }, 2000);
     };
+    if (navigator.clipboard) {
+        navigator.clipboard.writeText(url).then(done, () => window.prompt('Copy this link:', url));
+    } else {
+        window.prompt('Copy this link:', url);
+    }
 }
 
 // Job navigation
-function showJob(jobId) {
+function showJob(jobId, keepHash) {
+    showView('jobs', true);
     document.querySelectorAll('.job-section').forEach(section => {
         section.classList.remove('active');
     });
@@ -35,12 +111,61 @@ function showJob(jobId) {
     
     document.querySelectorAll('.job-item').forEach(item => {
         item.classList.remove('active');
+        item.removeAttribute('aria-current');
     });
-    document.querySelector('[data-job-id="' + jobId + '"]').classList.add('active');
+    const selected = document.querySelector('[data-job-id="' + jobId + '"]');
+    selected.classList.add('active');
+    selected.setAttribute('aria-current', 'true');
     
+    if (!keepHash) {
+        setHash(jobId);
+    }
     window.scrollTo(0, 0);
 }
 
+// Export of the report data embedded in the page (window.REPORT_DATA)
+function downloadFile(filename, content, type) {
+    const url = URL.createObjectURL(new Blob([content], { type: type }));
+    const link = document.createElement('a');
+    link.href = url;
+    link.download = filename;
+    document.body.appendChild(link);
+    link.click();
+    link.remove();
+    URL.revokeObjectURL(url);
+}
+
+function exportReportJSON() {
+    downloadFile('instrumentation-score-report.json', JSON.stringify(window.REPORT_DATA, null, 2), 'application/json');
+}
+
+// csvField quotes a value when needed, and keeps spreadsheets from evaluating text as a formula
+function csvField(value) {
+    let text = value === undefined || value === null ? '' : String(value);
+    if (typeof value !== 'number' && /^[=+\-@\t\r]/.test(text)) {
+        text = "'" + text;
+    }
+    if (/[",\r\n]/.test(text)) {
+        text = '"' + text.replace(/"/g, '""') + '"';
+    }
+    return text;
+}
+
+function exportJobsCSV() {
+    const header = ['job', 'service_version', 'score', 'total_metrics', 'failed_metrics', 'total_cardinality', 'estimated_cost'];
+    const rows = (window.REPORT_DATA.jobs || []).map(job => [
+        job.job_name,
+        job.service_version,
+        job.instrumentation_score,
+        job.total_metrics,
+        (job.failed_metrics || []).length,
+        job.total_cardinality,
+        job.estimated_cost
+    ]);
+    const csv = [header].concat(rows).map(row => row.map(csvField).join(',')).join('\r\n') + '\r\n';
+    downloadFile('instrumentation-score-jobs.csv', csv, 'text/csv');
+}
+
 // Search functionality
 document.addEventListener('DOMContentLoaded', () => {
     const searchBox = document.getElementById('searchBox');
@@ -49,12 +174,52 @@ document.addEventListener('DOMContentLoaded', () => {
             const searchTerm = e.target.value.toLowerCase();
             document.querySelectorAll('.job-item').forEach(item => {
                 const jobName = item.querySelector('.job-item-name').textContent.toLowerCase();
-                item.style.display = jobName.includes(searchTerm) ? 'block' : 'none';
+                item.closest('li').style.display = jobName.includes(searchTerm) ? '' : 'none';
             });
         });
     }
 });
 
+// Dialog focus handling: focus moves into an opened dialog, stays inside it while it is open,
+// and returns to the element that opened it when it closes
+let dialogTrigger = null;
+
+function openDialog(panel) {
+    dialogTrigger = document.activeElement;
+    const close = panel.querySelector('.metric-detail-close');
+    if (close) {
+        close.focus();
+    }
+}
+
+function closeDialog() {
+    if (dialogTrigger && typeof dialogTrigger.focus === 'function') {
+        dialogTrigger.focus();
+    }
+    dialogTrigger = null;
+}
+
+function openDialogPanel() {
+    return document.querySelector('.metric-detail-panel.open');
+}
+
+function trapFocus(e, panel) {
+    const focusable = Array.from(panel.querySelectorAll('button, [href], input, [tabindex]:not([tabindex="-1"])'))
+        .filter(el => el.offsetParent !== null);
+    if (focusable.length === 0) {
+        return;
+    }
+    const first = focusable[0];
+    const last = focusable[focusable.length - 1];
+    if (e.shiftKey && document.activeElement === first) {
+        e.preventDefault();
+        last.focus();
+    } else if (!e.shiftKey && document.activeElement === last) {
+        e.preventDefault();
+        first.focus();
+    }
+}
+
 // Metric detail modal
 function showMetricDetail(metricName, labels, cardinality, status, failedRulesStr, labelCardinalityJSON) {
     const panel = document.getElementById('metricDetailPanel');
@@ -63,13 +228,16 @@ function showMetricDetail(metricName, labels, cardinality, status, failedRulesSt
     
     document.getElementById('metricDetailName').textContent = metricName;
     
-    const statusHtml = status === 'pass' 
-        ? '<span class="metric-status-badge metric-status-pass">✓ Pass</span>'
-        : '<span class="metric-status-badge metric-status-fail">⚠ Failed</span>';
+    let statusHtml = '<span class="metric-status-badge metric-status-fail">⚠ Failed</span>';
+    if (status === 'pass') {
+        statusHtml = '<span class="metric-status-badge metric-status-pass">✓ Pass</span>';
+    } else if (status === 'acknowledged') {
+        statusHtml = '<span class="metric-status-badge metric-status-acknowledged">✓ Acknowledged</span>';
+    }
     document.getElementById('metricDetailStatus').innerHTML = statusHtml;
     
     const cardNum = parseInt(cardinality) || 0;
-    document.getElementById('metricDetailCardinality').textContent = cardNum.toLocaleString();
+    document.getElementById('metricDetailCardinality').textContent = cardNum.toLocaleString(reportLocale);
     
     const labelsArray = labels ? labels.split(',').map(l => l.trim()).filter(l => l) : [];
     document.getElementById('metricDetailLabelCount').textContent = labelsArray.length;
@@ -93,9 +261,9 @@ function showMetricDetail(metricName, labels, cardinality, status, failedRulesSt
             
             // Use actual cardinality if available, otherwise show estimate
             if (labelCardinality && labelCardinality[label] !== undefined) {
-                html += '<span class="metric-detail-info-value" style="color: #4caf50; font-size: 11px;">' + labelCardinality[label].toLocaleString() + '</span>';
+                html += '<span class="metric-detail-info-value" style="color: #4caf50; font-size: 11px;">' + labelCardinality[label].toLocaleString(reportLocale) + '</span>';
             } else {
-                html += '<span class="metric-detail-info-value" style="color: #888; font-size: 11px;">~' + Math.ceil(cardNum / labelsArray.length).toLocaleString() + ' est.</span>';
+                html += '<span class="metric-detail-info-value" style="color: #a3a3a3; font-size: 11px;">~' + Math.ceil(cardNum / labelsArray.length).toLocaleString(reportLocale) + ' est.</span>';
             }
             
             html += '</div>';
@@ -105,15 +273,25 @@ function showMetricDetail(metricName, labels, cardinality, status, failedRulesSt
         // Start collapsed by default
         document.getElementById('metricDetailLabels').style.display = 'none';
         document.getElementById('labelToggleIcon').textContent = '▶';
+        document.getElementById('labelToggle').setAttribute('aria-expanded', 'false');
     } else {
-        labelsContainer.innerHTML = '<div style="color: #888; font-size: 12px; padding: 12px; text-align: center;">No labels</div>';
+        labelsContainer.innerHTML = '<div style="color: #a3a3a3; font-size: 12px; padding: 12px; text-align: center;">No labels</div>';
     }
     
     if (status !== 'pass' && failedRules.length > 0) {
         document.getElementById('metricIssuesSection').style.display = 'block';
         const issuesHtml = failedRules.map(rule => {
             const validatorInfo = getValidatorInfo(rule);
-            const issueText = '<strong>' + validatorInfo.title + ':</strong> ' + validatorInfo.description;
+            let issueText = '<strong>' + escapeHtml(validatorInfo.title) + ':</strong> ' + escapeHtml(validatorInfo.description);
+            if (validatorInfo.severity) {
+                issueText += ' <span class="metric-issue-severity">' + escapeHtml(validatorInfo.severity) + '</span>';
+            }
+            if (validatorInfo.remediation) {
+                issueText += '<div class="metric-issue-remediation">' + escapeHtml(validatorInfo.remediation) + '</div>';
+            }
+            if (validatorInfo.docUrl) {
+                issueText += '<a class="metric-issue-doc" href="' + escapeHtml(validatorInfo.docUrl) + '" target="_blank" rel="noopener">Rule documentation</a>';
+            }
             return '<div style="margin-bottom: 10px; padding: 10px; background: rgba(244, 67, 54, 0.1); border-radius: 6px; font-size: 12px; color: #f44336;">' + issueText + '</div>';
         }).join('');
         document.getElementById('metricDetailIssues').innerHTML = issuesHtml;
@@ -129,6 +307,7 @@ function showMetricDetail(metricName, labels, cardinality, status, failedRulesSt
     overlay.classList.add('open');
     panel.classList.add('open');
     document.body.style.overflow = 'hidden';
+    openDialog(panel);
 }
 
 function closeMetricDetail() {
@@ -137,6 +316,7 @@ function closeMetricDetail() {
     panel.classList.remove('open');
     overlay.classList.remove('open');
     document.body.style.overflow = '';
+    closeDialog();
 }
 
 function generateRecommendations(metricName, labels, cardinality, failedRules) {
@@ -231,19 +411,47 @@ function sortTable(jobIndex, columnIndex) {
     
     const headers = table.querySelectorAll('th');
     headers.forEach((header, idx) => {
+        const indicator = header.querySelector('.sort-indicator');
         if (idx === columnIndex) {
-            header.textContent = header.textContent.split(' ')[0] + ' ' + (ascending ? '▲' : '▼');
+            header.setAttribute('aria-sort', ascending ? 'ascending' : 'descending');
+            indicator.textContent = ascending ? '▲' : '▼';
         } else {
-            const text = header.textContent.split(' ')[0];
-            header.textContent = text + ' ▼';
+            header.setAttribute('aria-sort', 'none');
+            indicator.textContent = '▼';
         }
     });
 }
 
+// Show only the metrics that newly failed or were fixed since the previous run
+function filterChangedMetrics(jobIndex, changedOnly) {
+    const rows = document.querySelectorAll('#metrics-table-' + jobIndex + ' tbody tr');
+    rows.forEach(row => {
+        const change = row.dataset.change;
+        row.style.display = !changedOnly || change === 'new' || change === 'fixed' ? '' : 'none';
+    });
+}
+
 // Keyboard support
 document.addEventListener('keydown', (e) => {
-    if (e.key === 'Escape' && document.getElementById('modalOverlay').classList.contains('open')) {
-        closeMetricDetail();
+    const panel = openDialogPanel();
+    if (e.key === 'Escape' && panel) {
+        if (panel.id === 'ruleDetailPanel') {
+            closeRuleDetail();
+        } else {
+            closeMetricDetail();
+        }
+    } else if (e.key === 'Tab' && panel) {
+        trapFocus(e, panel);
+    } else if ((e.key === 'ArrowLeft' || e.key === 'ArrowRight') && e.target.getAttribute && e.target.getAttribute('role') === 'tab') {
+        // Arrow keys move between the view tabs
+        const tabs = Array.from(document.querySelectorAll('.view-tab'));
+        const next = tabs[(tabs.indexOf(e.target) + (e.key === 'ArrowRight' ? 1 : tabs.length - 1)) % tabs.length];
+        next.focus();
+        next.click();
+    } else if ((e.key === 'Enter' || e.key === ' ') && e.target.getAttribute && e.target.getAttribute('role') === 'button') {
+        // Elements acting as buttons activate like native ones
+        e.preventDefault();
+        e.target.click();
     }
 });
 
@@ -251,13 +459,16 @@ document.addEventListener('keydown', (e) => {
 function toggleLabelBreakdown() {
     const labelsContainer = document.getElementById('metricDetailLabels');
     const icon = document.getElementById('labelToggleIcon');
+    const toggle = document.getElementById('labelToggle');
     
     if (labelsContainer.style.display === 'none') {
         labelsContainer.style.display = 'block';
         icon.textContent = '▼';
+        toggle.setAttribute('aria-expanded', 'true');
     } else {
         labelsContainer.style.display = 'none';
         icon.textContent = '▶';
+        toggle.setAttribute('aria-expanded', 'false');
     }
 }
 
@@ -368,7 +579,7 @@ function showRuleDetail(jobName, ruleID, passedMetrics, totalMetrics, passedCard
                        impact === 'Normal' ? '#2196f3' : '#9e9e9e';
     
     // Update modal
-    document.getElementById('ruleDetailTitle').innerHTML = `${ruleID} <span style="color: #888; font-weight: normal; font-size: 16px;">- ${jobName}</span>`;
+    document.getElementById('ruleDetailTitle').innerHTML = `${ruleID} <span style="color: #a3a3a3; font-weight: normal; font-size: 16px;">- ${jobName}</span>`;
     
     // Rule description
     document.getElementById('ruleDescription').textContent = getRuleDescription(ruleID);
@@ -377,36 +588,36 @@ function showRuleDetail(jobName, ruleID, passedMetrics, totalMetrics, passedCard
     const contributionColor = percentageOfFinalScore > 50 ? '#4caf50' : percentageOfFinalScore > 25 ? '#8bc34a' : percentageOfFinalScore > 10 ? '#ff9800' : '#f44336';
     document.getElementById('ruleContribution').innerHTML = `
         <div style="font-size: 24px; font-weight: bold; color: ${contributionColor};">${percentageOfFinalScore.toFixed(1)}%</div>
-        <div style="font-size: 11px; color: #888; margin-top: 4px;">of final score</div>
+        <div style="font-size: 11px; color: #a3a3a3; margin-top: 4px;">of final score</div>
     `;
     
     // Points Earned and Points Possible
-    document.getElementById('rulePointsEarned').textContent = pointsEarned.toLocaleString();
-    document.getElementById('rulePointsPossible').textContent = pointsPossible.toLocaleString();
+    document.getElementById('rulePointsEarned').textContent = pointsEarned.toLocaleString(reportLocale);
+    document.getElementById('rulePointsPossible').textContent = pointsPossible.toLocaleString(reportLocale);
     
     // Metrics Passed
     document.getElementById('ruleMetricsPassed').innerHTML = `
         <div style="font-size: 24px; font-weight: bold; color: ${passedMetrics === totalMetrics ? '#4caf50' : '#ff9800'};">${passedMetrics}/${totalMetrics}</div>
-        <div style="font-size: 11px; color: #888; margin-top: 4px;">metrics</div>
+        <div style="font-size: 11px; color: #a3a3a3; margin-top: 4px;">metrics</div>
     `;
     
     // Pass Rate
     document.getElementById('rulePassRate').innerHTML = `
         <div style="font-size: 24px; font-weight: bold; color: ${parseFloat(passRatePercent) >= 90 ? '#4caf50' : parseFloat(passRatePercent) >= 75 ? '#8bc34a' : parseFloat(passRatePercent) >= 50 ? '#ff9800' : '#f44336'};">${passRatePercent}%</div>
-        <div style="font-size: 11px; color: #888; margin-top: 4px;">of metrics</div>
+        <div style="font-size: 11px; color: #a3a3a3; margin-top: 4px;">of metrics</div>
     `;
     
     // Impact Level
     document.getElementById('ruleImpactLevel').innerHTML = `
         <div style="font-size: 20px; font-weight: 600; color: ${impactColor};">${impact}</div>
-        <div style="font-size: 11px; color: #888; margin-top: 4px;">weight: ${weight}</div>
+        <div style="font-size: 11px; color: #a3a3a3; margin-top: 4px;">weight: ${weight}</div>
     `;
     
     // Cardinality section - only show for rules that use cardinality-weighted scoring
     if (usesCardinalityScoring) {
         document.getElementById('ruleCardinalitySection').style.display = 'block';
-        document.getElementById('rulePassedCardinality').textContent = passedCardinality.toLocaleString();
-        document.getElementById('ruleTotalCardinality').textContent = totalCardinality.toLocaleString();
+        document.getElementById('rulePassedCardinality').textContent = passedCardinality.toLocaleString(reportLocale);
+        document.getElementById('ruleTotalCardinality').textContent = totalCardinality.toLocaleString(reportLocale);
     } else {
         document.getElementById('ruleCardinalitySection').style.display = 'none';
     }
@@ -426,17 +637,17 @@ function showRuleDetail(jobName, ruleID, passedMetrics, totalMetrics, passedCard
                     <div style="margin-bottom: 10px;">
                         <strong style="color: #4a9eff;">Step 1: Calculate Points</strong><br>
                         Points Earned = PassedCardinality × Weight<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= ${passedCardinality.toLocaleString()} × ${weight}<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #4caf50;">${pointsEarned.toLocaleString()}</strong><br>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= ${passedCardinality.toLocaleString(reportLocale)} × ${weight}<br>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #4caf50;">${pointsEarned.toLocaleString(reportLocale)}</strong><br>
                         <br>
                         Points Possible = TotalCardinality × Weight<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= ${totalCardinality.toLocaleString()} × ${weight}<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #888;">${pointsPossible.toLocaleString()}</strong>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= ${totalCardinality.toLocaleString(reportLocale)} × ${weight}<br>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #a3a3a3;">${pointsPossible.toLocaleString(reportLocale)}</strong>
                     </div>
                     <div style="margin-bottom: 10px;">
                         <strong style="color: #4a9eff;">Step 2: Calculate Contribution</strong><br>
                         Absolute Contribution = (Points Earned / Total Denominator) × 100<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= (${pointsEarned.toLocaleString()} / ${totalDenominator.toLocaleString()}) × 100<br>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= (${pointsEarned.toLocaleString(reportLocale)} / ${totalDenominator.toLocaleString(reportLocale)}) × 100<br>
                         &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #4caf50;">${absoluteContribution.toFixed(3)}%</strong><br>
                         <br>
                         % of Final Score (${finalScore.toFixed(2)}%) = (${absoluteContribution.toFixed(3)}% / ${finalScore.toFixed(2)}%) × 100<br>
@@ -445,17 +656,17 @@ function showRuleDetail(jobName, ruleID, passedMetrics, totalMetrics, passedCard
                     <div>
                         <strong style="color: #4a9eff;">Step 3: Calculate Lost Score</strong><br>
                         Lost Score = (Points Possible - Points Earned) / Total Denominator × 100<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= (${pointsPossible.toLocaleString()} - ${pointsEarned.toLocaleString()}) / ${totalDenominator.toLocaleString()} × 100<br>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= (${pointsPossible.toLocaleString(reportLocale)} - ${pointsEarned.toLocaleString(reportLocale)}) / ${totalDenominator.toLocaleString(reportLocale)} × 100<br>
                         &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #f44336;">${lostScore.toFixed(3)}%</strong>
                     </div>
                 </div>
                 <div style="margin: 15px 0;">
-                    This rule evaluates <strong style="color: #fff;">${totalCardinality.toLocaleString()} time series</strong> across ${totalMetrics} metrics.<br>
-                    <strong style="color: #4caf50;">${passedCardinality.toLocaleString()} series passed</strong> (${cardinalityPercent}%), 
-                    <strong style="color: #f44336;">${failedSeries.toLocaleString()} series failed</strong>.
+                    This rule evaluates <strong style="color: #fff;">${totalCardinality.toLocaleString(reportLocale)} time series</strong> across ${totalMetrics} metrics.<br>
+                    <strong style="color: #4caf50;">${passedCardinality.toLocaleString(reportLocale)} series passed</strong> (${cardinalityPercent}%), 
+                    <strong style="color: #f44336;">${failedSeries.toLocaleString(reportLocale)} series failed</strong>.
                 </div>
                 <div style="padding: 10px; background: rgba(255,152,0,0.1); border-radius: 6px; font-size: 12px; color: #ff9800;">
-                    ⚡ Cardinality-weighted: Each series counts individually toward the score
+                    <span aria-hidden="true">⚡</span> Cardinality-weighted: Each series counts individually toward the score
                 </div>
             </div>
         `;
@@ -469,16 +680,16 @@ function showRuleDetail(jobName, ruleID, passedMetrics, totalMetrics, passedCard
                         <strong style="color: #4a9eff;">Step 1: Calculate Points</strong><br>
                         Points Earned = PassedMetrics × Weight<br>
                         &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= ${passedMetrics} × ${weight}<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #4caf50;">${pointsEarned.toLocaleString()}</strong><br>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #4caf50;">${pointsEarned.toLocaleString(reportLocale)}</strong><br>
                         <br>
                         Points Possible = TotalMetrics × Weight<br>
                         &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= ${totalMetrics} × ${weight}<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #888;">${pointsPossible.toLocaleString()}</strong>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #a3a3a3;">${pointsPossible.toLocaleString(reportLocale)}</strong>
                     </div>
                     <div style="margin-bottom: 10px;">
                         <strong style="color: #4a9eff;">Step 2: Calculate Contribution</strong><br>
                         Absolute Contribution = (Points Earned / Total Denominator) × 100<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= (${pointsEarned.toLocaleString()} / ${totalDenominator.toLocaleString()}) × 100<br>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= (${pointsEarned.toLocaleString(reportLocale)} / ${totalDenominator.toLocaleString(reportLocale)}) × 100<br>
                         &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #4caf50;">${absoluteContribution.toFixed(3)}%</strong><br>
                         <br>
                         % of Final Score (${finalScore.toFixed(2)}%) = (${absoluteContribution.toFixed(3)}% / ${finalScore.toFixed(2)}%) × 100<br>
@@ -487,7 +698,7 @@ function showRuleDetail(jobName, ruleID, passedMetrics, totalMetrics, passedCard
                     <div>
                         <strong style="color: #4a9eff;">Step 3: Calculate Lost Score</strong><br>
                         Lost Score = (Points Possible - Points Earned) / Total Denominator × 100<br>
-                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= (${pointsPossible.toLocaleString()} - ${pointsEarned.toLocaleString()}) / ${totalDenominator.toLocaleString()} × 100<br>
+                        &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= (${pointsPossible.toLocaleString(reportLocale)} - ${pointsEarned.toLocaleString(reportLocale)}) / ${totalDenominator.toLocaleString(reportLocale)} × 100<br>
                         &nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;&nbsp;= <strong style="color: #f44336;">${lostScore.toFixed(3)}%</strong>
                     </div>
                 </div>
@@ -505,6 +716,7 @@ function showRuleDetail(jobName, ruleID, passedMetrics, totalMetrics, passedCard
     document.getElementById('ruleDetailModal').classList.add('open');
     document.getElementById('ruleDetailPanel').classList.add('open');
     document.body.style.overflow = 'hidden';
+    openDialog(document.getElementById('ruleDetailPanel'));
 }
 
 // Close rule detail modal
@@ -512,5 +724,6 @@ function closeRuleDetail() {
     document.getElementById('ruleDetailModal').classList.remove('open');
     document.getElementById('ruleDetailPanel').classList.remove('open');
     document.body.style.overflow = '';
+    closeDialog();
 }
 